@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/crawlertest"
+)
+
+// 用假的爬取替换 crawlInPool 跑完整的批量流程：单域名并发上限生效，失败和疑似登录页如实写入 manifest.json
+func TestBatchWithFakeCrawler(t *testing.T) {
+	quietLog(t)
+	outputDir := t.TempDir()
+	config, opts := buildCrawlFlags(t,
+		"-har", crawlertest.WriteHAR(t, nil), // 回放模式不启动浏览器池
+		"-concurrency", "4",
+		"-per-origin-concurrency", "1",
+		"-output", outputDir,
+	)
+
+	var (
+		mu        sync.Mutex
+		active    = make(map[string]int)
+		maxActive = make(map[string]int)
+	)
+	saved := crawlInPool
+	t.Cleanup(func() { crawlInPool = saved })
+	crawlInPool = func(_ context.Context, targetURL string, _ *crawler.Config, _ *outputOptions, _ string) (int, *crawler.CrawlResult, error) {
+		key := crawler.OriginKey(targetURL)
+		mu.Lock()
+		active[key]++
+		maxActive[key] = max(maxActive[key], active[key])
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		active[key]--
+		mu.Unlock()
+
+		switch targetURL {
+		case "https://b.example.org/broken":
+			return 3, nil, errors.New("connection refused")
+		case "https://c.example.net/login":
+			return 1, &crawler.CrawlResult{LikelyLoginPage: true, LoginSignal: "password field"}, nil
+		}
+		return 1, &crawler.CrawlResult{}, nil
+	}
+
+	urls := []string{
+		"https://a.example.com/1", "https://www.example.com/2", "https://example.com/3",
+		"https://b.example.org/ok", "https://b.example.org/broken",
+		"https://c.example.net/login",
+	}
+	if code := crawlMultipleURLs(urls, config, opts, outputDir); code != 0 {
+		t.Fatalf("批量爬取返回 %d", code)
+	}
+
+	for key, n := range maxActive {
+		if n > 1 {
+			t.Errorf("%s 的最大并发 %d，超过 -per-origin-concurrency 1", key, n)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest []ManifestEntry
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest) != len(urls) {
+		t.Fatalf("manifest.json 有 %d 条，应为 %d", len(manifest), len(urls))
+	}
+	for i, e := range manifest {
+		if e.URL != urls[i] {
+			t.Errorf("manifest[%d].url = %s，应按输入顺序为 %s", i, e.URL, urls[i])
+		}
+		switch e.URL {
+		case "https://b.example.org/broken":
+			if e.Success || e.Error != "connection refused" || e.Attempts != 3 {
+				t.Errorf("失败的 URL 记录为 %+v", e)
+			}
+		case "https://c.example.net/login":
+			if !e.Success || !e.LikelyLoginPage || e.LoginSignal != "password field" {
+				t.Errorf("疑似登录页记录为 %+v", e)
+			}
+		default:
+			if !e.Success || e.Error != "" {
+				t.Errorf("成功的 URL 记录为 %+v", e)
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"spider/internal/crawler"
+)
+
+// runCheck check 子命令（旧名 doctor）：依次检查浏览器查找、代理、浏览器启动和输出目录，
+// 每项失败都给出处理建议，全部检查完再以非零状态退出，提前发现环境问题
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.Usage = showCheckUsage
+	chromePath := fs.String("chrome-path", "", "Chrome/Chromium 可执行文件路径（默认自动搜索）")
+	proxy := fs.String("proxy", "", "要检查的代理地址，如 \"http://127.0.0.1:8080\"")
+	headless := fs.Bool("headless", true, "无头模式（默认true）")
+	timeout := fs.Int("timeout", 30, "检查超时时间（秒）")
+	outputDir := fs.String("output", "./output", "要检查是否可写的输出目录")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	config := crawler.DefaultConfig()
+	config.ChromePath = *chromePath
+	config.Proxy = *proxy
+	config.Headless = *headless
+	config.Timeout = time.Duration(*timeout) * time.Second
+	if err := config.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 配置无效:\n%v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+
+	failed := false
+
+	// 先单独查找浏览器：找不到时列出查找过的位置，不再尝试启动
+	path, err := crawler.FindChrome(config.ChromePath)
+	if err != nil {
+		fmt.Printf("[失败] 浏览器: 未找到 Chrome / Chromium / Edge\n")
+		fmt.Println("       已查找:")
+		for _, loc := range crawler.ChromeLocations() {
+			fmt.Printf("         %s\n", loc)
+		}
+		fmt.Print(chromeInstallHint)
+		failed = true
+	} else {
+		fmt.Printf("[正常] 浏览器路径: %s\n", path)
+	}
+
+	if config.Proxy != "" {
+		if err := crawler.CheckProxy(ctx, config.Proxy, 5*time.Second); err != nil {
+			fmt.Printf("[失败] 代理: %v\n", err)
+			fmt.Println("       请确认代理已启动、地址和端口正确，且本机可以访问")
+			failed = true
+		} else {
+			fmt.Printf("[正常] 代理: %s 可连接\n", config.Proxy)
+		}
+	}
+
+	if path != "" {
+		if info, err := crawler.CheckBrowser(ctx, config); err != nil {
+			fmt.Printf("[失败] 浏览器启动: %v\n", err)
+			if errors.Is(err, context.DeadlineExceeded) {
+				fmt.Printf("       %d 秒内未完成启动，可用 -timeout 加大超时；容器中运行时请加 --shm-size=2g\n", *timeout)
+			} else {
+				fmt.Println("       浏览器存在但无法启动：确认版本不过旧、依赖库齐全（Linux 上可先运行 chromium --headless 查看报错），")
+				fmt.Println("       或用 -chrome-path 指定其他浏览器")
+			}
+			failed = true
+		} else {
+			fmt.Printf("[正常] 浏览器启动: %s（协议 %s，启动耗时 %.1fs）\n", info.Product, info.ProtocolVersion, info.StartupTime.Seconds())
+			fmt.Printf("       路径: %s\n", info.Path)
+			fmt.Printf("       User-Agent: %s\n", info.UserAgent)
+		}
+	}
+
+	if err := checkOutputDir(*outputDir); err != nil {
+		fmt.Printf("[失败] 输出目录: %v\n", err)
+		fmt.Println("       请确认目录所在磁盘可写、当前用户有写权限，或用 -output 指定其他目录")
+		failed = true
+	} else {
+		fmt.Printf("[正常] 输出目录: %s 可写\n", *outputDir)
+	}
+
+	if failed {
+		return 1
+	}
+	fmt.Println("环境检查通过")
+	return 0
+}
+
+// checkOutputDir 确认输出目录可以创建，并能在其中写入和删除文件
+func checkOutputDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("无法创建 %s: %w", dir, err)
+	}
+	f, err := os.CreateTemp(dir, ".spider-check-*")
+	if err != nil {
+		return fmt.Errorf("无法在 %s 中写入文件: %w", dir, err)
+	}
+	name := f.Name()
+	f.Close()
+	if err := os.Remove(name); err != nil {
+		return fmt.Errorf("无法删除测试文件 %s: %w", filepath.Base(name), err)
+	}
+	return nil
+}
+
+// isChromeError 判断错误是否为浏览器找不到或启动失败（需要提示安装或运行 spider check）
+func isChromeError(err error) bool {
+	return errors.Is(err, crawler.ErrChromeNotFound) || errors.Is(err, crawler.ErrChromeStart)
+}
+
+func showCheckUsage() {
+	fmt.Fprintf(os.Stderr, `用法:
+  spider check [选项]
+  spider doctor [选项]   （旧名称，与 check 相同）
+
+爬取前的环境自检，依次检查：
+  1. 浏览器查找：-chrome-path 或按平台默认位置搜索 Chrome / Chromium / Edge，
+     找不到时列出查找过的全部位置
+  2. 代理：指定 -proxy 时检查能否连接
+  3. 浏览器启动：用与 crawl 相同的启动参数启动无头浏览器并打开 about:blank，
+     显示版本、可执行文件路径和启动耗时
+  4. 输出目录：确认 -output 目录可创建、可写
+任一检查失败时给出处理建议，全部检查完后以非零状态退出。
+
+搜索位置（当前平台）:
+  %s
+
+选项:
+  -chrome-path string  Chrome/Chromium 可执行文件路径（默认自动搜索）
+  -proxy string        要检查的代理地址，如 "http://127.0.0.1:8080"
+  -headless bool       无头模式 (默认 true)
+  -timeout int         检查超时时间，单位秒 (默认 30)
+  -output string       要检查是否可写的输出目录 (默认 "./output")
+
+示例:
+  spider check
+  spider check -chrome-path /usr/bin/chromium -proxy socks5://127.0.0.1:1080 -output /data/crawl
+
+`, strings.Join(crawler.ChromeLocations(), "\n  "))
+}
@@ -0,0 +1,2251 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/frontier"
+	"spider/internal/logging"
+	"spider/internal/notify"
+	"spider/internal/pipeline"
+	"spider/internal/sourcemap"
+	"spider/internal/storage"
+)
+
+// outputOptions 输出阶段的选项（提取、存储、导出），与爬虫配置分开传递
+type outputOptions struct {
+	exportFormat  string        // -export: burp | zap | ndjson | wpr
+	flushInterval time.Duration // -flush-interval: 爬取中按时间分批落盘
+	flushBytes    int64         // -flush-bytes: 爬取中按累计字节分批落盘
+
+	sourceMapWorkers int     // -sourcemap-workers: 并发提取 source map 的 worker 数
+	sourceFetchers   int     // -source-fetch-workers: 缺少 sourcesContent 时并发下载源文件的 worker 数
+	sourceMapRate    float64 // -sourcemap-rate: source map 下载速率上限（次/秒）
+	sourceTree       bool    // -source-tree: 按 sources 原始路径把源文件另存到 source-tree/
+	mapMeta          bool    // -map-meta: 为每个 source map 生成 .map.meta.json 概要
+	htmlInlineMaps   bool    // -html-inline-maps: 从 HTML 页面的内联 <script> / <style> 中查找 source map
+	probeMaps        bool    // -probe-maps: 没有 sourceMappingURL 时探测 <文件名>.map
+
+	maxSourceBytes    int64 // -max-source-size: 单个提取源文件的大小上限
+	maxMapSourceBytes int64 // -max-map-sources-size: 单个 map 提取源文件的合计大小上限
+	maxSources        int   // -max-map-sources: 单个 map 的源文件数上限
+
+	mapCache *sourcemap.MapCache // 同一进程内各 URL 共享的 source map 下载缓存（有界）
+
+	mainOutput         string // -main-output: 主文档另存路径（仅单 URL 模式）
+	mainOutputRendered bool   // -main-output-rendered: 另存渲染后的 DOM 而非原始响应
+
+	exportSession string                // -export-session: 会话导出路径（仅单 URL 模式）
+	session       *crawler.SessionState // -import-session: 爬取前恢复的会话
+
+	gitRepo   string // -export-git: 以提交形式写入的 Git 裸仓库目录
+	gitRemote string // -git-remote: Git 导出后推送的远程仓库
+
+	securityReport bool // -security-report: 根据主文档响应头生成 security-headers.txt
+	groupVariants  bool // -group-query-variants: 报告中合并仅查询字符串不同的资源
+	hintsReport    bool // -hints-report: 报告中对照声明的资源提示与实际加载
+	reportPreview  int  // -report-preview: 报告中每个文本资源附带的响应体预览字节数
+
+	unloadedRefs      bool // -unloaded-refs: 报告中列出 DOM / CSS 中引用但未加载的资源
+	fetchUnreferenced bool // -fetch-unreferenced: 直接下载这些资源并加入抓取结果
+
+	chunkSize int64 // -export-chunks: 爬取结束后将输出目录打包为 zstd 分块，每块的未压缩大小上限
+
+	notifier *notify.Notifier // -notify-webhook / -notify-cmd: 结束后发送完成通知
+	results  *runResults      // 各 URL 的结果，仅开启通知时记录
+
+	// collect 非 nil 时（递归模式）每个页面的资源交给它汇总，不单独生成报告和索引
+	collect func(resources map[string]*crawler.Resource)
+}
+
+// ManifestEntry 记录每个 URL 的爬取结果
+type ManifestEntry struct {
+	URL       string `json:"url"`
+	OutputDir string `json:"output_dir"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	Attempts  int    `json:"attempts"`
+	UserAgent string `json:"user_agent,omitempty"` // 本 URL 使用的 User-Agent（-ua / -ua-rotation）
+
+	LikelyLoginPage bool   `json:"likely_login_page,omitempty"` // 疑似抓到登录页（会话过期）
+	LoginSignal     string `json:"login_signal,omitempty"`
+
+	FinalURL      string `json:"final_url,omitempty"`      // 最终到达的页面 URL（停止时为跳转目标），仅与输入不同时写出
+	NavigatedAway string `json:"navigated_away,omitempty"` // 跨源跳转目标，抓取在此停止（未开启 -follow-client-redirects）
+
+	Skipped []crawler.SkippedArtifact `json:"skipped,omitempty"` // 因 DOM 过大跳过的可选产物
+
+	NotAttempted bool `json:"not_attempted,omitempty"` // 达到 -max-duration 时尚未开始，已跳过
+}
+
+// errBatchDeadline 批量爬取达到 -max-duration，进行中的爬取被取消
+var errBatchDeadline = errors.New("达到 -max-duration 时间上限，已取消")
+
+// crawlFlags crawl / batch 子命令共用的参数
+type crawlFlags struct {
+	targetURL   string
+	urlFile     string
+	outputDir   string
+	timeout     int
+	idleTimeout int
+	cookie      string
+	headers     headerFlags
+	proxy       string
+	proxyPac    string
+	userAgent   string
+	uaRotation  headerFlags
+	realUA      bool
+	concurrency int
+	session     bool
+	cookieCross bool
+	followNav   bool
+	headless    bool
+	maxRetry    int
+	chromePath  string
+	showHelp    bool
+
+	timestampDir    bool
+	timestampFormat string
+
+	collapsePolling  bool
+	cacheBusters     string
+	maxSourceMapSize int64
+	delay            float64
+	ignoreCrawlDelay bool
+	maxCrawlDelay    float64
+	exportFormat     string
+	captureWorkers   bool
+	requireSelector  string
+	loginPatterns    string
+	overrides        headerFlags
+	caCerts          headerFlags
+	pinCerts         headerFlags
+	noFallback       bool
+	flushInterval    int
+	flushBytes       int64
+	deterministic    bool
+	viewport         string
+	dumpEvents       bool
+	smWorkers        int
+	sourceFetchers   int
+	smRate           float64
+	labels           headerFlags
+	retryOnStatus    headerFlags
+	clickMarkers     headerFlags
+	onlyAfter        string
+	retryAttempts    int
+	retryDelay       time.Duration
+	mainOutput       string
+	mainRendered     bool
+	noCache          bool
+	enableAudio      bool
+	perOrigin        int
+	harFile          string
+	maxDuration      time.Duration
+	depth            int
+	maxPages         int
+	recursionWorkers int
+	strategy         string
+	resume           bool
+	bodyTimeout      int
+	gitRepo          string
+	gitRemote        string
+	cspEndpoints     bool
+	mapHosts         string
+	mapsSameOrigin   bool
+	suppressEmpty    bool
+	skipStatus       string
+	sniffMime        bool
+	timingAPI        bool
+	slowThreshold    time.Duration
+	scrollShots      bool
+	loadHints        bool
+	inlineScripts    bool
+	inlineStyles     bool
+	navigatorProps   bool
+	keepOpen         bool
+	exportSession    string
+	importSession    string
+	exportChunks     string
+	dataURIs         string
+	maxDOMSize       string
+	rewriteDataURIs  bool
+	startupDelay     time.Duration
+	settleDelay      time.Duration
+	scrollDuration   time.Duration
+	budget           time.Duration
+	waitUntil        string
+	blockDomains     string
+	pathPrefix       string
+	forwardHeaders   string
+	noTracking       bool
+	trackingAllow    string
+	ignoreList       string
+	securityReport   bool
+	privacyReport    bool
+	coverage         bool
+	browserIssues    bool
+	hintsReport      bool
+	unloadedRefs     bool
+	fetchUnref       bool
+	dismissDialogs   bool
+	dialogAccept     bool
+	sourceTree       bool
+	mapMeta          bool
+	htmlInlineMaps   bool
+	probeMaps        bool
+	maxSourceSize    string
+	maxMapSources    string
+	maxSources       int
+	onlyNewSources   bool
+	prettyJSON       bool
+	stripMapURL      bool
+	groupVariants    bool
+	reportPreview    int
+	notifyWebhook    string
+	notifyCmd        string
+	notifyOn         string
+
+	flags *flag.FlagSet // 用于判断单项时间参数是否显式指定
+}
+
+// newCrawlFlagSet 创建 crawl / batch 子命令的 FlagSet，参数名与旧版单命令完全一致
+func newCrawlFlagSet(name string, usage func()) (*flag.FlagSet, *crawlFlags) {
+	f := &crawlFlags{}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = usage
+	f.flags = fs
+
+	fs.StringVar(&f.targetURL, "url", "", "目标网页URL（与 -file 二选一）")
+	fs.StringVar(&f.urlFile, "file", "", "URL文件路径，每行一个URL（与 -url 二选一）")
+	fs.StringVar(&f.outputDir, "output", "./output", "输出目录")
+	fs.BoolVar(&f.timestampDir, "timestamp-dir", false, "每次运行输出到 <输出目录>/<时间戳>/，并将 <输出目录>/latest 符号链接指向最近一次运行")
+	fs.StringVar(&f.timestampFormat, "timestamp-format", defaultTimestampLayout, "-timestamp-dir 的目录名格式（Go 时间格式，UTC）")
+	fs.IntVar(&f.timeout, "timeout", 30, "爬取超时时间（秒）")
+	fs.IntVar(&f.idleTimeout, "idle-timeout", 10, "网络空闲等待上限（秒）")
+	fs.StringVar(&f.cookie, "cookie", "", "Cookie字符串，格式: \"key1=value1; key2=value2\"")
+	fs.BoolVar(&f.cookieCross, "cookie-cross-site", false, "-cookie 注入的 Cookie 设为 SameSite=None; Secure，使跨站 iframe / XHR 也带上（需 HTTPS）")
+	fs.BoolVar(&f.followNav, "follow-client-redirects", false, "页面加载后跨源跳转（location.replace 等）时继续抓取跳转后的页面（默认在跳转处停止）")
+	fs.Var(&f.headers, "header", "自定义Header，格式: \"Key:Value\"（可多次使用）")
+	fs.StringVar(&f.proxy, "proxy", "", "HTTP/SOCKS5代理地址，如 \"http://127.0.0.1:8080\"")
+	fs.StringVar(&f.proxyPac, "proxy-pac", "", "PAC 文件地址，如 \"http://wpad/wpad.dat\"，由浏览器按目标 URL 选择代理（不能与 -proxy 同时使用）")
+	fs.StringVar(&f.userAgent, "ua", "", "自定义 User-Agent")
+	fs.BoolVar(&f.realUA, "real-ua", true, "未指定 -ua 时使用与 Chrome 版本和当前系统一致的普通桌面版 UA 及 Client Hints（-real-ua=false 保留默认的 HeadlessChrome UA）")
+	fs.Var(&f.uaRotation, "ua-rotation", "批量模式下按 URL 顺序轮流使用的 User-Agent（可多次使用，覆盖 -ua；单 URL 模式使用第一个）")
+	fs.StringVar(&f.chromePath, "chrome-path", "", "Chrome/Chromium 可执行文件路径（默认自动搜索）")
+	fs.DurationVar(&f.startupDelay, "startup-delay", 0, "浏览器启动后额外等待的时间，如 2s（不计入 -timeout）")
+	fs.DurationVar(&f.settleDelay, "settle-delay", 0, "页面加载完成后、滚动前的固定等待，如 3s")
+	fs.DurationVar(&f.scrollDuration, "scroll-duration", 0, "滚动触发懒加载的总等待时间，如 2s（默认 3.9s）")
+	fs.DurationVar(&f.budget, "budget", 0, "单页总时间预算，如 20s：按比例分配到总超时、滚动前等待、滚动和空闲等待，显式指定的单项参数优先")
+	fs.StringVar(&f.waitUntil, "wait-until", crawler.WaitLoad, "导航后等待的页面事件: commit、domcontentloaded、load、networkidle0、networkidle2")
+	fs.IntVar(&f.concurrency, "concurrency", 1, "并发数（批量爬取时）")
+	fs.BoolVar(&f.session, "session", false, "批量模式下在同一浏览器中按文件顺序逐个爬取，登录等页面设置的 Cookie 延续到后续 URL")
+	fs.IntVar(&f.perOrigin, "per-origin-concurrency", 2, "批量模式下同一域名的最大并发数（0 表示不限制）")
+	fs.BoolVar(&f.headless, "headless", true, "无头模式（默认true）")
+	fs.IntVar(&f.maxRetry, "retry", 2, "失败重试次数（默认 2，指数退避）")
+	fs.Var(&f.retryOnStatus, "retry-on-status", "主文档返回该状态码时在同一 Tab 中退避后重新导航，如 503（可多次使用或逗号分隔）")
+	fs.IntVar(&f.retryAttempts, "retry-status-attempts", 3, "-retry-on-status 的重新导航次数")
+	fs.DurationVar(&f.retryDelay, "retry-status-delay", 3*time.Second, "-retry-on-status 每次重新导航前的等待（固定间隔）")
+	fs.BoolVar(&f.collapsePolling, "collapse-polling", false, "折叠仅缓存破坏参数不同的轮询响应，只保留首个")
+	fs.StringVar(&f.cacheBusters, "cache-busters", "", "缓存破坏参数列表，逗号分隔（默认 ts,_,cb,t,timestamp,nocache）")
+	fs.Int64Var(&f.maxSourceMapSize, "max-source-map-size", 0, "Source Map 大小上限（字节），超出则跳过下载（0 表示不限制）")
+	fs.StringVar(&f.mapHosts, "map-hosts", "", "允许下载 source map 的主机，逗号分隔，支持 *.example.com")
+	fs.BoolVar(&f.mapsSameOrigin, "maps-same-origin", false, "绝对地址的 source map 只从与引用资源同源的地址下载")
+	fs.Float64Var(&f.delay, "delay", 0, "批量模式下同一主机相邻两次爬取的间隔（秒）")
+	fs.BoolVar(&f.ignoreCrawlDelay, "ignore-crawl-delay", false, "忽略 robots.txt 的 Crawl-delay")
+	fs.Float64Var(&f.maxCrawlDelay, "max-crawl-delay", 0, "Crawl-delay 上限（秒），0 表示不封顶")
+	fs.DurationVar(&f.maxDuration, "max-duration", 0, "批量爬取整体时间上限，如 30m、2h（0 表示不限）")
+	fs.StringVar(&f.exportFormat, "export", "", "额外导出格式: burp（Burp XML）、zap（ZAP 可导入的 HAR）、ndjson（每行一个资源的 JSON）或 wpr（Web Page Replay 归档）")
+	fs.StringVar(&f.gitRepo, "export-git", "", "将爬取结果作为一次提交写入该目录的 Git 裸仓库")
+	fs.StringVar(&f.dataURIs, "extract-data-uris", "", "保存 CSS / HTML 时把超过该大小的 data URI 抽到 _data/ 下，如 64KB（默认不抽取）")
+	fs.BoolVar(&f.rewriteDataURIs, "rewrite-data-uris", false, "抽出 data URI 后把引用改写为指向 _data/ 的相对路径，而非占位注释")
+	fs.StringVar(&f.exportChunks, "export-chunks", "", "爬取结束后将输出目录打包为 capture.partNNN.tar.zst 分块，参数为每块大小，如 1GB")
+	fs.StringVar(&f.gitRemote, "git-remote", "", "-export-git 提交后推送的远程仓库地址")
+	fs.StringVar(&f.forwardHeaders, "forward-headers", "", "写入 resources.json 的响应头，逗号分隔，如 Cache-Control,ETag（默认全部）")
+	fs.StringVar(&f.blockDomains, "block-domains", "", "在浏览器中拦截的域名（含子域名），逗号分隔")
+	fs.StringVar(&f.pathPrefix, "path-prefix", "", "只保留 URL 路径以此开头的资源，如 /docs/；递归爬取时只跟随该路径下的链接")
+	fs.BoolVar(&f.noTracking, "no-tracking", false, "拦截内置的常见统计 / 广告 / 会话录制域名和上报 URL")
+	fs.StringVar(&f.trackingAllow, "tracking-allow", "", "-no-tracking 预设中不拦截的域名，逗号分隔")
+	fs.StringVar(&f.ignoreList, "ignore-list", "", "抓到后不保存的追踪地址清单，逗号分隔：预设 analytics、advertising、social 或自定义清单文件路径（请求照常发出）")
+	fs.BoolVar(&f.captureWorkers, "capture-workers", false, "抓取 Web Worker / 跨进程 iframe 中加载的资源")
+	fs.BoolVar(&f.cspEndpoints, "csp-endpoints", false, "从 CSP 头提取 report-uri / report-to 上报地址，记入索引和报告")
+	fs.BoolVar(&f.groupVariants, "group-query-variants", false, "报告中合并路径相同、仅查询字符串不同的资源，显示变体数和总大小")
+	fs.StringVar(&f.notifyWebhook, "notify-webhook", "", "爬取结束后将汇总 JSON POST 到该地址（失败时重试）")
+	fs.StringVar(&f.notifyCmd, "notify-cmd", "", "爬取结束后执行的本地命令，汇总 JSON 写入其标准输入")
+	fs.StringVar(&f.notifyOn, "notify-on", notify.OnAlways, "发送完成通知的条件: always 或 failure（有 URL 失败时）")
+	fs.IntVar(&f.reportPreview, "report-preview", 0, "报告中为每个文本资源（JS/CSS/JSON/HTML）附带前 N 字节的响应体预览，0 表示不附带")
+	fs.BoolVar(&f.sourceTree, "source-tree", false, "按 source map 中的原始路径把源文件另存到输出目录的 source-tree/（如 src/App.tsx）")
+	fs.BoolVar(&f.onlyNewSources, "only-new-sources", false, "跳过与输出目录中已有文件内容相同的源文件，只写新出现或有变化的（适合重复抓取同一站点）")
+	fs.BoolVar(&f.prettyJSON, "pretty-json", false, "保存时将 JSON 接口响应缩进格式化（默认保留原始内容）")
+	fs.BoolVar(&f.stripMapURL, "strip-sourcemap-url", false, "保存 JS / CSS 时去掉末尾的 sourceMappingURL 注释（source map 仍照常提取）")
+	fs.BoolVar(&f.securityReport, "security-report", false, "根据主文档响应头生成 security-headers.txt（Server、HSTS、CSP 等及缺失项）")
+	fs.BoolVar(&f.privacyReport, "privacy-report", false, "加载完成后读取 Cookie 和跨站 iframe 的 localStorage，生成 privacy.json 并在报告中列出第三方项")
+	fs.BoolVar(&f.coverage, "coverage", false, "统计爬取期间 JS 实际执行和 CSS 规则命中的部分，生成 coverage.json 并在报告中列出未使用字节最多的文件")
+	fs.BoolVar(&f.browserIssues, "browser-issues", false, "收集爬取期间浏览器报告的 CSP 违规、混合内容、CORS、Cookie 问题和弃用警告，生成 browser-issues.json 并在报告中按类型汇总")
+	fs.BoolVar(&f.hintsReport, "hints-report", false, "在报告中列出页面声明的 preload / prefetch / preconnect 等资源提示，以及是否有对应的加载和未加载的 preload")
+	fs.BoolVar(&f.unloadedRefs, "unloaded-refs", false, "在报告中按类型列出渲染后的 DOM 和 CSS 中引用（src、href、srcset、url()）但爬取期间未加载的资源")
+	fs.BoolVar(&f.fetchUnref, "fetch-unreferenced", false, "直接下载 DOM / CSS 中引用但未加载的资源并加入抓取结果（隐含 -unloaded-refs）")
+	fs.BoolVar(&f.dismissDialogs, "dismiss-dialogs", false, "自动关闭页面弹出的 alert / confirm / prompt 对话框并记入报告，避免阻塞页面")
+	fs.BoolVar(&f.dialogAccept, "dialog-accept", false, "自动关闭时对 confirm / prompt 按确定（默认按取消），需配合 -dismiss-dialogs")
+	fs.Var(&f.clickMarkers, "mark-after-click", "加载完成后点击该元素（CSS 选择器），之后的请求标记为 mark1、mark2 ...（可多次使用，按顺序执行）")
+	fs.StringVar(&f.onlyAfter, "only-after", "", "只保存该标记（如 mark1）及之后标记下的资源，需配合 -mark-after-click")
+	fs.StringVar(&f.requireSelector, "require-selector", "", "加载完成后必须存在的 CSS 选择器（如 '#dashboard'），缺失则该 URL 判为失败")
+	fs.StringVar(&f.loginPatterns, "login-patterns", "", "登录页 URL 路径特征，逗号分隔（默认 /login,/signin,/sign-in,/auth）")
+	fs.Var(&f.overrides, "override", "本地覆盖，格式: \"URL模式=本地文件\"，URL 模式支持 * 通配（可多次使用）")
+	fs.Var(&f.caCerts, "ca-cert", "备用下载额外信任的根证书 PEM 文件（可多次使用）")
+	fs.Var(&f.pinCerts, "pin-cert", "备用下载的证书公钥固定值，base64(SHA-256(公钥DER))（可多次使用）")
+	fs.BoolVar(&f.suppressEmpty, "suppress-empty", true, "跳过响应体为空的资源；设为 false 时写入零字节文件")
+	fs.StringVar(&f.skipStatus, "skip-status", "", "不保存这些状态码的响应，逗号分隔，可用 4xx / 5xx 表示整类，如 404,5xx（报告中列为失败）")
+	fs.BoolVar(&f.sniffMime, "sniff-mime", true, "按内容识别声明为 text/plain、application/octet-stream 的资源的实际类型")
+	fs.BoolVar(&f.noFallback, "no-fallback", false, "禁止浏览器取不到响应体时直接下载，所有内容必须来自浏览器会话")
+	fs.IntVar(&f.bodyTimeout, "body-timeout", 15, "单个响应体从浏览器获取的超时（秒）")
+	fs.IntVar(&f.flushInterval, "flush-interval", 0, "爬取进行中每隔 N 秒分批落盘已完成资源并刷新 manifest.partial.json（0 表示关闭）")
+	fs.Int64Var(&f.flushBytes, "flush-bytes", 0, "爬取进行中已完成资源累计达到 N 字节时分批落盘（0 表示关闭）")
+	fs.BoolVar(&f.deterministic, "deterministic", false, "确定性模式：固定视口、冻结 Date/Math.random、禁用动画，便于回归比对")
+	fs.StringVar(&f.viewport, "viewport", "", "视口大小，格式 WIDTHxHEIGHT，如 1366x768")
+	fs.StringVar(&f.maxDOMSize, "max-dom-size", "", "序列化 DOM 超过该大小时跳过截图、渲染 DOM 和链接提取，如 20MB（默认不检查）")
+	fs.BoolVar(&f.inlineScripts, "inline-scripts", false, "将页面中没有 src 的 <script> 保存为 inline://<host>/inline_script_<N>.js，并同样提取 source map")
+	fs.BoolVar(&f.inlineStyles, "inline-styles", false, "将页面中的 <style> 保存为 inline://<host>/inline_style_<N>.css，并同样提取 source map")
+	fs.BoolVar(&f.navigatorProps, "navigator-props", false, "加载完成后将页面看到的 navigator 属性保存为 diagnostic://<host>/navigator.json，排查 UA / 平台伪装")
+	fs.BoolVar(&f.loadHints, "load-prefetch", false, "滚动后主动请求 <link rel=\"prefetch|preload\"> 提示的资源，抓取未访问路由的拆分 chunk")
+	fs.BoolVar(&f.keepOpen, "keep-open", false, "抓取完成后保持浏览器窗口打开（隐含 -headless=false），按回车后关闭并保存，期间手动操作加载的资源同样会保存")
+	fs.StringVar(&f.exportSession, "export-session", "", "爬取结束（-keep-open 时为关闭窗口）后将全部 Cookie 和页面的 localStorage 导出到指定 JSON 文件（仅单 URL 模式）")
+	fs.StringVar(&f.importSession, "import-session", "", "爬取前从 -export-session 导出的 JSON 文件恢复 Cookie 和 localStorage")
+	fs.BoolVar(&f.scrollShots, "scroll-screenshots", false, "滚动阶段每一步后截取视口，保存为 screenshot-1.png、screenshot-2.png ...")
+	fs.BoolVar(&f.timingAPI, "timing-api", false, "加载完成后读取 Resource Timing API，记录每个资源的 DNS / 连接 / TTFB / 传输耗时")
+	fs.DurationVar(&f.slowThreshold, "slow-threshold", 0, "单个资源从请求到取得响应体超过该耗时时打印警告，如 5s（0 表示不检查）")
+	fs.BoolVar(&f.dumpEvents, "dump-network-events", false, "记录全部 network.* CDP 事件到 network-events.jsonl")
+	fs.IntVar(&f.smWorkers, "sourcemap-workers", 4, "并发提取 source map 的 worker 数")
+	fs.BoolVar(&f.mapMeta, "map-meta", false, "为每个 source map 生成 <name>.map.meta.json：源文件数、是否内联 sourcesContent、可还原字节数、names / mappings 统计")
+	fs.BoolVar(&f.htmlInlineMaps, "html-inline-maps", false, "解析抓到的 HTML 页面，从内联 <script> / <style> 的 sourceMappingURL 提取 source map（相对地址按页面解析）")
+	fs.BoolVar(&f.probeMaps, "probe-maps", false, "JS / CSS / WebAssembly 没有 sourceMappingURL 时尝试下载同目录的 <文件名>.map（如 app.wasm.map）")
+	fs.StringVar(&f.maxSourceSize, "max-source-size", "10MB", "source map 中单个源文件的大小上限，超出的跳过不提取（0 表示不限制）")
+	fs.StringVar(&f.maxMapSources, "max-map-sources-size", "200MB", "单个 source map 提取的源文件合计大小上限，超出后剩余源文件跳过（0 表示不限制）")
+	fs.IntVar(&f.maxSources, "max-map-sources", sourcemap.DefaultMaxSources, "单个 source map 提取的源文件数上限（0 表示不限制）")
+	fs.IntVar(&f.sourceFetchers, "source-fetch-workers", 0, "source map 缺少 sourcesContent 时按 sources 地址下载源文件的并发数（0 表示不下载）")
+	fs.Float64Var(&f.smRate, "sourcemap-rate", 0, "source map 下载速率上限（次/秒），0 表示不限速")
+	fs.Var(&f.labels, "label", "资源标签，格式: \"key=value\"，附加到本次爬取的所有资源（可多次使用）")
+	fs.StringVar(&f.mainOutput, "main-output", "", "将主文档另存到指定文件（仅单 URL 模式）")
+	fs.BoolVar(&f.mainRendered, "main-output-rendered", false, "-main-output 保存渲染后的 DOM 而非原始响应")
+	fs.BoolVar(&f.noCache, "no-cache", false, "禁用 Chrome 磁盘缓存，导航前清空缓存")
+	fs.BoolVar(&f.enableAudio, "enable-audio", false, "允许页面无用户手势创建 AudioContext，用于录音 / 音乐类应用（Linux 上可能需要额外的音频配置）")
+	fs.StringVar(&f.harFile, "har", "", "HAR 回放：从 HAR 文件还原资源而不启动浏览器")
+	fs.IntVar(&f.depth, "depth", 0, "递归爬取同主机链接的层数（0 表示只爬目标页）")
+	fs.IntVar(&f.maxPages, "max-pages", 0, "递归爬取的页面数上限（0 表示不限）")
+	fs.IntVar(&f.recursionWorkers, "recursion-workers", 0, "递归爬取同时处理的 Tab 数，分布在 -concurrency 个浏览器进程中（0 表示与 -concurrency 相同）")
+	fs.StringVar(&f.strategy, "strategy", frontier.BFS, "递归出队顺序: bfs 或 dfs")
+	fs.BoolVar(&f.resume, "resume", false, "从输出目录中的 frontier.jsonl 继续上次中断的递归爬取")
+	fs.BoolVar(&f.showHelp, "help", false, "显示帮助信息")
+
+	return fs, f
+}
+
+// stdinLines 按行读取标准输入，-keep-open 下多个页面依次等待时共用同一个读取协程
+var (
+	stdinOnce  sync.Once
+	stdinLines chan struct{}
+)
+
+// waitForClose -keep-open：提示用户检查完后按回车，浏览器被手动关闭或收到中断信号时也会返回
+func waitForClose(ctx context.Context) {
+	stdinOnce.Do(func() {
+		stdinLines = make(chan struct{})
+		go func() {
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				stdinLines <- struct{}{}
+			}
+			close(stdinLines)
+		}()
+	})
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintln(os.Stderr, "浏览器保持打开，可在 DevTools 中检查页面；press Enter to close and save")
+	select {
+	case <-stdinLines:
+	case <-ctx.Done():
+	}
+}
+
+// build 校验参数并生成爬虫配置和输出选项
+func (f *crawlFlags) build() (*crawler.Config, *outputOptions, error) {
+	if f.gitRemote != "" && f.gitRepo == "" {
+		return nil, nil, fmt.Errorf("-git-remote 需要同时指定 -export-git")
+	}
+
+	if f.exportFormat != "" && !slices.Contains([]string{storage.FormatBurp, storage.FormatZAP, storage.FormatNDJSON, storage.FormatWPR}, f.exportFormat) {
+		return nil, nil, fmt.Errorf("-export 仅支持 burp、zap、ndjson 或 wpr，当前值: %s", f.exportFormat)
+	}
+
+	if f.keepOpen && (f.concurrency > 1 || f.recursionWorkers > 1) {
+		return nil, nil, fmt.Errorf("-keep-open 不能与 -concurrency / -recursion-workers 大于 1 同时使用")
+	}
+	if f.timestampDir && f.resume {
+		return nil, nil, fmt.Errorf("-timestamp-dir 每次运行使用新目录，不能与 -resume 同时使用")
+	}
+	if f.reportPreview < 0 {
+		return nil, nil, fmt.Errorf("-report-preview 不能为负数: %d", f.reportPreview)
+	}
+	var session *crawler.SessionState
+	if f.importSession != "" {
+		var err error
+		if session, err = loadSession(f.importSession); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// 解析 headers，并过滤含换行符的注入攻击
+	headerMap := make(map[string]string)
+	for _, h := range f.headers {
+		idx := strings.Index(h, ":")
+		if idx == -1 {
+			logging.Warnf("忽略无效的 Header 格式: %s (应为 Key:Value)", h)
+			continue
+		}
+		key := strings.TrimSpace(h[:idx])
+		value := strings.TrimSpace(h[idx+1:])
+		if strings.ContainsAny(key, "\r\n") || strings.ContainsAny(value, "\r\n") {
+			logging.Warnf("忽略包含非法字符的 Header: %s", h)
+			continue
+		}
+		headerMap[key] = value
+	}
+
+	var viewportWidth, viewportHeight int
+	if f.viewport != "" {
+		if _, err := fmt.Sscanf(f.viewport, "%dx%d", &viewportWidth, &viewportHeight); err != nil || viewportWidth <= 0 || viewportHeight <= 0 {
+			return nil, nil, fmt.Errorf("-viewport 格式应为 WIDTHxHEIGHT，当前值: %s", f.viewport)
+		}
+	}
+
+	var retryCodes []int
+	for _, v := range f.retryOnStatus {
+		for _, s := range splitList(v) {
+			code, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, nil, fmt.Errorf("-retry-on-status 应为 HTTP 状态码，当前值: %s", s)
+			}
+			retryCodes = append(retryCodes, code)
+		}
+	}
+
+	skipCodes, err := parseStatusCodes(f.skipStatus)
+	if err != nil {
+		return nil, nil, fmt.Errorf("-skip-status 格式错误: %w", err)
+	}
+
+	var clickMarkers []crawler.ClickMarker
+	for i, selector := range f.clickMarkers {
+		clickMarkers = append(clickMarkers, crawler.ClickMarker{Label: fmt.Sprintf("mark%d", i+1), Selector: selector})
+	}
+
+	labelMap := make(map[string]string)
+	for _, l := range f.labels {
+		key, value, ok := strings.Cut(l, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			logging.Warnf("忽略无效的 -label 格式: %s (应为 key=value)", l)
+			continue
+		}
+		labelMap[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	overrideMap := make(map[string]string)
+	for _, o := range f.overrides {
+		pattern, file, ok := strings.Cut(o, "=")
+		if !ok || strings.TrimSpace(pattern) == "" || strings.TrimSpace(file) == "" {
+			logging.Warnf("忽略无效的 -override 格式: %s (应为 URL模式=本地文件)", o)
+			continue
+		}
+		overrideMap[strings.TrimSpace(pattern)] = strings.TrimSpace(file)
+	}
+
+	config := &crawler.Config{
+		Timeout:     time.Duration(f.timeout) * time.Second,
+		IdleTimeout: time.Duration(f.idleTimeout) * time.Second,
+		Cookies:     f.cookie,
+		Headers:     headerMap,
+		Proxy:       f.proxy,
+		ProxyPacURL: f.proxyPac,
+		UserAgent:   f.userAgent,
+		ChromePath:  f.chromePath,
+		Headless:    f.headless,
+		Concurrency: f.concurrency,
+		MaxRetry:    f.maxRetry,
+
+		UserAgentRotation: f.uaRotation,
+		RealUserAgent:     f.realUA,
+
+		SharedSession: f.session,
+
+		CookieSameSiteBypass: f.cookieCross,
+
+		FollowClientRedirects: f.followNav,
+
+		RetryOnStatusCodes: retryCodes,
+		RetryAttempts:      f.retryAttempts,
+		RetryBaseDelay:     f.retryDelay,
+
+		ChromeStabilizationDelay: f.startupDelay,
+		NavigationSettleDelay:    f.settleDelay,
+		ScrollDuration:           f.scrollDuration,
+		WaitUntil:                f.waitUntil,
+
+		CollapsePolling:   f.collapsePolling,
+		CacheBusterParams: splitList(f.cacheBusters),
+		MaxSourceMapSize:  f.maxSourceMapSize,
+
+		MapHostAllowlist:   splitList(f.mapHosts),
+		SameOriginMapsOnly: f.mapsSameOrigin,
+
+		Delay:             time.Duration(f.delay * float64(time.Second)),
+		RespectCrawlDelay: !f.ignoreCrawlDelay,
+		MaxCrawlDelay:     time.Duration(f.maxCrawlDelay * float64(time.Second)),
+		MaxDuration:       f.maxDuration,
+		CaptureWorkers:    f.captureWorkers,
+		LoginURLPatterns:  splitList(f.loginPatterns),
+		RequireSelector:   f.requireSelector,
+		ClickMarkers:      clickMarkers,
+		OnlyAfterMarker:   f.onlyAfter,
+		LocalOverrides:    overrideMap,
+		ExtraRootCAs:      f.caCerts,
+		TLSPinningCerts:   f.pinCerts,
+
+		DisableFallbackDownload: f.noFallback,
+		BodyFetchTimeout:        time.Duration(f.bodyTimeout) * time.Second,
+
+		Deterministic:  f.deterministic,
+		ViewportWidth:  viewportWidth,
+		ViewportHeight: viewportHeight,
+
+		DumpNetworkEvents: f.dumpEvents,
+		ResourceLabels:    labelMap,
+
+		CaptureRenderedHTML: (f.mainOutput != "" && f.mainRendered) || f.hintsReport || f.unloadedRefs || f.fetchUnref,
+
+		DisableDiskCache: f.noCache,
+
+		EnableAudioContext: f.enableAudio,
+
+		PerOriginConcurrency: f.perOrigin,
+
+		HARReplayMode: f.harFile != "",
+		HARFile:       f.harFile,
+
+		RecursionDepth: f.depth,
+		MaxPages:       f.maxPages,
+		CrawlStrategy:  f.strategy,
+		Resume:         f.resume,
+
+		RecursionWorkers: f.recursionWorkers,
+
+		FollowCSPReportURIs: f.cspEndpoints,
+
+		SuppressEmptyContent: f.suppressEmpty,
+		SkipStatusCodes:      skipCodes,
+		SniffMime:            f.sniffMime,
+
+		SkipUnchangedSources: f.onlyNewSources,
+
+		PrettyPrintJSON: f.prettyJSON,
+
+		StripSourceMappingURL: f.stripMapURL,
+
+		CaptureTimingAPI: f.timingAPI,
+
+		SlowResourceThreshold: f.slowThreshold,
+
+		ScrollScreenshots: f.scrollShots,
+
+		CapturePrefetchAndPreload: f.loadHints,
+
+		ExtractInlineScripts: f.inlineScripts,
+		ExtractInlineStyles:  f.inlineStyles,
+
+		IncludeNavigatorProperties: f.navigatorProps,
+
+		CapturePrivacy: f.privacyReport,
+		CaptureSession: f.exportSession != "",
+
+		CaptureCoverage:      f.coverage,
+		CaptureBrowserIssues: f.browserIssues,
+
+		DisableJavaScriptDialogs: f.dismissDialogs,
+		DialogAcceptValue:        f.dialogAccept,
+
+		BlockDomains: splitList(f.blockDomains),
+		PathPrefix:   f.pathPrefix,
+
+		ForwardHTTPHeaders: splitList(f.forwardHeaders),
+
+		ResourceIgnoreList: splitList(f.ignoreList),
+	}
+	// SPIDER_* 环境变量取代参数默认值，显式指定的参数仍然优先
+	if err := config.PopulateFromEnvironment(); err != nil {
+		return nil, nil, fmt.Errorf("环境变量格式错误:\n%w", err)
+	}
+	f.applyExplicitFlags(config)
+	if f.noTracking {
+		domains, patterns := crawler.TrackingBlocklist(splitList(f.trackingAllow))
+		config.BlockDomains = append(config.BlockDomains, domains...)
+		config.BlockURLPatterns = append(config.BlockURLPatterns, patterns...)
+	}
+	if f.keepOpen {
+		config.Headless = false
+		config.KeepOpen = waitForClose
+	}
+	if f.budget > 0 {
+		config.ApplyTimeBudget(f.budget)
+		// 显式指定的单阶段参数覆盖预算分配
+		f.flags.Visit(func(fl *flag.Flag) {
+			switch fl.Name {
+			case "timeout":
+				config.Timeout = time.Duration(f.timeout) * time.Second
+			case "idle-timeout":
+				config.IdleTimeout = time.Duration(f.idleTimeout) * time.Second
+			case "settle-delay":
+				config.NavigationSettleDelay = f.settleDelay
+			case "scroll-duration":
+				config.ScrollDuration = f.scrollDuration
+			}
+		})
+	}
+
+	if f.dataURIs != "" {
+		size, err := parseByteSize(f.dataURIs)
+		if err != nil || size <= 0 {
+			return nil, nil, fmt.Errorf("-extract-data-uris 格式错误（应如 64KB、1MB）: %s", f.dataURIs)
+		}
+		config.DataURIThreshold = size
+		config.RewriteDataURIs = f.rewriteDataURIs
+	}
+
+	if f.maxDOMSize != "" {
+		size, err := parseByteSize(f.maxDOMSize)
+		if err != nil || size <= 0 {
+			return nil, nil, fmt.Errorf("-max-dom-size 格式错误（应如 20MB）: %s", f.maxDOMSize)
+		}
+		config.MaxDOMBytes = size
+	}
+
+	var chunkSize int64
+	if f.exportChunks != "" {
+		size, err := parseByteSize(f.exportChunks)
+		if err != nil || size <= 0 {
+			return nil, nil, fmt.Errorf("-export-chunks 格式错误（应如 512MB、1GB）: %s", f.exportChunks)
+		}
+		chunkSize = size
+	}
+
+	var maxSourceBytes, maxMapSourceBytes int64
+	for _, limit := range []struct {
+		flag, value string
+		dest        *int64
+	}{
+		{"-max-source-size", f.maxSourceSize, &maxSourceBytes},
+		{"-max-map-sources-size", f.maxMapSources, &maxMapSourceBytes},
+	} {
+		size, err := parseByteSize(limit.value)
+		if err != nil || size < 0 {
+			return nil, nil, fmt.Errorf("%s 格式错误（应如 10MB、200MB，0 表示不限制）: %s", limit.flag, limit.value)
+		}
+		*limit.dest = size
+	}
+
+	opts := &outputOptions{
+		exportFormat:  f.exportFormat,
+		flushInterval: time.Duration(f.flushInterval) * time.Second,
+		flushBytes:    f.flushBytes,
+
+		sourceMapWorkers: f.smWorkers,
+		sourceFetchers:   f.sourceFetchers,
+		mapMeta:          f.mapMeta,
+		htmlInlineMaps:   f.htmlInlineMaps,
+		probeMaps:        f.probeMaps,
+		sourceMapRate:    f.smRate,
+		sourceTree:       f.sourceTree,
+
+		maxSourceBytes:    maxSourceBytes,
+		maxMapSourceBytes: maxMapSourceBytes,
+		maxSources:        f.maxSources,
+
+		mapCache: sourcemap.NewMapCache(sourcemap.DefaultMapCacheEntries, sourcemap.DefaultMapCacheBytes, sourcemap.DefaultMapCacheTTL),
+
+		mainOutput:         f.mainOutput,
+		exportSession:      f.exportSession,
+		session:            session,
+		mainOutputRendered: f.mainRendered,
+
+		gitRepo:   f.gitRepo,
+		gitRemote: f.gitRemote,
+
+		securityReport: f.securityReport,
+		groupVariants:  f.groupVariants,
+		hintsReport:    f.hintsReport,
+		reportPreview:  f.reportPreview,
+
+		unloadedRefs:      f.unloadedRefs || f.fetchUnref,
+		fetchUnreferenced: f.fetchUnref,
+
+		chunkSize: chunkSize,
+	}
+	if f.notifyWebhook != "" || f.notifyCmd != "" {
+		opts.notifier = &notify.Notifier{
+			WebhookURL: f.notifyWebhook,
+			Command:    f.notifyCmd,
+			On:         f.notifyOn,
+			Retries:    notify.DefaultRetries,
+			RetryDelay: notify.DefaultRetryDelay,
+		}
+		if err := opts.notifier.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("-notify-on / -notify-webhook: %w", err)
+		}
+		opts.results = &runResults{}
+	}
+
+	// 在启动 Chrome 之前报告全部配置问题
+	if err := config.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("配置无效:\n%w", err)
+	}
+
+	return config, opts, nil
+}
+
+// logConfig 打印本次爬取的主要参数
+func (f *crawlFlags) logConfig(config *crawler.Config) {
+	log.Printf("Spider - 浏览器模拟爬虫工具")
+	log.Printf("================================")
+	log.Printf("输出目录: %s", f.outputDir)
+	log.Printf("超时时间: %v / 空闲等待上限: %v", config.Timeout, config.IdleTimeout)
+	if config.TimeBudget > 0 {
+		log.Printf("时间预算: %v（滚动前等待 %v / 滚动 %v）", config.TimeBudget, config.NavigationSettleDelay, config.ScrollDuration)
+	}
+	log.Printf("无头模式: %v / 重试次数: %d", config.Headless, f.maxRetry)
+	if len(config.Headers) > 0 {
+		log.Printf("自定义Headers: %v", config.Headers)
+	}
+	if config.Proxy != "" {
+		log.Printf("代理: %s", config.Proxy)
+	}
+	if f.proxyPac != "" {
+		log.Printf("代理 PAC: %s", f.proxyPac)
+	}
+	if config.UserAgent != "" {
+		log.Printf("User-Agent: %s", config.UserAgent)
+	}
+	log.Printf("================================\n")
+}
+
+// runCrawl crawl 子命令（也是省略子命令时的默认命令），兼容旧版 -url / -file 用法
+func runCrawl(args []string) int {
+	fs, f := newCrawlFlagSet("crawl", showCrawlUsage)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if f.showHelp {
+		showCrawlUsage()
+		return 0
+	}
+	if err := f.applyEnvironment(true); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+
+	if f.targetURL == "" && f.urlFile == "" {
+		fmt.Fprintln(os.Stderr, "错误: 必须指定 -url 或 -file 参数")
+		showCrawlUsage()
+		return 1
+	}
+
+	if f.targetURL != "" && f.urlFile != "" {
+		fmt.Fprintln(os.Stderr, "错误: -url 和 -file 参数只能选择一个")
+		showCrawlUsage()
+		return 1
+	}
+
+	if f.mainOutput != "" && f.urlFile != "" {
+		fmt.Fprintln(os.Stderr, "错误: -main-output 仅支持单 URL 模式（-url）")
+		return 1
+	}
+
+	if f.depth > 0 && (f.urlFile != "" || f.mainOutput != "") {
+		fmt.Fprintln(os.Stderr, "错误: -depth 递归爬取仅支持 -url，且不能与 -main-output 同时使用")
+		return 1
+	}
+
+	if f.exportSession != "" && (f.urlFile != "" || f.depth > 0) {
+		fmt.Fprintln(os.Stderr, "错误: -export-session 仅支持单 URL 模式（-url），不能与 -file / -depth 同时使用")
+		return 1
+	}
+
+	config, opts, err := f.build()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+
+	// 获取 URL 列表（先于 -timestamp-dir 占用目录，读取失败时不留下空目录）
+	var urls []string
+	if f.targetURL != "" {
+		urls = []string{f.targetURL}
+	} else {
+		urls, err = readURLsFromFile(f.urlFile)
+		if err != nil {
+			logging.Errorf("读取URL文件失败: %v", err)
+			return 1
+		}
+	}
+	baseDir, err := f.applyTimestampDir(time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+	f.logConfig(config)
+	if f.targetURL == "" {
+		log.Printf("从文件读取了 %d 个URL，并发数: %d", len(urls), config.Concurrency)
+	}
+
+	started := time.Now()
+	var code int
+	switch {
+	case config.RecursionDepth > 0:
+		code = crawlRecursive(urls[0], config, opts, f.outputDir)
+	case len(urls) == 1:
+		code = crawlSingleURL(urls[0], config, opts, f.outputDir)
+	default:
+		code = crawlMultipleURLs(urls, config, opts, f.outputDir)
+	}
+	code = exportChunks(code, opts, f.outputDir)
+	updateLatestLink(baseDir, f.outputDir)
+	sendNotification("crawl", started, code, opts, f.outputDir)
+	return code
+}
+
+// runBatch batch 子命令：从 URL 文件批量爬取，始终按批量模式输出 manifest.json
+func runBatch(args []string) int {
+	fs, f := newCrawlFlagSet("batch", showBatchUsage)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if f.showHelp {
+		showBatchUsage()
+		return 0
+	}
+
+	// URL 文件既可用 -file 指定，也可作为位置参数
+	if f.urlFile == "" && fs.NArg() > 0 {
+		f.urlFile = fs.Arg(0)
+	}
+	if err := f.applyEnvironment(false); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+	if f.urlFile == "" {
+		fmt.Fprintln(os.Stderr, "错误: 必须指定 URL 文件")
+		showBatchUsage()
+		return 1
+	}
+	if f.targetURL != "" {
+		fmt.Fprintln(os.Stderr, "错误: batch 不支持 -url，单个 URL 请使用 crawl")
+		return 1
+	}
+	if f.mainOutput != "" {
+		fmt.Fprintln(os.Stderr, "错误: -main-output 仅支持单 URL 模式（crawl -url）")
+		return 1
+	}
+	if f.exportSession != "" {
+		fmt.Fprintln(os.Stderr, "错误: -export-session 仅支持单 URL 模式（crawl -url）")
+		return 1
+	}
+
+	config, opts, err := f.build()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+	urls, err := readURLsFromFile(f.urlFile)
+	if err != nil {
+		logging.Errorf("读取URL文件失败: %v", err)
+		return 1
+	}
+	baseDir, err := f.applyTimestampDir(time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+	f.logConfig(config)
+	log.Printf("从文件读取了 %d 个URL，并发数: %d", len(urls), config.Concurrency)
+
+	started := time.Now()
+	code := exportChunks(crawlMultipleURLs(urls, config, opts, f.outputDir), opts, f.outputDir)
+	updateLatestLink(baseDir, f.outputDir)
+	sendNotification("batch", started, code, opts, f.outputDir)
+	return code
+}
+
+// runReplay replay 子命令：从 HAR 文件离线还原一次爬取，走与 crawl 相同的提取和存储流程
+func runReplay(args []string) int {
+	fs, f := newCrawlFlagSet("replay", showReplayUsage)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if f.showHelp {
+		showReplayUsage()
+		return 0
+	}
+
+	// HAR 文件既可用 -har 指定，也可作为位置参数
+	if f.harFile == "" && fs.NArg() > 0 {
+		f.harFile = fs.Arg(0)
+	}
+	if err := f.applyEnvironment(true); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+	if f.harFile == "" || f.targetURL == "" {
+		fmt.Fprintln(os.Stderr, "错误: 必须指定 HAR 文件和 -url")
+		showReplayUsage()
+		return 1
+	}
+	if f.urlFile != "" {
+		fmt.Fprintln(os.Stderr, "错误: replay 不支持 -file")
+		return 1
+	}
+
+	config, opts, err := f.build()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+	baseDir, err := f.applyTimestampDir(time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+	log.Printf("HAR 回放: %s", f.harFile)
+
+	started := time.Now()
+	code := exportChunks(crawlSingleURL(f.targetURL, config, opts, f.outputDir), opts, f.outputDir)
+	updateLatestLink(baseDir, f.outputDir)
+	sendNotification("replay", started, code, opts, f.outputDir)
+	return code
+}
+
+// sourceTreeDirName -source-tree 还原的源码目录在输出目录中的子目录
+const sourceTreeDirName = "source-tree"
+
+// chunkDirName -export-chunks 分块在输出目录中的子目录
+const chunkDirName = "capture-parts"
+
+// exportChunks 开启 -export-chunks 时将整个输出目录打包为分块，写到 outputDir/capture-parts；
+// 爬取的退出码原样返回，打包失败时返回 1
+func exportChunks(code int, opts *outputOptions, outputDir string) int {
+	if opts.chunkSize <= 0 {
+		return code
+	}
+	destDir := filepath.Join(outputDir, chunkDirName)
+	// 清掉上一次的分块，避免残留的 partNNN 与新索引混在一起
+	if err := os.RemoveAll(destDir); err != nil {
+		logging.Warnf("清理 %s 失败: %v", destDir, err)
+	}
+	index, err := storage.ExportChunks(outputDir, destDir, opts.chunkSize)
+	if err != nil {
+		logging.Errorf("分块导出失败: %v", err)
+		return 1
+	}
+	log.Printf("分块导出完成: %d 个分块 → %s（spider import -dir %s -out <目录> 还原）", len(index.Chunks), destDir, destDir)
+	return code
+}
+
+// parseByteSize 解析 512MB、1GB、1.5G、1048576 这样的大小（1024 进制）
+func parseByteSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	units := []struct {
+		suffix string
+		size   float64
+	}{
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+	}
+	multiplier := 1.0
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			multiplier = u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("无效的大小: %q", s)
+	}
+	return int64(n * multiplier), nil
+}
+
+// parseStatusCodes 解析逗号分隔的状态码列表，4xx / 5xx 等展开为整类
+func parseStatusCodes(list string) ([]int, error) {
+	var codes []int
+	for _, v := range splitList(list) {
+		if class, ok := strings.CutSuffix(strings.ToLower(v), "xx"); ok && len(class) == 1 && class[0] >= '1' && class[0] <= '5' {
+			start := int(class[0]-'0') * 100
+			for code := start; code < start+100; code++ {
+				codes = append(codes, code)
+			}
+			continue
+		}
+		code, err := strconv.Atoi(v)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("无效的状态码: %s", v)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// crawlSingle 单 URL 模式下带重试地爬取，测试中替换为假的爬取（见 crawlInPool）
+var crawlSingle = crawlWithRetry
+
+// crawlSingleURL 爬取单个URL（含重试）
+func crawlSingleURL(targetURL string, config *crawler.Config, opts *outputOptions, outputDir string) int {
+	log.Printf("目标URL: %s", targetURL)
+	_, result, err := crawlSingle(targetURL, config, opts, outputDir)
+	if final := navigationTarget(result, targetURL); final != "" {
+		log.Printf("页面跳转: %s → %s", targetURL, final)
+	}
+	if result != nil && result.LikelyLoginPage {
+		logging.Warnf("!!! 抓取结果疑似为登录页（%s），请检查 Cookie 是否已过期 !!!", result.LoginSignal)
+	}
+	entry := ManifestEntry{URL: targetURL, OutputDir: outputDir, Success: err == nil}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if result != nil {
+		entry.LikelyLoginPage = result.LikelyLoginPage
+		entry.LoginSignal = result.LoginSignal
+		entry.Skipped = result.Skipped
+	}
+	opts.results.add(entry)
+	if err != nil {
+		logging.Errorf("%v", err)
+		if isChromeError(err) {
+			log.Print(chromeInstallHint + "\n可运行 spider check 检查浏览器、代理和输出目录。\n")
+		}
+		return 1
+	}
+	return 0
+}
+
+// navigationTarget 返回页面最终到达的地址（在跨源跳转处停止时为跳转目标），
+// 与输入 URL 相同（忽略末尾 /）或没有结果时返回空字符串
+func navigationTarget(result *crawler.CrawlResult, inputURL string) string {
+	if result == nil {
+		return ""
+	}
+	final := result.FinalURL
+	if result.NavigatedAway != "" {
+		final = result.NavigatedAway
+	}
+	if final == "" || strings.TrimSuffix(final, "/") == strings.TrimSuffix(inputURL, "/") {
+		return ""
+	}
+	return final
+}
+
+// chromeInstallHint 浏览器找不到或启动失败时的安装提示（crawl 与 check 共用）
+const chromeInstallHint = `
+Chrome 浏览器未找到或启动失败！
+
+请确保系统中已安装 Chrome、Chromium 或 Edge 浏览器（或用 -chrome-path 指定路径）：
+
+macOS:
+  brew install --cask google-chrome
+
+Linux (Ubuntu/Debian):
+  sudo apt-get install chromium-browser
+
+Windows:
+  从 https://www.google.com/chrome/ 下载安装
+`
+
+// batchTask 批量模式中的一个 URL 及其预先分配的输出目录
+type batchTask struct {
+	url       string
+	outputDir string
+}
+
+// batchJob 批量爬取的单 URL 处理（crawler.BatchCrawler）：等待主机限速、占用浏览器进程、带重试地爬取，
+// 结果记入 entries 的对应下标
+type batchJob struct {
+	tasks    []batchTask
+	config   *crawler.Config
+	opts     *outputOptions
+	pool     *crawler.Pool // HAR 回放时为 nil
+	throttle *crawler.HostThrottle
+
+	// crawl 带重试地爬取单个 URL，见 crawlInPool
+	crawl func(allocCtx context.Context, targetURL string, config *crawler.Config, opts *outputOptions, outputDir string) (int, *crawler.CrawlResult, error)
+
+	mu      sync.Mutex
+	entries []ManifestEntry
+}
+
+// CrawlURL 爬取 tasks[idx]；ctx 为批量截止时间，到时取消进行中的爬取，尚未开始的记为未尝试
+func (j *batchJob) CrawlURL(ctx context.Context, idx int, _ string) error {
+	t := j.tasks[idx]
+	// 先等待主机限速，避免占着浏览器进程空等
+	j.throttle.Wait(t.url)
+
+	if ctx.Err() != nil {
+		j.record(idx, ManifestEntry{URL: t.url, OutputDir: t.outputDir, NotAttempted: true, Error: errBatchDeadline.Error()})
+		return errBatchDeadline
+	}
+
+	// Acquire 阻塞直到有空闲浏览器进程
+	allocCtx := context.Background()
+	if j.pool != nil {
+		allocCtx = j.pool.Acquire()
+		defer j.pool.Release(allocCtx)
+	}
+
+	// 从 allocCtx 派生（保留 chromedp 分配器），并在批量截止时取消
+	crawlCtx, crawlCancel := context.WithCancel(allocCtx)
+	defer crawlCancel()
+	stop := context.AfterFunc(ctx, crawlCancel)
+	defer stop()
+
+	log.Printf("[%d/%d] 开始爬取: %s → %s", idx+1, len(j.tasks), t.url, t.outputDir)
+
+	// -ua-rotation：按输入顺序为每个 URL 分配 User-Agent
+	taskConfig := j.config
+	if len(j.config.UserAgentRotation) > 0 {
+		copied := *j.config
+		copied.UserAgent = j.config.UserAgentFor(idx)
+		copied.UserAgentRotation = nil
+		taskConfig = &copied
+	}
+
+	entry := ManifestEntry{
+		URL:       t.url,
+		OutputDir: t.outputDir,
+		UserAgent: taskConfig.UserAgent,
+	}
+
+	// 每个 URL 使用独立的 Git 仓库（与输出目录同名），保证各自的提交历史可比较
+	taskOpts := j.opts
+	if j.opts.gitRepo != "" {
+		copied := *j.opts
+		copied.gitRepo = filepath.Join(j.opts.gitRepo, filepath.Base(t.outputDir))
+		taskOpts = &copied
+	}
+
+	used, result, err := j.crawl(crawlCtx, t.url, taskConfig, taskOpts, t.outputDir)
+	entry.Attempts = used
+	if result != nil {
+		entry.Skipped = result.Skipped
+		entry.NavigatedAway = result.NavigatedAway
+		if final := navigationTarget(result, t.url); final != "" {
+			entry.FinalURL = final
+			log.Printf("[%d/%d] 页面跳转: %s → %s", idx+1, len(j.tasks), t.url, final)
+		}
+	}
+	if result != nil && result.LikelyLoginPage {
+		entry.LikelyLoginPage = true
+		entry.LoginSignal = result.LoginSignal
+		logging.Warnf("[%d/%d] 疑似登录页（%s）: %s", idx+1, len(j.tasks), result.LoginSignal, t.url)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		logging.Errorf("[%d/%d] 全部重试失败: %s - %v", idx+1, len(j.tasks), t.url, err)
+	} else {
+		entry.Success = true
+		log.Printf("[%d/%d] 完成 (第 %d 次成功): %s", idx+1, len(j.tasks), used, t.url)
+	}
+	j.record(idx, entry)
+	return err
+}
+
+func (j *batchJob) record(idx int, entry ManifestEntry) {
+	j.mu.Lock()
+	j.entries[idx] = entry
+	j.mu.Unlock()
+}
+
+// crawlInPool 批量和递归爬取中在浏览器池的进程里带重试地爬取单个 URL，测试中替换为假的爬取
+var crawlInPool = crawlWithRetryInContext
+
+// crawlMultipleURLs 批量爬取：预启动浏览器池，按 hostname 分配输出目录，并行执行，最终写 manifest
+func crawlMultipleURLs(urls []string, config *crawler.Config, opts *outputOptions, baseOutputDir string) int {
+	// 预先按 hostname 分配稳定的输出目录，重复 host 加数字后缀
+	usedDirs := make(map[string]int)
+	tasks := make([]batchTask, len(urls))
+	for i, u := range urls {
+		tasks[i] = batchTask{
+			url:       u,
+			outputDir: buildBatchOutputDir(baseOutputDir, u, usedDirs),
+		}
+	}
+
+	// 会话模式：只启动一个浏览器，按输入顺序逐个爬取，登录等页面设置的 Cookie 延续到后续 URL
+	poolConfig := config
+	if config.SharedSession {
+		if config.Concurrency > 1 {
+			log.Printf("-session 模式按顺序爬取，忽略 -concurrency %d", config.Concurrency)
+		}
+		copied := *config
+		copied.Concurrency = 1
+		poolConfig = &copied
+	}
+
+	// 预热浏览器池：N 个 Chrome 进程对应 N 并发，避免每 URL 冷启动；HAR 回放不需要浏览器
+	var pool *crawler.Pool
+	if !config.HARReplayMode {
+		var err error
+		pool, err = crawler.NewPool(poolConfig)
+		if err != nil {
+			logging.Errorf("浏览器池启动失败: %v", err)
+			if isChromeError(err) {
+				log.Print(chromeInstallHint)
+			}
+			return 1
+		}
+		defer pool.Close()
+	}
+
+	// 整体时间上限：到时取消进行中的爬取，尚未开始的 URL 记为未尝试
+	batchCtx := context.Background()
+	if config.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		batchCtx, cancel = context.WithTimeout(batchCtx, config.MaxDuration)
+		defer cancel()
+	}
+
+	job := &batchJob{
+		tasks:  tasks,
+		config: config,
+		opts:   opts,
+		pool:   pool,
+		// 按主机限速：通用间隔 + robots.txt Crawl-delay
+		throttle: crawler.NewHostThrottle(config),
+		crawl:    crawlInPool,
+		entries:  make([]ManifestEntry, len(tasks)),
+	}
+
+	// 调度器控制全局并发（= 浏览器池大小）和单域名并发，并在域名间轮转；会话模式不轮转，保持输入顺序
+	scheduler := crawler.NewScheduler(poolConfig)
+	if config.SharedSession {
+		scheduler = crawler.NewOrderedScheduler()
+	}
+	errs := scheduler.Crawl(batchCtx, urls, job)
+
+	entries := job.entries
+	successCount, failCount, loginCount, skippedCount := 0, 0, 0, 0
+	for idx, err := range errs {
+		switch {
+		case entries[idx].URL == "":
+			// 调度器在截止后不再调用 CrawlURL，这些 URL 同样记为未尝试
+			entries[idx] = ManifestEntry{URL: tasks[idx].url, OutputDir: tasks[idx].outputDir, NotAttempted: true, Error: errBatchDeadline.Error()}
+			skippedCount++
+		case entries[idx].NotAttempted:
+			skippedCount++
+		case err != nil:
+			failCount++
+		default:
+			successCount++
+		}
+		if entries[idx].LikelyLoginPage {
+			loginCount++
+		}
+	}
+
+	writeManifest(baseOutputDir, entries)
+	opts.results.add(entries...)
+
+	log.Printf("\n================================")
+	log.Printf("批量爬取完成!")
+	log.Printf("成功: %d, 失败: %d, 总计: %d", successCount, failCount, len(tasks))
+	if skippedCount > 0 {
+		log.Printf("达到 -max-duration（%v），%d 个 URL 未尝试（见 manifest.json 中 not_attempted）", config.MaxDuration, skippedCount)
+	}
+	if loginCount > 0 {
+		logging.Warnf("!!! 疑似登录页: %d 个（见 manifest.json 中 likely_login_page），请检查 Cookie 是否已过期 !!!", loginCount)
+	}
+	log.Printf("结果清单: %s/manifest.json", baseOutputDir)
+	log.Printf("================================")
+	return 0
+}
+
+// crawlWithRetry 爬取单个 URL，失败时按指数退避重试。
+// 返回实际尝试次数、最后一次尝试的页面结果和错误；
+// 永久性错误（URL 非法、缺少必需元素）立即返回，不消耗重试次数。
+func crawlWithRetry(targetURL string, config *crawler.Config, opts *outputOptions, outputDir string) (attempts int, result *crawler.CrawlResult, err error) {
+	// 永久性错误：URL scheme 不合法，无需重试
+	u, parseErr := url.Parse(targetURL)
+	if parseErr != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		scheme := ""
+		if u != nil {
+			scheme = u.Scheme
+		}
+		return 0, nil, fmt.Errorf("不支持的 URL scheme %q：仅允许 http 和 https", scheme)
+	}
+
+	maxAttempts := config.MaxRetry + 1
+	var lastErr error
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			backoff := time.Duration(attempt-1) * 3 * time.Second
+			log.Printf("  [重试 %d/%d] 等待 %v 后重试: %s", attempt-1, config.MaxRetry, backoff, targetURL)
+			time.Sleep(backoff)
+		}
+
+		spider, err := crawler.New(config)
+		if err != nil {
+			return attempt, nil, err
+		}
+		if opts.session != nil {
+			if err := spider.ImportSession(opts.session); err != nil {
+				return attempt, nil, err
+			}
+		}
+		flusher := startFlusher(spider, config, opts, outputDir, false)
+		err = spider.Crawl(targetURL)
+		if flusher != nil {
+			flusher.Close()
+		}
+		if err != nil {
+			if errors.Is(err, crawler.ErrRequiredSelectorMissing) || errors.Is(err, crawler.ErrRetryStatusExhausted) {
+				spider.Close()
+				return attempt, spider.Result(), err
+			}
+			// 找不到浏览器时重试没有意义
+			if errors.Is(err, crawler.ErrChromeNotFound) {
+				return attempt, nil, err
+			}
+			spider.Close()
+			lastErr = err
+			logging.Warnf("[尝试 %d/%d] 失败: %v", attempt, maxAttempts, err)
+			continue
+		}
+
+		processResources(spider, config, opts, targetURL, outputDir, false)
+		if opts.exportSession != "" {
+			saveSession(spider, opts.exportSession)
+		}
+		// 每个 URL 用一个新 Spider，处理完后不再持有它；这里只需等后台获取结束并释放空闲连接
+		spider.Close()
+		return attempt, spider.Result(), nil
+	}
+
+	return maxAttempts, nil, fmt.Errorf("已重试 %d 次，最后错误: %w", config.MaxRetry, lastErr)
+}
+
+// crawlWithRetryInContext 在浏览器池的 allocCtx 中爬取，失败时指数退避重试；
+// allocCtx 被取消（达到 -max-duration）时立即返回，不再重试
+func crawlWithRetryInContext(allocCtx context.Context, targetURL string, config *crawler.Config, opts *outputOptions, outputDir string) (attempts int, result *crawler.CrawlResult, err error) {
+	u, parseErr := url.Parse(targetURL)
+	if parseErr != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		scheme := ""
+		if u != nil {
+			scheme = u.Scheme
+		}
+		return 0, nil, fmt.Errorf("不支持的 URL scheme %q：仅允许 http 和 https", scheme)
+	}
+
+	maxAttempts := config.MaxRetry + 1
+	var lastErr error
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			backoff := time.Duration(attempt-1) * 3 * time.Second
+			log.Printf("  [重试 %d/%d] 等待 %v: %s", attempt-1, config.MaxRetry, backoff, targetURL)
+			select {
+			case <-time.After(backoff):
+			case <-allocCtx.Done():
+				return attempt - 1, nil, errBatchDeadline
+			}
+		}
+
+		spider, err := crawler.New(config)
+		if err != nil {
+			return attempt, nil, err
+		}
+		if opts.session != nil {
+			if err := spider.ImportSession(opts.session); err != nil {
+				return attempt, nil, err
+			}
+		}
+		flusher := startFlusher(spider, config, opts, outputDir, true)
+		err = spider.CrawlInContext(allocCtx, targetURL)
+		if flusher != nil {
+			flusher.Close()
+		}
+		if err != nil {
+			if errors.Is(err, crawler.ErrRequiredSelectorMissing) || errors.Is(err, crawler.ErrRetryStatusExhausted) {
+				spider.Close()
+				return attempt, spider.Result(), err
+			}
+			// 批量截止导致的取消不再重试
+			if allocCtx.Err() != nil {
+				return attempt, nil, errBatchDeadline
+			}
+			spider.Close()
+			lastErr = err
+			logging.Warnf("[尝试 %d/%d] 失败: %v", attempt, maxAttempts, err)
+			continue
+		}
+
+		processResources(spider, config, opts, targetURL, outputDir, true)
+		spider.Close()
+		return attempt, spider.Result(), nil
+	}
+
+	return maxAttempts, nil, fmt.Errorf("已重试 %d 次，最后错误: %w", config.MaxRetry, lastErr)
+}
+
+// processResources 处理爬取到的资源：提取 source map、保存文件、生成报告。
+// flatStorage=true 时使用扁平路径（批量模式的 outputDir 已含 hostname）。
+func processResources(spider *crawler.Spider, config *crawler.Config, opts *outputOptions, targetURL, outputDir string, flatStorage bool) {
+	resources := spider.GetResources()
+	log.Printf("成功抓取 %d 个资源", len(resources))
+	if config.OnlyAfterMarker != "" {
+		if after, err := spider.ResourcesAfter(config.OnlyAfterMarker); err != nil {
+			logging.Warnf("%v，保存全部资源", err)
+		} else {
+			log.Printf("-only-after %s: 保留其中 %d 个资源", config.OnlyAfterMarker, len(after))
+			resources = after
+		}
+	}
+
+	store := newStore(outputDir, flatStorage, config)
+	if opts.unloadedRefs {
+		store.SetUnloadedReferences(fetchUnloadedReferences(spider, resources, opts.fetchUnreferenced))
+	}
+	set := &pipeline.ResourceSet{TargetURL: targetURL, OutputDir: outputDir, Resources: resources}
+	if err := defaultPipeline(spider, config, opts, store, targetURL, outputDir).Run(context.Background(), set); err != nil {
+		logging.Errorf("处理资源失败: %v", err)
+		return
+	}
+	if opts.collect == nil {
+		log.Printf("完成! 所有资源已保存到: %s", outputDir)
+	}
+}
+
+// defaultPipeline 按命令行选项组装单页的处理流水线：
+// 提取 Source Maps → 保存 → 网络事件 →（递归模式在此交给汇总）→ 报告与索引 → 导出 → 截图 / 主文档 / 安全报告。
+// 只有保存失败会中止，其余阶段出错只记录警告
+func defaultPipeline(spider *crawler.Spider, config *crawler.Config, opts *outputOptions, store *storage.Storage, targetURL, outputDir string) *pipeline.Pipeline {
+	var p pipeline.Pipeline
+	p.Add("sourcemaps", pipeline.ExtractSourceMaps{
+		Batch:   newBatchExtractor(config, opts, targetURL, outputDir),
+		Workers: opts.sourceMapWorkers,
+		Labels:  config.ResourceLabels,
+	}, pipeline.Continue)
+	p.Add("save", pipeline.Save{Store: store}, pipeline.Abort)
+
+	p.Add("network-events", pipeline.StageFunc(func(context.Context, *pipeline.ResourceSet) error {
+		if events := spider.NetworkEvents(); len(events) > 0 {
+			if err := store.SaveNetworkEvents(events); err != nil {
+				return fmt.Errorf("写入 network-events.jsonl 失败: %w", err)
+			}
+		}
+		return nil
+	}), pipeline.Continue)
+
+	// 递归模式：报告、索引和导出在全部页面结束后按汇总结果统一生成
+	if opts.collect != nil {
+		p.Add("collect", pipeline.StageFunc(func(_ context.Context, set *pipeline.ResourceSet) error {
+			opts.collect(set.Resources)
+			return pipeline.ErrStop
+		}), pipeline.Abort)
+		return &p
+	}
+
+	p.Add("report", pipeline.StageFunc(func(ctx context.Context, set *pipeline.ResourceSet) error {
+		result := spider.Result()
+		store.SetNavigation(set.TargetURL, result.FinalURL, result.Navigations, result.NavigatedAway)
+		store.SetSkippedArtifacts(result.Skipped)
+		store.SetDialogs(result.Dialogs)
+		if privacy := result.Privacy; privacy != nil {
+			store.SetPrivacyReport(privacy)
+			if err := store.WritePrivacyReport(privacy); err != nil {
+				logging.Warnf("写入 privacy.json 失败: %v", err)
+			}
+		}
+		if coverage := result.Coverage; coverage != nil {
+			store.SetCoverageReport(coverage)
+			if err := store.WriteCoverageReport(coverage); err != nil {
+				logging.Warnf("写入 coverage.json 失败: %v", err)
+			}
+		}
+		if config.CaptureBrowserIssues {
+			store.SetBrowserIssues(result.BrowserIssues)
+			if err := store.WriteBrowserIssues(result.BrowserIssues); err != nil {
+				logging.Warnf("写入 browser-issues.json 失败: %v", err)
+			}
+		}
+		if opts.hintsReport {
+			store.SetResourceHints(pageHints(spider, set.Resources))
+		}
+		store.SetGroupQueryVariants(opts.groupVariants)
+		store.SetReportPreview(opts.reportPreview)
+		return pipeline.Report{Store: store}.Process(ctx, set)
+	}), pipeline.Continue)
+
+	p.Add("export", pipeline.StageFunc(func(_ context.Context, set *pipeline.ResourceSet) error {
+		exportResources(store, set.Resources, opts, set.TargetURL)
+		return nil
+	}), pipeline.Continue)
+
+	p.Add("screenshots", pipeline.StageFunc(func(context.Context, *pipeline.ResourceSet) error {
+		shots := spider.Result().Screenshots
+		if len(shots) == 0 {
+			return nil
+		}
+		if err := store.SaveScreenshots(shots); err != nil {
+			return fmt.Errorf("写入滚动截图失败: %w", err)
+		}
+		log.Printf("已保存 %d 张滚动截图", len(shots))
+		return nil
+	}), pipeline.Continue)
+
+	if opts.mainOutput != "" {
+		p.Add("main-output", pipeline.StageFunc(func(_ context.Context, set *pipeline.ResourceSet) error {
+			writeMainOutput(spider, set.Resources, opts)
+			return nil
+		}), pipeline.Continue)
+	}
+
+	if opts.securityReport {
+		p.Add("security-report", pipeline.StageFunc(func(_ context.Context, set *pipeline.ResourceSet) error {
+			doc := findDocument(spider, set.Resources)
+			if doc == nil {
+				logging.Warnf("未找到主文档响应，跳过安全响应头报告")
+				return nil
+			}
+			if err := store.WriteSecurityReport(doc); err != nil {
+				return fmt.Errorf("写入 security-headers.txt 失败: %w", err)
+			}
+			return nil
+		}), pipeline.Continue)
+	}
+	return &p
+}
+
+// newBatchExtractor 按配置和输出选项创建 source map 批量提取器
+func newBatchExtractor(config *crawler.Config, opts *outputOptions, targetURL, outputDir string) *sourcemap.BatchExtractor {
+	extractorOpts := []sourcemap.Option{
+		sourcemap.WithMaxSourceMapSize(config.MaxSourceMapSize),
+		sourcemap.WithMapHostAllowlist(config.MapHostAllowlist),
+		sourcemap.WithSameOriginMapsOnly(config.SameOriginMapsOnly),
+		sourcemap.WithSourceFetchWorkers(opts.sourceFetchers),
+		sourcemap.WithMapMetadata(opts.mapMeta),
+		sourcemap.WithHTMLInlineMaps(opts.htmlInlineMaps),
+		sourcemap.WithMapProbing(opts.probeMaps),
+		sourcemap.WithMaxSourceBytes(opts.maxSourceBytes),
+		sourcemap.WithMaxMapSourceBytes(opts.maxMapSourceBytes),
+		sourcemap.WithMaxSources(opts.maxSources),
+		sourcemap.WithMapCache(opts.mapCache),
+	}
+	if opts.sourceTree {
+		extractorOpts = append(extractorOpts,
+			sourcemap.WithSourceTreeDir(filepath.Join(outputDir, sourceTreeDirName)),
+			sourcemap.WithSkipUnchangedSources(config.SkipUnchangedSources))
+	}
+	return sourcemap.NewBatchExtractor(sourcemap.New(targetURL, extractorOpts...), opts.sourceMapRate, opts.sourceMapWorkers)
+}
+
+// writeSummaries 生成报告、resources.json 索引和 -export / -export-git 指定的导出
+func writeSummaries(store *storage.Storage, resources map[string]*crawler.Resource, opts *outputOptions, targetURL string) {
+	store.SetGroupQueryVariants(opts.groupVariants)
+	store.SetReportPreview(opts.reportPreview)
+	if err := store.GenerateReport(resources); err != nil {
+		logging.Warnf("生成报告失败: %v", err)
+	}
+
+	if err := store.WriteIndex(resources); err != nil {
+		logging.Warnf("写入 resources.json 失败: %v", err)
+	}
+
+	exportResources(store, resources, opts, targetURL)
+}
+
+// exportResources 按 -export / -export-git 导出站点地图或 Git 仓库
+func exportResources(store *storage.Storage, resources map[string]*crawler.Resource, opts *outputOptions, targetURL string) {
+	if opts.exportFormat != "" {
+		if err := store.Export(opts.exportFormat, resources); err != nil {
+			logging.Warnf("导出 %s 失败: %v", opts.exportFormat, err)
+		} else {
+			log.Printf("已导出 %s 格式站点地图", opts.exportFormat)
+		}
+	}
+
+	if opts.gitRepo != "" {
+		store.SetTargetURL(targetURL)
+		store.SetGitRemote(opts.gitRemote)
+		if err := store.ExportGitBundle(context.Background(), opts.gitRepo, resources); err != nil {
+			logging.Warnf("Git 导出失败: %v", err)
+		}
+	}
+}
+
+// writeMainOutput 将主文档（原始响应或渲染后的 DOM）写到 -main-output 指定的路径
+func writeMainOutput(spider *crawler.Spider, resources map[string]*crawler.Resource, opts *outputOptions) {
+	result := spider.Result()
+
+	var content []byte
+	if opts.mainOutputRendered {
+		content = []byte(result.RenderedHTML)
+	} else if doc := findDocument(spider, resources); doc != nil {
+		content = doc.Content
+	}
+	if len(content) == 0 {
+		if opts.mainOutputRendered && len(result.Skipped) > 0 {
+			logging.Warnf("DOM 过大未保存渲染结果，跳过写入 %s（详见报告中的 Skipped Artifacts）", opts.mainOutput)
+			return
+		}
+		logging.Warnf("未获取到主文档内容，跳过写入 %s", opts.mainOutput)
+		return
+	}
+
+	if dir := filepath.Dir(opts.mainOutput); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			logging.Warnf("创建目录失败 %s: %v", dir, err)
+			return
+		}
+	}
+	if err := storage.WriteFileAtomic(opts.mainOutput, content, 0644); err != nil {
+		logging.Warnf("写入主文档失败 %s: %v", opts.mainOutput, err)
+		return
+	}
+	log.Printf("主文档已保存到: %s", opts.mainOutput)
+}
+
+// pageHints 从渲染后的 DOM（DOM 过大未保存时退回原始文档）提取资源提示并与抓取结果对照
+func pageHints(spider *crawler.Spider, resources map[string]*crawler.Resource) []storage.ResourceHint {
+	document, pageURL := pageDocument(spider, resources)
+	if base := spider.Result().BaseURL; base != "" {
+		pageURL = base
+	}
+	return storage.ResourceHints(document, pageURL, resources)
+}
+
+// fetchUnloadedReferences 找出页面 DOM 和 CSS 中引用、但爬取期间没有加载的资源（-unloaded-refs），
+// fetch 为 true 时（-fetch-unreferenced）直接下载并加入 resources
+func fetchUnloadedReferences(spider *crawler.Spider, resources map[string]*crawler.Resource, fetch bool) []storage.UnloadedReference {
+	document, pageURL := pageDocument(spider, resources)
+	refs := storage.UnloadedReferences(document, pageURL, spider.Result().BaseURL, resources)
+	if len(refs) == 0 || !fetch {
+		return refs
+	}
+
+	fetched := 0
+	for i, ref := range refs {
+		res, err := spider.DownloadResource(ref.URL, "UnreferencedDownload")
+		if err != nil {
+			logging.Warnf("直接下载未加载的引用失败: %s - %v", ref.URL, err)
+			continue
+		}
+		if res == nil {
+			continue
+		}
+		resources[ref.URL] = res
+		refs[i].Fetched = true
+		fetched++
+	}
+	log.Printf("已直接下载 %d 个引用了但未加载的资源（共 %d 个）", fetched, len(refs))
+	return refs
+}
+
+// pageDocument 返回渲染后的 DOM（DOM 过大未保存时退回原始文档）和用于解析相对地址的页面 URL
+func pageDocument(spider *crawler.Spider, resources map[string]*crawler.Resource) ([]byte, string) {
+	result := spider.Result()
+	document := []byte(result.RenderedHTML)
+	if len(document) == 0 {
+		if doc := findDocument(spider, resources); doc != nil {
+			document = doc.Content
+		}
+	}
+	pageURL := result.FinalURL
+	if pageURL == "" {
+		pageURL = result.DocumentURL
+	}
+	return document, pageURL
+}
+
+// findDocument 返回主文档资源；资源表的键可能经过 CollapsePolling 规范化，按 URL 查找
+func findDocument(spider *crawler.Spider, resources map[string]*crawler.Resource) *crawler.Resource {
+	documentURL := spider.Result().DocumentURL
+	for _, res := range resources {
+		if res.URL == documentURL {
+			return res
+		}
+	}
+	return nil
+}
+
+// newStore 创建存储管理器，flat=true 时不追加 hostname 子目录
+func newStore(outputDir string, flat bool, config *crawler.Config) *storage.Storage {
+	store := storage.New(outputDir)
+	if flat {
+		store = storage.NewFlat(outputDir)
+	}
+	store.SetSuppressEmptyContent(config.SuppressEmptyContent)
+	store.SetSkipStatusCodes(config.SkipStatusCodes)
+	store.SetForwardHeaders(config.ForwardHTTPHeaders)
+	store.SetDataURIExtraction(config.DataURIThreshold, config.RewriteDataURIs)
+	store.SetSkipUnchangedSources(config.SkipUnchangedSources)
+	store.SetPrettyJSON(config.PrettyPrintJSON)
+	store.SetStripSourceMappingURL(config.StripSourceMappingURL)
+	return store
+}
+
+// startFlusher 开启分批落盘时，将爬虫的抓取回调接到 Flusher 上；未开启返回 nil
+func startFlusher(spider *crawler.Spider, config *crawler.Config, opts *outputOptions, outputDir string, flat bool) *storage.Flusher {
+	if opts.flushInterval <= 0 && opts.flushBytes <= 0 {
+		return nil
+	}
+	flusher := storage.NewFlusher(newStore(outputDir, flat, config), opts.flushInterval, opts.flushBytes)
+	spider.OnCapture(flusher.Add)
+	return flusher
+}
+
+// buildBatchOutputDir 根据 URL hostname 生成批量模式的输出目录名
+// 重复 hostname 自动加数字后缀（example.com → example.com_2）
+func buildBatchOutputDir(baseDir, rawURL string, usedDirs map[string]int) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fallbackDir(baseDir, "unknown", usedDirs)
+	}
+	host := strings.ReplaceAll(u.Host, ":", "_") // 端口号 : 替换为 _
+	return fallbackDir(baseDir, host, usedDirs)
+}
+
+func fallbackDir(baseDir, key string, usedDirs map[string]int) string {
+	count := usedDirs[key]
+	usedDirs[key]++
+	if count == 0 {
+		return filepath.Join(baseDir, key)
+	}
+	return filepath.Join(baseDir, fmt.Sprintf("%s_%d", key, count+1))
+}
+
+// writeManifest 将爬取结果清单写入 manifest.json
+func writeManifest(baseDir string, entries []ManifestEntry) {
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		logging.Warnf("无法创建输出目录写 manifest: %v", err)
+		return
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		logging.Warnf("序列化 manifest 失败: %v", err)
+		return
+	}
+
+	path := filepath.Join(baseDir, "manifest.json")
+	if err := storage.WriteFileAtomic(path, data, 0644); err != nil {
+		logging.Warnf("写入 manifest.json 失败: %v", err)
+	}
+}
+
+// readURLsFromFile 从文件读取URL列表，跳过空行和注释，规范化后去重
+func readURLsFromFile(filePath string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开文件: %w", err)
+	}
+	defer file.Close()
+
+	var urls []string
+	seen := make(map[string]int) // normalized URL → 首次出现行号
+	lineNum := 0
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		normalized, err := normalizeURL(line)
+		if err != nil {
+			logging.Warnf("第 %d 行 URL 无效，已跳过: %s (%v)", lineNum, line, err)
+			continue
+		}
+
+		if firstLine, dup := seen[normalized]; dup {
+			logging.Warnf("第 %d 行与第 %d 行重复，已跳过: %s", lineNum, firstLine, line)
+			continue
+		}
+
+		seen[normalized] = lineNum
+		urls = append(urls, normalized)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("文件中没有有效的URL")
+	}
+
+	return urls, nil
+}
+
+// normalizeURL 规范化 URL：统一 scheme/host 大小写，补全路径，去掉默认端口
+func normalizeURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("scheme 不支持: %s", u.Scheme)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	// 去掉默认端口（80/443）
+	if (u.Scheme == "http" && strings.HasSuffix(u.Host, ":80")) ||
+		(u.Scheme == "https" && strings.HasSuffix(u.Host, ":443")) {
+		u.Host = u.Host[:strings.LastIndex(u.Host, ":")]
+	}
+	// 空路径补 /，但不强制末尾 /（保留原始路径语义）
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u.String(), nil
+}
+
+// splitList 解析逗号分隔的列表，忽略空项
+func splitList(s string) []string {
+	var items []string
+	for item := range strings.SplitSeq(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// showCrawlUsage crawl 子命令帮助（省略子命令时同样适用）
+func showCrawlUsage() {
+	fmt.Fprintf(os.Stderr, `Spider - 浏览器模拟爬虫工具
+
+用法:
+  spider [crawl] -url <目标URL> [选项]
+  spider [crawl] -file <URL文件> [选项]
+
+crawl 为默认子命令，可省略。其他子命令见 spider help。
+
+选项:
+  -url string        目标网页URL（与 -file 二选一）
+  -file string       URL文件路径，每行一个URL（与 -url 二选一）
+  -output string     输出目录 (默认 "./output")
+  -timestamp-dir     每次运行输出到 <输出目录>/<时间戳>/（如 2026-01-02T15-04-05Z），
+                     并将 <输出目录>/latest 符号链接指向最近一次运行，适合定期归档
+  -timestamp-format string
+                     -timestamp-dir 的目录名格式，Go 时间格式，按 UTC
+                     (默认 "2006-01-02T15-04-05Z")
+  -timeout int       爬取超时时间，单位秒 (默认 30)
+  -idle-timeout int  网络空闲等待上限，单位秒 (默认 10)；
+                     取代固定延迟，检测到连续 2s 无新资源则提前结束
+  -budget duration   单页总时间预算，如 20s：总超时取该值，滚动前等待 5%%、
+                     滚动 15%%、事件与空闲等待上限 30%%（至少 3s），其余留给导航和
+                     响应体获取；同时指定 -timeout、-idle-timeout、-settle-delay、
+                     -scroll-duration 时以单项参数为准
+  -retry int         失败重试次数，指数退避 (默认 2)
+  -retry-on-status value
+                     主文档返回该状态码（如 503）时不判失败，在同一 Tab 中等待后
+                     重新导航，可多次使用或逗号分隔；用尽后该 URL 判为失败且不再整页重试
+  -retry-status-attempts int
+                     -retry-on-status 的重新导航次数 (默认 3)
+  -retry-status-delay duration
+                     每次重新导航前的等待，固定间隔不退避 (默认 3s)
+  -cookie string     Cookie字符串，格式: "key1=value1; key2=value2"
+  -cookie-cross-site 注入的 Cookie 设为 SameSite=None; Secure，目标页的跨站 iframe、
+                     跨域 XHR 等认证接口也能带上（Secure Cookie 只随 HTTPS 请求发送）
+  -follow-client-redirects
+                     页面加载后自己跨源跳转（location.replace、meta refresh 等）时
+                     继续抓取，以跳转后的页面为主文档；默认在跳转处停止抓取，只保留
+                     跳转前的资源。报告开头显示 输入 URL → 最终 URL 和导航链
+  -header string     自定义Header，格式: "Key:Value"（可多次使用）
+  -proxy string      HTTP/SOCKS5代理地址，如 "http://127.0.0.1:8080"
+  -proxy-pac string  PAC 文件地址，如 "http://wpad/wpad.dat"（也可用 file:// 本地文件），
+                     由浏览器按目标 URL 选择代理，适合按路由分流的企业网络；不能与
+                     -proxy 同时使用。浏览器之外的备用下载和 source map 下载不经过 PAC
+  -ua string         自定义 User-Agent
+  -real-ua           未指定 -ua 时按实际 Chrome 主版本和当前系统生成普通桌面版 UA，
+                     并同步 Sec-CH-UA 系列 Client Hints (默认 true)；
+                     -real-ua=false 保留含 HeadlessChrome 的默认 UA
+  -ua-rotation value 批量模式下按 URL 的输入顺序轮流使用的 User-Agent（可多次使用），
+                     覆盖 -ua；每个 URL 实际使用的值写入 manifest.json 的 user_agent。
+                     单 URL 模式使用第一个
+  -chrome-path string Chrome/Chromium 可执行文件路径（默认自动搜索）
+  -startup-delay duration
+                     浏览器启动完成后额外等待的时间，如 2s（不计入 -timeout，默认 0）
+  -wait-until string 导航后等待的页面事件 (默认 load)：commit（主框架收到响应、提交文档）、
+                     domcontentloaded（HTML 解析完成）、load（含图片样式等子资源）、
+                     networkidle0 / networkidle2（500ms 内进行中的请求为 0 / 不超过 2 个，
+                     最多等 -idle-timeout）。服务端渲染的页面用 load；SPA 用
+                     networkidle0（有长轮询时用 networkidle2）等首屏接口返回；
+                     JSON 接口等只需响应本身的地址用 commit
+  -settle-delay duration
+                     -wait-until 事件触发后、滚动之前的固定等待，如 3s，
+                     用于首屏脚本较慢的站点 (默认 0，仅依赖网络空闲检测)
+  -scroll-duration duration
+                     滚动触发懒加载的总等待时间，按比例分到 5 个滚动步骤 (默认 3.9s)
+  -concurrency int   并发数，批量爬取时生效 (默认 1)
+  -per-origin-concurrency int
+                     批量模式下同一可注册域名（如 example.com）的最大并发数，
+                     调度器会交替处理不同域名以保持 worker 忙碌 (默认 2，0 不限制)
+  -session           会话模式：批量爬取时只启动一个浏览器，按文件顺序逐个爬取，
+                     前面页面（如登录页）设置的 Cookie 延续到后续 URL；
+                     结果仍按 URL 分目录保存，忽略 -concurrency
+  -headless bool     无头模式 (默认 true)
+  -keep-open         抓取完成后保持浏览器窗口打开 (隐含 -headless=false)，
+                     按回车后关闭并保存，期间手动操作加载的资源一并保存；
+                     不能与 -concurrency / -recursion-workers 大于 1 同时使用
+  -export-session string
+                     爬取结束后（-keep-open 时为按回车关闭后）将全部 Cookie（含 HttpOnly）
+                     和页面的 localStorage 导出到 JSON 文件（权限 0600，仅单 URL 模式）；
+                     可先 -keep-open 手动登录，再用 -import-session 做无头 / 批量爬取
+  -import-session string
+                     爬取前从 -export-session 导出的文件恢复会话：导航前写入 Cookie
+                     （已过期的跳过），localStorage 在页面脚本执行前写入（不覆盖已有的键）
+  -collapse-polling  折叠仅缓存破坏参数不同的轮询响应（如 /api/poll?ts=...），
+                     只保存首个响应，报告中记录折叠次数和最后出现时间
+  -cache-busters string
+                     缓存破坏参数列表，逗号分隔 (默认 "ts,_,cb,t,timestamp,nocache")
+  -forward-headers string
+                     resources.json 每条记录保留的响应头，逗号分隔、不区分大小写，
+                     如 Cache-Control,ETag,Strict-Transport-Security；默认写入全部响应头
+  -path-prefix string
+                     只保留 URL 路径以此开头的资源，如 /docs/，用于镜像站点的一部分；
+                     配合 -depth 时只跟随该路径下的链接
+  -block-domains string
+                     在浏览器中拦截的域名，逗号分隔，子域名一并拦截
+  -no-tracking       拦截内置的常见统计、广告和会话录制域名（Google Analytics、
+                     GTM、DoubleClick、Hotjar、Clarity、Segment、百度统计等）及
+                     /g/collect、matomo.php 等上报 URL，详见 README
+  -tracking-allow string
+                     -no-tracking 预设中仍然放行的域名，逗号分隔
+  -ignore-list string
+                     抓到后不保存的追踪地址清单，逗号分隔：预设 analytics（统计与会话录制）、
+                     advertising（广告）、social（社交像素），或自定义清单文件路径（每行一个
+                     域名或 * 通配的 URL 模式）；与 -no-tracking 不同，请求照常发出、脚本照常执行
+  -capture-workers   附加到 Web Worker / 跨进程 iframe，抓取其中加载的脚本和请求，
+                     报告中标注资源来源上下文
+  -csp-endpoints     从所有资源的 Content-Security-Policy 头中提取 report-uri /
+                     report-to 指向的上报地址（常暴露内部基础设施），只记录不请求，
+                     写入 resources.json 的 report_endpoints 和报告
+  -group-query-variants
+                     报告中把路径相同、仅查询字符串不同的资源（带版本号或缓存破坏
+                     参数的变体）合并为一个逻辑资源，显示变体数和总大小；
+                     resources.json 仍逐个列出每个变体
+  -report-preview int
+                     报告的资源详情中为每个文本资源（JS/CSS/JSON/HTML）附带前 N 字节的
+                     响应体预览（不可打印字符转义），二进制资源标为 [binary]；
+                     所有预览合计不超过 1MB (默认 0，不附带)
+  -source-tree       按 source map 中 sources 的原始路径把源文件另存到输出目录的
+                     source-tree/，还原项目目录结构（webpack:///./src/App.tsx →
+                     source-tree/src/App.tsx），可直接用编辑器和 linter 打开
+  -only-new-sources  重复抓取同一站点到同一输出目录时，跳过与已有文件内容相同的
+                     源文件（含 source-tree/），只写新出现或有变化的，便于比较两次
+                     部署之间的差异；写入和跳过的数量列入报告的 Source Files
+  -pretty-json       保存时将 JSON 接口响应（5MB 以内且能解析）缩进格式化，便于阅读；
+                     默认保留原始内容。无论是否开启，resources.json 都记录 JSON 响应的
+                     顶层键 / 数组长度，报告的 JSON API Responses 按接口列出结构摘要、
+                     示例响应文件和无法解析的响应
+  -strip-sourcemap-url
+                     保存 JS / CSS 时去掉末尾的 sourceMappingURL 注释，不在保存的文件中
+                     暴露 .map 地址；source map 提取仍使用原始内容
+  -security-report   根据主文档的响应头生成 security-headers.txt：列出 Server、
+                     X-Powered-By 等暴露版本信息的头，以及 HSTS、CSP、
+                     X-Frame-Options、X-Content-Type-Options 等安全头，标注缺失项
+                     （递归模式下不生成）
+  -privacy-report    加载完成后读取 Cookie 和跨站 iframe 写入的 localStorage 键，
+                     按可注册域名区分第一方 / 第三方，生成 privacy.json（属性与
+                     有效期，不含值），并在报告中列出第三方项（递归模式下不生成）
+  -coverage          导航前开启 DevTools 的 JS 覆盖率和 CSS 规则使用跟踪，爬取结束后
+                     生成 coverage.json（每个脚本 / 样式表的总字节、已使用字节和
+                     已使用区间），报告的 Coverage 列出未使用字节最多的文件，
+                     用于找出抓取到的 bundle 中的无用代码（递归模式下不生成）
+  -browser-issues    导航前开启 DevTools 的 Log 和 Audits（Issues 面板）域，收集 CSP
+                     违规、混合内容、CORS、Cookie 问题和弃用警告，生成
+                     browser-issues.json，报告的 Browser Issues 按类型汇总，用于
+                     解释资源为何被拦截或缺失（递归模式下不生成）
+  -hints-report      从渲染后的 DOM 中提取 <link rel="preload">、modulepreload、
+                     prefetch、preconnect、dns-prefetch 提示，与抓取到的资源按 URL
+                     （preconnect / dns-prefetch 按主机）对照，在报告的 Resource Hints
+                     中列出，并单独列出声明了却没有加载的 preload（递归模式下不生成）
+  -unloaded-refs     从渲染后的 DOM（src、href、srcset、poster、style）和抓到的 CSS
+                     （url()、@import）中收集引用，按所在文档解析相对地址，在报告的
+                     Referenced But Not Loaded 中按类型列出爬取期间没有加载的资源
+                     （功能开关后的代码、失效链接、未触发的懒加载等；递归模式下不生成）
+  -fetch-unreferenced
+                     用 HTTP 客户端直接下载上述未加载的资源并加入抓取结果，
+                     隐含 -unloaded-refs
+  -dismiss-dialogs   自动关闭页面弹出的 alert / confirm / prompt / beforeunload 对话框，
+                     避免页面脚本和导航被阻塞；弹出的类型、内容和处理方式列入报告的
+                     JavaScript Dialogs
+  -dialog-accept     自动关闭时对 confirm / prompt 按确定（prompt 提交默认值），
+                     默认按取消；alert 和 beforeunload 总是确定
+  -mark-after-click value
+                     初始加载空闲后点击该元素（CSS 选择器），点击前设置标记 mark1、
+                     mark2 ...，之后发出的请求记为该标记（resources.json 的 marker、
+                     报告的 Resources by Marker）；可多次使用，按顺序点击
+  -only-after string 只保存该标记及之后标记下的资源，如 -only-after mark1
+  -require-selector string
+                     加载完成后必须存在的 CSS 选择器（如 '#dashboard'），
+                     缺失则该 URL 判为失败且不重试
+  -login-patterns string
+                     登录页 URL 路径特征，逗号分隔 (默认 "/login,/signin,/sign-in,/auth")；
+                     被重定向到登录页、出现密码框或标题含登录关键字时告警
+  -override string   本地覆盖，格式: "URL模式=本地文件"（可多次使用），
+                     命中的资源以本地文件内容保存，URL 模式支持 * 通配
+  -ca-cert string    备用 HTTP 下载额外信任的根证书 PEM 文件（可多次使用）
+  -pin-cert string   备用 HTTP 下载的公钥固定值（可多次使用），格式为
+                     base64(SHA-256(DER 公钥))，证书链中无一命中则拒绝握手
+  -no-fallback       禁止浏览器取不到响应体时直接 HTTP 下载（可能与已认证会话
+                     看到的内容不同），改为在报告中记录失败原因
+  -suppress-empty    跳过响应体为空的资源并逐个记录日志 (默认 true)；
+                     -suppress-empty=false 时为其写入零字节文件，
+                     未取得响应体的资源始终跳过
+  -skip-status string
+                     不保存这些状态码的响应（404 错误页、500 等），逗号分隔，
+                     4xx / 5xx 表示整类，如 404,5xx；仍写入 resources.json（path 为空），
+                     并在报告的 Failed Responses 中列出 (默认全部保存)
+  -sniff-mime        按内容识别声明为 text/plain、application/octet-stream 等
+                     通用类型的资源 (默认 true)：source map 提取和文件扩展名使用
+                     识别出的类型，报告列出声明与内容不符的资源
+  -body-timeout int  单个响应体从浏览器获取的超时，单位秒 (默认 15)；页面超时后
+                     仍在获取的响应体最多再等待这么久，失败的在报告中标注是否超时
+  -flush-interval int
+                     爬取进行中每隔 N 秒把已完成的资源落盘，并刷新
+                     manifest.partial.json 供下游提前处理 (默认 0，关闭)
+  -flush-bytes int   已完成资源累计达到 N 字节时落盘 (默认 0，关闭)
+  -deterministic     确定性模式，用于 CI 中对同一站点做回归比对：固定视口、
+                     冻结 Date / Math.random、prefers-reduced-motion、禁用 CSS 动画
+  -viewport string   视口大小，格式 WIDTHxHEIGHT (确定性模式默认 1366x768)
+  -timing-api        加载完成后读取 Performance Resource Timing API，把每个资源的
+                     DNS / 连接 / TTFB / 传输耗时写入 resources.json 的 timing 和报告；
+                     CDP 未报告的资源（CSS 引用、缓存命中等）补充为无内容的条目
+  -slow-threshold duration
+                     单个资源从发出请求到取得响应体超过该耗时时打印警告，如 5s
+                     (默认 0，不检查)；报告的 Slowest Resources 始终列出最慢的 10 个
+  -max-dom-size string
+                     导航完成后测量序列化 DOM 的大小，超过该值（如 20MB）时跳过滚动截图、
+                     渲染 DOM（-main-output-rendered）和链接提取，网络资源照常抓取；
+                     跳过项记入报告的 Skipped Artifacts 和 manifest.json 的 skipped
+  -load-prefetch     滚动后在页面中 fetch() 一次 <link rel="prefetch">、preload、
+                     modulepreload 提示的资源：浏览器可能推迟或忽略这些提示，
+                     拆分出的路由 chunk 只有进入对应页面才会加载；空闲后仍未抓到的
+                     直接下载补齐，resources.json 的 hint 记录提示类型
+  -inline-scripts    加载完成后把没有 src 的 <script> 内容（初始化配置、写死的密钥等）
+                     保存为合成资源 inline://<host>/inline_script_<N>.js（N 为脚本在
+                     文档中的序号），与外部脚本一样提取 source map
+  -inline-styles     加载完成后把 <style> 内容保存为合成资源
+                     inline://<host>/inline_style_<N>.css，为首屏内联的关键 CSS
+                     提取 source map
+  -navigator-props   加载完成后把页面看到的 navigator 属性（userAgent、platform、
+                     vendor、language、cookieEnabled、webdriver）保存为
+                     diagnostic://<host>/navigator.json，排查 UA / 平台伪装的效果
+  -scroll-screenshots
+                     滚动触发懒加载的每一步后截取当前视口，按顺序保存为
+                     screenshot-1.png、screenshot-2.png ...（递归模式不保存）
+  -dump-network-events
+                     记录全部 network.* CDP 事件到 network-events.jsonl，
+                     用于离线还原请求生命周期、排查资源缺失原因
+  -sourcemap-workers int
+                     并发提取 source map 的 worker 数 (默认 4)
+  -source-fetch-workers int
+                     source map 没有内联 sourcesContent 时，按 sources 中的地址下载
+                     原始源文件的并发数；与 source map 共用限速和主机限制，单个源
+                     下载失败不影响其他源 (默认 0，不下载)
+  -map-meta          每个 source map 旁额外保存 <name>.map.meta.json：声明的 file、
+                     源文件数、是否内联 sourcesContent、下载补全和仍缺失的源文件、
+                     可还原的字节数、names 数和 mappings 行数 / 段数，用于判断 map 是否完整
+  -html-inline-maps  解析抓到的 HTML 页面，从内联 <script> / <style> 末尾的 sourceMappingURL
+                     提取 source map（相对地址按页面 URL 解析），源文件归属到该页面；
+                     不需要 -inline-scripts 的浏览器端读取，replay 子命令同样有效
+  -probe-maps        JS / CSS / WebAssembly 既没有 sourceMappingURL 注释也没有 wasm 的
+                     sourceMappingURL 自定义段时，尝试下载同目录的 <文件名>.map
+                     （如 app.js.map、module.wasm.map）；不存在或不是 source map 时静默跳过
+  -max-source-size string
+                     source map 中单个源文件的大小上限，超出的跳过 (默认 10MB，0 不限制)
+  -max-map-sources-size string
+                     单个 source map 提取的源文件合计大小上限，超出后剩余源文件跳过
+                     (默认 200MB，0 不限制)
+  -max-map-sources int
+                     单个 source map 提取的源文件数上限 (默认 10000，0 不限制)；
+                     因以上限制跳过的数量列在报告的 Truncated Source Maps 中
+  -sourcemap-rate float
+                     source map 下载速率上限，次/秒 (默认 0，不限速)
+  -maps-same-origin  注释中以绝对地址引用的 source map 只在与引用它的资源同源时下载，
+                     避免向第三方主机暴露爬取行为；相对地址和内联 map 总是允许，
+                     跳过的 map 及原因列在报告中 (默认关闭)
+  -map-hosts string  额外允许下载 source map 的主机，逗号分隔，支持 *.example.com；
+                     设置后其他主机的绝对地址 map 同样跳过
+  -label string      资源标签，格式: "key=value"（可多次使用），写入报告、
+                     resources.json 和导出文件，便于流水线按任务关联
+  -main-output string
+                     将主文档另存到指定文件，独立于资源目录树（仅单 URL 模式）
+  -main-output-rendered
+                     -main-output 保存渲染后的 DOM，而非服务器返回的原始 HTML
+  -no-cache          禁用 Chrome 磁盘缓存并在导航前清空缓存，复爬时避免拿到
+                     旧响应掩盖站点更新 (默认使用缓存)
+  -enable-audio      允许页面无用户手势创建和启动 AudioContext，录音 / 音乐类
+                     应用的音频处理脚本才会加载；Linux 服务器上可能还需要
+                     PulseAudio / ALSA 虚拟声卡等额外配置
+  -har string        HAR 回放：不启动浏览器，从 HAR 文件（如 -export zap 生成的
+                     zap.har）还原资源，再走相同的提取和存储流程，用于离线测试
+  -export string     额外导出抓取结果，供后续工具导入：
+                       burp    输出 burp.xml（Burp Suite XML items）
+                       zap     输出 zap.har（含请求体和完整请求头的 HAR）
+                       ndjson  输出 resources.ndjson（每行一个资源：url、status、
+                               mime_type、size、content_b64、headers、labels、timing）
+                       wpr     输出 archive.wprgo（Web Page Replay 归档，可用
+                               wpr replay 离线重放做性能测量，不含合成资源）
+  -export-git string 将爬取结果作为一次提交写入该目录的 Git 裸仓库（不存在则初始化），
+                     文件布局为 host/path，每次爬取追加一个提交，可用 git diff 比较
+  -extract-data-uris string
+                     保存 CSS / HTML 时把解码后超过该大小（如 64KB）的 data URI
+                     （url()、src、srcset 等）抽到输出目录的 _data/ 下，按内容哈希
+                     命名；原位置替换为占位注释，抽取记录写入 resources.json 的
+                     data_uris。默认不抽取
+  -rewrite-data-uris 抽出的 data URI 改写为指向 _data/ 的相对路径（而非占位注释），
+                     保存的页面仍可离线打开
+  -export-chunks string
+                     爬取结束后把整个输出目录打包为 capture.partNNN.tar.zst 分块
+                     （zstd 压缩的 tar）和 capture.index.json，写到输出目录下的
+                     capture-parts/；参数为每块未压缩大小上限，如 512MB、1GB。
+                     单个文件不跨块，用 spider import 校验并还原
+  -git-remote string -export-git 提交后推送到该远程仓库（配置为 origin）
+  -delay float        批量模式下同一主机相邻两次爬取的间隔，单位秒 (默认 0)
+  -ignore-crawl-delay
+                     忽略 robots.txt 的 Crawl-delay（默认遵守，且覆盖 -delay）
+  -max-crawl-delay float
+                     Crawl-delay 上限，单位秒 (默认 0，不封顶)
+  -depth int         递归爬取页面中同主机的 <a href> 链接的层数 (默认 0，只爬目标页)；
+                     所有页面的资源保存到同一目录，报告和索引在结束时统一生成
+  -max-pages int     递归爬取的页面数上限 (默认 0，不限)
+  -recursion-workers int
+                     递归爬取同时处理 frontier 的 Tab 数 (默认 0，与 -concurrency 相同)；
+                     -concurrency 为浏览器进程数，Tab 轮流分布其中，同时受
+                     -per-origin-concurrency 和 -delay 限制；Tab 总数上限 32
+  -strategy string   递归出队顺序: bfs 广度优先 / dfs 深度优先 (默认 "bfs")
+  -resume            从输出目录中的 frontier.jsonl 继续上次中断的递归爬取，
+                     待爬、进行中的 URL 及其深度、来源页都会恢复
+  -max-duration duration
+                     批量爬取整体时间上限，如 30m、2h (默认 0，不限)；到时取消
+                     进行中的爬取，剩余 URL 在 manifest.json 中标记为 not_attempted
+  -notify-webhook string
+                     爬取结束后将汇总 JSON（计数、失败的 URL、输出目录、耗时）POST
+                     到该地址，网络错误和 5xx 重试 3 次；不包含 Cookie、请求头等配置
+  -notify-cmd string 爬取结束后执行的本地命令（sh -c），汇总 JSON 写入其标准输入
+  -notify-on string  发送完成通知的条件: always / failure（有 URL 失败、未尝试或
+                     退出码非 0 时） (默认 "always")
+  -max-source-map-size int
+                     Source Map 大小上限，单位字节 (默认 0，不限制)；
+                     下载前先发 HEAD 请求检查 Content-Length
+  -help              显示此帮助信息
+
+环境变量（未显式指定对应参数时生效）:
+  SPIDER_URL         -url（batch 忽略）
+  SPIDER_OUTPUT      -output
+  SPIDER_TIMEOUT     -timeout，秒数或时长（如 30、45s）
+  SPIDER_PROXY       -proxy
+  SPIDER_COOKIE      -cookie
+  SPIDER_USER_AGENT  -ua
+  SPIDER_CONCURRENCY -concurrency
+  SPIDER_HEADLESS    -headless（true / false）
+  SPIDER_LOGLEVEL    日志级别: debug / info（默认）/ warn（只输出警告和错误）/ error / silent
+
+批量模式输出结构:
+  output/
+  ├── manifest.json          URL → 目录映射 + 成败记录
+  ├── example.com/           按 hostname 命名
+  │   └── index.html
+  ├── another.com/
+  │   └── ...
+  └── another.com_2/         重复 hostname 自动加后缀
+
+示例:
+  spider -url https://example.com
+  spider -url https://example.com -cookie "session=abc123"
+  spider -url https://example.com -header "Authorization:Bearer token"
+  spider -url https://example.com -proxy http://127.0.0.1:8080
+  spider -file urls.txt -concurrency 3 -retry 3
+  spider -url https://example.com -headless=false
+  spider -url https://example.com -main-output page.html -main-output-rendered
+  spider -url https://example.com -override "https://example.com/static/*/app.js=./app.js"
+
+`)
+}
+
+// showBatchUsage batch 子命令帮助
+func showBatchUsage() {
+	fmt.Fprintf(os.Stderr, `用法:
+  spider batch [选项] <URL文件>
+  spider batch -file <URL文件> [选项]
+
+从文件批量爬取，每行一个 URL（# 开头为注释）。即使文件中只有一个 URL，
+也按批量模式输出：每个 hostname 一个子目录，并在输出目录写 manifest.json。
+
+选项与 crawl 相同（-url、-main-output 除外），详见 spider help crawl。
+
+示例:
+  spider batch urls.txt
+  spider batch -concurrency 3 -retry 3 -delay 2 urls.txt
+  spider batch -session -headless=false urls.txt   # 首行为登录页，之后的页面复用登录会话
+
+`)
+}
+
+// showReplayUsage replay 子命令帮助
+func showReplayUsage() {
+	fmt.Fprintf(os.Stderr, `用法:
+  spider replay [选项] -url <页面URL> <HAR文件>
+  spider replay -url <页面URL> -har <HAR文件> [选项]
+
+不启动浏览器、不访问网络，从 HAR 文件（如 -export zap 生成的 zap.har）还原
+资源，再执行与 crawl 相同的 Source Map 提取、存储和报告流程。-url 用于确定
+主文档和输出目录，HAR 中的全部条目都会载入。
+
+选项与 crawl 相同（-file 除外），详见 spider help crawl。
+
+示例:
+  spider replay -url https://example.com -output ./replayed output/example.com/zap.har
+
+`)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"spider/internal/crawler"
+	"spider/internal/crawlertest"
+)
+
+// 单 URL 模式与批量模式一样：疑似登录页的信号写入结果记录，并出现在警告中
+func TestSingleURLLoginSignal(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	saved := crawlSingle
+	t.Cleanup(func() { crawlSingle = saved })
+	crawlSingle = func(string, *crawler.Config, *outputOptions, string) (int, *crawler.CrawlResult, error) {
+		return 1, &crawler.CrawlResult{LikelyLoginPage: true, LoginSignal: "redirect to /login"}, nil
+	}
+
+	config, opts := buildCrawlFlags(t, "-url", "https://app.example.com/dashboard")
+	opts.results = &runResults{}
+	if code := crawlSingleURL("https://app.example.com/dashboard", config, opts, t.TempDir()); code != 0 {
+		t.Fatalf("退出码 %d，应为 0", code)
+	}
+
+	if len(opts.results.entries) != 1 {
+		t.Fatalf("记录了 %d 个结果，应为 1", len(opts.results.entries))
+	}
+	e := opts.results.entries[0]
+	if !e.Success || !e.LikelyLoginPage || e.LoginSignal != "redirect to /login" {
+		t.Errorf("结果记录为 %+v，应标记疑似登录页并带上信号", e)
+	}
+	if out := buf.String(); !strings.Contains(out, "疑似为登录页（redirect to /login）") {
+		t.Errorf("警告中没有登录页信号:\n%s", out)
+	}
+}
+
+// DOM 过大跳过的产物在单 URL 和批量模式下都写入 manifest 的 skipped
+func TestManifestSkippedArtifacts(t *testing.T) {
+	quietLog(t)
+	skipped := []crawler.SkippedArtifact{{Artifact: "links", Reason: crawler.SkipReasonDOMTooLarge, Detail: "60 bytes > MaxDOMBytes 10"}}
+	fake := func(string) (int, *crawler.CrawlResult, error) {
+		return 1, &crawler.CrawlResult{Skipped: skipped}, nil
+	}
+
+	savedSingle, savedPool := crawlSingle, crawlInPool
+	t.Cleanup(func() { crawlSingle, crawlInPool = savedSingle, savedPool })
+	crawlSingle = func(u string, _ *crawler.Config, _ *outputOptions, _ string) (int, *crawler.CrawlResult, error) {
+		return fake(u)
+	}
+	crawlInPool = func(_ context.Context, u string, _ *crawler.Config, _ *outputOptions, _ string) (int, *crawler.CrawlResult, error) {
+		return fake(u)
+	}
+
+	config, opts := buildCrawlFlags(t, "-url", "https://example.com/thread")
+	opts.results = &runResults{}
+	if code := crawlSingleURL("https://example.com/thread", config, opts, t.TempDir()); code != 0 {
+		t.Fatalf("单 URL 模式退出码 %d", code)
+	}
+	if got := opts.results.entries; len(got) != 1 || !slices.Equal(got[0].Skipped, skipped) {
+		t.Errorf("单 URL 模式的记录为 %+v", got)
+	}
+
+	outputDir := t.TempDir()
+	config, opts = buildCrawlFlags(t, "-har", crawlertest.WriteHAR(t, nil), "-output", outputDir)
+	if code := crawlMultipleURLs([]string{"https://example.com/a", "https://example.org/b"}, config, opts, outputDir); code != 0 {
+		t.Fatalf("批量爬取返回 %d", code)
+	}
+	data, err := os.ReadFile(filepath.Join(outputDir, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest []ManifestEntry
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range manifest {
+		if !slices.Equal(e.Skipped, skipped) {
+			t.Errorf("%s 的 skipped 为 %+v", e.URL, e.Skipped)
+		}
+	}
+	if !bytes.Contains(data, []byte(`"reason": "dom-too-large"`)) {
+		t.Errorf("manifest.json 中没有跳过原因:\n%s", data)
+	}
+}
+
+// -max-dom-size 按字节大小解析，非法值或非正数报错
+func TestMaxDOMSizeFlag(t *testing.T) {
+	if config, _ := buildCrawlFlags(t, "-url", "https://example.com", "-max-dom-size", "20MB"); config.MaxDOMBytes != 20<<20 {
+		t.Errorf("-max-dom-size 20MB 解析为 %d", config.MaxDOMBytes)
+	}
+	for _, v := range []string{"0", "huge", "-5MB"} {
+		fs, f := newCrawlFlagSet("crawl", func() {})
+		fs.SetOutput(io.Discard)
+		if err := fs.Parse([]string{"-url", "https://example.com", "-max-dom-size", v}); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := f.build(); err == nil {
+			t.Errorf("-max-dom-size %s 应报错", v)
+		}
+	}
+}
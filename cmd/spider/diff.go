@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"spider/internal/logging"
+	"spider/internal/storage"
+)
+
+// runDiff diff 子命令：按 URL 和内容哈希比较两次爬取的输出目录，列出新增、删除和变化的资源。
+// 两次爬取完全相同时退出码为 0，有差异时为 1，出错时为 2，便于在部署校验脚本中使用
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.Usage = showDiffUsage
+	out := fs.String("out", "", "将对比结果写入该文件而非标准输出")
+	asJSON := fs.Bool("json", false, "以 JSON 格式输出对比结果")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "错误: 需要指定两个输出目录")
+		showDiffUsage()
+		return 2
+	}
+
+	before, err := loadCrawlIndex(fs.Arg(0))
+	if err != nil {
+		logging.Errorf("%v", err)
+		return 2
+	}
+	after, err := loadCrawlIndex(fs.Arg(1))
+	if err != nil {
+		logging.Errorf("%v", err)
+		return 2
+	}
+	diff := storage.DiffIndexes(before, after)
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			logging.Errorf("无法创建 %s: %v", *out, err)
+			return 2
+		}
+		defer f.Close()
+		w = f
+	}
+	if *asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(diff)
+	} else {
+		err = diff.WriteText(w)
+	}
+	if err != nil {
+		logging.Errorf("写入对比结果失败: %v", err)
+		return 2
+	}
+	if *out != "" {
+		log.Printf("对比结果已写入: %s（新增 %d，删除 %d，变化 %d）", *out, len(diff.Added), len(diff.Removed), len(diff.Changed))
+	}
+
+	if diff.Empty() {
+		return 0
+	}
+	return 1
+}
+
+// loadCrawlIndex 读取一次爬取的资源索引：单 URL 输出目录直接读 resources.json；
+// 批量输出目录按 manifest.json 合并各子目录的 resources.json。
+// 子目录按目录名在 dir 下查找，输出目录移动或改名后仍可比较
+func loadCrawlIndex(dir string) ([]storage.IndexEntry, error) {
+	if _, err := os.Stat(filepath.Join(dir, "resources.json")); err == nil {
+		return storage.ReadIndex(dir)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		return nil, fmt.Errorf("%s 中既没有 resources.json 也没有 manifest.json", dir)
+	}
+	var manifest []ManifestEntry
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", filepath.Join(dir, "manifest.json"), err)
+	}
+
+	var entries []storage.IndexEntry
+	for _, m := range manifest {
+		if m.OutputDir == "" || m.NotAttempted {
+			continue
+		}
+		sub := filepath.Join(dir, filepath.Base(m.OutputDir))
+		index, err := storage.ReadIndex(sub)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logging.Warnf("读取 %s 的资源索引失败: %v", sub, err)
+			}
+			continue
+		}
+		entries = append(entries, index...)
+	}
+	return entries, nil
+}
+
+func showDiffUsage() {
+	fmt.Fprintf(os.Stderr, `用法:
+  spider diff [选项] <目录A> <目录B>
+
+按 URL 比较两次爬取的输出目录（resources.json 中的状态码、大小和 SHA-256），
+列出 B 相对 A 新增、删除和内容变化的资源，用于部署前后的回归检查。
+批量爬取的输出目录按 manifest.json 合并各 URL 的结果。
+
+两次爬取相同时退出码为 0，有差异时为 1，出错时为 2。
+
+选项:
+  -out string   将对比结果写入该文件而非标准输出
+  -json         以 JSON 格式输出（added / removed / changed / unchanged）
+
+示例:
+  spider diff ./output-v1 ./output-v2
+  spider diff -json -out diff.json ./before ./after
+
+`)
+}
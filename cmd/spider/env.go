@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/logging"
+)
+
+// 只由命令行读取的 SPIDER_* 环境变量；其余见 crawler.Config.PopulateFromEnvironment
+const (
+	envURL      = "SPIDER_URL"      // -url
+	envOutput   = "SPIDER_OUTPUT"   // -output
+	envLogLevel = "SPIDER_LOGLEVEL" // 日志级别: debug / info（默认）/ warn / error / silent
+)
+
+// applyEnvironment 未显式指定 -url / -output 时取 SPIDER_URL / SPIDER_OUTPUT，并按 SPIDER_LOGLEVEL 设置日志输出。
+// withURL 为 false 时（batch）忽略 SPIDER_URL
+func (f *crawlFlags) applyEnvironment(withURL bool) error {
+	set := make(map[string]bool)
+	f.flags.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+
+	if v, ok := os.LookupEnv(envURL); ok && withURL && !set["url"] && f.urlFile == "" {
+		f.targetURL = v
+	}
+	if v, ok := os.LookupEnv(envOutput); ok && !set["output"] {
+		f.outputDir = v
+	}
+	if v, ok := os.LookupEnv(envLogLevel); ok {
+		return applyLogLevel(v)
+	}
+	return nil
+}
+
+// applyExplicitFlags PopulateFromEnvironment 之后重新应用显式指定的参数，命令行优先于环境变量
+func (f *crawlFlags) applyExplicitFlags(config *crawler.Config) {
+	f.flags.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "timeout":
+			config.Timeout = time.Duration(f.timeout) * time.Second
+		case "proxy":
+			config.Proxy = f.proxy
+		case "cookie":
+			config.Cookies = f.cookie
+		case "ua":
+			config.UserAgent = f.userAgent
+		case "concurrency":
+			config.Concurrency = f.concurrency
+		case "headless":
+			config.Headless = f.headless
+		}
+	})
+}
+
+// applyLogLevel 按级别过滤日志：info 输出全部进度日志；debug 另外输出 slog.Debug 的调试细节；
+// warn / error 只输出 logging.Warnf / Errorf 记录的对应级别及以上的日志；silent 关闭日志
+func applyLogLevel(level string) error {
+	switch level {
+	case "", "info":
+		logging.SetLevel(os.Stderr, slog.LevelInfo)
+	case "debug":
+		logging.SetLevel(os.Stderr, slog.LevelDebug)
+	case "warn":
+		logging.SetLevel(os.Stderr, slog.LevelWarn)
+	case "error":
+		logging.SetLevel(os.Stderr, slog.LevelError)
+	case "silent", "none":
+		logging.SetLevel(nil, 0)
+	default:
+		return fmt.Errorf("%s 应为 debug、info、warn、error 或 silent，当前值: %s", envLogLevel, level)
+	}
+	return nil
+}
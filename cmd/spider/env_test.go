@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+)
+
+// 未显式指定的参数取 SPIDER_* 环境变量，显式指定的参数优先；未设置的变量保持参数默认值
+func TestEnvironmentAndExplicitFlags(t *testing.T) {
+	defaults, _ := buildCrawlFlags(t)
+
+	t.Setenv(crawler.EnvTimeout, "45s")
+	t.Setenv(crawler.EnvProxy, "http://env-proxy:8080")
+	t.Setenv(crawler.EnvCookie, "env=1")
+	t.Setenv(crawler.EnvUserAgent, "env-ua")
+	t.Setenv(crawler.EnvConcurrency, "4")
+	t.Setenv(crawler.EnvHeadless, "false")
+
+	config, _ := buildCrawlFlags(t)
+	if config.Timeout != 45*time.Second || config.Proxy != "http://env-proxy:8080" || config.Cookies != "env=1" ||
+		config.UserAgent != "env-ua" || config.Concurrency != 4 || config.Headless {
+		t.Errorf("环境变量应覆盖默认值: timeout=%v proxy=%q cookie=%q ua=%q concurrency=%d headless=%v",
+			config.Timeout, config.Proxy, config.Cookies, config.UserAgent, config.Concurrency, config.Headless)
+	}
+	if config.IdleTimeout != defaults.IdleTimeout {
+		t.Errorf("没有对应环境变量的参数被改动: IdleTimeout=%v", config.IdleTimeout)
+	}
+
+	// 显式指定的参数优先，即使与参数默认值相同（-headless=true、-concurrency 1）
+	config, _ = buildCrawlFlags(t, "-timeout", "10", "-proxy", "http://cli:3128", "-ua", "cli-ua",
+		"-concurrency", "1", "-headless=true")
+	if config.Timeout != 10*time.Second || config.Proxy != "http://cli:3128" || config.UserAgent != "cli-ua" ||
+		config.Concurrency != 1 || !config.Headless {
+		t.Errorf("显式参数应覆盖环境变量: timeout=%v proxy=%q ua=%q concurrency=%d headless=%v",
+			config.Timeout, config.Proxy, config.UserAgent, config.Concurrency, config.Headless)
+	}
+	if config.Cookies != "env=1" {
+		t.Errorf("未显式指定 -cookie 时应取环境变量，实际 %q", config.Cookies)
+	}
+
+	// 秒数形式的 SPIDER_TIMEOUT 与 -timeout 一致
+	t.Setenv(crawler.EnvTimeout, "90")
+	if config, _ := buildCrawlFlags(t); config.Timeout != 90*time.Second {
+		t.Errorf("SPIDER_TIMEOUT=90 得到 %v", config.Timeout)
+	}
+}
+
+// 格式错误的环境变量使 build 报错，并指出变量名和值；多个错误一起报告
+func TestEnvironmentMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{"超时不是时长", map[string]string{crawler.EnvTimeout: "abc"}, []string{"SPIDER_TIMEOUT", `"abc"`}},
+		{"超时为负数", map[string]string{crawler.EnvTimeout: "-5"}, []string{"SPIDER_TIMEOUT", `"-5"`}},
+		{"无头模式不是布尔值", map[string]string{crawler.EnvHeadless: "maybe"}, []string{"SPIDER_HEADLESS", `"maybe"`}},
+		{"并发数为 0", map[string]string{crawler.EnvConcurrency: "0"}, []string{"SPIDER_CONCURRENCY"}},
+		{"多个错误", map[string]string{crawler.EnvTimeout: "abc", crawler.EnvHeadless: "maybe"},
+			[]string{"SPIDER_TIMEOUT", "SPIDER_HEADLESS"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			fs, f := newCrawlFlagSet("crawl", func() {})
+			fs.SetOutput(io.Discard)
+			if err := fs.Parse(nil); err != nil {
+				t.Fatal(err)
+			}
+			_, _, err := f.build()
+			if err == nil {
+				t.Fatal("格式错误的环境变量应报错")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("错误中缺少 %s: %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+// SPIDER_URL / SPIDER_OUTPUT 只在未显式指定时生效，batch 忽略 SPIDER_URL
+func TestApplyEnvironment(t *testing.T) {
+	t.Setenv(envURL, "https://env.example.com/")
+	t.Setenv(envOutput, "/tmp/env-out")
+
+	parse := func(args ...string) *crawlFlags {
+		fs, f := newCrawlFlagSet("crawl", func() {})
+		fs.SetOutput(io.Discard)
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	f := parse()
+	if err := f.applyEnvironment(true); err != nil {
+		t.Fatal(err)
+	}
+	if f.targetURL != "https://env.example.com/" || f.outputDir != "/tmp/env-out" {
+		t.Errorf("url=%q output=%q，应取环境变量", f.targetURL, f.outputDir)
+	}
+
+	f = parse("-url", "https://cli.example.com/", "-output", "cli-out")
+	if err := f.applyEnvironment(true); err != nil {
+		t.Fatal(err)
+	}
+	if f.targetURL != "https://cli.example.com/" || f.outputDir != "cli-out" {
+		t.Errorf("url=%q output=%q，显式参数应优先", f.targetURL, f.outputDir)
+	}
+
+	f = parse()
+	if err := f.applyEnvironment(false); err != nil {
+		t.Fatal(err)
+	}
+	if f.targetURL != "" {
+		t.Errorf("batch 不应读取 SPIDER_URL，实际 %q", f.targetURL)
+	}
+}
+
+// SPIDER_LOGLEVEL 设置 slog 的级别：warn 过滤普通日志（log.Printf 按 INFO 处理），保留警告
+func TestApplyLogLevel(t *testing.T) {
+	saved, writer, flags := slog.Default(), log.Writer(), log.Flags()
+	t.Cleanup(func() {
+		slog.SetDefault(saved)
+		log.SetOutput(writer)
+		log.SetFlags(flags)
+		slog.SetLogLoggerLevel(slog.LevelInfo)
+	})
+
+	ctx := context.Background()
+	tests := []struct {
+		level       string
+		debug, info bool
+		warn, error bool
+	}{
+		{"debug", true, true, true, true},
+		{"info", false, true, true, true},
+		{"", false, true, true, true},
+		{"warn", false, false, true, true},
+		{"error", false, false, false, true},
+		{"silent", false, false, false, false},
+	}
+	for _, tt := range tests {
+		if err := applyLogLevel(tt.level); err != nil {
+			t.Fatalf("%q: %v", tt.level, err)
+		}
+		h := slog.Default().Handler()
+		got := [4]bool{h.Enabled(ctx, slog.LevelDebug), h.Enabled(ctx, slog.LevelInfo), h.Enabled(ctx, slog.LevelWarn), h.Enabled(ctx, slog.LevelError)}
+		if want := [4]bool{tt.debug, tt.info, tt.warn, tt.error}; got != want {
+			t.Errorf("%q: debug/info/warn/error 启用情况为 %v，应为 %v", tt.level, got, want)
+		}
+	}
+
+	t.Setenv(envLogLevel, "verbose")
+	fs, f := newCrawlFlagSet("crawl", func() {})
+	fs.SetOutput(io.Discard)
+	fs.Parse(nil)
+	if err := f.applyEnvironment(true); err == nil || !strings.Contains(err.Error(), "SPIDER_LOGLEVEL") {
+		t.Errorf("无效的 SPIDER_LOGLEVEL 应报错，实际 %v", err)
+	}
+}
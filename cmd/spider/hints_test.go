@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"spider/internal/crawler"
+	"spider/internal/crawlertest"
+)
+
+// HAR 回放没有渲染后的 DOM：-hints-report 退回从原始主文档提取资源提示，与回放的资源对照后写入报告
+func TestHintsReportEndToEnd(t *testing.T) {
+	quietLog(t)
+	const page = "https://shop.example.com/"
+	doc := `<!DOCTYPE html><html><head>
+<link rel="preload" href="/app.js" as="script">
+<link rel="preload" href="/hero.webp" as="image">
+<link rel="preconnect" href="https://cdn.example.net">
+</head><body><script src="/app.js"></script></body></html>`
+	resources := map[string]*crawler.Resource{
+		page:                              {URL: page, StatusCode: 200, MimeType: "text/html", Content: []byte(doc)},
+		"https://shop.example.com/app.js": {URL: "https://shop.example.com/app.js", StatusCode: 200, MimeType: "application/javascript", Content: []byte("console.log(1)")},
+	}
+	harFile := crawlertest.WriteHAR(t, resources)
+
+	outputDir := t.TempDir()
+	if code := run([]string{"crawl", "-url", page, "-har", harFile, "-output", outputDir, "-hints-report"}); code != 0 {
+		t.Fatalf("spider crawl 返回 %d", code)
+	}
+	report, err := os.ReadFile(filepath.Join(findIndexDir(t, outputDir), "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"Resource Hints (3 declared, 1 matched a load):",
+		"  [preload as=script] https://shop.example.com/app.js: loaded",
+		"  [preload as=image] https://shop.example.com/hero.webp: not loaded",
+		"  [preconnect] https://cdn.example.net: not loaded",
+		"Unused Preloads (declared but never loaded; check the as / crossorigin attributes):\n  https://shop.example.com/hero.webp",
+	} {
+		if !strings.Contains(string(report), line+"\n") {
+			t.Errorf("报告缺少 %q:\n%s", line, report)
+		}
+	}
+}
+
+// -hints-report 需要渲染后的 DOM（脚本插入的提示也在其中），因此开启 CaptureRenderedHTML
+func TestHintsReportFlag(t *testing.T) {
+	config, opts := buildCrawlFlags(t, "-url", "https://example.com", "-hints-report")
+	if !opts.hintsReport || !config.CaptureRenderedHTML {
+		t.Errorf("hintsReport / CaptureRenderedHTML = %v / %v，应都开启", opts.hintsReport, config.CaptureRenderedHTML)
+	}
+	if config, _ := buildCrawlFlags(t, "-url", "https://example.com"); config.CaptureRenderedHTML {
+		t.Error("默认不应开启 CaptureRenderedHTML")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"spider/internal/logging"
+	"spider/internal/storage"
+)
+
+// runImport import 子命令：校验 -export-chunks 生成的分块并还原目录树
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.Usage = showImportUsage
+	dir := fs.String("dir", "", "分块所在目录（含 capture.index.json）")
+	out := fs.String("out", "", "还原到的目录")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if *dir == "" || *out == "" {
+		fmt.Fprintln(os.Stderr, "错误: 必须指定 -dir 和 -out")
+		showImportUsage()
+		return 1
+	}
+
+	index, err := storage.ImportChunks(*dir, *out)
+	if err != nil {
+		logging.Errorf("还原失败: %v", err)
+		return 1
+	}
+	files := 0
+	for _, chunk := range index.Chunks {
+		files += len(chunk.Files)
+	}
+	log.Printf("还原完成: %d 个分块、%d 个文件 → %s", len(index.Chunks), files, *out)
+	return 0
+}
+
+func showImportUsage() {
+	fmt.Fprintf(os.Stderr, `用法:
+  spider import -dir <分块目录> -out <还原目录>
+
+还原 -export-chunks 生成的 capture.partNNN.tar.zst 分块。先按 capture.index.json
+校验全部分块的 SHA-256（缺块或损坏时不写任何文件），再逐块解包，并核对每个
+还原文件的大小和校验值。
+
+选项:
+  -dir string   分块所在目录（含 capture.index.json）
+  -out string   还原到的目录
+
+示例:
+  spider import -dir ./output/capture-parts -out ./restored
+
+`)
+}
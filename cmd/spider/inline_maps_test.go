@@ -0,0 +1,47 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"spider/internal/crawlertest"
+	"spider/internal/testsite"
+)
+
+// 回放测试站点的 /bootstrap.html：开启 -html-inline-maps 后，内联脚本引用的 bootstrap.js.map 中的
+// src/bootstrap.ts 被提取并归属到该页面；不开启时不会被发现
+func TestHTMLInlineMapsEndToEnd(t *testing.T) {
+	quietLog(t)
+	site := testsite.New()
+	defer site.Close()
+	page := site.Resolve("/bootstrap.html")
+	harFile := crawlertest.RecordHAR(t, page)
+
+	crawl := func(extra ...string) map[string][]string {
+		outputDir := t.TempDir()
+		if code := run(append([]string{"crawl", "-url", page, "-har", harFile, "-output", outputDir}, extra...)); code != 0 {
+			t.Fatalf("spider crawl 返回 %d", code)
+		}
+		index, err := loadCrawlIndex(findIndexDir(t, outputDir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages := make(map[string][]string)
+		for _, e := range index {
+			pages[e.URL] = e.Pages
+		}
+		return pages
+	}
+
+	source := site.Resolve("/src/bootstrap.ts")
+	pages, ok := crawl("-html-inline-maps")[source]
+	if !ok {
+		t.Fatalf("开启 -html-inline-maps 后 resources.json 中没有 %s", source)
+	}
+	if !slices.Contains(pages, page) {
+		t.Errorf("%s 的 pages 为 %v，应包含 %s", source, pages, page)
+	}
+	if _, ok := crawl()[source]; ok {
+		t.Errorf("未开启 -html-inline-maps 时不应提取出 %s", source)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"spider/internal/crawler"
+	"spider/internal/crawlertest"
+)
+
+// -browser-issues 在导航前开启问题收集；HAR 回放不经过浏览器，browser-issues.json 为空数组，报告中为 (none)
+func TestBrowserIssuesFlag(t *testing.T) {
+	config, _ := buildCrawlFlags(t, "-url", "https://example.com", "-browser-issues")
+	if !config.CaptureBrowserIssues {
+		t.Error("-browser-issues 应开启 CaptureBrowserIssues")
+	}
+	if config, _ := buildCrawlFlags(t, "-url", "https://example.com"); config.CaptureBrowserIssues {
+		t.Error("默认不应开启 CaptureBrowserIssues")
+	}
+
+	quietLog(t)
+	const page = "https://shop.example.com/"
+	har := crawlertest.WriteHAR(t, map[string]*crawler.Resource{
+		page: {URL: page, StatusCode: 200, MimeType: "text/html", Content: []byte("<html><body>hi</body></html>")},
+	})
+	outputDir := t.TempDir()
+	if code := run([]string{"crawl", "-url", page, "-har", har, "-output", outputDir, "-browser-issues"}); code != 0 {
+		t.Fatalf("spider crawl 返回 %d", code)
+	}
+	dir := findIndexDir(t, outputDir)
+	if data, err := os.ReadFile(filepath.Join(dir, "browser-issues.json")); err != nil || string(data) != "[]" {
+		t.Errorf("browser-issues.json 为 %q（%v），应为 []", data, err)
+	}
+	report, err := os.ReadFile(filepath.Join(dir, "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(report), "Browser Issues (0 distinct, 0 occurrences):\n  (none)\n") {
+		t.Errorf("报告中缺少 Browser Issues 一节:\n%s", report)
+	}
+}
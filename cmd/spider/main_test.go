@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/crawlertest"
+	"spider/internal/storage"
+	"spider/internal/testsite"
+)
+
+// stubCommands 把子命令表替换为只记录调用的版本，测试结束后恢复
+func stubCommands(t *testing.T) *[]string {
+	t.Helper()
+	saved := commands
+	t.Cleanup(func() { commands = saved })
+
+	var calls []string
+	commands = slices.Clone(saved)
+	for i := range commands {
+		name := commands[i].name
+		commands[i].run = func(args []string) int {
+			calls = append(calls, name)
+			calls = append(calls, args...)
+			return 0
+		}
+	}
+	return &calls
+}
+
+// 旧版只带参数、不带子命令的调用方式按 crawl 处理，参数原样传给 crawl
+func TestDispatchLegacyInvocationToCrawl(t *testing.T) {
+	tests := [][]string{
+		{"-url", "https://example.com"},
+		{"-file", "urls.txt", "-concurrency", "3", "-timeout", "40"},
+		{"-url", "https://example.com", "-output", "./result", "-proxy", "http://127.0.0.1:8080"},
+		{"-help"},
+		{},
+	}
+	for _, args := range tests {
+		calls := stubCommands(t)
+		run(args)
+		if want := append([]string{"crawl"}, args...); !slices.Equal(*calls, want) {
+			t.Errorf("run(%q) 调用了 %q，应为 %q", args, *calls, want)
+		}
+	}
+}
+
+func TestDispatchSubcommands(t *testing.T) {
+	for _, name := range []string{"crawl", "batch", "replay", "check", "doctor", "import", "diff", "serve", "version", "help"} {
+		calls := stubCommands(t)
+		run([]string{name, "-x", "arg"})
+		if want := []string{name, "-x", "arg"}; !slices.Equal(*calls, want) {
+			t.Errorf("run(%q) 调用了 %q，应为 %q", name, *calls, want)
+		}
+	}
+}
+
+// README 中列出的旧版参数在隐式的 crawl 下仍按原含义解析
+func TestLegacyFlagsParse(t *testing.T) {
+	config, _ := buildCrawlFlags(t,
+		"-url", "https://example.com",
+		"-output", "./result",
+		"-timeout", "60",
+		"-idle-timeout", "5",
+		"-retry", "3",
+		"-concurrency", "4",
+		"-proxy", "http://127.0.0.1:8080",
+		"-cookie", "session=abc; token=xyz",
+		"-header", "Authorization:Bearer TOKEN",
+		"-header", "X-Custom:value",
+		"-ua", "legacy-agent",
+		"-headless=false",
+	)
+	if config.Timeout != 60*time.Second || config.IdleTimeout != 5*time.Second {
+		t.Errorf("超时 = %v / %v，应为 60s / 5s", config.Timeout, config.IdleTimeout)
+	}
+	if config.MaxRetry != 3 || config.Concurrency != 4 {
+		t.Errorf("retry / concurrency = %d / %d，应为 3 / 4", config.MaxRetry, config.Concurrency)
+	}
+	if config.Proxy != "http://127.0.0.1:8080" || config.Cookies != "session=abc; token=xyz" || config.UserAgent != "legacy-agent" {
+		t.Errorf("proxy / cookie / ua 解析错误: %q / %q / %q", config.Proxy, config.Cookies, config.UserAgent)
+	}
+	if config.Headers["Authorization"] != "Bearer TOKEN" || config.Headers["X-Custom"] != "value" {
+		t.Errorf("headers = %v", config.Headers)
+	}
+	if config.Headless {
+		t.Error("-headless=false 未生效")
+	}
+}
+
+func TestDeterministicFlags(t *testing.T) {
+	config, _ := buildCrawlFlags(t, "-url", "https://example.com", "-deterministic", "-viewport", "800x600")
+	if !config.Deterministic || config.ViewportWidth != 800 || config.ViewportHeight != 600 {
+		t.Errorf("Deterministic / 视口 = %v / %dx%d，应为 true / 800x600", config.Deterministic, config.ViewportWidth, config.ViewportHeight)
+	}
+
+	for _, viewport := range []string{"800", "0x600", "-1x5", "widexhigh"} {
+		fs, f := newCrawlFlagSet("crawl", func() {})
+		fs.SetOutput(io.Discard)
+		if err := fs.Parse([]string{"-url", "https://example.com", "-viewport", viewport}); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := f.build(); err == nil {
+			t.Errorf("-viewport %s 应报错", viewport)
+		}
+	}
+}
+
+// -sourcemap-rate 默认不限速，批量提取器不创建令牌桶；显式设置时才限速
+func TestSourceMapRateFlag(t *testing.T) {
+	_, opts := buildCrawlFlags(t, "-url", "https://example.com")
+	if opts.sourceMapRate != 0 {
+		t.Errorf("-sourcemap-rate 默认值为 %v，应为 0", opts.sourceMapRate)
+	}
+	_, opts = buildCrawlFlags(t, "-url", "https://example.com", "-sourcemap-rate", "2.5")
+	if opts.sourceMapRate != 2.5 {
+		t.Errorf("-sourcemap-rate 2.5 解析为 %v", opts.sourceMapRate)
+	}
+}
+
+// 不带子命令的 spider -url ... -output ... 完整跑通（HAR 回放，不需要浏览器），输出与 spider crawl 相同
+func TestLegacyInvocationEndToEnd(t *testing.T) {
+	quietLog(t)
+	site := testsite.New()
+	defer site.Close()
+	harFile := crawlertest.RecordHAR(t, site.Resolve("/"), site.Resolve("/app.js"))
+
+	legacyDir, crawlDir := t.TempDir(), t.TempDir()
+	if code := run([]string{"-url", site.Resolve("/"), "-har", harFile, "-output", legacyDir}); code != 0 {
+		t.Fatalf("spider -url ... 返回 %d", code)
+	}
+	if code := run([]string{"crawl", "-url", site.Resolve("/"), "-har", harFile, "-output", crawlDir}); code != 0 {
+		t.Fatalf("spider crawl -url ... 返回 %d", code)
+	}
+
+	legacy, err := loadCrawlIndex(findIndexDir(t, legacyDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	explicit, err := loadCrawlIndex(findIndexDir(t, crawlDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := storage.DiffIndexes(legacy, explicit); !d.Empty() {
+		t.Errorf("隐式 crawl 与 spider crawl 的结果不同: 新增 %d，删除 %d，变化 %d", len(d.Added), len(d.Removed), len(d.Changed))
+	}
+	if len(legacy) < 3 { // 首页、app.js 和 source map 提取的源文件
+		t.Errorf("resources.json 只有 %d 条记录", len(legacy))
+	}
+}
+
+// findIndexDir 返回 root 下包含 resources.json 的目录（单 URL 模式按主机名建子目录）
+func findIndexDir(t *testing.T, root string) string {
+	t.Helper()
+	var dir string
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err == nil && d.Name() == "resources.json" && dir == "" {
+			dir = filepath.Dir(path)
+		}
+		return nil
+	})
+	if dir == "" {
+		t.Fatalf("%s 中没有 resources.json", root)
+	}
+	return dir
+}
+
+func TestServeHandler(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := newServeHandler(dir); err == nil {
+		t.Fatal("没有 resources.json 的目录应报错")
+	}
+
+	os.MkdirAll(filepath.Join(dir, "js"), 0o755)
+	os.WriteFile(filepath.Join(dir, "js", "app.js"), []byte("console.log(1)"), 0o644)
+	os.WriteFile(filepath.Join(dir, "resources.json"), []byte(`[{"url":"https://example.com/js/app.js","path":"js/app.js","status":200,"mime_type":"application/javascript","size":14}]`), 0o644)
+	handler, err := newServeHandler(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/js/app.js", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "console.log(1)" {
+		t.Errorf("GET /js/app.js = %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/_index", nil))
+	var entries []storage.IndexEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil || len(entries) != 1 || entries[0].Path != "js/app.js" {
+		t.Errorf("GET /_index = %d %s (%v)", rec.Code, rec.Body.String(), err)
+	}
+}
+
+// -privacy-report 开启 CapturePrivacy，默认关闭
+func TestPrivacyReportFlag(t *testing.T) {
+	if config, _ := buildCrawlFlags(t, "-url", "https://example.com"); config.CapturePrivacy {
+		t.Error("CapturePrivacy 默认应关闭")
+	}
+	if config, _ := buildCrawlFlags(t, "-url", "https://example.com", "-privacy-report"); !config.CapturePrivacy {
+		t.Error("-privacy-report 没有开启 CapturePrivacy")
+	}
+}
+
+// -mark-after-click 可多次使用，按顺序命名为 mark1、mark2 ...；-only-after 须指向其中的标记
+func TestClickMarkerFlags(t *testing.T) {
+	config, _ := buildCrawlFlags(t, "-url", "https://example.com", "-mark-after-click", "#open", "-mark-after-click", ".tab:nth-child(2)", "-only-after", "mark2")
+	want := []crawler.ClickMarker{{Label: "mark1", Selector: "#open"}, {Label: "mark2", Selector: ".tab:nth-child(2)"}}
+	if !slices.Equal(config.ClickMarkers, want) || config.OnlyAfterMarker != "mark2" {
+		t.Errorf("ClickMarkers / OnlyAfterMarker = %v / %q", config.ClickMarkers, config.OnlyAfterMarker)
+	}
+
+	fs, f := newCrawlFlagSet("crawl", func() {})
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse([]string{"-url", "https://example.com", "-only-after", "mark1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := f.build(); err == nil {
+		t.Error("没有 -mark-after-click 时 -only-after 应报错")
+	}
+}
+
+// -real-ua 默认开启，-real-ua=false 保留默认 UA
+func TestRealUAFlag(t *testing.T) {
+	if config, _ := buildCrawlFlags(t, "-url", "https://example.com"); !config.RealUserAgent {
+		t.Error("RealUserAgent 默认应开启")
+	}
+	if config, _ := buildCrawlFlags(t, "-url", "https://example.com", "-real-ua=false"); config.RealUserAgent {
+		t.Error("-real-ua=false 没有关闭 RealUserAgent")
+	}
+}
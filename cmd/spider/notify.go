@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"spider/internal/logging"
+	"spider/internal/notify"
+)
+
+// notifyTimeout 发送完成通知（含 webhook 重试和通知命令）的总时间上限
+const notifyTimeout = 2 * time.Minute
+
+// runResults 本次运行中各 URL 的结果，供完成通知汇总；nil 时不记录
+type runResults struct {
+	mu      sync.Mutex
+	entries []ManifestEntry
+}
+
+func (r *runResults) add(entries ...ManifestEntry) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entries...)
+}
+
+// sendNotification 设置了 -notify-webhook / -notify-cmd 时汇总本次运行并发送通知。
+// 通知失败只记录日志，不影响退出码
+func sendNotification(command string, started time.Time, code int, opts *outputOptions, outputDir string) {
+	if !opts.notifier.Enabled() {
+		return
+	}
+	finished := time.Now()
+	summary := &notify.Summary{
+		Command:         command,
+		OutputDir:       outputDir,
+		StartedAt:       started,
+		FinishedAt:      finished,
+		DurationSeconds: finished.Sub(started).Seconds(),
+		ExitCode:        code,
+	}
+	opts.results.mu.Lock()
+	for _, e := range opts.results.entries {
+		summary.Total++
+		switch {
+		case e.NotAttempted:
+			summary.NotAttempted++
+		case e.Success:
+			summary.Succeeded++
+		default:
+			summary.Failed++
+			summary.AddFailure(e.URL, e.Error)
+		}
+		if e.LikelyLoginPage {
+			summary.LikelyLoginPages++
+		}
+	}
+	opts.results.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if err := opts.notifier.Notify(ctx, summary); err != nil {
+		logging.Warnf("发送完成通知失败: %v", err)
+	}
+}
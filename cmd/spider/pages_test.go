@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/crawlertest"
+	"spider/internal/storage"
+	"spider/internal/testsite"
+)
+
+// readSiteIndex 读取递归爬取写在 outputDir/<host> 下的 resources.json，按 URL 建表
+func readSiteIndex(t *testing.T, siteDir string) map[string]storage.IndexEntry {
+	t.Helper()
+	index, err := storage.ReadIndex(siteDir)
+	if err != nil {
+		t.Fatalf("读取 resources.json 失败: %v", err)
+	}
+	entries := make(map[string]storage.IndexEntry)
+	for _, e := range index {
+		entries[e.URL] = e
+	}
+	return entries
+}
+
+// 两个页面的递归爬取：各页面独有的资源只列出自己的页面，两页共用的样式和脚本列出两个页面，
+// 报告按页面汇总资源数、字节数和最慢的资源
+func TestRecursivePageAttribution(t *testing.T) {
+	quietLog(t)
+	const (
+		home  = "https://shop.example.com/"
+		about = "https://shop.example.com/about"
+	)
+	assets := map[string][]string{
+		home:  {home, "https://shop.example.com/style.css", "https://shop.example.com/app.js", "https://shop.example.com/hero.png"},
+		about: {about, "https://shop.example.com/style.css", "https://shop.example.com/app.js", "https://shop.example.com/team.jpg"},
+	}
+
+	saved := crawlInPool
+	t.Cleanup(func() { crawlInPool = saved })
+	crawlInPool = func(_ context.Context, targetURL string, _ *crawler.Config, opts *outputOptions, _ string) (int, *crawler.CrawlResult, error) {
+		// 与真实爬取一样，每个页面得到自己的 Resource 实例，Pages 为当时的主框架 URL
+		resources := make(map[string]*crawler.Resource)
+		for i, u := range assets[targetURL] {
+			resources[u] = &crawler.Resource{
+				URL:        u,
+				StatusCode: 200,
+				MimeType:   "text/plain",
+				Content:    []byte(strings.Repeat("x", 100*(i+1))),
+				Headers:    map[string]string{},
+				Pages:      []string{targetURL},
+				Latency:    time.Duration(i+1) * 10 * time.Millisecond,
+			}
+		}
+		opts.collect(resources)
+		result := &crawler.CrawlResult{}
+		if targetURL == home {
+			result.Links = []string{about}
+		}
+		return 1, result, nil
+	}
+
+	outputDir := t.TempDir()
+	config, opts := buildCrawlFlags(t, "-har", crawlertest.WriteHAR(t, nil), "-output", outputDir, "-depth", "1")
+	if code := crawlRecursive(home, config, opts, outputDir); code != 0 {
+		t.Fatalf("递归爬取返回 %d", code)
+	}
+
+	siteDir := filepath.Join(outputDir, "shop.example.com")
+	entries := readSiteIndex(t, siteDir)
+	want := map[string][]string{
+		home:                                 {home},
+		about:                                {about},
+		"https://shop.example.com/hero.png":  {home},
+		"https://shop.example.com/team.jpg":  {about},
+		"https://shop.example.com/style.css": {home, about},
+		"https://shop.example.com/app.js":    {home, about},
+	}
+	if len(entries) != len(want) {
+		t.Errorf("resources.json 有 %d 条，应为 %d", len(entries), len(want))
+	}
+	for u, pages := range want {
+		got := slices.Clone(entries[u].Pages)
+		slices.Sort(got)
+		if !slices.Equal(got, pages) {
+			t.Errorf("%s 的 pages 为 %v，应为 %v", u, entries[u].Pages, pages)
+		}
+	}
+
+	report, err := os.ReadFile(filepath.Join(siteDir, "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每页 4 个资源，100+200+300+400 字节，最慢的是第 4 个（40ms）
+	for _, line := range []string{
+		"Resources by Page:",
+		"  " + home + "\n    Resources: 4, Size: 1000 bytes\n    Slowest: https://shop.example.com/hero.png (40ms)",
+		"  " + about + "\n    Resources: 4, Size: 1000 bytes\n    Slowest: https://shop.example.com/team.jpg (40ms)",
+		"  Pages: ",
+	} {
+		if !strings.Contains(string(report), line) {
+			t.Errorf("report.txt 中缺少 %q", line)
+		}
+	}
+}
+
+// 在真实 Chrome 中递归爬取测试站点首页和 /page2.html：两页都引用的 /style.css、/app.js 列出两个页面，
+// 只有首页加载的 /lazy.js 只列出首页
+func TestRecursivePageAttributionTestSite(t *testing.T) {
+	chrome := crawlertest.RequireChrome(t)
+	quietLog(t)
+	site := testsite.New()
+	defer site.Close()
+
+	outputDir := t.TempDir()
+	config, opts := buildCrawlFlags(t, "-chrome-path", chrome, "-output", outputDir, "-depth", "1", "-retry", "0")
+	if code := crawlRecursive(site.Resolve("/"), config, opts, outputDir); code != 0 {
+		t.Fatalf("递归爬取返回 %d", code)
+	}
+
+	entries, err := os.ReadDir(outputDir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("输出目录中应只有一个站点目录: %v %v", entries, err)
+	}
+	index := readSiteIndex(t, filepath.Join(outputDir, entries[0].Name()))
+	home, page2 := site.Resolve("/"), site.Resolve("/page2.html")
+	for _, p := range []string{"/style.css", "/app.js"} {
+		pages := index[site.Resolve(p)].Pages
+		if !slices.Contains(pages, home) || !slices.Contains(pages, page2) {
+			t.Errorf("%s 的 pages 为 %v，应包含 %s 和 %s", p, pages, home, page2)
+		}
+	}
+	if pages := index[site.Resolve("/lazy.js")].Pages; !slices.Equal(pages, []string{home}) {
+		t.Errorf("/lazy.js 的 pages 为 %v，应只有 %s", pages, home)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/frontier"
+	"spider/internal/logging"
+)
+
+// frontierCheckpointInterval frontier.jsonl 的检查点间隔
+const frontierCheckpointInterval = 10 * time.Second
+
+// crawlRecursive 从目标页出发，递归爬取同主机链接。
+// 待爬 / 进行中 / 已完成的 URL 持久化在 <输出目录>/<hostname>/frontier.jsonl，
+// 中断后使用 -resume 可从断点继续（包括递归中途发现、尚未爬取的链接）。
+func crawlRecursive(targetURL string, config *crawler.Config, opts *outputOptions, baseOutputDir string) int {
+	// 种子与发现的链接使用同一规范化，避免 https://a.com 与 https://a.com/ 重复
+	normalized, err := normalizeURL(targetURL)
+	if err != nil {
+		logging.Errorf("无效的 URL: %s (%v)", targetURL, err)
+		return 1
+	}
+	targetURL = normalized
+	seed, _ := url.Parse(targetURL)
+	siteDir := buildBatchOutputDir(baseOutputDir, targetURL, make(map[string]int))
+	frontierPath := filepath.Join(siteDir, frontier.FileName)
+
+	if !config.Resume {
+		if err := os.Remove(frontierPath); err != nil && !os.IsNotExist(err) {
+			logging.Errorf("无法清除旧的 %s: %v", frontierPath, err)
+			return 1
+		}
+	}
+	fr, err := frontier.Open(frontierPath, config.CrawlStrategy, config.MaxPages, frontierCheckpointInterval)
+	if err != nil {
+		logging.Errorf("%v", err)
+		return 1
+	}
+	if config.Resume {
+		stats := fr.Stats()
+		log.Printf("从 %s 继续: 已完成 %d，失败 %d，待爬 %d", frontierPath,
+			stats[frontier.StateDone], stats[frontier.StateFailed], stats[frontier.StatePending])
+	}
+	fr.Add(targetURL, 0, "")
+
+	var pool *crawler.Pool
+	if !config.HARReplayMode {
+		pool, err = crawler.NewPool(config)
+		if err != nil {
+			logging.Errorf("浏览器池启动失败: %v", err)
+			if isChromeError(err) {
+				log.Print(chromeInstallHint)
+			}
+			return 1
+		}
+		defer pool.Close()
+	}
+	throttle := crawler.NewHostThrottle(config)
+	limiter := crawler.NewOriginLimiter(config.PerOriginConcurrency)
+
+	// -recursion-workers 个 Tab 轮流分布在 -concurrency 个浏览器进程中，0 表示每个进程一个 Tab
+	workers := config.RecursionWorkers
+	if workers <= 0 {
+		workers = max(config.Concurrency, 1)
+	}
+	if pool != nil {
+		log.Printf("递归爬取: %d 个 Tab，分布在 %d 个浏览器进程中", workers, pool.Size())
+	}
+
+	// 汇总所有页面的资源，结束后统一生成报告和索引
+	var mu sync.Mutex
+	merged := make(map[string]*crawler.Resource)
+	pageOpts := *opts
+	pageOpts.collect = func(resources map[string]*crawler.Resource) {
+		mu.Lock()
+		defer mu.Unlock()
+		for key, res := range resources {
+			existing, ok := merged[key]
+			if !ok {
+				merged[key] = res
+				continue
+			}
+			// 多个页面共用的资源：保留首次抓到的内容，记录所有引用它的页面
+			for _, page := range res.Pages {
+				if !slices.Contains(existing.Pages, page) {
+					existing.Pages = append(existing.Pages, page)
+				}
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for worker := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 同一进程中的多个 Tab 共享 Cookie 和缓存，各自独立导航
+			allocCtx := context.Background()
+			if pool != nil {
+				allocCtx = pool.Slot(worker)
+			}
+			for {
+				item, ok := fr.Next()
+				if !ok {
+					return
+				}
+				release := limiter.Acquire(item.URL)
+				throttle.Wait(item.URL)
+
+				log.Printf("[深度 %d] 开始爬取: %s", item.Depth, item.URL)
+				_, result, err := crawlInPool(allocCtx, item.URL, config, &pageOpts, siteDir)
+				release()
+				entry := ManifestEntry{URL: item.URL, OutputDir: siteDir, Success: err == nil}
+				if err != nil {
+					entry.Error = err.Error()
+					logging.Errorf("[深度 %d] 失败: %s - %v", item.Depth, item.URL, err)
+				}
+				if result != nil {
+					entry.LikelyLoginPage = result.LikelyLoginPage
+					entry.LoginSignal = result.LoginSignal
+				}
+				opts.results.add(entry)
+
+				if result != nil && item.Depth < config.RecursionDepth {
+					added := 0
+					for _, link := range result.Links {
+						if next, ok := scopeLink(seed, link, config.PathPrefix); ok && fr.Add(next, item.Depth+1, item.URL) {
+							added++
+						}
+					}
+					if added > 0 {
+						log.Printf("[深度 %d] %s 发现 %d 个新链接", item.Depth, item.URL, added)
+					}
+				}
+
+				if err := fr.Done(item.URL, err); err != nil {
+					logging.Warnf("写入 %s 失败: %v", frontierPath, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := fr.Checkpoint(); err != nil {
+		logging.Warnf("写入 %s 失败: %v", frontierPath, err)
+	}
+
+	writeSummaries(newStore(siteDir, true, config), merged, opts, targetURL)
+
+	stats := fr.Stats()
+	log.Printf("\n================================")
+	log.Printf("递归爬取完成! 已完成: %d, 失败: %d, 未爬取: %d",
+		stats[frontier.StateDone], stats[frontier.StateFailed], stats[frontier.StatePending])
+	log.Printf("共 %d 个资源，已保存到: %s", len(merged), siteDir)
+	log.Printf("================================")
+	return 0
+}
+
+// scopeLink 过滤并规范化页面链接：仅保留与种子 URL 同主机、路径以 pathPrefix 开头（为空时不限）的 http(s) 链接，去掉 #fragment
+func scopeLink(seed *url.URL, link, pathPrefix string) (string, bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", false
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	normalized, err := normalizeURL(u.String())
+	if err != nil {
+		return "", false
+	}
+	if n, _ := url.Parse(normalized); n.Host != seed.Host || !crawler.HasPathPrefix(normalized, pathPrefix) {
+		return "", false
+	}
+	return normalized, true
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"spider/internal/crawler"
+	"spider/internal/crawlertest"
+	"spider/internal/frontier"
+)
+
+// 递归爬取在 -max-pages 处停下后用 -resume 继续：已爬的页面不重爬，上次发现但未爬的链接接着爬完
+func TestRecursiveResume(t *testing.T) {
+	quietLog(t)
+	const (
+		base  = "https://docs.example.com"
+		pages = 15
+	)
+	var (
+		mu      sync.Mutex
+		crawled = make(map[string]int)
+	)
+	saved := crawlInPool
+	t.Cleanup(func() { crawlInPool = saved })
+	crawlInPool = func(_ context.Context, targetURL string, _ *crawler.Config, _ *outputOptions, _ string) (int, *crawler.CrawlResult, error) {
+		mu.Lock()
+		crawled[targetURL]++
+		mu.Unlock()
+		var page int
+		fmt.Sscanf(strings.TrimPrefix(targetURL, base+"/p/"), "%d", &page)
+		result := &crawler.CrawlResult{}
+		for _, c := range []int{2*page + 1, 2*page + 2} {
+			if c < pages {
+				result.Links = append(result.Links, fmt.Sprintf("%s/p/%d", base, c), "https://other.example.org/")
+			}
+		}
+		return 1, result, nil
+	}
+
+	outputDir := t.TempDir()
+	harFile := crawlertest.WriteHAR(t, nil)
+	args := []string{"-har", harFile, "-output", outputDir, "-depth", "10"}
+	config, opts := buildCrawlFlags(t, append(args, "-max-pages", "4")...)
+	if code := crawlRecursive(base+"/p/0", config, opts, outputDir); code != 0 {
+		t.Fatalf("第一次递归爬取返回 %d", code)
+	}
+	if len(crawled) != 4 {
+		t.Fatalf("-max-pages 4 时爬取了 %d 个页面", len(crawled))
+	}
+
+	frontierPath := filepath.Join(outputDir, "docs.example.com", frontier.FileName)
+	fr, err := frontier.Open(frontierPath, frontier.BFS, 0, 0)
+	if err != nil {
+		t.Fatalf("读取 %s 失败: %v", frontierPath, err)
+	}
+	if stats := fr.Stats(); stats[frontier.StateDone] != 4 || stats[frontier.StatePending] == 0 {
+		t.Fatalf("中断后的 frontier 状态 %v", stats)
+	}
+
+	config, opts = buildCrawlFlags(t, append(args, "-resume")...)
+	if code := crawlRecursive(base+"/p/0", config, opts, outputDir); code != 0 {
+		t.Fatalf("-resume 返回 %d", code)
+	}
+	if len(crawled) != pages {
+		t.Errorf("两次共爬取 %d 个页面，应为 %d", len(crawled), pages)
+	}
+	for u, n := range crawled {
+		if n != 1 {
+			t.Errorf("%s 被爬取了 %d 次", u, n)
+		}
+	}
+}
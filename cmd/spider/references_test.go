@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"spider/internal/crawler"
+	"spider/internal/crawlertest"
+)
+
+// unloadedRun 回放一个引用了 /late.png 和 /fonts/late.woff2 却没有加载它们的页面；
+// 这两个资源只在 server 上，-fetch-unreferenced 时由备用 HTTP 客户端直接下载
+func unloadedRun(t *testing.T, flags ...string) (report string, urls []string) {
+	t.Helper()
+	quietLog(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/late.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("png"))
+		case "/fonts/late.woff2":
+			w.Header().Set("Content-Type", "font/woff2")
+			w.Write([]byte("woff2"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	page := server.URL + "/"
+	css := server.URL + "/site.css"
+	har := crawlertest.WriteHAR(t, map[string]*crawler.Resource{
+		page: {URL: page, StatusCode: 200, MimeType: "text/html",
+			Content: []byte(`<html><head><link rel="stylesheet" href="/site.css"></head><body><img src="late.png"></body></html>`)},
+		css: {URL: css, StatusCode: 200, MimeType: "text/css",
+			Content: []byte(`@font-face { src: url(fonts/late.woff2) }`)},
+	})
+
+	outputDir := t.TempDir()
+	args := append([]string{"crawl", "-url", page, "-har", har, "-output", outputDir}, flags...)
+	if code := run(args); code != 0 {
+		t.Fatalf("spider crawl 返回 %d", code)
+	}
+	dir := findIndexDir(t, outputDir)
+	data, err := os.ReadFile(filepath.Join(dir, "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := loadCrawlIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range index {
+		urls = append(urls, strings.ReplaceAll(entry.URL, server.URL, "http://site"))
+	}
+	return strings.ReplaceAll(string(data), server.URL, "http://site"), urls
+}
+
+func TestUnloadedRefsReport(t *testing.T) {
+	report, urls := unloadedRun(t, "-unloaded-refs")
+	want := "\nReferenced But Not Loaded (2 in DOM / CSS, 0 fetched directly):\n" +
+		"  image (1):\n" +
+		"    http://site/late.png ← http://site/\n" +
+		"  font (1):\n" +
+		"    http://site/fonts/late.woff2 ← http://site/site.css\n"
+	if !strings.Contains(report, want) {
+		t.Errorf("报告中的未加载引用不正确:\n%s", report)
+	}
+	if slices.Contains(urls, "http://site/late.png") || slices.Contains(urls, "http://site/fonts/late.woff2") {
+		t.Errorf("只开启 -unloaded-refs 时不应下载，resources.json 中有 %v", urls)
+	}
+}
+
+func TestFetchUnreferenced(t *testing.T) {
+	report, urls := unloadedRun(t, "-fetch-unreferenced")
+	for _, line := range []string{
+		"Referenced But Not Loaded (2 in DOM / CSS, 2 fetched directly):",
+		"    http://site/late.png ← http://site/ (fetched)",
+		"    http://site/fonts/late.woff2 ← http://site/site.css (fetched)",
+	} {
+		if !strings.Contains(report, line+"\n") {
+			t.Errorf("报告缺少 %q:\n%s", line, report)
+		}
+	}
+	if !slices.Contains(urls, "http://site/late.png") || !slices.Contains(urls, "http://site/fonts/late.woff2") {
+		t.Errorf("resources.json 中缺少直接下载的资源: %v", urls)
+	}
+}
+
+// -fetch-unreferenced 隐含 -unloaded-refs；两者都需要渲染后的 DOM
+func TestUnloadedRefsFlags(t *testing.T) {
+	config, opts := buildCrawlFlags(t, "-url", "https://example.com", "-fetch-unreferenced")
+	if !opts.unloadedRefs || !opts.fetchUnreferenced || !config.CaptureRenderedHTML {
+		t.Errorf("unloadedRefs / fetchUnreferenced / CaptureRenderedHTML = %v / %v / %v，应都开启",
+			opts.unloadedRefs, opts.fetchUnreferenced, config.CaptureRenderedHTML)
+	}
+	config, opts = buildCrawlFlags(t, "-url", "https://example.com", "-unloaded-refs")
+	if !opts.unloadedRefs || opts.fetchUnreferenced || !config.CaptureRenderedHTML {
+		t.Errorf("-unloaded-refs 时 unloadedRefs / fetchUnreferenced / CaptureRenderedHTML = %v / %v / %v",
+			opts.unloadedRefs, opts.fetchUnreferenced, config.CaptureRenderedHTML)
+	}
+}
+
+// 没有渲染后的 DOM 时（HAR 回放）资源提示和未加载引用都按原始文档中的 <base href> 解析
+func TestReportsHonorBaseHref(t *testing.T) {
+	quietLog(t)
+	const page = "https://shop.example.com/products/item.html"
+	doc := `<html><head><base href="https://cdn.example.com/app/">
+<link rel="preload" href="main.js" as="script"></head>
+<body><img src="logo.png"><a href="next.html">next</a></body></html>`
+	har := crawlertest.WriteHAR(t, map[string]*crawler.Resource{
+		page: {URL: page, StatusCode: 200, MimeType: "text/html", Content: []byte(doc)},
+	})
+
+	outputDir := t.TempDir()
+	if code := run([]string{"crawl", "-url", page, "-har", har, "-output", outputDir, "-hints-report", "-unloaded-refs"}); code != 0 {
+		t.Fatalf("spider crawl 返回 %d", code)
+	}
+	report, err := os.ReadFile(filepath.Join(findIndexDir(t, outputDir), "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"  [preload as=script] https://cdn.example.com/app/main.js: not loaded",
+		"    https://cdn.example.com/app/main.js ← " + page,
+		"    https://cdn.example.com/app/logo.png ← " + page,
+	} {
+		if !strings.Contains(string(report), line+"\n") {
+			t.Errorf("报告缺少 %q:\n%s", line, report)
+		}
+	}
+	if strings.Contains(string(report), "https://shop.example.com/products/main.js") {
+		t.Errorf("相对地址不应按页面 URL 解析:\n%s", report)
+	}
+}
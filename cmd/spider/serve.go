@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"spider/internal/logging"
+)
+
+// defaultServeAddr serve 默认只监听本机，输出目录中可能有 Cookie、会话等敏感内容
+const defaultServeAddr = "127.0.0.1:8080"
+
+// runServe serve 子命令：以 HTTP 浏览一次爬取的输出目录。
+// 目录中的文件原样提供，/_index 返回 resources.json（批量输出目录按 manifest.json 合并各 URL 的结果）；
+// Ctrl+C 后等待进行中的请求结束再退出
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.Usage = showServeUsage
+	addr := fs.String("addr", defaultServeAddr, "监听地址")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "错误: 需要指定一个输出目录")
+		showServeUsage()
+		return 2
+	}
+
+	handler, err := newServeHandler(fs.Arg(0))
+	if err != nil {
+		logging.Errorf("%v", err)
+		return 2
+	}
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		logging.Errorf("无法监听 %s: %v", *addr, err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("正在提供 %s: http://%s/（资源索引见 /_index，Ctrl+C 退出）", fs.Arg(0), ln.Addr())
+	if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logging.Errorf("%v", err)
+		return 1
+	}
+	return 0
+}
+
+// newServeHandler 检查 dir 是一次爬取的输出目录（有 resources.json 或 manifest.json）并返回其处理器
+func newServeHandler(dir string) (http.Handler, error) {
+	if _, err := loadCrawlIndex(dir); err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /_index", func(w http.ResponseWriter, r *http.Request) {
+		// 每次请求重新读取：serve 期间可能有新的爬取写入同一目录
+		entries, err := loadCrawlIndex(dir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+	})
+	mux.Handle("/", http.FileServer(http.Dir(dir)))
+	return mux, nil
+}
+
+func showServeUsage() {
+	fmt.Fprintf(os.Stderr, `用法:
+  spider serve [选项] <输出目录>
+
+以 HTTP 浏览一次爬取的输出目录：目录中的文件原样提供，
+/_index 以 JSON 返回资源索引（批量输出目录按 manifest.json 合并各 URL 的 resources.json）。
+
+选项:
+  -addr string   监听地址 (默认 %s)
+
+示例:
+  spider serve ./output/example.com
+  spider serve -addr :9000 ./output
+
+`, defaultServeAddr)
+}
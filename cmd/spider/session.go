@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"spider/internal/crawler"
+	"spider/internal/logging"
+	"spider/internal/storage"
+)
+
+// loadSession 读取 -import-session 指定的会话文件
+func loadSession(path string) (*crawler.SessionState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取会话文件失败: %w", err)
+	}
+	var state crawler.SessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析会话文件 %s 失败: %w", path, err)
+	}
+	return &state, nil
+}
+
+// saveSession 将爬取结束时的会话写入 -export-session 指定的文件。
+// 文件中含全部 Cookie（包括 HttpOnly 的登录凭据），权限为 0600
+func saveSession(spider *crawler.Spider, path string) {
+	state, err := spider.ExportSession()
+	if err != nil {
+		logging.Warnf("导出会话失败: %v", err)
+		return
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		logging.Warnf("导出会话失败: %v", err)
+		return
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			logging.Warnf("创建目录失败 %s: %v", dir, err)
+			return
+		}
+	}
+	if err := storage.WriteFileAtomic(path, data, 0600); err != nil {
+		logging.Warnf("写入会话文件失败 %s: %v", path, err)
+		return
+	}
+	log.Printf("会话已导出到: %s（%d 个 Cookie），可用 -import-session 在之后的爬取中恢复", path, len(state.Cookies))
+}
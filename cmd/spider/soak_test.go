@@ -0,0 +1,96 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/crawlertest"
+	"spider/internal/testsite"
+)
+
+// buildCrawlFlags 按命令行参数构建配置，与 runCrawl 相同
+func buildCrawlFlags(t *testing.T, args ...string) (*crawler.Config, *outputOptions) {
+	t.Helper()
+	fs, f := newCrawlFlagSet("crawl", func() {})
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("解析参数 %v 失败: %v", args, err)
+	}
+	config, opts, err := f.build()
+	if err != nil {
+		t.Fatalf("构建配置失败: %v", err)
+	}
+	return config, opts
+}
+
+func quietLog(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+func memStats() (heap uint64, goroutines int) {
+	runtime.GC()
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc, runtime.NumGoroutine()
+}
+
+// 长时间运行的进程中连续 100 次（HAR 回放的）爬取：每次都走完整的 提取 → 保存 → 报告 流程，
+// 处理完的 Spider 不再被持有，堆内存和 goroutine 数在热身后趋于稳定
+func TestSequentialCrawlsMemoryStable(t *testing.T) {
+	if testing.Short() {
+		t.Skip("soak 测试较慢")
+	}
+	quietLog(t)
+	site := testsite.New()
+	defer site.Close()
+
+	var urls []string
+	for _, p := range append([]string{"/"}, testsite.Paths[1:6]...) {
+		urls = append(urls, site.Resolve(p))
+	}
+	harFile := crawlertest.RecordHAR(t, urls...)
+	outputDir := t.TempDir()
+
+	config, opts := buildCrawlFlags(t, "-url", site.Resolve("/"), "-har", harFile, "-output", outputDir)
+	crawlOnce := func() {
+		if code := crawlSingleURL(site.Resolve("/"), config, opts, outputDir); code != 0 {
+			t.Fatalf("爬取返回 %d", code)
+		}
+	}
+
+	const warmup, total = 20, 100
+	for range warmup {
+		crawlOnce()
+	}
+	baseHeap, baseGoroutines := memStats()
+
+	for range total - warmup {
+		crawlOnce()
+	}
+	// 空闲连接的读写 goroutine 异步退出
+	deadline := time.Now().Add(2 * time.Second)
+	heap, goroutines := memStats()
+	for goroutines > baseGoroutines && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		heap, goroutines = memStats()
+	}
+
+	if goroutines > baseGoroutines {
+		t.Errorf("第 %d 次爬取后 goroutine 从 %d 增加到 %d", total, baseGoroutines, goroutines)
+	}
+	// 每次爬取都分配数百 KB；若资源表或缓存随次数累积，80 次后增长会远超此余量
+	const slack = 4 << 20
+	if heap > baseHeap+slack {
+		t.Errorf("第 %d 次爬取后堆内存从 %d 增长到 %d 字节（第 %d 次后为基准）", total, baseHeap, heap, warmup)
+	}
+	if opts.mapCache.Len() == 0 {
+		t.Error("source map 下载缓存未被使用")
+	}
+}
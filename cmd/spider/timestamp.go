@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"spider/internal/logging"
+)
+
+// defaultTimestampLayout -timestamp-dir 默认的目录名格式：UTC 的 RFC 3339 时间，冒号换成连字符以兼容 Windows
+const defaultTimestampLayout = "2006-01-02T15-04-05Z"
+
+// latestLinkName -timestamp-dir 在输出根目录中维护的符号链接，指向最近一次运行的目录
+const latestLinkName = "latest"
+
+// applyTimestampDir 开启 -timestamp-dir 时创建并改用 <-output>/<时间戳>/ 作为输出目录，返回原来的根目录；未开启时返回空字符串。
+// 目录以 os.Mkdir 原子地占用：同一秒内的多次运行（包括并发的进程）依次加后缀 -2、-3 ...，不会共用或覆盖已有目录
+func (f *crawlFlags) applyTimestampDir(now time.Time) (string, error) {
+	if !f.timestampDir {
+		return "", nil
+	}
+	name := now.UTC().Format(f.timestampFormat)
+	if name == "" || name == "." || name == ".." || name == latestLinkName || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("-timestamp-format 生成的目录名无效: %q", name)
+	}
+
+	base := f.outputDir
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return "", fmt.Errorf("创建输出目录失败: %w", err)
+	}
+	dir := filepath.Join(base, name)
+	for i := 2; ; i++ {
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", fmt.Errorf("创建输出目录失败: %w", err)
+		}
+		dir = filepath.Join(base, fmt.Sprintf("%s-%d", name, i))
+	}
+	f.outputDir = dir
+	return base, nil
+}
+
+// updateLatestLink 将 <base>/latest 指向本次运行的目录（相对路径，整个根目录可以移动）。
+// 先创建临时链接再 rename 覆盖，替换过程中 latest 始终有效；不支持符号链接的系统上只打印警告
+func updateLatestLink(base, runDir string) {
+	if base == "" {
+		return
+	}
+	if entries, err := os.ReadDir(runDir); err != nil || len(entries) == 0 {
+		// 本次运行没有产生输出（如全部失败且未落盘）：删掉占用的空目录，保留 latest 指向上一次
+		os.Remove(runDir)
+		return
+	}
+	link := filepath.Join(base, latestLinkName)
+	// 临时链接名带进程号，并发运行的进程互不干扰；rename 覆盖时后完成的一次生效
+	tmp := fmt.Sprintf("%s.tmp-%d", link, os.Getpid())
+	os.Remove(tmp)
+	if err := os.Symlink(filepath.Base(runDir), tmp); err != nil {
+		logging.Warnf("创建 %s 符号链接失败: %v", link, err)
+		return
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		logging.Warnf("更新 %s 符号链接失败: %v", link, err)
+		return
+	}
+	log.Printf("已将 %s 指向 %s", link, filepath.Base(runDir))
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func timestampFlags(base string) *crawlFlags {
+	return &crawlFlags{timestampDir: true, timestampFormat: defaultTimestampLayout, outputDir: base}
+}
+
+// 同一秒内的多次运行依次占用 <时间戳>、-2、-3 ...，目录在返回前已创建
+func TestApplyTimestampDir(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "archive") // 根目录不存在时一并创建
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("CST", 8*3600))
+
+	var dirs []string
+	for range 3 {
+		f := timestampFlags(base)
+		got, err := f.applyTimestampDir(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != base {
+			t.Errorf("返回的根目录为 %q，应为 %q", got, base)
+		}
+		if info, err := os.Stat(f.outputDir); err != nil || !info.IsDir() {
+			t.Errorf("%s 未创建: %v", f.outputDir, err)
+		}
+		dirs = append(dirs, filepath.Base(f.outputDir))
+	}
+	want := []string{"2026-01-01T19-04-05Z", "2026-01-01T19-04-05Z-2", "2026-01-01T19-04-05Z-3"}
+	if !slices.Equal(dirs, want) {
+		t.Errorf("目录依次为 %v，应为 %v（按 UTC）", dirs, want)
+	}
+
+	// 未开启时不改动输出目录
+	f := &crawlFlags{outputDir: base}
+	if got, err := f.applyTimestampDir(now); got != "" || err != nil || f.outputDir != base {
+		t.Errorf("未开启时返回 %q, %v，输出目录 %q", got, err, f.outputDir)
+	}
+
+	for _, layout := range []string{"", "latest", "2006/01/02", `2006\01`} {
+		f := timestampFlags(base)
+		f.timestampFormat = layout
+		if _, err := f.applyTimestampDir(now); err == nil {
+			t.Errorf("格式 %q 生成的目录名无效，应报错", layout)
+		}
+	}
+}
+
+// 并发运行的进程各自占用不同的目录
+func TestApplyTimestampDirConcurrent(t *testing.T) {
+	base := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	const n = 16
+	dirs := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f := timestampFlags(base)
+			_, errs[i] = f.applyTimestampDir(now)
+			dirs[i] = f.outputDir
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	slices.Sort(dirs)
+	if len(slices.Compact(dirs)) != n {
+		t.Errorf("%d 次并发运行只得到 %d 个不同的目录", n, len(slices.Compact(dirs)))
+	}
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != n {
+		t.Errorf("根目录中有 %d 个目录，应为 %d", len(entries), n)
+	}
+}
+
+// latest 以相对路径指向最近一次有输出的运行；已有的链接被替换，没有输出的运行删掉空目录并保留原链接
+func TestUpdateLatestLink(t *testing.T) {
+	quietLog(t)
+	base := t.TempDir()
+	link := filepath.Join(base, latestLinkName)
+
+	run := func(name string, withOutput bool) string {
+		t.Helper()
+		dir := filepath.Join(base, name)
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if withOutput {
+			if err := os.WriteFile(filepath.Join(dir, "report.txt"), []byte(name), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		updateLatestLink(base, dir)
+		return dir
+	}
+	target := func() string {
+		t.Helper()
+		got, err := os.Readlink(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	run("first", true)
+	if got := target(); got != "first" {
+		t.Errorf("latest 指向 %q，应为相对路径 first", got)
+	}
+
+	run("second", true)
+	if got := target(); got != "second" {
+		t.Errorf("已有的 latest 应被替换为 second，实际 %q", got)
+	}
+	if data, err := os.ReadFile(filepath.Join(link, "report.txt")); err != nil || string(data) != "second" {
+		t.Errorf("通过 latest 读到 %q (%v)", data, err)
+	}
+
+	empty := run("third", false)
+	if got := target(); got != "second" {
+		t.Errorf("没有输出的运行不应更新 latest，实际指向 %q", got)
+	}
+	if _, err := os.Stat(empty); !os.IsNotExist(err) {
+		t.Errorf("没有输出的运行应删掉占用的空目录: %v", err)
+	}
+
+	// 没有残留的临时链接
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"first", latestLinkName, "second"}; !slices.Equal(names, want) {
+		t.Errorf("根目录中为 %v，应为 %v", names, want)
+	}
+
+	// 未开启 -timestamp-dir 时不做任何事
+	updateLatestLink("", filepath.Join(base, "first"))
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/crawlertest"
+	"spider/internal/testsite"
+)
+
+// docsRun 用假的爬取递归处理 testsite.DocsPages 个互相链接的文档页（每页耗时 pageDelay），
+// 返回总耗时、同时进行的最大页数和爬取的页数
+func docsRun(t *testing.T, pageDelay time.Duration, args ...string) (elapsed time.Duration, maxActive, pages int) {
+	t.Helper()
+	const base = "https://docs.example.com/docs/"
+	var (
+		mu     sync.Mutex
+		active int
+	)
+	saved := crawlInPool
+	t.Cleanup(func() { crawlInPool = saved })
+	crawlInPool = func(_ context.Context, targetURL string, _ *crawler.Config, _ *outputOptions, _ string) (int, *crawler.CrawlResult, error) {
+		mu.Lock()
+		active++
+		pages++
+		maxActive = max(maxActive, active)
+		mu.Unlock()
+		time.Sleep(pageDelay)
+		mu.Lock()
+		active--
+		mu.Unlock()
+
+		result := &crawler.CrawlResult{}
+		for i := range testsite.DocsPages {
+			result.Links = append(result.Links, fmt.Sprintf("%s%d", base, i))
+		}
+		return 1, result, nil
+	}
+
+	outputDir := t.TempDir()
+	config, opts := buildCrawlFlags(t, append([]string{"-har", crawlertest.WriteHAR(t, nil), "-output", outputDir, "-depth", "1"}, args...)...)
+	start := time.Now()
+	if code := crawlRecursive(base+"0", config, opts, outputDir); code != 0 {
+		t.Fatalf("递归爬取返回 %d", code)
+	}
+	return time.Since(start), maxActive, pages
+}
+
+// 20 个页面从 1 个 Tab 增加到 4 个：同时处理的页数达到 4，耗时接近线性下降
+func TestRecursionWorkersSpeedup(t *testing.T) {
+	quietLog(t)
+	const pageDelay = 40 * time.Millisecond
+	serial, serialActive, pages := docsRun(t, pageDelay, "-recursion-workers", "1")
+	if pages != testsite.DocsPages || serialActive != 1 {
+		t.Fatalf("1 个 Tab: 爬取 %d 页，最大并行 %d", pages, serialActive)
+	}
+	parallel, parallelActive, pages := docsRun(t, pageDelay, "-recursion-workers", "4", "-per-origin-concurrency", "4")
+	if pages != testsite.DocsPages || parallelActive != 4 {
+		t.Fatalf("4 个 Tab: 爬取 %d 页，最大并行 %d", pages, parallelActive)
+	}
+	// 第一页只能单独爬，其余 19 页分 5 轮：理想加速比 20/6 ≈ 3.3
+	if speedup := float64(serial) / float64(parallel); speedup < 2.5 {
+		t.Errorf("1 → 4 个 Tab 的加速比 %.1f（%s → %s），应接近线性", speedup, serial, parallel)
+	}
+}
+
+// 递归只有一个站点：并行 Tab 数同时受 -per-origin-concurrency 限制；默认与 -concurrency 相同
+func TestRecursionWorkersBounded(t *testing.T) {
+	quietLog(t)
+	if _, active, _ := docsRun(t, 10*time.Millisecond, "-recursion-workers", "8", "-per-origin-concurrency", "2"); active != 2 {
+		t.Errorf("-per-origin-concurrency 2 时最大并行 %d，应为 2", active)
+	}
+	if _, active, _ := docsRun(t, 10*time.Millisecond, "-concurrency", "3", "-per-origin-concurrency", "0"); active != 3 {
+		t.Errorf("未设置 -recursion-workers 时最大并行 %d，应与 -concurrency 3 相同", active)
+	}
+}
+
+// Tab 总数不超过 crawler.MaxTabs，负数报错
+func TestRecursionWorkersFlag(t *testing.T) {
+	if config, _ := buildCrawlFlags(t, "-url", "https://example.com", "-recursion-workers", "8"); config.RecursionWorkers != 8 {
+		t.Errorf("RecursionWorkers = %d，应为 8", config.RecursionWorkers)
+	}
+	for _, args := range [][]string{
+		{"-recursion-workers", "-1"},
+		{"-recursion-workers", fmt.Sprint(crawler.MaxTabs + 1)},
+		{"-concurrency", fmt.Sprint(crawler.MaxTabs + 1)},
+	} {
+		fs, f := newCrawlFlagSet("crawl", func() {})
+		fs.SetOutput(io.Discard)
+		if err := fs.Parse(append([]string{"-url", "https://example.com"}, args...)); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := f.build(); err == nil {
+			t.Errorf("%s 应报错", strings.Join(args, " "))
+		}
+	}
+}
+
+// 在真实 Chrome 中递归爬取测试站点的 20 个文档页：4 个 Tab 共用一个浏览器进程，全部页面都被爬取且明显快于 1 个 Tab
+func TestRecursionWorkersTestSite(t *testing.T) {
+	chrome := crawlertest.RequireChrome(t)
+	quietLog(t)
+	site := testsite.New()
+	defer site.Close()
+
+	run := func(workers string) time.Duration {
+		outputDir := t.TempDir()
+		config, opts := buildCrawlFlags(t, "-chrome-path", chrome, "-output", outputDir, "-depth", "1", "-retry", "0",
+			"-recursion-workers", workers, "-per-origin-concurrency", workers)
+		start := time.Now()
+		if code := crawlRecursive(site.Resolve("/docs/0"), config, opts, outputDir); code != 0 {
+			t.Fatalf("递归爬取返回 %d", code)
+		}
+		elapsed := time.Since(start)
+
+		entries, err := os.ReadDir(outputDir)
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("输出目录中应只有一个站点目录: %v %v", entries, err)
+		}
+		index := readSiteIndex(t, filepath.Join(outputDir, entries[0].Name()))
+		for i := range testsite.DocsPages {
+			if _, ok := index[site.Resolve(fmt.Sprintf("/docs/%d", i))]; !ok {
+				t.Errorf("%s 个 Tab 时缺少 /docs/%d", workers, i)
+			}
+		}
+		return elapsed
+	}
+
+	serial, parallel := run("1"), run("4")
+	if speedup := float64(serial) / float64(parallel); speedup < 1.5 {
+		t.Errorf("1 → 4 个 Tab 的加速比 %.1f（%s → %s）", speedup, serial, parallel)
+	}
+}
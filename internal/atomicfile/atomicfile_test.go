@@ -0,0 +1,50 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 写入成功后替换旧内容并设置权限，不留下临时文件；写入失败时保留旧内容
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "resources.json")
+	if err := WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(path, []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Fatalf("内容为 %q（%v），应为 new", data, err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o644 {
+		t.Errorf("权限为 %v，应为 0644", info.Mode().Perm())
+	}
+
+	failed := errors.New("disk full")
+	err = Write(path, 0o644, func(w io.Writer) error {
+		w.Write([]byte("half"))
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("写入失败应返回原错误，实际 %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("写入失败后内容为 %q，应保留 new", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("目录中应只有 resources.json，实际 %v", entries)
+	}
+
+	if err := WriteFile(filepath.Join(dir, "missing", "a.json"), []byte("x"), 0o644); err == nil {
+		t.Error("目录不存在时应返回错误")
+	}
+	if err := SyncDir(dir); err != nil {
+		t.Errorf("SyncDir: %v", err)
+	}
+}
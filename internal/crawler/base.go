@@ -0,0 +1,31 @@
+package crawler
+
+import (
+	"context"
+
+	"github.com/chromedp/chromedp"
+
+	"spider/internal/logging"
+)
+
+// documentBase 页面解析相对地址的基准 document.baseURI：有 <base href> 时为它（可能指向其它源），否则为页面 URL
+func (s *Spider) documentBase(ctx context.Context) string {
+	var base string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(`document.baseURI || ""`, &base)); err != nil {
+		logging.Warnf("读取 document.baseURI 失败: %v", err)
+		return ""
+	}
+	return base
+}
+
+// ReferenceBase 解析资源中相对地址（sourceMappingURL、CSS url() 等）的基准：
+// 设置了 Resource.BaseURL 时为它，内联合成资源为所在页面，其余为资源自身的 URL
+func ReferenceBase(res *Resource) string {
+	if res.BaseURL != "" {
+		return res.BaseURL
+	}
+	if page := InlineResourcePage(res); page != "" {
+		return page
+	}
+	return res.URL
+}
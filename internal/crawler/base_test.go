@@ -0,0 +1,23 @@
+package crawler
+
+import "testing"
+
+// 设置了 BaseURL（页面有 <base href>）时按它解析，内联合成资源按所在页面，其余按资源自身的 URL
+func TestReferenceBase(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		res  *Resource
+		want string
+	}{
+		{"普通资源", &Resource{URL: "https://example.com/js/app.js"}, "https://example.com/js/app.js"},
+		{"主文档带 <base href>", &Resource{URL: "https://example.com/page.html", BaseURL: "https://cdn.example.net/app/"}, "https://cdn.example.net/app/"},
+		{"内联脚本", &Resource{URL: InlineScheme + "://example.com/inline_script_1.js", Pages: []string{"https://example.com/shop/"}}, "https://example.com/shop/"},
+		{"内联脚本，页面带 <base href>", &Resource{URL: InlineScheme + "://example.com/inline_script_1.js", Pages: []string{"https://example.com/shop/"},
+			BaseURL: "https://cdn.example.net/app/"}, "https://cdn.example.net/app/"},
+		{"没有页面的内联资源", &Resource{URL: InlineScheme + "://example.com/inline_style_1.css"}, InlineScheme + "://example.com/inline_style_1.css"},
+	} {
+		if got := ReferenceBase(tc.res); got != tc.want {
+			t.Errorf("%s: ReferenceBase = %q，应为 %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultTrackingDomains -no-tracking 拦截的常见统计 / 广告 / 会话录制域名（含子域名）
+var DefaultTrackingDomains = []string{
+	// Google
+	"google-analytics.com",
+	"googletagmanager.com",
+	"doubleclick.net",
+	"googleadservices.com",
+	"googlesyndication.com",
+	"analytics.google.com",
+	// 社交平台像素
+	"connect.facebook.net",
+	"ads-twitter.com",
+	"analytics.twitter.com",
+	"snap.licdn.com",
+	"px.ads.linkedin.com",
+	"analytics.tiktok.com",
+	"bat.bing.com",
+	// 产品分析与会话录制
+	"hotjar.com",
+	"clarity.ms",
+	"fullstory.com",
+	"mixpanel.com",
+	"amplitude.com",
+	"cdn.segment.com",
+	"api.segment.io",
+	"heap.io",
+	"heapanalytics.com",
+	"mouseflow.com",
+	// 测量与广告
+	"scorecardresearch.com",
+	"quantserve.com",
+	"criteo.com",
+	"taboola.com",
+	"outbrain.com",
+	"adnxs.com",
+	// 国内常见统计
+	"hm.baidu.com",
+	"cnzz.com",
+	"mc.yandex.ru",
+}
+
+// DefaultTrackingURLPatterns -no-tracking 拦截的统计上报 URL 特征（* 通配，匹配完整 URL），
+// 覆盖自托管或经第一方代理转发的统计脚本
+var DefaultTrackingURLPatterns = []string{
+	"*/g/collect?*", // GA4 经第一方域名转发的上报
+	"*/j/collect?*", // Universal Analytics
+	"*/__utm.gif*",  // 旧版 ga.js
+	"*/matomo.php*", // Matomo / Piwik
+	"*/piwik.php*",
+	"*/hm.gif?*",     // 百度统计
+	"*/tr?id=*&ev=*", // Facebook Pixel 图片上报
+}
+
+// TrackingBlocklist 返回 -no-tracking 预设的域名和 URL 特征，allow 中的域名（含其子域名）不拦截
+func TrackingBlocklist(allow []string) (domains, patterns []string) {
+	for _, d := range DefaultTrackingDomains {
+		if !slices.ContainsFunc(allow, func(a string) bool { return domainMatches(d, a) }) {
+			domains = append(domains, d)
+		}
+	}
+	return domains, slices.Clone(DefaultTrackingURLPatterns)
+}
+
+// blockedURLPatterns 将 BlockDomains 和 BlockURLPatterns 转为 Network.setBlockedURLs 的模式
+func blockedURLPatterns(config *Config) []string {
+	var patterns []string
+	for _, d := range config.BlockDomains {
+		d = strings.ToLower(strings.TrimPrefix(d, "*."))
+		patterns = append(patterns, "*://"+d+"/*", "*://*."+d+"/*")
+	}
+	return append(patterns, config.BlockURLPatterns...)
+}
+
+// blocker 在 Go 侧判断 URL 是否被拦截（HAR 回放等不经过浏览器的路径）
+type blocker struct {
+	domains  []string
+	patterns []*regexp.Regexp
+}
+
+func newBlocker(config *Config) *blocker {
+	b := &blocker{}
+	for _, d := range config.BlockDomains {
+		b.domains = append(b.domains, strings.ToLower(strings.TrimPrefix(d, "*.")))
+	}
+	for _, p := range config.BlockURLPatterns {
+		parts := strings.Split(p, "*")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		b.patterns = append(b.patterns, regexp.MustCompile("^"+strings.Join(parts, ".*")+"$"))
+	}
+	return b
+}
+
+// blocked 判断 URL 是否命中拦截的域名或 URL 特征
+func (b *blocker) blocked(rawURL string) bool {
+	if u, err := url.Parse(rawURL); err == nil {
+		host := strings.ToLower(u.Hostname())
+		for _, d := range b.domains {
+			if domainMatches(host, d) {
+				return true
+			}
+		}
+	}
+	for _, re := range b.patterns {
+		if re.MatchString(rawURL) {
+			return true
+		}
+	}
+	return false
+}
+
+// domainMatches host 等于 domain 或为其子域名
+func domainMatches(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
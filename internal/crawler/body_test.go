@@ -0,0 +1,152 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// hangingBody 模拟卡住的 GetResponseBody：直到 ctx 结束才返回
+func hangingBody(ctx context.Context, _ network.RequestID) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// newBodySpider 返回不下载兜底、响应体获取超时为 timeout 的 Spider，getBody 替换为 fake
+func newBodySpider(t *testing.T, timeout time.Duration, fake func(context.Context, network.RequestID) ([]byte, error)) *Spider {
+	t.Helper()
+	config := DefaultConfig()
+	config.BodyFetchTimeout = timeout
+	config.DisableFallbackDownload = true
+	s, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.getBody = fake
+	return s
+}
+
+// receive 模拟一次已加载完成的 responseReceived，等待响应体获取结束并返回耗时
+func receive(ctx context.Context, s *Spider, id network.RequestID, url string) time.Duration {
+	s.loads.finish(id)
+	start := time.Now()
+	s.handleResponse(ctx, &network.EventResponseReceived{
+		RequestID: id,
+		Response:  &network.Response{URL: url, Status: 200, MimeType: "application/javascript"},
+	}, "page")
+	s.wg.Wait()
+	return time.Since(start)
+}
+
+// 卡住的 CDP 调用在 BodyFetchTimeout 后放弃，资源记录超时原因，并计入汇总
+func TestBodyFetchTimeoutBoundsHangingCall(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+	s := newBodySpider(t, timeout, hangingBody)
+
+	elapsed := receive(context.Background(), s, "1", "https://example.com/stuck.js")
+	if elapsed > timeout+time.Second {
+		t.Fatalf("卡住的响应体获取 %s 后才返回，超时为 %s", elapsed, timeout)
+	}
+	res := s.resources[s.resourceKey("https://example.com/stuck.js")]
+	if res == nil {
+		t.Fatal("资源未记录")
+	}
+	if res.BodyError == "" || !res.BodyTimedOut || res.Content != nil {
+		t.Errorf("BodyError = %q，BodyTimedOut = %v，Content = %q，应记录超时且没有内容", res.BodyError, res.BodyTimedOut, res.Content)
+	}
+	if s.bodyFailures != 1 || s.bodyTimeouts != 1 {
+		t.Errorf("bodyFailures / bodyTimeouts = %d / %d，应为 1 / 1", s.bodyFailures, s.bodyTimeouts)
+	}
+}
+
+// 排空截止时间早于 BodyFetchTimeout 时以截止时间为准，卡住的调用不会拖长爬取
+func TestBodyFetchBoundedByDrainDeadline(t *testing.T) {
+	s := newBodySpider(t, time.Hour, hangingBody)
+	s.drainDeadline = time.Now().Add(100 * time.Millisecond)
+
+	if elapsed := receive(context.Background(), s, "1", "https://example.com/stuck.js"); elapsed > 2*time.Second {
+		t.Fatalf("响应体获取 %s 后才返回，应在排空截止时间（100ms）结束", elapsed)
+	}
+	if res := s.resources[s.resourceKey("https://example.com/stuck.js")]; !res.BodyTimedOut {
+		t.Errorf("BodyTimedOut = false，BodyError = %q", res.BodyError)
+	}
+}
+
+// 导航 context 已结束（临近爬取结束时收到的响应）不影响响应体获取
+func TestBodyFetchOutlivesNavigationContext(t *testing.T) {
+	s := newBodySpider(t, time.Second, func(ctx context.Context, _ network.RequestID) ([]byte, error) {
+		select {
+		case <-time.After(20 * time.Millisecond):
+			return []byte("late"), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+
+	navCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	receive(navCtx, s, "1", "https://example.com/late.js")
+
+	res := s.resources[s.resourceKey("https://example.com/late.js")]
+	if string(res.Content) != "late" || res.BodyError != "" {
+		t.Errorf("Content = %q，BodyError = %q，导航结束后仍应取回响应体", res.Content, res.BodyError)
+	}
+}
+
+// 非超时的失败不标记为超时；允许兜底下载时改为直接下载，成功后不记录 BodyError
+func TestBodyFetchErrorAndFallback(t *testing.T) {
+	failing := func(context.Context, network.RequestID) ([]byte, error) {
+		return nil, errors.New("No resource with given identifier found")
+	}
+
+	s := newBodySpider(t, time.Second, failing)
+	receive(context.Background(), s, "1", "https://example.com/gone.js")
+	res := s.resources[s.resourceKey("https://example.com/gone.js")]
+	if res.BodyError == "" || res.BodyTimedOut {
+		t.Errorf("BodyError = %q，BodyTimedOut = %v，应记录非超时错误", res.BodyError, res.BodyTimedOut)
+	}
+	if s.bodyFailures != 1 || s.bodyTimeouts != 0 {
+		t.Errorf("bodyFailures / bodyTimeouts = %d / %d，应为 1 / 0", s.bodyFailures, s.bodyTimeouts)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("downloaded"))
+	}))
+	defer server.Close()
+	s = newBodySpider(t, time.Second, failing)
+	s.config.DisableFallbackDownload = false
+	receive(context.Background(), s, "1", server.URL+"/app.js")
+	res = s.resources[s.resourceKey(server.URL+"/app.js")]
+	if string(res.Content) != "downloaded" || res.BodyError != "" {
+		t.Errorf("Content = %q，BodyError = %q，应使用直接下载的内容", res.Content, res.BodyError)
+	}
+}
+
+func TestBodyFetchContext(t *testing.T) {
+	s := newBodySpider(t, time.Minute, hangingBody)
+
+	ctx, cancel := s.bodyFetchContext(context.Background())
+	deadline, ok := ctx.Deadline()
+	cancel()
+	if !ok || time.Until(deadline) < 50*time.Second {
+		t.Errorf("没有排空截止时间时应按 BodyFetchTimeout 设置截止，得到 %v", deadline)
+	}
+
+	s.drainDeadline = time.Now().Add(time.Second)
+	ctx, cancel = s.bodyFetchContext(context.Background())
+	deadline, _ = ctx.Deadline()
+	cancel()
+	if !deadline.Equal(s.drainDeadline) {
+		t.Errorf("截止时间为 %v，应为排空截止时间 %v", deadline, s.drainDeadline)
+	}
+
+	s.config.BodyFetchTimeout = 0
+	if got := s.bodyFetchTimeout(); got != DefaultBodyFetchTimeout {
+		t.Errorf("BodyFetchTimeout 为 0 时使用 %s，应为 %s", got, DefaultBodyFetchTimeout)
+	}
+}
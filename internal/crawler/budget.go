@@ -0,0 +1,65 @@
+package crawler
+
+import "time"
+
+// 时间预算在各阶段的分配比例。导航和子资源加载、响应体获取使用剩余部分（约 50%），
+// 它们都受整体超时约束，不单独分配
+const (
+	budgetSettleShare = 0.05 // 导航后、滚动前的固定等待
+	budgetScrollShare = 0.15 // 滚动触发懒加载
+	budgetIdleShare   = 0.30 // 等待 WaitUntil 事件和网络空闲的上限
+)
+
+// minBudgetIdle 空闲检测需要连续 2s 没有新资源，上限低于此值时永远等不到空闲
+const minBudgetIdle = 3 * time.Second
+
+// defaultScrollSteps 滚动的位置（页面高度的比例）和每步之后的等待，ScrollDuration 为 0 时使用
+var defaultScrollSteps = []struct {
+	frac  float64
+	delay time.Duration
+}{
+	{0.25, 800 * time.Millisecond},
+	{0.50, 800 * time.Millisecond},
+	{0.75, 800 * time.Millisecond},
+	{1.00, 1000 * time.Millisecond},
+	{0.00, 500 * time.Millisecond},
+}
+
+// defaultScrollDuration 默认滚动步骤的总等待时间
+func defaultScrollDuration() time.Duration {
+	var total time.Duration
+	for _, step := range defaultScrollSteps {
+		total += step.delay
+	}
+	return total
+}
+
+// ApplyTimeBudget 用一个单页总时间预算设置各阶段的时长：Timeout 取 budget，
+// NavigationSettleDelay、ScrollDuration、IdleTimeout 按固定比例分配。
+// 之后再单独设置的字段覆盖分配结果，如
+//
+//	config.ApplyTimeBudget(20 * time.Second)
+//	config.IdleTimeout = 10 * time.Second
+func (c *Config) ApplyTimeBudget(budget time.Duration) {
+	c.TimeBudget = budget
+	if budget <= 0 {
+		return
+	}
+	c.Timeout = budget
+	c.NavigationSettleDelay = budgetShare(budget, budgetSettleShare)
+	c.ScrollDuration = budgetShare(budget, budgetScrollShare)
+	c.IdleTimeout = max(budgetShare(budget, budgetIdleShare), minBudgetIdle)
+}
+
+func budgetShare(budget time.Duration, share float64) time.Duration {
+	return time.Duration(float64(budget) * share).Round(100 * time.Millisecond)
+}
+
+// scrollDelay 第 i 步滚动之后的等待：设置 ScrollDuration 时按默认步骤的比例缩放
+func (c *Config) scrollDelay(i int) time.Duration {
+	delay := defaultScrollSteps[i].delay
+	if c.ScrollDuration <= 0 {
+		return delay
+	}
+	return time.Duration(float64(delay) * float64(c.ScrollDuration) / float64(defaultScrollDuration()))
+}
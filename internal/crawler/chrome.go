@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrChromeNotFound 未指定 ChromePath 且在 PATH 和各平台默认安装位置都找不到浏览器
+var ErrChromeNotFound = errors.New("no Chrome found")
+
+// ErrChromeStart 找到了浏览器但进程启动或建立 CDP 连接失败
+var ErrChromeStart = errors.New("chrome failed to start")
+
+// ChromeLocations 自动搜索浏览器时依次查找的位置：不含路径分隔符的按 PATH 查找，
+// 其余为当前平台的默认安装路径。覆盖 Chrome、Chromium、Edge 和 chrome-headless-shell。
+func ChromeLocations() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+			"/Applications/Microsoft Edge.app/Contents/MacOS/Microsoft Edge",
+			filepath.Join(os.Getenv("HOME"), "Applications/Google Chrome.app/Contents/MacOS/Google Chrome"),
+			"google-chrome",
+			"chromium",
+		}
+	case "windows":
+		local := os.Getenv("LOCALAPPDATA")
+		return []string{
+			"chrome.exe",
+			`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+			`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
+			filepath.Join(local, `Google\Chrome\Application\chrome.exe`),
+			filepath.Join(local, `Chromium\Application\chrome.exe`),
+			`C:\Program Files (x86)\Microsoft\Edge\Application\msedge.exe`,
+			`C:\Program Files\Microsoft\Edge\Application\msedge.exe`,
+			"msedge.exe",
+		}
+	default:
+		return []string{
+			"google-chrome",
+			"google-chrome-stable",
+			"chromium",
+			"chromium-browser",
+			"chrome",
+			"headless_shell",
+			"headless-shell",
+			"chrome-headless-shell",
+			"microsoft-edge",
+			"microsoft-edge-stable",
+			"/usr/bin/google-chrome",
+			"/usr/local/bin/chrome",
+			"/snap/bin/chromium",
+			"/opt/google/chrome/chrome",
+		}
+	}
+}
+
+// FindChrome 返回要启动的浏览器可执行文件：chromePath 非空时只检查它是否存在，
+// 否则按 ChromeLocations 的顺序查找。找不到时返回包含 ErrChromeNotFound 的错误，
+// 并列出查找过的全部位置。
+func FindChrome(chromePath string) (string, error) {
+	if chromePath != "" {
+		if _, err := os.Stat(chromePath); err != nil {
+			return "", fmt.Errorf("%w; looked in: %s", ErrChromeNotFound, chromePath)
+		}
+		return chromePath, nil
+	}
+
+	locations := ChromeLocations()
+	for _, loc := range locations {
+		if path, err := exec.LookPath(loc); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%w; looked in: %s", ErrChromeNotFound, strings.Join(locations, ", "))
+}
@@ -1,6 +1,14 @@
 package crawler
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
 
 // Config 爬虫配置
 type Config struct {
@@ -9,11 +17,291 @@ type Config struct {
 	Cookies     string            // Cookie 字符串，格式: "key1=value1; key2=value2"
 	Headers     map[string]string // 自定义请求头
 	Proxy       string            // 代理地址，如 "http://127.0.0.1:8080"
+	ProxyPacURL string            // PAC 文件地址（Chrome --proxy-pac-url），按目标 URL 选择代理；与 Proxy 互斥
 	UserAgent   string            // 自定义 User-Agent
 	ChromePath  string            // Chrome/Chromium 可执行文件路径，空则自动搜索
 	Headless    bool              // 是否无头模式
 	Concurrency int               // 并发数（批量爬取时）
 	MaxRetry    int               // 失败重试次数
+
+	// KeepOpen 非 nil 时，每个页面自动爬取结束后 Tab 保持打开并调用 KeepOpen，返回前监听不断开，
+	// 手动交互期间加载的资源照常抓取；ctx 在 Tab 或浏览器关闭时结束。
+	// 用于排查资源为何没有抓到，通常配合 Headless=false；不能与 Concurrency > 1 同时使用
+	KeepOpen func(ctx context.Context)
+
+	// RealUserAgent 未指定 UserAgent 时，按实际启动的 Chrome 主版本和当前系统生成普通桌面版 Chrome 的 UA
+	// （默认 UA 含 HeadlessChrome，部分站点会因此返回降级内容），并同步 Sec-CH-UA 系列 Client Hints
+	RealUserAgent bool
+
+	UserAgentRotation []string // 批量模式下按 URL 的输入顺序轮流使用的 User-Agent（覆盖 UserAgent），单 URL 模式使用第一个
+
+	SharedSession bool // 批量模式下所有 URL 在同一浏览器中按输入顺序逐个爬取，Cookie 等会话状态延续（忽略 Concurrency）
+
+	CookieSameSiteBypass bool // 注入的 Cookie 设为 SameSite=None; Secure，使跨站 iframe / XHR 请求也能带上（目标需为 HTTPS）
+
+	FollowClientRedirects bool // 加载后页面跨源跳转（location.replace 等）时继续抓取并以最终页面为主文档；false 时在跳转处停止抓取（CrawlResult.NavigatedAway）
+
+	RetryOnStatusCodes []int         // 主文档返回这些状态码时在同一 Tab 中重新导航（如部署期间的 503）
+	RetryAttempts      int           // RetryOnStatusCodes 的重新导航次数
+	RetryBaseDelay     time.Duration // 每次重新导航前的等待（固定间隔，不退避）
+
+	ChromeStabilizationDelay time.Duration // 浏览器启动（空 Run）完成后额外等待的时间，不计入爬取超时；默认 0
+	NavigationSettleDelay    time.Duration // 导航且 WaitUntil 事件触发后、滚动之前的固定等待；默认 0
+	ScrollDuration           time.Duration // 滚动各步之后的等待合计，按默认步骤的比例分配；0 使用默认（3.9s）
+
+	// TimeBudget 单页总时间预算，由 ApplyTimeBudget 设置并分配到 Timeout、NavigationSettleDelay、
+	// ScrollDuration 和 IdleTimeout；只用于日志，直接修改该字段不会重新分配
+	TimeBudget time.Duration
+
+	WaitUntil string // 导航后等待的生命周期事件: commit / domcontentloaded / load / networkidle0 / networkidle2，空表示 load
+
+	// NavigationWaitEvent 以 CDP Page.lifecycleEvent 名称指定导航后等待的事件：
+	// load / DOMContentLoaded / networkIdle / networkAlmostIdle / commit，设置时优先于 WaitUntil。
+	// 服务端渲染的页面用 load；SPA 用 networkIdle（长轮询页面用 networkAlmostIdle）等首屏接口返回；
+	// 只需响应本身的 JSON 接口等地址用 commit
+	NavigationWaitEvent string
+
+	CollapsePolling   bool     // 折叠仅缓存破坏参数不同的重复响应（轮询 XHR），只保留首个响应
+	CacheBusterParams []string // 视为缓存破坏参数的查询键，空则使用 DefaultCacheBusterParams
+	MaxSourceMapSize  int64    // Source Map 文件大小上限（字节），0 表示不预检
+
+	MapHostAllowlist   []string // 允许下载 source map 的主机，支持 *.example.com；相对地址和内联 map 不受限
+	SameOriginMapsOnly bool     // 绝对地址的 source map 只从与引用资源同源的地址下载（可由 MapHostAllowlist 放宽）
+
+	Delay             time.Duration // 批量模式下同一主机相邻两次爬取的间隔
+	RespectCrawlDelay bool          // 遵守 robots.txt 的 Crawl-delay（覆盖该主机的 Delay）
+	MaxCrawlDelay     time.Duration // Crawl-delay 上限，0 表示不封顶
+	MaxDuration       time.Duration // 批量模式整体时间上限，到时取消进行中的爬取并跳过剩余 URL；0 表示不限
+
+	CaptureWorkers bool // 附加到 worker / 跨进程 iframe 子目标，抓取其独立执行上下文中的网络请求
+
+	LoginURLPatterns []string // 登录页 URL 路径特征，空则使用 DefaultLoginURLPatterns
+	RequireSelector  string   // 加载完成后必须存在的 CSS 选择器，缺失则该 URL 判为失败
+
+	ClickMarkers    []ClickMarker // 初始加载空闲后依次标记并点击，之后的请求归到对应标记（Resource.Marker）
+	OnlyAfterMarker string        // 只保存该标记及之后标记下的资源，须为 ClickMarkers 中的标记
+
+	CaptureRenderedHTML bool // 加载完成后保存渲染后的 DOM 到 CrawlResult.RenderedHTML
+
+	MaxDOMBytes int64 // 序列化 DOM 超过该字节数时跳过截图、渲染 DOM 和链接提取（记入 CrawlResult.Skipped），0 表示不检查
+
+	LocalOverrides map[string]string // URL 模式 → 本地文件路径，命中时用本地文件替代抓取到的响应体；模式支持 * 通配
+
+	ExtraRootCAs    []string // 备用 HTTP 客户端额外信任的根证书（PEM 文件路径）
+	TLSPinningCerts []string // 备用 HTTP 客户端的公钥固定值：DER 公钥的 SHA-256，base64 编码
+
+	DisableFallbackDownload bool          // 禁止在浏览器取不到响应体时直接下载，改为记录 BodyError
+	BodyFetchTimeout        time.Duration // 单个响应体从浏览器获取的超时，0 使用 DefaultBodyFetchTimeout
+
+	Deterministic  bool // 确定性模式：固定视口、冻结 Date / Math.random、禁用动画，用于回归比对
+	ViewportWidth  int  // 视口宽度（像素），0 表示浏览器默认；确定性模式默认 1366
+	ViewportHeight int  // 视口高度（像素），0 表示浏览器默认；确定性模式默认 768
+
+	DumpNetworkEvents bool // 记录全部 network.* CDP 事件，输出 network-events.jsonl 供离线分析
+
+	ResourceLabels map[string]string // 附加到本次爬取所有资源上的标签，便于下游按任务关联
+
+	DisableDiskCache bool // 禁用 Chrome 磁盘缓存并在导航前清空缓存，避免复爬时拿到旧响应
+
+	// EnableAudioContext 允许无用户手势创建和启动 AudioContext（--autoplay-policy=no-user-gesture-required），
+	// 并开启音频服务相关特性，录音 / 音乐类 SPA 的 AudioWorklet 等音频处理脚本才会加载。
+	// Linux 服务器上没有声卡或 PulseAudio / ALSA 时可能仍需额外的系统音频配置（如虚拟声卡）
+	EnableAudioContext bool
+
+	PerOriginConcurrency int // 批量模式下同一可注册域名的最大并发数，<=0 表示只受 Concurrency 限制
+
+	// RecursionWorkers 递归爬取时同时处理 frontier 的 Tab 数，分布在 Concurrency 个浏览器进程中；
+	// 同样受 PerOriginConcurrency 和 Delay / Crawl-delay 限制。0 表示与 Concurrency 相同（每个进程一个 Tab）
+	RecursionWorkers int
+
+	HARReplayMode bool   // 回放模式：不启动浏览器，从 HARFile 还原资源表，用于离线测试
+	HARFile       string // 回放使用的 HAR 文件（storage.ExportHAR 的输出）
+
+	RecursionDepth int    // 递归爬取同主机链接的层数，0 表示只爬目标页
+	MaxPages       int    // 递归爬取的页面数上限，0 表示不限
+	CrawlStrategy  string // 递归出队顺序: bfs（默认）或 dfs
+	Resume         bool   // 从输出目录中的 frontier.jsonl 继续上次中断的递归爬取
+
+	FollowCSPReportURIs bool // 从所有资源的 CSP 头中提取 report-uri / report-to 上报地址，记入索引和报告（不请求）
+
+	SuppressEmptyContent bool // 保存时跳过响应体为空的资源（以 slog.Debug 记录 URL 和原因）；false 时写入零字节文件
+
+	SkipStatusCodes []int // 保存时跳过这些状态码的响应（如 404 错误页、5xx），仍写入索引并在报告中列为失败；为空时全部保存
+
+	CaptureTimingAPI bool // 加载完成后读取 Performance Resource Timing，合并到 Resource.TimingBreakdown
+
+	SlowResourceThreshold time.Duration // 从发出请求到取得响应体超过该耗时的资源打印警告，0 表示不检查
+
+	BlockDomains     []string // 浏览器中拦截的域名（含子域名），如 TrackingBlocklist 预设
+	BlockURLPatterns []string // 浏览器中拦截的 URL 特征，* 通配，匹配完整 URL
+
+	Filters    []ResourceFilter // 自定义资源筛选，按顺序执行，任一返回 false 则不获取也不保存（仅 API 使用）
+	PathPrefix string           // 只保留 URL 路径以此开头的资源（如 /docs/），用于镜像站点的一部分；递归爬取时也只跟随该路径下的链接
+
+	// ResourceIgnoreList 抓到后不保存的常见追踪地址：预设名（ignorelist.Analytics、Advertising、Social）
+	// 或自定义清单文件的路径。与 BlockDomains 不同，请求照常发出、脚本照常执行，只是不获取响应体也不保存
+	ResourceIgnoreList []string
+
+	CapturePrefetchAndPreload bool // 滚动后在页面中 fetch() 一次 <link rel="prefetch|preload|modulepreload"> 提示的资源，抓取未访问路由的拆分 chunk
+
+	ScrollScreenshots bool // 滚动阶段每一步后截取当前视口，保存到 CrawlResult.Screenshots，记录懒加载的渲染过程
+
+	ForwardHTTPHeaders []string // 写入 resources.json 的响应头（不区分大小写），空表示全部写入
+
+	DataURIThreshold int64 // 保存 CSS / HTML 时把解码后超过该字节数的 data URI 抽到 _data/ 下，0 表示不抽取
+	RewriteDataURIs  bool  // 抽取后把引用改写为指向 _data/ 的相对路径（页面可离线打开）；false 时替换为占位注释
+
+	PrettyPrintJSON bool // 保存时将 JSON 响应缩进格式化（resources.json 中的 sha256 / size 仍按原始响应），默认保留原始内容
+
+	StripSourceMappingURL bool // 保存 JS / CSS 时去掉末尾的 sourceMappingURL 注释，不暴露 .map 地址；source map 提取仍使用原始内容
+
+	SkipUnchangedSources bool // 保存 source map 提取的源文件时跳过与磁盘上同路径文件内容相同的，只写新出现或变化的源文件
+
+	SniffMime bool // 声明为 text/plain、application/octet-stream 等通用类型的资源按内容嗅探实际类型（Resource.DetectedMimeType）
+
+	CapturePrivacy bool // 加载完成后读取 Cookie 与跨站 iframe 的 localStorage，区分第一方 / 第三方，保存到 CrawlResult.Privacy
+
+	CaptureSession bool // 爬取结束（KeepOpen 返回）后读取全部 Cookie 和页面源的 localStorage，供 Spider.ExportSession 导出
+
+	ExtractInlineScripts bool // 加载完成后将没有 src 的 <script> 内容保存为 inline://<host>/inline_script_<N>.js 合成资源，同样提取 source map
+	ExtractInlineStyles  bool // 加载完成后将 <style> 内容保存为 inline://<host>/inline_style_<N>.css 合成资源，同样提取 CSS source map
+
+	// IncludeNavigatorProperties 加载完成后将页面看到的 navigator 属性（userAgent、platform、vendor、language、
+	// cookieEnabled、webdriver）保存为 diagnostic://<host>/navigator.json，用于排查 UA / 平台伪装的效果
+	IncludeNavigatorProperties bool
+
+	CaptureCoverage bool // 爬取期间统计 JS 执行覆盖率和 CSS 规则使用情况，保存到 CrawlResult.Coverage，用于找出未使用的代码
+
+	// CaptureBrowserIssues 爬取期间开启 Log 和 Audits 域，把 CSP 违规、混合内容、CORS、Cookie 问题和弃用警告
+	// 收集到 CrawlResult.BrowserIssues，用于解释资源为何被拦截或缺失
+	CaptureBrowserIssues bool
+
+	DisableJavaScriptDialogs bool // 自动关闭 alert / confirm / prompt / beforeunload 对话框（记入 CrawlResult.Dialogs），避免页面脚本被阻塞
+	DialogAcceptValue        bool // 自动关闭时对 confirm / prompt 的回答：true 为确定，false 为取消
+}
+
+// Validate 检查配置，返回列出全部问题的错误（errors.Join），配置有效时返回 nil
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(bad bool, format string, args ...any) {
+		if bad {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Timeout <= 0, "Timeout 必须大于 0，当前值: %v", c.Timeout)
+	check(c.IdleTimeout < 0, "IdleTimeout 不能为负数，当前值: %v", c.IdleTimeout)
+	check(c.ChromeStabilizationDelay < 0, "ChromeStabilizationDelay 不能为负数，当前值: %v", c.ChromeStabilizationDelay)
+	check(c.NavigationSettleDelay < 0, "NavigationSettleDelay 不能为负数，当前值: %v", c.NavigationSettleDelay)
+	check(c.ScrollDuration < 0, "ScrollDuration 不能为负数，当前值: %v", c.ScrollDuration)
+	check(c.TimeBudget < 0, "TimeBudget 不能为负数，当前值: %v", c.TimeBudget)
+	_, knownWait := lifecycleEventNames[c.WaitUntil]
+	check(c.WaitUntil != "" && !knownWait, "WaitUntil 仅支持 commit、domcontentloaded、load、networkidle0、networkidle2，当前值: %s", c.WaitUntil)
+	_, knownEvent := waitUntilFor(c.NavigationWaitEvent)
+	check(c.NavigationWaitEvent != "" && !knownEvent, "NavigationWaitEvent 仅支持 load、DOMContentLoaded、networkIdle、networkAlmostIdle、commit，当前值: %s", c.NavigationWaitEvent)
+	check(c.PathPrefix != "" && !strings.HasPrefix(c.PathPrefix, "/"), "PathPrefix 必须以 / 开头，当前值: %s", c.PathPrefix)
+	check(c.Concurrency <= 0, "Concurrency 必须大于 0，当前值: %d", c.Concurrency)
+	check(c.KeepOpen != nil && c.Concurrency > 1, "KeepOpen 不能与 Concurrency > 1 同时使用，当前值: %d", c.Concurrency)
+	check(c.MaxRetry < 0, "MaxRetry 不能为负数，当前值: %d", c.MaxRetry)
+	check(c.RetryAttempts < 0, "RetryAttempts 不能为负数，当前值: %d", c.RetryAttempts)
+	check(c.RetryBaseDelay < 0, "RetryBaseDelay 不能为负数，当前值: %v", c.RetryBaseDelay)
+	for _, code := range c.RetryOnStatusCodes {
+		check(code < 100 || code > 599, "RetryOnStatusCodes 中的状态码无效: %d", code)
+	}
+	for _, code := range c.SkipStatusCodes {
+		check(code < 100 || code > 599, "SkipStatusCodes 中的状态码无效: %d", code)
+	}
+	check(c.MaxDOMBytes < 0, "MaxDOMBytes 不能为负数，当前值: %d", c.MaxDOMBytes)
+	check(c.DataURIThreshold < 0, "DataURIThreshold 不能为负数，当前值: %d", c.DataURIThreshold)
+	check(c.MaxSourceMapSize < 0, "MaxSourceMapSize 不能为负数，当前值: %d", c.MaxSourceMapSize)
+	check(c.Delay < 0, "Delay 不能为负数，当前值: %v", c.Delay)
+	check(c.MaxCrawlDelay < 0, "MaxCrawlDelay 不能为负数，当前值: %v", c.MaxCrawlDelay)
+	check(c.MaxDuration < 0, "MaxDuration 不能为负数，当前值: %v", c.MaxDuration)
+	check(c.SlowResourceThreshold < 0, "SlowResourceThreshold 不能为负数，当前值: %v", c.SlowResourceThreshold)
+	check(c.BodyFetchTimeout < 0, "BodyFetchTimeout 不能为负数，当前值: %v", c.BodyFetchTimeout)
+	check(c.ViewportWidth < 0 || c.ViewportHeight < 0, "视口大小不能为负数，当前值: %dx%d", c.ViewportWidth, c.ViewportHeight)
+	check((c.ViewportWidth > 0) != (c.ViewportHeight > 0), "ViewportWidth 和 ViewportHeight 需要同时设置，当前值: %dx%d", c.ViewportWidth, c.ViewportHeight)
+	check(c.RecursionDepth < 0, "RecursionDepth 不能为负数，当前值: %d", c.RecursionDepth)
+	check(c.RecursionWorkers < 0, "RecursionWorkers 不能为负数，当前值: %d", c.RecursionWorkers)
+	check(max(c.RecursionWorkers, c.Concurrency) > MaxTabs, "RecursionWorkers / Concurrency 不能超过 %d（同时打开的 Tab 上限），当前值: %d / %d",
+		MaxTabs, c.RecursionWorkers, c.Concurrency)
+	check(c.MaxPages < 0, "MaxPages 不能为负数，当前值: %d", c.MaxPages)
+	check(c.CrawlStrategy != "" && c.CrawlStrategy != "bfs" && c.CrawlStrategy != "dfs",
+		"CrawlStrategy 仅支持 bfs 或 dfs，当前值: %s", c.CrawlStrategy)
+	check(c.HARReplayMode && c.HARFile == "", "HARReplayMode 需要指定 HARFile")
+	check(c.DialogAcceptValue && !c.DisableJavaScriptDialogs, "DialogAcceptValue 需要同时开启 DisableJavaScriptDialogs")
+	markerLabels := make(map[string]bool)
+	for _, m := range c.ClickMarkers {
+		check(m.Label == "" || m.Selector == "", "ClickMarkers 的标记名和选择器都不能为空，当前值: %q=%q", m.Label, m.Selector)
+		check(markerLabels[m.Label], "ClickMarkers 中的标记名重复: %s", m.Label)
+		markerLabels[m.Label] = true
+	}
+	check(c.OnlyAfterMarker != "" && !markerLabels[c.OnlyAfterMarker], "OnlyAfterMarker %q 不在 ClickMarkers 中", c.OnlyAfterMarker)
+
+	if c.Proxy != "" {
+		u, err := url.Parse(c.Proxy)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("Proxy 地址无效 %q: %v", c.Proxy, err))
+		case u.Host == "":
+			errs = append(errs, fmt.Errorf("Proxy 地址缺少主机，应如 http://127.0.0.1:8080，当前值: %s", c.Proxy))
+		case u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks4" && u.Scheme != "socks5":
+			errs = append(errs, fmt.Errorf("Proxy 仅支持 http / https / socks4 / socks5，当前值: %s", c.Proxy))
+		}
+	}
+
+	if c.ProxyPacURL != "" {
+		u, err := url.Parse(c.ProxyPacURL)
+		switch {
+		case c.Proxy != "":
+			errs = append(errs, fmt.Errorf("Proxy 与 ProxyPacURL 不能同时设置（当前 Proxy: %s，ProxyPacURL: %s）", c.Proxy, c.ProxyPacURL))
+		case err != nil:
+			errs = append(errs, fmt.Errorf("ProxyPacURL 地址无效 %q: %v", c.ProxyPacURL, err))
+		case u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "file" && u.Scheme != "data":
+			errs = append(errs, fmt.Errorf("ProxyPacURL 仅支持 http / https / file / data 地址，如 http://wpad/wpad.dat，当前值: %s", c.ProxyPacURL))
+		}
+	}
+
+	if c.ChromePath != "" {
+		if _, err := os.Stat(c.ChromePath); err != nil {
+			errs = append(errs, fmt.Errorf("ChromePath 不存在: %s", c.ChromePath))
+		}
+	}
+	if c.HARFile != "" {
+		if _, err := os.Stat(c.HARFile); err != nil {
+			errs = append(errs, fmt.Errorf("HARFile 不存在: %s", c.HARFile))
+		}
+	}
+	for _, path := range c.ExtraRootCAs {
+		if _, err := os.Stat(path); err != nil {
+			errs = append(errs, fmt.Errorf("ExtraRootCAs 证书文件不存在: %s", path))
+		} else if _, err := loadRootCAs([]string{path}); err != nil {
+			errs = append(errs, fmt.Errorf("ExtraRootCAs: %w", err))
+		}
+	}
+	if _, err := parsePins(c.TLSPinningCerts); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// DefaultCacheBusterParams 常见的缓存破坏查询参数
+var DefaultCacheBusterParams = []string{"ts", "_", "cb", "t", "timestamp", "nocache"}
+
+// MaxTabs 同时打开的 Tab 上限：批量模式为 Concurrency，递归模式为 RecursionWorkers
+const MaxTabs = 32
+
+// DefaultBodyFetchTimeout 单个响应体获取的默认超时
+const DefaultBodyFetchTimeout = 15 * time.Second
+
+// UserAgentFor 返回第 i 个 URL（从 0 开始）使用的 User-Agent：设置了 UserAgentRotation 时
+// 按顺序轮换，否则为 UserAgent（空表示浏览器默认）
+func (c *Config) UserAgentFor(i int) string {
+	if len(c.UserAgentRotation) == 0 {
+		return c.UserAgent
+	}
+	return c.UserAgentRotation[i%len(c.UserAgentRotation)]
 }
 
 // DefaultConfig 返回默认配置
@@ -25,5 +313,18 @@ func DefaultConfig() *Config {
 		Headless:    true,
 		Concurrency: 1,
 		MaxRetry:    2,
+
+		RetryAttempts:  3,
+		RetryBaseDelay: 3 * time.Second,
+
+		BodyFetchTimeout: DefaultBodyFetchTimeout,
+		WaitUntil:        WaitLoad,
+
+		RealUserAgent: true,
+
+		RespectCrawlDelay:    true,
+		PerOriginConcurrency: 2,
+		SuppressEmptyContent: true,
+		SniffMime:            true,
 	}
 }
@@ -0,0 +1,234 @@
+package crawler
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/chromedp/cdproto/css"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/profiler"
+	"github.com/chromedp/chromedp"
+
+	"spider/internal/logging"
+)
+
+// CoverageReport 页面加载和交互期间 JS / CSS 的实际使用情况（CaptureCoverage），写入 coverage.json
+type CoverageReport struct {
+	TotalBytes int            `json:"total_bytes"`
+	UsedBytes  int            `json:"used_bytes"`
+	Files      []FileCoverage `json:"files"` // 按未使用字节数从多到少排列
+}
+
+// FileCoverage 单个脚本或样式表的覆盖率。偏移按 DevTools 的字符位置计，内联脚本 / 样式相对各自内容
+type FileCoverage struct {
+	URL         string   `json:"url"`
+	Type        string   `json:"type"` // js / css
+	Inline      bool     `json:"inline,omitempty"`
+	TotalBytes  int      `json:"total_bytes"`
+	UsedBytes   int      `json:"used_bytes"`
+	UsedPercent float64  `json:"used_percent"`
+	UsedRanges  [][2]int `json:"used_ranges"` // 执行过的代码 / 命中的规则，[start, end)
+}
+
+// UnusedBytes 未执行的代码或未命中的规则的字节数
+func (f FileCoverage) UnusedBytes() int {
+	return f.TotalBytes - f.UsedBytes
+}
+
+// coverageSpan 一段带使用标记的区间；嵌套时内层覆盖外层
+type coverageSpan struct {
+	start, end int
+	used       bool
+}
+
+// startCoverage 导航前开启 JS 精确覆盖率（块级）和 CSS 规则使用跟踪。
+// 失败只记录警告，不影响抓取
+func (s *Spider) startCoverage(ctx context.Context) error {
+	err := func() error {
+		if err := profiler.Enable().Do(ctx); err != nil {
+			return err
+		}
+		if _, err := profiler.StartPreciseCoverage().WithDetailed(true).Do(ctx); err != nil {
+			return err
+		}
+		// CSS 域依赖 DOM 域
+		if err := dom.Enable().Do(ctx); err != nil {
+			return err
+		}
+		if err := css.Enable().Do(ctx); err != nil {
+			return err
+		}
+		return css.StartRuleUsageTracking().Do(ctx)
+	}()
+	if err != nil {
+		logging.Warnf("开启覆盖率统计失败: %v", err)
+		return nil
+	}
+	s.mu.Lock()
+	s.coverageStarted = true
+	s.mu.Unlock()
+	return nil
+}
+
+// recordStyleSheet 记录样式表的 URL 和长度，供 CSS 覆盖率按样式表汇总
+func (s *Spider) recordStyleSheet(header *css.StyleSheetHeader) {
+	if header == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.styleSheets == nil {
+		s.styleSheets = make(map[css.StyleSheetID]*css.StyleSheetHeader)
+	}
+	s.styleSheets[header.StyleSheetID] = header
+}
+
+// captureCoverage 读取并停止覆盖率统计，按文件汇总已使用的区间，保存到 CrawlResult.Coverage
+func (s *Spider) captureCoverage(ctx context.Context) {
+	s.mu.Lock()
+	started := s.coverageStarted
+	s.mu.Unlock()
+	if !started {
+		return
+	}
+
+	var scripts []*profiler.ScriptCoverage
+	var rules []*css.RuleUsage
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		if scripts, _, err = profiler.TakePreciseCoverage().Do(ctx); err != nil {
+			return err
+		}
+		if rules, err = css.StopRuleUsageTracking().Do(ctx); err != nil {
+			return err
+		}
+		return profiler.StopPreciseCoverage().Do(ctx)
+	}))
+	if err != nil {
+		logging.Warnf("读取覆盖率失败: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	documentURL := s.pageURL
+	var files []FileCoverage
+	for _, script := range scripts {
+		if f, ok := scriptCoverage(script, documentURL); ok {
+			files = append(files, f)
+		}
+	}
+	files = append(files, styleSheetCoverage(rules, s.styleSheets)...)
+	s.mu.Unlock()
+
+	report := &CoverageReport{Files: files}
+	for _, f := range files {
+		report.TotalBytes += f.TotalBytes
+		report.UsedBytes += f.UsedBytes
+	}
+	sort.SliceStable(report.Files, func(i, j int) bool {
+		a, b := report.Files[i], report.Files[j]
+		if a.UnusedBytes() != b.UnusedBytes() {
+			return a.UnusedBytes() > b.UnusedBytes()
+		}
+		return a.URL < b.URL
+	})
+
+	s.mu.Lock()
+	s.result.Coverage = report
+	s.mu.Unlock()
+
+	log.Printf("覆盖率: %d 个脚本 / 样式表，共 %d 字节，已使用 %d 字节（%.1f%%）",
+		len(report.Files), report.TotalBytes, report.UsedBytes, usedPercent(report.UsedBytes, report.TotalBytes))
+}
+
+// scriptCoverage 汇总一个脚本的块级覆盖率：顶层函数的区间覆盖整个脚本，
+// 内层区间的执行次数覆盖外层，次数大于 0 的部分视为已执行。没有 URL 的脚本（eval 等）跳过
+func scriptCoverage(script *profiler.ScriptCoverage, documentURL string) (FileCoverage, bool) {
+	if script == nil || !strings.HasPrefix(script.URL, "http") {
+		return FileCoverage{}, false
+	}
+	var spans []coverageSpan
+	length := 0
+	for _, fn := range script.Functions {
+		for _, r := range fn.Ranges {
+			spans = append(spans, coverageSpan{start: int(r.StartOffset), end: int(r.EndOffset), used: r.Count > 0})
+			length = max(length, int(r.EndOffset))
+		}
+	}
+	if length == 0 {
+		return FileCoverage{}, false
+	}
+	f := FileCoverage{URL: script.URL, Type: "js", Inline: script.URL == documentURL, TotalBytes: length}
+	f.UsedBytes, f.UsedRanges = usedRanges(length, spans)
+	f.UsedPercent = usedPercent(f.UsedBytes, f.TotalBytes)
+	return f, true
+}
+
+// styleSheetCoverage 按样式表汇总规则使用情况，命中的规则所在区间视为已使用。
+// 没有 URL 的样式表（new CSSStyleSheet() 创建的）跳过
+func styleSheetCoverage(rules []*css.RuleUsage, headers map[css.StyleSheetID]*css.StyleSheetHeader) []FileCoverage {
+	spans := make(map[css.StyleSheetID][]coverageSpan)
+	for _, r := range rules {
+		if r.Used {
+			spans[r.StyleSheetID] = append(spans[r.StyleSheetID], coverageSpan{start: int(r.StartOffset), end: int(r.EndOffset), used: true})
+		}
+	}
+
+	var files []FileCoverage
+	for id, header := range headers {
+		if !strings.HasPrefix(header.SourceURL, "http") || header.Length <= 0 {
+			continue
+		}
+		length := int(header.Length)
+		f := FileCoverage{URL: header.SourceURL, Type: "css", Inline: header.IsInline, TotalBytes: length}
+		f.UsedBytes, f.UsedRanges = usedRanges(length, spans[id])
+		f.UsedPercent = usedPercent(f.UsedBytes, f.TotalBytes)
+		files = append(files, f)
+	}
+	return files
+}
+
+// usedRanges 将可能嵌套的区间按 外层在前、内层在后 依次标记，返回已使用的字节数和合并后的区间
+func usedRanges(length int, spans []coverageSpan) (int, [][2]int) {
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+	used := make([]bool, length)
+	for _, sp := range spans {
+		start, end := max(sp.start, 0), min(sp.end, length)
+		for i := start; i < end; i++ {
+			used[i] = sp.used
+		}
+	}
+
+	total := 0
+	ranges := [][2]int{}
+	for i := 0; i < length; {
+		if !used[i] {
+			i++
+			continue
+		}
+		j := i
+		for j < length && used[j] {
+			j++
+		}
+		ranges = append(ranges, [2]int{i, j})
+		total += j - i
+		i = j
+	}
+	return total, ranges
+}
+
+// usedPercent 使用比例（百分比，保留一位小数）
+func usedPercent(used, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(used)/float64(total)*1000) / 10
+}
@@ -2,6 +2,8 @@ package crawler
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -12,8 +14,18 @@ import (
 	"sync"
 	"time"
 
+	"github.com/chromedp/cdproto/audits"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/css"
+	"github.com/chromedp/cdproto/emulation"
+	cdplog "github.com/chromedp/cdproto/log"
 	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/target"
 	"github.com/chromedp/chromedp"
+
+	"spider/internal/ignorelist"
+	"spider/internal/logging"
 )
 
 const maxBodyBytes = 100 * 1024 * 1024 // 100 MB per resource
@@ -23,94 +35,296 @@ type Resource struct {
 	URL          string
 	Method       string
 	StatusCode   int
+	StatusText   string
+	Protocol     string // 如 "http/1.1"、"h2"
+	RemoteIP     string
 	MimeType     string
 	Content      []byte
 	Headers      map[string]string
 	ResponseTime time.Time
+
+	RequestHeaders map[string]string // 实际发出的请求头
+	RequestBody    []byte            // 请求体（POST 等）
+	Context        string            // 发起请求的执行上下文: page / iframe / worker / shared_worker / service_worker
+	BodyError      string            // 未能从浏览器会话取得响应体的原因
+	BodyTimedOut   bool              // BodyError 是否由获取超时引起
+	Labels         map[string]string // 来自 Config.ResourceLabels 的标签（任务 ID、环境等）
+
+	CollapsedCount int       // 被折叠的重复轮询响应数（不含首个响应）
+	LastSeen       time.Time // 最后一次收到同一轮询资源的时间
+
+	ReportEndpoints  []string // CSP report-uri / report-to 指向的上报地址（FollowCSPReportURIs）
+	SourceMapSkipped string   // 按主机限制跳过的 source map 及原因
+
+	SourceMapTruncated string // 因提取上限（单文件大小、合计大小、源文件数）跳过部分源文件的 source map 及跳过数量
+
+	Pages   []string      // 收到响应时主框架所在的页面 URL；递归爬取时被多个页面共用的资源列出全部页面
+	Latency time.Duration // 从发出请求到收到响应头的耗时，未知时为 0
+
+	FetchDuration time.Duration // 从发出请求到取得响应体的总耗时（含备用下载），未知时为 0
+
+	Marker string // 请求发出时生效的标记（Mark / ClickMarkers），标记前的初始加载为空
+
+	TimingBreakdown *TimingBreakdown // Resource Timing API 的分阶段耗时（CaptureTimingAPI）
+
+	DetectedMimeType string // 按内容嗅探出的类型，仅在与声明的 MimeType 不同时设置（SniffMime）
+
+	DataURIs []DataURIFile // 保存时从内容中抽出的超大 data URI（DataURIThreshold）
+
+	ResourceHint string // 页面以 <link rel> 提示过该资源时的类型: preload / modulepreload / prefetch（CapturePrefetchAndPreload）
+
+	BaseURL string // 页面的 document.baseURI（<base href>），仅在与页面 URL 不同时设置于主文档和内联合成资源上，见 ReferenceBase
+}
+
+// DataURIFile 从 CSS / HTML 中抽出为独立文件的 data URI
+type DataURIFile struct {
+	Path     string `json:"path"` // 相对输出根目录的 / 分隔路径，如 _data/3f2a….woff2
+	MimeType string `json:"mime_type"`
+	Size     int    `json:"size"` // 解码后的字节数
+}
+
+// CrawlResult 单次爬取的页面级结果
+type CrawlResult struct {
+	FinalURL        string            // 加载完成后的页面 URL（跟随重定向后）
+	DocumentURL     string            // 主文档响应的 URL（资源表中的键）
+	Title           string            // 页面标题
+	BaseURL         string            // document.baseURI：页面中相对地址的解析基准，有 <base href> 时可能与 FinalURL 不同源
+	RenderedHTML    string            // 渲染后的 DOM（仅 CaptureRenderedHTML 开启时）
+	LikelyLoginPage bool              // 疑似抓到了登录页（会话过期等）
+	LoginSignal     string            // 命中的登录页特征
+	Links           []string          // 页面中 <a href> 的绝对 URL，由浏览器按 document.baseURI 解析（递归爬取时作为下一层候选）
+	Screenshots     [][]byte          // 滚动各步骤的视口截图（PNG，仅 ScrollScreenshots 开启时）
+	PeakFetches     int               // 同时进行的响应体获取数峰值
+	Skipped         []SkippedArtifact // 因 DOM 过大等原因跳过的可选产物（MaxDOMBytes）
+	Privacy         *PrivacyReport    // 第三方 Cookie 与跨站 iframe 存储（仅 CapturePrivacy 开启时）
+	Dialogs         []JSDialog        // 自动关闭的 JavaScript 对话框（DisableJavaScriptDialogs）
+	Navigations     []string          // 主框架依次提交的文档 URL（服务端重定向后的首个文档在前），页面自己跳转时多于一项
+	NavigatedAway   string            // 因跨源跳转停止抓取时的跳转目标（FollowClientRedirects 关闭时）
+	Coverage        *CoverageReport   // JS / CSS 覆盖率（仅 CaptureCoverage 开启时）
+	BrowserIssues   []BrowserIssue    // CSP 违规、混合内容、CORS、Cookie、弃用等浏览器问题（仅 CaptureBrowserIssues 开启时）
+
+	// 以下仅由 Run 填充，Result() 返回的结果中为空
+	Resources     map[string]*Resource // 抓取到的资源（副本），同 GetResources
+	Stats         CrawlStats           // 过滤、拦截、响应体失败等计数
+	Failures      []ResourceFailure    // 未能取得响应体的资源，按 URL 排序
+	NetworkEvents []byte               // 同 NetworkEvents（DumpNetworkEvents）
+}
+
+// requestInfo 记录 requestWillBeSent 中的请求数据，供响应到达时关联
+type requestInfo struct {
+	method  string
+	headers map[string]string
+	body    []byte
+	sent    time.Time
+	marker  string // 发出时生效的标记
 }
 
 // Spider 爬虫结构
 type Spider struct {
 	resources   map[string]*Resource
+	requests    map[network.RequestID]*requestInfo
+	attached    map[target.ID]bool // 已附加的 worker / iframe 子目标
+	loads       *loadTracker       // 各请求的 loadingFinished / loadingFailed，响应体获取前等待
+	overrides   []localOverride
+	onCapture   func(*Resource) // 响应体就绪后的回调
+	events      *eventRecorder  // DumpNetworkEvents 开启时记录 network.* 事件
+	eventLog    []byte          // 爬取结束后的 JSON Lines 事件日志
 	mu          sync.Mutex
 	wg          sync.WaitGroup
 	config      *Config
 	httpClient  *http.Client
 	lastCapture time.Time // 最后一次成功抓取资源的时间，用于空闲检测
+	result      CrawlResult
+	pageURL     string // 当前主框架文档的 URL，用于标注资源所属页面
+
+	documentStatus int // 最近一次主框架文档响应的状态码（RetryOnStatusCodes）
+
+	initialLoader cdp.LoaderID // 初始导航的 loader，之后的新 loader 为页面自己发起的导航
+	navOrigin     string       // 初始导航（跟随服务端重定向后）的源
+
+	domTooLarge bool // DOM 超过 MaxDOMBytes，跳过截图、渲染 DOM 和链接提取
+
+	coverageStarted bool                                       // 覆盖率统计已成功开启（CaptureCoverage）
+	styleSheets     map[css.StyleSheetID]*css.StyleSheetHeader // 页面加载的样式表，CSS 覆盖率按此汇总
+
+	marker  string   // 当前标记，记录到此后发出的请求上
+	markers []string // 按设置顺序排列的全部标记
+
+	blocker      *blocker // BlockDomains / BlockURLPatterns 的 Go 侧匹配（HAR 回放）
+	blockedCount int      // 被拦截的请求数
+
+	filteredCount int // 被 Config.PathPrefix / Filters 跳过的资源数
+
+	ignore       *ignorelist.List // Config.ResourceIgnoreList 合并后的清单
+	ignoredCount int              // 命中 ResourceIgnoreList 未保存的资源数
+
+	drainDeadline time.Time // 响应体获取的最晚截止时间：导航超时 + BodyFetchTimeout
+	bodyFailures  int       // 未能取得响应体的资源数
+	bodyTimeouts  int       // 其中因超时失败的资源数
+
+	getBody    func(ctx context.Context, id network.RequestID) ([]byte, error) // 从浏览器取回响应体，测试中替换为假的 CDP 调用
+	renavigate func(ctx context.Context, targetURL string) error               // RetryOnStatusCodes 的重新导航，测试中替换为假的导航
+
+	fetchesInFlight int // 正在进行的响应体获取数
+	slowCount       int // 超过 SlowResourceThreshold 的资源数
+
+	realUA string // RealUserAgent 生成的 User-Agent，备用下载时使用
+
+	hints []resourceHint // 页面中的 prefetch / preload 提示（CapturePrefetchAndPreload）
+
+	issueIndex map[string]int // 已记录的浏览器问题 → CrawlResult.BrowserIssues 下标，用于合并重复条目
+
+	session  *SessionState // 爬取结束时读取的会话（CaptureSession），见 ExportSession
+	imported *SessionState // 爬取前恢复的会话，见 ImportSession
 }
 
-// New 创建新的爬虫实例
-func New(config *Config) *Spider {
+// New 创建新的爬虫实例，config 为 nil 时使用 DefaultConfig；配置无效时返回 Validate 的错误
+func New(config *Config) (*Spider, error) {
 	if config == nil {
 		config = DefaultConfig()
 	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	ignore, err := ignorelist.Load(config.ResourceIgnoreList)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Spider{
+		resources:  make(map[string]*Resource),
+		requests:   make(map[network.RequestID]*requestInfo),
+		attached:   make(map[target.ID]bool),
+		loads:      newLoadTracker(),
+		overrides:  compileOverrides(config.LocalOverrides),
+		blocker:    newBlocker(config),
+		ignore:     ignore,
+		config:     config,
+		httpClient: newHTTPClient(config, 10*time.Second),
+		getBody:    getResponseBody,
+	}
+	s.renavigate = s.renavigateInTab
+	return s, nil
+}
 
+// getResponseBody 通过 CDP 取回请求的响应体
+func getResponseBody(ctx context.Context, id network.RequestID) ([]byte, error) {
+	var body []byte
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		body, err = network.GetResponseBody(id).Do(ctx)
+		return err
+	}))
+	return body, err
+}
+
+// newHTTPClient 构建继承代理、TLS 配置的 HTTP 客户端
+func newHTTPClient(config *Config, timeout time.Duration) *http.Client {
 	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = buildTLSConfig(config)
 	if config.Proxy != "" {
 		if proxyURL, err := url.Parse(config.Proxy); err == nil {
 			transport.Proxy = http.ProxyURL(proxyURL)
 		}
 	}
-
-	return &Spider{
-		resources: make(map[string]*Resource),
-		config:    config,
-		httpClient: &http.Client{
-			Timeout:   10 * time.Second,
-			Transport: transport,
-		},
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
 	}
 }
 
-// buildAllocatorOptions 构建 Chrome 启动参数（供 Crawl 和 Pool 共用）
-func buildAllocatorOptions(config *Config) []chromedp.ExecAllocatorOption {
+// buildAllocatorOptions 构建 Chrome 启动参数（供 Crawl 和 Pool 共用）。
+// 未指定 ChromePath 时由 FindChrome 搜索，找不到浏览器时返回列出查找位置的错误。
+func buildAllocatorOptions(config *Config) ([]chromedp.ExecAllocatorOption, error) {
+	execPath, err := FindChrome(config.ChromePath)
+	if err != nil {
+		return nil, err
+	}
 	base := make([]chromedp.ExecAllocatorOption, len(chromedp.DefaultExecAllocatorOptions))
 	copy(base, chromedp.DefaultExecAllocatorOptions[:])
 	opts := append(base,
 		chromedp.Flag("headless", config.Headless),
 		chromedp.Flag("disable-gpu", true),
 		// 保留 Chrome 渲染器沙箱，不使用 --no-sandbox
+		chromedp.ExecPath(execPath),
 	)
-	if config.ChromePath != "" {
-		opts = append(opts, chromedp.ExecPath(config.ChromePath))
-	}
 	if config.Proxy != "" {
 		opts = append(opts, chromedp.ProxyServer(config.Proxy))
 	}
-	if config.UserAgent != "" {
-		opts = append(opts, chromedp.UserAgent(config.UserAgent))
+	if config.ProxyPacURL != "" {
+		opts = append(opts, chromedp.Flag("proxy-pac-url", config.ProxyPacURL))
+	}
+	if ua := config.UserAgentFor(0); ua != "" {
+		opts = append(opts, chromedp.UserAgent(ua))
+	}
+	if config.Deterministic {
+		opts = append(opts, chromedp.Flag("force-prefers-reduced-motion", true))
+	}
+	if config.ViewportWidth > 0 && config.ViewportHeight > 0 {
+		opts = append(opts, chromedp.WindowSize(config.ViewportWidth, config.ViewportHeight))
+	}
+	if config.DisableDiskCache {
+		// 磁盘缓存设为 1 字节，等同于禁用
+		opts = append(opts, chromedp.Flag("disk-cache-size", "1"))
+	}
+	if config.EnableAudioContext {
+		opts = append(opts,
+			chromedp.Flag("autoplay-policy", "no-user-gesture-required"),
+			chromedp.Flag("enable-features", "WebRTC,AudioServiceAudioStreams"),
+		)
 	}
-	return opts
+	return opts, nil
 }
 
 // Crawl 单 URL 模式：自行启动/销毁 Chrome 进程。
 // 浏览器热身时间不计入页面爬取超时。
 func (s *Spider) Crawl(targetURL string) error {
+	return s.crawl(context.Background(), targetURL)
+}
+
+// crawl 在 parent 下启动 Chrome 并爬取，parent 取消时结束浏览器进程
+func (s *Spider) crawl(parent context.Context, targetURL string) error {
 	if err := validateURL(targetURL); err != nil {
 		return err
 	}
+	if s.config.HARReplayMode {
+		return s.replayHAR(targetURL)
+	}
 
-	opts := buildAllocatorOptions(s.config)
-	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	opts, err := buildAllocatorOptions(s.config)
+	if err != nil {
+		return err
+	}
+	allocCtx, allocCancel := chromedp.NewExecAllocator(parent, opts...)
 	defer allocCancel()
 
-	ctx, cancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
-	defer cancel()
+	tabCtx, tabCancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+	defer tabCancel()
 
 	// 热身：在同一 tab context 上启动浏览器并建立连接；
 	// 不使用子 timeout context，避免 cancel() 污染 chromedp 内部 session。
 	startAt := time.Now()
-	if err := chromedp.Run(ctx); err != nil {
-		return fmt.Errorf("chrome failed to start: %w", err)
+	if err := chromedp.Run(tabCtx); err != nil {
+		return fmt.Errorf("%w: %w", ErrChromeStart, err)
 	}
 	log.Printf("浏览器启动耗时 %.1fs", time.Since(startAt).Seconds())
+	if s.config.ChromeStabilizationDelay > 0 {
+		time.Sleep(s.config.ChromeStabilizationDelay)
+	}
 
-	// 超时从浏览器就绪后开始，不含启动时间。
-	// 注意：对同一变量重赋值；原 chromedp context 仍在树中，超时是其子节点。
-	ctx, cancel = context.WithTimeout(ctx, s.config.Timeout)
+	// 超时从浏览器就绪后开始，不含启动时间；超时是 tab context 的子节点，到期不关闭 Tab
+	ctx, cancel := context.WithTimeout(tabCtx, s.config.Timeout)
 	defer cancel()
 
-	return s.crawlInTab(ctx, targetURL)
+	err = s.crawlInTab(ctx, targetURL)
+	if s.config.KeepOpen != nil {
+		cancel()
+		s.keepOpen(tabCtx)
+	}
+	if s.config.CaptureSession {
+		s.captureSession(tabCtx)
+	}
+	return err
 }
 
 // CrawlInContext 批量模式：在浏览器池提供的 allocCtx 中开新 Tab 爬取，不关闭 Chrome 进程。
@@ -119,6 +333,9 @@ func (s *Spider) CrawlInContext(allocCtx context.Context, targetURL string) erro
 	if err := validateURL(targetURL); err != nil {
 		return err
 	}
+	if s.config.HARReplayMode {
+		return s.replayHAR(targetURL)
+	}
 
 	// 在现有 Chrome 进程中创建新 Tab（chromedp 懒创建，真正 Run 时才 open tab）
 	tabCtx, tabCancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
@@ -128,27 +345,58 @@ func (s *Spider) CrawlInContext(allocCtx context.Context, targetURL string) erro
 	ctx, cancel := context.WithTimeout(tabCtx, s.config.Timeout)
 	defer cancel()
 
-	return s.crawlInTab(ctx, targetURL)
+	err := s.crawlInTab(ctx, targetURL)
+	if s.config.KeepOpen != nil {
+		cancel()
+		s.keepOpen(tabCtx)
+	}
+	if s.config.CaptureSession {
+		s.captureSession(tabCtx)
+	}
+	return err
 }
 
 // crawlInTab 在已有上下文（含超时）中执行完整爬取流程。
 // 调用方（Crawl / CrawlInContext）负责设置超时，此函数不再重复创建。
 func (s *Spider) crawlInTab(ctx context.Context, targetURL string) error {
-	// 初始化 lastCapture 基线
+	// 初始化 lastCapture 基线；响应体获取可在导航超时后继续排空一个 BodyFetchTimeout
 	s.mu.Lock()
 	s.lastCapture = time.Now()
+	if deadline, ok := ctx.Deadline(); ok {
+		s.drainDeadline = deadline.Add(s.bodyFetchTimeout())
+	}
 	s.mu.Unlock()
 
+	if s.config.DumpNetworkEvents {
+		s.events = newEventRecorder()
+		defer func() { s.eventLog = s.events.close() }()
+	}
+
 	// 监听网络响应事件
-	chromedp.ListenTarget(ctx, func(ev any) {
-		switch ev := ev.(type) {
-		case *network.EventResponseReceived:
-			go s.handleResponse(ctx, ev)
-		}
-	})
+	s.listenTab(ctx)
 
 	actions := []chromedp.Action{network.Enable()}
 
+	// 批量模式下浏览器进程复用，之前 Tab 留下的缓存也要清掉
+	if s.config.DisableDiskCache {
+		actions = append(actions, network.ClearBrowserCache())
+	}
+
+	if patterns := blockedURLPatterns(s.config); len(patterns) > 0 {
+		actions = append(actions, network.SetBlockedURLs(patterns))
+	}
+
+	if s.config.Deterministic {
+		actions = append(actions, deterministicActions(s.config)...)
+	}
+
+	// 浏览器池中的进程按启动时的 User-Agent 运行，轮换时按 Tab 覆盖
+	if ua := s.config.UserAgentFor(0); ua != "" {
+		actions = append(actions, emulation.SetUserAgentOverride(ua))
+	} else if s.config.RealUserAgent {
+		actions = append(actions, chromedp.ActionFunc(s.overrideRealUserAgent))
+	}
+
 	if len(s.config.Headers) > 0 {
 		headers := make(map[string]any)
 		for k, v := range s.config.Headers {
@@ -163,45 +411,262 @@ func (s *Spider) crawlInTab(ctx context.Context, targetURL string) error {
 		}
 	}
 
-	// 导航：等待 DOM 就绪替代固定 Sleep
-	actions = append(actions,
-		chromedp.Navigate(targetURL),
-		chromedp.ActionFunc(waitForReadyState),
-	)
+	// 导入的 Cookie 在 Config.Cookies 之后写入，同名时以导入的为准
+	if s.imported != nil {
+		actions = append(actions, chromedp.ActionFunc(s.restoreSession))
+	}
+
+	// 问题收集同样需在导航前开启，加载阶段的 CSP / CORS 拦截才不会漏掉
+	if s.config.CaptureBrowserIssues {
+		actions = append(actions, chromedp.ActionFunc(s.enableIssueCapture))
+	}
+
+	// 覆盖率统计需在页面脚本执行前开启
+	if s.config.CaptureCoverage {
+		actions = append(actions, chromedp.ActionFunc(s.startCoverage))
+	}
+
+	// 导航：等待 WaitUntil 指定的生命周期事件
+	actions = append(actions, chromedp.ActionFunc(func(ctx context.Context) error {
+		return s.navigate(ctx, targetURL)
+	}))
 
 	if err := chromedp.Run(ctx, actions...); err != nil {
 		return fmt.Errorf("failed to crawl %s: %w", targetURL, err)
 	}
+	if err := s.retryOnStatus(ctx, targetURL); err != nil {
+		return err
+	}
+
+	// 已跨源跳转且不跟随时，滚动和点击只会作用在跳转后的页面上
+	if !s.navigatedAway() {
+		s.settlePage(ctx)
+	}
+
+	// 等待所有资源下载 goroutine 完成（每个都受 BodyFetchTimeout 和排空截止时间约束）
+	s.wg.Wait()
+	if s.bodyFailures > 0 {
+		logging.Warnf("%d 个资源未能取得响应体（其中 %d 个超时），详见报告中的 Body Error", s.bodyFailures, s.bodyTimeouts)
+	}
+	if s.slowCount > 0 {
+		logging.Warnf("%d 个资源耗时超过 %s，详见报告中的 Slowest Resources", s.slowCount, s.config.SlowResourceThreshold)
+	}
+	log.Printf("响应体获取峰值并发: %d", s.result.PeakFetches)
+	if s.filteredCount > 0 {
+		log.Printf("Config.PathPrefix / Filters 跳过了 %d 个资源", s.filteredCount)
+	}
+	if s.ignoredCount > 0 {
+		log.Printf("忽略清单跳过了 %d 个资源（-ignore-list）", s.ignoredCount)
+	}
+	if s.blockedCount > 0 {
+		log.Printf("已拦截 %d 个请求（-block-domains / -no-tracking）", s.blockedCount)
+	}
+
+	// 跨源跳转后停止抓取：跳转后的页面不做时间线、隐私和登录页检查
+	if s.navigatedAway() {
+		s.mu.Lock()
+		s.result.FinalURL = s.pageURL
+		s.mu.Unlock()
+		return nil
+	}
+
+	if s.config.CaptureTimingAPI {
+		s.captureResourceTiming(ctx)
+	}
+	if s.config.CapturePrivacy {
+		s.capturePrivacy(ctx, targetURL)
+	}
+	if s.config.CaptureCoverage {
+		s.captureCoverage(ctx)
+	}
+	if s.config.ExtractInlineScripts {
+		s.extractInlineScripts(ctx)
+	}
+	if s.config.ExtractInlineStyles {
+		s.extractInlineStyles(ctx)
+	}
+	if s.config.IncludeNavigatorProperties {
+		s.captureNavigatorProperties(ctx)
+	}
+
+	// 登录墙检测与必需元素校验
+	if err := s.inspectPage(ctx, targetURL); err != nil {
+		if errors.Is(err, ErrRequiredSelectorMissing) {
+			return err
+		}
+		logging.Warnf("%v", err)
+	}
+	if s.result.LikelyLoginPage {
+		logging.Warnf("%s 疑似为登录页（%s），会话可能已过期", targetURL, s.result.LoginSignal)
+	}
+
+	return nil
+}
+
+// listenTab 在 Tab 上挂载事件监听：记录请求、获取响应体、跟踪导航和子目标，ctx 结束后停止
+func (s *Spider) listenTab(ctx context.Context) {
+	chromedp.ListenTarget(ctx, func(ev any) {
+		if s.events != nil {
+			s.events.record(ev)
+		}
+		switch ev := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			if s.trackDocumentRequest(ctx, ev) {
+				return
+			}
+			s.recordRequest(ev)
+		case *network.EventLoadingFinished:
+			s.loads.finish(ev.RequestID)
+		case *network.EventLoadingFailed:
+			s.loads.finish(ev.RequestID)
+			s.recordBlocked(ev)
+		case *network.EventResponseReceived:
+			if s.navigatedAway() {
+				return
+			}
+			execContext := frameContext(ctx, ev.FrameID)
+			if ev.Type == network.ResourceTypeDocument && execContext == "page" {
+				s.mu.Lock()
+				// 跟随跨源跳转时以最终页面为主文档
+				if s.result.DocumentURL == "" || (s.config.FollowClientRedirects && urlOrigin(ev.Response.URL) != urlOrigin(s.result.DocumentURL)) {
+					s.result.DocumentURL = ev.Response.URL
+				}
+				s.pageURL = ev.Response.URL
+				s.documentStatus = int(ev.Response.Status)
+				s.mu.Unlock()
+			}
+			go s.handleResponse(ctx, ev, execContext)
+		case *page.EventFrameNavigated:
+			s.recordNavigation(ev.Frame)
+		case *css.EventStyleSheetAdded:
+			s.recordStyleSheet(ev.Header)
+		case *cdplog.EventEntryAdded:
+			if s.config.CaptureBrowserIssues {
+				s.recordLogEntry(ev.Entry)
+			}
+		case *audits.EventIssueAdded:
+			if s.config.CaptureBrowserIssues {
+				s.recordAuditIssue(ev.Issue)
+			}
+		case *page.EventJavascriptDialogOpening:
+			if s.config.DisableJavaScriptDialogs {
+				go s.dismissDialog(ctx, ev)
+			}
+		case *target.EventAttachedToTarget:
+			if s.config.CaptureWorkers {
+				go s.attachChildTarget(ctx, ev.TargetInfo)
+			}
+		case *target.EventTargetCreated:
+			if s.config.CaptureWorkers {
+				go s.attachChildTarget(ctx, ev.TargetInfo)
+			}
+		}
+	})
+}
+
+// settlePage 导航完成后的页面交互：固定等待、DOM 大小检查、滚动懒加载、资源提示、空闲检测和点击标记
+func (s *Spider) settlePage(ctx context.Context) {
+	// 给首屏脚本留出固定的执行时间（默认 0，依赖空闲检测）
+	if s.config.NavigationSettleDelay > 0 {
+		select {
+		case <-time.After(s.config.NavigationSettleDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	// 超大 DOM 上截图、序列化 DOM 可能卡住或耗尽内存，先测量再决定是否跳过
+	s.checkDOMSize(ctx)
 
 	// 滚动触发懒加载：每步独立容错
 	s.scrollPage(ctx)
 
+	// 浏览器未加载的 prefetch / preload 提示，由空闲检测等待其完成
+	if s.config.CapturePrefetchAndPreload {
+		s.loadResourceHints(ctx)
+	}
+
 	// 网络空闲检测（替代固定 Sleep）
 	s.waitForIdle()
 
-	// 等待所有资源下载 goroutine 完成
-	s.wg.Wait()
+	if s.config.CapturePrefetchAndPreload {
+		s.tagResourceHints()
+	}
 
-	return nil
+	// 初始加载之后的点击标记（-mark-after-click）
+	if len(s.config.ClickMarkers) > 0 {
+		s.runClickMarkers(ctx)
+	}
 }
 
-// scrollPage 分步滚动页面触发懒加载，每步独立容错不影响后续步骤
-func (s *Spider) scrollPage(ctx context.Context) {
-	steps := []struct {
-		frac  float64
-		delay time.Duration
-	}{
-		{0.25, 800 * time.Millisecond},
-		{0.50, 800 * time.Millisecond},
-		{0.75, 800 * time.Millisecond},
-		{1.00, 1000 * time.Millisecond},
-		{0.00, 500 * time.Millisecond},
+// frameContext 根据 frameID 区分主文档与同进程 iframe 发起的请求
+func frameContext(ctx context.Context, frameID cdp.FrameID) string {
+	// 主 frame 的 ID 与 page target 的 ID 相同
+	if c := chromedp.FromContext(ctx); c != nil && c.Target != nil && frameID != "" &&
+		string(frameID) != string(c.Target.TargetID) {
+		return "iframe"
+	}
+	return "page"
+}
+
+// attachChildTarget 附加到 worker / 跨进程 iframe 子目标并监听其网络事件。
+// 这些执行上下文的请求不会出现在主 target 的 Network 域中。
+func (s *Spider) attachChildTarget(ctx context.Context, info *target.Info) {
+	if info == nil {
+		return
+	}
+	switch info.Type {
+	case "worker", "shared_worker", "service_worker", "iframe":
+	default:
+		return
+	}
+
+	s.mu.Lock()
+	if s.attached[info.TargetID] {
+		s.mu.Unlock()
+		return
+	}
+	s.attached[info.TargetID] = true
+	s.mu.Unlock()
+
+	childCtx, cancel := chromedp.NewContext(ctx, chromedp.WithTargetID(info.TargetID))
+	context.AfterFunc(ctx, cancel)
+
+	kind := info.Type
+	chromedp.ListenTarget(childCtx, func(ev any) {
+		if s.events != nil {
+			s.events.record(ev)
+		}
+		switch ev := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			s.recordRequest(ev)
+		case *network.EventLoadingFinished:
+			s.loads.finish(ev.RequestID)
+		case *network.EventLoadingFailed:
+			s.loads.finish(ev.RequestID)
+			s.recordBlocked(ev)
+		case *network.EventResponseReceived:
+			go s.handleResponse(childCtx, ev, kind)
+		}
+	})
+
+	// 附加时 chromedp 会自动为子目标启用 Network 域；拦截规则按 target 生效，需要单独设置
+	var actions []chromedp.Action
+	if patterns := blockedURLPatterns(s.config); len(patterns) > 0 {
+		actions = append(actions, network.SetBlockedURLs(patterns))
 	}
+	if err := chromedp.Run(childCtx, actions...); err != nil {
+		logging.Warnf("附加 %s 目标失败 %s: %v", kind, info.URL, err)
+		return
+	}
+	log.Printf("已附加 %s: %s", kind, info.URL)
+}
 
+// scrollPage 分步滚动页面触发懒加载，每步独立容错不影响后续步骤
+func (s *Spider) scrollPage(ctx context.Context) {
 	log.Println("滚动页面以触发懒加载资源...")
-	for _, step := range steps {
+	for i, step := range defaultScrollSteps {
 		if ctx.Err() != nil {
-			log.Printf("警告: 页面上下文已结束，跳过剩余滚动步骤")
+			logging.Warnf("页面上下文已结束，跳过剩余滚动步骤")
 			break
 		}
 
@@ -214,38 +679,27 @@ func (s *Spider) scrollPage(ctx context.Context) {
 		})()`, step.frac)
 
 		if err := chromedp.Run(ctx, chromedp.Evaluate(js, nil)); err != nil {
-			log.Printf("警告: 滚动 %.0f%% 时出错（跳过此步）: %v", step.frac*100, err)
+			logging.Warnf("滚动 %.0f%% 时出错（跳过此步）: %v", step.frac*100, err)
 		}
 
 		// 等待期间同时监听 ctx 取消，避免超时后还在 sleep
-		timer := time.NewTimer(step.delay)
+		timer := time.NewTimer(s.config.scrollDelay(i))
 		select {
 		case <-ctx.Done():
 			timer.Stop()
 		case <-timer.C:
 		}
-	}
-}
 
-// waitForReadyState 轮询 document.readyState 直到 complete 或最多 10s
-func waitForReadyState(ctx context.Context) error {
-	ticker := time.NewTicker(300 * time.Millisecond)
-	defer ticker.Stop()
-	timeout := time.After(10 * time.Second)
-	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		case <-timeout:
-			return nil
-		case <-ticker.C:
-			var state string
-			if err := chromedp.Evaluate(`document.readyState`, &state).Do(ctx); err != nil {
-				return nil
-			}
-			if state == "complete" {
-				return nil
+		// 截取视口而非整页：超长页面整页截图可能失败，逐步截图还能反映懒加载内容的出现顺序
+		if s.config.ScrollScreenshots && !s.domTooLarge && ctx.Err() == nil {
+			var shot []byte
+			if err := chromedp.Run(ctx, chromedp.CaptureScreenshot(&shot)); err != nil {
+				logging.Warnf("滚动 %.0f%% 时截图失败: %v", step.frac*100, err)
+				continue
 			}
+			s.mu.Lock()
+			s.result.Screenshots = append(s.result.Screenshots, shot)
+			s.mu.Unlock()
 		}
 	}
 }
@@ -269,88 +723,334 @@ func (s *Spider) waitForIdle() {
 }
 
 // handleResponse 处理网络响应事件
-func (s *Spider) handleResponse(ctx context.Context, ev *network.EventResponseReceived) {
+func (s *Spider) handleResponse(ctx context.Context, ev *network.EventResponseReceived, execContext string) {
 	resp := ev.Response
 	requestID := ev.RequestID
 
 	resource := &Resource{
 		URL:          resp.URL,
+		Method:       "GET",
 		StatusCode:   int(resp.Status),
+		StatusText:   resp.StatusText,
+		Protocol:     resp.Protocol,
+		RemoteIP:     resp.RemoteIPAddress,
 		MimeType:     resp.MimeType,
-		Headers:      make(map[string]string),
+		Headers:      headersToMap(resp.Headers),
 		ResponseTime: time.Now(),
+		Context:      execContext,
+		Labels:       maps.Clone(s.config.ResourceLabels),
 	}
-	for k, v := range resp.Headers {
-		if str, ok := v.(string); ok {
-			resource.Headers[k] = str
-		}
+	if s.config.FollowCSPReportURIs {
+		resource.ReportEndpoints = cspReportEndpoints(resource.URL, resource.Headers)
+	}
+
+	var sent time.Time
+	s.mu.Lock()
+	resource.Marker = s.marker
+	if req, ok := s.requests[requestID]; ok {
+		sent = req.sent
+		resource.Marker = req.marker
+		resource.Method = req.method
+		resource.RequestHeaders = req.headers
+		resource.RequestBody = req.body
+		resource.Latency = resource.ResponseTime.Sub(req.sent)
+		delete(s.requests, requestID)
+	}
+	if s.pageURL != "" {
+		resource.Pages = []string{s.pageURL}
+	}
+	s.mu.Unlock()
+	// RequestHeaders 为实际发出的请求头（含 Cookie 等浏览器追加的头），优先使用
+	if len(resp.RequestHeaders) > 0 {
+		resource.RequestHeaders = headersToMap(resp.RequestHeaders)
+	}
+
+	if !s.shouldCapture(resource) {
+		return
 	}
 
 	// 占位写入：check + insert 在同一把锁内，消除 TOCTOU 竞态
+	key := s.resourceKey(resp.URL)
 	s.mu.Lock()
-	if _, exists := s.resources[resp.URL]; exists {
+	if existing, exists := s.resources[key]; exists {
+		if s.config.CollapsePolling {
+			existing.CollapsedCount++
+			existing.LastSeen = resource.ResponseTime
+		}
 		s.mu.Unlock()
 		return
 	}
-	s.resources[resp.URL] = resource
+	s.resources[key] = resource
 	s.mu.Unlock()
 
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
 
+		// 本地覆盖：用本地修改过的文件替代线上版本（类似 DevTools Local Overrides）
+		if body, ok := s.readLocalOverride(resource.URL); ok {
+			s.mu.Lock()
+			resource.Content = body
+			resource.Headers["X-Source"] = "LocalOverride"
+			s.sniff(resource)
+			s.lastCapture = time.Now()
+			s.mu.Unlock()
+			log.Printf("Overridden: %s ← 本地文件 - %d bytes", resource.URL, len(body))
+			s.notifyCapture(resource)
+			return
+		}
+
+		// 独立的获取超时：不随导航 context 一起取消（临近结束时发起的请求仍能取回），
+		// 但不超过排空截止时间，避免卡住的 GetResponseBody 无限拖长爬取
+		fetchCtx, cancel := s.bodyFetchContext(ctx)
+		defer cancel()
+
+		// 等响应体传输完成再获取，避免分块响应被截断；等待同样受获取超时约束
+		var err error
+		if !isStreamingResponse(resp) {
+			err = s.loads.wait(fetchCtx, requestID)
+		}
+
+		s.mu.Lock()
+		s.fetchesInFlight++
+		s.result.PeakFetches = max(s.result.PeakFetches, s.fetchesInFlight)
+		s.mu.Unlock()
+
 		var body []byte
-		err := chromedp.Run(ctx,
-			chromedp.ActionFunc(func(ctx context.Context) error {
-				var err error
-				body, err = network.GetResponseBody(requestID).Do(ctx)
-				return err
-			}),
-		)
+		if err == nil {
+			body, err = s.getBody(fetchCtx, requestID)
+		}
+		var bodyErr string
+		timedOut := false
+		if err == nil && body == nil {
+			// 成功取回但响应体为空：用空切片与"未取得"（nil）区分
+			body = []byte{}
+		}
 		if err != nil {
-			// 304 Not Modified 等情况，使用配置好的 HTTP 客户端重试
-			body = s.downloadResource(resource.URL)
+			timedOut = errors.Is(err, context.DeadlineExceeded)
+			if s.config.DisableFallbackDownload {
+				// 不直接下载：公开版本可能与已认证浏览器看到的内容不同
+				bodyErr = err.Error()
+			} else {
+				// 304 Not Modified 等情况，使用配置好的 HTTP 客户端重试
+				body = s.downloadResource(resource.URL)
+				if body == nil {
+					bodyErr = err.Error() + "；直接下载也失败"
+				}
+			}
 		}
 
 		s.mu.Lock()
+		s.fetchesInFlight--
 		resource.Content = body
+		if !sent.IsZero() {
+			resource.FetchDuration = time.Since(sent)
+		}
+		slow := s.isSlow(resource)
+		s.sniff(resource)
+		resource.BodyError = bodyErr
+		resource.BodyTimedOut = bodyErr != "" && timedOut
+		if bodyErr != "" {
+			s.bodyFailures++
+			if timedOut {
+				s.bodyTimeouts++
+			}
+		}
 		s.lastCapture = time.Now() // 更新空闲检测基线
 		s.mu.Unlock()
 
 		log.Printf("Captured: %s [%s] - %d bytes", resource.URL, resource.MimeType, len(body))
+		if slow {
+			logging.Warnf("慢资源 %s 耗时 %s（阈值 %s）", resource.URL,
+				resource.FetchDuration.Round(time.Millisecond), s.config.SlowResourceThreshold)
+		}
+		s.notifyCapture(resource)
 	}()
 }
 
+// isSlow 判断资源是否超过 SlowResourceThreshold 并计数，调用方持有 s.mu
+func (s *Spider) isSlow(res *Resource) bool {
+	if s.config.SlowResourceThreshold <= 0 || res.FetchDuration <= s.config.SlowResourceThreshold {
+		return false
+	}
+	s.slowCount++
+	return true
+}
+
+// bodyFetchContext 为单个响应体获取创建 context：保留 ctx 中的 chromedp target，
+// 超时取 BodyFetchTimeout，且不晚于 drainDeadline
+func (s *Spider) bodyFetchContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.bodyFetchTimeout())
+
+	s.mu.Lock()
+	deadline := s.drainDeadline
+	s.mu.Unlock()
+	if deadline.IsZero() {
+		return fetchCtx, cancel
+	}
+
+	bounded, cancelBounded := context.WithDeadline(fetchCtx, deadline)
+	return bounded, func() {
+		cancelBounded()
+		cancel()
+	}
+}
+
+// bodyFetchTimeout 返回单个响应体获取的超时
+func (s *Spider) bodyFetchTimeout() time.Duration {
+	if s.config.BodyFetchTimeout > 0 {
+		return s.config.BodyFetchTimeout
+	}
+	return DefaultBodyFetchTimeout
+}
+
+// OnCapture 注册资源抓取完成回调（须在爬取开始前调用），可用于边爬边落盘。
+// 回调在资源下载 goroutine 中执行，需自行保证并发安全。
+func (s *Spider) OnCapture(fn func(*Resource)) {
+	s.onCapture = fn
+}
+
+func (s *Spider) notifyCapture(res *Resource) {
+	if s.onCapture != nil {
+		s.onCapture(res)
+	}
+}
+
+// recordRequest 保存请求方法、请求头和请求体，等待对应响应到达时关联
+func (s *Spider) recordRequest(ev *network.EventRequestWillBeSent) {
+	if ev.Request == nil {
+		return
+	}
+	info := &requestInfo{
+		method:  ev.Request.Method,
+		headers: headersToMap(ev.Request.Headers),
+		sent:    time.Now(),
+	}
+	for _, entry := range ev.Request.PostDataEntries {
+		// CDP 的 binary 类型为 base64 编码
+		if data, err := base64.StdEncoding.DecodeString(entry.Bytes); err == nil {
+			info.body = append(info.body, data...)
+		} else {
+			info.body = append(info.body, entry.Bytes...)
+		}
+	}
+
+	s.mu.Lock()
+	info.marker = s.marker
+	s.requests[ev.RequestID] = info
+	s.mu.Unlock()
+}
+
+// recordBlocked 统计被 setBlockedURLs 拦截的请求，并丢弃其请求记录
+func (s *Spider) recordBlocked(ev *network.EventLoadingFailed) {
+	if ev.BlockedReason != network.BlockedReasonInspector {
+		return
+	}
+	s.mu.Lock()
+	s.blockedCount++
+	delete(s.requests, ev.RequestID)
+	s.mu.Unlock()
+}
+
+// headersToMap 将 CDP Headers 转为字符串 map
+func headersToMap(headers network.Headers) map[string]string {
+	result := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if str, ok := v.(string); ok {
+			result[k] = str
+		}
+	}
+	return result
+}
+
+// resourceKey 计算资源去重键。
+// 开启 CollapsePolling 时剥离缓存破坏参数，使 /api/poll?ts=1 与 /api/poll?ts=2 视为同一资源。
+func (s *Spider) resourceKey(rawURL string) string {
+	if !s.config.CollapsePolling {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+
+	params := s.config.CacheBusterParams
+	if len(params) == 0 {
+		params = DefaultCacheBusterParams
+	}
+	query := u.Query()
+	for key := range query {
+		for _, p := range params {
+			if strings.EqualFold(key, p) {
+				query.Del(key)
+				break
+			}
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
 // downloadResource 直接下载资源（备用），继承代理、Cookie、Headers 配置
 func (s *Spider) downloadResource(targetURL string) []byte {
-	req, err := http.NewRequest("GET", targetURL, nil)
+	_, body, err := s.fetchDirect(targetURL)
 	if err != nil {
 		return nil
 	}
+	return body
+}
+
+// fetchDirect 用备用 HTTP 客户端 GET targetURL，继承代理、Cookie、Headers 和 User-Agent 配置；
+// 返回的响应体已读取（最多 maxBodyBytes）并关闭
+func (s *Spider) fetchDirect(targetURL string) (*http.Response, []byte, error) {
+	req, err := http.NewRequest("GET", targetURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
 	for k, v := range s.config.Headers {
 		req.Header.Set(k, v)
 	}
 	if s.config.Cookies != "" {
 		req.Header.Set("Cookie", s.config.Cookies)
 	}
-	if s.config.UserAgent != "" {
-		req.Header.Set("User-Agent", s.config.UserAgent)
+	ua := s.config.UserAgentFor(0)
+	if ua == "" {
+		s.mu.Lock()
+		ua = s.realUA
+		s.mu.Unlock()
+	}
+	if ua != "" {
+		req.Header.Set("User-Agent", ua)
 	}
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return nil
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
 	if err != nil {
-		return nil
+		return nil, nil, err
 	}
-	return body
+	return resp, body, nil
 }
 
-// GetResources 返回所有已抓取资源的副本（线程安全）
+// Result 返回页面级爬取结果的副本
+func (s *Spider) Result() *CrawlResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := s.result
+	return &result
+}
+
+// NetworkEvents 返回爬取期间记录的 network.* 事件（JSON Lines），未开启 DumpNetworkEvents 时为 nil
+func (s *Spider) NetworkEvents() []byte {
+	return s.eventLog
+}
+
+// GetResources 返回所有已抓取资源的副本（线程安全）；一次性取用结果可直接用 Run，
+// 按类型、状态码或 URL 筛选见 GetResourcesByMIMEType 等
 func (s *Spider) GetResources() map[string]*Resource {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -364,10 +1064,13 @@ func (s *Spider) parseCookies(targetURL, cookieStr string) []*network.CookiePara
 	var cookies []*network.CookieParam
 	u, err := url.Parse(targetURL)
 	if err != nil {
-		log.Printf("警告: 无法解析 URL %s: %v", targetURL, err)
+		logging.Warnf("无法解析 URL %s: %v", targetURL, err)
 		return cookies
 	}
 	domain := u.Hostname()
+	if s.config.CookieSameSiteBypass && u.Scheme != "https" && domain != "localhost" && domain != "127.0.0.1" {
+		logging.Warnf("CookieSameSiteBypass 设置的 Secure Cookie 不会随 %s 的 HTTP 请求发送", domain)
+	}
 
 	for pair := range strings.SplitSeq(cookieStr, ";") {
 		pair = strings.TrimSpace(pair)
@@ -383,11 +1086,17 @@ func (s *Spider) parseCookies(targetURL, cookieStr string) []*network.CookiePara
 		if name == "" {
 			continue
 		}
-		cookies = append(cookies, &network.CookieParam{
+		cookie := &network.CookieParam{
 			Name:   name,
 			Value:  value,
 			Domain: domain,
-		})
+		}
+		// 跨站 iframe / XHR 只会带上 SameSite=None 的 Cookie，而浏览器要求 None 必须同时是 Secure
+		if s.config.CookieSameSiteBypass {
+			cookie.SameSite = network.CookieSameSiteNone
+			cookie.Secure = true
+		}
+		cookies = append(cookies, cookie)
 	}
 	return cookies
 }
@@ -0,0 +1,432 @@
+package crawler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/crawlertest"
+	"spider/internal/testsite"
+)
+
+// 在真实 Chrome 中爬取测试站点首页：懒加载、iframe、XHR、跳转前后的资源和 source map 源文件都应出现在 resources.json 中
+func TestCrawlTestSite(t *testing.T) {
+	site := testsite.New()
+	defer site.Close()
+
+	res := crawlertest.Run(t, site.Resolve("/"), nil)
+	res.RequirePaths(t, site.URL, testsite.Paths...)
+	res.RequirePaths(t, site.URL, testsite.SourcePaths...)
+
+	// 首页设置的 Cookie 应随 XHR 一起发出
+	e, ok := res.Entry(site.Resolve("/api/items"))
+	if !ok {
+		t.Fatal("缺少 /api/items")
+	}
+	var items struct {
+		Authenticated bool `json:"authenticated"`
+	}
+	data, err := os.ReadFile(filepath.Join(res.Dir, filepath.FromSlash(e.Path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatalf("解析 /api/items 响应失败: %v", err)
+	}
+	if !items.Authenticated {
+		t.Error("/api/items 请求没有带上首页设置的 Cookie")
+	}
+
+	// missing.js 的 source map 返回 404，不应产生源文件，也不应中断提取
+	if e, ok := res.Entry(site.Resolve("/missing.js.map")); ok && e.Path != "" {
+		t.Errorf("不存在的 source map 被保存为 %s", e.Path)
+	}
+}
+
+// 不需要浏览器：回放直接请求测试站点录制的 HAR，走完整的 提取 → 保存 → 索引 流程
+func TestReplayTestSite(t *testing.T) {
+	site := testsite.New()
+	defer site.Close()
+
+	var urls []string
+	for _, p := range testsite.Paths {
+		urls = append(urls, site.Resolve(p))
+	}
+	harFile := crawlertest.RecordHAR(t, urls...)
+
+	res := crawlertest.Run(t, site.Resolve("/"), crawlertest.ReplayConfig(harFile))
+	res.RequirePaths(t, site.URL, testsite.Paths...)
+	res.RequirePaths(t, site.URL, testsite.SourcePaths...)
+
+	if got, want := len(res.Index), len(testsite.Paths)+len(testsite.SourcePaths); got != want {
+		t.Errorf("resources.json 中有 %d 条记录，应为 %d（回放的资源加上提取的源文件）", got, want)
+	}
+	if res.Crawl.DocumentURL != site.Resolve("/") {
+		t.Errorf("DocumentURL = %q，应为 %q", res.Crawl.DocumentURL, site.Resolve("/"))
+	}
+	for _, p := range testsite.SourcePaths {
+		e, _ := res.Entry(site.Resolve(p))
+		if _, err := os.Stat(filepath.Join(res.Dir, filepath.FromSlash(e.Path))); err != nil {
+			t.Errorf("%s 的内容未写入磁盘: %v", p, err)
+		}
+	}
+}
+
+// 分块响应的头和前半部分先到、其余部分 testsite.LateBodyDelay 之后才到：
+// responseReceived 早于 loadingFinished，保存的响应体应是完整内容而不是截断的前半部分
+func TestCrawlLateBody(t *testing.T) {
+	site := testsite.New()
+	defer site.Close()
+
+	res := crawlertest.Run(t, site.Resolve("/late-body.html"), nil)
+
+	e, ok := res.Entry(site.Resolve(testsite.LateBodyPath))
+	if !ok {
+		t.Fatalf("缺少 %s", testsite.LateBodyPath)
+	}
+	data, err := os.ReadFile(filepath.Join(res.Dir, filepath.FromSlash(e.Path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != testsite.LateBody {
+		t.Errorf("保存的响应体有 %d 字节，应为完整的 %d 字节", len(data), len(testsite.LateBody))
+	}
+}
+
+// 确定性模式下 /clock.html 用冻结的 Date.now() 和固定种子的 Math.random() 拼出的接口地址每次都相同，
+// 两次爬取得到的 (URL, SHA-256) 列表一致
+func TestDeterministicCapture(t *testing.T) {
+	site := testsite.New()
+	defer site.Close()
+
+	capture := func() []string {
+		config := crawlertest.Config()
+		config.Deterministic = true
+		res := crawlertest.Run(t, site.Resolve("/clock.html"), config)
+		var hashes []string
+		for _, e := range res.Index {
+			hashes = append(hashes, e.URL+" "+e.SHA256)
+		}
+		return hashes
+	}
+
+	first, second := capture(), capture()
+	if !slices.Equal(first, second) {
+		t.Fatalf("两次确定性爬取的结果不同:\n%s\n---\n%s", strings.Join(first, "\n"), strings.Join(second, "\n"))
+	}
+	want := site.Resolve(testsite.ClockPath) + "?t=1704067200000&"
+	if !slices.ContainsFunc(first, func(s string) bool { return strings.HasPrefix(s, want) }) {
+		t.Errorf("没有抓到冻结时间 2024-01-01T00:00:00Z 对应的 %s…:\n%s", want, strings.Join(first, "\n"))
+	}
+}
+
+// /vendor/bundle 以 text/plain 返回：开启 SniffMime（默认）时嗅探为脚本，找到其 source map 并以 .js 保存；
+// 关闭后按声明的类型处理，source map 不会被发现
+func TestReplayMislabeledBundle(t *testing.T) {
+	site := testsite.New()
+	defer site.Close()
+	harFile := crawlertest.RecordHAR(t, site.Resolve("/"), site.Resolve("/vendor/bundle"))
+	bundle, vendorSource := site.Resolve("/vendor/bundle"), site.Resolve("/src/vendor.ts")
+
+	res := crawlertest.Run(t, site.Resolve("/"), crawlertest.ReplayConfig(harFile))
+	e, ok := res.Entry(bundle)
+	if !ok {
+		t.Fatal("缺少 /vendor/bundle")
+	}
+	if e.DetectedMimeType != "application/javascript" || !strings.HasSuffix(e.Path, ".js") {
+		t.Errorf("/vendor/bundle: detected_mime_type = %q，path = %q，应嗅探为脚本并以 .js 保存", e.DetectedMimeType, e.Path)
+	}
+	if _, ok := res.Entry(vendorSource); !ok {
+		t.Error("开启 SniffMime 时应从 text/plain 的 bundle 中找到 src/vendor.ts")
+	}
+
+	config := crawlertest.ReplayConfig(harFile)
+	config.SniffMime = false
+	res = crawlertest.Run(t, site.Resolve("/"), config)
+	if e, _ := res.Entry(bundle); e.DetectedMimeType != "" {
+		t.Errorf("关闭 SniffMime 时 detected_mime_type = %q", e.DetectedMimeType)
+	}
+	if _, ok := res.Entry(vendorSource); ok {
+		t.Error("关闭 SniffMime 时不应处理 text/plain 的 bundle")
+	}
+}
+
+// /privacy.html 设置第一方 Cookie，并从另一站点（127.0.0.1 ↔ localhost）嵌入像素和 iframe：
+// 两者设置的 Cookie 应判为第三方并带上 Secure / SameSite=None 和约 30 天的有效期，iframe 写入的 localStorage 键应被列出
+func TestCrawlPrivacy(t *testing.T) {
+	site := testsite.New()
+	defer site.Close()
+
+	config := crawlertest.Config()
+	config.CapturePrivacy = true
+	res := crawlertest.Run(t, site.Resolve("/privacy.html"), config)
+
+	privacy := res.Crawl.Privacy
+	if privacy == nil {
+		t.Fatal("开启 CapturePrivacy 后 CrawlResult.Privacy 为 nil")
+	}
+	var first, tracker bool
+	for _, c := range privacy.Cookies {
+		switch c.Name {
+		case testsite.CookieName:
+			first = true
+			if c.ThirdParty {
+				t.Errorf("第一方 Cookie %s 被判为第三方（站点 %s，域 %s）", c.Name, privacy.Site, c.Domain)
+			}
+		case testsite.TrackerCookieName:
+			tracker = true
+			if !c.ThirdParty || c.Domain != site.ThirdPartyHost() {
+				t.Errorf("%s 应为 %s 设置的第三方 Cookie，实际 %+v", c.Name, site.ThirdPartyHost(), c)
+			}
+			if !c.Secure || c.SameSite != "None" || c.Session || c.ExpiresIn < 29 || c.ExpiresIn > 30.1 {
+				t.Errorf("%s 的属性为 %+v，应为 Secure、SameSite=None、约 30 天后过期", c.Name, c)
+			}
+		}
+	}
+	if !first || !tracker {
+		t.Errorf("Cookie 列表不完整（第一方 %v，第三方 %v）: %+v", first, tracker, privacy.Cookies)
+	}
+
+	found := false
+	for _, fs := range privacy.ThirdPartyStorage {
+		if strings.Contains(fs.Origin, site.ThirdPartyHost()) && slices.Contains(fs.Keys, testsite.TrackerStorageKey) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("跨站 iframe 写入的 %s 没有出现在 ThirdPartyStorage 中: %+v", testsite.TrackerStorageKey, privacy.ThirdPartyStorage)
+	}
+}
+
+// 首页空闲后点击 #open-report：点击才发出的 /api/report 标记为 mark1，初始加载的资源没有标记
+func TestCrawlClickMarker(t *testing.T) {
+	site := testsite.New()
+	defer site.Close()
+
+	config := crawlertest.Config()
+	config.ClickMarkers = []crawler.ClickMarker{{Label: "mark1", Selector: testsite.ReportButton}}
+	res := crawlertest.Run(t, site.Resolve("/"), config)
+
+	e, ok := res.Entry(site.Resolve(testsite.ReportPath))
+	if !ok {
+		t.Fatalf("点击 %s 后没有抓到 %s", testsite.ReportButton, testsite.ReportPath)
+	}
+	if e.Marker != "mark1" {
+		t.Errorf("%s 的标记为 %q，应为 mark1", testsite.ReportPath, e.Marker)
+	}
+	for _, p := range testsite.Paths {
+		if e, ok := res.Entry(site.Resolve(p)); ok && e.Marker != "" {
+			t.Errorf("初始加载的 %s 被标记为 %q", p, e.Marker)
+		}
+	}
+}
+
+// /huge.html 生成约 50 MB 的 DOM：超过 MaxDOMBytes 时跳过截图、渲染 DOM 和链接提取并记录原因，主文档照常抓取
+func TestCrawlHugeDOM(t *testing.T) {
+	site := testsite.New()
+	defer site.Close()
+
+	config := crawlertest.Config()
+	config.Timeout = 90 * time.Second
+	config.MaxDOMBytes = 20 << 20
+	config.ScrollScreenshots = true
+	config.CaptureRenderedHTML = true
+	res := crawlertest.Run(t, site.Resolve("/huge.html"), config)
+
+	if _, ok := res.Entry(site.Resolve("/huge.html")); !ok {
+		t.Error("DOM 过大时主文档仍应被抓取")
+	}
+	var artifacts []string
+	for _, sk := range res.Crawl.Skipped {
+		artifacts = append(artifacts, sk.Artifact)
+		if sk.Reason != crawler.SkipReasonDOMTooLarge {
+			t.Errorf("%s 的跳过原因为 %q", sk.Artifact, sk.Reason)
+		}
+	}
+	if want := []string{"scroll-screenshots", "rendered-html", "links"}; !slices.Equal(artifacts, want) {
+		t.Errorf("跳过的产物为 %v，应为 %v", artifacts, want)
+	}
+	if len(res.Crawl.Screenshots) != 0 || res.Crawl.RenderedHTML != "" || len(res.Crawl.Links) != 0 {
+		t.Errorf("跳过后仍有 %d 张截图、%d 字节渲染 DOM、%d 个链接", len(res.Crawl.Screenshots), len(res.Crawl.RenderedHTML), len(res.Crawl.Links))
+	}
+}
+
+// /csp.html 的 CSP 只允许同源资源：另一站点的图片和内联脚本都被拦截，至少记录一条带被拦截地址的 CSP 问题
+func TestCrawlBrowserIssues(t *testing.T) {
+	site := testsite.New()
+	defer site.Close()
+
+	config := crawlertest.Config()
+	config.CaptureBrowserIssues = true
+	res := crawlertest.Run(t, site.Resolve("/csp.html"), config)
+
+	blocked := site.CrossSite("/img/header.svg")
+	var csp, blockedURL bool
+	for _, issue := range res.Crawl.BrowserIssues {
+		if issue.Category != crawler.IssueCSP {
+			continue
+		}
+		csp = true
+		if issue.URL == blocked || strings.Contains(issue.Message, blocked) {
+			blockedURL = true
+		}
+	}
+	if !csp || !blockedURL {
+		t.Errorf("应记录拦截 %s 的 CSP 问题: %+v", blocked, res.Crawl.BrowserIssues)
+	}
+
+	if res := crawlertest.Run(t, site.Resolve("/csp.html"), nil); len(res.Crawl.BrowserIssues) != 0 {
+		t.Errorf("未开启 CaptureBrowserIssues 时记录了 %+v", res.Crawl.BrowserIssues)
+	}
+}
+
+// /base.html 的 <base href> 指向另一站点：document.baseURI 记录在 CrawlResult 和主文档、内联脚本上，
+// 链接和图片按它解析，内联脚本的 sourceMappingURL 也从另一站点提取；没有 <base> 的页面 BaseURL 即页面 URL
+func TestCrawlDocumentBase(t *testing.T) {
+	site := testsite.New()
+	defer site.Close()
+
+	config := crawlertest.Config()
+	config.ExtractInlineScripts = true
+	res := crawlertest.Run(t, site.Resolve("/base.html"), config)
+
+	if want := site.CrossSite("/docs/"); res.Crawl.BaseURL != want {
+		t.Errorf("BaseURL 为 %q，应为 %q", res.Crawl.BaseURL, want)
+	}
+	for _, link := range []string{site.CrossSite("/docs/3"), site.CrossSite("/page2.html")} {
+		if !slices.Contains(res.Crawl.Links, link) {
+			t.Errorf("链接 %v 中缺少 %s", res.Crawl.Links, link)
+		}
+	}
+	res.RequirePaths(t, site.CrossSite("/"), "/img/header.svg", "/src/bootstrap.ts")
+
+	if doc := res.Resources[site.Resolve("/base.html")]; doc == nil || doc.BaseURL != res.Crawl.BaseURL {
+		t.Errorf("主文档的 BaseURL 应为 %q: %+v", res.Crawl.BaseURL, doc)
+	}
+	inline := 0
+	for u, r := range res.Resources {
+		if strings.HasPrefix(u, crawler.InlineScheme+"://") {
+			inline++
+			if r.BaseURL != res.Crawl.BaseURL {
+				t.Errorf("内联脚本 %s 的 BaseURL 为 %q", u, r.BaseURL)
+			}
+		}
+	}
+	if inline == 0 {
+		t.Error("没有提取到内联脚本")
+	}
+
+	res = crawlertest.Run(t, site.Resolve("/page2.html"), nil)
+	if res.Crawl.BaseURL != res.Crawl.FinalURL {
+		t.Errorf("没有 <base> 时 BaseURL 为 %q，应为页面 URL %q", res.Crawl.BaseURL, res.Crawl.FinalURL)
+	}
+	if doc := res.Resources[site.Resolve("/page2.html")]; doc == nil || doc.BaseURL != "" {
+		t.Errorf("没有 <base> 时主文档不应设置 BaseURL: %+v", doc)
+	}
+}
+
+// /ua.html 把页面所见的 UA 和 navigator.userAgentData 连同请求头一起回显：默认的 RealUserAgent 下
+// 请求头、navigator.userAgent、userAgentData 和 Sec-CH-UA 的版本与平台一致，且不含 HeadlessChrome
+func TestRealUserAgentConsistent(t *testing.T) {
+	site := testsite.New()
+	defer site.Close()
+
+	echo := func(realUA bool) (testsite.UAEcho, uaPage) {
+		config := crawlertest.Config()
+		config.RealUserAgent = realUA
+		res := crawlertest.Run(t, site.Resolve("/ua.html"), config)
+		e, ok := res.Entry(site.Resolve(testsite.UAPath))
+		if !ok {
+			t.Fatalf("缺少 %s", testsite.UAPath)
+		}
+		data, err := os.ReadFile(filepath.Join(res.Dir, filepath.FromSlash(e.Path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got testsite.UAEcho
+		var page uaPage
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(got.Page, &page); err != nil {
+			t.Fatal(err)
+		}
+		return got, page
+	}
+
+	got, page := echo(true)
+	if strings.Contains(got.UserAgent, "HeadlessChrome") || got.UserAgent != page.UserAgent {
+		t.Fatalf("请求头 UA %q 与页面 UA %q 应一致且不含 HeadlessChrome", got.UserAgent, page.UserAgent)
+	}
+	_, rest, _ := strings.Cut(got.UserAgent, "Chrome/")
+	major, _, _ := strings.Cut(rest, ".")
+	if !slices.Contains(page.Brands, uaBrand{Brand: "Google Chrome", Version: major}) {
+		t.Errorf("userAgentData.brands 为 %+v，应包含 Google Chrome %s", page.Brands, major)
+	}
+	if want := `"Google Chrome";v="` + major + `"`; !strings.Contains(got.SecCHUA, want) {
+		t.Errorf("Sec-CH-UA 为 %q，应包含 %s", got.SecCHUA, want)
+	}
+	if got.SecCHUAPlatform != `"`+page.Platform+`"` || page.Mobile || got.SecCHUAMobile != "?0" {
+		t.Errorf("Sec-CH-UA-Platform / Mobile 为 %s / %s，页面中为 %s / %v", got.SecCHUAPlatform, got.SecCHUAMobile, page.Platform, page.Mobile)
+	}
+
+	if got, _ := echo(false); !strings.Contains(got.UserAgent, "HeadlessChrome") {
+		t.Errorf("RealUserAgent=false 时应保留默认 UA，实际 %q", got.UserAgent)
+	}
+}
+
+// uaPage /ua.html 上报的 navigator.userAgent 和 navigator.userAgentData
+type uaPage struct {
+	UserAgent string    `json:"userAgent"`
+	Brands    []uaBrand `json:"brands"`
+	Mobile    bool      `json:"mobile"`
+	Platform  string    `json:"platform"`
+}
+
+type uaBrand struct {
+	Brand   string `json:"brand"`
+	Version string `json:"version"`
+}
+
+// 在真实 Chrome 中：/flaky.html 前两次返回 503，同一 Tab 重新导航后保存的是 200 的页面；
+// 一直返回 503 时以 ErrRetryStatusExhausted 失败
+func TestCrawlRetryOnStatus(t *testing.T) {
+	site := testsite.New()
+	defer site.Close()
+
+	config := crawlertest.Config()
+	config.RetryOnStatusCodes = []int{503}
+	config.RetryAttempts = 3
+	config.RetryBaseDelay = 100 * time.Millisecond
+	target := site.Resolve(testsite.FlakyPath + "?key=recover&fail=2")
+	res := crawlertest.Run(t, target, config)
+
+	doc := res.Resources[target]
+	if doc == nil {
+		t.Fatal("没有保存主文档")
+	}
+	if doc.StatusCode != 200 || !strings.Contains(string(doc.Content), testsite.FlakyReady+" (3)") {
+		t.Errorf("主文档为 %d %q，应为第 3 次请求得到的 200 页面", doc.StatusCode, doc.Content)
+	}
+
+	config = crawlertest.Config()
+	config.ChromePath = crawlertest.RequireChrome(t)
+	config.RetryOnStatusCodes = []int{503}
+	config.RetryAttempts = 1
+	config.RetryBaseDelay = 100 * time.Millisecond
+	spider, err := crawler.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spider.Close()
+	if _, err := spider.Run(context.Background(), site.Resolve(testsite.FlakyPath+"?key=down&fail=10")); !errors.Is(err, crawler.ErrRetryStatusExhausted) {
+		t.Errorf("一直返回 503 时应为 ErrRetryStatusExhausted，实际 %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package crawler
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// cspReportEndpoints 从响应头的 CSP（含 Report-Only）中提取 report-uri 和 report-to 指向的上报地址。
+// report-uri 的相对地址按资源 URL 解析；report-to 的组名通过 Reporting-Endpoints / Report-To 头解析为 URL。
+func cspReportEndpoints(resourceURL string, headers map[string]string) []string {
+	base, _ := url.Parse(resourceURL)
+	seen := make(map[string]bool)
+	add := func(raw string) {
+		raw = strings.Trim(strings.TrimSpace(raw), `"`)
+		if raw == "" {
+			return
+		}
+		if base != nil {
+			if ref, err := url.Parse(raw); err == nil {
+				raw = base.ResolveReference(ref).String()
+			}
+		}
+		seen[raw] = true
+	}
+
+	var groups []string
+	for name, value := range headers {
+		lower := strings.ToLower(name)
+		if lower != "content-security-policy" && lower != "content-security-policy-report-only" {
+			continue
+		}
+		// 多个策略可能以换行（CDP 合并同名头）或逗号分隔
+		for directive := range strings.FieldsFuncSeq(value, func(r rune) bool { return r == ';' || r == '\n' || r == ',' }) {
+			fields := strings.Fields(directive)
+			if len(fields) < 2 {
+				continue
+			}
+			switch strings.ToLower(fields[0]) {
+			case "report-uri":
+				for _, u := range fields[1:] {
+					add(u)
+				}
+			case "report-to":
+				groups = append(groups, fields[1:]...)
+			}
+		}
+	}
+
+	if len(groups) > 0 {
+		endpoints := reportingEndpoints(headers)
+		for _, group := range groups {
+			for _, u := range endpoints[group] {
+				add(u)
+			}
+		}
+	}
+
+	if len(seen) == 0 {
+		return nil
+	}
+	result := make([]string, 0, len(seen))
+	for u := range seen {
+		result = append(result, u)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// reportingEndpoints 解析 Reporting-Endpoints（name="url", ...）和旧版 Report-To（JSON）头，返回组名 → URL
+func reportingEndpoints(headers map[string]string) map[string][]string {
+	endpoints := make(map[string][]string)
+	for name, value := range headers {
+		switch strings.ToLower(name) {
+		case "reporting-endpoints":
+			for item := range strings.FieldsFuncSeq(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+				group, u, ok := strings.Cut(item, "=")
+				if !ok {
+					continue
+				}
+				group = strings.TrimSpace(group)
+				endpoints[group] = append(endpoints[group], strings.Trim(strings.TrimSpace(u), `"`))
+			}
+		case "report-to":
+			// 每行一个 JSON 对象；多个对象也可能以逗号连接，包成数组再解析
+			for line := range strings.SplitSeq(value, "\n") {
+				var list []struct {
+					Group     string `json:"group"`
+					Endpoints []struct {
+						URL string `json:"url"`
+					} `json:"endpoints"`
+				}
+				if err := json.Unmarshal([]byte("["+line+"]"), &list); err != nil {
+					continue
+				}
+				for _, g := range list {
+					group := g.Group
+					if group == "" {
+						group = "default"
+					}
+					for _, e := range g.Endpoints {
+						endpoints[group] = append(endpoints[group], e.URL)
+					}
+				}
+			}
+		}
+	}
+	return endpoints
+}
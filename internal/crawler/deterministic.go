@@ -0,0 +1,64 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// 确定性模式的默认视口和冻结时间（2024-01-01T00:00:00Z）
+const (
+	deterministicWidth  = 1366
+	deterministicHeight = 768
+	deterministicEpoch  = 1704067200000
+	deterministicSeed   = 42
+)
+
+// deterministicScript 在每个文档执行前注入：冻结 Date、用固定种子替换 Math.random、
+// DOM 就绪后禁用 CSS 动画与过渡。
+var deterministicScript = fmt.Sprintf(`(function(){
+	var FIXED = %d, OrigDate = Date;
+	function FrozenDate() {
+		if (!new.target) return new OrigDate(FIXED).toString();
+		var args = arguments.length ? Array.prototype.slice.call(arguments) : [FIXED];
+		return Reflect.construct(OrigDate, args, new.target);
+	}
+	FrozenDate.prototype = OrigDate.prototype;
+	FrozenDate.now = function() { return FIXED; };
+	FrozenDate.parse = OrigDate.parse;
+	FrozenDate.UTC = OrigDate.UTC;
+	Date = FrozenDate;
+
+	var seed = %d;
+	Math.random = function() {
+		seed = (seed * 1664525 + 1013904223) %% 4294967296;
+		return seed / 4294967296;
+	};
+
+	document.addEventListener('DOMContentLoaded', function() {
+		var style = document.createElement('style');
+		style.textContent = '*,*::before,*::after{animation:none!important;transition:none!important;caret-color:transparent!important}';
+		(document.head || document.documentElement).appendChild(style);
+	});
+})();`, deterministicEpoch, deterministicSeed)
+
+// deterministicActions 返回确定性模式在导航前执行的动作：固定视口、减少动效、注入冻结脚本
+func deterministicActions(config *Config) []chromedp.Action {
+	width, height := int64(config.ViewportWidth), int64(config.ViewportHeight)
+	if width <= 0 || height <= 0 {
+		width, height = deterministicWidth, deterministicHeight
+	}
+	return []chromedp.Action{
+		emulation.SetDeviceMetricsOverride(width, height, 1, false),
+		emulation.SetEmulatedMedia().WithFeatures([]*emulation.MediaFeature{
+			{Name: "prefers-reduced-motion", Value: "reduce"},
+		}),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			_, err := page.AddScriptToEvaluateOnNewDocument(deterministicScript).Do(ctx)
+			return err
+		}),
+	}
+}
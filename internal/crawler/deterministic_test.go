@@ -0,0 +1,77 @@
+package crawler
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/emulation"
+)
+
+// 未指定视口时确定性模式使用 1366x768，-viewport 覆盖默认值
+func TestDeterministicViewport(t *testing.T) {
+	cases := []struct {
+		width, height int
+		want          [2]int64
+	}{
+		{0, 0, [2]int64{deterministicWidth, deterministicHeight}},
+		{1920, 1080, [2]int64{1920, 1080}},
+	}
+	for _, tc := range cases {
+		actions := deterministicActions(&Config{Deterministic: true, ViewportWidth: tc.width, ViewportHeight: tc.height})
+		metrics, ok := actions[0].(*emulation.SetDeviceMetricsOverrideParams)
+		if !ok {
+			t.Fatalf("第一个动作为 %T，应为设置视口", actions[0])
+		}
+		if got := [2]int64{metrics.Width, metrics.Height}; got != tc.want {
+			t.Errorf("视口 %dx%d 时设置为 %v，应为 %v", tc.width, tc.height, got, tc.want)
+		}
+		if metrics.DeviceScaleFactor != 1 || metrics.Mobile {
+			t.Errorf("设备参数为 %+v，应为桌面、缩放 1", metrics)
+		}
+
+		media, ok := actions[1].(*emulation.SetEmulatedMediaParams)
+		if !ok || len(media.Features) != 1 || media.Features[0].Name != "prefers-reduced-motion" || media.Features[0].Value != "reduce" {
+			t.Errorf("没有模拟 prefers-reduced-motion: reduce: %#v", actions[1])
+		}
+	}
+}
+
+// 注入脚本冻结在固定时间，使用固定种子，并禁用动画
+func TestDeterministicScript(t *testing.T) {
+	for _, want := range []string{
+		"FIXED = 1704067200000",
+		"seed = 42",
+		"Math.random = function",
+		"% 4294967296", // fmt 的 %% 应已转义为单个 %
+		"animation:none!important",
+	} {
+		if !strings.Contains(deterministicScript, want) {
+			t.Errorf("注入脚本中缺少 %q", want)
+		}
+	}
+}
+
+func TestDeterministicAllocatorFlags(t *testing.T) {
+	// 只需要一个存在的路径充当 Chrome，不会真的启动
+	chrome, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := func(config *Config) int {
+		config.ChromePath = chrome
+		opts, err := buildAllocatorOptions(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(opts)
+	}
+
+	plain := count(&Config{})
+	if n := count(&Config{Deterministic: true}); n != plain+1 {
+		t.Errorf("确定性模式增加了 %d 个启动参数，应为 1（force-prefers-reduced-motion）", n-plain)
+	}
+	if n := count(&Config{ViewportWidth: 800, ViewportHeight: 600}); n != plain+1 {
+		t.Errorf("指定视口增加了 %d 个启动参数，应为 1（窗口大小）", n-plain)
+	}
+}
@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"time"
+
+	"github.com/chromedp/chromedp"
+
+	"spider/internal/logging"
+)
+
+// DiagnosticScheme 诊断信息合成资源的 URL 协议，如 diagnostic://example.com/navigator.json
+const DiagnosticScheme = "diagnostic"
+
+// navigatorPropertiesScript 读取与 UA 伪装相关的 navigator 属性，返回 JSON 字符串
+const navigatorPropertiesScript = `JSON.stringify(Object.fromEntries(["userAgent","platform","vendor","language","cookieEnabled","webdriver"].map(k=>[k,navigator[k]])))`
+
+// captureNavigatorProperties 将页面看到的 navigator 属性保存为 diagnostic://<host>/navigator.json（IncludeNavigatorProperties）。
+// 用于排查 UA / 平台伪装后抓取结果异常：页面实际看到的值可能与配置不一致
+func (s *Spider) captureNavigatorProperties(ctx context.Context) {
+	var props string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(navigatorPropertiesScript, &props)); err != nil {
+		logging.Warnf("读取 navigator 属性失败: %v", err)
+		return
+	}
+	content := []byte(props)
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, content, "", "  "); err == nil {
+		content = indented.Bytes()
+	}
+
+	s.mu.Lock()
+	pageURL := s.pageURL
+	marker := s.marker
+	s.mu.Unlock()
+	host := "unknown"
+	if u, err := url.Parse(pageURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	resource := &Resource{
+		URL:          fmt.Sprintf("%s://%s/navigator.json", DiagnosticScheme, host),
+		Method:       "GET",
+		StatusCode:   200,
+		MimeType:     "application/json",
+		Content:      content,
+		Headers:      map[string]string{"X-Source": "NavigatorProperties"},
+		ResponseTime: time.Now(),
+		Context:      "page",
+		Labels:       maps.Clone(s.config.ResourceLabels),
+		Marker:       marker,
+	}
+	if pageURL != "" {
+		resource.Pages = []string{pageURL}
+	}
+
+	// 诊断资源是显式要求的，不受 PathPrefix / Filters 限制；重复抓取（重新导航）时覆盖旧值
+	key := s.resourceKey(resource.URL)
+	s.mu.Lock()
+	s.resources[key] = resource
+	s.mu.Unlock()
+	s.notifyCapture(resource)
+}
@@ -0,0 +1,57 @@
+package crawler
+
+import (
+	"context"
+	"log"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+
+	"spider/internal/logging"
+)
+
+// JSDialog 爬取期间页面弹出并被自动关闭的 JavaScript 对话框（DisableJavaScriptDialogs）
+type JSDialog struct {
+	Type     string `json:"type"` // alert / confirm / prompt / beforeunload
+	Message  string `json:"message"`
+	FrameURL string `json:"frame_url"`
+	Accepted bool   `json:"accepted"` // 按确定（true）还是取消（false）关闭
+}
+
+// dismissDialog 关闭页面弹出的对话框，避免 alert / confirm 阻塞页面脚本和导航。
+// alert 和 beforeunload 总是确认（前者只有确定按钮，后者确认才能离开页面）；
+// confirm 和 prompt 按 DialogAcceptValue 回答，prompt 确认时提交默认值。
+// 在 ListenTarget 回调中以 goroutine 调用：回调里同步执行 CDP 命令会死锁。
+func (s *Spider) dismissDialog(ctx context.Context, ev *page.EventJavascriptDialogOpening) {
+	accept := true
+	if ev.Type == page.DialogTypeConfirm || ev.Type == page.DialogTypePrompt {
+		accept = s.config.DialogAcceptValue
+	}
+
+	action := page.HandleJavaScriptDialog(accept)
+	if ev.Type == page.DialogTypePrompt && accept {
+		action = action.WithPromptText(ev.DefaultPrompt)
+	}
+	if err := chromedp.Run(ctx, action); err != nil {
+		logging.Warnf("关闭 %s 对话框失败: %v", ev.Type, err)
+		return
+	}
+
+	s.mu.Lock()
+	s.result.Dialogs = append(s.result.Dialogs, JSDialog{
+		Type:     ev.Type.String(),
+		Message:  ev.Message,
+		FrameURL: ev.URL,
+		Accepted: accept,
+	})
+	s.mu.Unlock()
+	log.Printf("已关闭 %s 对话框（%s）: %q", ev.Type, dialogAnswer(accept), ev.Message)
+}
+
+// dialogAnswer 报告和日志中对话框的处理方式
+func dialogAnswer(accept bool) string {
+	if accept {
+		return "accepted"
+	}
+	return "dismissed"
+}
@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/chromedp"
+)
+
+// BrowserInfo CheckBrowser 探测到的浏览器信息
+type BrowserInfo struct {
+	Path            string        // 实际启动的可执行文件（优先取浏览器命令行，否则为 FindChrome 的结果）
+	Product         string        // 如 HeadlessChrome/126.0.6478.126
+	ProtocolVersion string        // CDP 协议版本
+	UserAgent       string        // 默认 User-Agent
+	StartupTime     time.Duration // 启动到可用的耗时
+}
+
+// CheckBrowser 按 config 的启动参数（路径、代理、无头模式等）启动一个最小的浏览器会话，
+// 打开 about:blank 并读取版本信息，用于批量爬取前的环境自检
+func CheckBrowser(ctx context.Context, config *Config) (*BrowserInfo, error) {
+	path, err := FindChrome(config.ChromePath)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := buildAllocatorOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	allocCtx, allocCancel := chromedp.NewExecAllocator(ctx, opts...)
+	defer allocCancel()
+	tabCtx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+
+	start := time.Now()
+	if err := chromedp.Run(tabCtx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrChromeStart, err)
+	}
+	info := &BrowserInfo{Path: path, StartupTime: time.Since(start)}
+
+	err = chromedp.Run(tabCtx,
+		chromedp.Navigate("about:blank"),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			info.ProtocolVersion, info.Product, _, info.UserAgent, _, err = browser.GetVersion().Do(ctx)
+			if err != nil {
+				return fmt.Errorf("读取浏览器版本失败: %w", err)
+			}
+			// 命令行的第一项即可执行文件路径；仅在 --enable-automation 下可用，失败不影响自检
+			if args, err := browser.GetBrowserCommandLine().Do(ctx); err == nil && len(args) > 0 {
+				info.Path = args[0]
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("浏览器已启动但无法打开页面: %w", err)
+	}
+	return info, nil
+}
+
+// CheckProxy 检查代理地址能否建立 TCP 连接（不发送请求）
+func CheckProxy(ctx context.Context, proxy string, timeout time.Duration) error {
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("代理地址无效: %s", proxy)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		switch u.Scheme {
+		case "https":
+			port = "443"
+		case "socks4", "socks5":
+			port = "1080"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return fmt.Errorf("无法连接代理 %s: %w", host, err)
+	}
+	return conn.Close()
+}
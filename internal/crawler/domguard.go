@@ -0,0 +1,57 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/chromedp"
+
+	"spider/internal/logging"
+)
+
+// SkipReasonDOMTooLarge DOM 超过 MaxDOMBytes 时跳过可选步骤的原因
+const SkipReasonDOMTooLarge = "dom-too-large"
+
+// SkippedArtifact 因页面异常（DOM 过大等）而跳过的可选产物，网络资源抓取不受影响
+type SkippedArtifact struct {
+	Artifact string `json:"artifact"` // scroll-screenshots / rendered-html / links
+	Reason   string `json:"reason"`   // 如 dom-too-large
+	Detail   string `json:"detail,omitempty"`
+}
+
+// checkDOMSize 在滚动等重步骤之前测量序列化后的 DOM 大小（在浏览器内计算，只返回长度），
+// 超过 MaxDOMBytes 时标记 domTooLarge，截图、渲染 DOM 和链接提取随后跳过。
+// 测量失败时按未超限处理。
+func (s *Spider) checkDOMSize(ctx context.Context) {
+	if s.config.MaxDOMBytes <= 0 {
+		return
+	}
+	var size int64
+	if err := chromedp.Run(ctx, chromedp.Evaluate(`document.documentElement ? document.documentElement.outerHTML.length : 0`, &size)); err != nil {
+		logging.Warnf("测量 DOM 大小失败: %v", err)
+		return
+	}
+	if size <= s.config.MaxDOMBytes {
+		return
+	}
+
+	detail := fmt.Sprintf("%d bytes > MaxDOMBytes %d", size, s.config.MaxDOMBytes)
+	logging.Warnf("DOM 过大（%s），跳过截图、渲染 DOM 和链接提取，网络资源照常抓取", detail)
+	s.mu.Lock()
+	s.domTooLarge = true
+	s.mu.Unlock()
+
+	s.skip(s.config.ScrollScreenshots, "scroll-screenshots", detail)
+	s.skip(s.config.CaptureRenderedHTML, "rendered-html", detail)
+	s.skip(true, "links", detail)
+}
+
+// skip 记录一个因 DOM 过大被跳过的产物，enabled 为 false（本就未开启）时不记录
+func (s *Spider) skip(enabled bool, artifact, detail string) {
+	if !enabled {
+		return
+	}
+	s.mu.Lock()
+	s.result.Skipped = append(s.result.Skipped, SkippedArtifact{Artifact: artifact, Reason: SkipReasonDOMTooLarge, Detail: detail})
+	s.mu.Unlock()
+}
@@ -0,0 +1,50 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+)
+
+// 只记录本就开启的产物；链接提取始终进行，因此总会记录
+func TestSkipRecordsEnabledArtifacts(t *testing.T) {
+	config := DefaultConfig()
+	config.CaptureRenderedHTML = true
+	s, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.skip(config.ScrollScreenshots, "scroll-screenshots", "60 bytes > MaxDOMBytes 10")
+	s.skip(config.CaptureRenderedHTML, "rendered-html", "60 bytes > MaxDOMBytes 10")
+	s.skip(true, "links", "60 bytes > MaxDOMBytes 10")
+
+	skipped := s.Result().Skipped
+	if len(skipped) != 2 || skipped[0].Artifact != "rendered-html" || skipped[1].Artifact != "links" {
+		t.Fatalf("Skipped = %+v，应为 rendered-html 和 links", skipped)
+	}
+	for _, sk := range skipped {
+		if sk.Reason != SkipReasonDOMTooLarge || sk.Detail == "" {
+			t.Errorf("跳过记录 %+v 缺少原因或详情", sk)
+		}
+	}
+}
+
+// MaxDOMBytes 为 0 时不测量（不访问浏览器），也不跳过任何步骤
+func TestCheckDOMSizeDisabled(t *testing.T) {
+	s, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.checkDOMSize(ctx)
+	if s.domTooLarge || len(s.Result().Skipped) != 0 {
+		t.Error("未设置 MaxDOMBytes 时不应跳过任何步骤")
+	}
+
+	config := DefaultConfig()
+	config.MaxDOMBytes = -1
+	if err := config.Validate(); err == nil {
+		t.Error("负的 MaxDOMBytes 应报错")
+	}
+}
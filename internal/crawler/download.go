@@ -0,0 +1,86 @@
+package crawler
+
+import (
+	"fmt"
+	"maps"
+	"mime"
+	"strings"
+	"time"
+)
+
+// DownloadResource 用备用 HTTP 客户端（继承代理、Cookie、Headers 和 User-Agent）直接下载 rawURL，
+// 加入资源表并返回；source 写入 X-Source 头，标明资源不是浏览器加载的。
+// 资源表中已有该地址或被 PathPrefix / Filters 排除时返回 nil, nil。
+// 用于补齐页面引用了但爬取期间没有加载的资源
+func (s *Spider) DownloadResource(rawURL, source string) (*Resource, error) {
+	resource, err := s.directResource(rawURL, source)
+	if err != nil {
+		return nil, err
+	}
+	if !s.addResource(resource) {
+		return nil, nil
+	}
+	return resource, nil
+}
+
+// directResource 直接下载 rawURL 并生成资源，归到当前页面和标记
+func (s *Spider) directResource(rawURL, source string) (*Resource, error) {
+	resp, body, err := s.fetchDirect(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string, len(resp.Header)+1)
+	for name, values := range resp.Header {
+		headers[name] = strings.Join(values, "\n")
+	}
+	headers["X-Source"] = source
+	mimeType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	s.mu.Lock()
+	pageURL := s.pageURL
+	marker := s.marker
+	s.mu.Unlock()
+	resource := &Resource{
+		URL:          rawURL,
+		Method:       "GET",
+		StatusCode:   resp.StatusCode,
+		StatusText:   strings.TrimSpace(strings.TrimPrefix(resp.Status, fmt.Sprint(resp.StatusCode))),
+		Protocol:     directProtocol(resp.ProtoMajor),
+		MimeType:     mimeType,
+		Content:      body,
+		Headers:      headers,
+		ResponseTime: time.Now(),
+		Context:      "page",
+		Labels:       maps.Clone(s.config.ResourceLabels),
+		Marker:       marker,
+	}
+	if pageURL != "" {
+		resource.Pages = []string{pageURL}
+	}
+	return resource, nil
+}
+
+// addResource 按 PathPrefix / Filters 过滤后加入资源表，已有同一地址时不覆盖；返回是否加入
+func (s *Spider) addResource(resource *Resource) bool {
+	if !s.shouldCapture(resource) {
+		return false
+	}
+	key := s.resourceKey(resource.URL)
+	s.mu.Lock()
+	if _, exists := s.resources[key]; exists {
+		s.mu.Unlock()
+		return false
+	}
+	s.resources[key] = resource
+	s.mu.Unlock()
+	s.notifyCapture(resource)
+	return true
+}
+
+// directProtocol 备用下载的协议，与 CDP 的 Response.protocol 写法一致
+func directProtocol(major int) string {
+	if major == 2 {
+		return "h2"
+	}
+	return "http/1.1"
+}
@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// DownloadResource 直接下载并加入资源表：X-Source 标明来源，MIME 去掉参数，归到当前页面；
+// 已有的地址不覆盖，PathPrefix 之外的不加入
+func TestDownloadResource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/assets/missing.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+		w.Write([]byte("<svg/>"))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.PathPrefix = "/assets/"
+	s, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.pageURL = server.URL + "/assets/page.html"
+
+	res, err := s.DownloadResource(server.URL+"/assets/logo.svg", "UnreferencedDownload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res == nil {
+		t.Fatal("未加入资源表")
+	}
+	if res.StatusCode != 200 || res.MimeType != "image/svg+xml" || string(res.Content) != "<svg/>" {
+		t.Errorf("资源为 %d %q %q", res.StatusCode, res.MimeType, res.Content)
+	}
+	if res.Headers["X-Source"] != "UnreferencedDownload" {
+		t.Errorf("X-Source 为 %q", res.Headers["X-Source"])
+	}
+	if len(res.Pages) != 1 || res.Pages[0] != s.pageURL {
+		t.Errorf("Pages 为 %v", res.Pages)
+	}
+	if got := s.GetResources()[res.URL]; got != res {
+		t.Error("资源表中没有下载的资源")
+	}
+
+	// 错误状态也照常记录
+	if res, err := s.DownloadResource(server.URL+"/assets/missing.png", "UnreferencedDownload"); err != nil || res == nil || res.StatusCode != 404 {
+		t.Errorf("404 资源为 %+v, %v", res, err)
+	}
+
+	// 已有的地址不覆盖
+	if res, err := s.DownloadResource(server.URL+"/assets/logo.svg", "Other"); res != nil || err != nil {
+		t.Errorf("已有的地址返回 %+v, %v", res, err)
+	}
+	if got := s.GetResources()[server.URL+"/assets/logo.svg"].Headers["X-Source"]; got != "UnreferencedDownload" {
+		t.Errorf("已有的资源被覆盖，X-Source 为 %q", got)
+	}
+
+	// PathPrefix 之外的不加入
+	if res, err := s.DownloadResource(server.URL+"/other.svg", "UnreferencedDownload"); res != nil || err != nil {
+		t.Errorf("PathPrefix 之外的地址返回 %+v, %v", res, err)
+	}
+	if _, ok := s.GetResources()[server.URL+"/other.svg"]; ok {
+		t.Error("PathPrefix 之外的地址被加入资源表")
+	}
+
+	if _, err := s.DownloadResource("http://127.0.0.1:1/unreachable.js", "UnreferencedDownload"); err == nil {
+		t.Error("下载失败时应返回错误")
+	}
+}
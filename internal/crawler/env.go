@@ -0,0 +1,72 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// PopulateFromEnvironment 读取的环境变量，容器部署中代替命令行参数
+const (
+	EnvTimeout     = "SPIDER_TIMEOUT"     // Timeout：秒数（如 30）或时长（如 45s、2m）
+	EnvProxy       = "SPIDER_PROXY"       // Proxy
+	EnvCookie      = "SPIDER_COOKIE"      // Cookies
+	EnvUserAgent   = "SPIDER_USER_AGENT"  // UserAgent
+	EnvConcurrency = "SPIDER_CONCURRENCY" // Concurrency：正整数
+	EnvHeadless    = "SPIDER_HEADLESS"    // Headless：true / false / 1 / 0
+)
+
+// PopulateFromEnvironment 用 SPIDER_* 环境变量覆盖对应字段，未设置的变量不改动字段。
+// 格式错误的变量全部列出后返回，此时格式正确的变量已经生效。
+// 命令行参数应在之后再次应用，以保持显式参数优先
+func (c *Config) PopulateFromEnvironment() error {
+	var errs []error
+	if v, ok := os.LookupEnv(EnvTimeout); ok {
+		if d, err := parseEnvDuration(v); err != nil {
+			errs = append(errs, fmt.Errorf("%s 应为秒数或时长（如 30、45s）: %q", EnvTimeout, v))
+		} else {
+			c.Timeout = d
+		}
+	}
+	if v, ok := os.LookupEnv(EnvProxy); ok {
+		c.Proxy = v
+	}
+	if v, ok := os.LookupEnv(EnvCookie); ok {
+		c.Cookies = v
+	}
+	if v, ok := os.LookupEnv(EnvUserAgent); ok {
+		c.UserAgent = v
+	}
+	if v, ok := os.LookupEnv(EnvConcurrency); ok {
+		if n, err := strconv.Atoi(v); err != nil || n <= 0 {
+			errs = append(errs, fmt.Errorf("%s 应为正整数: %q", EnvConcurrency, v))
+		} else {
+			c.Concurrency = n
+		}
+	}
+	if v, ok := os.LookupEnv(EnvHeadless); ok {
+		if b, err := strconv.ParseBool(v); err != nil {
+			errs = append(errs, fmt.Errorf("%s 应为 true 或 false: %q", EnvHeadless, v))
+		} else {
+			c.Headless = b
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parseEnvDuration 不带单位的整数按秒计（与 -timeout 一致），否则按 time.ParseDuration 解析
+func parseEnvDuration(v string) (time.Duration, error) {
+	if n, err := strconv.Atoi(v); err == nil {
+		if n <= 0 {
+			return 0, errors.New("必须大于 0")
+		}
+		return time.Duration(n) * time.Second, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err == nil && d <= 0 {
+		err = errors.New("必须大于 0")
+	}
+	return d, err
+}
@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"spider/internal/logging"
+)
+
+const eventQueueSize = 8192
+
+// eventRecord network-events.jsonl 中的一行
+type eventRecord struct {
+	Time   time.Time `json:"time"`
+	Method string    `json:"method"`
+	Params any       `json:"params"`
+}
+
+// eventRecorder 将 CDP network.* 事件编码为 JSON Lines。
+// 监听回调只做入队，编码和写入在独立 goroutine 中经 bufio 缓冲完成，避免拖慢事件分发。
+type eventRecorder struct {
+	mu      sync.Mutex
+	ch      chan eventRecord
+	closed  bool
+	dropped int
+	done    chan struct{}
+	buf     bytes.Buffer
+}
+
+func newEventRecorder() *eventRecorder {
+	r := &eventRecorder{
+		ch:   make(chan eventRecord, eventQueueSize),
+		done: make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+func (r *eventRecorder) run() {
+	defer close(r.done)
+	w := bufio.NewWriterSize(&r.buf, 64*1024)
+	enc := json.NewEncoder(w)
+	for rec := range r.ch {
+		if err := enc.Encode(rec); err != nil {
+			logging.Warnf("编码网络事件失败 %s: %v", rec.Method, err)
+		}
+	}
+	w.Flush()
+}
+
+// record 记录一个事件；非 network.* 事件忽略，队列满时丢弃并计数
+func (r *eventRecorder) record(ev any) {
+	method, ok := networkEventMethod(ev)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.ch <- eventRecord{Time: time.Now(), Method: method, Params: ev}:
+	default:
+		r.dropped++
+	}
+}
+
+// close 停止接收并等待缓冲写完，返回 JSON Lines 数据
+func (r *eventRecorder) close() []byte {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.ch)
+	}
+	dropped := r.dropped
+	r.mu.Unlock()
+
+	<-r.done
+	if dropped > 0 {
+		logging.Warnf("网络事件队列已满，丢弃了 %d 个事件", dropped)
+	}
+	return r.buf.Bytes()
+}
+
+// networkEventMethod 由事件类型推导 CDP 方法名：*network.EventResponseReceived → Network.responseReceived
+func networkEventMethod(ev any) (string, bool) {
+	name := fmt.Sprintf("%T", ev)
+	const prefix = "*network.Event"
+	if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+		return "", false
+	}
+	runes := []rune(name[len(prefix):])
+	runes[0] = unicode.ToLower(runes[0])
+	return "Network." + string(runes), true
+}
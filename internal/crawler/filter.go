@@ -0,0 +1,160 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ResourceFilter 自定义资源筛选（Config.Filters）。ShouldCapture 在收到响应头后、获取响应体之前调用，
+// 此时 Content 为空，只能依据 URL、状态码、MimeType、响应头等判断；返回 false 的资源不获取也不保存。
+// 会被多个响应 goroutine 并发调用，实现需并发安全。
+type ResourceFilter interface {
+	ShouldCapture(r *Resource) bool
+}
+
+// MIMEFilter 只保留 MimeType 命中 Types 的资源。以 / 结尾的项按前缀匹配（如 "image/"），
+// 其余按去掉参数后的类型精确匹配（如 "application/javascript"），不区分大小写。
+// 以 application/octet-stream 等通用类型返回的 .wasm 按 application/wasm 匹配（此时还没有内容可供嗅探）
+type MIMEFilter struct {
+	Types []string
+}
+
+func (f MIMEFilter) ShouldCapture(r *Resource) bool {
+	base, _, _ := strings.Cut(strings.ToLower(r.MimeType), ";")
+	base = strings.TrimSpace(base)
+	if (base == "" || base == "application/octet-stream" || base == "binary/octet-stream") && isWasmURL(r.URL) {
+		base = "application/wasm"
+	}
+	for _, t := range f.Types {
+		t = strings.ToLower(t)
+		if strings.HasSuffix(t, "/") && strings.HasPrefix(base, t) || base == t {
+			return true
+		}
+	}
+	return false
+}
+
+// isWasmURL URL 路径是否以 .wasm 结尾
+func isWasmURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && strings.HasSuffix(strings.ToLower(u.Path), ".wasm")
+}
+
+// DomainFilter 只保留主机为 Domains 之一或其子域名的资源；Exclude 为 true 时反过来排除这些域名
+type DomainFilter struct {
+	Domains []string
+	Exclude bool
+}
+
+func (f DomainFilter) ShouldCapture(r *Resource) bool {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return f.Exclude
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, d := range f.Domains {
+		if domainMatches(host, strings.ToLower(strings.TrimPrefix(d, "*."))) {
+			return !f.Exclude
+		}
+	}
+	return f.Exclude
+}
+
+// URLRegexFilter 只保留完整 URL 匹配 Pattern 的资源；Exclude 为 true 时排除匹配的资源
+type URLRegexFilter struct {
+	Pattern *regexp.Regexp
+	Exclude bool
+}
+
+// NewURLRegexFilter 编译 pattern 构建 URLRegexFilter（未加锚点时匹配 URL 的任意部分）；
+// pattern 不是合法的正则表达式时返回错误
+func NewURLRegexFilter(pattern string, exclude bool) (URLRegexFilter, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return URLRegexFilter{}, fmt.Errorf("URLRegexFilter 正则无效 %q: %w", pattern, err)
+	}
+	return URLRegexFilter{Pattern: re, Exclude: exclude}, nil
+}
+
+func (f URLRegexFilter) ShouldCapture(r *Resource) bool {
+	return f.Pattern.MatchString(r.URL) != f.Exclude
+}
+
+// StatusCodeFilter 只保留状态码在 [Min, Max] 内的资源，Max 为 0 表示不设上限
+type StatusCodeFilter struct {
+	Min, Max int
+}
+
+func (f StatusCodeFilter) ShouldCapture(r *Resource) bool {
+	return r.StatusCode >= f.Min && (f.Max == 0 || r.StatusCode <= f.Max)
+}
+
+// PathPrefixFilter 只保留 URL 路径以 Prefix 开头的资源（Config.PathPrefix）
+type PathPrefixFilter struct {
+	Prefix string
+}
+
+func (f PathPrefixFilter) ShouldCapture(r *Resource) bool {
+	return HasPathPrefix(r.URL, f.Prefix)
+}
+
+// HasPathPrefix URL 路径是否以 prefix 开头，prefix 为空时总是成立
+func HasPathPrefix(rawURL, prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	return strings.HasPrefix(path, prefix)
+}
+
+// AndFilter 全部子筛选通过时保留，空列表保留全部
+type AndFilter []ResourceFilter
+
+func (f AndFilter) ShouldCapture(r *Resource) bool {
+	for _, filter := range f {
+		if !filter.ShouldCapture(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// OrFilter 任一子筛选通过时保留，空列表不保留任何资源
+type OrFilter []ResourceFilter
+
+func (f OrFilter) ShouldCapture(r *Resource) bool {
+	for _, filter := range f {
+		if filter.ShouldCapture(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldCapture 依次执行 Config.ResourceIgnoreList、PathPrefix 和 Filters，任一不通过即跳过该资源并计数。
+// 内联合成资源的路径不是真实路径，不受 PathPrefix 限制
+func (s *Spider) shouldCapture(r *Resource) bool {
+	if s.ignore.Match(r.URL) {
+		s.mu.Lock()
+		s.ignoredCount++
+		s.mu.Unlock()
+		return false
+	}
+	inPrefix := strings.HasPrefix(r.URL, InlineScheme+"://") || HasPathPrefix(r.URL, s.config.PathPrefix)
+	if inPrefix && AndFilter(s.config.Filters).ShouldCapture(r) {
+		return true
+	}
+	s.mu.Lock()
+	s.filteredCount++
+	s.mu.Unlock()
+	return false
+}
@@ -0,0 +1,248 @@
+package crawler
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+
+	"spider/internal/ignorelist"
+)
+
+// keep / drop 固定结果的筛选，用于组合测试
+type constFilter bool
+
+func (f constFilter) ShouldCapture(*Resource) bool { return bool(f) }
+
+func TestMIMEFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []string
+		mime  string
+		url   string
+		want  bool
+	}{
+		{"精确匹配", []string{"application/javascript"}, "application/javascript", "https://a.com/app.js", true},
+		{"忽略参数和大小写", []string{"Text/HTML"}, "text/html; charset=UTF-8", "https://a.com/", true},
+		{"前缀匹配", []string{"image/"}, "image/svg+xml", "https://a.com/logo.svg", true},
+		{"不带 / 不按前缀", []string{"image"}, "image/png", "https://a.com/logo.png", false},
+		{"不在列表中", []string{"image/", "text/css"}, "application/json", "https://a.com/api", false},
+		{"空列表", nil, "text/html", "https://a.com/", false},
+		{"通用类型的 wasm", []string{"application/wasm"}, "application/octet-stream", "https://a.com/m.WASM?v=1", true},
+		{"缺少类型的 wasm", []string{"application/wasm"}, "", "https://a.com/m.wasm", true},
+		{"通用类型但不是 wasm", []string{"application/wasm"}, "application/octet-stream", "https://a.com/m.bin", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (MIMEFilter{Types: tt.types}).ShouldCapture(&Resource{URL: tt.url, MimeType: tt.mime}); got != tt.want {
+				t.Errorf("%v 对 %q (%s) 返回 %v，应为 %v", tt.types, tt.mime, tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDomainFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		domains []string
+		exclude bool
+		url     string
+		want    bool
+	}{
+		{"主机相同", []string{"example.com"}, false, "https://example.com/a.js", true},
+		{"子域名", []string{"example.com"}, false, "https://cdn.EXAMPLE.com:8443/a.js", true},
+		{"*. 前缀", []string{"*.example.com"}, false, "https://cdn.example.com/a.js", true},
+		{"后缀相同但不是子域名", []string{"example.com"}, false, "https://badexample.com/a.js", false},
+		{"其他域名", []string{"example.com"}, false, "https://other.org/a.js", false},
+		{"排除命中", []string{"tracker.io"}, true, "https://px.tracker.io/p.gif", false},
+		{"排除未命中", []string{"tracker.io"}, true, "https://example.com/a.js", true},
+		{"无效 URL", []string{"example.com"}, false, "http://[::1", false},
+		{"排除时的无效 URL", []string{"example.com"}, true, "http://[::1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := DomainFilter{Domains: tt.domains, Exclude: tt.exclude}
+			if got := f.ShouldCapture(&Resource{URL: tt.url}); got != tt.want {
+				t.Errorf("%+v 对 %s 返回 %v，应为 %v", f, tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLRegexFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		exclude bool
+		url     string
+		want    bool
+	}{
+		{"匹配任意部分", `\.js$`, false, "https://a.com/app.js", true},
+		{"不匹配", `\.js$`, false, "https://a.com/app.css", false},
+		{"锚定完整 URL", `^https://a\.com/static/`, false, "https://b.com/static/a.js?u=https://a.com/static/", false},
+		{"排除匹配", `/analytics/`, true, "https://a.com/analytics/p.gif", false},
+		{"排除未匹配", `/analytics/`, true, "https://a.com/app.js", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewURLRegexFilter(tt.pattern, tt.exclude)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.ShouldCapture(&Resource{URL: tt.url}); got != tt.want {
+				t.Errorf("%s (exclude=%v) 对 %s 返回 %v，应为 %v", tt.pattern, tt.exclude, tt.url, got, tt.want)
+			}
+			// 与直接构造的筛选等价
+			direct := URLRegexFilter{Pattern: regexp.MustCompile(tt.pattern), Exclude: tt.exclude}
+			if direct.ShouldCapture(&Resource{URL: tt.url}) != tt.want {
+				t.Error("直接构造的 URLRegexFilter 结果不同")
+			}
+		})
+	}
+
+	for _, pattern := range []string{`(`, `[a-`, `a**`} {
+		if _, err := NewURLRegexFilter(pattern, false); err == nil {
+			t.Errorf("无效的正则 %q 应返回错误", pattern)
+		}
+	}
+}
+
+func TestStatusCodeFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max int
+		status   int
+		want     bool
+	}{
+		{"区间内", 200, 299, 204, true},
+		{"下界", 200, 299, 200, true},
+		{"上界", 200, 299, 299, true},
+		{"低于下界", 200, 299, 199, false},
+		{"高于上界", 200, 299, 300, false},
+		{"Max 为 0 不设上限", 400, 0, 599, true},
+		{"零值保留全部", 0, 0, 500, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := StatusCodeFilter{Min: tt.min, Max: tt.max}
+			if got := f.ShouldCapture(&Resource{StatusCode: tt.status}); got != tt.want {
+				t.Errorf("%+v 对 %d 返回 %v，应为 %v", f, tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAndOrFilter(t *testing.T) {
+	keep, drop := constFilter(true), constFilter(false)
+	tests := []struct {
+		name   string
+		filter ResourceFilter
+		want   bool
+	}{
+		{"And 空列表保留全部", AndFilter{}, true},
+		{"And nil 保留全部", AndFilter(nil), true},
+		{"And 全部通过", AndFilter{keep, keep}, true},
+		{"And 一个不通过", AndFilter{keep, drop}, false},
+		{"Or 空列表不保留", OrFilter{}, false},
+		{"Or nil 不保留", OrFilter(nil), false},
+		{"Or 一个通过", OrFilter{drop, keep}, true},
+		{"Or 全部不通过", OrFilter{drop, drop}, false},
+		{"嵌套", AndFilter{OrFilter{drop, keep}, AndFilter{}}, true},
+		{"嵌套空 Or", AndFilter{keep, OrFilter{}}, false},
+		{"组合真实筛选", AndFilter{
+			MIMEFilter{Types: []string{"image/"}},
+			OrFilter{DomainFilter{Domains: []string{"cdn.com"}}, StatusCodeFilter{Min: 400}},
+		}, true},
+	}
+	res := &Resource{URL: "https://cdn.com/logo.png", MimeType: "image/png", StatusCode: 200}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.ShouldCapture(res); got != tt.want {
+				t.Errorf("返回 %v，应为 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// shouldCapture 依次检查忽略清单、PathPrefix 和 Filters，分别计入 ignoredCount 和 filteredCount；
+// 内联合成资源不受 PathPrefix 限制
+func TestShouldCapture(t *testing.T) {
+	ignore, err := ignorelist.Load([]string{ignorelist.Analytics})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig()
+	config.PathPrefix = "/assets/"
+	config.Filters = []ResourceFilter{StatusCodeFilter{Min: 200, Max: 299}}
+	s, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.ignore = ignore
+
+	tests := []struct {
+		name            string
+		res             Resource
+		want            bool
+		ignored, filter int // 调用后的累计计数
+	}{
+		{"通过", Resource{URL: "https://a.com/assets/app.js", StatusCode: 200}, true, 0, 0},
+		{"PathPrefix 之外", Resource{URL: "https://a.com/app.js", StatusCode: 200}, false, 0, 1},
+		{"Filters 不通过", Resource{URL: "https://a.com/assets/gone.js", StatusCode: 404}, false, 0, 2},
+		{"忽略清单优先", Resource{URL: "https://www.google-analytics.com/assets/a.js", StatusCode: 404}, false, 1, 2},
+		{"内联资源不受 PathPrefix 限制", Resource{URL: InlineScheme + "://a.com/page/inline-script-0.js", StatusCode: 200}, true, 1, 2},
+		{"内联资源仍经过 Filters", Resource{URL: InlineScheme + "://a.com/page/inline-script-1.js", StatusCode: 500}, false, 1, 3},
+	}
+	for _, tt := range tests {
+		if got := s.shouldCapture(&tt.res); got != tt.want {
+			t.Errorf("%s: 返回 %v，应为 %v", tt.name, got, tt.want)
+		}
+		if s.ignoredCount != tt.ignored || s.filteredCount != tt.filter {
+			t.Errorf("%s: ignored=%d filtered=%d，应为 %d、%d", tt.name, s.ignoredCount, s.filteredCount, tt.ignored, tt.filter)
+		}
+	}
+
+	// 没有任何筛选时全部保留
+	s, err = New(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.shouldCapture(&Resource{URL: "https://a.com/x", StatusCode: 500}) || s.filteredCount != 0 {
+		t.Error("未配置筛选时跳过了资源")
+	}
+}
+
+// handleResponse 中被 Filters 拒绝的资源不获取响应体、不加入资源表，计入 Stats.Filtered
+func TestHandleResponseFiltered(t *testing.T) {
+	fetched := 0
+	s := newBodySpider(t, time.Second, func(context.Context, network.RequestID) ([]byte, error) {
+		fetched++
+		return []byte("body"), nil
+	})
+	s.config.Filters = []ResourceFilter{MIMEFilter{Types: []string{"image/"}}}
+
+	ctx := context.Background()
+	receive(ctx, s, "1", "https://a.com/app.js")
+	s.loads.finish("2")
+	s.handleResponse(ctx, &network.EventResponseReceived{
+		RequestID: "2",
+		Response:  &network.Response{URL: "https://a.com/logo.png", Status: 200, MimeType: "image/png"},
+	}, "page")
+	s.wg.Wait()
+
+	resources := s.GetResources()
+	if _, ok := resources["https://a.com/app.js"]; ok {
+		t.Error("被筛掉的脚本加入了资源表")
+	}
+	if res := resources["https://a.com/logo.png"]; res == nil || string(res.Content) != "body" {
+		t.Errorf("通过筛选的图片为 %+v", res)
+	}
+	if fetched != 1 {
+		t.Errorf("获取了 %d 次响应体，被筛掉的资源不应获取", fetched)
+	}
+	if stats := s.snapshot().Stats; stats.Filtered != 1 || stats.Resources != 1 {
+		t.Errorf("Stats 为 %+v，应为 Filtered 1、Resources 1", stats)
+	}
+}
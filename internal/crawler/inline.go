@@ -0,0 +1,136 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+
+	"spider/internal/logging"
+)
+
+// InlineScheme 内联脚本 / 样式合成资源的 URL 协议，如 inline://example.com/inline_script_1.js
+const InlineScheme = "inline"
+
+// inlineScriptsScript 按文档顺序列出没有 src 的 <script> 的内容
+const inlineScriptsScript = `Array.from(document.querySelectorAll('script:not([src])'), function(s){ return s.textContent || ""; })`
+
+// inlineStylesScript 按文档顺序列出 <style> 的内容
+const inlineStylesScript = `Array.from(document.querySelectorAll('style'), function(s){ return s.textContent || ""; })`
+
+// inlineKind 一类内联内容的合成资源参数
+type inlineKind struct {
+	script   string // 读取内容列表的页面脚本
+	name     string // 文件名前缀，如 inline_script
+	ext      string
+	mimeType string
+	source   string // X-Source 头
+	label    string // 日志中的名称
+}
+
+var (
+	inlineScripts = inlineKind{
+		script:   inlineScriptsScript,
+		name:     "inline_script",
+		ext:      ".js",
+		mimeType: "application/javascript",
+		source:   "InlineScript",
+		label:    "内联脚本（无 src 的 <script>）",
+	}
+	inlineStyles = inlineKind{
+		script:   inlineStylesScript,
+		name:     "inline_style",
+		ext:      ".css",
+		mimeType: "text/css",
+		source:   "InlineStyle",
+		label:    "内联样式（<style>）",
+	}
+)
+
+// extractInlineScripts 将页面中的内联 <script> 保存为合成资源（ExtractInlineScripts）。
+// 内联脚本不产生网络请求，监听抓不到；其中常有初始化配置和写死的密钥。
+func (s *Spider) extractInlineScripts(ctx context.Context) {
+	s.extractInline(ctx, inlineScripts)
+}
+
+// extractInlineStyles 将页面中的 <style> 保存为合成资源（ExtractInlineStyles）。
+// 为首屏性能内联的关键 CSS 同样不产生网络请求，其 source map 只能从这里发现
+func (s *Spider) extractInlineStyles(ctx context.Context) {
+	s.extractInline(ctx, inlineStyles)
+}
+
+// extractInline 读取页面中一类内联内容并保存为 inline://<host>/<name>_<N><ext> 合成资源。
+// 编号按元素在文档中的位置（从 1 开始），空内容跳过但占用编号，重复抓取同一页面时编号稳定。
+func (s *Spider) extractInline(ctx context.Context, kind inlineKind) {
+	var contents []string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(kind.script, &contents)); err != nil {
+		logging.Warnf("读取%s失败: %v", kind.label, err)
+		return
+	}
+
+	s.mu.Lock()
+	pageURL := s.pageURL
+	marker := s.marker
+	s.mu.Unlock()
+	// 有 <base href> 时内联内容中的相对地址按它解析
+	base := s.documentBase(ctx)
+	if base == pageURL {
+		base = ""
+	}
+	host := "unknown"
+	if u, err := url.Parse(pageURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	extracted := 0
+	for i, content := range contents {
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		resource := &Resource{
+			URL:          fmt.Sprintf("%s://%s/%s_%d%s", InlineScheme, host, kind.name, i+1, kind.ext),
+			Method:       "GET",
+			StatusCode:   200,
+			MimeType:     kind.mimeType,
+			Content:      []byte(content),
+			Headers:      map[string]string{"X-Source": kind.source},
+			ResponseTime: time.Now(),
+			Context:      "page",
+			Labels:       maps.Clone(s.config.ResourceLabels),
+			Marker:       marker,
+			BaseURL:      base,
+		}
+		if pageURL != "" {
+			resource.Pages = []string{pageURL}
+		}
+		if !s.shouldCapture(resource) {
+			continue
+		}
+
+		key := s.resourceKey(resource.URL)
+		s.mu.Lock()
+		if _, exists := s.resources[key]; exists {
+			s.mu.Unlock()
+			continue
+		}
+		s.resources[key] = resource
+		s.mu.Unlock()
+		extracted++
+		s.notifyCapture(resource)
+	}
+	log.Printf("已提取 %d 个%s，页面共 %d 个", extracted, kind.label, len(contents))
+}
+
+// InlineResourcePage 内联脚本 / 样式合成资源所在页面的 URL，其中的相对 sourceMappingURL 按该页面（或其 <base href>，见 ReferenceBase）解析；
+// 不是内联合成资源时返回空字符串
+func InlineResourcePage(res *Resource) string {
+	if !strings.HasPrefix(res.URL, InlineScheme+"://") || len(res.Pages) == 0 {
+		return ""
+	}
+	return res.Pages[0]
+}
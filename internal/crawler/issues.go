@@ -0,0 +1,182 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/cdproto/audits"
+	cdplog "github.com/chromedp/cdproto/log"
+
+	"spider/internal/logging"
+)
+
+// 浏览器问题的分类，报告按此顺序分组输出
+const (
+	IssueCSP          = "csp"
+	IssueMixedContent = "mixed-content"
+	IssueCORS         = "cors"
+	IssueCookie       = "cookie"
+	IssueBlocked      = "blocked-response"
+	IssueDeprecation  = "deprecation"
+	IssueOther        = "other"
+)
+
+// IssueCategories 全部分类，按报告中的输出顺序排列
+var IssueCategories = []string{IssueCSP, IssueMixedContent, IssueCORS, IssueCookie, IssueBlocked, IssueDeprecation, IssueOther}
+
+// BrowserIssue 爬取期间浏览器报告的问题（CaptureBrowserIssues）：DevTools Issues 面板中的条目（Audits 域）
+// 和 Log 域的警告 / 错误，用于解释资源为何被拦截或没有加载
+type BrowserIssue struct {
+	Category string `json:"category"`        // csp / mixed-content / cors / cookie / blocked-response / deprecation / other
+	Source   string `json:"source"`          // audits，或 Log 条目的来源: network / security / deprecation / intervention ...
+	Code     string `json:"code,omitempty"`  // Audits 的问题类型，如 ContentSecurityPolicyIssue
+	Level    string `json:"level,omitempty"` // Log 条目的级别: warning / error
+	Message  string `json:"message"`
+	URL      string `json:"url,omitempty"` // 涉及的资源：被拦截或不安全的地址、请求地址、脚本位置
+	Count    int    `json:"count"`         // 完全相同的条目出现的次数
+}
+
+// enableIssueCapture 开启 Log 和 Audits 域；旧版浏览器不支持 Audits 时只记录 Log 条目
+func (s *Spider) enableIssueCapture(ctx context.Context) error {
+	if err := cdplog.Enable().Do(ctx); err != nil {
+		logging.Warnf("开启 Log 域失败: %v", err)
+	}
+	if err := audits.Enable().Do(ctx); err != nil {
+		logging.Warnf("开启 Audits 域失败，浏览器问题只包含日志条目: %v", err)
+	}
+	return nil
+}
+
+// recordLogEntry 记录 Log 域的警告和错误；verbose / info 级别只保留弃用提示
+func (s *Spider) recordLogEntry(entry *cdplog.Entry) {
+	if entry == nil {
+		return
+	}
+	if entry.Level != cdplog.LevelWarning && entry.Level != cdplog.LevelError && entry.Source != cdplog.SourceDeprecation {
+		return
+	}
+	s.recordIssue(BrowserIssue{
+		Category: logIssueCategory(entry.Source, entry.Text),
+		Source:   entry.Source.String(),
+		Level:    entry.Level.String(),
+		Message:  entry.Text,
+		URL:      entry.URL,
+	})
+}
+
+// recordAuditIssue 记录 Issues 面板中的条目，按类型取出涉及的地址和原因
+func (s *Spider) recordAuditIssue(issue *audits.InspectorIssue) {
+	if issue == nil {
+		return
+	}
+	rec := BrowserIssue{Category: IssueOther, Source: "audits", Code: issue.Code.String(), Message: issue.Code.String()}
+	if d := issue.Details; d != nil {
+		switch {
+		case d.ContentSecurityPolicyIssueDetails != nil:
+			c := d.ContentSecurityPolicyIssueDetails
+			rec.Category = IssueCSP
+			rec.Message = fmt.Sprintf("%s violates directive %q", c.ContentSecurityPolicyViolationType, c.ViolatedDirective)
+			if c.IsReportOnly {
+				rec.Message += " (report-only)"
+			}
+			rec.URL = c.BlockedURL
+			if rec.URL == "" && c.SourceCodeLocation != nil {
+				rec.URL = c.SourceCodeLocation.URL
+			}
+		case d.MixedContentIssueDetails != nil:
+			c := d.MixedContentIssueDetails
+			rec.Category = IssueMixedContent
+			rec.Message = fmt.Sprintf("%s loaded by %s: %s", c.ResourceType, c.MainResourceURL, c.ResolutionStatus)
+			rec.URL = c.InsecureURL
+		case d.CorsIssueDetails != nil:
+			c := d.CorsIssueDetails
+			rec.Category = IssueCORS
+			if c.CorsErrorStatus != nil {
+				rec.Message = c.CorsErrorStatus.CorsError.String()
+				if c.CorsErrorStatus.FailedParameter != "" {
+					rec.Message += " (" + c.CorsErrorStatus.FailedParameter + ")"
+				}
+			}
+			if c.Request != nil {
+				rec.URL = c.Request.URL
+			}
+		case d.CookieIssueDetails != nil:
+			c := d.CookieIssueDetails
+			rec.Category = IssueCookie
+			var reasons []string
+			for _, r := range c.CookieExclusionReasons {
+				reasons = append(reasons, r.String())
+			}
+			for _, r := range c.CookieWarningReasons {
+				reasons = append(reasons, r.String())
+			}
+			name := c.RawCookieLine
+			if c.Cookie != nil {
+				name = c.Cookie.Name
+			}
+			rec.Message = fmt.Sprintf("%s cookie %s: %s", c.Operation, name, strings.Join(reasons, ", "))
+			rec.URL = c.CookieURL
+		case d.BlockedByResponseIssueDetails != nil:
+			c := d.BlockedByResponseIssueDetails
+			rec.Category = IssueBlocked
+			rec.Message = c.Reason.String()
+			if c.Request != nil {
+				rec.URL = c.Request.URL
+			}
+		case d.DeprecationIssueDetails != nil:
+			c := d.DeprecationIssueDetails
+			rec.Category = IssueDeprecation
+			rec.Message = c.Type
+			if c.SourceCodeLocation != nil {
+				rec.URL = c.SourceCodeLocation.URL
+			}
+		case d.GenericIssueDetails != nil:
+			c := d.GenericIssueDetails
+			rec.Message = c.ErrorType.String()
+			if c.Request != nil {
+				rec.URL = c.Request.URL
+			}
+		}
+	}
+	if issue.Code == audits.InspectorIssueCodeCookieDeprecationMetadataIssue {
+		rec.Category = IssueCookie
+	}
+	s.recordIssue(rec)
+}
+
+// recordIssue 追加问题，完全相同的条目只累加次数
+func (s *Spider) recordIssue(issue BrowserIssue) {
+	key := strings.Join([]string{issue.Source, issue.Code, issue.Level, issue.Message, issue.URL}, "\x00")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i, ok := s.issueIndex[key]; ok {
+		s.result.BrowserIssues[i].Count++
+		return
+	}
+	if s.issueIndex == nil {
+		s.issueIndex = make(map[string]int)
+	}
+	issue.Count = 1
+	s.issueIndex[key] = len(s.result.BrowserIssues)
+	s.result.BrowserIssues = append(s.result.BrowserIssues, issue)
+}
+
+// logIssueCategory 按 Log 条目的来源和文本归类；Chrome 对同一问题常同时发出 Log 条目和 Audits 问题
+func logIssueCategory(source cdplog.Source, text string) string {
+	switch {
+	case source == cdplog.SourceDeprecation:
+		return IssueDeprecation
+	case strings.Contains(text, "Content Security Policy"):
+		return IssueCSP
+	case strings.Contains(text, "Mixed Content"):
+		return IssueMixedContent
+	case strings.Contains(text, "CORS policy"):
+		return IssueCORS
+	case strings.Contains(text, "cookie") || strings.Contains(text, "Cookie"):
+		return IssueCookie
+	case strings.Contains(text, "ERR_BLOCKED_BY_RESPONSE"):
+		return IssueBlocked
+	}
+	return IssueOther
+}
@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chromedp/cdproto/audits"
+	cdplog "github.com/chromedp/cdproto/log"
+)
+
+// replayIssueEvents 把 testdata/issues/events.jsonl 中从 Chrome 捕获的 Log.entryAdded / Audits.issueAdded 事件
+// 依次交给问题收集，与 listenTab 收到事件时相同
+func replayIssueEvents(t *testing.T, s *Spider) {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "issues", "events.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var msg struct {
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatal(err)
+		}
+		switch msg.Method {
+		case "Log.entryAdded":
+			var ev cdplog.EventEntryAdded
+			if err := json.Unmarshal(msg.Params, &ev); err != nil {
+				t.Fatal(err)
+			}
+			s.recordLogEntry(ev.Entry)
+		case "Audits.issueAdded":
+			var ev audits.EventIssueAdded
+			if err := json.Unmarshal(msg.Params, &ev); err != nil {
+				t.Fatal(err)
+			}
+			s.recordAuditIssue(ev.Issue)
+		default:
+			t.Fatalf("未知事件 %s", msg.Method)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 每类问题取出涉及的地址和原因，info 级别的日志丢弃、弃用提示保留，完全相同的条目只累加次数
+func TestRecordBrowserIssues(t *testing.T) {
+	s, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayIssueEvents(t, s)
+
+	want := []BrowserIssue{
+		{Category: IssueCSP, Source: "audits", Code: "ContentSecurityPolicyIssue",
+			Message: `kURLViolation violates directive "img-src"`, URL: "http://localhost:8080/img/header.svg", Count: 2},
+		{Category: IssueCSP, Source: "audits", Code: "ContentSecurityPolicyIssue",
+			Message: `kInlineViolation violates directive "script-src-elem"`, URL: "http://127.0.0.1:8080/csp.html", Count: 1},
+		{Category: IssueCSP, Source: "security", Level: "error",
+			Message: `Refused to load the image 'http://localhost:8080/img/header.svg' because it violates the following Content Security Policy directive: "default-src 'self'".`,
+			URL:     "http://127.0.0.1:8080/csp.html", Count: 1},
+		{Category: IssueDeprecation, Source: "deprecation", Level: "verbose",
+			Message: "Synchronous XMLHttpRequest on the main thread is deprecated because of its detrimental effects to the end user's experience.",
+			URL:     "https://shop.example.com/app.js", Count: 1},
+		{Category: IssueMixedContent, Source: "audits", Code: "MixedContentIssue",
+			Message: "Image loaded by https://shop.example.com/: MixedContentAutomaticallyUpgraded", URL: "http://cdn.example.net/a.png", Count: 1},
+		{Category: IssueCORS, Source: "audits", Code: "CorsIssue", Message: "MissingAllowOriginHeader", URL: "https://api.example.net/data", Count: 1},
+		{Category: IssueCORS, Source: "network", Level: "error",
+			Message: "Access to fetch at 'https://api.example.net/data' from origin 'https://shop.example.com' has been blocked by CORS policy: No 'Access-Control-Allow-Origin' header is present on the requested resource.",
+			URL:     "https://shop.example.com/", Count: 1},
+		{Category: IssueCookie, Source: "audits", Code: "CookieIssue",
+			Message: "SetCookie cookie sid: ExcludeSameSiteUnspecifiedTreatedAsLax, WarnSameSiteUnspecifiedCrossSiteContext", URL: "https://tracker.example/pixel", Count: 1},
+		{Category: IssueBlocked, Source: "audits", Code: "BlockedByResponseIssue", Message: "CorpNotSameOrigin", URL: "https://embed.example.org/frame", Count: 1},
+		{Category: IssueDeprecation, Source: "audits", Code: "DeprecationIssue", Message: "UnloadHandler", URL: "https://shop.example.com/app.js", Count: 1},
+		{Category: IssueOther, Source: "audits", Code: "GenericIssue", Message: "FormLabelForNameError", Count: 1},
+	}
+	got := s.Result().BrowserIssues
+	if len(got) != len(want) {
+		t.Fatalf("记录了 %d 条问题，应为 %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("BrowserIssues[%d] = %+v，应为 %+v", i, got[i], want[i])
+		}
+	}
+
+	// Reset 后重新计数
+	s.Reset()
+	replayIssueEvents(t, s)
+	if got := s.Result().BrowserIssues; len(got) != len(want) || got[0].Count != 2 {
+		t.Errorf("Reset 后记录了 %d 条，首条出现 %d 次", len(got), got[0].Count)
+	}
+}
+
+func TestLogIssueCategory(t *testing.T) {
+	for _, tc := range []struct {
+		source cdplog.Source
+		text   string
+		want   string
+	}{
+		{cdplog.SourceDeprecation, "Content Security Policy of this API is deprecated", IssueDeprecation},
+		{cdplog.SourceSecurity, "Refused to frame because it violates the following Content Security Policy directive", IssueCSP},
+		{cdplog.SourceSecurity, "Mixed Content: The page was loaded over HTTPS, but requested an insecure image", IssueMixedContent},
+		{cdplog.SourceNetwork, "has been blocked by CORS policy", IssueCORS},
+		{cdplog.SourceOther, "A cookie associated with a cross-site resource was set without SameSite", IssueCookie},
+		{cdplog.SourceNetwork, "Failed to load resource: net::ERR_BLOCKED_BY_RESPONSE.NotSameOrigin", IssueBlocked},
+		{cdplog.SourceJavascript, "Uncaught TypeError: x is not a function", IssueOther},
+	} {
+		if got := logIssueCategory(tc.source, tc.text); got != tc.want {
+			t.Errorf("logIssueCategory(%s, %q) = %s，应为 %s", tc.source, tc.text, got, tc.want)
+		}
+	}
+}
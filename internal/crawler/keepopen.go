@@ -0,0 +1,29 @@
+package crawler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// keepOpen 自动爬取结束后保持 Tab 打开（Config.KeepOpen），供手动在 DevTools 中检查。
+// 爬取阶段的监听随超时 context 结束，这里在 Tab 的 context 上重新挂载，交互期间加载的资源照常抓取；
+// KeepOpen 返回后停止监听并等待已开始的响应体获取完成
+func (s *Spider) keepOpen(tabCtx context.Context) {
+	// 爬取阶段的排空截止时间已过，之后的响应体获取只受 BodyFetchTimeout 约束
+	s.mu.Lock()
+	s.drainDeadline = time.Time{}
+	before := len(s.resources)
+	s.mu.Unlock()
+
+	lctx, lcancel := context.WithCancel(tabCtx)
+	s.listenTab(lctx)
+	s.config.KeepOpen(tabCtx)
+	lcancel()
+	s.wg.Wait()
+
+	s.mu.Lock()
+	added := len(s.resources) - before
+	s.mu.Unlock()
+	log.Printf("浏览器保持打开期间新抓取了 %d 个资源", added)
+}
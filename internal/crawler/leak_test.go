@@ -0,0 +1,101 @@
+package crawler_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/crawlertest"
+	"spider/internal/testsite"
+)
+
+// settledGoroutines 等待后台 goroutine（空闲连接的读写循环等）退出后返回当前数量，最多等 2 秒
+func settledGoroutines(limit int) int {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		n := runtime.NumGoroutine()
+		if n <= limit || time.Now().After(deadline) {
+			return n
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func heapAlloc() uint64 {
+	runtime.GC()
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+// 用完即弃的 Spider 调用 Close 后不留下 goroutine 和空闲连接，反复爬取时堆内存不随次数增长
+func TestSpiderCloseReleasesResources(t *testing.T) {
+	site := testsite.New()
+	defer site.Close()
+	harFile := crawlertest.RecordHAR(t, site.Resolve("/"), site.Resolve("/app.js"), site.Resolve("/style.css"))
+
+	crawlOnce := func() {
+		spider, err := crawler.New(crawlertest.ReplayConfig(harFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := spider.Run(context.Background(), site.Resolve("/"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Resources) != 3 {
+			t.Fatalf("回放得到 %d 个资源，应为 3", len(result.Resources))
+		}
+		// 备用 HTTP 客户端建立的连接在 Close 后应被关闭
+		if _, err := spider.DownloadResource(site.Resolve("/lazy.js"), "test"); err != nil {
+			t.Fatal(err)
+		}
+		spider.Close()
+	}
+
+	crawlOnce()
+	baseGoroutines := settledGoroutines(0)
+	baseHeap := heapAlloc()
+
+	for range 50 {
+		crawlOnce()
+	}
+
+	if n := settledGoroutines(baseGoroutines); n > baseGoroutines {
+		t.Errorf("50 次爬取后 goroutine 从 %d 增加到 %d", baseGoroutines, n)
+	}
+	const slack = 4 << 20
+	if heap := heapAlloc(); heap > baseHeap+slack {
+		t.Errorf("50 次爬取后堆内存从 %d 增长到 %d 字节", baseHeap, heap)
+	}
+}
+
+// Reset 之后 Spider 可以再次使用，且不保留上一次的资源
+func TestResetClearsState(t *testing.T) {
+	site := testsite.New()
+	defer site.Close()
+	harFile := crawlertest.RecordHAR(t, site.Resolve("/"), site.Resolve("/app.js"))
+
+	spider, err := crawler.New(crawlertest.ReplayConfig(harFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := spider.DownloadResource(site.Resolve("/lazy.js"), "test"); err != nil {
+		t.Fatal(err)
+	}
+	spider.Reset()
+	if n := len(spider.GetResources()); n != 0 {
+		t.Fatalf("Reset 后仍有 %d 个资源", n)
+	}
+
+	result, err := spider.Run(context.Background(), site.Resolve("/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Resources) != 2 {
+		t.Errorf("Reset 后再次爬取得到 %d 个资源，应为 2", len(result.Resources))
+	}
+}
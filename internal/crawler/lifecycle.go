@@ -0,0 +1,130 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+
+	"spider/internal/logging"
+)
+
+// Config.WaitUntil 的取值：导航后等待的页面生命周期事件（与 Puppeteer / Playwright 的 waitUntil 一致）
+const (
+	WaitCommit           = "commit"           // 主框架提交新文档（收到响应头），不等 HTML 解析
+	WaitDOMContentLoaded = "domcontentloaded" // HTML 解析完成
+	WaitLoad             = "load"             // load 事件，含图片、样式等子资源（默认）
+	WaitNetworkIdle0     = "networkidle0"     // 500ms 内没有进行中的请求
+	WaitNetworkIdle2     = "networkidle2"     // 500ms 内进行中的请求不超过 2 个
+)
+
+// lifecycleEventNames WaitUntil 对应的 Page.lifecycleEvent 名称；
+// commit 没有对应的生命周期事件，以主框架的 frameNavigated 为准
+var lifecycleEventNames = map[string]string{
+	WaitCommit:           "commit",
+	WaitDOMContentLoaded: "DOMContentLoaded",
+	WaitLoad:             "load",
+	WaitNetworkIdle0:     "networkIdle",
+	WaitNetworkIdle2:     "networkAlmostIdle",
+}
+
+// waitUntilFor 按 Page.lifecycleEvent 名称（Config.NavigationWaitEvent）查找对应的 WaitUntil 取值
+func waitUntilFor(event string) (string, bool) {
+	for waitUntil, name := range lifecycleEventNames {
+		if name == event {
+			return waitUntil, true
+		}
+	}
+	return "", false
+}
+
+// waitUntil 导航后实际等待的事件：NavigationWaitEvent 优先，其次 WaitUntil，都为空时为 load
+func (c *Config) waitUntil() string {
+	if waitUntil, ok := waitUntilFor(c.NavigationWaitEvent); ok {
+		return waitUntil
+	}
+	if c.WaitUntil != "" {
+		return c.WaitUntil
+	}
+	return WaitLoad
+}
+
+// navigate 导航到 targetURL 并等待主框架触发 WaitUntil 对应的生命周期事件（commit 等待文档提交）。
+// networkidle 在有长轮询的页面上可能一直不触发，最多等待 IdleTimeout 后告警继续。
+// 等待期间页面自己跳转（location.replace 等）时改为等待新文档；因跨源跳转停止抓取时立即返回。
+func (s *Spider) navigate(ctx context.Context, targetURL string) error {
+	waitUntil := s.config.waitUntil()
+	want := lifecycleEventNames[waitUntil]
+
+	// Navigate 返回 loaderId 之前事件可能已经到达，先缓冲再按 frame / loader 过滤；
+	// 生命周期事件和 frameNavigated 走同一个通道，保持到达顺序
+	events := make(chan any, 32)
+	lctx, lcancel := context.WithCancel(ctx)
+	defer lcancel()
+	chromedp.ListenTarget(lctx, func(ev any) {
+		switch e := ev.(type) {
+		case *page.EventLifecycleEvent:
+			if e.Name != want {
+				return
+			}
+		case *page.EventFrameNavigated:
+		default:
+			return
+		}
+		select {
+		case events <- ev:
+		default:
+		}
+	})
+
+	if err := page.SetLifecycleEventsEnabled(true).Do(ctx); err != nil {
+		return err
+	}
+	frameID, loaderID, errorText, _, err := page.Navigate(targetURL).Do(ctx)
+	switch {
+	case err != nil:
+		return err
+	case errorText != "":
+		return fmt.Errorf("page load error %s", errorText)
+	case loaderID == "":
+		// 同文档导航（仅 hash 变化）不产生新的 loader
+		return nil
+	}
+
+	var limit <-chan time.Time
+	if waitUntil == WaitNetworkIdle0 || waitUntil == WaitNetworkIdle2 {
+		limit = time.After(s.config.IdleTimeout)
+	}
+	for {
+		select {
+		case ev := <-events:
+			if waitUntil == WaitCommit && mainFrameCommitted(ev, frameID, loaderID) {
+				return nil
+			}
+			if next, ok := mainFrameNavigated(ev, frameID, loaderID); ok {
+				if s.navigatedAway() {
+					return nil
+				}
+				loaderID = next
+				continue
+			}
+			if ev, ok := ev.(*page.EventLifecycleEvent); ok && ev.FrameID == frameID && ev.LoaderID == loaderID {
+				return nil
+			}
+		case <-limit:
+			logging.Warnf("%v 内未等到 %s，继续处理: %s", s.config.IdleTimeout, waitUntil, targetURL)
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// mainFrameCommitted 判断事件是否为 frameID 主框架提交了 loaderID 的文档
+func mainFrameCommitted(ev any, frameID cdp.FrameID, loaderID cdp.LoaderID) bool {
+	nav, ok := ev.(*page.EventFrameNavigated)
+	return ok && nav.Frame != nil && nav.Frame.ID == frameID && nav.Frame.LoaderID == loaderID
+}
@@ -0,0 +1,66 @@
+package crawler
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// loadTracker 按请求 ID 记录 loadingFinished / loadingFailed。
+// responseReceived 只表示响应头到达，分块传输或较大的响应此时可能还没收完，
+// 立即 GetResponseBody 会取回截断的内容；获取响应体前先等待该请求加载结束。
+// 两个事件的先后不确定（响应体获取在单独的 goroutine 中），先到的一方创建通道。
+type loadTracker struct {
+	mu      sync.Mutex
+	pending map[network.RequestID]chan struct{}
+}
+
+func newLoadTracker() *loadTracker {
+	return &loadTracker{pending: make(map[network.RequestID]chan struct{})}
+}
+
+// channel 返回请求对应的通道，不存在时创建，调用方持有 t.mu
+func (t *loadTracker) channel(id network.RequestID) chan struct{} {
+	ch, ok := t.pending[id]
+	if !ok {
+		ch = make(chan struct{})
+		t.pending[id] = ch
+	}
+	return ch
+}
+
+// finish 标记请求加载结束（成功或失败），重复调用无副作用
+func (t *loadTracker) finish(id network.RequestID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ch := t.channel(id)
+	select {
+	case <-ch:
+	default:
+		close(ch)
+	}
+}
+
+// wait 阻塞到请求加载结束或 ctx 结束，返回 ctx 的错误；结束后释放该请求的记录
+func (t *loadTracker) wait(ctx context.Context, id network.RequestID) error {
+	t.mu.Lock()
+	ch := t.channel(id)
+	t.mu.Unlock()
+
+	select {
+	case <-ch:
+		t.mu.Lock()
+		delete(t.pending, id)
+		t.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isStreamingResponse 事件流等长连接响应不会触发 loadingFinished，直接取已收到的部分
+func isStreamingResponse(resp *network.Response) bool {
+	return strings.HasPrefix(strings.ToLower(resp.MimeType), "text/event-stream")
+}
@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// responseReceived 先到、loadingFinished 稍后才到：wait 应阻塞到 finish 之后才返回
+func TestLoadTrackerWaitsForLoadingFinished(t *testing.T) {
+	tr := newLoadTracker()
+	const id = network.RequestID("1")
+
+	finished := make(chan time.Time, 1)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		finished <- time.Now()
+		tr.finish(id)
+	}()
+
+	if err := tr.wait(context.Background(), id); err != nil {
+		t.Fatalf("wait 返回错误: %v", err)
+	}
+	returned := time.Now()
+	if at := <-finished; returned.Before(at) {
+		t.Error("wait 在 loadingFinished 之前就返回了")
+	}
+	if n := len(tr.pending); n != 0 {
+		t.Errorf("wait 结束后仍有 %d 条记录", n)
+	}
+}
+
+// loadingFinished 先于响应体获取到达时 wait 立即返回，重复 finish 无副作用
+func TestLoadTrackerFinishBeforeWait(t *testing.T) {
+	tr := newLoadTracker()
+	const id = network.RequestID("2")
+
+	tr.finish(id)
+	tr.finish(id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := tr.wait(ctx, id); err != nil {
+		t.Fatalf("wait 返回错误: %v", err)
+	}
+}
+
+// 一直没有 loadingFinished 时 wait 随 ctx 超时返回
+func TestLoadTrackerWaitTimeout(t *testing.T) {
+	tr := newLoadTracker()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := tr.wait(ctx, "3"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wait 返回 %v，应为 context.DeadlineExceeded", err)
+	}
+}
+
+func TestIsStreamingResponse(t *testing.T) {
+	cases := map[string]bool{
+		"text/event-stream":                true,
+		"Text/Event-Stream; charset=utf-8": true,
+		"text/plain":                       false,
+		"application/json":                 false,
+	}
+	for mime, want := range cases {
+		if got := isStreamingResponse(&network.Response{MimeType: mime}); got != want {
+			t.Errorf("isStreamingResponse(%q) = %v，应为 %v", mime, got, want)
+		}
+	}
+}
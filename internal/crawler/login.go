@@ -0,0 +1,124 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+
+	"spider/internal/logging"
+)
+
+// ErrRequiredSelectorMissing 页面中缺少 RequireSelector 指定的元素（通常意味着会话失效）。
+// 属于确定性失败，调用方不应重试。
+var ErrRequiredSelectorMissing = errors.New("页面中未找到必需的元素")
+
+// DefaultLoginURLPatterns 登录页 URL 路径特征
+var DefaultLoginURLPatterns = []string{"/login", "/signin", "/sign-in", "/auth"}
+
+// DefaultLoginTitleKeywords 登录页标题关键字（不区分大小写）
+var DefaultLoginTitleKeywords = []string{"login", "log in", "sign in", "登录"}
+
+// pageState 加载完成后从渲染页面读取的状态
+type pageState struct {
+	URL         string   `json:"url"`
+	Title       string   `json:"title"`
+	BaseURL     string   `json:"baseURL"`
+	HasPassword bool     `json:"hasPassword"`
+	HasRequired bool     `json:"hasRequired"`
+	Links       []string `json:"links"`
+}
+
+// inspectPage 读取最终 URL、标题、页面链接等状态，检测登录墙并校验 RequireSelector
+func (s *Spider) inspectPage(ctx context.Context, targetURL string) error {
+	s.mu.Lock()
+	tooLarge := s.domTooLarge
+	s.mu.Unlock()
+
+	// DOM 过大时不收集链接：数十万个 <a> 的数组序列化会拖垮 CDP 连接
+	js := fmt.Sprintf(`(function(){
+		var sel = %q, has = true, withLinks = %t;
+		if (sel) { try { has = !!document.querySelector(sel); } catch(e) { has = false; } }
+		return {
+			url: location.href,
+			title: document.title || "",
+			baseURL: document.baseURI || "",
+			hasPassword: !!document.querySelector('input[type="password"]'),
+			hasRequired: has,
+			links: withLinks ? Array.from(document.querySelectorAll('a[href]'), function(a){ return a.href; }) : []
+		};
+	})()`, s.config.RequireSelector, !tooLarge)
+
+	var state pageState
+	if err := chromedp.Run(ctx, chromedp.Evaluate(js, &state)); err != nil {
+		return fmt.Errorf("读取页面状态失败: %w", err)
+	}
+
+	var rendered string
+	if s.config.CaptureRenderedHTML && !tooLarge {
+		if err := chromedp.Run(ctx, chromedp.OuterHTML("html", &rendered, chromedp.ByQuery)); err != nil {
+			logging.Warnf("获取渲染后 DOM 失败: %v", err)
+		}
+	}
+
+	s.mu.Lock()
+	s.result.FinalURL = state.URL
+	s.result.RenderedHTML = rendered
+	s.result.Title = state.Title
+	s.result.BaseURL = state.BaseURL
+	if state.BaseURL != "" && state.BaseURL != state.URL {
+		if doc, ok := s.resources[s.resourceKey(s.result.DocumentURL)]; ok {
+			doc.BaseURL = state.BaseURL
+		}
+	}
+	s.result.Links = state.Links
+	if signals := s.loginSignals(targetURL, state); len(signals) > 0 {
+		s.result.LikelyLoginPage = true
+		s.result.LoginSignal = strings.Join(signals, "; ")
+	}
+	s.mu.Unlock()
+
+	if s.config.RequireSelector != "" && !state.HasRequired {
+		return fmt.Errorf("%w: %s", ErrRequiredSelectorMissing, s.config.RequireSelector)
+	}
+	return nil
+}
+
+// loginSignals 返回命中的登录页特征
+func (s *Spider) loginSignals(targetURL string, state pageState) []string {
+	var signals []string
+
+	// 主文档被重定向到登录类 URL（直接爬登录页本身不算）
+	if state.URL != "" && state.URL != targetURL {
+		patterns := s.config.LoginURLPatterns
+		if len(patterns) == 0 {
+			patterns = DefaultLoginURLPatterns
+		}
+		if u, err := url.Parse(state.URL); err == nil {
+			path := strings.ToLower(u.Path)
+			for _, p := range patterns {
+				if strings.Contains(path, strings.ToLower(p)) {
+					signals = append(signals, "redirected to "+u.Path)
+					break
+				}
+			}
+		}
+	}
+
+	if state.HasPassword {
+		signals = append(signals, "password input")
+	}
+
+	title := strings.ToLower(state.Title)
+	for _, kw := range DefaultLoginTitleKeywords {
+		if strings.Contains(title, kw) {
+			signals = append(signals, fmt.Sprintf("title contains %q", kw))
+			break
+		}
+	}
+
+	return signals
+}
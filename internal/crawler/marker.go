@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/chromedp/chromedp"
+
+	"spider/internal/logging"
+)
+
+// ClickMarker 页面加载完成后依次执行的点击：点击前设置标记 Label，之后发出的请求都归到该标记下
+type ClickMarker struct {
+	Label    string // 标记名，如 mark1
+	Selector string // 要点击的元素（CSS 选择器）
+}
+
+// Mark 设置当前标记：此后发出的请求对应的资源 Resource.Marker 为 label，直到下一次 Mark。
+// 从其它 goroutine 调用时，与调用同时发出的请求可能归到前一个标记；ClickMarkers 会先排空事件再标记。
+func (s *Spider) Mark(label string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marker = label
+	if !slices.Contains(s.markers, label) {
+		s.markers = append(s.markers, label)
+	}
+	log.Printf("标记: %s", label)
+}
+
+// Markers 返回按设置顺序排列的标记
+func (s *Spider) Markers() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.markers)
+}
+
+// ResourcesAfter 返回标记 label 及其之后的标记下发出的资源（不含标记前的初始加载）
+func (s *Spider) ResourcesAfter(label string) (map[string]*Resource, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx := slices.Index(s.markers, label)
+	if idx < 0 {
+		return nil, fmt.Errorf("未设置过标记 %q（已有: %v）", label, s.markers)
+	}
+	after := s.markers[idx:]
+	result := make(map[string]*Resource)
+	for key, res := range s.resources {
+		if slices.Contains(after, res.Marker) {
+			result[key] = res
+		}
+	}
+	return result, nil
+}
+
+// runClickMarkers 依次执行 ClickMarkers：排空已到达的网络事件、标记、点击，再等待网络空闲。
+// 点击失败（元素不存在等）只记录警告，后续标记照常执行。
+func (s *Spider) runClickMarkers(ctx context.Context) {
+	for _, m := range s.config.ClickMarkers {
+		// 一次往返命令：chromedp 按到达顺序分发事件，命令返回时点击前的请求事件都已记录
+		if err := chromedp.Run(ctx, chromedp.Evaluate(`0`, nil)); err != nil {
+			logging.Warnf("标记 %s 前同步事件失败: %v", m.Label, err)
+			return
+		}
+		s.Mark(m.Label)
+
+		if err := chromedp.Run(ctx, chromedp.Click(m.Selector, chromedp.ByQuery, chromedp.NodeVisible)); err != nil {
+			logging.Warnf("标记 %s 点击 %s 失败: %v", m.Label, m.Selector, err)
+			continue
+		}
+		s.waitForIdle()
+	}
+}
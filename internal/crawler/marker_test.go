@@ -0,0 +1,121 @@
+package crawler
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func okBody(context.Context, network.RequestID) ([]byte, error) { return []byte("ok"), nil }
+
+// 资源的标记取请求发出时（requestWillBeSent）生效的标记，而不是响应到达时的：
+// 标记前发出、标记后才返回的请求仍属于初始加载
+func TestMarkLabelsByRequestTime(t *testing.T) {
+	s := newBodySpider(t, DefaultConfig().BodyFetchTimeout, okBody)
+	ctx := context.Background()
+	send := func(id network.RequestID, url string) {
+		s.recordRequest(&network.EventRequestWillBeSent{RequestID: id, Request: &network.Request{URL: url, Method: "GET"}})
+	}
+
+	send("1", "https://example.com/app.js")
+	receive(ctx, s, "1", "https://example.com/app.js")
+	send("2", "https://example.com/slow.js") // 点击前发出，点击后才返回
+
+	s.Mark("mark1")
+	receive(ctx, s, "2", "https://example.com/slow.js")
+	send("3", "https://example.com/api/report")
+	receive(ctx, s, "3", "https://example.com/api/report")
+
+	s.Mark("mark2")
+	send("4", "https://example.com/api/detail")
+	receive(ctx, s, "4", "https://example.com/api/detail")
+	// 没有 requestWillBeSent 记录的响应取响应到达时的标记
+	receive(ctx, s, "5", "https://example.com/pushed.js")
+
+	want := map[string]string{
+		"https://example.com/app.js":     "",
+		"https://example.com/slow.js":    "",
+		"https://example.com/api/report": "mark1",
+		"https://example.com/api/detail": "mark2",
+		"https://example.com/pushed.js":  "mark2",
+	}
+	for url, marker := range want {
+		res := s.resources[s.resourceKey(url)]
+		if res == nil {
+			t.Fatalf("缺少 %s", url)
+		}
+		if res.Marker != marker {
+			t.Errorf("%s 的标记为 %q，应为 %q", url, res.Marker, marker)
+		}
+	}
+
+	s.Mark("mark1") // 重复设置不改变顺序
+	if got := s.Markers(); !slices.Equal(got, []string{"mark1", "mark2"}) {
+		t.Errorf("Markers() = %v", got)
+	}
+}
+
+// ResourcesAfter 返回该标记及之后标记下的资源，不含初始加载；未设置过的标记报错
+func TestResourcesAfter(t *testing.T) {
+	s := newBodySpider(t, DefaultConfig().BodyFetchTimeout, okBody)
+	ctx := context.Background()
+	receive(ctx, s, "1", "https://example.com/")
+	s.Mark("mark1")
+	receive(ctx, s, "2", "https://example.com/a")
+	s.Mark("mark2")
+	receive(ctx, s, "3", "https://example.com/b")
+
+	urls := func(label string) []string {
+		after, err := s.ResourcesAfter(label)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result []string
+		for _, res := range after {
+			result = append(result, res.URL)
+		}
+		slices.Sort(result)
+		return result
+	}
+	if got := urls("mark1"); !slices.Equal(got, []string{"https://example.com/a", "https://example.com/b"}) {
+		t.Errorf("mark1 之后的资源为 %v", got)
+	}
+	if got := urls("mark2"); !slices.Equal(got, []string{"https://example.com/b"}) {
+		t.Errorf("mark2 之后的资源为 %v", got)
+	}
+	if _, err := s.ResourcesAfter("mark3"); err == nil || !strings.Contains(err.Error(), "mark3") {
+		t.Errorf("未设置的标记应报错，实际 %v", err)
+	}
+	if got := slices.Collect(maps.Keys(s.GetResources())); len(got) != 3 {
+		t.Errorf("ResourcesAfter 不应修改完整的资源表，剩余 %d 个", len(got))
+	}
+}
+
+func TestValidateClickMarkers(t *testing.T) {
+	for name, tc := range map[string]struct {
+		markers   []ClickMarker
+		onlyAfter string
+		wantErr   string
+	}{
+		"ok":             {markers: []ClickMarker{{"mark1", "#a"}, {"mark2", "#b"}}, onlyAfter: "mark2"},
+		"空选择器":           {markers: []ClickMarker{{"mark1", ""}}, wantErr: "不能为空"},
+		"重复标记":           {markers: []ClickMarker{{"mark1", "#a"}, {"mark1", "#b"}}, wantErr: "重复"},
+		"only-after 未知":  {markers: []ClickMarker{{"mark1", "#a"}}, onlyAfter: "mark2", wantErr: "不在 ClickMarkers 中"},
+		"only-after 无标记": {onlyAfter: "mark1", wantErr: "不在 ClickMarkers 中"},
+	} {
+		config := DefaultConfig()
+		config.ClickMarkers = tc.markers
+		config.OnlyAfterMarker = tc.onlyAfter
+		err := config.Validate()
+		if tc.wantErr == "" && err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("%s: 错误为 %v，应包含 %q", name, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+
+	"spider/internal/logging"
+)
+
+// trackDocumentRequest 处理主框架的文档请求：首个 loader（含服务端重定向的各跳）为初始导航，
+// 之后出现的新 loader 即页面自己发起的导航（location.replace、meta refresh 等）。
+// FollowClientRedirects 关闭时，第一次跨源的客户端导航会停止抓取，返回 true 表示该请求不再记录。
+func (s *Spider) trackDocumentRequest(ctx context.Context, ev *network.EventRequestWillBeSent) bool {
+	if ev.Type != network.ResourceTypeDocument || frameContext(ctx, ev.FrameID) != "page" || ev.Request == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.result.NavigatedAway != "" {
+		return true
+	}
+	if s.initialLoader == "" || ev.LoaderID == s.initialLoader {
+		s.initialLoader = ev.LoaderID
+		s.navOrigin = urlOrigin(ev.Request.URL)
+		return false
+	}
+	if s.config.FollowClientRedirects || urlOrigin(ev.Request.URL) == s.navOrigin {
+		return false
+	}
+
+	s.result.NavigatedAway = ev.Request.URL
+	logging.Warnf("页面跨源跳转到 %s，已停止抓取（-follow-client-redirects 可继续跟随）", ev.Request.URL)
+	return true
+}
+
+// recordNavigation 记录主框架提交的文档 URL，得到 输入 URL → 最终 URL 的导航链
+func (s *Spider) recordNavigation(frame *cdp.Frame) {
+	if frame == nil || frame.ParentID != "" || !strings.HasPrefix(frame.URL, "http") {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n := len(s.result.Navigations); n > 0 && s.result.Navigations[n-1] == frame.URL {
+		return
+	}
+	s.result.Navigations = append(s.result.Navigations, frame.URL)
+	if len(s.result.Navigations) > 1 {
+		log.Printf("主框架导航: %s", frame.URL)
+	}
+}
+
+// navigatedAway 抓取是否已因跨源的客户端导航而停止
+func (s *Spider) navigatedAway() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.result.NavigatedAway != ""
+}
+
+// mainFrameNavigated 判断事件是否为 frameID 主框架提交了 loaderID 以外的新文档
+func mainFrameNavigated(ev any, frameID cdp.FrameID, loaderID cdp.LoaderID) (cdp.LoaderID, bool) {
+	nav, ok := ev.(*page.EventFrameNavigated)
+	if !ok || nav.Frame == nil || nav.Frame.ID != frameID || nav.Frame.LoaderID == loaderID {
+		return "", false
+	}
+	return nav.Frame.LoaderID, true
+}
+
+// urlOrigin 返回 URL 的源（scheme://host[:port]），无法解析时返回原串
+func urlOrigin(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
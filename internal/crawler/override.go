@@ -0,0 +1,58 @@
+package crawler
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"spider/internal/logging"
+)
+
+// localOverride 一条编译后的本地覆盖规则
+type localOverride struct {
+	pattern string
+	re      *regexp.Regexp
+	path    string
+}
+
+// compileOverrides 编译 URL 模式：* 匹配任意字符，其余字符按字面匹配整个 URL。
+// 模式越长越具体，优先匹配。
+func compileOverrides(overrides map[string]string) []localOverride {
+	var list []localOverride
+	for pattern, path := range overrides {
+		parts := strings.Split(pattern, "*")
+		for i, p := range parts {
+			parts[i] = regexp.QuoteMeta(p)
+		}
+		list = append(list, localOverride{
+			pattern: pattern,
+			re:      regexp.MustCompile("^" + strings.Join(parts, ".*") + "$"),
+			path:    path,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if len(list[i].pattern) != len(list[j].pattern) {
+			return len(list[i].pattern) > len(list[j].pattern)
+		}
+		return list[i].pattern < list[j].pattern
+	})
+	return list
+}
+
+// readLocalOverride 若 URL 命中覆盖规则，读取对应本地文件。
+// 读取失败时记录警告并回退到正常抓取。
+func (s *Spider) readLocalOverride(rawURL string) ([]byte, bool) {
+	for _, o := range s.overrides {
+		if !o.re.MatchString(rawURL) {
+			continue
+		}
+		body, err := os.ReadFile(o.path)
+		if err != nil {
+			logging.Warnf("读取本地覆盖文件失败 %s（规则 %s）: %v", o.path, o.pattern, err)
+			return nil, false
+		}
+		return body, true
+	}
+	return nil, false
+}
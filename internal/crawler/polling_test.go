@@ -0,0 +1,70 @@
+package crawler_test
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/crawlertest"
+)
+
+// 合成的轮询接口：同一地址只有时间戳参数不同的 N 个响应，开启 CollapsePolling 后只保存首个响应，
+// resources.json 记录折叠数和最后一次收到的时间
+func TestCollapsePollingSyntheticPoller(t *testing.T) {
+	const (
+		base  = "https://app.example.com"
+		polls = 25
+	)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	resources := map[string]*crawler.Resource{
+		base + "/": {URL: base + "/", StatusCode: 200, MimeType: "text/html", Content: []byte("<html></html>"),
+			Headers: map[string]string{}, ResponseTime: start},
+	}
+	for i := range polls {
+		u := fmt.Sprintf("%s/api/poll?ts=%04d", base, i)
+		resources[u] = &crawler.Resource{URL: u, StatusCode: 200, MimeType: "application/json",
+			Content: fmt.Appendf(nil, `{"tick":%d}`, i), Headers: map[string]string{},
+			ResponseTime: start.Add(time.Duration(i) * time.Second)}
+	}
+
+	config := crawlertest.ReplayConfig(crawlertest.WriteHAR(t, resources))
+	config.CollapsePolling = true
+	res := crawlertest.Run(t, base+"/", config)
+
+	var polled []string
+	for _, e := range res.Index {
+		if e.Path != "" && filepath.Base(filepath.Dir(e.Path)) == "api" {
+			polled = append(polled, e.URL)
+		}
+	}
+	if len(polled) != 1 {
+		t.Fatalf("轮询接口保存了 %d 条记录 %v，应只有 1 条", len(polled), polled)
+	}
+	e, _ := res.Entry(polled[0])
+	if e.CollapsedCount != polls-1 {
+		t.Errorf("collapsed_count = %d，应为 %d", e.CollapsedCount, polls-1)
+	}
+	if want := start.Add((polls - 1) * time.Second); e.LastSeen == nil || !e.LastSeen.Equal(want) {
+		t.Errorf("last_seen = %v，应为 %v", e.LastSeen, want)
+	}
+
+	var files int
+	filepath.WalkDir(filepath.Join(res.Dir, filepath.Dir(filepath.FromSlash(e.Path))), func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files++
+		}
+		return nil
+	})
+	if files != 1 {
+		t.Errorf("磁盘上轮询接口的目录中有 %d 个文件，应只有 1 个", files)
+	}
+
+	// 未开启时每个响应都单独保存
+	res = crawlertest.Run(t, base+"/", crawlertest.ReplayConfig(config.HARFile))
+	if got := len(res.Index); got != polls+1 {
+		t.Errorf("未开启 CollapsePolling 时有 %d 条记录，应为 %d", got, polls+1)
+	}
+}
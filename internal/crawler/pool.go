@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/chromedp/chromedp"
+
+	"spider/internal/logging"
 )
 
 // Pool 管理固定数量的 Chrome 浏览器进程供批量爬取复用。
@@ -14,6 +16,7 @@ import (
 // Tab 关闭但进程保留，彻底消除每 URL 冷启动开销，也限制了系统进程总量。
 type Pool struct {
 	available chan context.Context  // 可用的 Chrome allocCtx（进程级别）
+	allocs    []context.Context     // 全部进程，按启动顺序（Slot 共享使用）
 	cancels   []context.CancelFunc // 对应的关闭函数
 	config    *Config
 }
@@ -31,7 +34,7 @@ func NewPool(config *Config) (*Pool, error) {
 	estimated := size * chromeProcPerInstance
 	log.Printf("浏览器池: 预启动 %d 个 Chrome 实例，预计占用约 %d+ 个系统进程", size, estimated)
 	if estimated > 60 {
-		log.Printf("警告: 系统进程占用较高，请确认进程上限 (ulimit -u 或 /proc/sys/kernel/pid_max)")
+		logging.Warnf("系统进程占用较高，请确认进程上限 (ulimit -u 或 /proc/sys/kernel/pid_max)")
 	}
 
 	p := &Pool{
@@ -47,6 +50,7 @@ func NewPool(config *Config) (*Pool, error) {
 			return nil, fmt.Errorf("浏览器槽 %d/%d 启动失败: %w", i+1, size, err)
 		}
 		p.cancels = append(p.cancels, cancel)
+		p.allocs = append(p.allocs, allocCtx)
 		p.available <- allocCtx
 	}
 
@@ -56,7 +60,10 @@ func NewPool(config *Config) (*Pool, error) {
 
 // launchBrowser 启动单个 Chrome 进程并预热（开临时 Tab 验证可用后关闭 Tab）
 func (p *Pool) launchBrowser(idx, total int) (context.Context, context.CancelFunc, error) {
-	opts := buildAllocatorOptions(p.config)
+	opts, err := buildAllocatorOptions(p.config)
+	if err != nil {
+		return nil, nil, err
+	}
 	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
 
 	// 预热：开临时 Tab 触发 Chrome 进程真正启动，完成后关闭 Tab 保留进程
@@ -71,6 +78,9 @@ func (p *Pool) launchBrowser(idx, total int) (context.Context, context.CancelFun
 		return nil, nil, fmt.Errorf("预热失败: %w", err)
 	}
 	warmCancel() // 关闭预热 Tab，Chrome 进程继续存活
+	if p.config.ChromeStabilizationDelay > 0 {
+		time.Sleep(p.config.ChromeStabilizationDelay)
+	}
 
 	log.Printf("浏览器 %d/%d 已就绪 (启动耗时 %.1fs)", idx, total, time.Since(start).Seconds())
 	return allocCtx, allocCancel, nil
@@ -86,10 +96,22 @@ func (p *Pool) Release(allocCtx context.Context) {
 	p.available <- allocCtx
 }
 
+// Size 池中的浏览器进程数
+func (p *Pool) Size() int {
+	return len(p.allocs)
+}
+
+// Slot 返回第 i 个浏览器进程（按 Size 取模），不经过 Acquire / Release，
+// 供多个 Tab 共用同一进程（递归爬取的 RecursionWorkers）
+func (p *Pool) Slot(i int) context.Context {
+	return p.allocs[i%len(p.allocs)]
+}
+
 // Close 关闭所有 Chrome 进程（必须在所有爬取任务完成后调用）
 func (p *Pool) Close() {
 	for _, cancel := range p.cancels {
 		cancel()
 	}
 	p.cancels = nil
+	p.allocs = nil
 }
@@ -0,0 +1,116 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/chromedp/chromedp"
+
+	"spider/internal/logging"
+)
+
+// resourceHintsScript 列出页面中 prefetch / preload / modulepreload 提示的 http(s) 地址（去重），
+// 同一地址有多个提示时取第一个；一个 <link> 的 rel 含多个值时按 preload、modulepreload、prefetch 取其一
+const resourceHintsScript = `(function(){
+	var links = document.querySelectorAll('link[rel~="prefetch" i], link[rel~="preload" i], link[rel~="modulepreload" i]');
+	var seen = {}, hints = [];
+	for (var i = 0; i < links.length; i++) {
+		var href = links[i].href;
+		if (!href || !/^https?:/.test(href) || seen[href]) {
+			continue;
+		}
+		seen[href] = true;
+		var rels = links[i].relList, rel = "prefetch";
+		if (rels.contains("preload")) {
+			rel = "preload";
+		} else if (rels.contains("modulepreload")) {
+			rel = "modulepreload";
+		}
+		hints.push({url: href, rel: rel});
+	}
+	return hints;
+})()`
+
+// resourceHint 页面中的一个资源提示
+type resourceHint struct {
+	URL string `json:"url"`
+	Rel string `json:"rel"`
+}
+
+// loadResourceHints 主动请求 <link rel="prefetch|preload"> 提示的资源（CapturePrefetchAndPreload）。
+// 浏览器可能推迟或忽略这些提示，拆分出的路由 chunk 只有用户进入对应页面才会加载；
+// 在页面中 fetch() 一次即可由网络监听照常抓取。已抓到的地址不再请求。
+// 提示列表保留到空闲等待之后，由 tagResourceHints 标记和补齐
+func (s *Spider) loadResourceHints(ctx context.Context) {
+	var hints []resourceHint
+	if err := chromedp.Run(ctx, chromedp.Evaluate(resourceHintsScript, &hints)); err != nil {
+		logging.Warnf("读取 prefetch / preload 提示失败: %v", err)
+		return
+	}
+
+	var pending []string
+	s.mu.Lock()
+	s.hints = hints
+	for _, h := range hints {
+		if _, exists := s.resources[s.resourceKey(h.URL)]; !exists {
+			pending = append(pending, h.URL)
+		}
+	}
+	s.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	urls, err := json.Marshal(pending)
+	if err != nil {
+		return
+	}
+	// 带上 Cookie；失败（跨域 CORS 等）不影响抓取，响应到达即被记录
+	js := fmt.Sprintf(`%s.forEach(function(u){ fetch(u, {credentials: "include"}).catch(function(){}); })`, urls)
+	if err := chromedp.Run(ctx, chromedp.Evaluate(js, nil)); err != nil {
+		logging.Warnf("请求 prefetch / preload 资源失败: %v", err)
+		return
+	}
+	log.Printf("已请求 %d 个 prefetch / preload 提示的资源（共 %d 个提示）", len(pending), len(hints))
+}
+
+// tagResourceHints 空闲等待之后，在提示的资源上记录提示类型（Resource.ResourceHint）；
+// 页面内 fetch 后仍未抓到的（如被 CORS 或 Service Worker 拦下）用备用 HTTP 客户端直接下载补齐，
+// DisableFallbackDownload 时只记录日志
+func (s *Spider) tagResourceHints() {
+	s.mu.Lock()
+	hints := s.hints
+	var missing []resourceHint
+	for _, h := range hints {
+		if res, ok := s.resources[s.resourceKey(h.URL)]; ok {
+			res.ResourceHint = h.Rel
+		} else {
+			missing = append(missing, h)
+		}
+	}
+	s.mu.Unlock()
+	if len(missing) == 0 {
+		return
+	}
+	if s.config.DisableFallbackDownload {
+		logging.Warnf("%d 个 prefetch / preload 提示的资源未抓到（已禁止直接下载）", len(missing))
+		return
+	}
+
+	downloaded := 0
+	for _, h := range missing {
+		resource, err := s.directResource(h.URL, "ResourceHintDownload")
+		if err != nil {
+			logging.Warnf("直接下载 %s 提示的资源失败: %s - %v", h.Rel, h.URL, err)
+			continue
+		}
+		resource.ResourceHint = h.Rel
+		if !s.addResource(resource) {
+			continue
+		}
+		downloaded++
+	}
+	log.Printf("已直接下载 %d 个页面内未能抓到的 prefetch / preload 资源（共 %d 个）", downloaded, len(missing))
+}
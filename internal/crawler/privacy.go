@@ -0,0 +1,192 @@
+package crawler
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/domstorage"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+
+	"spider/internal/logging"
+)
+
+// PrivacyReport 页面加载后的 Cookie 和跨域 iframe 存储分析（CapturePrivacy），写入 privacy.json
+type PrivacyReport struct {
+	Site    string       `json:"site"` // 目标页面的可注册域名，据此区分第一方 / 第三方
+	Cookies []CookieInfo `json:"cookies"`
+
+	ThirdPartyStorage []FrameStorage `json:"third_party_storage,omitempty"` // 跨站 iframe 写入的 localStorage
+}
+
+// CookieInfo 单个 Cookie 的属性（不含值）
+type CookieInfo struct {
+	Name       string  `json:"name"`
+	Domain     string  `json:"domain"`
+	Path       string  `json:"path"`
+	ThirdParty bool    `json:"third_party"`
+	Secure     bool    `json:"secure"`
+	HTTPOnly   bool    `json:"http_only"`
+	SameSite   string  `json:"same_site,omitempty"` // Strict / Lax / None，未设置时为空
+	Session    bool    `json:"session"`
+	ExpiresIn  float64 `json:"expires_in_days,omitempty"` // 距抓取时的有效期（天），会话 Cookie 为 0
+}
+
+// FrameStorage 一个跨站 iframe 源写入的 localStorage 键
+type FrameStorage struct {
+	Origin   string   `json:"origin"`
+	FrameURL string   `json:"frame_url"`
+	Keys     []string `json:"keys"`
+}
+
+// capturePrivacy 读取本页请求过的全部 URL 可见的 Cookie，并列出跨站 iframe 的 localStorage 键。
+// 只读分析，不影响抓取；任何一步失败只记录警告。
+func (s *Spider) capturePrivacy(ctx context.Context, targetURL string) {
+	s.mu.Lock()
+	siteURL := targetURL
+	if s.pageURL != "" {
+		siteURL = s.pageURL
+	}
+	urls := make([]string, 0, len(s.resources)+1)
+	urls = append(urls, siteURL)
+	for _, res := range s.resources {
+		if strings.HasPrefix(res.URL, "http") {
+			urls = append(urls, res.URL)
+		}
+	}
+	s.mu.Unlock()
+
+	var cookies []*network.Cookie
+	var tree *page.FrameTree
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		// 按 URL 取而不是 storage.GetCookies：批量模式的浏览器进程复用，避免混入之前页面的 Cookie
+		if cookies, err = network.GetCookies().WithURLs(urls).Do(ctx); err != nil {
+			return err
+		}
+		tree, err = page.GetFrameTree().Do(ctx)
+		return err
+	}))
+	if err != nil {
+		logging.Warnf("读取 Cookie 失败: %v", err)
+		return
+	}
+
+	report := cookieReport(OriginKey(siteURL), cookies, time.Now())
+	report.ThirdPartyStorage = s.thirdPartyStorage(ctx, tree, report.Site)
+
+	s.mu.Lock()
+	s.result.Privacy = report
+	s.mu.Unlock()
+
+	thirdParty := 0
+	for _, c := range report.Cookies {
+		if c.ThirdParty {
+			thirdParty++
+		}
+	}
+	log.Printf("隐私分析: %d 个 Cookie（第三方 %d 个），%d 个跨站 iframe 写入了 localStorage",
+		len(report.Cookies), thirdParty, len(report.ThirdPartyStorage))
+}
+
+// cookieReport 按可注册域名把 cookies 分为第一方 / 第三方，记录属性和距 now 的有效期，按域名、名称排序
+func cookieReport(site string, cookies []*network.Cookie, now time.Time) *PrivacyReport {
+	report := &PrivacyReport{Site: site, Cookies: []CookieInfo{}}
+	for _, c := range cookies {
+		info := CookieInfo{
+			Name:       c.Name,
+			Domain:     c.Domain,
+			Path:       c.Path,
+			ThirdParty: cookieSite(c.Domain) != report.Site,
+			Secure:     c.Secure,
+			HTTPOnly:   c.HTTPOnly,
+			SameSite:   c.SameSite.String(),
+			Session:    c.Session,
+		}
+		if !c.Session && c.Expires > 0 {
+			days := time.Unix(int64(c.Expires), 0).Sub(now).Hours() / 24
+			info.ExpiresIn = math.Round(days*10) / 10
+		}
+		report.Cookies = append(report.Cookies, info)
+	}
+	sort.Slice(report.Cookies, func(i, j int) bool {
+		a, b := report.Cookies[i], report.Cookies[j]
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		return a.Name < b.Name
+	})
+	return report
+}
+
+// thirdPartyStorage 遍历 frame 树，读取与目标站点不同站的 iframe 的 localStorage 键。
+// 存储分区后第三方 iframe 的 localStorage 按 storage key（源 + 顶层站点）隔离，因此按 frame 取 key。
+func (s *Spider) thirdPartyStorage(ctx context.Context, tree *page.FrameTree, site string) []FrameStorage {
+	if tree == nil {
+		return nil
+	}
+	var frames []*page.FrameTree
+	var walk func(t *page.FrameTree)
+	walk = func(t *page.FrameTree) {
+		for _, child := range t.ChildFrames {
+			frames = append(frames, child)
+			walk(child)
+		}
+	}
+	walk(tree)
+
+	var result []FrameStorage
+	seen := make(map[string]bool)
+	for _, f := range frames {
+		frame := f.Frame
+		if frame == nil || !strings.HasPrefix(frame.SecurityOrigin, "http") || OriginKey(frame.URL) == site || seen[frame.SecurityOrigin] {
+			continue
+		}
+		seen[frame.SecurityOrigin] = true
+
+		var items []domstorage.Item
+		err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			if err := domstorage.Enable().Do(ctx); err != nil {
+				return err
+			}
+			key, err := storage.GetStorageKeyForFrame(frame.ID).Do(ctx)
+			if err != nil {
+				return err
+			}
+			items, err = domstorage.GetDOMStorageItems(&domstorage.StorageID{
+				StorageKey:     domstorage.SerializedStorageKey(key),
+				IsLocalStorage: true,
+			}).Do(ctx)
+			return err
+		}))
+		if err != nil {
+			// 跨进程 iframe 的存储不在主 target 中，只记录不中断
+			logging.Warnf("读取 iframe %s 的 localStorage 失败: %v", frame.SecurityOrigin, err)
+			continue
+		}
+		if len(items) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(items))
+		for _, item := range items {
+			if len(item) > 0 {
+				keys = append(keys, item[0])
+			}
+		}
+		sort.Strings(keys)
+		result = append(result, FrameStorage{Origin: frame.SecurityOrigin, FrameURL: frame.URL, Keys: keys})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Origin < result[j].Origin })
+	return result
+}
+
+// cookieSite 返回 Cookie 域（可能以 . 开头）的可注册域名
+func cookieSite(domain string) string {
+	return siteOfHost(strings.TrimPrefix(domain, "."))
+}
@@ -0,0 +1,65 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// 按可注册域名区分第一方 / 第三方：子域和以 . 开头的域属于同一站点，公共后缀下的其他站点和 IP 属于第三方
+func TestCookieReport(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expires := func(d time.Duration) float64 { return float64(now.Add(d).Unix()) }
+	cookies := []*network.Cookie{
+		{Name: "track", Domain: ".ads.example.net", Path: "/", Secure: true, SameSite: network.CookieSameSiteNone, Expires: expires(30 * 24 * time.Hour)},
+		{Name: "sid", Domain: "www.example.co.uk", Path: "/", HTTPOnly: true, SameSite: network.CookieSameSiteLax, Session: true, Expires: -1},
+		{Name: "pref", Domain: ".example.co.uk", Path: "/app", Expires: expires(36 * time.Hour)},
+		{Name: "other", Domain: "other.co.uk", Path: "/", Session: true},
+		{Name: "ip", Domain: "127.0.0.1", Path: "/", Session: true},
+	}
+
+	report := cookieReport(OriginKey("https://shop.example.co.uk/cart"), cookies, now)
+	if report.Site != "example.co.uk" {
+		t.Fatalf("Site = %q，应为 example.co.uk", report.Site)
+	}
+
+	want := []CookieInfo{
+		{Name: "track", Domain: ".ads.example.net", Path: "/", ThirdParty: true, Secure: true, SameSite: "None", ExpiresIn: 30},
+		{Name: "pref", Domain: ".example.co.uk", Path: "/app", ExpiresIn: 1.5},
+		{Name: "ip", Domain: "127.0.0.1", Path: "/", ThirdParty: true, Session: true},
+		{Name: "other", Domain: "other.co.uk", Path: "/", ThirdParty: true, Session: true},
+		{Name: "sid", Domain: "www.example.co.uk", Path: "/", HTTPOnly: true, SameSite: "Lax", Session: true},
+	}
+	if len(report.Cookies) != len(want) {
+		t.Fatalf("有 %d 个 Cookie，应为 %d: %+v", len(report.Cookies), len(want), report.Cookies)
+	}
+	for i, c := range report.Cookies {
+		if c != want[i] {
+			t.Errorf("Cookies[%d] = %+v，应为 %+v", i, c, want[i])
+		}
+	}
+}
+
+// 没有 Cookie 时 cookies 为空数组而不是 null，privacy.json 的结构保持稳定
+func TestCookieReportEmpty(t *testing.T) {
+	report := cookieReport("example.com", nil, time.Now())
+	if report.Cookies == nil || len(report.Cookies) != 0 {
+		t.Errorf("Cookies = %#v，应为空切片", report.Cookies)
+	}
+}
+
+func TestCookieSite(t *testing.T) {
+	for domain, want := range map[string]string{
+		".example.com":      "example.com",
+		"a.b.example.com":   "example.com",
+		"EXAMPLE.github.io": "example.github.io",
+		"localhost":         "localhost",
+		"127.0.0.1":         "127.0.0.1",
+		".tracker.co.uk":    "tracker.co.uk",
+	} {
+		if got := cookieSite(domain); got != want {
+			t.Errorf("cookieSite(%q) = %q，应为 %q", domain, got, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package crawler
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// GetResourcesByMIMEType 返回 MimeType 以 prefix 开头的资源（如 "application/javascript"、"image/"），按 URL 排序。
+// 按声明的类型匹配，不含嗅探出的 DetectedMimeType
+func (s *Spider) GetResourcesByMIMEType(prefix string) []*Resource {
+	return s.selectResources(func(res *Resource) bool {
+		return strings.HasPrefix(res.MimeType, prefix)
+	})
+}
+
+// GetResourcesByStatusCode 返回响应状态码为 code 的资源，按 URL 排序
+func (s *Spider) GetResourcesByStatusCode(code int) []*Resource {
+	return s.selectResources(func(res *Resource) bool {
+		return res.StatusCode == code
+	})
+}
+
+// GetResourcesByURLPattern 返回 URL 匹配正则 pattern 的资源（未加锚点时匹配 URL 的任意部分），按 URL 排序；
+// pattern 不是合法的正则表达式时返回错误
+func (s *Spider) GetResourcesByURLPattern(pattern string) ([]*Resource, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return s.selectResources(func(res *Resource) bool {
+		return re.MatchString(res.URL)
+	}), nil
+}
+
+// selectResources 返回满足 match 的资源，按 URL 排序；切片是新建的，资源本身与资源表共享（同 GetResources）
+func (s *Spider) selectResources(match func(*Resource) bool) []*Resource {
+	s.mu.Lock()
+	var selected []*Resource
+	for _, res := range s.resources {
+		if match(res) {
+			selected = append(selected, res)
+		}
+	}
+	s.mu.Unlock()
+	slices.SortFunc(selected, func(a, b *Resource) int {
+		return strings.Compare(a.URL, b.URL)
+	})
+	return selected
+}
@@ -0,0 +1,164 @@
+package crawler
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"strings"
+	"time"
+
+	"spider/internal/har"
+	"spider/internal/logging"
+)
+
+// replayHAR 从 HAR 文件（storage.ExportHAR / -export zap 的输出）重建资源表，
+// 不启动浏览器、不访问网络。HAR 中的全部条目都会载入，targetURL 只用于确定主文档。
+func (s *Spider) replayHAR(targetURL string) error {
+	if s.config.HARFile == "" {
+		return fmt.Errorf("HAR 回放模式需要指定 HARFile")
+	}
+
+	f, err := os.Open(s.config.HARFile)
+	if err != nil {
+		return fmt.Errorf("打开 HAR 文件失败: %w", err)
+	}
+	defer f.Close()
+
+	doc, err := har.Decode(f)
+	if err != nil {
+		return fmt.Errorf("解析 HAR 文件失败: %w", err)
+	}
+
+	s.result.FinalURL = targetURL
+	for _, entry := range doc.Log.Entries {
+		if s.blocker.blocked(entry.Request.URL) {
+			s.blockedCount++
+			continue
+		}
+		resource, err := resourceFromHAR(entry)
+		if err != nil {
+			logging.Warnf("跳过无法还原的 HAR 条目 %s: %v", entry.Request.URL, err)
+			continue
+		}
+		if !s.shouldCapture(resource) {
+			continue
+		}
+		if resource.Labels == nil {
+			resource.Labels = maps.Clone(s.config.ResourceLabels)
+		}
+		if s.config.FollowCSPReportURIs {
+			resource.ReportEndpoints = cspReportEndpoints(resource.URL, resource.Headers)
+		}
+		resource.Pages = []string{targetURL}
+		if body, ok := s.readLocalOverride(resource.URL); ok {
+			resource.Content = body
+			resource.Headers["X-Source"] = "LocalOverride"
+		}
+		s.sniff(resource)
+
+		key := s.resourceKey(resource.URL)
+		s.mu.Lock()
+		if existing, exists := s.resources[key]; exists {
+			if s.config.CollapsePolling {
+				existing.CollapsedCount++
+				existing.LastSeen = resource.ResponseTime
+			}
+			s.mu.Unlock()
+			continue
+		}
+		s.resources[key] = resource
+		if s.result.DocumentURL == "" && resource.URL == targetURL {
+			s.result.DocumentURL = resource.URL
+		}
+		s.mu.Unlock()
+
+		s.notifyCapture(resource)
+	}
+
+	log.Printf("HAR 回放: 从 %s 还原了 %d 个资源", s.config.HARFile, len(s.resources))
+	if s.filteredCount > 0 {
+		log.Printf("HAR 回放: Config.PathPrefix / Filters 跳过了 %d 个条目", s.filteredCount)
+	}
+	if s.ignoredCount > 0 {
+		log.Printf("HAR 回放: 忽略清单跳过了 %d 个条目", s.ignoredCount)
+	}
+	if s.blockedCount > 0 {
+		log.Printf("HAR 回放: 跳过了 %d 个被拦截的条目", s.blockedCount)
+	}
+	return nil
+}
+
+// resourceFromHAR 将 HAR 条目还原为 Resource，与 storage.ExportHAR 的映射互逆
+func resourceFromHAR(entry har.Entry) (*Resource, error) {
+	content, err := decodeHARText(entry.Response.Content.Text, entry.Response.Content.Encoding)
+	if err != nil {
+		return nil, fmt.Errorf("响应体: %w", err)
+	}
+
+	resource := &Resource{
+		URL:            entry.Request.URL,
+		Method:         entry.Request.Method,
+		StatusCode:     entry.Response.Status,
+		StatusText:     entry.Response.StatusText,
+		Protocol:       protocolFromHAR(entry.Response.HTTPVersion),
+		RemoteIP:       entry.ServerIPAddress,
+		MimeType:       entry.Response.Content.MimeType,
+		Content:        content,
+		Headers:        harHeadersToMap(entry.Response.Headers),
+		RequestHeaders: harHeadersToMap(entry.Request.Headers),
+		Labels:         maps.Clone(entry.Labels),
+	}
+	if resource.Method == "" {
+		resource.Method = "GET"
+	}
+	if t, err := time.Parse(time.RFC3339Nano, entry.StartedDateTime); err == nil {
+		resource.ResponseTime = t
+	}
+	if entry.Time > 0 {
+		resource.Latency = time.Duration(entry.Time * float64(time.Millisecond))
+		resource.FetchDuration = resource.Latency
+	}
+	if pd := entry.Request.PostData; pd != nil {
+		body, err := decodeHARText(pd.Text, pd.Encoding)
+		if err != nil {
+			return nil, fmt.Errorf("请求体: %w", err)
+		}
+		resource.RequestBody = body
+	}
+	return resource, nil
+}
+
+// decodeHARText 按 encoding 字段还原正文，"base64" 以外视为原文
+func decodeHARText(text, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(text)
+	}
+	return []byte(text), nil
+}
+
+// protocolFromHAR 将 HAR 的 httpVersion 还原为 CDP 的协议名（h2 / h3 / http/1.1）
+func protocolFromHAR(httpVersion string) string {
+	switch strings.ToUpper(httpVersion) {
+	case "HTTP/2", "HTTP/2.0":
+		return "h2"
+	case "HTTP/3":
+		return "h3"
+	default:
+		return strings.ToLower(httpVersion)
+	}
+}
+
+// harHeadersToMap 将 HAR 头列表转为 map，同名头按 CDP 的习惯以换行合并
+func harHeadersToMap(headers []har.NameValue) map[string]string {
+	result := make(map[string]string, len(headers))
+	for _, h := range headers {
+		if existing, ok := result[h.Name]; ok {
+			result[h.Name] = existing + "\n" + h.Value
+			continue
+		}
+		result[h.Name] = h.Value
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// ErrRetryStatusExhausted 主文档持续返回 RetryOnStatusCodes 中的状态码，页内重试已用尽。
+// 重试已在同一 Tab 中完成，调用方不应再整页重试。
+var ErrRetryStatusExhausted = errors.New("主文档状态码重试次数已用尽")
+
+// retryOnStatus 导航完成后检查主文档状态码，命中 RetryOnStatusCodes 时等待 RetryBaseDelay（每次相同，不退避），
+// 在同一 Tab 中重新导航，最多 RetryAttempts 次（适用于部署期间短暂返回 503 的环境）
+func (s *Spider) retryOnStatus(ctx context.Context, targetURL string) error {
+	if len(s.config.RetryOnStatusCodes) == 0 {
+		return nil
+	}
+	for attempt := 1; ; attempt++ {
+		s.mu.Lock()
+		status := s.documentStatus
+		s.mu.Unlock()
+		if !slices.Contains(s.config.RetryOnStatusCodes, status) {
+			return nil
+		}
+		if attempt > s.config.RetryAttempts {
+			return fmt.Errorf("%w: %s 返回 %d（已重试 %d 次）", ErrRetryStatusExhausted, targetURL, status, s.config.RetryAttempts)
+		}
+
+		log.Printf("  主文档返回 %d，%v 后重新导航（%d/%d）: %s", status, s.config.RetryBaseDelay, attempt, s.config.RetryAttempts, targetURL)
+		select {
+		case <-time.After(s.config.RetryBaseDelay):
+		case <-ctx.Done():
+			return fmt.Errorf("failed to crawl %s: %w", targetURL, ctx.Err())
+		}
+
+		// 丢弃错误响应，否则重新导航得到的正常文档会被当作重复资源跳过
+		s.mu.Lock()
+		s.documentStatus = 0
+		if s.result.DocumentURL != "" {
+			delete(s.resources, s.resourceKey(s.result.DocumentURL))
+		}
+		s.result.DocumentURL = ""
+		s.mu.Unlock()
+
+		if err := s.renavigate(ctx, targetURL); err != nil {
+			return fmt.Errorf("failed to crawl %s: %w", targetURL, err)
+		}
+	}
+}
+
+// renavigateInTab 在当前 Tab 中重新导航到 targetURL
+func (s *Spider) renavigateInTab(ctx context.Context, targetURL string) error {
+	return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return s.navigate(ctx, targetURL)
+	}))
+}
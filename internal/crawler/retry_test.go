@@ -0,0 +1,133 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flakyDocument 模拟部署中的服务：前 fail 次导航得到 503，之后为 200，和真实导航一样记录主文档
+type flakyDocument struct {
+	s     *Spider
+	url   string
+	fail  int
+	calls int
+}
+
+func (d *flakyDocument) load() {
+	status, body := 503, "deploying"
+	if d.calls > d.fail {
+		status, body = 200, "ready"
+	}
+	d.s.mu.Lock()
+	defer d.s.mu.Unlock()
+	d.s.result.DocumentURL = d.url
+	d.s.documentStatus = status
+	if _, ok := d.s.resources[d.url]; !ok {
+		d.s.resources[d.url] = &Resource{URL: d.url, StatusCode: status, Content: []byte(body)}
+	}
+}
+
+func (d *flakyDocument) renavigate(ctx context.Context, targetURL string) error {
+	if targetURL != d.url {
+		return fmt.Errorf("重新导航到了 %s", targetURL)
+	}
+	d.calls++
+	d.load()
+	return nil
+}
+
+func newFlakySpider(t *testing.T, fail, attempts int) (*Spider, *flakyDocument) {
+	t.Helper()
+	config := DefaultConfig()
+	config.RetryOnStatusCodes = []int{502, 503}
+	config.RetryAttempts = attempts
+	config.RetryBaseDelay = 20 * time.Millisecond
+	s, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := &flakyDocument{s: s, url: "https://example.com/", fail: fail, calls: 1}
+	doc.load() // 首次导航
+	s.renavigate = doc.renavigate
+	return s, doc
+}
+
+// 503 两次后恢复：重新导航两次，每次等待相同的 RetryBaseDelay，最终保存的是 200 的文档
+func TestRetryOnStatus(t *testing.T) {
+	var logs bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&logs)
+
+	s, doc := newFlakySpider(t, 2, 3)
+	start := time.Now()
+	if err := s.retryOnStatus(context.Background(), doc.url); err != nil {
+		t.Fatal(err)
+	}
+	if doc.calls != 3 {
+		t.Errorf("共导航 %d 次，应为 3（首次 + 重试 2 次）", doc.calls)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("两次重试只等待了 %v", elapsed)
+	}
+	res := s.GetResources()[doc.url]
+	if res == nil || res.StatusCode != 200 || string(res.Content) != "ready" {
+		t.Errorf("保存的主文档为 %+v，应为重试成功后的 200 响应", res)
+	}
+	// 固定间隔：两次重试都等待 20ms，不翻倍
+	if n := strings.Count(logs.String(), "主文档返回 503，20ms 后重新导航"); n != 2 {
+		t.Errorf("日志中有 %d 条 20ms 的重试记录，应为 2:\n%s", n, logs.String())
+	}
+}
+
+// 重试用尽返回 ErrRetryStatusExhausted；状态码不在列表中或未配置时不重试
+func TestRetryOnStatusExhausted(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(new(bytes.Buffer))
+
+	s, doc := newFlakySpider(t, 10, 2)
+	err := s.retryOnStatus(context.Background(), doc.url)
+	if !errors.Is(err, ErrRetryStatusExhausted) {
+		t.Fatalf("重试用尽应返回 ErrRetryStatusExhausted，实际 %v", err)
+	}
+	if doc.calls != 3 {
+		t.Errorf("共导航 %d 次，应为 3（首次 + RetryAttempts 2 次）", doc.calls)
+	}
+	if !strings.Contains(err.Error(), "返回 503") {
+		t.Errorf("错误中缺少状态码: %v", err)
+	}
+
+	s, doc = newFlakySpider(t, 10, 0)
+	if err := s.retryOnStatus(context.Background(), doc.url); !errors.Is(err, ErrRetryStatusExhausted) || doc.calls != 1 {
+		t.Errorf("RetryAttempts 为 0 时应直接返回 ErrRetryStatusExhausted，实际 %v（导航 %d 次）", err, doc.calls)
+	}
+
+	s, doc = newFlakySpider(t, 10, 2)
+	s.config.RetryOnStatusCodes = []int{500}
+	if err := s.retryOnStatus(context.Background(), doc.url); err != nil || doc.calls != 1 {
+		t.Errorf("503 不在列表中时不应重试，实际 %v（导航 %d 次）", err, doc.calls)
+	}
+	s.config.RetryOnStatusCodes = nil
+	if err := s.retryOnStatus(context.Background(), doc.url); err != nil || doc.calls != 1 {
+		t.Errorf("未配置时不应重试，实际 %v（导航 %d 次）", err, doc.calls)
+	}
+}
+
+// 等待期间取消时立即返回，不再导航
+func TestRetryOnStatusCanceled(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(new(bytes.Buffer))
+
+	s, doc := newFlakySpider(t, 10, 3)
+	s.config.RetryBaseDelay = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.retryOnStatus(ctx, doc.url); !errors.Is(err, context.DeadlineExceeded) || doc.calls != 1 {
+		t.Errorf("取消后应返回 ctx 的错误且不再导航，实际 %v（导航 %d 次）", err, doc.calls)
+	}
+}
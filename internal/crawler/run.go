@@ -0,0 +1,124 @@
+package crawler
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+// CrawlStats 单次爬取的计数
+type CrawlStats struct {
+	Resources    int // 抓取到的资源数
+	Filtered     int // 被 Config.PathPrefix / Filters 跳过的资源数
+	Ignored      int // 命中 Config.ResourceIgnoreList 未保存的资源数
+	Blocked      int // 被拦截的请求数（BlockDomains / BlockURLPatterns）
+	BodyFailures int // 未能取得响应体的资源数
+	BodyTimeouts int // 其中因超时失败的资源数
+	Slow         int // 超过 SlowResourceThreshold 的资源数
+}
+
+// ResourceFailure 抓到了响应但未能取得响应体的资源
+type ResourceFailure struct {
+	URL      string
+	Error    string // 同 Resource.BodyError
+	TimedOut bool
+}
+
+// Run 爬取 targetURL 并返回本次的全部结果（资源、计数、页面信息），供库调用一次性取用。
+// 每次调用前清空上一次爬取的状态，同一个 Spider 可依次 Run 多个 URL（不可并发）；
+// OnCapture 回调、ImportSession 设置的会话和配置保留。
+// ctx 来自浏览器池（Pool.Acquire）时在其中开新 Tab，否则自行启动 Chrome，ctx 取消时结束爬取。
+// 爬取出错（超时、缺少必需元素等）时仍返回已抓到的部分结果和错误；URL 非法时结果为 nil
+func (s *Spider) Run(ctx context.Context, targetURL string) (*CrawlResult, error) {
+	if err := validateURL(targetURL); err != nil {
+		return nil, err
+	}
+	s.Reset()
+
+	var err error
+	if c := chromedp.FromContext(ctx); c != nil && c.Allocator != nil {
+		err = s.CrawlInContext(ctx, targetURL)
+	} else {
+		err = s.crawl(ctx, targetURL)
+	}
+	return s.snapshot(), err
+}
+
+// snapshot 汇总当前爬取的结果，资源表为副本
+func (s *Spider) snapshot() *CrawlResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := s.result
+	result.Resources = maps.Clone(s.resources)
+	result.Stats = CrawlStats{
+		Resources:    len(s.resources),
+		Filtered:     s.filteredCount,
+		Ignored:      s.ignoredCount,
+		Blocked:      s.blockedCount,
+		BodyFailures: s.bodyFailures,
+		BodyTimeouts: s.bodyTimeouts,
+		Slow:         s.slowCount,
+	}
+	for _, res := range s.resources {
+		if res.BodyError != "" {
+			result.Failures = append(result.Failures, ResourceFailure{URL: res.URL, Error: res.BodyError, TimedOut: res.BodyTimedOut})
+		}
+	}
+	slices.SortFunc(result.Failures, func(a, b ResourceFailure) int {
+		return strings.Compare(a.URL, b.URL)
+	})
+	result.NetworkEvents = s.eventLog
+	return &result
+}
+
+// Close 等待仍在进行的响应体获取结束，并关闭备用 HTTP 客户端的空闲连接；已抓取的资源和结果保留，
+// 之后仍可调用 Result / GetResources。爬取出错或超时返回时后台 goroutine 可能还在写资源表，
+// 用完即弃的 Spider 在读取结果前调用，不会留下 goroutine 和连接
+func (s *Spider) Close() {
+	s.wg.Wait()
+	s.httpClient.CloseIdleConnections()
+}
+
+// Reset 清空上一次爬取留下的状态（资源表、请求记录、事件日志、计数等），并关闭备用 HTTP 客户端的空闲连接；
+// 配置、本地覆盖、OnCapture 回调和导入的会话不变。Run 每次调用前自动执行。
+// 复用同一个 Spider 依次爬取时调用，可及时释放内存；清空前先等待仍在进行的响应体获取结束（见 Close）。
+// 之前由 GetResources / Run 取得的资源表副本不受影响。不可与爬取并发调用
+func (s *Spider) Reset() {
+	s.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resources = make(map[string]*Resource)
+	s.requests = make(map[network.RequestID]*requestInfo)
+	s.attached = make(map[target.ID]bool)
+	s.loads = newLoadTracker()
+	s.events = nil
+	s.eventLog = nil
+	s.lastCapture = time.Time{}
+	s.result = CrawlResult{}
+	s.pageURL = ""
+	s.documentStatus = 0
+	s.initialLoader = ""
+	s.navOrigin = ""
+	s.domTooLarge = false
+	s.coverageStarted = false
+	s.styleSheets = nil
+	s.marker = ""
+	s.markers = nil
+	s.blockedCount = 0
+	s.filteredCount = 0
+	s.ignoredCount = 0
+	s.drainDeadline = time.Time{}
+	s.bodyFailures = 0
+	s.bodyTimeouts = 0
+	s.fetchesInFlight = 0
+	s.slowCount = 0
+	s.hints = nil
+	s.issueIndex = nil
+	s.session = nil
+}
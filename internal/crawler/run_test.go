@@ -0,0 +1,28 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+)
+
+// 出错或超时返回时仍可能有响应体获取在写资源表，Reset 应等它们结束后再清空
+func TestResetWaitsForInFlightFetches(t *testing.T) {
+	s, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		time.Sleep(50 * time.Millisecond)
+		s.mu.Lock()
+		s.resources["https://example.com/late.js"] = &Resource{URL: "https://example.com/late.js"}
+		s.mu.Unlock()
+	}()
+
+	s.Reset()
+	if n := len(s.GetResources()); n != 0 {
+		t.Errorf("Reset 返回后资源表中仍有 %d 个资源，说明清空时获取尚未结束", n)
+	}
+}
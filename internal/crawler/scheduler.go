@@ -0,0 +1,211 @@
+package crawler
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Scheduler 批量模式的任务调度器。
+// 全局并发由 Concurrency 控制；同一可注册域名（eTLD+1）同时进行的任务数
+// 不超过 PerOriginConcurrency。各域名轮流出队，避免按输入顺序把所有
+// worker 压在同一个站点上，而其他站点的任务在队尾空等。
+type Scheduler struct {
+	workers   int
+	perOrigin int  // <=0 表示不限制
+	ordered   bool // 按输入顺序逐个执行，不按域名分组（NewOrderedScheduler）
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	origins []*originQueue // 按首次出现顺序排列，轮询出队
+	next    int            // 下一次轮询的起始下标
+	pending int            // 尚未出队的任务数
+}
+
+type originQueue struct {
+	key    string
+	tasks  []int // 任务下标（对应输入 urls）
+	active int
+}
+
+// NewScheduler 根据配置创建调度器
+func NewScheduler(config *Config) *Scheduler {
+	workers := config.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	s := &Scheduler{
+		workers:   workers,
+		perOrigin: config.PerOriginConcurrency,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// NewOrderedScheduler 创建按输入顺序逐个执行任务的调度器（会话模式：前面页面设置的 Cookie 延续到后续 URL）
+func NewOrderedScheduler() *Scheduler {
+	s := &Scheduler{workers: 1, ordered: true}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// BatchCrawler 批量模式中爬取单个 URL 的实现，由 Scheduler.Crawl 并发调用；
+// idx 为 url 在输入切片中的下标，返回的错误按下标交回调用方
+type BatchCrawler interface {
+	CrawlURL(ctx context.Context, idx int, url string) error
+}
+
+// Crawl 以调度器的并发限制对每个 URL 调用 c.CrawlURL，全部完成后按输入下标返回各自的错误。
+// ctx 取消后尚未开始的 URL 不再调用 CrawlURL，其错误为 ctx.Err()
+func (s *Scheduler) Crawl(ctx context.Context, urls []string, c BatchCrawler) []error {
+	errs := make([]error, len(urls))
+	s.Run(urls, func(idx int, u string) {
+		if err := ctx.Err(); err != nil {
+			errs[idx] = err
+			return
+		}
+		errs[idx] = c.CrawlURL(ctx, idx, u)
+	})
+	return errs
+}
+
+// Run 以调度器的并发限制执行 fn(idx, url)，全部完成后返回。
+// fn 可并发调用，idx 为 url 在输入切片中的下标。
+func (s *Scheduler) Run(urls []string, fn func(idx int, url string)) {
+	s.enqueue(urls)
+
+	var wg sync.WaitGroup
+	for range min(s.workers, len(urls)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				q, idx, ok := s.dequeue()
+				if !ok {
+					return
+				}
+				fn(idx, urls[idx])
+				s.done(q)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// enqueue 按可注册域名分组，组内保持输入顺序
+func (s *Scheduler) enqueue(urls []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byKey := make(map[string]*originQueue)
+	for i, u := range urls {
+		key := ""
+		if !s.ordered {
+			key = OriginKey(u)
+		}
+		q, ok := byKey[key]
+		if !ok {
+			q = &originQueue{key: key}
+			byKey[key] = q
+			s.origins = append(s.origins, q)
+		}
+		q.tasks = append(q.tasks, i)
+	}
+	s.pending += len(urls)
+}
+
+// dequeue 阻塞直到有任务可执行；所有任务已出队时返回 ok=false
+func (s *Scheduler) dequeue() (*originQueue, int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		if s.pending == 0 {
+			return nil, 0, false
+		}
+		// 从上次位置开始轮询，找到第一个有任务且未达上限的域名
+		for i := range s.origins {
+			pos := (s.next + i) % len(s.origins)
+			q := s.origins[pos]
+			if len(q.tasks) == 0 || (s.perOrigin > 0 && q.active >= s.perOrigin) {
+				continue
+			}
+			idx := q.tasks[0]
+			q.tasks = q.tasks[1:]
+			q.active++
+			s.pending--
+			s.next = pos + 1
+			return q, idx, true
+		}
+		// 剩余任务所属域名都已达上限，等待有任务完成
+		s.cond.Wait()
+	}
+}
+
+// done 标记任务完成，唤醒等待该域名名额的 worker
+func (s *Scheduler) done(q *originQueue) {
+	s.mu.Lock()
+	q.active--
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+// OriginLimiter 限制同一可注册域名同时进行的任务数，用于任务动态产生、无法预先入队的场景（递归爬取）
+type OriginLimiter struct {
+	perOrigin int // <=0 表示不限制
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	active map[string]int
+}
+
+// NewOriginLimiter 创建每个域名最多 perOrigin 个并发任务的限制器
+func NewOriginLimiter(perOrigin int) *OriginLimiter {
+	l := &OriginLimiter{perOrigin: perOrigin, active: make(map[string]int)}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// Acquire 阻塞直到 rawURL 所属域名有空闲名额，返回的函数用于归还名额
+func (l *OriginLimiter) Acquire(rawURL string) (release func()) {
+	key := OriginKey(rawURL)
+	l.mu.Lock()
+	for l.perOrigin > 0 && l.active[key] >= l.perOrigin {
+		l.cond.Wait()
+	}
+	l.active[key]++
+	l.mu.Unlock()
+
+	return func() {
+		l.mu.Lock()
+		l.active[key]--
+		l.mu.Unlock()
+		l.cond.Broadcast()
+	}
+}
+
+// OriginKey 返回 URL 的可注册域名（eTLD+1），如 a.cdn.example.co.uk → example.co.uk。
+// IP、localhost 等无法取得可注册域名时退回到小写主机名。
+func OriginKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return siteOfHost(u.Hostname())
+}
+
+// siteOfHost 返回主机名的可注册域名，规则同 OriginKey
+func siteOfHost(host string) string {
+	host = strings.ToLower(host)
+	if net.ParseIP(host) != nil {
+		return host
+	}
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return domain
+	}
+	return host
+}
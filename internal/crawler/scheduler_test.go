@@ -0,0 +1,203 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCrawler 记录调用顺序、全局和每个域名的最大并发；fail 中的下标返回错误
+type fakeCrawler struct {
+	delay time.Duration
+	fail  map[int]bool
+
+	mu           sync.Mutex
+	started      []string
+	active       int
+	maxActive    int
+	perOrigin    map[string]int
+	maxPerOrigin map[string]int
+}
+
+func newFakeCrawler(delay time.Duration) *fakeCrawler {
+	return &fakeCrawler{delay: delay, perOrigin: make(map[string]int), maxPerOrigin: make(map[string]int)}
+}
+
+func (f *fakeCrawler) CrawlURL(ctx context.Context, idx int, url string) error {
+	key := OriginKey(url)
+	f.mu.Lock()
+	f.started = append(f.started, url)
+	f.active++
+	f.maxActive = max(f.maxActive, f.active)
+	f.perOrigin[key]++
+	f.maxPerOrigin[key] = max(f.maxPerOrigin[key], f.perOrigin[key])
+	f.mu.Unlock()
+
+	time.Sleep(f.delay)
+
+	f.mu.Lock()
+	f.active--
+	f.perOrigin[key]--
+	f.mu.Unlock()
+	if f.fail[idx] {
+		return fmt.Errorf("crawl %s failed", url)
+	}
+	return nil
+}
+
+func schedulerURLs(origins, perOrigin int) []string {
+	var urls []string
+	for o := range origins {
+		for p := range perOrigin {
+			urls = append(urls, fmt.Sprintf("https://s%d.site%d.com/page/%d", p%2, o, p))
+		}
+	}
+	return urls
+}
+
+func TestSchedulerGlobalConcurrency(t *testing.T) {
+	f := newFakeCrawler(10 * time.Millisecond)
+	urls := schedulerURLs(10, 2)
+	NewScheduler(&Config{Concurrency: 4}).Crawl(context.Background(), urls, f)
+
+	if len(f.started) != len(urls) {
+		t.Fatalf("执行了 %d 个任务，应为 %d", len(f.started), len(urls))
+	}
+	if f.maxActive != 4 {
+		t.Errorf("最大全局并发 %d，应为 4", f.maxActive)
+	}
+}
+
+// 同一可注册域名（含不同子域名）的并发不超过 PerOriginConcurrency，其余 worker 去处理其他域名
+func TestSchedulerPerOriginCap(t *testing.T) {
+	f := newFakeCrawler(5 * time.Millisecond)
+	// 一个域名 50 个 URL 排在前面，另外 3 个域名各 2 个
+	urls := schedulerURLs(1, 50)
+	for o := 1; o <= 3; o++ {
+		urls = append(urls, fmt.Sprintf("https://site%d.com/a", o), fmt.Sprintf("https://site%d.com/b", o))
+	}
+	NewScheduler(&Config{Concurrency: 8, PerOriginConcurrency: 2}).Crawl(context.Background(), urls, f)
+
+	for key, n := range f.maxPerOrigin {
+		if n > 2 {
+			t.Errorf("%s 的最大并发 %d，超过上限 2", key, n)
+		}
+	}
+	if f.maxPerOrigin["site0.com"] != 2 {
+		t.Errorf("site0.com 的最大并发 %d，应达到上限 2", f.maxPerOrigin["site0.com"])
+	}
+	// 其他域名不必等大站点的 50 个 URL 排完：都应出现在前 10 个开始的任务中
+	first := f.started[:10]
+	for _, key := range []string{"site1.com", "site2.com", "site3.com"} {
+		if !slices.ContainsFunc(first, func(u string) bool { return OriginKey(u) == key }) {
+			t.Errorf("%s 没有在前 10 个任务中开始: %v", key, first)
+		}
+	}
+}
+
+// 域名之间轮流出队，域名内保持输入顺序
+func TestSchedulerInterleavesOrigins(t *testing.T) {
+	urls := []string{
+		"https://a.com/1", "https://a.com/2", "https://a.com/3",
+		"https://b.com/1", "https://b.com/2",
+		"https://c.com/1",
+	}
+	f := newFakeCrawler(0)
+	NewScheduler(&Config{Concurrency: 1}).Crawl(context.Background(), urls, f)
+	want := []string{
+		"https://a.com/1", "https://b.com/1", "https://c.com/1",
+		"https://a.com/2", "https://b.com/2",
+		"https://a.com/3",
+	}
+	if !slices.Equal(f.started, want) {
+		t.Errorf("执行顺序 %v，应为 %v", f.started, want)
+	}
+
+	f = newFakeCrawler(0)
+	NewOrderedScheduler().Crawl(context.Background(), urls, f)
+	if !slices.Equal(f.started, urls) {
+		t.Errorf("NewOrderedScheduler 执行顺序 %v，应保持输入顺序", f.started)
+	}
+}
+
+// CrawlURL 返回的错误按输入下标交回，不影响其他任务
+func TestSchedulerErrorPropagation(t *testing.T) {
+	f := newFakeCrawler(time.Millisecond)
+	f.fail = map[int]bool{1: true, 4: true}
+	urls := schedulerURLs(3, 2)
+	errs := NewScheduler(&Config{Concurrency: 3, PerOriginConcurrency: 1}).Crawl(context.Background(), urls, f)
+
+	if len(errs) != len(urls) || len(f.started) != len(urls) {
+		t.Fatalf("返回 %d 个结果、执行 %d 个任务，应都为 %d", len(errs), len(f.started), len(urls))
+	}
+	for i, err := range errs {
+		if f.fail[i] != (err != nil) {
+			t.Errorf("errs[%d] = %v", i, err)
+		}
+	}
+	if want := "crawl " + urls[4] + " failed"; errs[4] == nil || errs[4].Error() != want {
+		t.Errorf("errs[4] = %v，应为 %q", errs[4], want)
+	}
+}
+
+// ctx 取消后尚未开始的任务不再执行，错误为 ctx.Err()
+func TestSchedulerStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	urls := schedulerURLs(1, 5)
+	var calls int
+	errs := NewOrderedScheduler().Crawl(ctx, urls, crawlerFunc(func(_ context.Context, idx int, _ string) error {
+		calls++
+		if idx == 1 {
+			cancel()
+		}
+		return nil
+	}))
+	if calls != 2 {
+		t.Errorf("取消后仍执行了 %d 个任务，应只有 2 个", calls)
+	}
+	for i, err := range errs[2:] {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("errs[%d] = %v，应为 context.Canceled", i+2, err)
+		}
+	}
+}
+
+type crawlerFunc func(ctx context.Context, idx int, url string) error
+
+func (f crawlerFunc) CrawlURL(ctx context.Context, idx int, url string) error {
+	return f(ctx, idx, url)
+}
+
+// OriginLimiter 按可注册域名计数：同一站点（含子域名）最多 perOrigin 个，其他站点不受影响；<=0 不限制
+func TestOriginLimiter(t *testing.T) {
+	l := NewOriginLimiter(2)
+	r1 := l.Acquire("https://a.example.com/1")
+	r2 := l.Acquire("https://www.example.com/2")
+	l.Acquire("https://other.org/")() // 其他站点不被阻塞
+
+	acquired := make(chan func())
+	go func() { acquired <- l.Acquire("https://example.com/3") }()
+	select {
+	case <-acquired:
+		t.Fatal("同一站点的第 3 个任务没有被阻塞")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	r1()
+	select {
+	case r3 := <-acquired:
+		r3()
+	case <-time.After(time.Second):
+		t.Fatal("归还名额后等待的任务没有继续")
+	}
+	r2()
+
+	unlimited := NewOriginLimiter(0)
+	for range 10 {
+		unlimited.Acquire("https://example.com/")
+	}
+}
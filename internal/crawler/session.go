@@ -0,0 +1,171 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+
+	"spider/internal/logging"
+)
+
+// SessionVersion SessionState 的格式版本，格式不兼容地变化时递增
+const SessionVersion = 1
+
+// ErrNoSession 没有可导出的会话：未开启 Config.CaptureSession 或爬取没有进行到读取会话的一步
+var ErrNoSession = errors.New("没有可导出的会话")
+
+// SessionState 浏览器会话：全部 Cookie 和页面源的 localStorage，可序列化为 JSON。
+// 用于在有界面（-keep-open）下手动登录后，把会话带到之后的无头 / 批量爬取中
+type SessionState struct {
+	Version      int                          `json:"version"`
+	URL          string                       `json:"url"`           // 导出时所在的页面
+	Exported     time.Time                    `json:"exported"`      // 导出时间
+	Cookies      []*network.Cookie            `json:"cookies"`       // 浏览器中的全部 Cookie（含 HttpOnly）
+	LocalStorage map[string]map[string]string `json:"local_storage"` // 源（如 https://example.com）→ 键值
+}
+
+// sessionStorageScript 读取当前页面源的 localStorage
+const sessionStorageScript = `(function(){
+	var items = {};
+	try {
+		for (var i = 0; i < localStorage.length; i++) {
+			var k = localStorage.key(i);
+			items[k] = localStorage.getItem(k);
+		}
+	} catch (e) {}
+	return {origin: location.origin, items: items};
+})()`
+
+// sessionRestoreScript 每个新文档脚本执行前写入该源导出的 localStorage，页面已有的键不覆盖
+const sessionRestoreScript = `(function(){
+	var items = (%s)[location.origin];
+	if (!items) {
+		return;
+	}
+	try {
+		for (var k in items) {
+			if (localStorage.getItem(k) === null) {
+				localStorage.setItem(k, items[k]);
+			}
+		}
+	} catch (e) {}
+})()`
+
+// ExportSession 返回爬取结束时（KeepOpen 返回之后）读取的会话，需开启 Config.CaptureSession；
+// 还没有读取到会话时返回 ErrNoSession
+func (s *Spider) ExportSession() (*SessionState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.session == nil {
+		return nil, ErrNoSession
+	}
+	return s.session, nil
+}
+
+// ImportSession 设置爬取前恢复的会话（须在爬取开始前调用）：导航前写入 Cookie，
+// 并在每个新文档的脚本执行前写入对应源的 localStorage。已过期的 Cookie 跳过
+func (s *Spider) ImportSession(state *SessionState) error {
+	if state == nil {
+		return errors.New("会话为空")
+	}
+	if state.Version > SessionVersion {
+		return fmt.Errorf("不支持的会话格式版本 %d（当前支持 %d）", state.Version, SessionVersion)
+	}
+	s.imported = state
+	return nil
+}
+
+// captureSession 读取浏览器中的全部 Cookie（storage.getCookies，即已弃用的 Network.getAllCookies）
+// 和当前页面源的 localStorage，保存供 ExportSession 返回
+func (s *Spider) captureSession(ctx context.Context) {
+	var cookies []*network.Cookie
+	var local struct {
+		Origin string            `json:"origin"`
+		Items  map[string]string `json:"items"`
+	}
+	err := chromedp.Run(ctx,
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			cookies, err = storage.GetCookies().Do(ctx)
+			return err
+		}),
+		chromedp.Evaluate(sessionStorageScript, &local),
+	)
+	if err != nil {
+		logging.Warnf("读取会话失败: %v", err)
+		return
+	}
+
+	state := &SessionState{
+		Version:      SessionVersion,
+		Exported:     time.Now(),
+		Cookies:      cookies,
+		LocalStorage: make(map[string]map[string]string),
+	}
+	if local.Origin != "" && local.Origin != "null" && len(local.Items) > 0 {
+		state.LocalStorage[local.Origin] = local.Items
+	}
+	s.mu.Lock()
+	state.URL = s.pageURL
+	s.session = state
+	s.mu.Unlock()
+	log.Printf("已读取会话: %d 个 Cookie，localStorage %d 项", len(cookies), len(local.Items))
+}
+
+// restoreSession 写入 ImportSession 设置的 Cookie 和 localStorage 恢复脚本，在导航前执行
+func (s *Spider) restoreSession(ctx context.Context) error {
+	state := s.imported
+	now := float64(time.Now().Unix())
+	var params []*network.CookieParam
+	for _, c := range state.Cookies {
+		if !c.Session && c.Expires > 0 && c.Expires < now {
+			continue
+		}
+		param := &network.CookieParam{
+			Name:         c.Name,
+			Value:        c.Value,
+			Domain:       c.Domain,
+			Path:         c.Path,
+			Secure:       c.Secure,
+			HTTPOnly:     c.HTTPOnly,
+			SameSite:     c.SameSite,
+			Priority:     c.Priority,
+			SourceScheme: c.SourceScheme,
+			SourcePort:   c.SourcePort,
+			PartitionKey: c.PartitionKey,
+		}
+		if !c.Session && c.Expires > 0 {
+			sec, frac := math.Modf(c.Expires)
+			expires := cdp.TimeSinceEpoch(time.Unix(int64(sec), int64(frac*1e9)))
+			param.Expires = &expires
+		}
+		params = append(params, param)
+	}
+	if len(params) > 0 {
+		if err := network.SetCookies(params).Do(ctx); err != nil {
+			return fmt.Errorf("恢复会话 Cookie 失败: %w", err)
+		}
+	}
+
+	if len(state.LocalStorage) > 0 {
+		data, err := json.Marshal(state.LocalStorage)
+		if err != nil {
+			return err
+		}
+		if _, err := page.AddScriptToEvaluateOnNewDocument(fmt.Sprintf(sessionRestoreScript, data)).Do(ctx); err != nil {
+			return fmt.Errorf("恢复会话 localStorage 失败: %w", err)
+		}
+	}
+	log.Printf("已恢复会话: %d 个 Cookie，%d 个源的 localStorage", len(params), len(state.LocalStorage))
+	return nil
+}
@@ -0,0 +1,151 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// genericMimeTypes 不说明内容类型的声明：只有声明为这些类型（或缺失）时才按内容嗅探
+var genericMimeTypes = map[string]bool{
+	"":                         true,
+	"text/plain":               true,
+	"application/octet-stream": true,
+	"binary/octet-stream":      true,
+	"application/unknown":      true,
+	"application/x-unknown":    true,
+}
+
+// sniffLimit 嗅探时检查的内容前缀长度
+const sniffLimit = 4096
+
+var (
+	// jsSignature 脚本开头的常见写法：IIFE、严格模式、声明、模块语法、打包器运行时
+	jsSignature = regexp.MustCompile(`^(?:\(\s*function\b|\(\s*\(\s*\)\s*=>|!function\b|function\s*[\w$]*\s*\(|["']use strict["']|(?:var|let|const)\s+[\w$\[{]|import\s*[\w${*"'(]|export\s+|define\s*\(|(?:self|window|globalThis)\s*[.\[]|\(\s*(?:self|window|globalThis)\s*[.\[]|webpackJsonp|System\.register\s*\()`)
+	// cssSignature 样式表开头的 @ 规则或 "选择器 { 属性: 值" 结构
+	cssSignature = regexp.MustCompile(`^(?:@(?:charset|import|media|font-face|keyframes|supports|layer|namespace)\b|[\w\s.#:*>+~,\-\[\]="'()]+\{\s*[\w-]+\s*:)`)
+)
+
+// sniffMimeType 按内容判断资源类型，仅在 declared 为 text/plain、application/octet-stream 等通用类型时生效；
+// 识别出与声明不同的类型时返回该类型，否则返回空字符串
+func sniffMimeType(declared string, content []byte) string {
+	base, _, err := mime.ParseMediaType(declared)
+	if err != nil {
+		base = strings.ToLower(strings.TrimSpace(declared))
+	}
+	if !genericMimeTypes[base] || len(content) == 0 {
+		return ""
+	}
+
+	detected := detectMimeType(content)
+	if detected == "" || detected == base {
+		return ""
+	}
+	return detected
+}
+
+// detectMimeType 先检查 WebAssembly / JSON / source map / JS / CSS 等文本特征，
+// 再回退到 http.DetectContentType；无法确定时返回空字符串
+func detectMimeType(content []byte) string {
+	if bytes.HasPrefix(content, []byte("\x00asm")) {
+		return "application/wasm"
+	}
+
+	head := content
+	if len(head) > sniffLimit {
+		head = head[:sniffLimit]
+	}
+	detected, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if detected != "text/plain" {
+		// 二进制（图片、字体、压缩包）和 HTML / XML 由标准库识别；application/octet-stream 表示无法判断
+		if detected == "application/octet-stream" {
+			return ""
+		}
+		return detected
+	}
+
+	text := bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	// sourceMappingURL 注释通常在文件末尾
+	tail := content[max(0, len(content)-sniffLimit):]
+	switch {
+	case isSourceMap(content):
+		// source map 没有专用的注册类型，按 JSON 处理
+		return "application/json"
+	case json.Valid(bytes.TrimSpace(content)):
+		return "application/json"
+	case bytes.Contains(tail, []byte("//# sourceMappingURL=")) || bytes.Contains(tail, []byte("//@ sourceMappingURL=")):
+		return "application/javascript"
+	case bytes.Contains(tail, []byte("/*# sourceMappingURL=")):
+		return "text/css"
+	}
+
+	code := skipLeadingComments(text)
+	switch {
+	case jsSignature.Match(code):
+		return "application/javascript"
+	case cssSignature.Match(code):
+		return "text/css"
+	}
+	return ""
+}
+
+// isSourceMap 判断内容是否为 source map：含 version、mappings 和 sources（或 sections）字段的 JSON 对象
+func isSourceMap(content []byte) bool {
+	trimmed := bytes.TrimSpace(content)
+	// 部分 source map 以 )]}' 开头防止被当作脚本执行
+	trimmed = bytes.TrimSpace(bytes.TrimPrefix(trimmed, []byte(")]}'")))
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return false
+	}
+	var m struct {
+		Version  int             `json:"version"`
+		Mappings *string         `json:"mappings"`
+		Sources  []string        `json:"sources"`
+		Sections json.RawMessage `json:"sections"`
+	}
+	if err := json.Unmarshal(trimmed, &m); err != nil {
+		return false
+	}
+	return m.Version > 0 && ((m.Mappings != nil && m.Sources != nil) || m.Sections != nil)
+}
+
+// skipLeadingComments 跳过开头的空白、/* */ 许可证注释和 // 行注释
+func skipLeadingComments(text []byte) []byte {
+	for {
+		text = bytes.TrimLeft(text, " \t\r\n")
+		switch {
+		case bytes.HasPrefix(text, []byte("/*")):
+			end := bytes.Index(text[2:], []byte("*/"))
+			if end < 0 {
+				return nil
+			}
+			text = text[2+end+2:]
+		case bytes.HasPrefix(text, []byte("//")):
+			end := bytes.IndexByte(text, '\n')
+			if end < 0 {
+				return nil
+			}
+			text = text[end+1:]
+		default:
+			return text
+		}
+	}
+}
+
+// sniff 按 SniffMime 设置嗅探资源类型，调用方持有 s.mu 或资源尚未共享
+func (s *Spider) sniff(resource *Resource) {
+	if s.config.SniffMime {
+		resource.DetectedMimeType = sniffMimeType(resource.MimeType, resource.Content)
+	}
+}
+
+// ContentMimeType 资源实际使用的类型：嗅探到的类型优先于响应声明的类型
+func (r *Resource) ContentMimeType() string {
+	if r.DetectedMimeType != "" {
+		return r.DetectedMimeType
+	}
+	return r.MimeType
+}
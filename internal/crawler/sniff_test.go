@@ -0,0 +1,75 @@
+package crawler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testdata/mislabeled 中的文件被服务端以通用类型返回时嗅探出的类型
+func TestSniffMislabeledFixtures(t *testing.T) {
+	cases := map[string]string{
+		"bundle.js":    "application/javascript",
+		"module.mjs":   "application/javascript",
+		"commented.js": "application/javascript", // 只靠末尾的 sourceMappingURL 注释识别
+		"app.js.map":   "application/json",
+		"xssi.js.map":  "application/json",
+		"data.json":    "application/json",
+		"styles.css":   "text/css",
+		"rules.css":    "text/css",
+		"page.html":    "text/html",
+		"module.wasm":  "application/wasm",
+		"pixel.png":    "image/png",
+		"notes.txt":    "", // 确实是纯文本，无法给出更具体的类型
+	}
+	for name, want := range cases {
+		content, err := os.ReadFile(filepath.Join("testdata", "mislabeled", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, declared := range []string{"text/plain; charset=utf-8", "application/octet-stream", ""} {
+			if got := sniffMimeType(declared, content); got != want {
+				t.Errorf("%s 以 %q 返回时嗅探为 %q，应为 %q", name, declared, got, want)
+			}
+		}
+	}
+}
+
+// 只有通用类型才嗅探：声明了具体类型（哪怕与内容不符）或嗅探结果与声明相同时不设置
+func TestSniffKeepsSpecificTypes(t *testing.T) {
+	js, err := os.ReadFile(filepath.Join("testdata", "mislabeled", "bundle.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, declared := range []string{"application/javascript", "text/html", "image/png"} {
+		if got := sniffMimeType(declared, js); got != "" {
+			t.Errorf("声明为 %s 时不应嗅探，得到 %q", declared, got)
+		}
+	}
+	if got := sniffMimeType("text/plain", nil); got != "" {
+		t.Errorf("空内容嗅探为 %q", got)
+	}
+}
+
+// SniffMime 关闭时不设置 DetectedMimeType；ContentMimeType 优先使用嗅探到的类型
+func TestSpiderSniff(t *testing.T) {
+	js, err := os.ReadFile(filepath.Join("testdata", "mislabeled", "bundle.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, enabled := range []bool{true, false} {
+		s := &Spider{config: &Config{SniffMime: enabled}}
+		res := &Resource{MimeType: "text/plain", Content: js}
+		s.sniff(res)
+
+		want, wantType := "", "text/plain"
+		if enabled {
+			want, wantType = "application/javascript", "application/javascript"
+		}
+		if res.DetectedMimeType != want || res.ContentMimeType() != wantType {
+			t.Errorf("SniffMime = %v: DetectedMimeType = %q，ContentMimeType = %q，应为 %q、%q",
+				enabled, res.DetectedMimeType, res.ContentMimeType(), want, wantType)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+/*! @license MIT */
+// webpack runtime
+(function (modules) {
+  var installed = {};
+  modules[0]();
+})([function () { console.log("bundle"); }]);
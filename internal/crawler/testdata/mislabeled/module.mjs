@@ -0,0 +1,2 @@
+import { h } from "./vendor.js";
+export const app = h("div");
@@ -0,0 +1,98 @@
+package crawler
+
+import (
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"spider/internal/logging"
+	"spider/internal/robots"
+)
+
+// HostThrottle 按主机限制相邻两次爬取的间隔（批量模式调度器使用）。
+// 间隔取 Config.Delay；开启 RespectCrawlDelay 时，robots.txt 中的 Crawl-delay
+// 覆盖该主机的通用间隔，并受 MaxCrawlDelay 封顶。
+type HostThrottle struct {
+	mu     sync.Mutex
+	config *Config
+	hosts  map[string]*hostState
+}
+
+type hostState struct {
+	once  sync.Once
+	delay time.Duration
+	next  time.Time // 下一次允许开始爬取的时间
+}
+
+// NewHostThrottle 创建按主机限速器
+func NewHostThrottle(config *Config) *HostThrottle {
+	return &HostThrottle{
+		config: config,
+		hosts:  make(map[string]*hostState),
+	}
+}
+
+// Wait 阻塞直到该 URL 所属主机允许开始下一次爬取
+func (t *HostThrottle) Wait(rawURL string) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return
+	}
+	host := strings.ToLower(u.Host)
+
+	t.mu.Lock()
+	state, ok := t.hosts[host]
+	if !ok {
+		state = &hostState{}
+		t.hosts[host] = state
+	}
+	t.mu.Unlock()
+
+	// 每个主机只解析一次 robots.txt，其余 goroutine 等待结果
+	state.once.Do(func() { state.delay = t.resolveDelay(rawURL, host) })
+	if state.delay <= 0 {
+		return
+	}
+
+	// 预约时间槽：锁内推进 next，锁外 sleep
+	t.mu.Lock()
+	start := time.Now()
+	if state.next.After(start) {
+		start = state.next
+	}
+	state.next = start.Add(state.delay)
+	t.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		log.Printf("限速: %s 等待 %.1fs", host, wait.Seconds())
+		time.Sleep(wait)
+	}
+}
+
+// resolveDelay 计算主机的有效间隔
+func (t *HostThrottle) resolveDelay(rawURL, host string) time.Duration {
+	delay := t.config.Delay
+	if !t.config.RespectCrawlDelay {
+		return delay
+	}
+
+	rules, err := robots.Fetch(newHTTPClient(t.config, 10*time.Second), rawURL, t.config.UserAgentFor(0))
+	if err != nil {
+		logging.Warnf("获取 %s 的 robots.txt 失败，使用默认间隔: %v", host, err)
+		return delay
+	}
+	if rules.CrawlDelay <= 0 {
+		return delay
+	}
+
+	crawlDelay := rules.CrawlDelay
+	if t.config.MaxCrawlDelay > 0 && crawlDelay > t.config.MaxCrawlDelay {
+		log.Printf("%s 的 Crawl-delay %v 超过上限，按 %v 执行", host, crawlDelay, t.config.MaxCrawlDelay)
+		crawlDelay = t.config.MaxCrawlDelay
+	} else {
+		log.Printf("%s 声明 Crawl-delay: %v", host, crawlDelay)
+	}
+	return crawlDelay
+}
@@ -0,0 +1,130 @@
+package crawler
+
+import (
+	"context"
+	"log"
+	"maps"
+	"time"
+
+	"github.com/chromedp/chromedp"
+
+	"spider/internal/logging"
+)
+
+// TimingBreakdown 来自 Performance Resource Timing API 的分阶段耗时（毫秒）。
+// 跨域资源未返回 Timing-Allow-Origin 时，浏览器把 DNS / 连接 / TTFB 等细分字段置 0。
+type TimingBreakdown struct {
+	InitiatorType   string  `json:"initiator_type,omitempty"` // script / link / css / img / fetch 等
+	DNS             float64 `json:"dns_ms"`
+	Connect         float64 `json:"connect_ms"` // 含 TLS 握手
+	TTFB            float64 `json:"ttfb_ms"`    // requestStart → responseStart
+	Transfer        float64 `json:"transfer_ms"`
+	Duration        float64 `json:"duration_ms"`
+	TransferSize    int     `json:"transfer_size"` // 0 通常表示命中缓存或跨域未授权
+	EncodedBodySize int     `json:"encoded_body_size"`
+}
+
+// resourceTimingEntry performance.getEntriesByType("resource") 中本工具用到的字段
+type resourceTimingEntry struct {
+	Name              string  `json:"name"`
+	InitiatorType     string  `json:"initiatorType"`
+	StartTime         float64 `json:"startTime"`
+	Duration          float64 `json:"duration"`
+	DomainLookupStart float64 `json:"domainLookupStart"`
+	DomainLookupEnd   float64 `json:"domainLookupEnd"`
+	ConnectStart      float64 `json:"connectStart"`
+	ConnectEnd        float64 `json:"connectEnd"`
+	RequestStart      float64 `json:"requestStart"`
+	ResponseStart     float64 `json:"responseStart"`
+	ResponseEnd       float64 `json:"responseEnd"`
+	TransferSize      int     `json:"transferSize"`
+	EncodedBodySize   int     `json:"encodedBodySize"`
+	ResponseStatus    int     `json:"responseStatus"`
+}
+
+// resourceTimingScript PerformanceResourceTiming 对象不能直接序列化，先取出需要的字段
+const resourceTimingScript = `performance.getEntriesByType("resource").map(e => ({
+	name: e.name,
+	initiatorType: e.initiatorType,
+	startTime: e.startTime,
+	duration: e.duration,
+	domainLookupStart: e.domainLookupStart,
+	domainLookupEnd: e.domainLookupEnd,
+	connectStart: e.connectStart,
+	connectEnd: e.connectEnd,
+	requestStart: e.requestStart,
+	responseStart: e.responseStart,
+	responseEnd: e.responseEnd,
+	transferSize: e.transferSize,
+	encodedBodySize: e.encodedBodySize,
+	responseStatus: e.responseStatus || 0
+}))`
+
+// breakdown 由时间戳计算各阶段耗时，缺失的阶段为 0
+func (e resourceTimingEntry) breakdown() *TimingBreakdown {
+	span := func(start, end float64) float64 {
+		if start <= 0 || end < start {
+			return 0
+		}
+		return end - start
+	}
+	return &TimingBreakdown{
+		InitiatorType:   e.InitiatorType,
+		DNS:             span(e.DomainLookupStart, e.DomainLookupEnd),
+		Connect:         span(e.ConnectStart, e.ConnectEnd),
+		TTFB:            span(e.RequestStart, e.ResponseStart),
+		Transfer:        span(e.ResponseStart, e.ResponseEnd),
+		Duration:        e.Duration,
+		TransferSize:    e.TransferSize,
+		EncodedBodySize: e.EncodedBodySize,
+	}
+}
+
+// captureResourceTiming 读取页面的 Resource Timing 条目并合并到资源表。
+// CDP 未报告的资源（如 CSS 中引用、由缓存直接提供的）按计时数据补充为只有元数据的条目。
+func (s *Spider) captureResourceTiming(ctx context.Context) {
+	var entries []resourceTimingEntry
+	if err := chromedp.Run(ctx, chromedp.Evaluate(resourceTimingScript, &entries)); err != nil {
+		logging.Warnf("读取 Resource Timing 失败: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	added := 0
+	for _, entry := range entries {
+		key := s.resourceKey(entry.Name)
+		if res, ok := s.resources[key]; ok {
+			// 同一 URL 多次加载（轮询折叠）只保留首次的计时
+			if res.TimingBreakdown == nil {
+				res.TimingBreakdown = entry.breakdown()
+			}
+			continue
+		}
+
+		res := &Resource{
+			URL:             entry.Name,
+			Method:          "GET",
+			StatusCode:      entry.ResponseStatus,
+			Headers:         make(map[string]string),
+			Context:         "page",
+			Labels:          maps.Clone(s.config.ResourceLabels),
+			TimingBreakdown: entry.breakdown(),
+		}
+		if entry.ResponseStart > entry.StartTime {
+			res.Latency = time.Duration((entry.ResponseStart - entry.StartTime) * float64(time.Millisecond))
+		}
+		if entry.Duration > 0 {
+			res.FetchDuration = time.Duration(entry.Duration * float64(time.Millisecond))
+		}
+		if s.pageURL != "" {
+			res.Pages = []string{s.pageURL}
+		}
+		s.resources[key] = res
+		added++
+	}
+	if added > 0 {
+		log.Printf("Resource Timing: 补充了 %d 个未经 CDP 报告的资源", added)
+	}
+}
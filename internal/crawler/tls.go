@@ -0,0 +1,104 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"spider/internal/logging"
+)
+
+// errPinMismatch 服务端证书链中没有任何公钥命中固定值
+var errPinMismatch = errors.New("证书公钥未命中任何固定值 (TLSPinningCerts)")
+
+// buildTLSConfig 构建备用 HTTP 客户端的 TLS 配置：追加自定义根证书、启用公钥固定。
+// 两者均未配置时返回 nil，使用 Go 默认行为。证书文件与固定值已由 Validate 检查
+func buildTLSConfig(config *Config) *tls.Config {
+	if len(config.ExtraRootCAs) == 0 && len(config.TLSPinningCerts) == 0 {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{}
+
+	if len(config.ExtraRootCAs) > 0 {
+		pool, err := loadRootCAs(config.ExtraRootCAs)
+		if err != nil {
+			logging.Warnf("%v", err)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(config.TLSPinningCerts) > 0 {
+		pins, err := parsePins(config.TLSPinningCerts)
+		if err != nil {
+			logging.Warnf("%v", err)
+		}
+		// 在常规链校验通过后执行，任一证书公钥命中即放行
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			for _, raw := range rawCerts {
+				cert, err := x509.ParseCertificate(raw)
+				if err != nil {
+					continue
+				}
+				if pins[spkiHash(cert)] {
+					return nil
+				}
+			}
+			return errPinMismatch
+		}
+	}
+
+	return tlsConfig
+}
+
+// loadRootCAs 在系统根证书之上追加 files 中的 PEM 证书；
+// 文件无法读取或不含任何有效证书时返回错误（其余文件照常追加）
+func loadRootCAs(files []string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	var errs []error
+	for _, file := range files {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("读取根证书失败 %s: %w", file, err))
+			continue
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			errs = append(errs, fmt.Errorf("%s 中没有有效的 PEM 证书", file))
+		}
+	}
+	return pool, errors.Join(errs...)
+}
+
+// parsePins 解析公钥固定值（可带 sha256/ 前缀），返回以标准 base64 编码为键的集合；
+// 不是 base64 或解码后不是 32 字节（SHA-256）的值返回错误
+func parsePins(values []string) (map[string]bool, error) {
+	pins := make(map[string]bool, len(values))
+	var errs []error
+	for _, value := range values {
+		sum, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(value), "sha256/"))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("TLSPinningCerts 固定值 %q 不是有效的 base64: %v", value, err))
+			continue
+		}
+		if len(sum) != sha256.Size {
+			errs = append(errs, fmt.Errorf("TLSPinningCerts 固定值 %q 解码后为 %d 字节，SHA-256 应为 %d 字节", value, len(sum), sha256.Size))
+			continue
+		}
+		pins[base64.StdEncoding.EncodeToString(sum)] = true
+	}
+	return pins, errors.Join(errs...)
+}
+
+// spkiHash 计算证书 DER 编码公钥（SubjectPublicKeyInfo）的 SHA-256，base64 编码
+func spkiHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
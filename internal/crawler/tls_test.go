@@ -0,0 +1,126 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 备用客户端信任 ExtraRootCAs 中的自签证书；固定值命中证书公钥时握手成功（可带 sha256/ 前缀），
+// 未命中时握手失败
+func TestTLSPinning(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // 握手失败是预期的
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pin := spkiHash(server.Certificate())
+	other := sha256.Sum256([]byte("other key"))
+
+	tests := []struct {
+		name string
+		pins []string
+		ok   bool
+	}{
+		{"未固定", nil, true},
+		{"命中", []string{pin}, true},
+		{"sha256/ 前缀", []string{"sha256/" + pin}, true},
+		{"多个固定值之一命中", []string{base64.StdEncoding.EncodeToString(other[:]), pin}, true},
+		{"未命中", []string{base64.StdEncoding.EncodeToString(other[:])}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.ExtraRootCAs = []string{caFile}
+			config.TLSPinningCerts = tt.pins
+			s, err := New(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := s.httpClient.Get(server.URL)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("握手失败: %v", err)
+				}
+				resp.Body.Close()
+				return
+			}
+			if err == nil {
+				resp.Body.Close()
+				t.Fatal("固定值未命中时握手应失败")
+			}
+			if !errors.Is(err, errPinMismatch) {
+				t.Errorf("错误应为 errPinMismatch，实际 %v", err)
+			}
+		})
+	}
+
+	// 不信任自签证书时，即使固定值命中也在链校验阶段失败
+	config := DefaultConfig()
+	config.TLSPinningCerts = []string{pin}
+	s, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := s.httpClient.Get(server.URL); err == nil {
+		resp.Body.Close()
+		t.Error("未信任的证书不应通过固定值放行")
+	}
+}
+
+// 无法解析的根证书文件、不是 base64 或长度不对的固定值在 Validate 阶段报错
+func TestValidateTLS(t *testing.T) {
+	dir := t.TempDir()
+	badCA := filepath.Join(dir, "bad.pem")
+	if err := os.WriteFile(badCA, []byte("not a certificate\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	short := sha256.Sum224([]byte("key"))
+
+	tests := []struct {
+		name    string
+		cas     []string
+		pins    []string
+		wantErr string
+	}{
+		{"证书文件不存在", []string{filepath.Join(dir, "missing.pem")}, nil, "证书文件不存在"},
+		{"没有有效的 PEM", []string{badCA}, nil, "没有有效的 PEM 证书"},
+		{"不是 base64", nil, []string{"sha256/not base64!"}, "不是有效的 base64"},
+		{"长度不对", nil, []string{base64.StdEncoding.EncodeToString(short[:])}, "解码后为 28 字节"},
+		{"十六进制", nil, []string{strings.Repeat("ab", 32)}, "解码后为 48 字节"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.ExtraRootCAs = tt.cas
+			config.TLSPinningCerts = tt.pins
+			err := config.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v，应包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	sum := sha256.Sum256([]byte("key"))
+	config := DefaultConfig()
+	config.TLSPinningCerts = []string{" sha256/" + base64.StdEncoding.EncodeToString(sum[:]) + " "}
+	if err := config.Validate(); err != nil {
+		t.Errorf("有效的固定值: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/emulation"
+)
+
+// realUATemplates 各平台桌面版 Chrome 的 User-Agent，%s 为主版本号。
+// Chrome 自 101 起 UA 中只保留主版本（其余为 0.0.0），平台部分也已冻结
+var realUATemplates = map[string]string{
+	"windows": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%s.0.0.0 Safari/537.36",
+	"darwin":  "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%s.0.0.0 Safari/537.36",
+	"linux":   "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%s.0.0.0 Safari/537.36",
+}
+
+// realUAPlatforms Sec-CH-UA-Platform 和 Sec-CH-UA-Platform-Version，与 realUATemplates 对应
+var realUAPlatforms = map[string][2]string{
+	"windows": {"Windows", "10.0.0"},
+	"darwin":  {"macOS", "10.15.7"},
+	"linux":   {"Linux", ""},
+}
+
+// realUABrand Sec-CH-UA 中的 GREASE 品牌，与 Chrome 自身发送的格式一致
+const realUABrand = "Not/A)Brand"
+
+// realUserAgent 按浏览器的 Product（如 HeadlessChrome/126.0.6478.126）生成当前系统上
+// 普通桌面版 Chrome 的 User-Agent 和匹配的 Client Hints；版本无法识别时返回空
+func realUserAgent(product, goos, goarch string) (string, *emulation.UserAgentMetadata) {
+	_, full, ok := strings.Cut(product, "/")
+	if !ok {
+		return "", nil
+	}
+	major, _, _ := strings.Cut(full, ".")
+	if major == "" || strings.Trim(major, "0123456789") != "" {
+		return "", nil
+	}
+	template, ok := realUATemplates[goos]
+	if !ok {
+		goos = "linux"
+		template = realUATemplates[goos]
+	}
+
+	arch := "x86"
+	if strings.HasPrefix(goarch, "arm") {
+		arch = "arm"
+	}
+	bitness := "64"
+	if goarch == "386" || goarch == "arm" {
+		bitness = "32"
+	}
+	metadata := &emulation.UserAgentMetadata{
+		Brands: []*emulation.UserAgentBrandVersion{
+			{Brand: realUABrand, Version: "8"},
+			{Brand: "Chromium", Version: major},
+			{Brand: "Google Chrome", Version: major},
+		},
+		FullVersionList: []*emulation.UserAgentBrandVersion{
+			{Brand: realUABrand, Version: "8.0.0.0"},
+			{Brand: "Chromium", Version: full},
+			{Brand: "Google Chrome", Version: full},
+		},
+		Platform:        realUAPlatforms[goos][0],
+		PlatformVersion: realUAPlatforms[goos][1],
+		Architecture:    arch,
+		Bitness:         bitness,
+	}
+	return fmt.Sprintf(template, major), metadata
+}
+
+// overrideRealUserAgent 未指定 User-Agent 且开启 RealUserAgent 时，按实际启动的 Chrome 版本
+// 覆盖默认 UA（无头模式下带 HeadlessChrome），并同步 navigator.userAgentData 和 Sec-CH-UA 系列请求头，
+// 避免 UA 与 Client Hints 不一致。生成的 UA 也用于备用下载
+func (s *Spider) overrideRealUserAgent(ctx context.Context) error {
+	_, product, _, _, _, err := browser.GetVersion().Do(ctx)
+	if err != nil {
+		return fmt.Errorf("读取浏览器版本失败: %w", err)
+	}
+	override := realUAOverride(product, runtime.GOOS, runtime.GOARCH)
+	if override == nil {
+		return nil
+	}
+	s.mu.Lock()
+	s.realUA = override.UserAgent
+	s.mu.Unlock()
+	return override.Do(ctx)
+}
+
+// realUAOverride 按 realUserAgent 生成 emulation.SetUserAgentOverride 的参数，版本无法识别时返回 nil
+func realUAOverride(product, goos, goarch string) *emulation.SetUserAgentOverrideParams {
+	ua, metadata := realUserAgent(product, goos, goarch)
+	if ua == "" {
+		return nil
+	}
+	return emulation.SetUserAgentOverride(ua).WithUserAgentMetadata(metadata)
+}
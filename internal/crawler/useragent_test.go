@@ -0,0 +1,110 @@
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRealUserAgent(t *testing.T) {
+	cases := []struct {
+		product, goos, goarch string
+		ua                    string
+		platform, version     string
+		arch, bitness         string
+	}{
+		{"HeadlessChrome/126.0.6478.126", "windows", "amd64",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			"Windows", "10.0.0", "x86", "64"},
+		{"Chrome/131.0.6778.85", "darwin", "arm64",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
+			"macOS", "10.15.7", "arm", "64"},
+		{"HeadlessChrome/99.0.4844.51", "linux", "386",
+			"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.0.0 Safari/537.36",
+			"Linux", "", "x86", "32"},
+		// 没有模板的系统按 Linux 处理，UA 与 Client Hints 仍一致
+		{"HeadlessChrome/120.0.6099.0", "freebsd", "amd64",
+			"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			"Linux", "", "x86", "64"},
+	}
+	for _, c := range cases {
+		ua, md := realUserAgent(c.product, c.goos, c.goarch)
+		if ua != c.ua {
+			t.Errorf("%s on %s: UA = %q，应为 %q", c.product, c.goos, ua, c.ua)
+			continue
+		}
+		if strings.Contains(ua, "Headless") {
+			t.Errorf("UA 中仍含 Headless: %s", ua)
+		}
+		if md.Platform != c.platform || md.PlatformVersion != c.version || md.Architecture != c.arch || md.Bitness != c.bitness || md.Mobile {
+			t.Errorf("%s on %s/%s: Client Hints 为 %+v", c.product, c.goos, c.goarch, md)
+		}
+		_, full, _ := strings.Cut(c.product, "/")
+		major, _, _ := strings.Cut(full, ".")
+		for _, b := range md.Brands {
+			if b.Brand != realUABrand && b.Version != major {
+				t.Errorf("Sec-CH-UA 中 %s 的版本为 %s，应与 UA 的主版本 %s 一致", b.Brand, b.Version, major)
+			}
+		}
+		for _, b := range md.FullVersionList {
+			if b.Brand != realUABrand && b.Version != full {
+				t.Errorf("完整版本列表中 %s 的版本为 %s，应为 %s", b.Brand, b.Version, full)
+			}
+		}
+	}
+
+	for _, product := range []string{"", "HeadlessChrome", "HeadlessChrome/", "Chrome/abc.1", "Chrome/.1.2"} {
+		if ua, md := realUserAgent(product, "linux", "amd64"); ua != "" || md != nil {
+			t.Errorf("无法识别的 Product %q 生成了 %q", product, ua)
+		}
+	}
+}
+
+// 发给浏览器的 Emulation.setUserAgentOverride 参数：UA 与 userAgentMetadata 中的品牌版本、平台互相一致
+func TestRealUAOverrideParams(t *testing.T) {
+	if realUAOverride("unknown", "linux", "amd64") != nil {
+		t.Error("版本无法识别时不应覆盖 UA")
+	}
+	params := realUAOverride("HeadlessChrome/126.0.6478.126", "windows", "amd64")
+	data, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"userAgent":"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",` +
+		`"userAgentMetadata":{"brands":[{"brand":"Not/A)Brand","version":"8"},{"brand":"Chromium","version":"126"},{"brand":"Google Chrome","version":"126"}],` +
+		`"fullVersionList":[{"brand":"Not/A)Brand","version":"8.0.0.0"},{"brand":"Chromium","version":"126.0.6478.126"},{"brand":"Google Chrome","version":"126.0.6478.126"}],` +
+		`"platform":"Windows","platformVersion":"10.0.0","architecture":"x86","model":"","mobile":false,"bitness":"64","wow64":false}}`
+	if string(data) != want {
+		t.Errorf("setUserAgentOverride 参数为\n%s\n应为\n%s", data, want)
+	}
+}
+
+// 备用下载使用生成的 UA；显式指定的 UserAgent 优先
+func TestFallbackDownloadUsesRealUA(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.UserAgent()))
+	}))
+	defer server.Close()
+
+	s, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.realUA = "Mozilla/5.0 (X11; Linux x86_64) Chrome/126.0.0.0 Safari/537.36"
+	if got := string(s.downloadResource(server.URL)); got != s.realUA {
+		t.Errorf("备用下载的 UA 为 %q，应为 %q", got, s.realUA)
+	}
+
+	config := DefaultConfig()
+	config.UserAgent = "custom/1.0"
+	s, err = New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.realUA = "ignored"
+	if got := string(s.downloadResource(server.URL)); got != "custom/1.0" {
+		t.Errorf("指定 UserAgent 时备用下载的 UA 为 %q", got)
+	}
+}
@@ -0,0 +1,214 @@
+// Package crawlertest 集成测试辅助：在真实 Chrome 中跑完整的 爬取 → source map 提取 → 存储 流程，
+// 并读回 resources.json 供断言。未安装 Chrome 时跳过测试。
+//
+// 典型用法：
+//
+//	site := testsite.New()
+//	defer site.Close()
+//	res := crawlertest.Run(t, site.Resolve("/"), nil)
+//	res.RequirePaths(t, site.URL, testsite.Paths...)
+package crawlertest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/pipeline"
+	"spider/internal/sourcemap"
+	"spider/internal/storage"
+)
+
+// FindChrome 查找 Chrome 可执行文件：优先 CHROME_PATH 环境变量，其次 crawler.FindChrome 的 PATH 和各平台默认安装位置
+func FindChrome() (string, bool) {
+	if p := os.Getenv("CHROME_PATH"); p != "" {
+		if _, err := os.Stat(p); err == nil {
+			return p, true
+		}
+	}
+	p, err := crawler.FindChrome("")
+	return p, err == nil
+}
+
+// RequireChrome 返回 Chrome 路径；未安装时跳过当前测试
+func RequireChrome(tb testing.TB) string {
+	tb.Helper()
+	path, ok := FindChrome()
+	if !ok {
+		tb.Skip("未找到 Chrome / Chromium（可用 CHROME_PATH 指定），跳过集成测试")
+	}
+	return path
+}
+
+// Config 适合测试的默认配置：无头、较短的超时、不重试
+func Config() *crawler.Config {
+	config := crawler.DefaultConfig()
+	config.Timeout = 20 * time.Second
+	config.IdleTimeout = 3 * time.Second
+	config.MaxRetry = 0
+	return config
+}
+
+// RecordHAR 不经浏览器直接请求 urls，把响应写成 HAR 文件并返回其路径（位于 tb.TempDir）；
+// 配合 ReplayConfig 可在没有 Chrome 的环境中驱动 爬取 → 提取 → 存储 的完整流程
+func RecordHAR(tb testing.TB, urls ...string) string {
+	tb.Helper()
+	resources := make(map[string]*crawler.Resource, len(urls))
+	for _, u := range urls {
+		resp, err := http.Get(u)
+		if err != nil {
+			tb.Fatalf("请求 %s 失败: %v", u, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			tb.Fatalf("读取 %s 失败: %v", u, err)
+		}
+		headers := make(map[string]string, len(resp.Header))
+		for k := range resp.Header {
+			headers[k] = resp.Header.Get(k)
+		}
+		resources[u] = &crawler.Resource{
+			URL:          u,
+			Method:       http.MethodGet,
+			StatusCode:   resp.StatusCode,
+			StatusText:   http.StatusText(resp.StatusCode),
+			Protocol:     "http/1.1",
+			MimeType:     resp.Header.Get("Content-Type"),
+			Content:      body,
+			Headers:      headers,
+			ResponseTime: time.Now(),
+		}
+	}
+	return WriteHAR(tb, resources)
+}
+
+// WriteHAR 把 resources 写成 HAR 文件并返回其路径（位于 tb.TempDir）
+func WriteHAR(tb testing.TB, resources map[string]*crawler.Resource) string {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "recorded.har")
+	f, err := os.Create(path)
+	if err != nil {
+		tb.Fatalf("创建 HAR 文件失败: %v", err)
+	}
+	defer f.Close()
+	if err := storage.ExportHAR(resources, f); err != nil {
+		tb.Fatalf("写入 HAR 文件失败: %v", err)
+	}
+	return path
+}
+
+// ReplayConfig 回放 harFile 的测试配置（HARReplayMode），不需要 Chrome
+func ReplayConfig(harFile string) *crawler.Config {
+	config := Config()
+	config.HARReplayMode = true
+	config.HARFile = harFile
+	return config
+}
+
+// Result 一次完整流程的产物
+type Result struct {
+	Dir       string                       // 输出目录（tb.TempDir，测试结束自动清理）
+	Resources map[string]*crawler.Resource // 爬取结果加上 source map 提取出的源文件
+	Crawl     *crawler.CrawlResult
+	Index     []storage.IndexEntry // 从 Dir 读回的 resources.json
+}
+
+// Run 用 config（nil 时使用 Config()）爬取 targetURL，提取 source map，
+// 以扁平布局保存到临时目录并写入 resources.json，任何一步失败都会终止测试
+func Run(tb testing.TB, targetURL string, config *crawler.Config) *Result {
+	tb.Helper()
+	return RunWith(tb, targetURL, config)
+}
+
+// RunWith 与 Run 相同，额外的 stages 在 source map 提取之后、保存之前依次执行，
+// 可用于在测试中插入自定义处理（过滤、改写资源等）；任一阶段出错都会终止测试
+func RunWith(tb testing.TB, targetURL string, config *crawler.Config, stages ...pipeline.Stage) *Result {
+	tb.Helper()
+	if config == nil {
+		config = Config()
+	}
+	if config.ChromePath == "" && !config.HARReplayMode {
+		config.ChromePath = RequireChrome(tb)
+	}
+
+	spider, err := crawler.New(config)
+	if err != nil {
+		tb.Fatalf("配置无效: %v", err)
+	}
+	crawl, err := spider.Run(context.Background(), targetURL)
+	if err != nil {
+		tb.Fatalf("爬取 %s 失败: %v", targetURL, err)
+	}
+
+	extractor := sourcemap.New(targetURL,
+		sourcemap.WithMaxSourceMapSize(config.MaxSourceMapSize),
+		sourcemap.WithMapHostAllowlist(config.MapHostAllowlist),
+		sourcemap.WithSameOriginMapsOnly(config.SameOriginMapsOnly),
+	)
+	dir := tb.TempDir()
+	store := storage.NewFlat(dir)
+
+	var p pipeline.Pipeline
+	p.Add("sourcemaps", pipeline.ExtractSourceMaps{Batch: sourcemap.NewBatchExtractor(extractor, 0, 1), Workers: 1}, pipeline.Abort)
+	for i, stage := range stages {
+		p.Add(fmt.Sprintf("custom-%d", i+1), stage, pipeline.Abort)
+	}
+	p.Add("save", pipeline.Save{Store: store}, pipeline.Abort)
+	p.Add("index", pipeline.StageFunc(func(_ context.Context, set *pipeline.ResourceSet) error {
+		return store.WriteIndex(set.Resources)
+	}), pipeline.Abort)
+
+	set := &pipeline.ResourceSet{TargetURL: targetURL, OutputDir: dir, Resources: crawl.Resources}
+	if err := p.Run(context.Background(), set); err != nil {
+		tb.Fatalf("处理资源失败: %v", err)
+	}
+	index, err := storage.ReadIndex(dir)
+	if err != nil {
+		tb.Fatalf("读取 resources.json 失败: %v", err)
+	}
+
+	return &Result{Dir: dir, Resources: set.Resources, Crawl: crawl, Index: index}
+}
+
+// Entry 按 URL 查找 resources.json 中的记录
+func (r *Result) Entry(rawURL string) (storage.IndexEntry, bool) {
+	for _, e := range r.Index {
+		if e.URL == rawURL {
+			return e, true
+		}
+	}
+	return storage.IndexEntry{}, false
+}
+
+// RequirePaths 断言 resources.json 中包含 baseURL 下的每个路径，且已保存了内容
+func (r *Result) RequirePaths(tb testing.TB, baseURL string, paths ...string) {
+	tb.Helper()
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		tb.Fatalf("无效的 baseURL %q: %v", baseURL, err)
+	}
+	for _, p := range paths {
+		ref, err := url.Parse(p)
+		if err != nil {
+			tb.Errorf("无效的路径 %q: %v", p, err)
+			continue
+		}
+		want := base.ResolveReference(ref).String()
+		e, ok := r.Entry(want)
+		if !ok {
+			tb.Errorf("resources.json 中缺少 %s", want)
+			continue
+		}
+		if e.Path == "" {
+			tb.Errorf("%s 没有保存内容（status %d）", want, e.Status)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+// Package frontier 递归爬取的 URL 边界（待爬 / 进行中 / 已完成），
+// 以追加写入的 JSON Lines 日志持久化到输出目录：每个检查点只追加上次以来变化的条目，
+// 开销与变化量而不是已发现的 URL 总数成正比，进程中断后可从日志继续。
+package frontier
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"spider/internal/atomicfile"
+)
+
+// 出队策略
+const (
+	BFS = "bfs" // 广度优先：先爬浅层页面
+	DFS = "dfs" // 深度优先：沿最新发现的链接向下
+)
+
+// FileName 输出目录中的日志文件名
+const FileName = "frontier.jsonl"
+
+// 条目状态
+const (
+	StatePending    = "pending"
+	StateInProgress = "in_progress"
+	StateDone       = "done"
+	StateFailed     = "failed"
+)
+
+// Item 一个待爬或已爬的 URL
+type Item struct {
+	URL    string `json:"url"`
+	Depth  int    `json:"depth"`
+	Source string `json:"source,omitempty"` // 发现该 URL 的页面，种子 URL 为空
+	State  string `json:"state"`
+	Error  string `json:"error,omitempty"`
+	Seq    int    `json:"seq"` // 发现顺序，决定 BFS / DFS 出队次序
+}
+
+// header 日志的第一行；其后每行是一个 Item 的最新状态，同一 URL 以最后一行为准
+type header struct {
+	Strategy string `json:"strategy"`
+}
+
+// Frontier 并发安全的 URL 边界。Next 会阻塞到有 URL 可爬，
+// 或所有 URL 都已完成（不再可能发现新链接）时返回 false。
+type Frontier struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	path     string
+	strategy string
+	maxPages int // 最多出队的页面数，<=0 表示不限
+
+	items      map[string]*Item
+	pending    []*Item // 按 Seq 升序
+	inProgress int
+	dispatched int // 已出队（进行中 + 已完成 + 失败）的页面数
+	seq        int
+
+	saveMu    sync.Mutex // 串行化检查点，保证日志中的记录按变化顺序追加
+	dirty     []Item     // 上次检查点以来新增或改变状态的条目（进行中不记录，恢复时视为待爬）
+	added     int        // dirty 中新发现的 URL 数，非零时 Done 立即写检查点
+	written   bool       // 日志文件（含第一行）已存在
+	interval  time.Duration
+	lastSaved time.Time
+}
+
+// Open 打开 path 处的日志；文件不存在时创建空边界。
+// 日志记录的策略与 strategy 不同时报错（换策略续爬会打乱出队顺序）；
+// “进行中”的条目视为被中断，恢复为待爬；崩溃时写了一半的最后一行被丢弃。
+// 打开已有日志时会将其压缩为每个 URL 一行
+func Open(path, strategy string, maxPages int, interval time.Duration) (*Frontier, error) {
+	if strategy == "" {
+		strategy = BFS
+	}
+	if strategy != BFS && strategy != DFS {
+		return nil, fmt.Errorf("不支持的策略 %q：仅支持 bfs 或 dfs", strategy)
+	}
+
+	f := &Frontier{
+		path:      path,
+		strategy:  strategy,
+		maxPages:  maxPages,
+		items:     make(map[string]*Item),
+		interval:  interval,
+		lastSaved: time.Now(),
+	}
+	f.cond = sync.NewCond(&f.mu)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	if err := f.replay(data); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", path, err)
+	}
+	if err := f.compact(); err != nil {
+		return nil, fmt.Errorf("压缩 %s 失败: %w", path, err)
+	}
+	return f, nil
+}
+
+// replay 按日志重建各条目的最新状态
+func (f *Frontier) replay(data []byte) error {
+	// 没有以换行结尾的最后一行是崩溃时写了一半的记录
+	if i := bytes.LastIndexByte(data, '\n'); i < len(data)-1 {
+		data = data[:i+1]
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	var h header
+	if err := json.Unmarshal(lines[0], &h); err != nil {
+		return fmt.Errorf("第 1 行: %w", err)
+	}
+	if h.Strategy != f.strategy {
+		return fmt.Errorf("上次使用 -strategy %s，与本次的 %s 不同；请使用相同的策略续爬，或去掉 -resume 重新开始", h.Strategy, f.strategy)
+	}
+	for n, line := range lines[1:] {
+		item := new(Item)
+		if err := json.Unmarshal(line, item); err != nil {
+			return fmt.Errorf("第 %d 行: %w", n+2, err)
+		}
+		if item.State == StateInProgress {
+			item.State = StatePending
+		}
+		f.items[item.URL] = item
+		f.seq = max(f.seq, item.Seq+1)
+	}
+	for _, item := range f.items {
+		if item.State == StatePending {
+			f.pending = append(f.pending, item)
+		} else {
+			f.dispatched++
+		}
+	}
+	sortBySeq(f.pending)
+	return nil
+}
+
+// compact 把日志原子地重写为第一行加每个 URL 一行
+func (f *Frontier) compact() error {
+	items := make([]*Item, 0, len(f.items))
+	for _, item := range f.items {
+		items = append(items, item)
+	}
+	sortBySeq(items)
+	err := atomicfile.Write(f.path, 0644, func(w io.Writer) error {
+		bw := bufio.NewWriter(w)
+		enc := json.NewEncoder(bw)
+		if err := enc.Encode(header{Strategy: f.strategy}); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	})
+	if err == nil {
+		f.written = true
+	}
+	return err
+}
+
+// Add 加入新发现的 URL，已存在（任意状态）返回 false
+func (f *Frontier) Add(rawURL string, depth int, source string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.items[rawURL]; exists {
+		return false
+	}
+	item := &Item{URL: rawURL, Depth: depth, Source: source, State: StatePending, Seq: f.seq}
+	f.seq++
+	f.items[rawURL] = item
+	f.pending = append(f.pending, item)
+	f.dirty = append(f.dirty, *item)
+	f.added++
+	f.cond.Broadcast()
+	return true
+}
+
+// Next 按策略取出下一个待爬 URL。没有待爬 URL 但仍有进行中的页面时阻塞等待；
+// 全部完成或达到 maxPages 时返回 false。
+func (f *Frontier) Next() (Item, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for {
+		if f.maxPages > 0 && f.dispatched >= f.maxPages {
+			return Item{}, false
+		}
+		if len(f.pending) > 0 {
+			var item *Item
+			if f.strategy == DFS {
+				item = f.pending[len(f.pending)-1]
+				f.pending = f.pending[:len(f.pending)-1]
+			} else {
+				item = f.pending[0]
+				f.pending = f.pending[1:]
+			}
+			item.State = StateInProgress
+			f.inProgress++
+			f.dispatched++
+			return *item, true
+		}
+		if f.inProgress == 0 {
+			return Item{}, false
+		}
+		f.cond.Wait()
+	}
+}
+
+// Done 标记 URL 完成（err 非 nil 时记为失败）。此前新发现了 URL（通常是该页面的链接）
+// 或到达检查点间隔时写检查点，递归中途发现的链接不会因进程中断而丢失
+func (f *Frontier) Done(rawURL string, err error) error {
+	f.mu.Lock()
+	if item, ok := f.items[rawURL]; ok && item.State == StateInProgress {
+		item.State = StateDone
+		if err != nil {
+			item.State = StateFailed
+			item.Error = err.Error()
+		}
+		f.inProgress--
+		f.dirty = append(f.dirty, *item)
+	}
+	f.cond.Broadcast()
+
+	due := f.added > 0 || time.Since(f.lastSaved) >= f.interval
+	f.mu.Unlock()
+
+	if due {
+		return f.Checkpoint()
+	}
+	return nil
+}
+
+// Checkpoint 把上次检查点以来变化的条目追加到日志并 fsync；日志不存在时先原子地创建（含第一行）。
+// 写入失败时这些条目留待下次检查点重试
+func (f *Frontier) Checkpoint() error {
+	f.saveMu.Lock()
+	defer f.saveMu.Unlock()
+
+	f.mu.Lock()
+	records, added, written := f.dirty, f.added, f.written
+	f.dirty, f.added = nil, 0
+	f.lastSaved = time.Now()
+	f.mu.Unlock()
+
+	err := f.appendRecords(records, written)
+	f.mu.Lock()
+	if err != nil {
+		f.dirty = append(records, f.dirty...)
+		f.added += added
+	} else {
+		f.written = true
+	}
+	f.mu.Unlock()
+	return err
+}
+
+// appendRecords 追加 records；written 为 false 时连同第一行原子地创建日志
+func (f *Frontier) appendRecords(records []Item, written bool) error {
+	if written && len(records) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if !written {
+		if err := enc.Encode(header{Strategy: f.strategy}); err != nil {
+			return err
+		}
+	}
+	for i := range records {
+		if err := enc.Encode(&records[i]); err != nil {
+			return err
+		}
+	}
+
+	if !written {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+			return err
+		}
+		return atomicfile.WriteFile(f.path, buf.Bytes(), 0644)
+	}
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		// 去掉写了一半的记录，下次追加不会接在残缺的行后面
+		file.Truncate(info.Size())
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Stats 各状态的条目数
+func (f *Frontier) Stats() map[string]int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stats := make(map[string]int)
+	for _, item := range f.items {
+		stats[item.State]++
+	}
+	return stats
+}
+
+// sortBySeq 按发现顺序排序
+func sortBySeq(items []*Item) {
+	sort.Slice(items, func(i, j int) bool { return items[i].Seq < items[j].Seq })
+}
@@ -0,0 +1,297 @@
+package frontier
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openTemp(t *testing.T, strategy string, maxPages int) (*Frontier, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	f, err := Open(path, strategy, maxPages, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, path
+}
+
+// drain 依次取出并完成所有 URL，返回出队顺序
+func drain(t *testing.T, f *Frontier) []string {
+	t.Helper()
+	var order []string
+	for {
+		item, ok := f.Next()
+		if !ok {
+			return order
+		}
+		order = append(order, item.URL)
+		if err := f.Done(item.URL, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDedup(t *testing.T) {
+	f, _ := openTemp(t, BFS, 0)
+	if !f.Add("https://a.com/", 0, "") || f.Add("https://a.com/", 1, "https://a.com/x") {
+		t.Fatal("重复的 URL 应只加入一次")
+	}
+	item, _ := f.Next()
+	f.Done(item.URL, nil)
+	if f.Add("https://a.com/", 2, "") {
+		t.Error("已完成的 URL 不应再次加入")
+	}
+	if item.Depth != 0 || item.Source != "" {
+		t.Errorf("保留的应为首次加入的记录，实际为 %+v", item)
+	}
+}
+
+func TestOrdering(t *testing.T) {
+	for _, tt := range []struct {
+		strategy string
+		want     []string
+	}{
+		{BFS, []string{"a", "b", "c", "d"}},
+		{DFS, []string{"d", "c", "b", "a"}},
+	} {
+		f, _ := openTemp(t, tt.strategy, 0)
+		for _, u := range []string{"a", "b", "c", "d"} {
+			f.Add(u, 1, "")
+		}
+		if got := drain(t, f); !slices.Equal(got, tt.want) {
+			t.Errorf("%s 出队顺序 %v，应为 %v", tt.strategy, got, tt.want)
+		}
+	}
+
+	if _, err := Open(filepath.Join(t.TempDir(), FileName), "random", 0, 0); err == nil {
+		t.Error("不支持的策略应报错")
+	}
+}
+
+func TestMaxPages(t *testing.T) {
+	f, path := openTemp(t, BFS, 2)
+	for i := range 5 {
+		f.Add(fmt.Sprint(i), 0, "")
+	}
+	if got := drain(t, f); len(got) != 2 {
+		t.Fatalf("maxPages 2 时出队 %d 个", len(got))
+	}
+	f.Checkpoint()
+
+	// 恢复后已出队的页面计入上限
+	f, err := Open(path, BFS, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := drain(t, f); !slices.Equal(got, []string{"2"}) {
+		t.Errorf("恢复后出队 %v，应只剩 1 个名额", got)
+	}
+}
+
+// site 合成站点：页面 i 链接到 2i+1 和 2i+2，共 n 个页面
+func siteLinks(page, n int) []string {
+	var links []string
+	for _, c := range []int{2*page + 1, 2*page + 2} {
+		if c < n {
+			links = append(links, fmt.Sprint(c))
+		}
+	}
+	return links
+}
+
+// 爬取到一半时进程被杀（一个页面已出队但未完成，内存中的状态全部丢失），
+// 从快照恢复后继续：每个页面都恰好完成一次，被中断的页面重新爬取，不会遗漏递归中途发现的链接
+func TestKillResumeRoundTrip(t *testing.T) {
+	const pages = 31
+	for _, strategy := range []string{BFS, DFS} {
+		t.Run(strategy, func(t *testing.T) {
+			f, path := openTemp(t, strategy, 0)
+			f.Add("0", 0, "")
+
+			crawled := make(map[string]int)
+			crawl := func(f *Frontier, abortAfter int) (aborted string) {
+				for done := 0; ; done++ {
+					item, ok := f.Next()
+					if !ok {
+						return ""
+					}
+					crawled[item.URL]++
+					if done == abortAfter {
+						return item.URL // 注入的中断：不调用 Done，也不再写快照
+					}
+					var page int
+					fmt.Sscan(item.URL, &page)
+					for _, link := range siteLinks(page, pages) {
+						f.Add(link, item.Depth+1, item.URL)
+					}
+					if err := f.Done(item.URL, nil); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			interrupted := crawl(f, 9)
+			if interrupted == "" {
+				t.Fatal("中断前已爬完")
+			}
+
+			resumed, err := Open(path, strategy, 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stats := resumed.Stats()
+			if stats[StateDone] != 9 || stats[StateInProgress] != 0 {
+				t.Errorf("恢复时的状态 %v，应有 9 个已完成且没有进行中的条目", stats)
+			}
+			if crawl(resumed, -1) != "" {
+				t.Fatal("恢复后意外中断")
+			}
+
+			if stats := resumed.Stats(); stats[StateDone] != pages || stats[StatePending] != 0 {
+				t.Errorf("恢复后完成状态 %v，应全部 %d 个已完成", stats, pages)
+			}
+			for i := range pages {
+				u := fmt.Sprint(i)
+				want := 1
+				if u == interrupted {
+					want = 2
+				}
+				if crawled[u] != want {
+					t.Errorf("页面 %s 爬取了 %d 次，应为 %d", u, crawled[u], want)
+				}
+			}
+		})
+	}
+}
+
+// 日志按行追加：崩溃时写了一半的最后一行被丢弃，中间损坏的行报错而非当作空边界；
+// 首次创建日志时留下的临时文件不影响恢复
+func TestCrashDuringCheckpoint(t *testing.T) {
+	f, path := openTemp(t, BFS, 0)
+	f.Add("a", 0, "")
+	f.Add("b", 0, "")
+	item, _ := f.Next()
+	f.Done(item.URL, errors.New("boom"))
+	if err := f.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "."+FileName+".tmp123"), []byte(`{"strategy":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// 追加 c 时崩溃，只写出了半行
+	appendFile(t, path, `{"url":"c","depth":0,"state":"pen`)
+
+	resumed, err := Open(path, BFS, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := resumed.Stats(); stats[StateFailed] != 1 || stats[StatePending] != 1 || len(stats) != 2 {
+		t.Errorf("恢复后状态 %v，应为 1 个失败、1 个待爬", stats)
+	}
+	data, _ := os.ReadFile(path)
+	if want := "{\"strategy\":\"bfs\"}\n" +
+		"{\"url\":\"a\",\"depth\":0,\"state\":\"failed\",\"error\":\"boom\",\"seq\":0}\n" +
+		"{\"url\":\"b\",\"depth\":0,\"state\":\"pending\",\"seq\":1}\n"; string(data) != want {
+		t.Errorf("打开后应压缩为每个 URL 一行并去掉残缺的记录:\n%s", data)
+	}
+
+	appendFile(t, path, "{\"url\":\n{\"url\":\"d\",\"depth\":0,\"state\":\"pending\",\"seq\":5}\n")
+	if _, err := Open(path, BFS, 0, 0); err == nil {
+		t.Error("中间损坏的日志应报错")
+	}
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 每个检查点只追加变化的条目：新发现的链接在页面完成时立即写入（即使未到检查点间隔），
+// 日志行数与变化次数成正比
+func TestCheckpointAppendsChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	f, err := Open(path, BFS, 0, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := func() int {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return 0
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bytes.Count(data, []byte("\n"))
+	}
+
+	f.Add("0", 0, "")
+	item, _ := f.Next()
+	f.Add("1", 1, "0")
+	f.Add("2", 1, "0")
+	if lines() != 0 {
+		t.Fatal("Done 之前不应写入")
+	}
+	if err := f.Done(item.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 第一行 + 0、1、2 的加入 + 0 的完成
+	if n := lines(); n != 5 {
+		t.Fatalf("发现新链接后 Done 应立即写检查点，日志有 %d 行", n)
+	}
+
+	// 没有新链接且未到间隔时不写
+	item, _ = f.Next()
+	if err := f.Done(item.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := lines(); n != 5 {
+		t.Errorf("未到检查点间隔时写入了日志，共 %d 行", n)
+	}
+	if err := f.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if n := lines(); n != 6 {
+		t.Errorf("检查点应只追加 1 条完成记录，日志有 %d 行", n)
+	}
+
+	resumed, err := Open(path, BFS, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := resumed.Stats(); stats[StateDone] != 2 || stats[StatePending] != 1 {
+		t.Errorf("恢复后状态 %v", stats)
+	}
+}
+
+// 续爬时策略必须与日志一致，否则出队顺序会被悄悄改变
+func TestResumeStrategyMismatch(t *testing.T) {
+	f, path := openTemp(t, DFS, 0)
+	f.Add("a", 0, "")
+	if err := f.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path, BFS, 0, 0); err == nil || !strings.Contains(err.Error(), "-strategy dfs") {
+		t.Errorf("换用 bfs 续爬 dfs 的日志应报错，实际 %v", err)
+	}
+	if _, err := Open(path, DFS, 0, 0); err != nil {
+		t.Errorf("相同策略续爬: %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+// Package ignorelist 提供常见统计 / 广告 / 社交追踪地址的预设清单（Config.ResourceIgnoreList）。
+// 与 -no-tracking 的拦截不同，命中的请求照常发出、脚本照常执行，只是抓到后不保存。
+package ignorelist
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// 预设清单名
+const (
+	Analytics   = "analytics"   // 统计、产品分析与会话录制
+	Advertising = "advertising" // 广告投放与效果测量
+	Social      = "social"      // 社交平台像素与分享组件
+)
+
+// presets 各预设的条目：不含 / 和 * 的为域名（含子域名），其余为匹配完整 URL 的 * 通配模式。
+// 新增的服务直接追加到对应清单，发布新版本即随之更新
+var presets = map[string][]string{
+	Analytics: {
+		"google-analytics.com",
+		"analytics.google.com",
+		"googletagmanager.com",
+		"hotjar.com",
+		"hotjar.io",
+		"clarity.ms",
+		"fullstory.com",
+		"mixpanel.com",
+		"amplitude.com",
+		"cdn.segment.com",
+		"api.segment.io",
+		"heap.io",
+		"heapanalytics.com",
+		"mouseflow.com",
+		"plausible.io",
+		"js-agent.newrelic.com",
+		"bam.nr-data.net",
+		"scorecardresearch.com",
+		"quantserve.com",
+		"hm.baidu.com",
+		"cnzz.com",
+		"mc.yandex.ru",
+		"*/g/collect?*", // GA4 经第一方域名转发的上报
+		"*/j/collect?*", // Universal Analytics
+		"*/__utm.gif*",  // 旧版 ga.js
+		"*/matomo.php*", // Matomo / Piwik
+		"*/piwik.php*",
+		"*/hm.gif?*", // 百度统计
+	},
+	Advertising: {
+		"doubleclick.net",
+		"googleadservices.com",
+		"googlesyndication.com",
+		"adservice.google.com",
+		"amazon-adsystem.com",
+		"adnxs.com",
+		"criteo.com",
+		"criteo.net",
+		"taboola.com",
+		"outbrain.com",
+		"pubmatic.com",
+		"rubiconproject.com",
+		"openx.net",
+		"adsrvr.org",
+		"moatads.com",
+		"bat.bing.com",
+	},
+	Social: {
+		"connect.facebook.net",
+		"ads-twitter.com",
+		"analytics.twitter.com",
+		"platform.twitter.com",
+		"snap.licdn.com",
+		"px.ads.linkedin.com",
+		"analytics.tiktok.com",
+		"ct.pinterest.com",
+		"sc-static.net",
+		"*://www.facebook.com/tr?*", // Facebook Pixel 图片上报
+		"*://www.facebook.com/tr/?*",
+	},
+}
+
+// Presets 返回全部预设清单名（按字母排序）
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// List 合并后的忽略清单；零值和 nil 不匹配任何地址
+type List struct {
+	domains  []string
+	patterns []*regexp.Regexp
+}
+
+// Load 按顺序合并 sources：预设清单名，或自定义清单文件的路径。
+// 文件每行一个条目，格式与预设相同（域名，或 * 通配的完整 URL 模式），空行和 # 开头的行忽略。
+// sources 为空时返回 nil
+func Load(sources []string) (*List, error) {
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	l := &List{}
+	for _, src := range sources {
+		src = strings.TrimSpace(src)
+		if src == "" {
+			continue
+		}
+		if entries, ok := presets[strings.ToLower(src)]; ok {
+			l.add(entries)
+			continue
+		}
+		entries, err := readFile(src)
+		if err != nil {
+			return nil, fmt.Errorf("忽略清单 %q 既不是预设（%s）也无法读取: %w", src, strings.Join(Presets(), "、"), err)
+		}
+		l.add(entries)
+	}
+	return l, nil
+}
+
+// readFile 读取自定义清单文件的条目
+func readFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	return entries, scanner.Err()
+}
+
+func (l *List) add(entries []string) {
+	for _, e := range entries {
+		if d, ok := strings.CutPrefix(e, "*."); ok && !strings.ContainsAny(d, "/*") {
+			e = d
+		}
+		if !strings.ContainsAny(e, "/*") {
+			l.domains = append(l.domains, strings.ToLower(strings.TrimPrefix(e, ".")))
+			continue
+		}
+		parts := strings.Split(e, "*")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		l.patterns = append(l.patterns, regexp.MustCompile("^"+strings.Join(parts, ".*")+"$"))
+	}
+}
+
+// Match 判断 rawURL 的主机是否为清单中的域名（或其子域名），或完整 URL 命中某个模式
+func (l *List) Match(rawURL string) bool {
+	if l == nil {
+		return false
+	}
+	if u, err := url.Parse(rawURL); err == nil {
+		host := strings.ToLower(u.Hostname())
+		for _, d := range l.domains {
+			if host == d || strings.HasSuffix(host, "."+d) {
+				return true
+			}
+		}
+	}
+	for _, re := range l.patterns {
+		if re.MatchString(rawURL) {
+			return true
+		}
+	}
+	return false
+}
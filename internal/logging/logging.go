@@ -0,0 +1,62 @@
+// Package logging 分级日志：警告和错误经 Warnf / Errorf 以 slog 的 WARN / ERROR 级别记录，
+// 普通进度日志仍使用 log.Printf（相当于 INFO），调试细节使用 slog.Debug。
+// SetLevel 按级别过滤全部三类输出，不再依据日志文字判断级别。
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// 进程启动时的默认设置，SetLevel 回到 INFO / DEBUG 时恢复
+var (
+	defaultLogger = slog.Default()
+	defaultFlags  = log.Flags()
+)
+
+// Warnf 以 WARN 级别记录格式化的消息
+func Warnf(format string, args ...any) {
+	logf(slog.LevelWarn, format, args...)
+}
+
+// Errorf 以 ERROR 级别记录格式化的消息
+func Errorf(format string, args ...any) {
+	logf(slog.LevelError, format, args...)
+}
+
+func logf(level slog.Level, format string, args ...any) {
+	ctx := context.Background()
+	handler := slog.Default().Handler()
+	if !handler.Enabled(ctx, level) {
+		return
+	}
+	// 跳过 runtime.Callers、logf 和 Warnf / Errorf，记录调用方的位置
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	record := slog.NewRecord(time.Now(), level, fmt.Sprintf(format, args...), pcs[0])
+	_ = handler.Handle(ctx, record)
+}
+
+// SetLevel 只向 w 输出 level 及以上级别的日志。
+// INFO、DEBUG 保持 log 的默认格式（DEBUG 额外输出 slog.Debug）；
+// 更高级别改用 slog 的文本格式，log.Printf 的普通日志按 INFO 处理而被丢弃。
+// w 为 nil 时丢弃全部日志
+func SetLevel(w io.Writer, level slog.Level) {
+	switch {
+	case w == nil:
+		slog.SetDefault(slog.New(slog.DiscardHandler))
+	case level <= slog.LevelInfo:
+		slog.SetDefault(defaultLogger)
+		log.SetOutput(w)
+		log.SetFlags(defaultFlags)
+		slog.SetLogLoggerLevel(level)
+	default:
+		slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})))
+		slog.SetLogLoggerLevel(slog.LevelInfo) // log.Printf 经由该处理器时使用的级别
+	}
+}
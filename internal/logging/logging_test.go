@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// restore 测试结束后恢复 log 与 slog 的默认设置
+func restore(t *testing.T) {
+	saved, writer, flags := slog.Default(), log.Writer(), log.Flags()
+	t.Cleanup(func() {
+		slog.SetDefault(saved)
+		log.SetOutput(writer)
+		log.SetFlags(flags)
+		slog.SetLogLoggerLevel(slog.LevelInfo)
+	})
+}
+
+// emitAll 依次输出四个级别的日志
+func emitAll() {
+	slog.Debug("调试细节")
+	log.Printf("进度 %d", 1)
+	Warnf("磁盘空间不足: %d%%", 95)
+	Errorf("写入 %s 失败", "a.js")
+}
+
+func TestSetLevel(t *testing.T) {
+	restore(t)
+	tests := []struct {
+		level slog.Level
+		want  []string
+		not   []string
+	}{
+		{slog.LevelDebug, []string{"DEBUG 调试细节", "进度 1", "WARN 磁盘空间不足: 95%", "ERROR 写入 a.js 失败"}, nil},
+		{slog.LevelInfo, []string{"进度 1", "WARN 磁盘空间不足: 95%", "ERROR 写入 a.js 失败"}, []string{"调试细节"}},
+		{slog.LevelWarn, []string{`level=WARN msg="磁盘空间不足: 95%"`, `level=ERROR msg="写入 a.js 失败"`}, []string{"调试细节", "进度"}},
+		{slog.LevelError, []string{`level=ERROR msg="写入 a.js 失败"`}, []string{"调试细节", "进度", "磁盘空间不足"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			SetLevel(&buf, tt.level)
+			emitAll()
+			out := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("输出中缺少 %q:\n%s", want, out)
+				}
+			}
+			for _, not := range tt.not {
+				if strings.Contains(out, not) {
+					t.Errorf("输出中不应有 %q:\n%s", not, out)
+				}
+			}
+		})
+	}
+}
+
+// 从更高级别切回 INFO 时恢复 log 的默认格式，log.Printf 直接写入而不经过 slog 处理器
+func TestSetLevelRestoresDefault(t *testing.T) {
+	restore(t)
+	var buf bytes.Buffer
+	SetLevel(&buf, slog.LevelWarn)
+	SetLevel(&buf, slog.LevelInfo)
+	log.SetFlags(0)
+	log.Printf("进度")
+	if got := buf.String(); got != "进度\n" {
+		t.Errorf("切回 INFO 后 log.Printf 输出 %q", got)
+	}
+
+	// w 为 nil 时丢弃全部日志
+	buf.Reset()
+	SetLevel(nil, 0)
+	emitAll()
+	if buf.Len() != 0 {
+		t.Errorf("丢弃全部日志后仍有输出: %s", buf.String())
+	}
+}
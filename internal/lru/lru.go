@@ -0,0 +1,121 @@
+// Package lru 有条目数（可选总大小）上限和可选过期时间的并发安全 LRU 缓存，
+// 用于长时间运行的进程（批量、递归爬取）中跨任务共享、否则会无限增长的缓存。
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache 最近最少使用的条目先被淘汰；ttl > 0 时超过 ttl 未更新的条目视为不存在
+type Cache[K comparable, V any] struct {
+	mu sync.Mutex
+
+	maxEntries int              // 条目数上限，<=0 表示不限
+	maxCost    int64            // 总大小上限，<=0 表示不限
+	cost       func(V) int64    // 单个值的大小，nil 时不统计
+	ttl        time.Duration    // 条目的有效期，<=0 表示不过期
+	now        func() time.Time // 测试中可替换
+
+	ll    *list.List // 前端为最近使用
+	items map[K]*list.Element
+	total int64
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	cost    int64
+	expires time.Time
+}
+
+// New 创建最多保存 maxEntries 个条目的缓存，ttl > 0 时条目在写入 ttl 之后过期
+func New[K comparable, V any](maxEntries int, ttl time.Duration) *Cache[K, V] {
+	return NewWithCost[K, V](maxEntries, 0, ttl, nil)
+}
+
+// NewWithCost 与 New 相同，另按 cost 统计每个值的大小，总大小超过 maxCost 时从最久未用的开始淘汰；
+// 单个值超过 maxCost 时不缓存
+func NewWithCost[K comparable, V any](maxEntries int, maxCost int64, ttl time.Duration, cost func(V) int64) *Cache[K, V] {
+	return &Cache[K, V]{
+		maxEntries: maxEntries,
+		maxCost:    maxCost,
+		cost:       cost,
+		ttl:        ttl,
+		now:        time.Now,
+		ll:         list.New(),
+		items:      make(map[K]*list.Element),
+	}
+}
+
+// Get 返回 key 对应的值并将其标为最近使用；不存在或已过期时返回 false
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	e := el.Value.(*entry[K, V])
+	if c.ttl > 0 && !c.now().Before(e.expires) {
+		c.remove(el)
+		return zero, false
+	}
+	c.ll.MoveToFront(el)
+	return e.value, true
+}
+
+// Add 写入或更新 key，随后淘汰超出上限的条目
+func (c *Cache[K, V]) Add(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var size int64
+	if c.cost != nil {
+		size = c.cost(value)
+	}
+	if c.maxCost > 0 && size > c.maxCost {
+		if el, ok := c.items[key]; ok {
+			c.remove(el)
+		}
+		return
+	}
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = c.now().Add(c.ttl)
+	}
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		c.total += size - e.cost
+		e.value, e.cost, e.expires = value, size, expires
+		c.ll.MoveToFront(el)
+	} else {
+		c.items[key] = c.ll.PushFront(&entry[K, V]{key: key, value: value, cost: size, expires: expires})
+		c.total += size
+	}
+	for (c.maxEntries > 0 && c.ll.Len() > c.maxEntries) || (c.maxCost > 0 && c.total > c.maxCost) {
+		c.remove(c.ll.Back())
+	}
+}
+
+// Len 当前条目数（含尚未清理的过期条目）
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Cost 当前条目的总大小
+func (c *Cache[K, V]) Cost() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+func (c *Cache[K, V]) remove(el *list.Element) {
+	e := c.ll.Remove(el).(*entry[K, V])
+	delete(c.items, e.key)
+	c.total -= e.cost
+}
@@ -0,0 +1,85 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	c := New[string, int](2, 0)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Get("a") // a 变为最近使用，b 最先淘汰
+	c.Add("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("b 应已被淘汰")
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, ok := c.Get(k); !ok {
+			t.Errorf("%s 不应被淘汰", k)
+		}
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+func TestCostLimit(t *testing.T) {
+	c := NewWithCost[string, []byte](0, 10, 0, func(b []byte) int64 { return int64(len(b)) })
+	c.Add("a", make([]byte, 4))
+	c.Add("b", make([]byte, 4))
+	c.Add("c", make([]byte, 4)) // 总计 12 > 10，淘汰 a
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("a 应已被淘汰")
+	}
+	if c.Cost() != 8 {
+		t.Errorf("Cost() = %d, want 8", c.Cost())
+	}
+
+	c.Add("huge", make([]byte, 11))
+	if _, ok := c.Get("huge"); ok {
+		t.Error("超过总上限的单个值不应缓存")
+	}
+	if c.Cost() != 8 {
+		t.Errorf("拒绝超大值后 Cost() = %d, want 8", c.Cost())
+	}
+
+	c.Add("b", make([]byte, 1)) // 更新时按新大小统计
+	if c.Cost() != 5 {
+		t.Errorf("更新后 Cost() = %d, want 5", c.Cost())
+	}
+}
+
+func TestTTL(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := New[string, int](0, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Add("a", 1)
+	now = now.Add(59 * time.Second)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("未到期的条目应能取到")
+	}
+	now = now.Add(time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("到期的条目不应返回")
+	}
+	if c.Len() != 0 {
+		t.Errorf("过期条目取用后应被移除，Len() = %d", c.Len())
+	}
+}
+
+func TestBoundedUnderChurn(t *testing.T) {
+	c := New[int, int](100, 0)
+	for i := range 100000 {
+		c.Add(i, i)
+	}
+	if c.Len() != 100 {
+		t.Fatalf("Len() = %d, want 100", c.Len())
+	}
+	if _, ok := c.Get(99999); !ok {
+		t.Error("最近写入的条目应保留")
+	}
+}
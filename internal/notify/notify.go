@@ -0,0 +1,204 @@
+// Package notify 爬取结束后发送完成通知：POST 汇总 JSON 到 webhook，或把汇总写入本地命令的标准输入。
+// 汇总中只有计数、失败的 URL 和输出路径，不包含 Cookie、请求头、代理等配置，
+// URL 中的用户名密码和查询字符串也会去掉，避免令牌随通知外泄。
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// PayloadVersion 汇总 JSON 的格式版本，字段有不兼容的变化时递增
+const PayloadVersion = 1
+
+// 通知条件
+const (
+	OnFailure = "failure" // 只在有 URL 失败或退出码非 0 时通知
+	OnAlways  = "always"  // 每次结束都通知
+)
+
+// 默认的 webhook 重试参数
+const (
+	DefaultRetries    = 3
+	DefaultRetryDelay = 2 * time.Second
+)
+
+// maxResponseBytes 读取 webhook 错误响应的上限，只用于错误信息
+const maxResponseBytes = 4 * 1024
+
+// Failure 一个失败的 URL
+type Failure struct {
+	URL   string `json:"url"`
+	Error string `json:"error,omitempty"`
+}
+
+// Summary 一次爬取的汇总，即通知的载荷
+type Summary struct {
+	Version         int       `json:"version"`
+	Command         string    `json:"command"` // crawl / batch / replay
+	OutputDir       string    `json:"output_dir"`
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	ExitCode        int       `json:"exit_code"`
+
+	Total            int       `json:"total"`
+	Succeeded        int       `json:"succeeded"`
+	Failed           int       `json:"failed"`
+	NotAttempted     int       `json:"not_attempted"`      // 达到 -max-duration 时尚未开始
+	LikelyLoginPages int       `json:"likely_login_pages"` // 疑似抓到登录页（会话过期）
+	Failures         []Failure `json:"failures"`
+}
+
+// HasFailures 是否有 URL 失败、未尝试或退出码非 0
+func (s *Summary) HasFailures() bool {
+	return s.Failed > 0 || s.NotAttempted > 0 || s.ExitCode != 0
+}
+
+// AddFailure 记录一个失败的 URL（去掉用户名密码和查询字符串）
+func (s *Summary) AddFailure(rawURL, errMsg string) {
+	s.Failures = append(s.Failures, Failure{URL: RedactURL(rawURL), Error: errMsg})
+}
+
+// RedactURL 去掉 URL 中的用户名密码、查询字符串和片段，解析失败时返回空字符串
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	return u.String()
+}
+
+// Notifier 完成通知的目标和条件；WebhookURL 和 Command 可同时设置，各自独立发送
+type Notifier struct {
+	WebhookURL string        // 接收 POST 的地址，Content-Type: application/json
+	Command    string        // 本地命令（经 sh -c / cmd /C 执行），汇总 JSON 写入标准输入
+	On         string        // OnFailure / OnAlways，空表示 OnAlways
+	Retries    int           // webhook 失败（网络错误、429、5xx）后的重试次数
+	RetryDelay time.Duration // 首次重试前的等待，之后每次翻倍
+	Client     *http.Client  // nil 时使用 30s 超时的默认客户端
+}
+
+// Enabled 是否设置了任一通知目标
+func (n *Notifier) Enabled() bool {
+	return n != nil && (n.WebhookURL != "" || n.Command != "")
+}
+
+// Validate 检查通知条件和 webhook 地址
+func (n *Notifier) Validate() error {
+	if n.On != "" && n.On != OnFailure && n.On != OnAlways {
+		return fmt.Errorf("通知条件应为 %s 或 %s，当前值: %s", OnFailure, OnAlways, n.On)
+	}
+	if n.WebhookURL != "" {
+		u, err := url.Parse(n.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook 地址应为 http(s) URL，当前值: %s", n.WebhookURL)
+		}
+	}
+	return nil
+}
+
+// Notify 按条件发送通知；webhook 和命令都会尝试，返回合并的错误
+func (n *Notifier) Notify(ctx context.Context, s *Summary) error {
+	if !n.Enabled() || (n.On == OnFailure && !s.HasFailures()) {
+		return nil
+	}
+	s.Version = PayloadVersion
+	if s.Failures == nil {
+		s.Failures = []Failure{}
+	}
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if n.WebhookURL != "" {
+		if err := n.postWebhook(ctx, payload); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		}
+	}
+	if n.Command != "" {
+		if err := n.runCommand(ctx, payload); err != nil {
+			errs = append(errs, fmt.Errorf("通知命令: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// postWebhook POST 载荷，网络错误、429 和 5xx 按指数退避重试，其他 4xx 直接返回
+func (n *Notifier) postWebhook(ctx context.Context, payload []byte) error {
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	delay := n.RetryDelay
+	var err error
+	for attempt := 0; attempt <= n.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			delay *= 2
+		}
+		var retry bool
+		retry, err = n.post(ctx, client, payload)
+		if err == nil || !retry {
+			return err
+		}
+	}
+	return fmt.Errorf("重试 %d 次后仍失败: %w", n.Retries, err)
+}
+
+// post 发送一次，返回错误是否值得重试
+func (n *Notifier) post(ctx context.Context, client *http.Client, payload []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Spider-Payload-Version", fmt.Sprint(PayloadVersion))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// runCommand 经系统 shell 执行通知命令，载荷写入标准输入，命令的输出转到本进程的 stderr
+func (n *Notifier) runCommand(ctx context.Context, payload []byte) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", n.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", n.Command)
+	}
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
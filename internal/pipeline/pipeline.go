@@ -0,0 +1,109 @@
+// Package pipeline 抓取后的资源处理流水线：source map 提取、保存、报告等步骤
+// 作为可组合的阶段依次执行，调用方可以增删或插入自定义阶段。
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/logging"
+)
+
+// ErrStop 阶段返回 ErrStop 表示流水线正常结束，后续阶段不再执行，Run 不视为错误
+var ErrStop = errors.New("pipeline stopped")
+
+// ResourceSet 在各阶段之间传递的资源集合，阶段可以直接增删 Resources
+type ResourceSet struct {
+	TargetURL string
+	OutputDir string
+	Resources map[string]*crawler.Resource
+}
+
+// Stage 流水线中的一个处理步骤
+type Stage interface {
+	Process(ctx context.Context, set *ResourceSet) error
+}
+
+// StageFunc 将普通函数适配为 Stage
+type StageFunc func(ctx context.Context, set *ResourceSet) error
+
+// Process 调用 f
+func (f StageFunc) Process(ctx context.Context, set *ResourceSet) error {
+	return f(ctx, set)
+}
+
+// ErrorPolicy 阶段出错时的处理方式
+type ErrorPolicy int
+
+const (
+	Abort    ErrorPolicy = iota // 停止流水线并返回错误
+	Continue                    // 记录警告，继续执行后续阶段
+)
+
+// StageTiming 一个阶段的执行耗时和结果
+type StageTiming struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+}
+
+type entry struct {
+	name   string
+	stage  Stage
+	policy ErrorPolicy
+}
+
+// Pipeline 按添加顺序执行的阶段列表，零值即可使用
+type Pipeline struct {
+	stages  []entry
+	timings []StageTiming
+}
+
+// Add 追加一个阶段，name 用于日志和错误信息
+func (p *Pipeline) Add(name string, stage Stage, policy ErrorPolicy) *Pipeline {
+	p.stages = append(p.stages, entry{name: name, stage: stage, policy: policy})
+	return p
+}
+
+// Run 依次执行各阶段并记录耗时。Abort 阶段出错时立即返回该错误；
+// Continue 阶段的错误记录为警告，全部执行完后合并返回。
+// 阶段返回 ErrStop 或 ctx 结束时停止执行后续阶段。
+func (p *Pipeline) Run(ctx context.Context, set *ResourceSet) error {
+	p.timings = p.timings[:0]
+	var errs []error
+	for _, e := range p.stages {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		start := time.Now()
+		err := e.stage.Process(ctx, set)
+		elapsed := time.Since(start)
+		log.Printf("阶段 %s 耗时 %s", e.name, elapsed.Round(time.Millisecond))
+		if errors.Is(err, ErrStop) {
+			p.timings = append(p.timings, StageTiming{Name: e.name, Duration: elapsed})
+			break
+		}
+		p.timings = append(p.timings, StageTiming{Name: e.name, Duration: elapsed, Err: err})
+		if err == nil {
+			continue
+		}
+
+		err = fmt.Errorf("阶段 %s: %w", e.name, err)
+		if e.policy == Abort {
+			return errors.Join(append(errs, err)...)
+		}
+		logging.Warnf("%v", err)
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Timings 返回最近一次 Run 中各阶段的耗时（按执行顺序）
+func (p *Pipeline) Timings() []StageTiming {
+	return p.timings
+}
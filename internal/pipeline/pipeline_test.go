@@ -0,0 +1,206 @@
+package pipeline_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"spider/internal/crawler"
+	"spider/internal/pipeline"
+	"spider/internal/storage"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// recorder 记录各阶段的执行顺序
+type recorder struct {
+	calls []string
+}
+
+// stage 返回一个把 name 记入 calls 并返回 err 的阶段
+func (r *recorder) stage(name string, err error) pipeline.Stage {
+	return pipeline.StageFunc(func(context.Context, *pipeline.ResourceSet) error {
+		r.calls = append(r.calls, name)
+		return err
+	})
+}
+
+func newSet() *pipeline.ResourceSet {
+	return &pipeline.ResourceSet{Resources: make(map[string]*crawler.Resource)}
+}
+
+func TestRunOrder(t *testing.T) {
+	var r recorder
+	var p pipeline.Pipeline
+	p.Add("a", r.stage("a", nil), pipeline.Abort).
+		Add("b", r.stage("b", nil), pipeline.Abort).
+		Add("c", r.stage("c", nil), pipeline.Continue)
+
+	if err := p.Run(context.Background(), newSet()); err != nil {
+		t.Fatalf("Run 返回错误: %v", err)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("执行顺序 %v，应为 %v", r.calls, want)
+	}
+
+	var names []string
+	for _, timing := range p.Timings() {
+		names = append(names, timing.Name)
+		if timing.Err != nil {
+			t.Errorf("阶段 %s 记录了错误 %v", timing.Name, timing.Err)
+		}
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Timings 记录了 %v，应为 %v", names, want)
+	}
+}
+
+// Abort 阶段出错时后续阶段不再执行，返回的错误包装了原始错误并带有阶段名
+func TestRunAbortShortCircuits(t *testing.T) {
+	boom := errors.New("boom")
+	var r recorder
+	var p pipeline.Pipeline
+	p.Add("a", r.stage("a", nil), pipeline.Abort).
+		Add("b", r.stage("b", boom), pipeline.Abort).
+		Add("c", r.stage("c", nil), pipeline.Abort)
+
+	err := p.Run(context.Background(), newSet())
+	if !errors.Is(err, boom) {
+		t.Fatalf("Run 返回 %v，应包装 %v", err, boom)
+	}
+	if !strings.Contains(err.Error(), "阶段 b") {
+		t.Errorf("错误 %q 没有带上阶段名", err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("执行了 %v，应为 %v", r.calls, want)
+	}
+	if n := len(p.Timings()); n != 2 {
+		t.Errorf("Timings 有 %d 条，应为 2", n)
+	}
+}
+
+// Continue 阶段出错时继续执行，错误在最后合并返回；之后的 Abort 错误同样带上之前的错误
+func TestRunContinueCollectsErrors(t *testing.T) {
+	warn1 := errors.New("warn1")
+	warn2 := errors.New("warn2")
+	var r recorder
+	var p pipeline.Pipeline
+	p.Add("a", r.stage("a", warn1), pipeline.Continue).
+		Add("b", r.stage("b", nil), pipeline.Abort).
+		Add("c", r.stage("c", warn2), pipeline.Continue)
+
+	err := p.Run(context.Background(), newSet())
+	if !errors.Is(err, warn1) || !errors.Is(err, warn2) {
+		t.Fatalf("Run 返回 %v，应同时包含 %v 和 %v", err, warn1, warn2)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("执行了 %v，应为 %v", r.calls, want)
+	}
+	if timings := p.Timings(); !errors.Is(timings[0].Err, warn1) || timings[1].Err != nil {
+		t.Errorf("Timings 记录的错误不对: %+v", timings)
+	}
+
+	fatal := errors.New("fatal")
+	r.calls = nil
+	var q pipeline.Pipeline
+	q.Add("a", r.stage("a", warn1), pipeline.Continue).
+		Add("b", r.stage("b", fatal), pipeline.Abort).
+		Add("c", r.stage("c", nil), pipeline.Abort)
+	err = q.Run(context.Background(), newSet())
+	if !errors.Is(err, warn1) || !errors.Is(err, fatal) {
+		t.Fatalf("Run 返回 %v，应同时包含 %v 和 %v", err, warn1, fatal)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("执行了 %v，应为 %v", r.calls, want)
+	}
+}
+
+// ErrStop 正常结束流水线：后续阶段不执行，Run 不返回错误
+func TestRunStop(t *testing.T) {
+	var r recorder
+	var p pipeline.Pipeline
+	p.Add("a", r.stage("a", nil), pipeline.Abort).
+		Add("b", r.stage("b", pipeline.ErrStop), pipeline.Abort).
+		Add("c", r.stage("c", nil), pipeline.Abort)
+
+	if err := p.Run(context.Background(), newSet()); err != nil {
+		t.Fatalf("Run 返回错误: %v", err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("执行了 %v，应为 %v", r.calls, want)
+	}
+}
+
+// ctx 在阶段之间被取消时停止执行并返回 ctx 的错误
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var r recorder
+	var p pipeline.Pipeline
+	p.Add("a", r.stage("a", nil), pipeline.Abort).
+		Add("cancel", pipeline.StageFunc(func(context.Context, *pipeline.ResourceSet) error {
+			cancel()
+			return nil
+		}), pipeline.Continue).
+		Add("c", r.stage("c", nil), pipeline.Abort)
+
+	if err := p.Run(ctx, newSet()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run 返回 %v，应为 context.Canceled", err)
+	}
+	if want := []string{"a"}; !reflect.DeepEqual(r.calls, want) {
+		t.Errorf("执行了 %v，应为 %v", r.calls, want)
+	}
+}
+
+// 自定义阶段插在内置的 Save / Report 之前，对资源集合的修改应反映到写出的文件和索引中
+func TestCustomStageWithBuiltinStages(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewFlat(dir)
+
+	set := newSet()
+	set.OutputDir = dir
+	for _, u := range []string{"https://example.com/app.js", "https://example.com/tracker.js"} {
+		set.Resources[u] = &crawler.Resource{URL: u, StatusCode: 200, MimeType: "application/javascript", Content: []byte("// " + u)}
+	}
+
+	dropTracker := pipeline.StageFunc(func(_ context.Context, set *pipeline.ResourceSet) error {
+		for u := range set.Resources {
+			if strings.Contains(u, "tracker") {
+				delete(set.Resources, u)
+			}
+		}
+		return nil
+	})
+
+	var p pipeline.Pipeline
+	p.Add("filter", dropTracker, pipeline.Abort).
+		Add("save", pipeline.Save{Store: store}, pipeline.Abort).
+		Add("report", pipeline.Report{Store: store}, pipeline.Abort)
+	if err := p.Run(context.Background(), set); err != nil {
+		t.Fatalf("Run 返回错误: %v", err)
+	}
+
+	index, err := storage.ReadIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != 1 || index[0].URL != "https://example.com/app.js" {
+		t.Fatalf("resources.json 为 %+v，应只有 app.js", index)
+	}
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(index[0].Path))); err != nil {
+		t.Errorf("app.js 未写入磁盘: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "report.txt")); err != nil {
+		t.Errorf("缺少 report.txt: %v", err)
+	}
+}
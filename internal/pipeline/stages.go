@@ -0,0 +1,71 @@
+package pipeline
+
+import (
+	"context"
+	"log"
+	"maps"
+
+	"spider/internal/crawler"
+	"spider/internal/sourcemap"
+	"spider/internal/storage"
+)
+
+// ExtractSourceMaps 用批量提取器从资源中提取 source map 源文件，并加入资源集合
+type ExtractSourceMaps struct {
+	Batch   *sourcemap.BatchExtractor
+	Workers int
+	Labels  map[string]string // 附加到提取出的源文件上的标签
+}
+
+// Process 实现 Stage
+func (s ExtractSourceMaps) Process(ctx context.Context, set *ResourceSet) error {
+	log.Printf("正在提取 Source Maps...")
+	extracted := make(map[string]*crawler.Resource)
+
+	in := make(chan *crawler.Resource)
+	out := make(chan *crawler.Resource)
+	go func() {
+		defer close(in)
+		for _, res := range set.Resources {
+			select {
+			case in <- res:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go s.Batch.ProcessStream(in, out, s.Workers)
+	for sourceFile := range out {
+		sourceFile.Labels = maps.Clone(s.Labels)
+		extracted[sourceFile.URL] = sourceFile
+	}
+
+	log.Printf("从 Source Maps 提取了 %d 个源文件", len(extracted))
+	maps.Copy(set.Resources, extracted)
+	log.Printf("总共 %d 个资源（包括源文件）", len(set.Resources))
+	return ctx.Err()
+}
+
+// Save 将资源集合写入存储
+type Save struct {
+	Store *storage.Storage
+}
+
+// Process 实现 Stage
+func (s Save) Process(_ context.Context, set *ResourceSet) error {
+	log.Printf("正在保存资源到: %s", set.OutputDir)
+	return s.Store.Save(set.Resources)
+}
+
+// Report 生成 report.txt 和 resources.json 索引
+type Report struct {
+	Store *storage.Storage
+}
+
+// Process 实现 Stage
+func (s Report) Process(_ context.Context, set *ResourceSet) error {
+	if err := s.Store.GenerateReport(set.Resources); err != nil {
+		return err
+	}
+	return s.Store.WriteIndex(set.Resources)
+}
@@ -0,0 +1,127 @@
+package robots
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxRobotsBytes = 512 * 1024 // 512 KB，与主流搜索引擎的截断上限一致
+
+// Rules 表示 robots.txt 中适用于某个 User-Agent 的规则组
+type Rules struct {
+	CrawlDelay time.Duration // Crawl-delay 指令，0 表示未声明
+	Allow      []string
+	Disallow   []string
+}
+
+// Fetch 下载并解析 origin 下的 /robots.txt。
+// robots.txt 不存在（4xx）视为无限制，返回空规则；网络错误和 5xx 返回 error。
+func Fetch(client *http.Client, rawURL, userAgent string) (*Rules, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	robotsURL := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}).String()
+
+	req, err := http.NewRequest("GET", robotsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	case resp.StatusCode >= 400:
+		return &Rules{}, nil
+	}
+
+	return Parse(io.LimitReader(resp.Body, maxRobotsBytes), userAgent), nil
+}
+
+// Parse 解析 robots.txt，返回与 userAgent 最匹配的规则组。
+// 优先匹配 User-agent 名称包含于 UA 中的组，否则回退到 "*" 组。
+func Parse(r io.Reader, userAgent string) *Rules {
+	ua := strings.ToLower(userAgent)
+
+	var (
+		specific, wildcard *Rules
+		current            []*Rules // 当前规则组适用的目标（可能同时命中具体 UA 与 *）
+		inAgents           bool     // 是否处于连续的 User-agent 行中
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = line[:idx]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			if !inAgents {
+				current = nil
+				inAgents = true
+			}
+			agent := strings.ToLower(value)
+			switch {
+			case agent == "*":
+				if wildcard == nil {
+					wildcard = &Rules{}
+				}
+				current = append(current, wildcard)
+			case ua != "" && strings.Contains(ua, agent):
+				if specific == nil {
+					specific = &Rules{}
+				}
+				current = append(current, specific)
+			}
+			continue
+		}
+		inAgents = false
+
+		for _, rules := range current {
+			switch key {
+			case "crawl-delay":
+				if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+					rules.CrawlDelay = time.Duration(secs * float64(time.Second))
+				}
+			case "allow":
+				if value != "" {
+					rules.Allow = append(rules.Allow, value)
+				}
+			case "disallow":
+				if value != "" {
+					rules.Disallow = append(rules.Disallow, value)
+				}
+			}
+		}
+	}
+
+	if specific != nil {
+		return specific
+	}
+	if wildcard != nil {
+		return wildcard
+	}
+	return &Rules{}
+}
@@ -0,0 +1,97 @@
+package sourcemap
+
+import (
+	"sync"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/logging"
+)
+
+// BatchExtractor 并发处理资源流，多个 worker 同时下载/解析 source map，
+// 并通过令牌桶限制对 source map 服务器的请求速率。
+type BatchExtractor struct {
+	extractor *Extractor
+}
+
+// NewBatchExtractor 基于 extractor 创建批量提取器。
+// rate 为每秒允许的 source map 下载数，burst 为令牌桶容量；rate <= 0 表示不限速。
+func NewBatchExtractor(extractor *Extractor, rate float64, burst int) *BatchExtractor {
+	if rate > 0 {
+		extractor.limiter = newTokenBucket(rate, burst)
+	}
+	return &BatchExtractor{extractor: extractor}
+}
+
+// ProcessStream 从 in 读取资源，由 workers 个 goroutine 并发提取，源文件写入 out。
+// 阻塞直到 in 关闭且全部处理完成，随后关闭 out。
+// out 无人读取时 worker 阻塞，形成对上游的反压。
+func (be *BatchExtractor) ProcessStream(in <-chan *crawler.Resource, out chan<- *crawler.Resource, workers int) {
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for res := range in {
+				sourceFiles, err := be.extractor.ExtractFromResource(res)
+				if err != nil {
+					logging.Warnf("提取 source map 失败: %v", err)
+					continue
+				}
+				for _, sourceFile := range sourceFiles {
+					out <- sourceFile
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(out)
+}
+
+// tokenBucket 简单令牌桶：按固定速率补充令牌，取不到时阻塞等待
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64 // 每秒补充的令牌数
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:     rate,
+		capacity: float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// wait 阻塞直到取得一个令牌
+func (tb *tokenBucket) wait() {
+	for {
+		tb.mu.Lock()
+		now := time.Now()
+		tb.tokens += now.Sub(tb.last).Seconds() * tb.rate
+		if tb.tokens > tb.capacity {
+			tb.tokens = tb.capacity
+		}
+		tb.last = now
+
+		if tb.tokens >= 1 {
+			tb.tokens--
+			tb.mu.Unlock()
+			return
+		}
+		wait := time.Duration((1 - tb.tokens) / tb.rate * float64(time.Second))
+		tb.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
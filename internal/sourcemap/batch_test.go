@@ -0,0 +1,239 @@
+package sourcemap
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+)
+
+// mapServer 为每个 /N.js.map 返回只含一个源文件 src/N.ts 的 source map，
+// 每次下载停顿 delay 并记录同时进行的下载数的峰值
+type mapServer struct {
+	*httptest.Server
+	delay   time.Duration
+	active  atomic.Int32
+	peak    atomic.Int32
+	fetches atomic.Int32
+}
+
+func newMapServer(t *testing.T, delay time.Duration) *mapServer {
+	s := &mapServer{delay: delay}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/"), ".js.map")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		s.fetches.Add(1)
+		n := s.active.Add(1)
+		for {
+			peak := s.peak.Load()
+			if n <= peak || s.peak.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		time.Sleep(s.delay)
+		s.active.Add(-1)
+		fmt.Fprintf(w, `{"version":3,"sources":["src/%s.ts"],"sourcesContent":["export const n = %s;\n"],"mappings":"AAAA"}`, name, name)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// resource 返回引用 /name.js.map 的脚本
+func (s *mapServer) resource(name string) *crawler.Resource {
+	return &crawler.Resource{
+		URL:      s.URL + "/" + name + ".js",
+		MimeType: "application/javascript",
+		Content:  []byte("console.log(1);\n//# sourceMappingURL=" + name + ".js.map\n"),
+		Headers:  map[string]string{},
+	}
+}
+
+// runStream 把 resources 送入 ProcessStream，收集 out 中的全部源文件；
+// out 没有在 timeout 内关闭时测试失败
+func runStream(t *testing.T, be *BatchExtractor, resources []*crawler.Resource, workers int, timeout time.Duration) []*crawler.Resource {
+	t.Helper()
+	in := make(chan *crawler.Resource)
+	out := make(chan *crawler.Resource)
+	go func() {
+		defer close(in)
+		for _, res := range resources {
+			in <- res
+		}
+	}()
+	go be.ProcessStream(in, out, workers)
+
+	var files []*crawler.Resource
+	deadline := time.After(timeout)
+	for {
+		select {
+		case f, ok := <-out:
+			if !ok {
+				return files
+			}
+			files = append(files, f)
+		case <-deadline:
+			t.Fatalf("%s 内 out 没有关闭（已收到 %d 个源文件）", timeout, len(files))
+		}
+	}
+}
+
+// 多个 worker 同时下载 source map，全部源文件都送到 out，处理完后 out 被关闭
+func TestProcessStreamFanOut(t *testing.T) {
+	const workers, count = 4, 12
+	server := newMapServer(t, 50*time.Millisecond)
+
+	var resources []*crawler.Resource
+	for i := range count {
+		resources = append(resources, server.resource(fmt.Sprint(i)))
+	}
+	be := NewBatchExtractor(New(server.URL), 0, workers)
+
+	start := time.Now()
+	files := runStream(t, be, resources, workers, 10*time.Second)
+	elapsed := time.Since(start)
+
+	if len(files) != count {
+		t.Fatalf("收到 %d 个源文件，应为 %d", len(files), count)
+	}
+	seen := make(map[string]bool)
+	for _, f := range files {
+		seen[f.URL] = true
+	}
+	for i := range count {
+		if u := fmt.Sprintf("%s/src/%d.ts", server.URL, i); !seen[u] {
+			t.Errorf("缺少源文件 %s", u)
+		}
+	}
+	if peak := server.peak.Load(); peak < 2 || peak > workers {
+		t.Errorf("同时进行的下载数峰值为 %d，应在 2 到 %d 之间", peak, workers)
+	}
+	// 顺序下载至少需要 count × delay
+	if sequential := count * server.delay; elapsed >= sequential {
+		t.Errorf("耗时 %s，不快于顺序下载的 %s", elapsed, sequential)
+	}
+}
+
+// 没有 source map 或下载失败的资源只记录警告，不影响其他资源，out 照常关闭
+func TestProcessStreamErrorsAndEmpty(t *testing.T) {
+	server := newMapServer(t, 0)
+	be := NewBatchExtractor(New(server.URL), 0, 2)
+
+	if files := runStream(t, be, nil, 2, 5*time.Second); len(files) != 0 {
+		t.Errorf("空输入收到 %d 个源文件", len(files))
+	}
+
+	broken := server.resource("broken")
+	broken.Content = []byte("//# sourceMappingURL=missing.map\n")
+	plain := &crawler.Resource{URL: server.URL + "/page.html", MimeType: "text/html", Content: []byte("<p>hi</p>"), Headers: map[string]string{}}
+	files := runStream(t, be, []*crawler.Resource{broken, plain, server.resource("ok")}, 2, 5*time.Second)
+	if len(files) != 1 || files[0].URL != server.URL+"/src/ok.ts" {
+		t.Errorf("收到的源文件为 %v，应只有 src/ok.ts", files)
+	}
+}
+
+// workers <= 0 按 1 个处理
+func TestProcessStreamDefaultWorkers(t *testing.T) {
+	server := newMapServer(t, 10*time.Millisecond)
+	be := NewBatchExtractor(New(server.URL), 0, 0)
+
+	resources := []*crawler.Resource{server.resource("a"), server.resource("b"), server.resource("c")}
+	if files := runStream(t, be, resources, 0, 5*time.Second); len(files) != 3 {
+		t.Fatalf("收到 %d 个源文件，应为 3", len(files))
+	}
+	if peak := server.peak.Load(); peak != 1 {
+		t.Errorf("同时进行的下载数峰值为 %d，应为 1", peak)
+	}
+}
+
+// out 无人读取时 worker 阻塞，不再从 in 取资源；恢复读取后全部处理完并关闭 out
+func TestProcessStreamBackpressure(t *testing.T) {
+	const workers = 2
+	server := newMapServer(t, 0)
+	be := NewBatchExtractor(New(server.URL), 0, workers)
+
+	in := make(chan *crawler.Resource, 10)
+	for i := range 10 {
+		in <- server.resource(fmt.Sprint(i))
+	}
+	close(in)
+	out := make(chan *crawler.Resource)
+	done := make(chan struct{})
+	go func() {
+		be.ProcessStream(in, out, workers)
+		close(done)
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	// 每个 worker 最多取走一个资源，下载完后阻塞在发送上
+	if n := server.fetches.Load(); n > workers {
+		t.Errorf("out 无人读取时已下载 %d 个 source map，应不超过 %d", n, workers)
+	}
+	select {
+	case <-done:
+		t.Fatal("out 无人读取时 ProcessStream 不应返回")
+	default:
+	}
+
+	var n int
+	for range out {
+		n++
+	}
+	<-done
+	if n != 10 {
+		t.Errorf("收到 %d 个源文件，应为 10", n)
+	}
+}
+
+// rate <= 0 不创建令牌桶；rate > 0 时下载按速率限制
+func TestBatchExtractorRateLimit(t *testing.T) {
+	if be := NewBatchExtractor(New("https://example.com"), 0, 4); be.extractor.limiter != nil {
+		t.Error("rate 为 0 时不应创建令牌桶")
+	}
+
+	server := newMapServer(t, 0)
+	const rate, burst, count = 20, 1, 6
+	be := NewBatchExtractor(New(server.URL), rate, burst)
+	if be.extractor.limiter == nil {
+		t.Fatal("rate > 0 时应创建令牌桶")
+	}
+
+	var resources []*crawler.Resource
+	for i := range count {
+		resources = append(resources, server.resource(fmt.Sprint(i)))
+	}
+	start := time.Now()
+	if files := runStream(t, be, resources, 4, 10*time.Second); len(files) != count {
+		t.Fatalf("收到 %d 个源文件，应为 %d", len(files), count)
+	}
+	// 首个令牌立即可用，其余 count-1 个按 rate 补充
+	if min := time.Duration(count-burst) * time.Second / rate; time.Since(start) < min*8/10 {
+		t.Errorf("%d 次下载耗时 %s，限速 %d 次/秒时应不少于 %s", count, time.Since(start), rate, min)
+	}
+}
+
+func TestTokenBucketConcurrent(t *testing.T) {
+	tb := newTokenBucket(50, 5)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range 15 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tb.wait()
+		}()
+	}
+	wg.Wait()
+	// 5 个令牌立即可用，其余 10 个按 50 次/秒补充，约 200ms
+	if elapsed := time.Since(start); elapsed < 160*time.Millisecond {
+		t.Errorf("15 次取令牌耗时 %s，应不少于约 200ms", elapsed)
+	}
+}
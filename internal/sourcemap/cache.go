@@ -0,0 +1,43 @@
+package sourcemap
+
+import (
+	"time"
+
+	"spider/internal/lru"
+)
+
+// MapCache 默认上限：批量 / 递归爬取中各页面引用的同一 vendor map 只下载一次，
+// 同时保证长时间运行时缓存不随页面数增长
+const (
+	DefaultMapCacheEntries = 256
+	DefaultMapCacheBytes   = 64 * 1024 * 1024
+	DefaultMapCacheTTL     = 10 * time.Minute
+)
+
+// MapCache 按 URL 缓存下载成功的 source map 内容，可由多个 Extractor 共享（并发安全）。
+// 条目数、总字节数和有效期都有上限，最久未用的先被淘汰
+type MapCache struct {
+	maps *lru.Cache[string, []byte]
+}
+
+// NewMapCache 创建最多 maxEntries 个条目、合计 maxBytes 字节的缓存，条目在下载 ttl 之后过期
+func NewMapCache(maxEntries int, maxBytes int64, ttl time.Duration) *MapCache {
+	return &MapCache{maps: lru.NewWithCost[string, []byte](maxEntries, maxBytes, ttl, func(b []byte) int64 {
+		return int64(len(b))
+	})}
+}
+
+// Len 当前缓存的 source map 数
+func (mc *MapCache) Len() int {
+	return mc.maps.Len()
+}
+
+// Bytes 当前缓存的总字节数
+func (mc *MapCache) Bytes() int64 {
+	return mc.maps.Cost()
+}
+
+// WithMapCache 下载 source map 前先查 cache，下载成功后写入；nil 表示不缓存
+func WithMapCache(cache *MapCache) Option {
+	return func(sme *Extractor) { sme.mapCache = cache }
+}
@@ -0,0 +1,69 @@
+package sourcemap
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+)
+
+const cachedMap = `{"version":3,"sources":["src/app.ts"],"sourcesContent":["export const x = 1;\n"],"mappings":"AAAA"}`
+
+// 共享 MapCache 的多个 Extractor（批量爬取中每个 URL 一个）只下载一次同一个 source map
+func TestMapCacheSharedAcrossExtractors(t *testing.T) {
+	var gets atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets.Add(1)
+		}
+		w.Write([]byte(cachedMap))
+	}))
+	defer server.Close()
+
+	cache := NewMapCache(DefaultMapCacheEntries, DefaultMapCacheBytes, DefaultMapCacheTTL)
+	res := &crawler.Resource{
+		URL:      server.URL + "/app.js",
+		MimeType: "application/javascript",
+		Content:  []byte("console.log(1);\n//# sourceMappingURL=app.js.map\n"),
+		Headers:  map[string]string{},
+	}
+	for i := range 3 {
+		files, err := New(server.URL, WithMapCache(cache)).ExtractFromResource(res)
+		if err != nil {
+			t.Fatalf("第 %d 次提取失败: %v", i+1, err)
+		}
+		if len(files) != 1 {
+			t.Fatalf("第 %d 次提取得到 %d 个源文件，应为 1", i+1, len(files))
+		}
+	}
+	if n := gets.Load(); n != 1 {
+		t.Errorf("source map 被下载了 %d 次，应只下载 1 次", n)
+	}
+	if cache.Len() != 1 || cache.Bytes() != int64(len(cachedMap)) {
+		t.Errorf("缓存中有 %d 个条目、%d 字节，应为 1 个、%d 字节", cache.Len(), cache.Bytes(), len(cachedMap))
+	}
+}
+
+// 缓存的总字节数有上限，大量不同的 map 不会让内存无限增长
+func TestMapCacheBounded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(cachedMap))
+	}))
+	defer server.Close()
+
+	maxBytes := int64(len(cachedMap) * 10)
+	cache := NewMapCache(1000, maxBytes, time.Minute)
+	sme := New(server.URL, WithMapCache(cache))
+	for i := range 100 {
+		if _, err := sme.downloadSourceMap(fmt.Sprintf("%s/%d.map", server.URL, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cache.Bytes() > maxBytes || cache.Len() != 10 {
+		t.Errorf("缓存有 %d 个条目、%d 字节，应不超过 %d 字节（10 个条目）", cache.Len(), cache.Bytes(), maxBytes)
+	}
+}
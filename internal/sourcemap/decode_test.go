@@ -0,0 +1,109 @@
+package sourcemap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"spider/internal/crawler"
+	"spider/internal/storage"
+)
+
+// testdata/encoded-sources.js.map 的 sources 含 %20、%5B%5D、%3F（后面还有真正的 ?v=3）和编码过的 ../
+func TestPercentEncodedSources(t *testing.T) {
+	mapData, err := os.ReadFile(filepath.Join("testdata", "encoded-sources.js.map"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(mapData)
+	}))
+	defer server.Close()
+
+	treeDir := filepath.Join(t.TempDir(), "source-tree")
+	sme := New(server.URL, WithSourceTreeDir(treeDir))
+	files, err := sme.ExtractFromResource(&crawler.Resource{
+		URL:      server.URL + "/static/js/app.js",
+		MimeType: "application/javascript",
+		Content:  []byte("//# sourceMappingURL=app.js.map\n"),
+		Headers:  map[string]string{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 源文件 URL：路径部分是解码后的字面路径，只有原本就未编码的 ?v=3 是查询串
+	want := []struct{ path, query string }{
+		{"/static/js/src/components/Item List.ts", ""},
+		{"/static/js/src/pages/[id]/page.tsx", ""},
+		{"/static/js/src/what?not.ts", "v=3"},
+		{"/etc/passwd.ts", ""}, // 解码出的 ../ 按 URL 规则消去，停在站点根目录
+	}
+	if len(files) != len(want) {
+		t.Fatalf("提取出 %d 个源文件，应为 %d", len(files), len(want))
+	}
+	resources := make(map[string]*crawler.Resource)
+	for i, f := range files {
+		u, err := url.Parse(f.URL)
+		if err != nil {
+			t.Fatalf("无效的源文件 URL %q: %v", f.URL, err)
+		}
+		if u.Path != want[i].path || u.RawQuery != want[i].query {
+			t.Errorf("源文件 %d: URL %s 的路径为 %q、查询串为 %q，应为 %q、%q", i, f.URL, u.Path, u.RawQuery, want[i].path, want[i].query)
+		}
+		resources[f.URL] = f
+	}
+
+	// 保存后的文件名是解码后的形式
+	outDir := t.TempDir()
+	if err := storage.NewFlat(outDir).Save(resources); err != nil {
+		t.Fatal(err)
+	}
+	for _, rel := range []string{
+		"static/js/src/components/Item List.ts",
+		"static/js/src/pages/[id]/page.tsx",
+		"static/js/src/what?not.ts_v_3",
+		"etc/passwd.ts",
+	} {
+		if _, err := os.Stat(filepath.Join(outDir, filepath.FromSlash(rel))); err != nil {
+			t.Errorf("缺少保存的源文件 %s: %v", rel, err)
+		}
+	}
+
+	// source-tree 按解码后的原始路径还原，越出根目录的 ../ 被截掉，不会写到 treeDir 之外
+	for _, rel := range []string{
+		"src/components/Item List.ts",
+		"src/pages/[id]/page.tsx",
+		"src/what?not.ts",
+		"etc/passwd.ts",
+	} {
+		if _, err := os.Stat(filepath.Join(treeDir, filepath.FromSlash(rel))); err != nil {
+			t.Errorf("source-tree 中缺少 %s: %v", rel, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(treeDir), "etc")); err == nil {
+		t.Error("编码过的 ../ 越出了 source-tree 目录")
+	}
+}
+
+func TestSourceTreePathClamp(t *testing.T) {
+	tests := []struct{ source, root, want string }{
+		{"webpack:///./src/a%20b.ts", "", "src/a b.ts"},
+		{"src/what%3Fnot.ts?v=3", "", "src/what?not.ts"},
+		{"src/%23hash.ts#L1", "", "src/#hash.ts"},
+		{"%2e%2e%2f%2e%2e%2fetc/passwd", "", "etc/passwd"},
+		{"..%2F..%2F..%2Fsecret.ts", "app", "secret.ts"},
+		{"../node_modules/x/index.js", "", "node_modules/x/index.js"},
+		{"C:\\build\\src\\main.ts", "", "build/src/main.ts"},
+		{"100%.ts", "", "100%.ts"},
+		{"%2e%2e", "", ""},
+	}
+	for _, tt := range tests {
+		if got := sourceTreePath(tt.source, tt.root); got != tt.want {
+			t.Errorf("sourceTreePath(%q, %q) = %q，应为 %q", tt.source, tt.root, got, tt.want)
+		}
+	}
+}
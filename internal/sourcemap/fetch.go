@@ -0,0 +1,128 @@
+package sourcemap
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"spider/internal/logging"
+)
+
+// WithSourceFetchWorkers source map 没有内联 sourcesContent 时，按 sources 中的地址（相对 map 解析）
+// 下载原始源文件，最多 workers 个并发；0 表示不下载，只提取内联内容。
+// 下载共用提取器的 HTTP 客户端和限速，并受 WithSameOriginMapsOnly / WithMapHostAllowlist 限制。
+func WithSourceFetchWorkers(workers int) Option {
+	return func(sme *Extractor) { sme.sourceFetchWorkers = workers }
+}
+
+// fetchMissingSources 为缺少内容的 http(s) 源下载原文并填回 sm.SourcesContent。
+// 每个源独立容错：下载失败只记录警告，不影响其他源和内联内容的提取。
+func (sme *Extractor) fetchMissingSources(sm *SourceMap, mapURL string) {
+	if sme.sourceFetchWorkers <= 0 {
+		return
+	}
+	base, err := url.Parse(mapURL)
+	if err != nil {
+		return
+	}
+
+	type job struct {
+		index int
+		url   string
+	}
+	var jobs []job
+	for i, source := range sm.Sources {
+		if i < len(sm.SourcesContent) && sm.SourcesContent[i] != "" || sm.limits.skipped[i] {
+			continue
+		}
+		if sourceURL := sme.resolveFetchURL(base, source, sm.SourceRoot); sourceURL != "" {
+			jobs = append(jobs, job{index: i, url: sourceURL})
+		}
+	}
+	if len(jobs) == 0 {
+		return
+	}
+	if len(sm.SourcesContent) < len(sm.Sources) {
+		sm.SourcesContent = append(sm.SourcesContent, make([]string, len(sm.Sources)-len(sm.SourcesContent))...)
+	}
+
+	queue := make(chan job)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	fetched := 0
+	for range min(sme.sourceFetchWorkers, len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				content, err := sme.fetchSource(j.url)
+				if err != nil {
+					logging.Warnf("下载源文件失败 %s: %v", j.url, err)
+					continue
+				}
+				// 各 worker 写不同下标，无需加锁
+				sm.SourcesContent[j.index] = string(content)
+				mu.Lock()
+				fetched++
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, j := range jobs {
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
+
+	log.Printf("Source Map %s 缺少 %d 个源文件的内容，已下载 %d 个", mapURL, len(jobs), fetched)
+}
+
+// resolveFetchURL 按 source map 规范把 sourceRoot + source 相对 map 地址解析为可下载的 URL，
+// 解析结果不是 http(s)（如 webpack://）或被主机限制拒绝时返回空字符串
+func (sme *Extractor) resolveFetchURL(base *url.URL, source, sourceRoot string) string {
+	raw := source
+	if sourceRoot != "" {
+		raw = strings.TrimSuffix(sourceRoot, "/") + "/" + strings.TrimPrefix(source, "/")
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	resolved := base.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ""
+	}
+	fullURL := resolved.String()
+	if reason := sme.mapHostDenied(base.String(), raw, fullURL); reason != "" {
+		log.Printf("跳过源文件 %s: %s", fullURL, reason)
+		return ""
+	}
+	return fullURL
+}
+
+// fetchSource 下载单个源文件，大小上限与 source map 相同
+func (sme *Extractor) fetchSource(rawURL string) ([]byte, error) {
+	if sme.limiter != nil {
+		sme.limiter.wait()
+	}
+
+	resp, err := sme.client.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	limit := int64(maxSourceMapBytes)
+	if sme.maxSize > 0 {
+		limit = sme.maxSize
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
@@ -0,0 +1,130 @@
+package sourcemap
+
+import (
+	"bytes"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"spider/internal/crawler"
+	"spider/internal/logging"
+)
+
+// WithHTMLInlineMaps 同时解析抓到的 HTML 页面，从内联 <script> / <style> 块中查找 sourceMappingURL。
+// 直接写在页面里的启动脚本不是单独的资源，不开启时它引用的 map 不会被发现；
+// 相对地址按页面的 <base href>（没有时为页面 URL）解析，提取出的源文件归属到该页面（Resource.Pages）
+func WithHTMLInlineMaps(enabled bool) Option {
+	return func(sme *Extractor) { sme.htmlInlineMaps = enabled }
+}
+
+// isHTML 资源是否为 HTML 页面
+func isHTML(res *crawler.Resource) bool {
+	mime := res.ContentMimeType()
+	return strings.Contains(mime, "text/html") || strings.Contains(mime, "application/xhtml+xml")
+}
+
+// extractFromHTML 提取页面中各内联块引用的 source map，同一 map 只提取一次
+func (sme *Extractor) extractFromHTML(res *crawler.Resource) ([]*crawler.Resource, error) {
+	var mapURLs []string
+	for _, block := range inlineBlocks(res.Content) {
+		if u := sme.findSourceMapURL(block); u != "" && !slices.Contains(mapURLs, u) {
+			mapURLs = append(mapURLs, u)
+		}
+	}
+	if len(mapURLs) == 0 {
+		return nil, nil
+	}
+	log.Printf("页面 %s 的内联脚本 / 样式引用了 %d 个 Source Map", res.URL, len(mapURLs))
+
+	// 爬取时读到了 document.baseURI 的（主文档）用它，否则从文档中找 <base href>
+	base := res.BaseURL
+	if base == "" {
+		base = documentBase(res.Content, res.URL)
+	}
+	var resources []*crawler.Resource
+	for _, u := range mapURLs {
+		extracted, err := sme.extractMap(res, u, base)
+		if err != nil {
+			logging.Warnf("提取页面内联 Source Map 失败 %s: %v", res.URL, err)
+			continue
+		}
+		for _, r := range extracted {
+			r.Pages = []string{res.URL}
+		}
+		resources = append(resources, extracted...)
+	}
+	return resources, nil
+}
+
+// inlineBlocks 按文档顺序返回没有 src 的 <script> 和 <style> 的文本内容
+func inlineBlocks(document []byte) []string {
+	var blocks []string
+	z := html.NewTokenizer(bytes.NewReader(document))
+	inBlock := false
+	var text strings.Builder
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return blocks
+		case html.StartTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			if tag != "script" && tag != "style" {
+				continue
+			}
+			inBlock = true
+			for hasAttr && tag == "script" {
+				var key []byte
+				key, _, hasAttr = z.TagAttr()
+				if string(key) == "src" {
+					inBlock = false
+				}
+			}
+			text.Reset()
+		case html.TextToken:
+			if inBlock {
+				text.Write(z.Text())
+			}
+		case html.EndTagToken:
+			if inBlock {
+				blocks = append(blocks, text.String())
+				inBlock = false
+			}
+		}
+	}
+}
+
+// documentBase 文档中第一个带 href 的 <base> 按 pageURL 解析后的地址，没有或无法解析时返回 pageURL
+func documentBase(document []byte, pageURL string) string {
+	z := html.NewTokenizer(bytes.NewReader(document))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return pageURL
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "base" {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) != "href" {
+					continue
+				}
+				page, err := url.Parse(pageURL)
+				if err != nil {
+					return pageURL
+				}
+				u, err := page.Parse(strings.TrimSpace(string(val)))
+				if err != nil {
+					return pageURL
+				}
+				return u.String()
+			}
+		}
+	}
+}
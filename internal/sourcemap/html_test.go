@@ -0,0 +1,171 @@
+package sourcemap
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"spider/internal/crawler"
+)
+
+// mapRecorder 为任意 .map 路径返回只含一个源文件 src/<文件名去掉扩展名>.ts 的 map，并记录每个路径的请求次数
+type mapRecorder struct {
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func newMapRecorder(t *testing.T) (*httptest.Server, *mapRecorder) {
+	rec := &mapRecorder{requests: make(map[string]int)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.mu.Lock()
+		rec.requests[r.URL.Path]++
+		rec.mu.Unlock()
+		name, _, _ := strings.Cut(filepath.Base(r.URL.Path), ".")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"version":3,"sources":["src/%s.ts"],"sourcesContent":["// %s\n"],"mappings":"AAAA"}`, name, r.URL.Path)
+	}))
+	t.Cleanup(server.Close)
+	return server, rec
+}
+
+func (rec *mapRecorder) count(path string) int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.requests[path]
+}
+
+func inlineFixture(t *testing.T, pageURL string) *crawler.Resource {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "inline.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &crawler.Resource{URL: pageURL, MimeType: "text/html", Content: data, Headers: map[string]string{}}
+}
+
+func extractedURLs(files []*crawler.Resource) []string {
+	var urls []string
+	for _, f := range files {
+		urls = append(urls, f.URL)
+	}
+	slices.Sort(urls)
+	return urls
+}
+
+// 内联 <script> / <style> 末尾的 sourceMappingURL 按页面地址解析；带 src 的外部脚本里的注释不算，
+// 同一 map 只下载一次，提取出的源文件归属到该页面
+func TestExtractFromHTMLInlineBlocks(t *testing.T) {
+	server, rec := newMapRecorder(t)
+	page := inlineFixture(t, server.URL+"/app/page.html")
+
+	files, err := New(server.URL, WithHTMLInlineMaps(true)).ExtractFromResource(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		server.URL + "/app/src/bootstrap.ts",
+		server.URL + "/css/src/theme.ts",
+	}
+	if got := extractedURLs(files); !slices.Equal(got, want) {
+		t.Fatalf("提取出 %v，应为 %v", got, want)
+	}
+	for _, f := range files {
+		if !slices.Equal(f.Pages, []string{page.URL}) {
+			t.Errorf("%s 的 Pages 为 %v，应为生成它的页面", f.URL, f.Pages)
+		}
+	}
+	if n := rec.count("/app/bootstrap.js.map"); n != 1 {
+		t.Errorf("bootstrap.js.map 请求了 %d 次，应为 1", n)
+	}
+	if n := rec.count("/app/ignored.js.map"); n != 0 {
+		t.Error("带 src 的 <script> 中的 sourceMappingURL 不应被提取")
+	}
+}
+
+// 相对地址优先按爬取时读到的 document.baseURI，其次按文档中的 <base href> 解析
+func TestExtractFromHTMLBase(t *testing.T) {
+	server, rec := newMapRecorder(t)
+	doc := `<html><head><base href="/static/v2/"></head><body><script>run();
+//# sourceMappingURL=boot.js.map
+</script></body></html>`
+
+	page := &crawler.Resource{URL: server.URL + "/index.html", MimeType: "text/html; charset=utf-8", Content: []byte(doc), Headers: map[string]string{}}
+	if _, err := New(server.URL, WithHTMLInlineMaps(true)).ExtractFromResource(page); err != nil {
+		t.Fatal(err)
+	}
+	if rec.count("/static/v2/boot.js.map") != 1 {
+		t.Errorf("应按 <base href> 请求 /static/v2/boot.js.map，实际请求 %v", rec.requests)
+	}
+
+	page.BaseURL = server.URL + "/cdn/"
+	if _, err := New(server.URL, WithHTMLInlineMaps(true)).ExtractFromResource(page); err != nil {
+		t.Fatal(err)
+	}
+	if rec.count("/cdn/boot.js.map") != 1 {
+		t.Errorf("有 BaseURL 时应请求 /cdn/boot.js.map，实际请求 %v", rec.requests)
+	}
+}
+
+// 未开启时 HTML 页面不做处理
+func TestExtractFromHTMLDisabled(t *testing.T) {
+	server, rec := newMapRecorder(t)
+	files, err := New(server.URL).ExtractFromResource(inlineFixture(t, server.URL+"/app/page.html"))
+	if err != nil || len(files) != 0 || len(rec.requests) != 0 {
+		t.Errorf("未开启 WithHTMLInlineMaps 时提取出 %v（%v），请求 %v", files, err, rec.requests)
+	}
+}
+
+func TestInlineBlocks(t *testing.T) {
+	doc := `<script src="a.js">ignored</script><script>one</script><style>two</style><SCRIPT>three</SCRIPT><p>text</p>`
+	if got := inlineBlocks([]byte(doc)); !slices.Equal(got, []string{"one", "two", "three"}) {
+		t.Errorf("inlineBlocks = %q", got)
+	}
+}
+
+func TestDocumentBase(t *testing.T) {
+	for doc, want := range map[string]string{
+		`<base href="/static/">`:                   "https://example.com/static/",
+		`<base target="_blank"><base href="x/">`:   "https://example.com/app/x/",
+		`<base href="https://cdn.example.net/a/">`: "https://cdn.example.net/a/",
+		`<p>no base</p>`:                           "https://example.com/app/page.html",
+	} {
+		if got := documentBase([]byte(doc), "https://example.com/app/page.html"); got != want {
+			t.Errorf("documentBase(%q) = %q，应为 %q", doc, got, want)
+		}
+	}
+}
+
+// 内联合成资源中的相对 sourceMappingURL 按所在页面解析，页面的 <base href> 指向另一个源（BaseURL）时按它解析
+func TestExtractInlineResourceBase(t *testing.T) {
+	pageServer, pageRec := newMapRecorder(t)
+	cdnServer, cdnRec := newMapRecorder(t)
+	script := func(base string) *crawler.Resource {
+		return &crawler.Resource{URL: crawler.InlineScheme + "://example.com/inline_script_1.js", MimeType: "application/javascript",
+			Content: []byte("run();\n//# sourceMappingURL=boot.js.map\n"), Headers: map[string]string{},
+			Pages: []string{pageServer.URL + "/app/page.html"}, BaseURL: base}
+	}
+
+	if _, err := New(pageServer.URL).ExtractFromResource(script("")); err != nil {
+		t.Fatal(err)
+	}
+	if pageRec.count("/app/boot.js.map") != 1 {
+		t.Errorf("没有 <base href> 时应按页面请求 /app/boot.js.map，实际请求 %v", pageRec.requests)
+	}
+
+	files, err := New(pageServer.URL).ExtractFromResource(script(cdnServer.URL + "/assets/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cdnRec.count("/assets/boot.js.map") != 1 || len(pageRec.requests) != 1 {
+		t.Errorf("应按 <base href> 向另一个源请求 /assets/boot.js.map，实际请求 %v / %v", cdnRec.requests, pageRec.requests)
+	}
+	if urls := extractedURLs(files); !slices.Equal(urls, []string{cdnServer.URL + "/assets/src/boot.ts"}) {
+		t.Errorf("提取出 %v", urls)
+	}
+}
@@ -0,0 +1,156 @@
+package sourcemap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// 提取上限的默认值，防止损坏或恶意的 map 在 sourcesContent 中塞入巨量内容撑爆内存和磁盘
+const (
+	DefaultMaxSourceBytes    = 10 * 1024 * 1024  // 单个源文件 10 MB
+	DefaultMaxMapSourceBytes = 200 * 1024 * 1024 // 单个 map 的源文件合计 200 MB
+	DefaultMaxSources        = 10000             // 单个 map 的源文件数
+)
+
+// WithMaxSourceBytes 单个源文件的大小上限（按 JSON 中转义后的长度计），超出的跳过；<=0 表示不限制
+func WithMaxSourceBytes(n int64) Option {
+	return func(sme *Extractor) { sme.maxSourceBytes = n }
+}
+
+// WithMaxMapSourceBytes 单个 map 中源文件的合计大小上限，累计超出后剩余的源文件全部跳过；<=0 表示不限制
+func WithMaxMapSourceBytes(n int64) Option {
+	return func(sme *Extractor) { sme.maxMapSourceBytes = n }
+}
+
+// WithMaxSources 单个 map 的源文件数上限，超出部分的 sources 和 sourcesContent 丢弃；<=0 表示不限制
+func WithMaxSources(n int) Option {
+	return func(sme *Extractor) { sme.maxSources = n }
+}
+
+// sourceLimits 解析 sourcesContent 时因上限跳过的源文件
+type sourceLimits struct {
+	oversized int          // 超过单文件上限
+	overTotal int          // 超过 map 合计上限
+	overCount int          // 超过源文件数上限
+	skipped   map[int]bool // 被跳过内容的源文件下标（不含 overCount），不再按地址下载
+}
+
+func (l sourceLimits) total() int {
+	return l.oversized + l.overTotal + l.overCount
+}
+
+func (l sourceLimits) String() string {
+	return fmt.Sprintf("%d oversized, %d over map total, %d over source count", l.oversized, l.overTotal, l.overCount)
+}
+
+// sourcesContentList 按上限解码 sourcesContent 数组。逐个扫描元素，先按原始 JSON 长度判断，
+// 跳过的元素不会被解码或复制，巨大的 map 只占用下载内容本身的内存
+type sourcesContentList struct {
+	maxSourceBytes int64
+	maxTotalBytes  int64
+	maxSources     int
+
+	items  []string
+	total  int64
+	limits sourceLimits
+}
+
+func (l *sourcesContentList) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) < 2 || data[0] != '[' {
+		return errors.New("sourcesContent 不是数组")
+	}
+	i := skipJSONSpace(data, 1)
+	if i < len(data) && data[i] == ']' {
+		return nil
+	}
+	for {
+		end, err := scanContentValue(data, i)
+		if err != nil {
+			return err
+		}
+		if err := l.add(data[i:end]); err != nil {
+			return err
+		}
+		i = skipJSONSpace(data, end)
+		if i >= len(data) {
+			return errors.New("sourcesContent 数组不完整")
+		}
+		switch data[i] {
+		case ',':
+			i = skipJSONSpace(data, i+1)
+		case ']':
+			return nil
+		default:
+			return fmt.Errorf("sourcesContent 第 %d 字节处格式错误", i)
+		}
+	}
+}
+
+// add 按上限决定是否解码一个元素，跳过的元素记为空内容
+func (l *sourcesContentList) add(raw []byte) error {
+	idx := len(l.items)
+	if raw[0] == 'n' {
+		l.items = append(l.items, "")
+		return nil
+	}
+	size := int64(len(raw) - 2)
+	switch {
+	case l.maxSources > 0 && idx >= l.maxSources:
+		// 超出数量上限的部分随 sources 一起丢弃，在 parseSourceMap 中计数
+		l.items = append(l.items, "")
+		return nil
+	case l.maxSourceBytes > 0 && size > l.maxSourceBytes:
+		l.limits.oversized++
+	case l.maxTotalBytes > 0 && l.total+size > l.maxTotalBytes:
+		l.limits.overTotal++
+	default:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+		l.items = append(l.items, s)
+		l.total += size
+		return nil
+	}
+	if l.limits.skipped == nil {
+		l.limits.skipped = make(map[int]bool)
+	}
+	l.limits.skipped[idx] = true
+	l.items = append(l.items, "")
+	return nil
+}
+
+// scanContentValue 返回从 i 开始的字符串或 null 的结束位置（不含），只定位不解码
+func scanContentValue(data []byte, i int) (int, error) {
+	if i >= len(data) {
+		return 0, errors.New("sourcesContent 数组不完整")
+	}
+	if bytes.HasPrefix(data[i:], []byte("null")) {
+		return i + 4, nil
+	}
+	if data[i] != '"' {
+		return 0, fmt.Errorf("sourcesContent 元素应为字符串或 null（第 %d 字节）", i)
+	}
+	for j := i + 1; j < len(data); j++ {
+		switch data[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1, nil
+		}
+	}
+	return 0, errors.New("sourcesContent 字符串未结束")
+}
+
+func skipJSONSpace(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t' || data[i] == '\r' || data[i] == '\n') {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,125 @@
+package sourcemap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func decodeContents(t *testing.T, l *sourcesContentList, data string) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(data), l); err != nil {
+		t.Fatalf("解析 %s 失败: %v", data, err)
+	}
+}
+
+func TestSourcesContentPerEntryLimit(t *testing.T) {
+	l := &sourcesContentList{maxSourceBytes: 10}
+	decodeContents(t, l, `["short", "this one is far too long", null, "A\n"]`)
+
+	want := []string{"short", "", "", "A\n"}
+	if fmt.Sprint(l.items) != fmt.Sprint(want) {
+		t.Errorf("items = %q，应为 %q", l.items, want)
+	}
+	if l.limits.oversized != 1 || !l.limits.skipped[1] || len(l.limits.skipped) != 1 {
+		t.Errorf("limits = %+v，应只有下标 1 超过单文件上限", l.limits)
+	}
+}
+
+func TestSourcesContentTotalLimit(t *testing.T) {
+	l := &sourcesContentList{maxTotalBytes: 12}
+	decodeContents(t, l, `["aaaaa","bbbbb","ccccc","d"]`)
+
+	// 前两个合计 10 字节，第三个会超出 12，第四个仍放得下
+	want := []string{"aaaaa", "bbbbb", "", "d"}
+	if fmt.Sprint(l.items) != fmt.Sprint(want) {
+		t.Errorf("items = %q，应为 %q", l.items, want)
+	}
+	if l.limits.overTotal != 1 || !l.limits.skipped[2] || l.total != 11 {
+		t.Errorf("limits = %+v、total = %d，应为下标 2 超过合计上限、合计 11 字节", l.limits, l.total)
+	}
+}
+
+func TestSourcesContentCountLimit(t *testing.T) {
+	sme := New("https://example.com", WithMaxSources(2))
+	sm, err := sme.parseSourceMap([]byte(`{"version":3,"sources":["a.ts","b.ts","c.ts"],"sourcesContent":["a","b","c"],"mappings":""}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sm.Sources) != 2 || len(sm.SourcesContent) != 2 || sm.SourcesContent[1] != "b" {
+		t.Errorf("sources = %q、sourcesContent = %q，应只保留前 2 个", sm.Sources, sm.SourcesContent)
+	}
+	if sm.limits.overCount != 1 || sm.limits.total() != 1 {
+		t.Errorf("limits = %+v，应为 1 个超过数量上限", sm.limits)
+	}
+}
+
+func TestSourcesContentMalformed(t *testing.T) {
+	for _, data := range []string{
+		`{"a":1}`,
+		`["unterminated`,
+		`["a" "b"]`,
+		`["a", 1]`,
+		`["a",`,
+	} {
+		l := &sourcesContentList{}
+		if err := l.UnmarshalJSON([]byte(data)); err == nil {
+			t.Errorf("%s 应解析失败", data)
+		}
+	}
+	l := &sourcesContentList{}
+	for _, data := range []string{`null`, `[]`, ` [ ] `} {
+		if err := l.UnmarshalJSON([]byte(data)); err != nil || len(l.items) != 0 {
+			t.Errorf("%s: err = %v、items = %q", data, err, l.items)
+		}
+	}
+}
+
+// 超过单文件上限的巨大元素只扫描不解码：解析一个含 64 MB 源文件的 map 分配的内存远小于该元素本身
+func TestSourcesContentSkipsWithoutAllocating(t *testing.T) {
+	const huge = 64 << 20
+	var buf bytes.Buffer
+	buf.WriteString(`{"version":3,"sources":["small.ts","huge.ts"],"sourcesContent":["export {};",`)
+	buf.WriteByte('"')
+	chunk := strings.Repeat(`x\n`, 1<<16)
+	for buf.Len() < huge {
+		buf.WriteString(chunk)
+	}
+	buf.WriteString(`"],"mappings":"AAAA"}`)
+	data := buf.Bytes()
+
+	sme := New("https://example.com", WithMaxSourceBytes(1<<20))
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	sm, err := sme.parseSourceMap(data)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sm.SourcesContent[0] != "export {};" || sm.SourcesContent[1] != "" || sm.limits.oversized != 1 {
+		t.Fatalf("sourcesContent = %q…，limits = %+v", sm.SourcesContent[0], sm.limits)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 4<<20 {
+		t.Errorf("解析分配了 %d 字节，跳过的 %d 字节元素不应被解码或复制", allocated, len(data))
+	}
+}
+
+// 跳过元素的分配次数与其大小无关
+func TestSourcesContentSkipAllocs(t *testing.T) {
+	data := []byte(`["` + strings.Repeat("y", 1<<20) + `","small"]`)
+	allocs := testing.AllocsPerRun(20, func() {
+		l := &sourcesContentList{maxSourceBytes: 1024}
+		if err := l.UnmarshalJSON(data); err != nil {
+			t.Fatal(err)
+		}
+	})
+	// items 切片、"small" 字符串和 skipped map 各一两次
+	if allocs > 8 {
+		t.Errorf("每次解析分配 %.0f 次，跳过的 1 MB 元素不应产生额外分配", allocs)
+	}
+}
@@ -0,0 +1,103 @@
+package sourcemap
+
+import (
+	"encoding/json"
+	"strings"
+
+	"spider/internal/crawler"
+)
+
+// MapMeta 单个 source map 的概要（<name>.map.meta.json），用于快速判断 map 是否完整
+type MapMeta struct {
+	URL        string `json:"url"`  // source map 地址，内联 map 为引用它的资源地址
+	File       string `json:"file"` // map 声明的生成文件
+	Version    int    `json:"version"`
+	SourceRoot string `json:"source_root,omitempty"`
+
+	SourceCount         int      `json:"source_count"`
+	HasSourcesContent   bool     `json:"has_sources_content"`   // map 本身是否带 sourcesContent
+	InlineSources       int      `json:"inline_sources"`        // map 内联了内容的源文件数
+	FetchedSources      int      `json:"fetched_sources"`       // 按 sources 地址下载到内容的源文件数（WithSourceFetchWorkers）
+	MissingSources      []string `json:"missing_sources"`       // 最终仍没有内容的源文件（不含因提取上限跳过的）
+	SkippedSources      int      `json:"skipped_sources"`       // 因提取上限（大小、数量）跳过的源文件数
+	ReconstructedBytes  int      `json:"reconstructed_bytes"`   // 可还原的源文件总字节数
+	NameCount           int      `json:"name_count"`            // names 中的标识符数
+	MappingsBytes       int      `json:"mappings_bytes"`        // mappings 字符串长度
+	MappedLines         int      `json:"mapped_lines"`          // mappings 覆盖的生成文件行数
+	MappingSegments     int      `json:"mapping_segments"`      // mappings 中的映射段数
+	SourcesContentRatio float64  `json:"sources_content_ratio"` // 有内容的源文件比例（0-1）
+}
+
+// WithMapMetadata 每个解析成功的 source map 额外生成一个 <map 地址>.meta.json 资源，
+// 汇总源文件数、是否内联 sourcesContent、可还原的字节数和 mappings 统计
+func WithMapMetadata(enabled bool) Option {
+	return func(sme *Extractor) { sme.mapMetadata = enabled }
+}
+
+// inlineSourceCount 统计内联了内容的源文件数，需在 fetchMissingSources 之前调用
+func (sm *SourceMap) inlineSourceCount() int {
+	n := 0
+	for i := range sm.Sources {
+		if i < len(sm.SourcesContent) && sm.SourcesContent[i] != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Meta 汇总 source map 的概要；inlineSources 为下载缺失源文件之前已有内容的源文件数
+func (sm *SourceMap) Meta(mapURL string, inlineSources int) *MapMeta {
+	meta := &MapMeta{
+		URL:               mapURL,
+		File:              sm.File,
+		Version:           sm.Version,
+		SourceRoot:        sm.SourceRoot,
+		SourceCount:       len(sm.Sources),
+		HasSourcesContent: inlineSources > 0,
+		InlineSources:     inlineSources,
+		MissingSources:    []string{},
+		SkippedSources:    sm.limits.total(),
+		NameCount:         len(sm.Names),
+		MappingsBytes:     len(sm.Mappings),
+	}
+
+	withContent := 0
+	for i, source := range sm.Sources {
+		if i < len(sm.SourcesContent) && sm.SourcesContent[i] != "" {
+			withContent++
+			meta.ReconstructedBytes += len(sm.SourcesContent[i])
+		} else if !sm.limits.skipped[i] {
+			meta.MissingSources = append(meta.MissingSources, source)
+		}
+	}
+	meta.FetchedSources = withContent - inlineSources
+	if meta.SourceCount > 0 {
+		meta.SourcesContentRatio = float64(withContent) / float64(meta.SourceCount)
+	}
+
+	// mappings 以 ; 分隔生成文件的行，行内以 , 分隔映射段
+	if sm.Mappings != "" {
+		meta.MappedLines = strings.Count(sm.Mappings, ";") + 1
+		for _, line := range strings.Split(sm.Mappings, ";") {
+			if line != "" {
+				meta.MappingSegments += strings.Count(line, ",") + 1
+			}
+		}
+	}
+	return meta
+}
+
+// metaResource 将概要包装为与 source map 同目录的 .meta.json 资源
+func metaResource(meta *MapMeta, metaURL string) (*crawler.Resource, error) {
+	content, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &crawler.Resource{
+		URL:        metaURL,
+		StatusCode: 200,
+		MimeType:   "application/json",
+		Content:    content,
+		Headers:    map[string]string{"X-Source": "SourceMapMeta"},
+	}, nil
+}
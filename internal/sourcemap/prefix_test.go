@@ -0,0 +1,76 @@
+package sourcemap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"spider/internal/crawler"
+)
+
+// testdata 中同一个 map 分别带 UTF-8 BOM、)]}' 前缀、BOM 加 )]}'\r\n、while(1); 前缀，
+// 下载后都应能解析并提取出 src/app.ts
+func TestPrefixedSourceMaps(t *testing.T) {
+	for _, name := range []string{"bom.js.map", "xssi.js.map", "bom-xssi.js.map", "while1.js.map"} {
+		t.Run(name, func(t *testing.T) {
+			mapData, err := os.ReadFile(filepath.Join("testdata", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.Write(mapData)
+			}))
+			defer server.Close()
+
+			files, err := New(server.URL).ExtractFromResource(&crawler.Resource{
+				URL:      server.URL + "/app.js",
+				MimeType: "application/javascript",
+				Content:  []byte("console.log(1);\n//# sourceMappingURL=" + name + "\n"),
+				Headers:  map[string]string{},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 1 || files[0].URL != server.URL+"/src/app.ts" {
+				t.Fatalf("提取结果为 %v，应只有 src/app.ts", files)
+			}
+			if got := string(files[0].Content); got != "export const app = 1;\n" {
+				t.Errorf("源文件内容为 %q", got)
+			}
+		})
+	}
+}
+
+func TestStripSourceMapPrefix(t *testing.T) {
+	const doc = `{"version":3}`
+	cases := map[string]string{
+		doc:                          doc,
+		"\xEF\xBB\xBF" + doc:         doc,
+		")]}'\n" + doc:               doc,
+		")]}'" + doc:                 doc,
+		")]}\n" + doc:                doc,
+		"\xEF\xBB\xBF)]}'\r\n" + doc: doc,
+		"  \n)]}'\n" + doc:           doc,
+		"while(1);" + doc:            doc,
+		"for(;;);\n" + doc:           doc,
+		"\n" + doc:                   "\n" + doc, // 没有前缀时原样交给 json.Unmarshal
+	}
+	for in, want := range cases {
+		if got := string(stripSourceMapPrefix([]byte(in))); got != want {
+			t.Errorf("stripSourceMapPrefix(%q) = %q，应为 %q", in, got, want)
+		}
+	}
+
+	// 只去掉开头的前缀，字符串里出现的 )]}' 不受影响
+	in := `{"version":3,"names":[")]}'"]}`
+	if got := string(stripSourceMapPrefix([]byte(in))); got != in {
+		t.Errorf("stripSourceMapPrefix(%q) = %q", in, got)
+	}
+	if !strings.HasPrefix(string(stripSourceMapPrefix([]byte("\xEF\xBB\xBFnot json"))), "not json") {
+		t.Error("BOM 应被去掉")
+	}
+}
@@ -0,0 +1,40 @@
+package sourcemap
+
+import (
+	"log"
+	"net/url"
+
+	"spider/internal/crawler"
+)
+
+// WithMapProbing 没有 sourceMappingURL 的 JS / CSS / WebAssembly 资源尝试下载同目录的 <文件名>.map
+// （如 app.js.map、module.wasm.map）。不少站点删掉了注释但仍部署了 map；
+// 不存在或内容不是 source map 时静默跳过，不记录警告
+func WithMapProbing(enabled bool) Option {
+	return func(sme *Extractor) { sme.probeMaps = enabled }
+}
+
+// probeSourceMap 按 <资源路径>.map（去掉查询串）探测 source map
+func (sme *Extractor) probeSourceMap(res *crawler.Resource) ([]*crawler.Resource, error) {
+	u, err := url.Parse(res.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Path == "" || u.Path == "/" {
+		return nil, nil
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	u.Path += ".map"
+	u.RawPath = ""
+	mapURL := u.String()
+
+	content, err := sme.downloadSourceMap(mapURL)
+	if err != nil {
+		return nil, nil
+	}
+	// SPA 常对任意路径返回 index.html，只接受看起来像 source map 的 JSON
+	sourceMap, err := sme.parseSourceMap(content)
+	if err != nil || sourceMap.Version != 3 || (len(sourceMap.Sources) == 0 && sourceMap.Mappings == "") {
+		return nil, nil
+	}
+	log.Printf("探测到 Source Map: %s", mapURL)
+	return sme.extractParsed(res, mapURL, sourceMap), nil
+}
@@ -1,6 +1,8 @@
 package sourcemap
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,6 +16,7 @@ import (
 	"time"
 
 	"spider/internal/crawler"
+	"spider/internal/logging"
 )
 
 // 包级别编译，避免在热路径中重复编译
@@ -24,6 +27,18 @@ var (
 
 const maxSourceMapBytes = 10 * 1024 * 1024 // 10 MB
 
+// utf8BOM UTF-8 字节序标记，部分服务器/构建工具会写在 map 文件开头
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// xssiPrefixes 防 JSON 劫持前缀，source map 规范允许以 )]}' 开头的首行，
+// 部分框架还会使用 while(1); / for(;;); 等形式
+var xssiPrefixes = [][]byte{
+	[]byte(")]}'"),
+	[]byte(")]}"),
+	[]byte("while(1);"),
+	[]byte("for(;;);"),
+}
+
 // SourceMap 表示source map文件的结构
 type SourceMap struct {
 	Version        int      `json:"version"`
@@ -33,65 +48,207 @@ type SourceMap struct {
 	Mappings       string   `json:"mappings"`
 	File           string   `json:"file"`
 	SourceRoot     string   `json:"sourceRoot"`
+
+	limits sourceLimits // 解析时因提取上限跳过的源文件
 }
 
 // Extractor source map提取器
 type Extractor struct {
 	baseURL string
 	client  *http.Client
+	maxSize int64        // source map 大小上限（字节），0 表示不预检
+	limiter *tokenBucket // 下载限速（BatchExtractor 设置），nil 表示不限速
+
+	mapHostAllowlist   []string // 允许下载 source map 的主机（支持 *.example.com）
+	sameOriginMapsOnly bool     // 绝对地址的 source map 只允许与引用它的资源同源
+
+	sourceTreeDir string // 非空时按 sources 原始路径还原源文件目录结构（ReconstructSourceTree）
+	skipUnchanged bool   // 还原目录结构时跳过与已有文件内容相同的源文件
+
+	sourceFetchWorkers int // 缺少 sourcesContent 时并发下载原始源文件的 worker 数，0 表示不下载
+
+	mapMetadata bool // 为每个 source map 生成 .map.meta.json 概要
+
+	htmlInlineMaps bool // 解析 HTML 资源中内联 <script> / <style> 引用的 source map
+
+	probeMaps bool // 没有 sourceMappingURL 的 JS / CSS / WebAssembly 尝试下载同目录的 <文件名>.map
+
+	mapCache *MapCache // 跨 Extractor 共享的下载缓存，nil 表示不缓存
+
+	maxSourceBytes    int64 // 单个源文件大小上限，<=0 表示不限制
+	maxMapSourceBytes int64 // 单个 map 的源文件合计大小上限，<=0 表示不限制
+	maxSources        int   // 单个 map 的源文件数上限，<=0 表示不限制
+}
+
+// Option 提取器可选配置
+type Option func(*Extractor)
+
+// WithMaxSourceMapSize 设置 source map 大小上限，下载前通过 HEAD 请求预检 Content-Length
+func WithMaxSourceMapSize(n int64) Option {
+	return func(sme *Extractor) { sme.maxSize = n }
+}
+
+// WithMapHostAllowlist 限制绝对地址的 source map 只从这些主机（或与资源同源）下载，
+// 条目可写作 example.com 或 *.example.com。相对地址和内联 data: URI 不受限制。
+func WithMapHostAllowlist(hosts []string) Option {
+	return func(sme *Extractor) { sme.mapHostAllowlist = hosts }
+}
+
+// WithSameOriginMapsOnly 绝对地址的 source map 只允许与引用它的资源同源（可由 allowlist 放宽），
+// 避免向评论中引用的第三方主机发出请求
+func WithSameOriginMapsOnly(enabled bool) Option {
+	return func(sme *Extractor) { sme.sameOriginMapsOnly = enabled }
+}
+
+// WithSourceTreeDir 每个解析成功的 source map 额外调用 ReconstructSourceTree(dir)，
+// 按项目原始目录结构写出源文件；空字符串表示不还原
+func WithSourceTreeDir(dir string) Option {
+	return func(sme *Extractor) { sme.sourceTreeDir = dir }
+}
+
+// WithSkipUnchangedSources 还原源码目录（WithSourceTreeDir）时跳过与已有文件内容相同的源文件，
+// 重复抓取时只改写变化的文件
+func WithSkipUnchangedSources(enabled bool) Option {
+	return func(sme *Extractor) { sme.skipUnchanged = enabled }
+}
+
+// WithHTTPClient 使用调用方提供的 HTTP 客户端（共享连接池、代理、TLS 配置，或测试用 httptest.Server），
+// 传入 nil 时保留默认客户端
+func WithHTTPClient(client *http.Client) Option {
+	return func(sme *Extractor) {
+		if client != nil {
+			sme.client = client
+		}
+	}
 }
 
 // New 创建source map提取器
-func New(baseURL string) *Extractor {
-	return &Extractor{
+func New(baseURL string, opts ...Option) *Extractor {
+	sme := &Extractor{
 		baseURL: baseURL,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		maxSourceBytes:    DefaultMaxSourceBytes,
+		maxMapSourceBytes: DefaultMaxMapSourceBytes,
+		maxSources:        DefaultMaxSources,
 	}
+	for _, opt := range opts {
+		opt(sme)
+	}
+	return sme
 }
 
 // ExtractFromResource 从资源中提取source map
 func (sme *Extractor) ExtractFromResource(res *crawler.Resource) ([]*crawler.Resource, error) {
-	// 只处理 JavaScript 和 CSS 文件
-	if !strings.Contains(res.MimeType, "javascript") && !strings.Contains(res.MimeType, "css") {
-		return nil, nil
+	// 只处理 JavaScript、CSS 和 WebAssembly 文件（WithHTMLInlineMaps 时还有 HTML 中的内联块）
+	var sourceMapURL string
+	switch {
+	case strings.Contains(res.ContentMimeType(), "javascript") || strings.Contains(res.ContentMimeType(), "css"):
+		sourceMapURL = sme.findSourceMapURL(string(res.Content))
+	case isWasm(res.Content):
+		// WebAssembly 的 source map 地址在 sourceMappingURL 自定义段中（通常为 .wasm.map）
+		sourceMapURL = wasmSourceMapURL(res.Content)
+	case sme.htmlInlineMaps && isHTML(res):
+		return sme.extractFromHTML(res)
 	}
-
-	// 查找sourceMappingURL
-	sourceMapURL := sme.findSourceMapURL(string(res.Content))
 	if sourceMapURL == "" {
+		if sme.probeMaps {
+			return sme.probeSourceMap(res)
+		}
 		return nil, nil
 	}
+	return sme.extractMap(res, sourceMapURL, crawler.ReferenceBase(res))
+}
 
-	// 构建完整的source map URL
-	fullURL, err := sme.buildSourceMapURL(res.URL, sourceMapURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to build source map URL: %v", err)
-	}
+// extractMap 下载（或解码内联的）sourceMapURL 并提取源文件，res 为引用该 map 的资源，
+// 相对的 sourceMapURL 按 base 解析（见 crawler.ReferenceBase）
+func (sme *Extractor) extractMap(res *crawler.Resource, sourceMapURL, base string) ([]*crawler.Resource, error) {
+	var sourceMapContent []byte
+	var fullURL string
+	if strings.HasPrefix(sourceMapURL, "data:") {
+		// 内联 source map：直接解码，源文件路径相对于资源本身
+		content, err := decodeDataURI(sourceMapURL)
+		if err != nil {
+			logging.Warnf("解码内联 source map 失败 %s: %v", res.URL, err)
+			return nil, nil
+		}
+		sourceMapContent = content
+		fullURL = res.URL
+		log.Printf("发现内联 Source Map: %s", res.URL)
+	} else {
+		// 构建完整的source map URL；内联脚本 / 样式中的相对地址按所在页面（或其 <base href>）解析
+		mapBase := res.URL
+		if page := crawler.InlineResourcePage(res); page != "" {
+			mapBase = page
+		}
+		var err error
+		fullURL, err = sme.buildSourceMapURL(base, sourceMapURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build source map URL: %v", err)
+		}
 
-	log.Printf("发现 Source Map: %s", fullURL)
+		log.Printf("发现 Source Map: %s", fullURL)
 
-	// 下载source map
-	sourceMapContent, err := sme.downloadSourceMap(fullURL)
-	if err != nil {
-		log.Printf("警告: 下载 source map 失败: %v", err)
-		return nil, nil
+		// 主机限制：跳过的记录在资源上，由报告列出
+		if reason := sme.mapHostDenied(mapBase, sourceMapURL, fullURL); reason != "" {
+			res.SourceMapSkipped = fmt.Sprintf("%s (%s)", fullURL, reason)
+			log.Printf("跳过 Source Map %s: %s", fullURL, reason)
+			return nil, nil
+		}
+
+		// 下载source map
+		sourceMapContent, err = sme.downloadSourceMap(fullURL)
+		if err != nil {
+			logging.Warnf("下载 source map 失败: %v", err)
+			return nil, nil
+		}
 	}
 
 	// 解析source map
 	sourceMap, err := sme.parseSourceMap(sourceMapContent)
 	if err != nil {
-		log.Printf("警告: 解析 source map 失败: %v", err)
+		logging.Warnf("解析 source map 失败: %v", err)
 		return nil, nil
 	}
+	return sme.extractParsed(res, fullURL, sourceMap), nil
+}
+
+// extractParsed 从解析好的 source map 提取源文件（下载缺失的源文件、还原目录、生成概要），fullURL 为 map 的地址
+func (sme *Extractor) extractParsed(res *crawler.Resource, fullURL string, sourceMap *SourceMap) []*crawler.Resource {
+	if n := sourceMap.limits.total(); n > 0 {
+		res.SourceMapTruncated = fmt.Sprintf("%s (%s)", fullURL, sourceMap.limits)
+		logging.Warnf("Source Map %s 超出提取上限，跳过 %d 个源文件（%s）", fullURL, n, sourceMap.limits)
+	}
+
+	inlineSources := sourceMap.inlineSourceCount()
+	sme.fetchMissingSources(sourceMap, fullURL)
+
+	if sme.sourceTreeDir != "" {
+		if err := sourceMap.reconstructSourceTree(sme.sourceTreeDir, sme.skipUnchanged); err != nil {
+			logging.Warnf("还原源码目录失败 %s: %v", fullURL, err)
+		}
+	}
 
 	// 提取源代码文件
 	resources := sme.extractSourceFiles(sourceMap, fullURL)
 
 	log.Printf("从 Source Map 提取了 %d 个源文件", len(resources))
 
-	return resources, nil
+	if sme.mapMetadata {
+		// 内联 map 没有自己的地址，概要放在引用它的资源旁边
+		metaURL := fullURL + ".meta.json"
+		if fullURL == res.URL {
+			metaURL = res.URL + ".map.meta.json"
+		}
+		if meta, err := metaResource(sourceMap.Meta(fullURL, inlineSources), metaURL); err != nil {
+			logging.Warnf("生成 source map 概要失败 %s: %v", fullURL, err)
+		} else {
+			resources = append(resources, meta)
+		}
+	}
+
+	return resources
 }
 
 // findSourceMapURL 查找sourceMappingURL注释
@@ -128,8 +285,78 @@ func (sme *Extractor) buildSourceMapURL(baseURL, sourceMapURL string) (string, e
 	return fullURL.String(), nil
 }
 
-// downloadSourceMap 下载source map文件
+// mapHostDenied 检查 source map 地址是否允许下载，不允许时返回原因。
+// 注释中写的是相对地址时总是允许；绝对地址按解析后的 URL 与资源的源（scheme + host）比较。
+func (sme *Extractor) mapHostDenied(resourceURL, rawMapURL, fullURL string) string {
+	if !sme.sameOriginMapsOnly && len(sme.mapHostAllowlist) == 0 {
+		return ""
+	}
+	if ref, err := url.Parse(rawMapURL); err == nil && !ref.IsAbs() && ref.Host == "" {
+		return ""
+	}
+
+	mapURL, err := url.Parse(fullURL)
+	if err != nil {
+		return "invalid map URL"
+	}
+	if res, err := url.Parse(resourceURL); err == nil &&
+		strings.EqualFold(res.Scheme, mapURL.Scheme) && strings.EqualFold(res.Host, mapURL.Host) {
+		return ""
+	}
+
+	host := strings.ToLower(mapURL.Hostname())
+	for _, allowed := range sme.mapHostAllowlist {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return ""
+			}
+		} else if host == allowed {
+			return ""
+		}
+	}
+
+	if len(sme.mapHostAllowlist) > 0 {
+		return "host not in allowlist"
+	}
+	return "cross-origin"
+}
+
+// decodeDataURI 解码 data:[<mediatype>][;base64],<data> 形式的内联内容
+func decodeDataURI(uri string) ([]byte, error) {
+	meta, data, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("data URI 缺少逗号")
+	}
+	if strings.HasSuffix(strings.ToLower(meta), ";base64") {
+		return base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	}
+	decoded, err := url.PathUnescape(data)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(decoded), nil
+}
+
+// downloadSourceMap 下载source map文件；设置了 MapCache 时命中缓存不再请求（也不消耗限速令牌）
 func (sme *Extractor) downloadSourceMap(rawURL string) ([]byte, error) {
+	if sme.mapCache != nil {
+		if content, ok := sme.mapCache.maps.Get(rawURL); ok {
+			return content, nil
+		}
+	}
+	if sme.limiter != nil {
+		sme.limiter.wait()
+	}
+
+	if sme.maxSize > 0 {
+		// HEAD 预检：超大的 source map（monorepo 构建可达数百 MB）会拖慢整个爬取
+		if size := sme.headContentLength(rawURL); size > sme.maxSize {
+			logging.Warnf("跳过过大的 source map %s（%d 字节，上限 %d 字节）", rawURL, size, sme.maxSize)
+			return nil, fmt.Errorf("source map 过大: %d 字节", size)
+		}
+	}
+
 	resp, err := sme.client.Get(rawURL)
 	if err != nil {
 		return nil, err
@@ -140,23 +367,83 @@ func (sme *Extractor) downloadSourceMap(rawURL string) ([]byte, error) {
 		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
 	}
 
-	content, err := io.ReadAll(io.LimitReader(resp.Body, maxSourceMapBytes))
+	// HEAD 不被支持时，GET 响应头仍可能带 Content-Length
+	if sme.maxSize > 0 && resp.ContentLength > sme.maxSize {
+		logging.Warnf("跳过过大的 source map %s（%d 字节，上限 %d 字节）", rawURL, resp.ContentLength, sme.maxSize)
+		return nil, fmt.Errorf("source map 过大: %d 字节", resp.ContentLength)
+	}
+
+	limit := int64(maxSourceMapBytes)
+	if sme.maxSize > 0 {
+		limit = sme.maxSize
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, limit))
 	if err != nil {
 		return nil, err
 	}
 
+	if sme.mapCache != nil {
+		sme.mapCache.maps.Add(rawURL, content)
+	}
 	return content, nil
 }
 
-// parseSourceMap 解析source map JSON
+// headContentLength 通过 HEAD 请求获取资源大小，失败或未知时返回 -1
+func (sme *Extractor) headContentLength(rawURL string) int64 {
+	resp, err := sme.client.Head(rawURL)
+	if err != nil {
+		return -1
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return -1
+	}
+	return resp.ContentLength
+}
+
+// parseSourceMap 解析source map JSON，sourcesContent 按提取上限解码
 func (sme *Extractor) parseSourceMap(content []byte) (*SourceMap, error) {
 	var sourceMap SourceMap
-	if err := json.Unmarshal(content, &sourceMap); err != nil {
+	contents := &sourcesContentList{
+		maxSourceBytes: sme.maxSourceBytes,
+		maxTotalBytes:  sme.maxMapSourceBytes,
+		maxSources:     sme.maxSources,
+	}
+	doc := struct {
+		*SourceMap
+		SourcesContent *sourcesContentList `json:"sourcesContent"`
+	}{&sourceMap, contents}
+	if err := json.Unmarshal(stripSourceMapPrefix(content), &doc); err != nil {
 		return nil, err
 	}
+	sourceMap.SourcesContent = contents.items
+	sourceMap.limits = contents.limits
+
+	if sme.maxSources > 0 {
+		if len(sourceMap.Sources) > sme.maxSources {
+			sourceMap.limits.overCount = len(sourceMap.Sources) - sme.maxSources
+			sourceMap.Sources = sourceMap.Sources[:sme.maxSources]
+		}
+		if len(sourceMap.SourcesContent) > sme.maxSources {
+			sourceMap.SourcesContent = sourceMap.SourcesContent[:sme.maxSources]
+		}
+	}
 	return &sourceMap, nil
 }
 
+// stripSourceMapPrefix 去掉 JSON 之前的 UTF-8 BOM 和 XSSI 防护前缀，
+// 否则 json.Unmarshal 会直接失败，丢掉本来有效的 map
+func stripSourceMapPrefix(content []byte) []byte {
+	content = bytes.TrimPrefix(content, utf8BOM)
+	trimmed := bytes.TrimLeft(content, " \t\r\n")
+	for _, prefix := range xssiPrefixes {
+		if bytes.HasPrefix(trimmed, prefix) {
+			return bytes.TrimLeft(trimmed[len(prefix):], " \t\r\n")
+		}
+	}
+	return content
+}
+
 // extractSourceFiles 从source map中提取源文件
 func (sme *Extractor) extractSourceFiles(sm *SourceMap, sourceMapURL string) []*crawler.Resource {
 	var resources []*crawler.Resource
@@ -174,10 +461,10 @@ func (sme *Extractor) extractSourceFiles(sm *SourceMap, sourceMapURL string) []*
 		}
 
 		// 清理源文件路径
-		cleanPath := sme.cleanSourcePath(sourcePath, sm.SourceRoot)
+		cleanPath, suffix := sme.cleanSourcePath(sourcePath, sm.SourceRoot)
 
 		// 构建完整的源文件URL
-		sourceURL := sme.buildSourceURL(parsedURL, cleanPath)
+		sourceURL := sme.buildSourceURL(parsedURL, cleanPath, suffix)
 
 		// 创建资源
 		resource := &crawler.Resource{
@@ -194,15 +481,19 @@ func (sme *Extractor) extractSourceFiles(sm *SourceMap, sourceMapURL string) []*
 	return resources
 }
 
-// cleanSourcePath 清理源文件路径
-func (sme *Extractor) cleanSourcePath(sourcePath, sourceRoot string) string {
+// cleanSourcePath 清理源文件路径，返回解码后的路径和原样保留的 ?query / #fragment 后缀
+func (sme *Extractor) cleanSourcePath(sourcePath, sourceRoot string) (cleanPath, suffix string) {
 	// 移除 webpack:// 等前缀
-	cleanPath := strings.TrimPrefix(sourcePath, "webpack://")
+	cleanPath = strings.TrimPrefix(sourcePath, "webpack://")
 	cleanPath = strings.TrimPrefix(cleanPath, "webpack:///")
 
 	// 移除 ./
 	cleanPath = strings.TrimPrefix(cleanPath, "./")
 
+	// 部分打包工具对 sources 做了百分号编码（空格写作 %20），先解码，避免文件名中出现字面的 %20；
+	// 后缀单独保留，解码出的 ?、#（%3F、%23）属于文件名，不能再被当作查询串或片段切开
+	cleanPath, suffix = decodeSourcePath(cleanPath)
+
 	// 如果有 sourceRoot，也要处理
 	if sourceRoot != "" {
 		cleanRoot := strings.TrimPrefix(sourceRoot, "webpack://")
@@ -211,18 +502,36 @@ func (sme *Extractor) cleanSourcePath(sourcePath, sourceRoot string) string {
 		cleanPath = path.Join(cleanRoot, cleanPath)
 	}
 
-	return cleanPath
+	return cleanPath, suffix
 }
 
-// buildSourceURL 构建源文件的完整URL，保留 source map 所在目录的路径前缀
-func (sme *Extractor) buildSourceURL(baseURL *url.URL, sourcePath string) string {
-	ref, err := url.Parse(sourcePath)
+// buildSourceURL 构建源文件的完整URL，保留 source map 所在目录的路径前缀。
+// sourcePath 是已解码的字面路径（cleanSourcePath），按路径重新编码（其中的 ?、# 编码为 %3F、%23）；
+// suffix 是原始的 ?query / #fragment，原样附加。解码出的 ../ 由 ResolveReference 按 URL 规则消去，不会越出站点根目录
+func (sme *Extractor) buildSourceURL(baseURL *url.URL, sourcePath, suffix string) string {
+	ref, err := url.Parse(suffix)
 	if err != nil {
-		return sourcePath
+		ref = &url.URL{}
 	}
+	ref.Path = sourcePath
 	return baseURL.ResolveReference(ref).String()
 }
 
+// decodeSourcePath 把源路径拆成 ?query / #fragment 之前的路径和其后的后缀（含 ? 或 #），
+// 只解码路径部分的百分号编码（%20 → 空格、%5B → [），后缀原样返回；
+// 路径含不合法转义（如字面的 100%.ts）时路径原样返回
+func decodeSourcePath(sourcePath string) (decoded, suffix string) {
+	p := sourcePath
+	if i := strings.IndexAny(sourcePath, "?#"); i >= 0 {
+		p, suffix = sourcePath[:i], sourcePath[i:]
+	}
+	decoded, err := url.PathUnescape(p)
+	if err != nil {
+		return p, suffix
+	}
+	return decoded, suffix
+}
+
 // guessMimeType 根据文件扩展名猜测MIME类型
 func (sme *Extractor) guessMimeType(filePath string) string {
 	ext := strings.ToLower(filepath.Ext(filePath))
@@ -239,6 +548,13 @@ func (sme *Extractor) guessMimeType(filePath string) string {
 		".json": "application/json",
 		".html": "text/html",
 		".vue":  "text/x-vue",
+		".rs":   "text/x-rust",
+		".c":    "text/x-c",
+		".h":    "text/x-c",
+		".cpp":  "text/x-c++",
+		".cc":   "text/x-c++",
+		".hpp":  "text/x-c++",
+		".go":   "text/x-go",
 	}
 
 	if mimeType, ok := mimeTypes[ext]; ok {
@@ -0,0 +1,116 @@
+package sourcemap
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// reSourceScheme 源路径中的协议前缀，如 webpack://、vite://、file://
+	reSourceScheme = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://`)
+	// reWebpackNamespace webpack 5 的 webpack://<项目名>/./src/... 形式中的项目名
+	reWebpackNamespace = regexp.MustCompile(`^[^/]+/\./`)
+	// reDriveLetter Windows 构建机上的绝对路径盘符，如 C:/
+	reDriveLetter = regexp.MustCompile(`^/?[a-zA-Z]:/`)
+)
+
+// ReconstructSourceTree 将 sourcesContent 按 sources 中的原始路径写到 outputDir 下，
+// 还原项目本身的目录结构（如 webpack:///./src/App.tsx → outputDir/src/App.tsx），
+// 可直接交给编辑器和 linter 使用。没有内容的源文件跳过；清理后路径相同的只写第一个。
+func (sm *SourceMap) ReconstructSourceTree(outputDir string) error {
+	return sm.reconstructSourceTree(outputDir, false)
+}
+
+// reconstructSourceTree skipUnchanged 为 true 时，目标路径已有相同内容的文件不再重写
+func (sm *SourceMap) reconstructSourceTree(outputDir string, skipUnchanged bool) error {
+	written := make(map[string]bool)
+	unchanged := 0
+	for i, source := range sm.Sources {
+		if i >= len(sm.SourcesContent) || sm.SourcesContent[i] == "" {
+			continue
+		}
+		rel := sourceTreePath(source, sm.SourceRoot)
+		if rel == "" || written[rel] {
+			continue
+		}
+		written[rel] = true
+
+		target := filepath.Join(outputDir, filepath.FromSlash(rel))
+		content := []byte(sm.SourcesContent[i])
+		if skipUnchanged {
+			if existing, err := os.ReadFile(target); err == nil && bytes.Equal(existing, content) {
+				unchanged++
+				continue
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("创建目录失败 %s: %w", filepath.Dir(target), err)
+		}
+		// 同一页面的多个 map 常包含相同的依赖文件，并发提取时先写临时文件再 rename
+		if err := writeSourceFile(target, content); err != nil {
+			return fmt.Errorf("写入 %s 失败: %w", target, err)
+		}
+	}
+	if skipUnchanged {
+		log.Printf("已还原 %d 个源文件到 %s（内容未变跳过 %d 个）", len(written)-unchanged, outputDir, unchanged)
+		return nil
+	}
+	log.Printf("已还原 %d 个源文件到 %s", len(written), outputDir)
+	return nil
+}
+
+// sourceTreePath 将 source map 中的源路径转为相对项目根目录的 / 分隔路径：
+// 去掉协议前缀、webpack 项目名、查询串和 ./，解码百分号编码，拼上 sourceRoot，并把越出根目录的 ../ 截掉
+func sourceTreePath(source, sourceRoot string) string {
+	clean := func(p string) string {
+		p = reSourceScheme.ReplaceAllString(p, "")
+		p = reWebpackNamespace.ReplaceAllString(p, "")
+		// 丢掉查询串和片段，只解码路径；先解码再 Clean：编码过的 %2e%2e%2f 同样会被截掉
+		p, _ = decodeSourcePath(p)
+		return reDriveLetter.ReplaceAllString(strings.ReplaceAll(p, "\\", "/"), "/")
+	}
+
+	p := clean(source)
+	if root := clean(sourceRoot); root != "" && !strings.HasPrefix(p, "/") {
+		p = path.Join(root, p)
+	}
+	// 以 / 为根做 Clean：../node_modules/x 还原为 node_modules/x，不会写出 outputDir
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" || p == "." {
+		return ""
+	}
+	return p
+}
+
+// writeSourceFile 先写同目录下的临时文件再 rename，避免并发写同一路径时产生交错内容
+func writeSourceFile(target string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Chmod(tmpName, 0644); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, target); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
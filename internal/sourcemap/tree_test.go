@@ -0,0 +1,135 @@
+package sourcemap
+
+import (
+	"encoding/json"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func loadTreeFixture(t *testing.T) *SourceMap {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "tree.js.map"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sm SourceMap
+	if err := json.Unmarshal(data, &sm); err != nil {
+		t.Fatal(err)
+	}
+	return &sm
+}
+
+// readTree 返回 dir 下全部文件（/ 分隔的相对路径 → 内容）
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// 按项目原始目录结构还原：去掉协议前缀和 webpack 项目名，越出根目录的 ../ 与绝对路径被截在 outputDir 内，
+// 重复的源只写第一个，没有内容的源跳过
+func TestReconstructSourceTree(t *testing.T) {
+	parent := t.TempDir()
+	out := filepath.Join(parent, "tree")
+
+	if err := loadTreeFixture(t).ReconstructSourceTree(out); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"src/App.tsx":               "export const App = 1;\n",
+		"src/utils/format.ts":       "export const format = 2;\n",
+		"src/main.ts":               "import './App';\n",
+		"build/src/win.ts":          "export const win = 3;\n",
+		"etc/passwd":                "root:x:0:0\n",
+		"outside.ts":                "export const outside = 4;\n",
+		"node_modules/lib/index.js": "module.exports = 5;\n",
+	}
+	if got := readTree(t, out); !maps.Equal(got, want) {
+		t.Errorf("还原的目录为 %v，应为 %v", slices.Sorted(maps.Keys(got)), slices.Sorted(maps.Keys(want)))
+		for rel, content := range want {
+			if got[rel] != content {
+				t.Errorf("%s 内容为 %q，应为 %q", rel, got[rel], content)
+			}
+		}
+	}
+
+	// outputDir 之外什么都没有写
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "tree" {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("outputDir 的上级目录中出现了 %v", names)
+	}
+}
+
+// sourceRoot 拼在相对路径前，越出 sourceRoot 的 ../ 同样被截在 outputDir 内
+func TestReconstructSourceTreeSourceRoot(t *testing.T) {
+	sm := &SourceMap{
+		SourceRoot:     "webpack:///app/",
+		Sources:        []string{"src/index.ts", "../../../../escape.ts", "/rooted.ts"},
+		SourcesContent: []string{"index", "escape", "rooted"},
+	}
+	out := t.TempDir()
+	if err := sm.ReconstructSourceTree(out); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"app/src/index.ts": "index",
+		"escape.ts":        "escape",
+		"rooted.ts":        "rooted",
+	}
+	if got := readTree(t, out); !maps.Equal(got, want) {
+		t.Errorf("还原的目录为 %v，应为 %v", got, want)
+	}
+}
+
+// skipUnchanged 时内容相同的文件不重写（修改时间不变），内容变化的文件照常覆盖
+func TestReconstructSourceTreeSkipUnchanged(t *testing.T) {
+	sm := &SourceMap{Sources: []string{"a.ts", "b.ts"}, SourcesContent: []string{"a", "b"}}
+	out := t.TempDir()
+	if err := sm.reconstructSourceTree(out, true); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	for _, name := range []string{"a.ts", "b.ts"} {
+		if err := os.Chtimes(filepath.Join(out, name), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sm.SourcesContent[1] = "b2"
+	if err := sm.reconstructSourceTree(out, true); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(filepath.Join(out, "a.ts")); !info.ModTime().Equal(old) {
+		t.Error("内容未变的 a.ts 被重写")
+	}
+	if data, _ := os.ReadFile(filepath.Join(out, "b.ts")); string(data) != "b2" {
+		t.Errorf("b.ts 内容为 %q，应已更新为 b2", data)
+	}
+}
@@ -0,0 +1,53 @@
+package sourcemap
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// wasmMagic WebAssembly 二进制模块的文件头 "\0asm"
+var wasmMagic = []byte{0x00, 0x61, 0x73, 0x6d}
+
+// isWasm 按文件头判断是否为 WebAssembly 模块（服务端常把 .wasm 标成 application/octet-stream）
+func isWasm(content []byte) bool {
+	return len(content) >= 8 && bytes.HasPrefix(content, wasmMagic)
+}
+
+// wasmSourceMapURL 读取 WebAssembly 模块中名为 sourceMappingURL 的自定义段（emscripten / wasm-pack 的 -gsource-map 生成）。
+// 模块结构：8 字节文件头，之后每段为 id(1 字节) + 长度(LEB128) + 内容；自定义段 id 为 0，内容以 LEB128 长度的段名开头。
+func wasmSourceMapURL(content []byte) string {
+	if !isWasm(content) {
+		return ""
+	}
+	data := content[8:]
+	for len(data) > 0 {
+		id := data[0]
+		size, n := binary.Uvarint(data[1:])
+		if n <= 0 || uint64(len(data)-1-n) < size {
+			return ""
+		}
+		section := data[1+n : 1+n+int(size)]
+		data = data[1+n+int(size):]
+		if id != 0 {
+			continue
+		}
+
+		name, rest := readWasmString(section)
+		if name != "sourceMappingURL" {
+			continue
+		}
+		url, _ := readWasmString(rest)
+		return url
+	}
+	return ""
+}
+
+// readWasmString 读取 LEB128 长度前缀的字符串，返回字符串和剩余内容
+func readWasmString(data []byte) (string, []byte) {
+	length, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < length {
+		return "", nil
+	}
+	end := n + int(length)
+	return string(data[n:end]), data[end:]
+}
@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ChunkIndexName 分块导出的索引文件名
+const ChunkIndexName = "capture.index.json"
+
+// ChunkIndex 描述分块导出：每个分块包含哪些文件以及分块和文件的校验值
+type ChunkIndex struct {
+	ChunkSize int64       `json:"chunk_size"` // 每块未压缩内容的目标上限（字节）
+	Chunks    []ChunkInfo `json:"chunks"`
+}
+
+// ChunkInfo 单个 capture.partNNN.tar.zst 分块
+type ChunkInfo struct {
+	Name   string          `json:"name"`
+	Size   int64           `json:"size"`   // 压缩后的文件大小
+	SHA256 string          `json:"sha256"` // 压缩后文件的校验值
+	Files  []ChunkFileInfo `json:"files"`
+}
+
+// ChunkFileInfo 分块中的单个文件，Path 为相对导出根目录的 / 分隔路径
+type ChunkFileInfo struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ExportChunks 将 srcDir 下的文件按路径排序打包为 destDir 中的 capture.partNNN.tar.zst，
+// 每块未压缩内容不超过 chunkSize（单个文件不跨块，超过 chunkSize 的文件独占一块），
+// 并写入 capture.index.json。destDir 位于 srcDir 内时跳过该目录。
+func ExportChunks(srcDir, destDir string, chunkSize int64) (*ChunkIndex, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("分块大小必须大于 0")
+	}
+	absDest, err := filepath.Abs(destDir)
+	if err != nil {
+		return nil, err
+	}
+
+	type pending struct {
+		rel  string
+		path string
+		size int64
+	}
+	var files []pending
+	err = filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if abs, err := filepath.Abs(path); err == nil && abs == absDest {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, pending{rel: filepath.ToSlash(rel), path: path, size: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("遍历 %s 失败: %w", srcDir, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s 中没有可导出的文件", srcDir)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].rel < files[j].rel })
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建目录失败 %s: %w", destDir, err)
+	}
+
+	index := &ChunkIndex{ChunkSize: chunkSize}
+	var w *chunkWriter
+	var used int64
+	for _, f := range files {
+		if w != nil && used > 0 && used+f.size > chunkSize {
+			info, err := w.close()
+			if err != nil {
+				return nil, err
+			}
+			index.Chunks = append(index.Chunks, info)
+			w = nil
+		}
+		if w == nil {
+			name := fmt.Sprintf("capture.part%03d.tar.zst", len(index.Chunks))
+			if w, err = newChunkWriter(filepath.Join(destDir, name)); err != nil {
+				return nil, err
+			}
+			used = 0
+		}
+		if err := w.add(f.rel, f.path, f.size); err != nil {
+			w.abort()
+			return nil, err
+		}
+		used += f.size
+	}
+	info, err := w.close()
+	if err != nil {
+		return nil, err
+	}
+	index.Chunks = append(index.Chunks, info)
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteFileAtomic(filepath.Join(destDir, ChunkIndexName), data, 0644); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// chunkWriter 正在写入的分块：tar → zstd → 文件，同时计算压缩后文件的校验值
+type chunkWriter struct {
+	path string
+	file *os.File
+	sum  hash.Hash
+	zw   *zstd.Encoder
+	tw   *tar.Writer
+	info ChunkInfo
+}
+
+func newChunkWriter(path string) (*chunkWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("创建分块失败: %w", err)
+	}
+	sum := sha256.New()
+	zw, err := zstd.NewWriter(io.MultiWriter(file, sum))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &chunkWriter{
+		path: path,
+		file: file,
+		sum:  sum,
+		zw:   zw,
+		tw:   tar.NewWriter(zw),
+		info: ChunkInfo{Name: filepath.Base(path)},
+	}, nil
+}
+
+// add 写入一个文件，记录其大小和校验值
+func (w *chunkWriter) add(rel, path string, size int64) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	hdr := &tar.Header{Name: rel, Mode: 0644, Size: size, Typeflag: tar.TypeReg, Format: tar.FormatPAX}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", rel, err)
+	}
+	fileSum := sha256.New()
+	// 按遍历时的大小写入：文件在导出期间被改写时 tar 会报错而不是生成损坏的分块
+	if _, err := io.CopyN(io.MultiWriter(w.tw, fileSum), src, size); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", rel, err)
+	}
+	w.info.Files = append(w.info.Files, ChunkFileInfo{Path: rel, Size: size, SHA256: hex.EncodeToString(fileSum.Sum(nil))})
+	return nil
+}
+
+// close 结束分块并返回其描述
+func (w *chunkWriter) close() (ChunkInfo, error) {
+	err := errors.Join(w.tw.Close(), w.zw.Close())
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(w.path)
+		return ChunkInfo{}, fmt.Errorf("写入分块 %s 失败: %w", w.info.Name, err)
+	}
+	stat, err := os.Stat(w.path)
+	if err != nil {
+		return ChunkInfo{}, err
+	}
+	w.info.Size = stat.Size()
+	w.info.SHA256 = hex.EncodeToString(w.sum.Sum(nil))
+	log.Printf("分块导出: %s（%d 个文件，%d 字节）", w.info.Name, len(w.info.Files), w.info.Size)
+	return w.info, nil
+}
+
+// abort 出错时丢弃未完成的分块
+func (w *chunkWriter) abort() {
+	w.zw.Close()
+	w.file.Close()
+	os.Remove(w.path)
+}
+
+// ImportChunks 读取 dir 中的 capture.index.json，校验每个分块后解包到 outDir，
+// 并逐个核对还原文件的大小和校验值
+func ImportChunks(dir, outDir string) (*ChunkIndex, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ChunkIndexName))
+	if err != nil {
+		return nil, err
+	}
+	var index ChunkIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", ChunkIndexName, err)
+	}
+
+	// 先校验全部分块，避免还原到一半才发现缺块
+	for _, chunk := range index.Chunks {
+		if err := verifyChunk(filepath.Join(dir, chunk.Name), chunk); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, chunk := range index.Chunks {
+		if err := extractChunk(filepath.Join(dir, chunk.Name), outDir, chunk); err != nil {
+			return nil, err
+		}
+		log.Printf("已还原 %s（%d 个文件）", chunk.Name, len(chunk.Files))
+	}
+	return &index, nil
+}
+
+// verifyChunk 核对分块文件的大小和校验值
+func verifyChunk(path string, chunk ChunkInfo) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("缺少分块: %w", err)
+	}
+	defer f.Close()
+
+	sum := sha256.New()
+	n, err := io.Copy(sum, f)
+	if err != nil {
+		return fmt.Errorf("读取分块 %s 失败: %w", chunk.Name, err)
+	}
+	if n != chunk.Size || hex.EncodeToString(sum.Sum(nil)) != chunk.SHA256 {
+		return fmt.Errorf("分块 %s 校验失败（文件损坏或不完整）", chunk.Name)
+	}
+	return nil
+}
+
+// extractChunk 解包一个分块，只接受索引中列出的文件，并核对每个文件的校验值
+func extractChunk(path, outDir string, chunk ChunkInfo) error {
+	expected := make(map[string]ChunkFileInfo, len(chunk.Files))
+	for _, f := range chunk.Files {
+		expected[f.Path] = f
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("解压分块 %s 失败: %w", chunk.Name, err)
+	}
+	defer zr.Close()
+
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取分块 %s 失败: %w", chunk.Name, err)
+		}
+		want, ok := expected[hdr.Name]
+		if !ok || hdr.Typeflag != tar.TypeReg {
+			return fmt.Errorf("分块 %s 含有索引之外的条目: %s", chunk.Name, hdr.Name)
+		}
+		delete(expected, hdr.Name)
+
+		target, err := chunkTargetPath(outDir, hdr.Name)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := writeChunkFile(target, tr, want); err != nil {
+			return err
+		}
+	}
+	if len(expected) > 0 {
+		return fmt.Errorf("分块 %s 缺少 %d 个索引中列出的文件", chunk.Name, len(expected))
+	}
+	return nil
+}
+
+// writeChunkFile 写出单个文件并核对大小和校验值
+func writeChunkFile(target string, r io.Reader, want ChunkFileInfo) error {
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	sum := sha256.New()
+	n, err := io.Copy(io.MultiWriter(out, sum), r)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", target, err)
+	}
+	if n != want.Size || hex.EncodeToString(sum.Sum(nil)) != want.SHA256 {
+		return fmt.Errorf("%s 校验失败", want.Path)
+	}
+	return nil
+}
+
+// chunkTargetPath 将分块中的相对路径映射到 outDir 内，拒绝绝对路径和 ../ 逃逸
+func chunkTargetPath(outDir, name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("分块中的路径越界: %s", name)
+	}
+	return filepath.Join(outDir, clean), nil
+}
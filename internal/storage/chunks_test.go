@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testChunkSize = 1 << 20
+
+// randomBytes 返回固定种子的伪随机内容，zstd 压不动，分块大小接近原始大小
+func randomBytes(seed uint64, n int) []byte {
+	r := rand.New(rand.NewPCG(seed, seed))
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(r.Uint32())
+	}
+	return b
+}
+
+// writeCapture 在 dir 下写入一个合成的抓取目录，返回 相对路径 → 内容
+func writeCapture(t *testing.T, dir string) map[string][]byte {
+	t.Helper()
+	files := map[string][]byte{
+		"resources.json":                      []byte(`[{"url":"https://example.com/"}]`),
+		"report.txt":                          []byte("report\n"),
+		"example.com/index.html":              bytes.Repeat([]byte("<p>hello</p>\n"), 1000),
+		"example.com/assets/app.js":           randomBytes(1, 300<<10),
+		"example.com/assets/app.js.map":       randomBytes(2, 400<<10),
+		"example.com/assets/vendor.js":        randomBytes(3, 500<<10),
+		"example.com/big/video.bin":           randomBytes(4, 3*testChunkSize+17), // 大于分块大小，独占一块
+		"example.com/boundary/exact.bin":      randomBytes(5, testChunkSize),      // 恰好等于分块大小
+		"example.com/empty.txt":               nil,
+		"example.com/src/components/Item.tsx": []byte("export const Item = () => null\n"),
+	}
+	for rel, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return files
+}
+
+// 多分块导出再导入，还原的每个文件与原文件逐字节相同；单个文件不跨块，
+// 每块未压缩内容不超过分块大小（超过分块大小的单个文件独占一块）
+func TestChunksRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	files := writeCapture(t, src)
+	parts := filepath.Join(t.TempDir(), "parts")
+
+	index, err := ExportChunks(src, parts, testChunkSize)
+	if err != nil {
+		t.Fatalf("ExportChunks: %v", err)
+	}
+	if len(index.Chunks) < 3 {
+		t.Fatalf("只生成了 %d 个分块，测试数据应产生多个分块", len(index.Chunks))
+	}
+
+	seen := make(map[string]int)
+	for _, chunk := range index.Chunks {
+		var total int64
+		for _, f := range chunk.Files {
+			seen[f.Path]++
+			total += f.Size
+			if f.Size != int64(len(files[f.Path])) {
+				t.Errorf("%s 记录的大小 %d，应为 %d", f.Path, f.Size, len(files[f.Path]))
+			}
+		}
+		if total > testChunkSize && len(chunk.Files) > 1 {
+			t.Errorf("%s 有 %d 个文件共 %d 字节，超过分块大小 %d", chunk.Name, len(chunk.Files), total, testChunkSize)
+		}
+		if _, err := os.Stat(filepath.Join(parts, chunk.Name)); err != nil {
+			t.Errorf("缺少分块文件: %v", err)
+		}
+	}
+	for rel := range files {
+		if seen[rel] != 1 {
+			t.Errorf("%s 出现在 %d 个分块中，应恰好 1 个", rel, seen[rel])
+		}
+	}
+
+	out := t.TempDir()
+	if _, err := ImportChunks(parts, out); err != nil {
+		t.Fatalf("ImportChunks: %v", err)
+	}
+	for rel, want := range files {
+		got, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Errorf("未还原 %s: %v", rel, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s 还原后内容不同（%d 字节，应为 %d 字节）", rel, len(got), len(want))
+		}
+	}
+}
+
+// 恰好填满一块的文件不会被拆开，也不会与下一个文件挤在同一块
+func TestChunksBoundary(t *testing.T) {
+	src := t.TempDir()
+	a := randomBytes(10, testChunkSize/2)
+	b := randomBytes(11, testChunkSize/2)
+	c := randomBytes(12, 1)
+	for name, data := range map[string][]byte{"a.bin": a, "b.bin": b, "c.bin": c} {
+		if err := os.WriteFile(filepath.Join(src, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	parts := filepath.Join(t.TempDir(), "parts")
+	index, err := ExportChunks(src, parts, testChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Chunks) != 2 || len(index.Chunks[0].Files) != 2 || index.Chunks[1].Files[0].Path != "c.bin" {
+		t.Fatalf("分块划分不对: %+v", index.Chunks)
+	}
+
+	out := t.TempDir()
+	if _, err := ImportChunks(parts, out); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string][]byte{"a.bin": a, "b.bin": b, "c.bin": c} {
+		if got, _ := os.ReadFile(filepath.Join(out, name)); !bytes.Equal(got, want) {
+			t.Errorf("%s 还原后内容不同", name)
+		}
+	}
+}
+
+// 导出目录位于抓取目录内时不会把已生成的分块再打包进去
+func TestChunksSkipDestInsideSource(t *testing.T) {
+	src := t.TempDir()
+	writeCapture(t, src)
+	parts := filepath.Join(src, "parts")
+
+	index, err := ExportChunks(src, parts, testChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, chunk := range index.Chunks {
+		for _, f := range chunk.Files {
+			if strings.HasPrefix(f.Path, "parts/") {
+				t.Errorf("导出目录中的 %s 被打包", f.Path)
+			}
+		}
+	}
+}
+
+// 分块损坏或缺失时在还原任何文件之前报错
+func TestImportChunksVerifies(t *testing.T) {
+	src := t.TempDir()
+	writeCapture(t, src)
+	parts := filepath.Join(t.TempDir(), "parts")
+	index, err := ExportChunks(src, parts, testChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := filepath.Join(parts, index.Chunks[len(index.Chunks)-1].Name)
+	data, err := os.ReadFile(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data[len(data)/2] ^= 0xff
+	if err := os.WriteFile(last, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := t.TempDir()
+	if _, err := ImportChunks(parts, out); err == nil || !strings.Contains(err.Error(), "校验失败") {
+		t.Fatalf("损坏的分块应校验失败，得到 %v", err)
+	}
+	if entries, _ := os.ReadDir(out); len(entries) != 0 {
+		t.Errorf("校验失败后仍还原了 %d 个条目", len(entries))
+	}
+
+	if err := os.Remove(last); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImportChunks(parts, out); err == nil || !strings.Contains(err.Error(), "缺少分块") {
+		t.Fatalf("缺少分块时应报错，得到 %v", err)
+	}
+}
+
+func TestExportChunksErrors(t *testing.T) {
+	if _, err := ExportChunks(t.TempDir(), t.TempDir(), 0); err == nil {
+		t.Error("分块大小为 0 时应报错")
+	}
+	if _, err := ExportChunks(t.TempDir(), t.TempDir(), testChunkSize); err == nil {
+		t.Error("空目录应报错")
+	}
+}
+
+func TestChunkTargetPath(t *testing.T) {
+	out := t.TempDir()
+	for _, name := range []string{"../escape.txt", "a/../../escape.txt", ".."} {
+		if _, err := chunkTargetPath(out, name); err == nil {
+			t.Errorf("%q 应被拒绝", name)
+		}
+	}
+	got, err := chunkTargetPath(out, "example.com/a/../b.js")
+	if err != nil || got != filepath.Join(out, "example.com", "b.js") {
+		t.Errorf("chunkTargetPath = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"spider/internal/crawler"
+)
+
+// coverageReportLimit 报告中列出的未使用字节数最多的文件数，完整列表见 coverage.json
+const coverageReportLimit = 20
+
+// SetCoverageReport 设置要写入 report.txt 的 JS / CSS 覆盖率（CaptureCoverage），nil 表示不输出该节
+func (st *Storage) SetCoverageReport(report *crawler.CoverageReport) {
+	st.coverage = report
+}
+
+// WriteCoverageReport 写入 coverage.json：每个脚本 / 样式表的总字节数、已使用字节数和已使用的区间
+func (st *Storage) WriteCoverageReport(report *crawler.CoverageReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal coverage report: %v", err)
+	}
+	if err := os.MkdirAll(st.baseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create base directory: %v", err)
+	}
+	return WriteFileAtomic(filepath.Join(st.baseDir, "coverage.json"), data, 0644)
+}
+
+// writeCoverageSection 在报告中列出未使用字节数最多的脚本和样式表
+func (st *Storage) writeCoverageSection(report *strings.Builder) {
+	c := st.coverage
+	unused := c.TotalBytes - c.UsedBytes
+	report.WriteString(fmt.Sprintf("\nCoverage (%d files, %d of %d bytes unused):\n", len(c.Files), unused, c.TotalBytes))
+	if len(c.Files) == 0 {
+		report.WriteString("  (none)\n")
+	}
+	for i, f := range c.Files {
+		if i == coverageReportLimit {
+			report.WriteString(fmt.Sprintf("  ... %d more in coverage.json\n", len(c.Files)-coverageReportLimit))
+			break
+		}
+		inline := ""
+		if f.Inline {
+			inline = " (inline)"
+		}
+		report.WriteString(fmt.Sprintf("  [%s] %s%s: %d of %d bytes unused (%.1f%% used)\n",
+			f.Type, f.URL, inline, f.UnusedBytes(), f.TotalBytes, f.UsedPercent))
+	}
+}
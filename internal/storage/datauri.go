@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"spider/internal/crawler"
+	"spider/internal/logging"
+)
+
+// dataDirName 抽出的 data URI 文件所在的子目录（相对 baseDir）
+const dataDirName = "_data"
+
+// reDataURI 匹配 CSS url()、HTML src / srcset / style 中的 data URI。
+// 负载在引号、空白、右括号或尖括号处结束：base64 不含这些字符，srcset 中的描述符以空白分隔。
+var reDataURI = regexp.MustCompile(`data:([\w.+-]+/[\w.+-]+)?((?:;[\w.+-]+(?:=[\w.+-]+)?)*),([^"'\s)<>]+)`)
+
+// dataURIExtensions 常见内联资源的扩展名，其余类型交给 mime.ExtensionsByType
+var dataURIExtensions = map[string]string{
+	"font/woff2":             ".woff2",
+	"font/woff":              ".woff",
+	"application/font-woff":  ".woff",
+	"application/font-woff2": ".woff2",
+	"font/ttf":               ".ttf",
+	"font/otf":               ".otf",
+	"image/png":              ".png",
+	"image/jpeg":             ".jpg",
+	"image/gif":              ".gif",
+	"image/webp":             ".webp",
+	"image/avif":             ".avif",
+	"image/svg+xml":          ".svg",
+}
+
+// SetDataURIExtraction 设置保存 CSS / HTML 时抽取超大 data URI：解码后超过 threshold 字节的
+// 写到 _data/ 下（按内容哈希命名，相同内容只写一份），rewrite 为 true 时引用改写为相对路径，
+// 否则替换为占位注释。threshold 为 0 表示关闭。
+func (st *Storage) SetDataURIExtraction(threshold int64, rewrite bool) {
+	st.dataURIThreshold = threshold
+	st.rewriteDataURIs = rewrite
+}
+
+// extractDataURIs 抽出 resource 中的超大 data URI 并改写内容，filePath 为资源的保存路径。
+// 抽取记录追加到 resource.DataURIs，供 resources.json 列出。
+func (st *Storage) extractDataURIs(resource *crawler.Resource, filePath string) {
+	if st.dataURIThreshold <= 0 || !isMarkupOrStyle(resource.ContentMimeType()) {
+		return
+	}
+
+	var extracted []crawler.DataURIFile
+	content := reDataURI.ReplaceAllFunc(resource.Content, func(match []byte) []byte {
+		sub := reDataURI.FindSubmatch(match)
+		mimeType, params, payload := string(sub[1]), string(sub[2]), string(sub[3])
+		data, err := decodeDataURIPayload(params, payload)
+		if err != nil || int64(len(data)) <= st.dataURIThreshold {
+			return match
+		}
+
+		rel, err := st.writeDataFile(mimeType, data)
+		if err != nil {
+			logging.Warnf("抽取 data URI 失败 %s: %v", resource.URL, err)
+			return match
+		}
+		extracted = append(extracted, crawler.DataURIFile{Path: rel, MimeType: mimeType, Size: len(data)})
+
+		if st.rewriteDataURIs {
+			if ref, err := filepath.Rel(filepath.Dir(filePath), filepath.Join(st.baseDir, filepath.FromSlash(rel))); err == nil {
+				return []byte(filepath.ToSlash(ref))
+			}
+		}
+		return []byte(fmt.Sprintf("/* data URI extracted: %s, %d bytes */", rel, len(data)))
+	})
+	if len(extracted) == 0 {
+		return
+	}
+
+	resource.Content = content
+	resource.DataURIs = append(resource.DataURIs, extracted...)
+	log.Printf("已从 %s 抽出 %d 个 data URI 到 %s/", resource.URL, len(extracted), dataDirName)
+}
+
+// isMarkupOrStyle 只处理 CSS 和 HTML；JS 中的 data URI 常被拼接或比较，改写可能破坏逻辑
+func isMarkupOrStyle(mimeType string) bool {
+	base := baseMimeType(mimeType)
+	return base == "text/css" || base == "text/html" || base == "application/xhtml+xml"
+}
+
+// decodeDataURIPayload 按 ;base64 参数解码负载，否则按百分号编码解码
+func decodeDataURIPayload(params, payload string) ([]byte, error) {
+	for _, p := range strings.Split(params, ";") {
+		if strings.EqualFold(p, "base64") {
+			return base64.StdEncoding.DecodeString(payload)
+		}
+	}
+	decoded, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(decoded), nil
+}
+
+// writeDataFile 将解码后的内容写到 _data/<哈希><扩展名>，返回相对 baseDir 的 / 分隔路径
+func (st *Storage) writeDataFile(mimeType string, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:8]) + dataURIExtension(mimeType, data)
+	rel := dataDirName + "/" + name
+
+	path := filepath.Join(st.baseDir, dataDirName, name)
+	if _, err := os.Stat(path); err == nil {
+		return rel, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := WriteFileAtomic(path, data, 0644); err != nil {
+		return "", err
+	}
+	return rel, nil
+}
+
+// dataURIExtension 根据 data URI 的媒体类型选择扩展名，未声明时按内容判断
+func dataURIExtension(mimeType string, data []byte) string {
+	mimeType = strings.ToLower(mimeType)
+	if mimeType == "" {
+		mimeType = baseMimeType(http.DetectContentType(data))
+	}
+	if ext, ok := dataURIExtensions[mimeType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"spider/internal/crawler"
+)
+
+const dataURIThreshold = 1024
+
+// payload 返回 n 字节的确定内容及其 base64 编码
+func payload(seed byte, n int) ([]byte, string) {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = seed + byte(i*7)
+	}
+	return data, base64.StdEncoding.EncodeToString(data)
+}
+
+// dataPath 内容在 _data/ 下的相对路径
+func dataPath(data []byte, ext string) string {
+	sum := sha256.Sum256(data)
+	return dataDirName + "/" + hex.EncodeToString(sum[:8]) + ext
+}
+
+// saveWithExtraction 用分层布局保存单个资源并返回保存后的内容和 resources.json 记录
+func saveWithExtraction(t *testing.T, dir string, rewrite bool, res *crawler.Resource) (string, IndexEntry) {
+	t.Helper()
+	store := New(dir)
+	store.SetDataURIExtraction(dataURIThreshold, rewrite)
+	if res.Headers == nil {
+		res.Headers = map[string]string{}
+	}
+	res.StatusCode = 200
+	if err := store.Save(map[string]*crawler.Resource{res.URL: res}); err != nil {
+		t.Fatal(err)
+	}
+	entry := store.indexEntry(res)
+	saved, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(entry.Path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(saved), entry
+}
+
+// requireDataFile 确认 _data/ 下的文件与原始内容逐字节相同
+func requireDataFile(t *testing.T, dir, rel string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+	if err != nil {
+		t.Fatalf("缺少抽出的文件 %s: %v", rel, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s 的内容与原 data URI 不同", rel)
+	}
+}
+
+// CSS url() 中的超大字体按改写模式替换为相对路径，小图标保留原样；相同内容只写一份
+func TestExtractDataURIsCSS(t *testing.T) {
+	dir := t.TempDir()
+	font, fontB64 := payload(1, 4096)
+	_, iconB64 := payload(2, 100)
+	css := `@font-face { font-family: X; src: url(data:font/woff2;base64,` + fontB64 + `) format("woff2"); }
+.a { background: url("data:image/png;base64,` + iconB64 + `"); }
+.b { src: url('data:font/woff2;base64,` + fontB64 + `'); }
+`
+	saved, entry := saveWithExtraction(t, dir, true, &crawler.Resource{
+		URL: "https://example.com/static/css/site.css", MimeType: "text/css", Content: []byte(css),
+	})
+
+	rel := dataPath(font, ".woff2")
+	requireDataFile(t, dir, rel, font)
+	ref := "../../../" + rel // example.com/static/css/ → 输出根目录
+	if n := strings.Count(saved, "url("+ref+")") + strings.Count(saved, "url('"+ref+"')"); n != 2 {
+		t.Errorf("应有 2 处引用改写为 %s，实际 %d 处:\n%s", ref, n, saved)
+	}
+	if !strings.Contains(saved, "data:image/png;base64,"+iconB64) {
+		t.Error("低于阈值的 data URI 不应被抽取")
+	}
+	if strings.Contains(saved, fontB64) {
+		t.Error("超过阈值的 data URI 仍留在 CSS 中")
+	}
+	if len(entry.DataURIs) != 2 || entry.DataURIs[0] != (crawler.DataURIFile{Path: rel, MimeType: "font/woff2", Size: len(font)}) {
+		t.Errorf("resources.json 中的 data_uris 为 %+v", entry.DataURIs)
+	}
+	if files, _ := os.ReadDir(filepath.Join(dir, dataDirName)); len(files) != 1 {
+		t.Errorf("_data/ 下有 %d 个文件，相同内容应只写一份", len(files))
+	}
+}
+
+// HTML 的 img src 和 srcset 中每个候选都单独抽取，srcset 的描述符保留；不改写时替换为占位注释
+func TestExtractDataURIsHTML(t *testing.T) {
+	dir := t.TempDir()
+	hero, heroB64 := payload(3, 2048)
+	small, smallB64 := payload(4, 1500)
+	large, largeB64 := payload(5, 3000)
+	html := `<!DOCTYPE html><html><body>
+<img src="data:image/png;base64,` + heroB64 + `" alt="hero">
+<img srcset="data:image/webp;base64,` + smallB64 + ` 1x, data:image/webp;base64,` + largeB64 + ` 2x">
+</body></html>`
+	saved, entry := saveWithExtraction(t, dir, false, &crawler.Resource{
+		URL: "https://example.com/index.html", MimeType: "text/html", Content: []byte(html),
+	})
+
+	for _, f := range []struct {
+		data []byte
+		ext  string
+	}{{hero, ".png"}, {small, ".webp"}, {large, ".webp"}} {
+		rel := dataPath(f.data, f.ext)
+		requireDataFile(t, dir, rel, f.data)
+		if !strings.Contains(saved, "/* data URI extracted: "+rel) {
+			t.Errorf("HTML 中缺少 %s 的占位注释", rel)
+		}
+	}
+	if !strings.Contains(saved, dataPath(small, ".webp")+", ") || !strings.Contains(saved, " bytes */ 1x, ") || !strings.Contains(saved, " bytes */ 2x\"") {
+		t.Errorf("srcset 的描述符没有保留:\n%s", saved)
+	}
+	if len(entry.DataURIs) != 3 {
+		t.Errorf("data_uris 有 %d 条，应为 3", len(entry.DataURIs))
+	}
+}
+
+// 关闭（阈值为 0）或非 CSS / HTML 资源不做处理
+func TestExtractDataURIsSkipped(t *testing.T) {
+	_, b64 := payload(6, 4096)
+	content := `var img = "data:image/png;base64,` + b64 + `";`
+
+	dir := t.TempDir()
+	saved, entry := saveWithExtraction(t, dir, true, &crawler.Resource{
+		URL: "https://example.com/app.js", MimeType: "application/javascript", Content: []byte(content),
+	})
+	if saved != content || len(entry.DataURIs) != 0 {
+		t.Error("脚本中的 data URI 不应被抽取")
+	}
+
+	store := New(t.TempDir())
+	res := &crawler.Resource{URL: "https://example.com/a.css", MimeType: "text/css", StatusCode: 200,
+		Headers: map[string]string{}, Content: []byte(`.a{background:url(data:image/png;base64,` + b64 + `)}`)}
+	if err := store.Save(map[string]*crawler.Resource{res.URL: res}); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.DataURIs) != 0 || !bytes.Contains(res.Content, []byte(b64)) {
+		t.Error("未开启抽取时不应改写内容")
+	}
+}
+
+func TestDecodeDataURIPayload(t *testing.T) {
+	if got, err := decodeDataURIPayload(";charset=utf-8", "%3Csvg%3E%3C/svg%3E"); err != nil || string(got) != "<svg></svg>" {
+		t.Errorf("百分号编码负载解码为 %q, %v", got, err)
+	}
+	if got, err := decodeDataURIPayload(";BASE64", "aGk="); err != nil || string(got) != "hi" {
+		t.Errorf("base64 负载解码为 %q, %v", got, err)
+	}
+	if _, err := decodeDataURIPayload(";base64", "not base64!"); err == nil {
+		t.Error("无效的 base64 应报错")
+	}
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ResourceState 资源在一次爬取中的状态，用于比较两次爬取
+type ResourceState struct {
+	Status   int    `json:"status"`
+	MimeType string `json:"mime_type"`
+	Size     int    `json:"size"`
+	SHA256   string `json:"sha256,omitempty"`
+}
+
+// ResourceDiff 一个 URL 在两次爬取之间的变化；新增时 Before 为空，删除时 After 为空
+type ResourceDiff struct {
+	URL    string         `json:"url"`
+	Before *ResourceState `json:"before,omitempty"`
+	After  *ResourceState `json:"after,omitempty"`
+}
+
+// IndexDiff 两份 resources.json 按 URL 对比的结果，各列表按 URL 排序
+type IndexDiff struct {
+	Added     []ResourceDiff `json:"added"`
+	Removed   []ResourceDiff `json:"removed"`
+	Changed   []ResourceDiff `json:"changed"` // 状态码或内容（SHA-256，无内容时按大小）不同
+	Unchanged int            `json:"unchanged"`
+}
+
+// Empty 两次爬取的资源完全相同
+func (d *IndexDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffIndexes 按 URL 比较两次爬取的索引（ReadIndex），before 为基准。
+// 同一 URL 出现多次时（合并多个页面的索引）以最后一条为准
+func DiffIndexes(before, after []IndexEntry) *IndexDiff {
+	a := indexStates(before)
+	b := indexStates(after)
+
+	diff := &IndexDiff{Added: []ResourceDiff{}, Removed: []ResourceDiff{}, Changed: []ResourceDiff{}}
+	for _, url := range sortedKeys(a) {
+		old := a[url]
+		cur, ok := b[url]
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, ResourceDiff{URL: url, Before: old})
+		case old.Status != cur.Status || old.SHA256 != cur.SHA256 || old.Size != cur.Size:
+			diff.Changed = append(diff.Changed, ResourceDiff{URL: url, Before: old, After: cur})
+		default:
+			diff.Unchanged++
+		}
+	}
+	for _, url := range sortedKeys(b) {
+		if _, ok := a[url]; !ok {
+			diff.Added = append(diff.Added, ResourceDiff{URL: url, After: b[url]})
+		}
+	}
+	return diff
+}
+
+func indexStates(entries []IndexEntry) map[string]*ResourceState {
+	states := make(map[string]*ResourceState, len(entries))
+	for _, e := range entries {
+		states[e.URL] = &ResourceState{Status: e.Status, MimeType: e.MimeType, Size: e.Size, SHA256: e.SHA256}
+	}
+	return states
+}
+
+// WriteText 以与 report.txt 相同的风格输出对比结果
+func (d *IndexDiff) WriteText(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Crawl Diff: %d added, %d removed, %d changed, %d unchanged\n",
+		len(d.Added), len(d.Removed), len(d.Changed), d.Unchanged))
+
+	if len(d.Added) > 0 {
+		b.WriteString("\nAdded:\n")
+		for _, r := range d.Added {
+			b.WriteString(fmt.Sprintf("  + %s (%d, %d bytes)\n", r.URL, r.After.Status, r.After.Size))
+		}
+	}
+	if len(d.Removed) > 0 {
+		b.WriteString("\nRemoved:\n")
+		for _, r := range d.Removed {
+			b.WriteString(fmt.Sprintf("  - %s (%d, %d bytes)\n", r.URL, r.Before.Status, r.Before.Size))
+		}
+	}
+	if len(d.Changed) > 0 {
+		b.WriteString("\nChanged:\n")
+		for _, r := range d.Changed {
+			var parts []string
+			if r.Before.Status != r.After.Status {
+				parts = append(parts, fmt.Sprintf("status %d -> %d", r.Before.Status, r.After.Status))
+			}
+			if r.Before.Size != r.After.Size {
+				parts = append(parts, fmt.Sprintf("%d -> %d bytes", r.Before.Size, r.After.Size))
+			}
+			if r.Before.SHA256 != r.After.SHA256 {
+				parts = append(parts, fmt.Sprintf("sha256 %s -> %s", shortHash(r.Before.SHA256), shortHash(r.After.SHA256)))
+			}
+			b.WriteString(fmt.Sprintf("  ~ %s: %s\n", r.URL, strings.Join(parts, ", ")))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// shortHash 报告中只显示 SHA-256 的前 12 位，没有内容时显示 (empty)
+func shortHash(sum string) string {
+	if sum == "" {
+		return "(empty)"
+	}
+	return sum[:min(12, len(sum))]
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"spider/internal/crawler"
+)
+
+// captureDebug 在测试期间把 slog 的 Debug 及以上记录写入返回的缓冲区
+func captureDebug(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	saved := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(saved) })
+	return &buf
+}
+
+// 跳过的空资源只在 Debug 级别记录 URL 和原因；关闭 SuppressEmptyContent 后成功取回的空响应写入零字节文件
+func TestSaveEmptyResources(t *testing.T) {
+	resources := map[string]*crawler.Resource{
+		"https://example.com/empty.js":   {URL: "https://example.com/empty.js", StatusCode: 200, MimeType: "application/javascript", Content: []byte{}, Headers: map[string]string{}},
+		"https://example.com/nobody.css": {URL: "https://example.com/nobody.css", StatusCode: 200, MimeType: "text/css", BodyError: "timeout", Headers: map[string]string{}},
+	}
+
+	if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatal("默认不应输出 Debug 记录")
+	}
+	buf := captureDebug(t)
+	dir := t.TempDir()
+	if err := NewFlat(dir).Save(resources); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`msg=跳过空资源 url=https://example.com/empty.js reason=响应体为空`,
+		`msg=跳过空资源 url=https://example.com/nobody.css reason="未取得响应体: timeout"`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Debug 日志中缺少 %q:\n%s", want, buf)
+		}
+	}
+	if !strings.Contains(buf.String(), "level=DEBUG") || strings.Contains(buf.String(), "level=INFO msg=跳过空资源") {
+		t.Errorf("跳过空资源应为 Debug 级别:\n%s", buf)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "empty.js")); !os.IsNotExist(err) {
+		t.Errorf("默认不应写入空文件: %v", err)
+	}
+
+	dir = t.TempDir()
+	store := NewFlat(dir)
+	store.SetSuppressEmptyContent(false)
+	if err := store.Save(resources); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "empty.js")); err != nil || info.Size() != 0 {
+		t.Errorf("关闭 SuppressEmptyContent 后应写入零字节文件: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "nobody.css")); !os.IsNotExist(err) {
+		t.Errorf("未取得响应体的资源始终跳过: %v", err)
+	}
+}
@@ -0,0 +1,390 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"spider/internal/crawler"
+	"spider/internal/har"
+)
+
+const maxExportBodyBytes = 10 * 1024 * 1024 // 导出时单个 body 上限，超出截断并追加标记
+
+// 导出格式
+const (
+	FormatBurp   = "burp"   // Burp Suite XML items
+	FormatZAP    = "zap"    // ZAP 可导入的 HAR（含请求体和完整请求头）
+	FormatNDJSON = "ndjson" // 每行一个资源的 JSON（含 base64 body）
+	FormatWPR    = "wpr"    // Web Page Replay 归档（wpr replay 离线重放）
+)
+
+// Export 以指定格式将资源导出到 baseDir（burp.xml / zap.har / resources.ndjson / archive.wprgo）
+func (st *Storage) Export(format string, resources map[string]*crawler.Resource) error {
+	var (
+		name  string
+		write func(map[string]*crawler.Resource, io.Writer) error
+	)
+	switch format {
+	case FormatBurp:
+		name, write = "burp.xml", ExportBurpXML
+	case FormatZAP:
+		name, write = "zap.har", ExportHAR
+	case FormatNDJSON:
+		name = "resources.ndjson"
+		write = func(resources map[string]*crawler.Resource, w io.Writer) error {
+			return ExportNDJSON(resources, st.forwardHeaders, w)
+		}
+	case FormatWPR:
+		name, write = "archive.wprgo", ExportWPR
+	default:
+		return fmt.Errorf("不支持的导出格式 %q（可选: burp, zap, ndjson, wpr）", format)
+	}
+
+	if err := os.MkdirAll(st.baseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create base directory: %v", err)
+	}
+	return writeAtomic(filepath.Join(st.baseDir, name), 0644, func(w io.Writer) error {
+		return write(resources, w)
+	})
+}
+
+// ExportHAR 将资源导出为 HAR 1.2，包含请求体与实际发出的请求头。
+// 二进制 body 以 base64 编码（encoding: "base64"），超大 body 截断并在 comment 中标记。
+func ExportHAR(resources map[string]*crawler.Resource, w io.Writer) error {
+	doc := &har.HAR{Log: har.Log{
+		Version: "1.2",
+		Creator: har.Creator{Name: "spider", Version: "1.0"},
+		Entries: make([]har.Entry, 0, len(resources)),
+	}}
+
+	for _, res := range sortedResources(resources) {
+		httpVersion := harHTTPVersion(res.Protocol)
+
+		req := har.Request{
+			Method:      res.Method,
+			URL:         res.URL,
+			HTTPVersion: httpVersion,
+			Cookies:     []har.Cookie{},
+			Headers:     harHeaders(res.RequestHeaders),
+			QueryString: []har.NameValue{},
+			HeadersSize: -1,
+			BodySize:    len(res.RequestBody),
+		}
+		if req.Method == "" {
+			req.Method = "GET"
+		}
+		if u, err := url.Parse(res.URL); err == nil {
+			for k, values := range u.Query() {
+				for _, v := range values {
+					req.QueryString = append(req.QueryString, har.NameValue{Name: k, Value: v})
+				}
+			}
+		}
+		if len(res.RequestBody) > 0 {
+			text, encoding, _ := encodeBody(res.RequestBody)
+			req.PostData = &har.PostData{
+				MimeType: headerValue(res.RequestHeaders, "Content-Type"),
+				Text:     text,
+				Encoding: encoding,
+			}
+		}
+
+		text, encoding, truncated := encodeBody(res.Content)
+		content := har.Content{
+			Size:     len(res.Content),
+			MimeType: res.MimeType,
+			Text:     text,
+			Encoding: encoding,
+		}
+		if truncated {
+			content.Comment = fmt.Sprintf("truncated to %d bytes", maxExportBodyBytes)
+		}
+
+		doc.Log.Entries = append(doc.Log.Entries, har.Entry{
+			StartedDateTime: res.ResponseTime.Format(time.RFC3339Nano),
+			Request:         req,
+			Response: har.Response{
+				Status:      res.StatusCode,
+				StatusText:  res.StatusText,
+				HTTPVersion: httpVersion,
+				Cookies:     []har.Cookie{},
+				Headers:     harHeaders(res.Headers),
+				Content:     content,
+				RedirectURL: headerValue(res.Headers, "Location"),
+				HeadersSize: -1,
+				BodySize:    len(res.Content),
+			},
+			ServerIPAddress: res.RemoteIP,
+			Labels:          res.Labels,
+		})
+	}
+
+	return doc.Encode(w)
+}
+
+// burpItems Burp Suite "Save items" 导出格式
+type burpItems struct {
+	XMLName     xml.Name   `xml:"items"`
+	BurpVersion string     `xml:"burpVersion,attr"`
+	ExportTime  string     `xml:"exportTime,attr"`
+	Items       []burpItem `xml:"item"`
+}
+
+type burpItem struct {
+	Time           string   `xml:"time"`
+	URL            cdata    `xml:"url"`
+	Host           burpHost `xml:"host"`
+	Port           int      `xml:"port"`
+	Protocol       string   `xml:"protocol"`
+	Method         cdata    `xml:"method"`
+	Path           cdata    `xml:"path"`
+	Extension      string   `xml:"extension"`
+	Request        burpData `xml:"request"`
+	Status         int      `xml:"status"`
+	ResponseLength int      `xml:"responselength"`
+	MimeType       string   `xml:"mimetype"`
+	Response       burpData `xml:"response"`
+	Comment        string   `xml:"comment"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+type burpHost struct {
+	IP   string `xml:"ip,attr"`
+	Name string `xml:",chardata"`
+}
+
+type burpData struct {
+	Base64 bool   `xml:"base64,attr"`
+	Value  string `xml:",cdata"`
+}
+
+// burpTimeLayout Burp 导出使用的 Java Date.toString() 格式
+const burpTimeLayout = "Mon Jan 02 15:04:05 MST 2006"
+
+// ExportBurpXML 将资源导出为 Burp Suite XML items 格式。
+// 原始请求/响应统一 base64 编码（base64="true"），超大 body 截断并在 comment 中标记。
+func ExportBurpXML(resources map[string]*crawler.Resource, w io.Writer) error {
+	doc := burpItems{
+		BurpVersion: "spider",
+		ExportTime:  time.Now().Format(burpTimeLayout),
+	}
+
+	for _, res := range sortedResources(resources) {
+		u, err := url.Parse(res.URL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+		portNum, _ := strconv.Atoi(port)
+
+		method := res.Method
+		if method == "" {
+			method = "GET"
+		}
+		ext := strings.TrimPrefix(path.Ext(u.Path), ".")
+		if ext == "" {
+			ext = "null"
+		}
+
+		rawResp, truncated := rawResponse(res)
+		item := burpItem{
+			Time:           res.ResponseTime.Format(burpTimeLayout),
+			URL:            cdata{res.URL},
+			Host:           burpHost{IP: res.RemoteIP, Name: u.Hostname()},
+			Port:           portNum,
+			Protocol:       u.Scheme,
+			Method:         cdata{method},
+			Path:           cdata{u.RequestURI()},
+			Extension:      ext,
+			Request:        burpData{Base64: true, Value: base64.StdEncoding.EncodeToString(rawRequest(res, method, u))},
+			Status:         res.StatusCode,
+			ResponseLength: len(rawResp),
+			MimeType:       burpMimeType(res.MimeType),
+			Response:       burpData{Base64: true, Value: base64.StdEncoding.EncodeToString(rawResp)},
+		}
+		if truncated {
+			item.Comment = fmt.Sprintf("response body truncated to %d bytes", maxExportBodyBytes)
+		}
+		doc.Items = append(doc.Items, item)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// rawRequest 还原 HTTP/1.1 格式的原始请求
+func rawRequest(res *crawler.Resource, method string, u *url.URL) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s HTTP/1.1\r\n", method, u.RequestURI())
+	fmt.Fprintf(&b, "Host: %s\r\n", u.Host)
+	writeRawHeaders(&b, res.RequestHeaders, "host")
+	b.WriteString("\r\n")
+	body, _ := truncateBody(res.RequestBody)
+	return append([]byte(b.String()), body...)
+}
+
+// rawResponse 还原 HTTP/1.1 格式的原始响应，返回是否截断
+func rawResponse(res *crawler.Resource) ([]byte, bool) {
+	var b strings.Builder
+	statusText := res.StatusText
+	if statusText == "" {
+		// HTTP/2 没有 reason phrase
+		statusText = http.StatusText(res.StatusCode)
+	}
+	fmt.Fprintf(&b, "HTTP/1.1 %d %s\r\n", res.StatusCode, statusText)
+	writeRawHeaders(&b, res.Headers, "")
+	b.WriteString("\r\n")
+	body, truncated := truncateBody(res.Content)
+	return append([]byte(b.String()), body...), truncated
+}
+
+// writeRawHeaders 按名称排序写入 header，跳过 HTTP/2 伪首部和 skip 指定的 header。
+// CDP 将同名多值 header 以换行拼接，此处拆回多行。
+func writeRawHeaders(b *strings.Builder, headers map[string]string, skip string) {
+	for _, name := range sortedKeys(headers) {
+		if strings.HasPrefix(name, ":") || strings.EqualFold(name, skip) {
+			continue
+		}
+		for value := range strings.SplitSeq(headers[name], "\n") {
+			fmt.Fprintf(b, "%s: %s\r\n", name, value)
+		}
+	}
+}
+
+// encodeBody 文本 body 原样返回，二进制 body 返回 base64 和 "base64" 编码标记
+func encodeBody(body []byte) (text, encoding string, truncated bool) {
+	body, truncated = truncateBody(body)
+	if isBinary(body) {
+		return base64.StdEncoding.EncodeToString(body), "base64", truncated
+	}
+	return string(body), "", truncated
+}
+
+// truncateBody 截断超大 body 并追加标记
+func truncateBody(body []byte) ([]byte, bool) {
+	if len(body) <= maxExportBodyBytes {
+		return body, false
+	}
+	marker := fmt.Sprintf("\n[spider: truncated %d bytes]", len(body)-maxExportBodyBytes)
+	out := make([]byte, 0, maxExportBodyBytes+len(marker))
+	out = append(out, body[:maxExportBodyBytes]...)
+	return append(out, marker...), true
+}
+
+// isBinary 非 UTF-8 或含 NUL 字节的内容视为二进制
+func isBinary(data []byte) bool {
+	return !utf8.Valid(data) || strings.IndexByte(string(data), 0) != -1
+}
+
+// burpMimeType 映射到 Burp 的 MIME 分类
+func burpMimeType(mimeType string) string {
+	switch mt := strings.ToLower(mimeType); {
+	case strings.Contains(mt, "html"):
+		return "HTML"
+	case strings.Contains(mt, "javascript"), strings.Contains(mt, "ecmascript"):
+		return "script"
+	case strings.Contains(mt, "json"):
+		return "JSON"
+	case strings.Contains(mt, "css"):
+		return "CSS"
+	case strings.Contains(mt, "xml"):
+		return "XML"
+	case strings.HasPrefix(mt, "image/"):
+		return "image"
+	case strings.HasPrefix(mt, "text/"):
+		return "text"
+	case mt == "":
+		return ""
+	default:
+		return "app"
+	}
+}
+
+// harHTTPVersion 将 CDP protocol 字段转换为 HAR httpVersion
+func harHTTPVersion(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "h2":
+		return "HTTP/2"
+	case "h3", "h3-29":
+		return "HTTP/3"
+	case "http/1.0":
+		return "HTTP/1.0"
+	default:
+		return "HTTP/1.1"
+	}
+}
+
+// harHeaders 转为按名称排序的 HAR header 列表
+func harHeaders(headers map[string]string) []har.NameValue {
+	list := make([]har.NameValue, 0, len(headers))
+	for _, name := range sortedKeys(headers) {
+		for value := range strings.SplitSeq(headers[name], "\n") {
+			list = append(list, har.NameValue{Name: name, Value: value})
+		}
+	}
+	return list
+}
+
+// headerValue 大小写不敏感地查找 header
+func headerValue(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// sortedResources 按响应时间排序，保证导出结果稳定
+func sortedResources(resources map[string]*crawler.Resource) []*crawler.Resource {
+	list := make([]*crawler.Resource, 0, len(resources))
+	for _, res := range resources {
+		list = append(list, res)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].ResponseTime.Equal(list[j].ResponseTime) {
+			return list[i].ResponseTime.Before(list[j].ResponseTime)
+		}
+		return list[i].URL < list[j].URL
+	})
+	return list
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,354 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/har"
+)
+
+var update = flag.Bool("update", false, "用当前输出重写 testdata/*.golden")
+
+// pngHeader 二进制响应体（非 UTF-8），导出时应 base64 编码
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01")
+
+// exportFixture 覆盖 http / https 默认端口、显式端口、含 ]]> 的 URL、请求体和二进制响应
+func exportFixture() map[string]*crawler.Resource {
+	at := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	list := []*crawler.Resource{
+		{
+			URL: "http://example.com/index.html", Method: "GET", StatusCode: 200, StatusText: "OK", Protocol: "http/1.1",
+			RemoteIP: "93.184.216.34", MimeType: "text/html", Content: []byte("<html><body>hi</body></html>"),
+			Headers:        map[string]string{"Content-Type": "text/html", "Set-Cookie": "a=1\nb=2"},
+			RequestHeaders: map[string]string{"Accept": "text/html", "Host": "example.com"},
+			ResponseTime:   at,
+		},
+		{
+			URL: "https://secure.example.com/img/logo.png", StatusCode: 200, Protocol: "h2",
+			MimeType: "image/png", Content: pngHeader,
+			Headers:      map[string]string{":status": "200", "content-type": "image/png"},
+			ResponseTime: at.Add(time.Second),
+		},
+		{
+			URL: "http://example.com:8080/api/search?q=a]]>b", Method: "POST", StatusCode: 201, StatusText: "Created",
+			MimeType: "application/json", Content: []byte(`{"ok":"]]>"}`),
+			Headers:        map[string]string{"Content-Type": "application/json"},
+			RequestHeaders: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			RequestBody:    []byte("q=a%5D%5D%3Eb"),
+			ResponseTime:   at.Add(2 * time.Second),
+		},
+		{
+			URL: "https://secure.example.com:8443/app.js", StatusCode: 200, StatusText: "OK",
+			MimeType: "application/javascript", Content: []byte("console.log(1)"),
+			Headers:      map[string]string{"Content-Type": "application/javascript"},
+			ResponseTime: at.Add(3 * time.Second),
+		},
+	}
+	resources := make(map[string]*crawler.Resource, len(list))
+	for _, res := range list {
+		resources[res.URL] = res
+	}
+	return resources
+}
+
+// reExportTime Burp 导出头部的导出时间随运行变化，比较前替换掉
+var reExportTime = regexp.MustCompile(`exportTime="[^"]*"`)
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("读取 %s 失败（可用 go test -update 生成）: %v", golden, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("输出与 %s 不同（确认无误后用 go test -update 更新）:\n%s", golden, got)
+	}
+}
+
+func TestExportBurpXMLGolden(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportBurpXML(exportFixture(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "burp.golden", reExportTime.ReplaceAll(buf.Bytes(), []byte(`exportTime="-"`)))
+}
+
+func TestExportHARGolden(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportHAR(exportFixture(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "har.golden", buf.Bytes())
+}
+
+// 按 Burp 的格式读回：CDATA 中的 ]]> 被拆开转义后仍还原为原值，请求 / 响应 base64 可解码，端口按协议推导
+func TestExportBurpXMLRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportBurpXML(exportFixture(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "]]]]><![CDATA[>") {
+		t.Error("CDATA 中的 ]]> 未被拆分转义")
+	}
+	var doc burpItems
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("导出的 XML 无法解析: %v", err)
+	}
+
+	want := map[string]struct {
+		port     int
+		protocol string
+	}{
+		"http://example.com/index.html":              {80, "http"},
+		"https://secure.example.com/img/logo.png":    {443, "https"},
+		"http://example.com:8080/api/search?q=a]]>b": {8080, "http"},
+		"https://secure.example.com:8443/app.js":     {8443, "https"},
+	}
+	if len(doc.Items) != len(want) {
+		t.Fatalf("导出了 %d 项，应为 %d", len(doc.Items), len(want))
+	}
+	resources := exportFixture()
+	for _, item := range doc.Items {
+		w, ok := want[item.URL.Value]
+		if !ok {
+			t.Errorf("URL %q 未原样还原", item.URL.Value)
+			continue
+		}
+		if item.Port != w.port || item.Protocol != w.protocol {
+			t.Errorf("%s: port / protocol = %d / %s，应为 %d / %s", item.URL.Value, item.Port, item.Protocol, w.port, w.protocol)
+		}
+
+		res := resources[item.URL.Value]
+		req, err := base64.StdEncoding.DecodeString(item.Request.Value)
+		if err != nil || !item.Request.Base64 {
+			t.Fatalf("%s: 请求未 base64 编码: %v", item.URL.Value, err)
+		}
+		if !bytes.HasSuffix(req, append([]byte("\r\n\r\n"), res.RequestBody...)) {
+			t.Errorf("%s: 请求体未还原:\n%q", item.URL.Value, req)
+		}
+		resp, err := base64.StdEncoding.DecodeString(item.Response.Value)
+		if err != nil || !item.Response.Base64 {
+			t.Fatalf("%s: 响应未 base64 编码: %v", item.URL.Value, err)
+		}
+		if !bytes.HasSuffix(resp, res.Content) || item.ResponseLength != len(resp) {
+			t.Errorf("%s: 响应体未还原或长度不符 (%d / %d)", item.URL.Value, item.ResponseLength, len(resp))
+		}
+		if bytes.Contains(resp, []byte(":status")) {
+			t.Errorf("%s: 原始响应中包含 HTTP/2 伪首部", item.URL.Value)
+		}
+	}
+}
+
+// 二进制 body 在 HAR 中 base64 编码，文本 body 原样保存，请求体写入 postData
+func TestExportHARBodies(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportHAR(exportFixture(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := har.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range doc.Log.Entries {
+		switch e.Request.URL {
+		case "https://secure.example.com/img/logo.png":
+			if e.Response.Content.Encoding != "base64" {
+				t.Errorf("二进制响应的 encoding = %q", e.Response.Content.Encoding)
+			}
+			if body, _ := base64.StdEncoding.DecodeString(e.Response.Content.Text); !bytes.Equal(body, pngHeader) {
+				t.Error("二进制响应 base64 解码后与原内容不同")
+			}
+			if e.Response.HTTPVersion != "HTTP/2" {
+				t.Errorf("h2 的 httpVersion = %q", e.Response.HTTPVersion)
+			}
+		case "http://example.com:8080/api/search?q=a]]>b":
+			if e.Request.PostData == nil || e.Request.PostData.Text != "q=a%5D%5D%3Eb" {
+				t.Errorf("请求体 = %+v", e.Request.PostData)
+			}
+			if e.Response.Content.Encoding != "" || e.Response.Content.Text != `{"ok":"]]>"}` {
+				t.Errorf("文本响应 = %q (%q)", e.Response.Content.Text, e.Response.Content.Encoding)
+			}
+		}
+	}
+}
+
+// 每个资源一行且以换行结尾，按响应时间排序；content_b64 解码后与原内容相同（含二进制和空 body），
+// 响应头按 forwardHeaders 不区分大小写过滤
+func TestExportNDJSON(t *testing.T) {
+	resources := exportFixture()
+	resources["http://example.com/empty"] = &crawler.Resource{URL: "http://example.com/empty", StatusCode: 204,
+		ResponseTime: time.Date(2024, 3, 1, 9, 31, 0, 0, time.UTC)}
+
+	decode := func(t *testing.T, data []byte) []NDJSONRecord {
+		t.Helper()
+		if !bytes.HasSuffix(data, []byte("\n")) {
+			t.Fatal("最后一条记录没有以换行结尾")
+		}
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		records := make([]NDJSONRecord, len(lines))
+		for i, line := range lines {
+			dec := json.NewDecoder(strings.NewReader(line))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&records[i]); err != nil {
+				t.Fatalf("第 %d 行无法解码: %v\n%s", i+1, err, line)
+			}
+		}
+		return records
+	}
+
+	var buf bytes.Buffer
+	if err := ExportNDJSON(resources, nil, &buf); err != nil {
+		t.Fatal(err)
+	}
+	records := decode(t, buf.Bytes())
+	wantOrder := []string{
+		"http://example.com/index.html",
+		"https://secure.example.com/img/logo.png",
+		"http://example.com:8080/api/search?q=a]]>b",
+		"https://secure.example.com:8443/app.js",
+		"http://example.com/empty",
+	}
+	if len(records) != len(wantOrder) {
+		t.Fatalf("共 %d 行，应为 %d 行", len(records), len(wantOrder))
+	}
+	for i, rec := range records {
+		if rec.URL != wantOrder[i] {
+			t.Errorf("第 %d 行为 %s，应为 %s", i+1, rec.URL, wantOrder[i])
+			continue
+		}
+		res := resources[rec.URL]
+		content, err := base64.StdEncoding.DecodeString(rec.ContentB64)
+		if err != nil || !bytes.Equal(content, res.Content) {
+			t.Errorf("%s 的 content_b64 解码为 %q (%v)，应为 %q", rec.URL, content, err, res.Content)
+		}
+		if rec.Size != len(res.Content) || rec.Status != res.StatusCode || rec.MimeType != res.MimeType {
+			t.Errorf("%s 记录为 %d %d %q", rec.URL, rec.Size, rec.Status, rec.MimeType)
+		}
+		if fmt.Sprint(rec.Headers) != fmt.Sprint(res.Headers) {
+			t.Errorf("未设置过滤时 %s 的响应头为 %v，应为 %v", rec.URL, rec.Headers, res.Headers)
+		}
+	}
+	// HTML 不转义，URL 中的 ]]> 原样写出
+	if !strings.Contains(buf.String(), `"url":"http://example.com:8080/api/search?q=a]]>b"`) {
+		t.Error("URL 中的 > 被转义")
+	}
+
+	buf.Reset()
+	if err := ExportNDJSON(resources, []string{"content-type"}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range decode(t, buf.Bytes()) {
+		switch rec.URL {
+		case "http://example.com/index.html":
+			if len(rec.Headers) != 1 || rec.Headers["Content-Type"] != "text/html" {
+				t.Errorf("过滤后的响应头为 %v，应只有 Content-Type", rec.Headers)
+			}
+		case "https://secure.example.com/img/logo.png":
+			if len(rec.Headers) != 1 || rec.Headers["content-type"] != "image/png" {
+				t.Errorf("过滤应不区分大小写，实际 %v", rec.Headers)
+			}
+		case "http://example.com/empty":
+			if rec.Headers != nil {
+				t.Errorf("没有响应头的资源写出了 %v", rec.Headers)
+			}
+		}
+	}
+
+	// Storage.Export 使用 SetForwardHeaders 的列表
+	dir := t.TempDir()
+	st := NewFlat(dir)
+	st.SetForwardHeaders([]string{"Set-Cookie"})
+	if err := st.Export(FormatNDJSON, resources); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "resources.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range decode(t, data) {
+		if want := resources[rec.URL].Headers["Set-Cookie"]; rec.Headers["Set-Cookie"] != want || len(rec.Headers) > 1 {
+			t.Errorf("%s 的响应头为 %v，应只有 Set-Cookie", rec.URL, rec.Headers)
+		}
+	}
+}
+
+// 超过 maxExportBodyBytes 的 body 截断到上限并追加标记，Burp 和 HAR 都在 comment 中说明
+func TestExportTruncatesLargeBodies(t *testing.T) {
+	const extra = 1234
+	big := bytes.Repeat([]byte("a"), maxExportBodyBytes+extra)
+	resources := map[string]*crawler.Resource{
+		"https://example.com/big.js": {URL: "https://example.com/big.js", StatusCode: 200, StatusText: "OK",
+			MimeType: "application/javascript", Content: big, Headers: map[string]string{}},
+	}
+	marker := fmt.Sprintf("\n[spider: truncated %d bytes]", extra)
+
+	var buf bytes.Buffer
+	if err := ExportBurpXML(resources, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var doc burpItems
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	resp, _ := base64.StdEncoding.DecodeString(doc.Items[0].Response.Value)
+	_, body, _ := bytes.Cut(resp, []byte("\r\n\r\n"))
+	if len(body) != maxExportBodyBytes+len(marker) || !bytes.HasSuffix(body, []byte(marker)) {
+		t.Errorf("Burp 响应体长度 %d，应截断为 %d 字节并以 %q 结尾", len(body), maxExportBodyBytes+len(marker), marker)
+	}
+	if want := fmt.Sprintf("response body truncated to %d bytes", maxExportBodyBytes); doc.Items[0].Comment != want {
+		t.Errorf("Burp comment = %q，应为 %q", doc.Items[0].Comment, want)
+	}
+
+	buf.Reset()
+	if err := ExportHAR(resources, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var h struct {
+		Log struct {
+			Entries []struct {
+				Response struct {
+					Content struct {
+						Size    int    `json:"size"`
+						Text    string `json:"text"`
+						Comment string `json:"comment"`
+					} `json:"content"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &h); err != nil {
+		t.Fatal(err)
+	}
+	c := h.Log.Entries[0].Response.Content
+	if c.Size != len(big) || len(c.Text) != maxExportBodyBytes+len(marker) || !strings.HasSuffix(c.Text, marker) {
+		t.Errorf("HAR content size = %d、text 长度 = %d，应为 %d、%d", c.Size, len(c.Text), len(big), maxExportBodyBytes+len(marker))
+	}
+	if c.Comment != fmt.Sprintf("truncated to %d bytes", maxExportBodyBytes) {
+		t.Errorf("HAR comment = %q", c.Comment)
+	}
+
+	// 恰好等于上限的 body 不截断
+	if body, truncated := truncateBody(big[:maxExportBodyBytes]); truncated || len(body) != maxExportBodyBytes {
+		t.Errorf("等于上限的 body 被截断为 %d 字节", len(body))
+	}
+}
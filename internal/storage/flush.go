@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/logging"
+)
+
+const partialManifestName = "manifest.partial.json"
+
+// Flusher 在爬取进行中分批落盘已完成的资源，并滚动刷新 manifest.partial.json，
+// 让下游尽早开始处理。清单只在对应文件写完之后才更新，
+// 因此部分清单中引用的文件一定已经存在；最终的 resources.json 会取代它。
+type Flusher struct {
+	store    *Storage
+	maxBytes int64
+
+	mu           sync.Mutex
+	pending      []*crawler.Resource
+	pendingBytes int64
+	entries      []IndexEntry // 已落盘资源，单调增长
+
+	flushMu sync.Mutex // 串行化 flush，保证清单顺序
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewFlusher 创建分批落盘器：每隔 interval 或累计 maxBytes 字节触发一次落盘（0 表示不按该维度触发）
+func NewFlusher(store *Storage, interval time.Duration, maxBytes int64) *Flusher {
+	f := &Flusher{
+		store:    store,
+		maxBytes: maxBytes,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go f.run(interval)
+	return f
+}
+
+func (f *Flusher) run(interval time.Duration) {
+	defer close(f.done)
+	if interval <= 0 {
+		<-f.stop
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+			f.Flush()
+		}
+	}
+}
+
+// Add 加入一个已抓取完成的资源（可并发调用）
+func (f *Flusher) Add(res *crawler.Resource) {
+	f.mu.Lock()
+	f.pending = append(f.pending, res)
+	f.pendingBytes += int64(len(res.Content))
+	full := f.maxBytes > 0 && f.pendingBytes >= f.maxBytes
+	f.mu.Unlock()
+
+	if full {
+		go f.Flush()
+	}
+}
+
+// Flush 立即落盘当前批次并刷新部分清单
+func (f *Flusher) Flush() {
+	f.flushMu.Lock()
+	defer f.flushMu.Unlock()
+
+	f.mu.Lock()
+	batch := f.pending
+	f.pending = nil
+	f.pendingBytes = 0
+	f.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	// 先写文件，再更新清单
+	for _, res := range batch {
+		if err := f.store.saveResource(res); err != nil {
+			logging.Warnf("分批保存资源失败 %s: %v", res.URL, err)
+			continue
+		}
+		f.entries = append(f.entries, f.store.indexEntry(res))
+	}
+
+	if err := f.store.writeJSON(partialManifestName, f.entries); err != nil {
+		logging.Warnf("写入 %s 失败: %v", partialManifestName, err)
+		return
+	}
+	log.Printf("已分批落盘 %d 个资源（累计 %d）", len(batch), len(f.entries))
+}
+
+// Close 停止定时落盘并写出剩余资源
+func (f *Flusher) Close() {
+	close(f.stop)
+	<-f.done
+	f.Flush()
+}
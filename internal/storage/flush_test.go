@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+)
+
+func flushResource(i int) *crawler.Resource {
+	u := fmt.Sprintf("https://example.com/static/chunk-%03d.js", i)
+	return &crawler.Resource{
+		URL:        u,
+		StatusCode: 200,
+		MimeType:   "application/javascript",
+		Content:    []byte(strings.Repeat(fmt.Sprintf("// chunk %d\n", i), 50)),
+		Headers:    map[string]string{},
+	}
+}
+
+// loadPartial 读取 manifest.partial.json，不存在时返回 nil；
+// 同时确认清单中引用的每个文件都已写到磁盘
+func loadPartial(dir string) ([]IndexEntry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, partialManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []IndexEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s 不是合法 JSON: %w", partialManifestName, err)
+	}
+	for _, e := range entries {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(e.Path))); err != nil {
+			return nil, fmt.Errorf("部分清单引用了尚未落盘的文件 %s: %w", e.Path, err)
+		}
+	}
+	return entries, nil
+}
+
+// checkGrowth 新清单必须以旧清单为前缀：已发布的记录不会消失或改变
+func checkGrowth(prev, cur []IndexEntry) error {
+	if len(cur) < len(prev) {
+		return fmt.Errorf("部分清单从 %d 条缩减到 %d 条", len(prev), len(cur))
+	}
+	for i := range prev {
+		if prev[i].URL != cur[i].URL || prev[i].Path != cur[i].Path {
+			return fmt.Errorf("部分清单第 %d 条从 %s 变成了 %s", i, prev[i].URL, cur[i].URL)
+		}
+	}
+	return nil
+}
+
+func readPartial(t *testing.T, dir string) []IndexEntry {
+	t.Helper()
+	entries, err := loadPartial(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+// 每次 Flush 后部分清单都是合法 JSON、单调增长、只引用已落盘的文件；
+// WriteIndex 写出 resources.json 后删除部分清单
+func TestFlusherPartialManifest(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFlat(dir)
+	f := NewFlusher(store, 0, 0)
+
+	f.Flush() // 没有待落盘资源时不写清单
+	if entries := readPartial(t, dir); entries != nil {
+		t.Fatalf("空批次写出了部分清单: %v", entries)
+	}
+
+	resources := make(map[string]*crawler.Resource)
+	var prev []IndexEntry
+	for batch := range 4 {
+		for i := range 5 {
+			res := flushResource(batch*5 + i)
+			resources[res.URL] = res
+			f.Add(res)
+		}
+		f.Flush()
+		cur := readPartial(t, dir)
+		if err := checkGrowth(prev, cur); err != nil {
+			t.Fatal(err)
+		}
+		if len(cur) != (batch+1)*5 {
+			t.Fatalf("第 %d 批后部分清单有 %d 条，应为 %d", batch+1, len(cur), (batch+1)*5)
+		}
+		prev = cur
+	}
+	f.Close()
+
+	if err := store.WriteIndex(resources); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, partialManifestName)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("写出 resources.json 后 %s 仍存在", partialManifestName)
+	}
+	index, err := ReadIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != len(resources) {
+		t.Errorf("resources.json 有 %d 条，应为 %d", len(index), len(resources))
+	}
+}
+
+// 按字节数和按时间间隔触发的落盘在后台进行，读者在任何时刻读到的部分清单都有效且单调增长
+func TestFlusherBackgroundTriggers(t *testing.T) {
+	cases := []struct {
+		name     string
+		interval time.Duration
+		maxBytes int64
+	}{
+		{"bytes", 0, 2048},
+		{"interval", 10 * time.Millisecond, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			f := NewFlusher(NewFlat(dir), tc.interval, tc.maxBytes)
+
+			const total = 60
+			stop := make(chan struct{})
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var prev []IndexEntry
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					cur, err := loadPartial(dir)
+					if err == nil {
+						err = checkGrowth(prev, cur)
+					}
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					prev = cur
+					time.Sleep(time.Millisecond)
+				}
+			}()
+
+			for i := range total {
+				f.Add(flushResource(i))
+				time.Sleep(time.Millisecond)
+			}
+			// 关闭前后台已经落盘过至少一批
+			deadline := time.Now().Add(5 * time.Second)
+			for len(readPartial(t, dir)) == 0 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			if len(readPartial(t, dir)) == 0 {
+				t.Fatal("爬取进行中没有触发任何落盘")
+			}
+			f.Close()
+			close(stop)
+			wg.Wait()
+
+			if n := len(readPartial(t, dir)); n != total {
+				t.Errorf("Close 后部分清单有 %d 条，应为 %d", n, total)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+
+	"spider/internal/crawler"
+	"spider/internal/logging"
+)
+
+// gitRemoteName ExportGitBundle 推送使用的远程仓库名
+const gitRemoteName = "origin"
+
+// SetTargetURL 记录本次爬取的目标 URL，ExportGitBundle 用作提交作者邮箱
+func (st *Storage) SetTargetURL(targetURL string) {
+	st.targetURL = targetURL
+}
+
+// SetGitRemote 设置 ExportGitBundle 提交后推送的远程仓库地址（写入仓库的 origin 配置）
+func (st *Storage) SetGitRemote(remoteURL string) {
+	st.gitRemote = remoteURL
+}
+
+// ExportGitBundle 将资源作为一次提交写入 repoDir 处的裸仓库（不存在则初始化）。
+// 文件布局与 New 相同（host/path），每次爬取在上一次的基础上追加一个提交，
+// 因此可以直接用 git diff / git log 比较各次爬取的差异。
+// 仓库配置了 origin 远程时，提交后推送当前分支。
+func (st *Storage) ExportGitBundle(ctx context.Context, repoDir string, resources map[string]*crawler.Resource) error {
+	repo, err := git.PlainOpen(repoDir)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		repo, err = git.PlainInit(repoDir, true)
+	}
+	if err != nil {
+		return fmt.Errorf("打开 Git 仓库失败: %w", err)
+	}
+
+	if st.gitRemote != "" {
+		if err := setGitRemote(repo, st.gitRemote); err != nil {
+			return err
+		}
+	}
+
+	root := newGitDir()
+	layout := &Storage{} // 只借用 getFilePath 生成相对的 host/path
+	for _, res := range sortedByURL(resources) {
+		if len(res.Content) == 0 {
+			continue
+		}
+		path, err := layout.getFilePath(res.URL, res.ContentMimeType())
+		if err != nil {
+			continue
+		}
+		hash, err := writeGitBlob(repo.Storer, res.Content)
+		if err != nil {
+			return fmt.Errorf("写入 blob 失败 %s: %w", res.URL, err)
+		}
+		if !root.add(strings.Split(filepath.ToSlash(path), "/"), hash) {
+			logging.Warnf("Git 导出路径冲突，已跳过: %s", path)
+		}
+	}
+
+	treeHash, err := root.write(repo.Storer)
+	if err != nil {
+		return fmt.Errorf("写入 tree 失败: %w", err)
+	}
+
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return fmt.Errorf("读取 HEAD 失败: %w", err)
+	}
+	branch := head.Target()
+	if branch == "" {
+		branch = plumbing.Master
+	}
+
+	var parents []plumbing.Hash
+	if ref, err := repo.Storer.Reference(branch); err == nil {
+		parent, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return fmt.Errorf("读取上一次提交失败: %w", err)
+		}
+		if parent.TreeHash == treeHash {
+			log.Printf("Git 导出: 与上一次爬取相同，未创建新提交")
+			return st.pushGit(ctx, repo)
+		}
+		parents = append(parents, ref.Hash())
+	}
+
+	now := time.Now()
+	email := st.targetURL
+	if email == "" {
+		email = "spider@localhost"
+	}
+	sig := object.Signature{Name: "spider", Email: email, When: now}
+	commit := &object.Commit{
+		Author:       sig,
+		Committer:    sig,
+		Message:      now.Format(time.RFC3339) + "\n",
+		TreeHash:     treeHash,
+		ParentHashes: parents,
+	}
+	obj := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(obj); err != nil {
+		return fmt.Errorf("编码提交失败: %w", err)
+	}
+	commitHash, err := repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return fmt.Errorf("写入提交失败: %w", err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(branch, commitHash)); err != nil {
+		return fmt.Errorf("更新分支失败: %w", err)
+	}
+	log.Printf("Git 导出: %s 提交 %s", repoDir, commitHash.String()[:7])
+
+	return st.pushGit(ctx, repo)
+}
+
+// pushGit 仓库配置了 origin 时推送，已是最新不算错误
+func (st *Storage) pushGit(ctx context.Context, repo *git.Repository) error {
+	if _, err := repo.Remote(gitRemoteName); err != nil {
+		return nil
+	}
+	err := repo.PushContext(ctx, &git.PushOptions{RemoteName: gitRemoteName})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("推送到 %s 失败: %w", gitRemoteName, err)
+	}
+	return nil
+}
+
+// setGitRemote 创建或更新 origin 远程地址
+func setGitRemote(repo *git.Repository, remoteURL string) error {
+	if remote, err := repo.Remote(gitRemoteName); err == nil {
+		if urls := remote.Config().URLs; len(urls) == 1 && urls[0] == remoteURL {
+			return nil
+		}
+		if err := repo.DeleteRemote(gitRemoteName); err != nil {
+			return fmt.Errorf("更新远程仓库失败: %w", err)
+		}
+	}
+	if _, err := repo.CreateRemote(&gitconfig.RemoteConfig{Name: gitRemoteName, URLs: []string{remoteURL}}); err != nil {
+		return fmt.Errorf("配置远程仓库失败: %w", err)
+	}
+	return nil
+}
+
+// writeGitBlob 写入文件内容对象
+func writeGitBlob(s storer.EncodedObjectStorer, content []byte) (plumbing.Hash, error) {
+	obj := s.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	obj.SetSize(int64(len(content)))
+	w, err := obj.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if _, err := w.Write(content); err != nil {
+		w.Close()
+		return plumbing.ZeroHash, err
+	}
+	if err := w.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return s.SetEncodedObject(obj)
+}
+
+// gitDir 构建中的目录树
+type gitDir struct {
+	files map[string]plumbing.Hash
+	dirs  map[string]*gitDir
+}
+
+func newGitDir() *gitDir {
+	return &gitDir{files: make(map[string]plumbing.Hash), dirs: make(map[string]*gitDir)}
+}
+
+// add 按路径分段插入文件；与已有文件/目录同名冲突时返回 false
+func (d *gitDir) add(parts []string, hash plumbing.Hash) bool {
+	name := parts[0]
+	if len(parts) == 1 {
+		if _, isDir := d.dirs[name]; isDir {
+			return false
+		}
+		d.files[name] = hash
+		return true
+	}
+	if _, isFile := d.files[name]; isFile {
+		return false
+	}
+	sub, ok := d.dirs[name]
+	if !ok {
+		sub = newGitDir()
+		d.dirs[name] = sub
+	}
+	return sub.add(parts[1:], hash)
+}
+
+// write 自底向上写入 tree 对象，返回本目录的 tree hash
+func (d *gitDir) write(s storer.EncodedObjectStorer) (plumbing.Hash, error) {
+	tree := &object.Tree{}
+	for name, hash := range d.files {
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: name, Mode: filemode.Regular, Hash: hash})
+	}
+	for name, sub := range d.dirs {
+		hash, err := sub.write(s)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: name, Mode: filemode.Dir, Hash: hash})
+	}
+	sort.Sort(object.TreeEntrySorter(tree.Entries))
+
+	obj := s.NewEncodedObject()
+	if err := tree.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return s.SetEncodedObject(obj)
+}
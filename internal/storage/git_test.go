@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"maps"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"spider/internal/crawler"
+)
+
+// gitResources 每次导出的资源：a.js 与目录 a/ 同名前缀，检验 tree 条目按 git 的规则排序
+func gitResources(app string) map[string]*crawler.Resource {
+	resources := map[string]*crawler.Resource{}
+	for _, res := range []*crawler.Resource{
+		{URL: "https://example.com/", MimeType: "text/html", Content: []byte("<html></html>")},
+		{URL: "https://example.com/a.js", MimeType: "application/javascript", Content: []byte(app)},
+		{URL: "https://example.com/a/b.css", MimeType: "text/css", Content: []byte("body{}")},
+		{URL: "https://example.com/empty.js", MimeType: "application/javascript"}, // 空内容不写入
+	} {
+		resources[res.URL] = res
+	}
+	return resources
+}
+
+// headCommit 返回仓库当前分支的提交及其全部文件（路径 → 内容）
+func headCommit(t *testing.T, repoDir string) (*object.Commit, map[string]string) {
+	t.Helper()
+	repo, err := git.PlainOpen(repoDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	iter, err := commit.Files()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := iter.ForEach(func(f *object.File) error {
+		content, err := f.Contents()
+		files[f.Name] = content
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return commit, files
+}
+
+// 每次导出在上一次之上追加一个提交，作者为 spider、邮箱为目标 URL；内容未变时不产生空提交；
+// 没有 origin 远程时不推送也不报错
+func TestExportGitBundle(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	repoDir := filepath.Join(t.TempDir(), "crawls.git")
+	st := NewFlat(t.TempDir())
+	st.SetTargetURL("https://example.com/")
+	ctx := context.Background()
+
+	if err := st.ExportGitBundle(ctx, repoDir, gitResources("v1")); err != nil {
+		t.Fatal(err)
+	}
+	first, files := headCommit(t, repoDir)
+	want := map[string]string{
+		"example.com/index.html": "<html></html>",
+		"example.com/a.js":       "v1",
+		"example.com/a/b.css":    "body{}",
+	}
+	if !maps.Equal(files, want) {
+		t.Errorf("第一次提交的文件为 %v，应为 %v", files, want)
+	}
+	if first.NumParents() != 0 {
+		t.Errorf("第一次提交有 %d 个父提交", first.NumParents())
+	}
+	if first.Author.Name != "spider" || first.Author.Email != "https://example.com/" || first.Committer.Email != first.Author.Email {
+		t.Errorf("作者为 %s <%s>，提交者 <%s>", first.Author.Name, first.Author.Email, first.Committer.Email)
+	}
+
+	if err := st.ExportGitBundle(ctx, repoDir, gitResources("v2")); err != nil {
+		t.Fatal(err)
+	}
+	second, files := headCommit(t, repoDir)
+	want["example.com/a.js"] = "v2"
+	if !maps.Equal(files, want) {
+		t.Errorf("第二次提交的文件为 %v，应为 %v", files, want)
+	}
+	if !slices.Equal(second.ParentHashes, []plumbing.Hash{first.Hash}) {
+		t.Errorf("第二次提交的父提交为 %v，应为 %v", second.ParentHashes, first.Hash)
+	}
+	if patch, err := first.Patch(second); err != nil || len(patch.FilePatches()) != 1 {
+		t.Errorf("两次提交之间应只有 a.js 变化: %v", err)
+	}
+
+	// 内容相同：不创建空提交
+	if err := st.ExportGitBundle(ctx, repoDir, gitResources("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if head, _ := headCommit(t, repoDir); head.Hash != second.Hash {
+		t.Errorf("内容未变时创建了新提交 %s", head.Hash)
+	}
+
+	repo, err := git.PlainOpen(repoDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Remote(gitRemoteName); err == nil {
+		t.Error("未设置远程时不应创建 origin")
+	}
+
+	// 导出的仓库能通过 git 自身的完整性检查（tree 条目顺序等）
+	if gitBin, err := exec.LookPath("git"); err == nil {
+		if out, err := exec.Command(gitBin, "--git-dir", repoDir, "fsck", "--strict").CombinedOutput(); err != nil {
+			t.Errorf("git fsck 失败: %v\n%s", err, out)
+		}
+		out, err := exec.Command(gitBin, "--git-dir", repoDir, "rev-list", "--count", "HEAD").Output()
+		if err != nil || string(bytes.TrimSpace(out)) != "2" {
+			t.Errorf("git rev-list 得到 %q (%v)，应为 2 个提交", out, err)
+		}
+	}
+}
+
+// 设置了远程时提交后推送当前分支
+func TestExportGitBundlePush(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	remoteDir := filepath.Join(t.TempDir(), "remote.git")
+	if _, err := git.PlainInit(remoteDir, true); err != nil {
+		t.Fatal(err)
+	}
+	repoDir := filepath.Join(t.TempDir(), "crawls.git")
+	st := NewFlat(t.TempDir())
+	st.SetGitRemote(remoteDir)
+	for _, app := range []string{"v1", "v1"} { // 第二次已是最新，不算错误
+		if err := st.ExportGitBundle(context.Background(), repoDir, gitResources(app)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	local, _ := headCommit(t, repoDir)
+	remote, err := git.PlainOpen(remoteDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := remote.Reference(plumbing.Master, true)
+	if err != nil {
+		t.Fatalf("远程仓库没有 master: %v", err)
+	}
+	if ref.Hash() != local.Hash {
+		t.Errorf("远程 master 为 %s，应为本地提交 %s", ref.Hash(), local.Hash)
+	}
+	if local.Author.Email != "spider@localhost" {
+		t.Errorf("未设置目标 URL 时作者邮箱为 %q", local.Author.Email)
+	}
+}
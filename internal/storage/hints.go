@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"spider/internal/crawler"
+)
+
+// hintRels 报告中列出的资源提示类型，按此顺序输出
+var hintRels = []string{"preload", "modulepreload", "prefetch", "preconnect", "dns-prefetch"}
+
+// ResourceHint 页面声明的一个 <link rel> 资源提示及其是否有对应的抓取结果
+type ResourceHint struct {
+	Rel  string // preload / modulepreload / prefetch / preconnect / dns-prefetch
+	Href string // 按页面地址解析后的绝对 URL
+	As   string // preload 的 as 属性（script、style、font 等）
+
+	// Loaded preload / modulepreload / prefetch：资源表中有该 URL；
+	// preconnect / dns-prefetch：资源表中有该主机的资源
+	Loaded bool
+}
+
+// ResourceHints 从页面 HTML（优先用渲染后的 DOM，脚本插入的提示也在其中）中提取资源提示，
+// 并按 URL / 主机与抓取到的资源对照。一个 <link> 同时声明多种 rel 时各算一条。
+// 相对地址按 baseURL（页面的 document.baseURI，没有时为页面 URL；文档中的 <base href> 仍然生效）解析
+func ResourceHints(document []byte, baseURL string, resources map[string]*crawler.Resource) []ResourceHint {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil
+	}
+
+	loadedURLs := make(map[string]bool, len(resources))
+	loadedHosts := make(map[string]bool)
+	for _, res := range resources {
+		loadedURLs[stripFragment(res.URL)] = true
+		if u, err := url.Parse(res.URL); err == nil && u.Host != "" {
+			loadedHosts[strings.ToLower(u.Host)] = true
+		}
+	}
+
+	var hints []ResourceHint
+	seen := make(map[string]bool)
+	z := html.NewTokenizer(bytes.NewReader(document))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		name, hasAttr := z.TagName()
+		tag := string(name)
+		if (tag != "link" && tag != "base") || !hasAttr {
+			continue
+		}
+
+		var rel, href, as string
+		for {
+			key, val, more := z.TagAttr()
+			switch string(key) {
+			case "rel":
+				rel = strings.ToLower(string(val))
+			case "href":
+				href = strings.TrimSpace(string(val))
+			case "as":
+				as = strings.ToLower(string(val))
+			}
+			if !more {
+				break
+			}
+		}
+		if href == "" {
+			continue
+		}
+		if tag == "base" {
+			// 没有渲染后的 DOM（如 HAR 回放）时 baseURL 为页面 URL，按文档中的 <base href> 解析其后的地址
+			if u, err := base.Parse(href); err == nil {
+				base = u
+			}
+			continue
+		}
+		ref, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		resolved := base.ResolveReference(ref)
+		if resolved.Scheme != "http" && resolved.Scheme != "https" {
+			continue
+		}
+
+		for _, r := range strings.Fields(rel) {
+			if !slices.Contains(hintRels, r) || seen[r+" "+resolved.String()] {
+				continue
+			}
+			seen[r+" "+resolved.String()] = true
+			hint := ResourceHint{Rel: r, Href: resolved.String(), As: as}
+			if r == "preconnect" || r == "dns-prefetch" {
+				hint.Loaded = loadedHosts[strings.ToLower(resolved.Host)]
+			} else {
+				hint.Loaded = loadedURLs[stripFragment(hint.Href)]
+			}
+			hints = append(hints, hint)
+		}
+	}
+	return hints
+}
+
+// SetResourceHints 设置要写入 report.txt 的资源提示；调用后报告中总会输出该节（没有提示时为 none）
+func (st *Storage) SetResourceHints(hints []ResourceHint) {
+	st.hints = hints
+	st.hintsSet = true
+}
+
+// writeHintsSection 按类型列出声明的资源提示和是否有对应的加载，最后单独列出未加载的 preload
+func (st *Storage) writeHintsSection(report *strings.Builder) {
+	loaded := 0
+	for _, h := range st.hints {
+		if h.Loaded {
+			loaded++
+		}
+	}
+	report.WriteString(fmt.Sprintf("\nResource Hints (%d declared, %d matched a load):\n", len(st.hints), loaded))
+	if len(st.hints) == 0 {
+		report.WriteString("  (none)\n")
+		return
+	}
+
+	var unused []ResourceHint
+	for _, rel := range hintRels {
+		for _, h := range st.hints {
+			if h.Rel != rel {
+				continue
+			}
+			kind := h.Rel
+			if h.As != "" {
+				kind += " as=" + h.As
+			}
+			status := "loaded"
+			if !h.Loaded {
+				status = "not loaded"
+				if h.Rel == "preload" || h.Rel == "modulepreload" {
+					unused = append(unused, h)
+				}
+			}
+			report.WriteString(fmt.Sprintf("  [%s] %s: %s\n", kind, h.Href, status))
+		}
+	}
+
+	if len(unused) > 0 {
+		report.WriteString("\nUnused Preloads (declared but never loaded; check the as / crossorigin attributes):\n")
+		for _, h := range unused {
+			report.WriteString(fmt.Sprintf("  %s\n", h.Href))
+		}
+	}
+}
+
+// stripFragment 去掉 URL 的 #fragment，资源请求不含该部分
+func stripFragment(rawURL string) string {
+	if i := strings.IndexByte(rawURL, '#'); i >= 0 {
+		return rawURL[:i]
+	}
+	return rawURL
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"spider/internal/crawler"
+)
+
+// hintsResources 与 testdata/hints.html 对照的抓取结果：main.css、app.mjs、vendor.js 和 CDN 上的资源被加载，
+// brand.woff2、unused.mjs、next.html 和 analytics 主机没有
+func hintsResources() map[string]*crawler.Resource {
+	resources := make(map[string]*crawler.Resource)
+	for _, u := range []string{
+		"https://shop.example.com/products/",
+		"https://shop.example.com/css/main.css",
+		"https://shop.example.com/js/app.mjs",
+		"https://shop.example.com/js/vendor.js",
+		"https://cdn.example.net/lib.js",
+	} {
+		resources[u] = &crawler.Resource{URL: u, StatusCode: 200, MimeType: "text/plain", Content: []byte("x"), Headers: map[string]string{}}
+	}
+	return resources
+}
+
+func readHintsFixture(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "hints.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// 按页面地址解析相对地址，一个 <link> 声明多种 rel 时各算一条，重复的提示、非 http(s) 地址和没有 href 的忽略；
+// preload 类按 URL（忽略 #fragment）对照，preconnect / dns-prefetch 按主机对照
+func TestResourceHints(t *testing.T) {
+	hints := ResourceHints(readHintsFixture(t), "https://shop.example.com/products/", hintsResources())
+
+	want := []ResourceHint{
+		{Rel: "preload", Href: "https://shop.example.com/css/main.css", As: "style", Loaded: true},
+		{Rel: "preload", Href: "https://shop.example.com/products/fonts/brand.woff2", As: "font"},
+		{Rel: "modulepreload", Href: "https://shop.example.com/js/app.mjs#entry", Loaded: true},
+		{Rel: "modulepreload", Href: "https://shop.example.com/js/unused.mjs"},
+		{Rel: "preload", Href: "https://shop.example.com/js/vendor.js", As: "script", Loaded: true},
+		{Rel: "prefetch", Href: "https://shop.example.com/js/vendor.js", As: "script", Loaded: true},
+		{Rel: "prefetch", Href: "https://shop.example.com/next.html"},
+		{Rel: "preconnect", Href: "https://cdn.example.net", Loaded: true},
+		{Rel: "dns-prefetch", Href: "https://analytics.example.org"},
+	}
+	if len(hints) != len(want) {
+		t.Fatalf("提取到 %d 条提示，应为 %d: %+v", len(hints), len(want), hints)
+	}
+	for i, h := range hints {
+		if h != want[i] {
+			t.Errorf("hints[%d] = %+v，应为 %+v", i, h, want[i])
+		}
+	}
+
+	if hints := ResourceHints([]byte("<html><head></head></html>"), "https://example.com/", nil); len(hints) != 0 {
+		t.Errorf("没有提示的页面提取到 %+v", hints)
+	}
+}
+
+// 报告按类型顺序列出提示及是否加载，未加载的 preload / modulepreload 单独列出（prefetch 等不算）；
+// 开启后没有提示也输出 (none)，未开启时不输出该节
+func TestReportResourceHints(t *testing.T) {
+	resources := hintsResources()
+	generate := func(set bool, hints []ResourceHint) string {
+		dir := t.TempDir()
+		store := NewFlat(dir)
+		if set {
+			store.SetResourceHints(hints)
+		}
+		if err := store.GenerateReport(resources); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "report.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	report := generate(true, ResourceHints(readHintsFixture(t), "https://shop.example.com/products/", resources))
+	want := "\nResource Hints (9 declared, 5 matched a load):\n" +
+		"  [preload as=style] https://shop.example.com/css/main.css: loaded\n" +
+		"  [preload as=font] https://shop.example.com/products/fonts/brand.woff2: not loaded\n" +
+		"  [preload as=script] https://shop.example.com/js/vendor.js: loaded\n" +
+		"  [modulepreload] https://shop.example.com/js/app.mjs#entry: loaded\n" +
+		"  [modulepreload] https://shop.example.com/js/unused.mjs: not loaded\n" +
+		"  [prefetch as=script] https://shop.example.com/js/vendor.js: loaded\n" +
+		"  [prefetch] https://shop.example.com/next.html: not loaded\n" +
+		"  [preconnect] https://cdn.example.net: loaded\n" +
+		"  [dns-prefetch] https://analytics.example.org: not loaded\n" +
+		"\nUnused Preloads (declared but never loaded; check the as / crossorigin attributes):\n" +
+		"  https://shop.example.com/products/fonts/brand.woff2\n" +
+		"  https://shop.example.com/js/unused.mjs\n"
+	if !strings.Contains(report, want) {
+		t.Errorf("报告中的资源提示不正确:\n%s", report)
+	}
+
+	if report := generate(true, nil); !strings.Contains(report, "\nResource Hints (0 declared, 0 matched a load):\n  (none)\n") {
+		t.Errorf("没有提示时应输出 (none):\n%s", report)
+	}
+	if report := generate(false, nil); strings.Contains(report, "Resource Hints") {
+		t.Error("未开启 -hints-report 时不应输出该节")
+	}
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"spider/internal/atomicfile"
+	"spider/internal/crawler"
+)
+
+// IndexEntry resources.json / manifest.partial.json 中的单条资源记录
+type IndexEntry struct {
+	URL      string `json:"url"`
+	Path     string `json:"path,omitempty"` // 相对 baseDir 的保存路径，空内容或因状态码（SkipStatusCodes）未保存时为空
+	Status   int    `json:"status"`
+	MimeType string `json:"mime_type"`
+	Size     int    `json:"size"`
+	SHA256   string `json:"sha256,omitempty"`
+
+	DetectedMimeType string `json:"detected_mime_type,omitempty"` // 按内容嗅探出的类型，与 mime_type 不同时记录
+
+	Headers map[string]string `json:"headers,omitempty"` // 原始响应头，按 ForwardHTTPHeaders 过滤
+
+	Labels map[string]string `json:"labels,omitempty"`
+	Marker string            `json:"marker,omitempty"` // 请求发出时生效的标记（-mark-after-click）
+
+	ReportEndpoints []string `json:"report_endpoints,omitempty"` // CSP 上报地址（已发现，未请求）
+
+	Pages []string `json:"pages,omitempty"` // 引用该资源的页面，按页面汇总即可还原每个页面的资源和体积
+
+	Hint string `json:"hint,omitempty"` // 页面以 <link rel> 提示过该资源: preload / modulepreload / prefetch（-load-prefetch）
+
+	CollapsedCount int        `json:"collapsed_count,omitempty"` // 折叠到本条的重复轮询响应数（CollapsePolling），不含本条
+	LastSeen       *time.Time `json:"last_seen,omitempty"`       // 最后一次收到被折叠响应的时间
+
+	Timing  *crawler.TimingBreakdown `json:"timing,omitempty"`   // Resource Timing API 的分阶段耗时
+	FetchMS int64                    `json:"fetch_ms,omitempty"` // 从发出请求到取得响应体的耗时（毫秒）
+
+	DataURIs []crawler.DataURIFile `json:"data_uris,omitempty"` // 保存时抽到 _data/ 的超大 data URI
+
+	JSON *JSONSummary `json:"json,omitempty"` // JSON 响应的顶层结构摘要，无法解析时标记 invalid
+}
+
+// indexEntry 生成资源的索引记录
+func (st *Storage) indexEntry(res *crawler.Resource) IndexEntry {
+	entry := IndexEntry{
+		URL:      res.URL,
+		Status:   res.StatusCode,
+		MimeType: res.MimeType,
+		Size:     len(res.Content),
+		Labels:   res.Labels,
+		Marker:   res.Marker,
+
+		DetectedMimeType: res.DetectedMimeType,
+
+		Headers: st.forwardedHeaders(res.Headers),
+
+		ReportEndpoints: res.ReportEndpoints,
+
+		CollapsedCount: res.CollapsedCount,
+
+		Pages:   res.Pages,
+		Hint:    res.ResourceHint,
+		Timing:  res.TimingBreakdown,
+		FetchMS: res.FetchDuration.Milliseconds(),
+
+		DataURIs: res.DataURIs,
+
+		JSON: summarizeJSON(res),
+	}
+	if res.CollapsedCount > 0 && !res.LastSeen.IsZero() {
+		lastSeen := res.LastSeen
+		entry.LastSeen = &lastSeen
+	}
+	if len(res.Content) > 0 {
+		sum := sha256.Sum256(res.Content)
+		entry.SHA256 = hex.EncodeToString(sum[:])
+	}
+	if len(res.Content) > 0 && !st.skipsStatus(res) {
+		if fullPath, err := st.getFilePath(res.URL, res.ContentMimeType()); err == nil {
+			if rel, err := filepath.Rel(st.baseDir, fullPath); err == nil {
+				entry.Path = filepath.ToSlash(rel)
+			}
+		}
+	}
+	return entry
+}
+
+// forwardedHeaders 按 SetForwardHeaders 的列表筛选响应头，未设置时返回全部
+func (st *Storage) forwardedHeaders(headers map[string]string) map[string]string {
+	return filterHeaders(headers, st.forwardHeaders)
+}
+
+// filterHeaders 保留 names 中列出的响应头（不区分大小写），names 为空时返回全部
+func filterHeaders(headers map[string]string, names []string) map[string]string {
+	if len(names) == 0 || len(headers) == 0 {
+		return headers
+	}
+	forwarded := make(map[string]string)
+	for name, value := range headers {
+		if slices.ContainsFunc(names, func(want string) bool { return strings.EqualFold(name, want) }) {
+			forwarded[name] = value
+		}
+	}
+	if len(forwarded) == 0 {
+		return nil
+	}
+	return forwarded
+}
+
+// WriteIndex 写入 resources.json（按 URL 排序），并删除爬取过程中的 manifest.partial.json
+func (st *Storage) WriteIndex(resources map[string]*crawler.Resource) error {
+	entries := make([]IndexEntry, 0, len(resources))
+	for _, res := range resources {
+		entries = append(entries, st.indexEntry(res))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].URL < entries[j].URL })
+
+	if err := st.writeJSON("resources.json", entries); err != nil {
+		return err
+	}
+	// 最终索引已就绪，部分清单失去意义
+	os.Remove(filepath.Join(st.baseDir, partialManifestName))
+	return nil
+}
+
+// ReadIndex 读取输出目录中的 resources.json
+func ReadIndex(dir string) ([]IndexEntry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "resources.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []IndexEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("解析 resources.json 失败: %w", err)
+	}
+	return entries, nil
+}
+
+// writeJSON 以缩进格式原子写入 baseDir 下的 JSON 文件
+func (st *Storage) writeJSON(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(st.baseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create base directory: %v", err)
+	}
+	return WriteFileAtomic(filepath.Join(st.baseDir, name), data, 0644)
+}
+
+// WriteFileAtomic 原子写入（见 atomicfile.WriteFile），读者永远看不到写了一半的文件；
+// 进程崩溃或断电时最终路径要么是旧内容要么不存在，-resume 不会把截断的文件当作已完成
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return atomicfile.WriteFile(path, data, perm)
+}
+
+// writeAtomic 与 WriteFileAtomic 相同，内容由 write 流式写入
+func writeAtomic(path string, perm os.FileMode, write func(w io.Writer) error) error {
+	return atomicfile.Write(path, perm, write)
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"spider/internal/crawler"
+)
+
+// maxIssuesPerCategory 报告中每个分类最多列出的问题数，完整列表见 browser-issues.json
+const maxIssuesPerCategory = 20
+
+// SetBrowserIssues 设置要写入 report.txt 的浏览器问题（CaptureBrowserIssues）；调用后报告中总会输出该节（没有时为 none）
+func (st *Storage) SetBrowserIssues(issues []crawler.BrowserIssue) {
+	st.browserIssues = issues
+	st.browserIssuesSet = true
+}
+
+// WriteBrowserIssues 写入 browser-issues.json：爬取期间收集的全部浏览器问题，按出现顺序
+func (st *Storage) WriteBrowserIssues(issues []crawler.BrowserIssue) error {
+	if issues == nil {
+		issues = []crawler.BrowserIssue{}
+	}
+	data, err := json.MarshalIndent(issues, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal browser issues: %v", err)
+	}
+	if err := os.MkdirAll(st.baseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create base directory: %v", err)
+	}
+	return WriteFileAtomic(filepath.Join(st.baseDir, "browser-issues.json"), data, 0644)
+}
+
+// writeBrowserIssuesSection 按分类汇总浏览器问题：CSP、混合内容、CORS、Cookie 等，每类列出前若干条
+func (st *Storage) writeBrowserIssuesSection(report *strings.Builder) {
+	occurrences := 0
+	for _, issue := range st.browserIssues {
+		occurrences += issue.Count
+	}
+	report.WriteString(fmt.Sprintf("\nBrowser Issues (%d distinct, %d occurrences):\n", len(st.browserIssues), occurrences))
+	if len(st.browserIssues) == 0 {
+		report.WriteString("  (none)\n")
+		return
+	}
+	for _, category := range crawler.IssueCategories {
+		var lines []string
+		for _, issue := range st.browserIssues {
+			if issue.Category != category {
+				continue
+			}
+			line := fmt.Sprintf("    [%s] %s", issue.Source, issue.Message)
+			if issue.URL != "" && !strings.Contains(issue.Message, issue.URL) {
+				line += " ← " + issue.URL
+			}
+			if issue.Count > 1 {
+				line += fmt.Sprintf(" (×%d)", issue.Count)
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		report.WriteString(fmt.Sprintf("  %s (%d):\n", category, len(lines)))
+		if len(lines) > maxIssuesPerCategory {
+			more := len(lines) - maxIssuesPerCategory
+			lines = append(lines[:maxIssuesPerCategory], fmt.Sprintf("    ... %d more, see browser-issues.json", more))
+		}
+		report.WriteString(strings.Join(lines, "\n") + "\n")
+	}
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"spider/internal/crawler"
+)
+
+// browserIssuesFixture 爬取 /csp.html 之类页面时收集到的问题：重复的 CSP 违规累计了次数
+func browserIssuesFixture() []crawler.BrowserIssue {
+	return []crawler.BrowserIssue{
+		{Category: crawler.IssueCSP, Source: "audits", Code: "ContentSecurityPolicyIssue",
+			Message: `kURLViolation violates directive "img-src"`, URL: "http://localhost:8080/img/header.svg", Count: 2},
+		{Category: crawler.IssueCSP, Source: "security", Level: "error",
+			Message: "Refused to load the image 'http://localhost:8080/img/header.svg'", URL: "http://127.0.0.1:8080/csp.html", Count: 1},
+		{Category: crawler.IssueDeprecation, Source: "audits", Code: "DeprecationIssue", Message: "UnloadHandler", URL: "https://shop.example.com/app.js", Count: 1},
+		{Category: crawler.IssueCORS, Source: "audits", Code: "CorsIssue", Message: "MissingAllowOriginHeader", URL: "https://api.example.net/data", Count: 3},
+		{Category: crawler.IssueOther, Source: "audits", Code: "GenericIssue", Message: "FormLabelForNameError", Count: 1},
+	}
+}
+
+// browser-issues.json 按出现顺序保存全部问题，空字段省略；没有问题时为 []
+func TestWriteBrowserIssues(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFlat(dir)
+	if err := store.WriteBrowserIssues(browserIssuesFixture()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "browser-issues.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "browser-issues.golden", data)
+
+	var issues []crawler.BrowserIssue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(issues, browserIssuesFixture()) {
+		t.Errorf("读回的问题与写入的不同: %+v", issues)
+	}
+
+	if err := store.WriteBrowserIssues(nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "browser-issues.json")); string(data) != "[]" {
+		t.Errorf("没有问题时 browser-issues.json 为 %q，应为 []", data)
+	}
+}
+
+// 报告按分类顺序汇总，消息中没有的地址附在后面，多次出现的标注次数；每类最多列 maxIssuesPerCategory 条
+func TestReportBrowserIssues(t *testing.T) {
+	resources := map[string]*crawler.Resource{
+		"https://example.com/": {URL: "https://example.com/", StatusCode: 200, MimeType: "text/html", Content: []byte("<html></html>"), Headers: map[string]string{}},
+	}
+	generate := func(set bool, issues []crawler.BrowserIssue) string {
+		dir := t.TempDir()
+		store := NewFlat(dir)
+		if set {
+			store.SetBrowserIssues(issues)
+		}
+		if err := store.GenerateReport(resources); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "report.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	report := generate(true, browserIssuesFixture())
+	want := "\nBrowser Issues (5 distinct, 8 occurrences):\n" +
+		"  csp (2):\n" +
+		"    [audits] kURLViolation violates directive \"img-src\" ← http://localhost:8080/img/header.svg (×2)\n" +
+		"    [security] Refused to load the image 'http://localhost:8080/img/header.svg' ← http://127.0.0.1:8080/csp.html\n" +
+		"  cors (1):\n" +
+		"    [audits] MissingAllowOriginHeader ← https://api.example.net/data (×3)\n" +
+		"  deprecation (1):\n" +
+		"    [audits] UnloadHandler ← https://shop.example.com/app.js\n" +
+		"  other (1):\n" +
+		"    [audits] FormLabelForNameError\n"
+	if !strings.Contains(report, want) {
+		t.Errorf("报告中的浏览器问题不正确:\n%s", report)
+	}
+
+	var many []crawler.BrowserIssue
+	for i := range maxIssuesPerCategory + 3 {
+		many = append(many, crawler.BrowserIssue{Category: crawler.IssueCookie, Source: "audits", Message: fmt.Sprintf("cookie %d", i), Count: 1})
+	}
+	report = generate(true, many)
+	if !strings.Contains(report, fmt.Sprintf("  cookie (%d):\n", len(many))) ||
+		!strings.Contains(report, fmt.Sprintf("    [audits] cookie %d\n    ... 3 more, see browser-issues.json\n", maxIssuesPerCategory-1)) ||
+		strings.Contains(report, fmt.Sprintf("cookie %d\n", maxIssuesPerCategory)) {
+		t.Errorf("超过 %d 条时应截断:\n%s", maxIssuesPerCategory, report)
+	}
+
+	if report := generate(true, nil); !strings.Contains(report, "Browser Issues (0 distinct, 0 occurrences):\n  (none)\n") {
+		t.Errorf("没有问题时应输出 (none):\n%s", report)
+	}
+	if report := generate(false, nil); strings.Contains(report, "Browser Issues") {
+		t.Error("未开启 -browser-issues 时不应输出该节")
+	}
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"spider/internal/crawler"
+)
+
+// maxJSONSummaryBytes 超过该大小的 JSON 响应不解析摘要，也不格式化
+const maxJSONSummaryBytes = 5 * 1024 * 1024
+
+// JSONSummary JSON 响应的结构摘要，写入 resources.json 的 json 字段
+type JSONSummary struct {
+	Type        string   `json:"type,omitempty"`         // 顶层值类型: object / array / string / number / boolean / null
+	Keys        []string `json:"keys,omitempty"`         // 顶层对象的键（排序后）
+	ArrayLength *int     `json:"array_length,omitempty"` // 顶层数组的长度
+	ElementKeys []string `json:"element_keys,omitempty"` // 顶层数组首个元素为对象时的键
+	Invalid     bool     `json:"invalid,omitempty"`      // 声明为 JSON 但无法解析
+	Error       string   `json:"error,omitempty"`        // 解析错误
+}
+
+// SetPrettyJSON 保存时将 JSON 响应（不超过 5MB 且能解析）缩进格式化后写入，默认保留原始内容。
+// resources.json 中的 sha256 和 size 仍按原始响应计算
+func (st *Storage) SetPrettyJSON(enabled bool) {
+	st.prettyJSON = enabled
+}
+
+// isJSONResource 按内容类型判断是否为 JSON 响应（application/json、application/problem+json 等）
+func isJSONResource(res *crawler.Resource) bool {
+	mimeType := strings.ToLower(res.ContentMimeType())
+	return strings.HasSuffix(strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0]), "json")
+}
+
+// summarizeJSON 解析不超过 maxJSONSummaryBytes 的 JSON 响应并生成结构摘要；非 JSON 或过大时返回 nil
+func summarizeJSON(res *crawler.Resource) *JSONSummary {
+	if !isJSONResource(res) || len(res.Content) == 0 || len(res.Content) > maxJSONSummaryBytes {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(res.Content, &v); err != nil {
+		return &JSONSummary{Invalid: true, Error: err.Error()}
+	}
+
+	summary := &JSONSummary{Type: jsonType(v)}
+	switch v := v.(type) {
+	case map[string]any:
+		summary.Keys = sortedKeys(v)
+	case []any:
+		n := len(v)
+		summary.ArrayLength = &n
+		if n > 0 {
+			if first, ok := v[0].(map[string]any); ok {
+				summary.ElementKeys = sortedKeys(first)
+			}
+		}
+	}
+	return summary
+}
+
+// prettyJSONContent 返回缩进格式化后的 JSON；未开启、非 JSON、过大或无法解析时返回原内容
+func (st *Storage) prettyJSONContent(res *crawler.Resource) []byte {
+	if !st.prettyJSON || !isJSONResource(res) || len(res.Content) > maxJSONSummaryBytes {
+		return res.Content
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, res.Content, "", "  "); err != nil {
+		return res.Content
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// writeJSONAPISection 按接口（方法 + 不含查询串的 URL）汇总 JSON 响应的结构，
+// 列出每个接口保存的示例响应文件，并标出声明为 JSON 却无法解析的响应
+func (st *Storage) writeJSONAPISection(report *strings.Builder, resources map[string]*crawler.Resource) {
+	type endpoint struct {
+		name     string
+		summary  *JSONSummary
+		examples []string
+		invalid  []string
+	}
+	endpoints := make(map[string]*endpoint)
+	for _, res := range resources {
+		summary := summarizeJSON(res)
+		if summary == nil {
+			continue
+		}
+		name := jsonEndpoint(res)
+		ep, ok := endpoints[name]
+		if !ok {
+			ep = &endpoint{name: name}
+			endpoints[name] = ep
+		}
+		example := res.URL
+		if fullPath, err := st.getFilePath(res.URL, res.ContentMimeType()); err == nil {
+			if rel, err := filepath.Rel(st.baseDir, fullPath); err == nil {
+				example = filepath.ToSlash(rel)
+			}
+		}
+		if summary.Invalid {
+			ep.invalid = append(ep.invalid, fmt.Sprintf("%s (%s)", example, summary.Error))
+			continue
+		}
+		if ep.summary == nil {
+			ep.summary = summary
+		}
+		ep.examples = append(ep.examples, example)
+	}
+	if len(endpoints) == 0 {
+		return
+	}
+
+	names := sortedKeys(endpoints)
+	report.WriteString(fmt.Sprintf("\nJSON API Responses (%d endpoints):\n", len(names)))
+	for _, name := range names {
+		ep := endpoints[name]
+		report.WriteString(fmt.Sprintf("  %s\n", name))
+		if ep.summary != nil {
+			report.WriteString(fmt.Sprintf("    Schema: %s\n", describeJSON(ep.summary)))
+		}
+		sort.Strings(ep.examples)
+		for _, example := range ep.examples {
+			report.WriteString(fmt.Sprintf("    Example: %s\n", example))
+		}
+		sort.Strings(ep.invalid)
+		for _, invalid := range ep.invalid {
+			report.WriteString(fmt.Sprintf("    Invalid JSON: %s\n", invalid))
+		}
+	}
+}
+
+// jsonEndpoint 接口名：方法 + 去掉查询串和 fragment 的 URL
+func jsonEndpoint(res *crawler.Resource) string {
+	method := res.Method
+	if method == "" {
+		method = "GET"
+	}
+	endpoint := res.URL
+	if u, err := url.Parse(res.URL); err == nil {
+		u.RawQuery, u.Fragment = "", ""
+		endpoint = u.String()
+	}
+	return method + " " + endpoint
+}
+
+// describeJSON 将结构摘要写成一行，如 object {data, total} 或 array[20] of {id, name}
+func describeJSON(s *JSONSummary) string {
+	switch {
+	case s.Type == "object":
+		return fmt.Sprintf("object {%s}", strings.Join(s.Keys, ", "))
+	case s.Type == "array" && len(s.ElementKeys) > 0:
+		return fmt.Sprintf("array[%d] of {%s}", *s.ArrayLength, strings.Join(s.ElementKeys, ", "))
+	case s.Type == "array":
+		return fmt.Sprintf("array[%d]", *s.ArrayLength)
+	}
+	return s.Type
+}
+
+// jsonType 解析结果的 JSON 类型名
+func jsonType(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
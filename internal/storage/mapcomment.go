@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"regexp"
+	"strings"
+
+	"spider/internal/crawler"
+)
+
+// reTrailingMapComment 文件末尾的 //# sourceMappingURL=... 或 /*# sourceMappingURL=... */（含旧式 @ 写法），
+// 之后只允许空白
+var reTrailingMapComment = regexp.MustCompile(`(?://[#@][ \t]*sourceMappingURL=[^\r\n]*|/\*[#@][ \t]*sourceMappingURL=[^*]*\*/)[ \t\r\n]*\z`)
+
+// SetStripSourceMappingURL 设置保存 JS / CSS 时是否去掉末尾的 sourceMappingURL 注释，
+// 不暴露 .map 文件地址。只影响写入的文件，内存中的内容不变，source map 提取不受影响
+func (st *Storage) SetStripSourceMappingURL(strip bool) {
+	st.stripMapURL = strip
+}
+
+// stripSourceMappingURL 去掉 JS / CSS 内容末尾的 sourceMappingURL 注释，没有时原样返回
+func (st *Storage) stripSourceMappingURL(res *crawler.Resource, content []byte) []byte {
+	if !st.stripMapURL {
+		return content
+	}
+	mime := res.ContentMimeType()
+	if !strings.Contains(mime, "javascript") && !strings.Contains(mime, "css") {
+		return content
+	}
+	loc := reTrailingMapComment.FindIndex(content)
+	if loc == nil {
+		return content
+	}
+	stripped := append([]byte{}, content[:loc[0]]...)
+	if len(stripped) > 0 && stripped[len(stripped)-1] == '\n' {
+		return stripped
+	}
+	return append(stripped, trailingNewline(content)...)
+}
+
+// trailingNewline 内容末尾的换行符（\n 或 \r\n），用于去掉注释后保留文件原有的结尾
+func trailingNewline(content []byte) []byte {
+	switch {
+	case len(content) >= 2 && string(content[len(content)-2:]) == "\r\n":
+		return content[len(content)-2:]
+	case len(content) >= 1 && content[len(content)-1] == '\n':
+		return content[len(content)-1:]
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetNavigation 设置报告开头显示的 输入 URL → 最终 URL：finalURL 为加载完成后的页面地址，
+// navigations 为主框架依次提交的文档，navigatedAway 非空表示在该跨源跳转处停止了抓取
+func (st *Storage) SetNavigation(inputURL, finalURL string, navigations []string, navigatedAway string) {
+	st.inputURL = inputURL
+	st.finalURL = finalURL
+	st.navigations = navigations
+	st.navigatedAway = navigatedAway
+}
+
+// writeNavigationSection 最终地址与输入不同（重定向、页面自己跳转）时列出导航链
+func (st *Storage) writeNavigationSection(report *strings.Builder) {
+	final, note := st.finalURL, ""
+	if st.navigatedAway != "" {
+		final, note = st.navigatedAway, " (cross-origin navigation not followed, capture stopped)"
+	}
+	if st.inputURL == "" || final == "" || sameURL(st.inputURL, final) {
+		return
+	}
+
+	report.WriteString(fmt.Sprintf("URL: %s → %s%s\n", st.inputURL, final, note))
+	if len(st.navigations) > 1 {
+		report.WriteString("Navigations:\n")
+		for i, u := range st.navigations {
+			report.WriteString(fmt.Sprintf("  %d. %s\n", i+1, u))
+		}
+	}
+	report.WriteString("\n")
+}
+
+// sameURL 忽略末尾 / 比较两个 URL（浏览器会把 https://example.com 规范为 https://example.com/）
+func sameURL(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
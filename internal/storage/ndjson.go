@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+
+	"spider/internal/crawler"
+)
+
+// NDJSONRecord resources.ndjson 中的一行
+type NDJSONRecord struct {
+	URL        string                   `json:"url"`
+	Status     int                      `json:"status"`
+	MimeType   string                   `json:"mime_type"`
+	Size       int                      `json:"size"`
+	ContentB64 string                   `json:"content_b64"`
+	Headers    map[string]string        `json:"headers,omitempty"` // 按 ForwardHTTPHeaders 过滤
+	Labels     map[string]string        `json:"labels,omitempty"`
+	Timing     *crawler.TimingBreakdown `json:"timing,omitempty"`
+}
+
+// ExportNDJSON 以换行分隔的 JSON（每行一个资源，按响应时间排序）写入 dest，
+// 适合 jq、批量索引等流式消费。逐条编码直接写入 dest，不在内存中拼接完整输出；
+// body 以 base64 编码，不截断；响应头只保留 forwardHeaders 中列出的（为空时全部保留）
+func ExportNDJSON(resources map[string]*crawler.Resource, forwardHeaders []string, dest io.Writer) error {
+	enc := json.NewEncoder(dest)
+	enc.SetEscapeHTML(false)
+	for _, res := range sortedResources(resources) {
+		record := NDJSONRecord{
+			URL:        res.URL,
+			Status:     res.StatusCode,
+			MimeType:   res.MimeType,
+			Size:       len(res.Content),
+			ContentB64: base64.StdEncoding.EncodeToString(res.Content),
+			Headers:    filterHeaders(res.Headers, forwardHeaders),
+			Labels:     res.Labels,
+			Timing:     res.TimingBreakdown,
+		}
+		// Encode 每条末尾追加换行
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
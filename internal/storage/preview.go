@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"spider/internal/crawler"
+)
+
+// MaxReportPreviewTotal 报告中所有响应体预览的合计字节数上限，超出后其余资源不再附预览
+const MaxReportPreviewTotal = 1024 * 1024
+
+// SetReportPreview 设置报告的资源详情中附带的响应体预览长度（字节），0 表示不附带。
+// 只预览 JS / CSS / JSON / HTML 等文本资源，二进制资源标为 [binary]
+func (st *Storage) SetReportPreview(n int) {
+	st.previewBytes = n
+}
+
+// writePreview 输出一个资源的响应体预览：取前 previewBytes 字节（不截断多字节字符），
+// 以 Go 引号字符串形式写在一行，换行和不可打印字符均转义。used 为已输出的预览字节数
+func (st *Storage) writePreview(report *strings.Builder, res *crawler.Resource, used *int) {
+	if len(res.Content) == 0 {
+		return
+	}
+	if !isTextMime(reportMimeType(res)) {
+		report.WriteString("  Preview: [binary]\n")
+		return
+	}
+	if *used >= MaxReportPreviewTotal {
+		report.WriteString("  Preview: [omitted: report preview limit reached]\n")
+		return
+	}
+
+	n := min(len(res.Content), st.previewBytes, MaxReportPreviewTotal-*used)
+	preview := res.Content[:n]
+	// 回退到完整字符的边界，最多 3 字节
+	for i := 0; i < utf8.UTFMax-1 && len(preview) > 0 && !utf8.Valid(preview); i++ {
+		preview = preview[:len(preview)-1]
+	}
+	if isBinary(preview) {
+		report.WriteString("  Preview: [binary]\n")
+		return
+	}
+	*used += len(preview)
+
+	line := fmt.Sprintf("  Preview: %q", preview)
+	if rest := len(res.Content) - len(preview); rest > 0 {
+		line += fmt.Sprintf(" (+%d bytes)", rest)
+	}
+	report.WriteString(line + "\n")
+}
+
+// isTextMime 是否为可预览的文本类型
+func isTextMime(mimeType string) bool {
+	if strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	for _, kind := range []string{"javascript", "ecmascript", "json", "xml", "html", "css"} {
+		if strings.Contains(mimeType, kind) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"spider/internal/crawler"
+)
+
+// SetPrivacyReport 设置要写入 report.txt 的第三方 Cookie / 存储分析（CapturePrivacy），nil 表示不输出该节
+func (st *Storage) SetPrivacyReport(report *crawler.PrivacyReport) {
+	st.privacy = report
+}
+
+// WritePrivacyReport 写入 privacy.json：全部 Cookie 的属性（不含值）和跨站 iframe 写入的 localStorage 键
+func (st *Storage) WritePrivacyReport(report *crawler.PrivacyReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal privacy report: %v", err)
+	}
+	if err := os.MkdirAll(st.baseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create base directory: %v", err)
+	}
+	return WriteFileAtomic(filepath.Join(st.baseDir, "privacy.json"), data, 0644)
+}
+
+// writePrivacySection 在报告中列出第三方 Cookie 和跨站 iframe 的 localStorage 键
+func (st *Storage) writePrivacySection(report *strings.Builder) {
+	p := st.privacy
+	var thirdParty []crawler.CookieInfo
+	for _, c := range p.Cookies {
+		if c.ThirdParty {
+			thirdParty = append(thirdParty, c)
+		}
+	}
+
+	report.WriteString(fmt.Sprintf("\nThird-Party Cookies (site: %s, %d of %d cookies):\n", p.Site, len(thirdParty), len(p.Cookies)))
+	if len(thirdParty) == 0 {
+		report.WriteString("  (none)\n")
+	}
+	for _, c := range thirdParty {
+		var attrs []string
+		if c.Secure {
+			attrs = append(attrs, "Secure")
+		}
+		if c.HTTPOnly {
+			attrs = append(attrs, "HttpOnly")
+		}
+		if c.SameSite != "" {
+			attrs = append(attrs, "SameSite="+c.SameSite)
+		}
+		if c.Session {
+			attrs = append(attrs, "session")
+		} else {
+			attrs = append(attrs, fmt.Sprintf("expires in %.1f days", c.ExpiresIn))
+		}
+		report.WriteString(fmt.Sprintf("  %s%s %s (%s)\n", c.Domain, c.Path, c.Name, strings.Join(attrs, ", ")))
+	}
+
+	if len(p.ThirdPartyStorage) > 0 {
+		report.WriteString("\nThird-Party localStorage (cross-site frames):\n")
+		for _, fs := range p.ThirdPartyStorage {
+			report.WriteString(fmt.Sprintf("  %s: %s\n", fs.Origin, strings.Join(fs.Keys, ", ")))
+		}
+	}
+}
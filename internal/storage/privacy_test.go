@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"spider/internal/crawler"
+)
+
+func testPrivacyReport() *crawler.PrivacyReport {
+	return &crawler.PrivacyReport{
+		Site: "example.com",
+		Cookies: []crawler.CookieInfo{
+			{Name: "track", Domain: ".ads.example.net", Path: "/", ThirdParty: true, Secure: true, SameSite: "None", ExpiresIn: 30},
+			{Name: "sid", Domain: "example.com", Path: "/", HTTPOnly: true, SameSite: "Lax", Session: true},
+			{Name: "beacon", Domain: "cdn.tracker.io", Path: "/b", ThirdParty: true, Session: true},
+		},
+		ThirdPartyStorage: []crawler.FrameStorage{
+			{Origin: "https://ads.example.net", FrameURL: "https://ads.example.net/frame.html", Keys: []string{"ad_id", "seen"}},
+		},
+	}
+}
+
+// privacy.json 包含全部 Cookie 的属性和跨站 iframe 的 localStorage 键，不含 Cookie 值
+func TestWritePrivacyReport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	if err := NewFlat(dir).WritePrivacyReport(testPrivacyReport()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "privacy.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got crawler.PrivacyReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("privacy.json 不是有效的 JSON: %v", err)
+	}
+	if got.Site != "example.com" || len(got.Cookies) != 3 || len(got.ThirdPartyStorage) != 1 {
+		t.Errorf("读回的报告为 %+v", got)
+	}
+	for _, key := range []string{`"third_party": true`, `"same_site": "None"`, `"expires_in_days": 30`, `"http_only": true`, `"third_party_storage"`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("privacy.json 缺少 %s", key)
+		}
+	}
+	if strings.Contains(string(data), `"value"`) {
+		t.Error("privacy.json 不应包含 Cookie 值")
+	}
+}
+
+// 报告只列出第三方 Cookie（带属性和有效期）和跨站 iframe 的存储键；未设置时不输出该节
+func TestReportPrivacySection(t *testing.T) {
+	resources := map[string]*crawler.Resource{
+		"https://example.com/": {URL: "https://example.com/", StatusCode: 200, MimeType: "text/html", Content: []byte("<html></html>"), Headers: map[string]string{}},
+	}
+	generate := func(privacy *crawler.PrivacyReport) string {
+		dir := t.TempDir()
+		store := NewFlat(dir)
+		store.SetPrivacyReport(privacy)
+		if err := store.GenerateReport(resources); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "report.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	report := generate(testPrivacyReport())
+	for _, line := range []string{
+		"Third-Party Cookies (site: example.com, 2 of 3 cookies):",
+		"  .ads.example.net/ track (Secure, SameSite=None, expires in 30.0 days)",
+		"  cdn.tracker.io/b beacon (session)",
+		"Third-Party localStorage (cross-site frames):",
+		"  https://ads.example.net: ad_id, seen",
+	} {
+		if !strings.Contains(report, line+"\n") {
+			t.Errorf("报告缺少 %q", line)
+		}
+	}
+	if strings.Contains(report, " sid ") {
+		t.Error("第一方 Cookie 不应出现在第三方列表中")
+	}
+
+	firstParty := &crawler.PrivacyReport{Site: "example.com", Cookies: []crawler.CookieInfo{{Name: "sid", Domain: "example.com", Path: "/", Session: true}}}
+	if report := generate(firstParty); !strings.Contains(report, "1 cookies):\n  (none)\n") || strings.Contains(report, "localStorage") {
+		t.Errorf("只有第一方 Cookie 时应输出 (none):\n%s", report)
+	}
+	if report := generate(nil); strings.Contains(report, "Third-Party Cookies") {
+		t.Error("未开启 -privacy-report 时不应输出该节")
+	}
+}
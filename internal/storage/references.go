@@ -0,0 +1,303 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"spider/internal/crawler"
+)
+
+// referenceKinds 未加载引用的分类，报告按此顺序分组输出
+var referenceKinds = []string{"script", "stylesheet", "image", "font", "media", "frame", "other"}
+
+// UnloadedReference 页面 DOM 或已抓取的 CSS 中引用、但爬取期间没有加载的资源
+// （功能开关后的代码、失效链接、未触发的懒加载路径等）
+type UnloadedReference struct {
+	URL     string // 按所在文档解析后的绝对 URL，不含 #fragment
+	Kind    string // script / stylesheet / image / font / media / frame / other
+	Source  string // 引用所在的页面或 CSS 的 URL
+	Fetched bool   // 之后已直接下载补齐（-fetch-unreferenced）
+}
+
+var (
+	reCSSComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	reCSSURL     = regexp.MustCompile(`(?i)url\(\s*(?:"([^"]*)"|'([^']*)'|([^)'"\s]*))\s*\)`)
+	reCSSImport  = regexp.MustCompile(`(?i)@import\s+(?:"([^"]*)"|'([^']*)')`)
+)
+
+// UnloadedReferences 从页面 HTML（优先用渲染后的 DOM）的 src / href / srcset / poster / style 属性和 <style>，
+// 以及已抓取的 CSS 中的 url() / @import 收集引用，返回资源表中没有的 http(s) 地址。
+// 页面中的相对地址按 baseURL（页面的 document.baseURI，为空时取 pageURL；文档中的 <base href> 仍然生效）解析，
+// CSS 中的按该 CSS 的地址解析（内联样式按所在页面的基准，见 crawler.ReferenceBase）。
+// 同一地址只列一次，按 Kind、URL 排序
+func UnloadedReferences(document []byte, pageURL, baseURL string, resources map[string]*crawler.Resource) []UnloadedReference {
+	loaded := make(map[string]bool, len(resources))
+	for _, res := range resources {
+		loaded[stripFragment(res.URL)] = true
+	}
+
+	var refs []UnloadedReference
+	seen := make(map[string]bool)
+	add := func(raw, kind string, base *url.URL, source string) {
+		u := resolveReference(base, raw)
+		if u == "" || loaded[u] || seen[u] {
+			return
+		}
+		seen[u] = true
+		if kind == "" {
+			kind = kindByExtension(u)
+		}
+		refs = append(refs, UnloadedReference{URL: u, Kind: kind, Source: source})
+	}
+
+	if baseURL == "" {
+		baseURL = pageURL
+	}
+	if base, err := url.Parse(baseURL); err == nil && len(document) > 0 {
+		htmlReferences(document, base, func(raw, kind string, base *url.URL) {
+			add(raw, kind, base, pageURL)
+		})
+	}
+
+	for _, res := range sortedResources(resources) {
+		if !strings.Contains(reportMimeType(res), "css") || len(res.Content) == 0 {
+			continue
+		}
+		base, err := url.Parse(crawler.ReferenceBase(res))
+		if err != nil {
+			continue
+		}
+		cssReferences(string(res.Content), func(raw, kind string) {
+			add(raw, kind, base, res.URL)
+		})
+	}
+
+	slices.SortFunc(refs, func(a, b UnloadedReference) int {
+		if d := slices.Index(referenceKinds, a.Kind) - slices.Index(referenceKinds, b.Kind); d != 0 {
+			return d
+		}
+		return strings.Compare(a.URL, b.URL)
+	})
+	return refs
+}
+
+// htmlReferences 逐个标签报告资源引用；遇到 <base href> 后其后的相对地址按它解析
+func htmlReferences(document []byte, base *url.URL, emit func(raw, kind string, base *url.URL)) {
+	z := html.NewTokenizer(bytes.NewReader(document))
+	inStyle := false
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return
+		case html.TextToken:
+			if inStyle {
+				cssReferences(string(z.Text()), func(raw, kind string) { emit(raw, kind, base) })
+			}
+			continue
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "style" {
+				inStyle = false
+			}
+			continue
+		case html.StartTagToken, html.SelfClosingTagToken:
+		default:
+			continue
+		}
+
+		name, hasAttr := z.TagName()
+		tag := string(name)
+		if tag == "style" && tt == html.StartTagToken {
+			inStyle = true
+		}
+		if !hasAttr {
+			continue
+		}
+		attrs := make(map[string]string)
+		for {
+			key, val, more := z.TagAttr()
+			attrs[string(key)] = string(val)
+			if !more {
+				break
+			}
+		}
+
+		if style := attrs["style"]; style != "" {
+			cssReferences(style, func(raw, kind string) { emit(raw, kind, base) })
+		}
+		switch tag {
+		case "base":
+			if href, ok := attrs["href"]; ok {
+				if u, err := base.Parse(strings.TrimSpace(href)); err == nil {
+					base = u
+				}
+			}
+		case "script":
+			emitAttr(attrs, "src", "script", base, emit)
+		case "link":
+			if kind := linkKind(attrs["rel"], attrs["as"]); kind != "" {
+				emitAttr(attrs, "href", kind, base, emit)
+			}
+		case "img":
+			emitAttr(attrs, "src", "image", base, emit)
+			emitSrcset(attrs["srcset"], "image", base, emit)
+		case "input":
+			if strings.EqualFold(attrs["type"], "image") {
+				emitAttr(attrs, "src", "image", base, emit)
+			}
+		case "source":
+			// <picture> 中的 <source> 用 srcset，<video> / <audio> 中的用 src
+			emitAttr(attrs, "src", "media", base, emit)
+			emitSrcset(attrs["srcset"], "image", base, emit)
+		case "video":
+			emitAttr(attrs, "src", "media", base, emit)
+			emitAttr(attrs, "poster", "image", base, emit)
+		case "audio", "track":
+			emitAttr(attrs, "src", "media", base, emit)
+		case "iframe", "frame", "embed":
+			emitAttr(attrs, "src", "frame", base, emit)
+		case "object":
+			emitAttr(attrs, "data", "frame", base, emit)
+		}
+	}
+}
+
+func emitAttr(attrs map[string]string, name, kind string, base *url.URL, emit func(raw, kind string, base *url.URL)) {
+	if v, ok := attrs[name]; ok {
+		emit(v, kind, base)
+	}
+}
+
+// emitSrcset srcset 的每个候选为 "URL 描述符"，以逗号分隔
+func emitSrcset(srcset, kind string, base *url.URL, emit func(raw, kind string, base *url.URL)) {
+	for candidate := range strings.SplitSeq(srcset, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 {
+			emit(fields[0], kind, base)
+		}
+	}
+}
+
+// linkKind <link> 引用的资源类型；canonical、alternate、preconnect 等不是要加载的资源，返回空
+func linkKind(rel, as string) string {
+	for r := range strings.FieldsSeq(strings.ToLower(rel)) {
+		switch r {
+		case "stylesheet":
+			return "stylesheet"
+		case "icon", "apple-touch-icon", "apple-touch-icon-precomposed", "mask-icon":
+			return "image"
+		case "manifest":
+			return "other"
+		case "modulepreload":
+			return "script"
+		case "preload", "prefetch":
+			switch strings.ToLower(as) {
+			case "script", "worker":
+				return "script"
+			case "style":
+				return "stylesheet"
+			case "image":
+				return "image"
+			case "font":
+				return "font"
+			case "audio", "video", "track":
+				return "media"
+			case "document":
+				return "frame"
+			}
+			return "other"
+		}
+	}
+	return ""
+}
+
+// cssReferences 报告 CSS 中的 url() 和 @import（跳过注释）；url() 的类型按扩展名判断，@import 为 stylesheet
+func cssReferences(css string, emit func(raw, kind string)) {
+	css = reCSSComment.ReplaceAllString(css, "")
+	for _, m := range reCSSImport.FindAllStringSubmatch(css, -1) {
+		emit(m[1]+m[2], "stylesheet")
+	}
+	for _, m := range reCSSURL.FindAllStringSubmatch(css, -1) {
+		emit(m[1]+m[2]+m[3], "")
+	}
+}
+
+// kindByExtension 按路径扩展名归类 CSS url() 引用的资源
+func kindByExtension(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "other"
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".woff", ".woff2", ".ttf", ".otf", ".eot":
+		return "font"
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".avif", ".svg", ".ico", ".bmp", ".cur":
+		return "image"
+	case ".css":
+		return "stylesheet"
+	case ".mp4", ".webm", ".ogg", ".mp3", ".wav", ".m4a":
+		return "media"
+	}
+	return "other"
+}
+
+// resolveReference 按 base 解析引用，只保留 http(s) 地址并去掉 #fragment；
+// 空地址、纯片段和 data: / blob: / javascript: 等返回空字符串
+func resolveReference(base *url.URL, raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.HasPrefix(raw, "#") {
+		return ""
+	}
+	u, err := base.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// SetUnloadedReferences 设置要写入 report.txt 的未加载引用；调用后报告中总会输出该节（没有时为 none）
+func (st *Storage) SetUnloadedReferences(refs []UnloadedReference) {
+	st.unloadedRefs = refs
+	st.unloadedRefsSet = true
+}
+
+// writeUnloadedSection 按类型分组列出引用了但没有加载的资源及引用位置
+func (st *Storage) writeUnloadedSection(report *strings.Builder) {
+	fetched := 0
+	for _, r := range st.unloadedRefs {
+		if r.Fetched {
+			fetched++
+		}
+	}
+	report.WriteString(fmt.Sprintf("\nReferenced But Not Loaded (%d in DOM / CSS, %d fetched directly):\n", len(st.unloadedRefs), fetched))
+	if len(st.unloadedRefs) == 0 {
+		report.WriteString("  (none)\n")
+		return
+	}
+	for _, kind := range referenceKinds {
+		var lines []string
+		for _, r := range st.unloadedRefs {
+			if r.Kind != kind {
+				continue
+			}
+			line := fmt.Sprintf("    %s ← %s", r.URL, r.Source)
+			if r.Fetched {
+				line += " (fetched)"
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		report.WriteString(fmt.Sprintf("  %s (%d):\n", kind, len(lines)))
+		report.WriteString(strings.Join(lines, "\n") + "\n")
+	}
+}
@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"spider/internal/crawler"
+)
+
+const referencesPage = "https://example.com/shop/products/item.html"
+
+// referencesResources 与 testdata/references.html 对照的抓取结果：页面、main.css（其中引用未加载的字体和 @import）、
+// app.js、sprite.png，另一个源上的 CSS，以及以页面为基准的内联样式
+func referencesResources(t *testing.T) map[string]*crawler.Resource {
+	t.Helper()
+	doc, err := os.ReadFile(filepath.Join("testdata", "references.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources := make(map[string]*crawler.Resource)
+	add := func(u, mime, content string) *crawler.Resource {
+		res := &crawler.Resource{URL: u, StatusCode: 200, MimeType: mime, Content: []byte(content), Headers: map[string]string{}}
+		resources[u] = res
+		return res
+	}
+	add(referencesPage, "text/html", string(doc))
+	add("https://example.com/shop/css/main.css", "text/css", `@import url("reset.css");
+@font-face { src: url(../fonts/icons.woff2) format("woff2"); }
+.sprite { background: url("/img/sprite.png"); }
+.dot { background: url(data:image/gif;base64,R0lGOD==); }
+/* url(old.png) */`)
+	add("https://example.com/shop/products/app.js", "application/javascript", "run()")
+	add("https://example.com/img/sprite.png", "image/png", "png")
+	add("https://cdn.example.net/assets/css/site.css", "text/css", `.logo { background: url(../img/logo.svg) }`)
+	inline := add(crawler.InlineScheme+"://example.com/inline_style_1.css", "text/css", `.x { background: url(local.gif) }`)
+	inline.Pages = []string{referencesPage}
+	return resources
+}
+
+// 相对地址按所在文档解析：页面中的按页面（<base href> 之后按它），CSS 中的按该 CSS 的地址，内联样式按所在页面；
+// 已加载的（忽略 #fragment）、data: / javascript: / 纯片段 / 空地址、非资源的 <a> 和 rel=canonical 等不列出，
+// 同一地址只列一次，按类型、URL 排序
+func TestUnloadedReferences(t *testing.T) {
+	resources := referencesResources(t)
+	refs := UnloadedReferences(resources[referencesPage].Content, referencesPage, "", resources)
+
+	const (
+		page    = referencesPage
+		mainCSS = "https://example.com/shop/css/main.css"
+		siteCSS = "https://cdn.example.net/assets/css/site.css"
+		inline  = crawler.InlineScheme + "://example.com/inline_style_1.css"
+	)
+	want := []UnloadedReference{
+		{URL: "https://cdn.example.net/lib.js?v=2", Kind: "script", Source: page},
+		{URL: "https://example.com/css/print.css", Kind: "stylesheet", Source: page},
+		{URL: "https://example.com/shop/css/reset.css", Kind: "stylesheet", Source: mainCSS},
+		{URL: "https://example.com/shop/products/theme.css", Kind: "stylesheet", Source: page},
+		{URL: "https://cdn.example.net/assets/img/logo.svg", Kind: "image", Source: siteCSS},
+		{URL: "https://example.com/img/bg.webp", Kind: "image", Source: page},
+		{URL: "https://example.com/img/thumb@3x.png", Kind: "image", Source: page},
+		{URL: "https://example.com/shop/products/favicon.ico", Kind: "image", Source: page},
+		{URL: "https://example.com/shop/products/img/hero.jpg", Kind: "image", Source: page},
+		{URL: "https://example.com/shop/products/intro.jpg", Kind: "image", Source: page},
+		{URL: "https://example.com/shop/products/local.gif", Kind: "image", Source: inline},
+		{URL: "https://example.com/shop/products/photo.avif", Kind: "image", Source: page},
+		{URL: "https://example.com/shop/products/photo.jpg", Kind: "image", Source: page},
+		{URL: "https://example.com/shop/products/submit.png", Kind: "image", Source: page},
+		{URL: "https://example.com/shop/products/thumb.png", Kind: "image", Source: page},
+		{URL: "https://example.com/shop/products/thumb@2x.png", Kind: "image", Source: page},
+		{URL: "https://static.example.com/v2/late.png", Kind: "image", Source: page},
+		{URL: "https://example.com/fonts/brand.woff2", Kind: "font", Source: page},
+		{URL: "https://example.com/shop/fonts/icons.woff2", Kind: "font", Source: mainCSS},
+		{URL: "https://example.com/shop/products/intro.mp4", Kind: "media", Source: page},
+		{URL: "https://example.com/shop/products/intro.vtt", Kind: "media", Source: page},
+		{URL: "https://example.com/shop/products/movie.swf", Kind: "frame", Source: page},
+		{URL: "https://widgets.example.org/embed", Kind: "frame", Source: page},
+		{URL: "https://example.com/site.webmanifest", Kind: "other", Source: page},
+	}
+	if len(refs) != len(want) {
+		var got []string
+		for _, r := range refs {
+			got = append(got, r.Kind+" "+r.URL)
+		}
+		t.Fatalf("找到 %d 个未加载的引用，应为 %d:\n%s", len(refs), len(want), strings.Join(got, "\n"))
+	}
+	for i, r := range refs {
+		if r != want[i] {
+			t.Errorf("refs[%d] = %+v，应为 %+v", i, r, want[i])
+		}
+	}
+}
+
+// 页面的 document.baseURI（baseURL）优先于页面 URL；CSS 设置了 BaseURL 时按它解析
+func TestUnloadedReferencesBase(t *testing.T) {
+	doc := []byte(`<img src="a.png"><img src="../b.png">`)
+	css := &crawler.Resource{URL: "https://example.com/bundle.css", BaseURL: "https://cdn.example.net/theme/", StatusCode: 200,
+		MimeType: "text/css", Content: []byte(`.c { background: url(c.png) }`), Headers: map[string]string{}}
+	resources := map[string]*crawler.Resource{css.URL: css}
+
+	var got []string
+	for _, r := range UnloadedReferences(doc, "https://example.com/app/page", "https://example.com/static/v1/", resources) {
+		got = append(got, r.URL)
+	}
+	want := []string{"https://cdn.example.net/theme/c.png", "https://example.com/static/b.png", "https://example.com/static/v1/a.png"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("解析结果为 %v，应为 %v", got, want)
+	}
+
+	// 没有文档时只检查 CSS
+	if refs := UnloadedReferences(nil, "https://example.com/", "", resources); len(refs) != 1 {
+		t.Errorf("没有文档时找到 %+v", refs)
+	}
+}
+
+func TestResolveReference(t *testing.T) {
+	base, err := url.Parse("https://example.com/a/b/page.html?q=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for raw, want := range map[string]string{
+		"img.png":                    "https://example.com/a/b/img.png",
+		"../../../../up.png":         "https://example.com/up.png",
+		"  spaced.png\n":             "https://example.com/a/b/spaced.png",
+		"?page=2":                    "https://example.com/a/b/page.html?page=2",
+		"//cdn.example.net/x":        "https://cdn.example.net/x",
+		"HTTP://example.com/y#z":     "http://example.com/y",
+		"#top":                       "",
+		"":                           "",
+		"data:text/plain,hi":         "",
+		"blob:https://example.com/1": "",
+		"javascript:alert(1)":        "",
+		"mailto:a@example.com":       "",
+		"http:///nohost":             "",
+	} {
+		if got := resolveReference(base, raw); got != want {
+			t.Errorf("resolveReference(%q) = %q，应为 %q", raw, got, want)
+		}
+	}
+}
+
+// 报告按类型分组列出引用位置，直接下载过的标注 (fetched)；开启后没有引用也输出 (none)
+func TestReportUnloadedReferences(t *testing.T) {
+	resources := map[string]*crawler.Resource{
+		"https://example.com/": {URL: "https://example.com/", StatusCode: 200, MimeType: "text/html", Content: []byte("<html></html>"), Headers: map[string]string{}},
+	}
+	generate := func(set bool, refs []UnloadedReference) string {
+		dir := t.TempDir()
+		store := NewFlat(dir)
+		if set {
+			store.SetUnloadedReferences(refs)
+		}
+		if err := store.GenerateReport(resources); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "report.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	report := generate(true, []UnloadedReference{
+		{URL: "https://example.com/beta.js", Kind: "script", Source: "https://example.com/", Fetched: true},
+		{URL: "https://example.com/a.png", Kind: "image", Source: "https://example.com/site.css"},
+		{URL: "https://example.com/b.png", Kind: "image", Source: "https://example.com/"},
+	})
+	want := "\nReferenced But Not Loaded (3 in DOM / CSS, 1 fetched directly):\n" +
+		"  script (1):\n" +
+		"    https://example.com/beta.js ← https://example.com/ (fetched)\n" +
+		"  image (2):\n" +
+		"    https://example.com/a.png ← https://example.com/site.css\n" +
+		"    https://example.com/b.png ← https://example.com/\n"
+	if !strings.Contains(report, want) {
+		t.Errorf("报告中的未加载引用不正确:\n%s", report)
+	}
+	if report := generate(true, nil); !strings.Contains(report, "(0 in DOM / CSS, 0 fetched directly):\n  (none)\n") {
+		t.Errorf("没有未加载的引用时应输出 (none):\n%s", report)
+	}
+	if report := generate(false, nil); strings.Contains(report, "Referenced But Not Loaded") {
+		t.Error("未开启 -unloaded-refs 时不应输出该节")
+	}
+}
+
+// testdata/base.html 的 <base href> 指向另一个源：有它时相对地址按 CDN 解析，去掉后按页面解析；
+// 渲染时读到的 document.baseURI 与文档中的 <base href> 一致时结果相同
+func TestDocumentBaseResolution(t *testing.T) {
+	withBase, err := os.ReadFile(filepath.Join("testdata", "base.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutBase := []byte(strings.Replace(string(withBase), `<base href="https://cdn.example.com/app/">`, "", 1))
+	const page = "https://shop.example.com/products/item.html"
+
+	refURLs := func(document []byte, baseURL string) []string {
+		var urls []string
+		for _, r := range UnloadedReferences(document, page, baseURL, nil) {
+			urls = append(urls, r.URL)
+		}
+		return urls
+	}
+	hintURLs := func(document []byte, baseURL string) []string {
+		var urls []string
+		for _, h := range ResourceHints(document, baseURL, nil) {
+			urls = append(urls, h.Href)
+		}
+		return urls
+	}
+
+	for _, tc := range []struct {
+		name     string
+		document []byte
+		baseURL  string
+		refs     []string
+		hints    []string
+	}{
+		{"base", withBase, "", []string{
+			"https://cdn.example.com/app/main.js",
+			"https://cdn.example.com/theme.css",
+			"https://cdn.example.com/app/logo.png",
+			"https://cdn.example.com/favicon.png",
+		}, []string{"https://cdn.example.com/app/main.js"}},
+		{"base+baseURI", withBase, "https://cdn.example.com/app/", []string{
+			"https://cdn.example.com/app/main.js",
+			"https://cdn.example.com/theme.css",
+			"https://cdn.example.com/app/logo.png",
+			"https://cdn.example.com/favicon.png",
+		}, []string{"https://cdn.example.com/app/main.js"}},
+		{"no base", withoutBase, "", []string{
+			"https://shop.example.com/products/main.js",
+			"https://shop.example.com/theme.css",
+			"https://shop.example.com/favicon.png",
+			"https://shop.example.com/products/logo.png",
+		}, []string{"https://shop.example.com/products/main.js"}},
+	} {
+		hintsBase := tc.baseURL
+		if hintsBase == "" {
+			hintsBase = page
+		}
+		if got := refURLs(tc.document, tc.baseURL); !slices.Equal(got, tc.refs) {
+			t.Errorf("%s: UnloadedReferences = %v，应为 %v", tc.name, got, tc.refs)
+		}
+		if got := hintURLs(tc.document, hintsBase); !slices.Equal(got, tc.hints) {
+			t.Errorf("%s: ResourceHints = %v，应为 %v", tc.name, got, tc.hints)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"spider/internal/crawler"
+)
+
+// 相同的资源集合两次生成的 report.txt 和 resources.json 逐字节相同（不受 map 遍历顺序影响），
+// 确定性模式据此比对两次爬取的抓取哈希
+func TestReportAndIndexDeterministic(t *testing.T) {
+	mimeTypes := []string{"text/html", "application/javascript", "text/css", "image/png", "application/json"}
+	resources := make(map[string]*crawler.Resource)
+	for i := range 40 {
+		u := fmt.Sprintf("https://example.com/r/%02d", i)
+		resources[u] = &crawler.Resource{
+			URL:            u,
+			StatusCode:     200,
+			MimeType:       mimeTypes[i%len(mimeTypes)],
+			Content:        []byte(u),
+			Headers:        map[string]string{},
+			CollapsedCount: i % 3,
+		}
+	}
+
+	generate := func() (report, index []byte) {
+		dir := t.TempDir()
+		store := NewFlat(dir)
+		if err := store.Save(resources); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.GenerateReport(resources); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.WriteIndex(resources); err != nil {
+			t.Fatal(err)
+		}
+		report, err := os.ReadFile(filepath.Join(dir, "report.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		index, err = os.ReadFile(filepath.Join(dir, "resources.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report, index
+	}
+
+	report, index := generate()
+	for range 5 {
+		r, i := generate()
+		if !bytes.Equal(r, report) {
+			t.Fatal("两次生成的 report.txt 不同")
+		}
+		if !bytes.Equal(i, index) {
+			t.Fatal("两次生成的 resources.json 不同")
+		}
+	}
+}
+
+// 声明类型与嗅探结果不同的资源列在报告的 MIME Type Mismatches 中，并按嗅探到的类型补全扩展名
+func TestReportMimeMismatch(t *testing.T) {
+	dir := t.TempDir()
+	store := NewFlat(dir)
+	resources := map[string]*crawler.Resource{
+		"https://cdn.example.com/vendor/bundle": {
+			URL: "https://cdn.example.com/vendor/bundle", StatusCode: 200, Headers: map[string]string{},
+			MimeType: "text/plain", DetectedMimeType: "application/javascript", Content: []byte("(function(){})();"),
+		},
+		"https://cdn.example.com/api/config": {
+			URL: "https://cdn.example.com/api/config", StatusCode: 200, Headers: map[string]string{},
+			DetectedMimeType: "application/json", Content: []byte(`{"a":1}`),
+		},
+		"https://cdn.example.com/plain.txt": {
+			URL: "https://cdn.example.com/plain.txt", StatusCode: 200, Headers: map[string]string{},
+			MimeType: "text/plain", Content: []byte("hello"),
+		},
+	}
+	if err := store.Save(resources); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.GenerateReport(resources); err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(filepath.Join(dir, "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"MIME Type Mismatches (declared → detected):\n" +
+			"  https://cdn.example.com/api/config: (none) → application/json\n" +
+			"  https://cdn.example.com/vendor/bundle: text/plain → application/javascript\n",
+		"  Type: text/plain (detected: application/javascript)\n",
+	} {
+		if !bytes.Contains(report, []byte(want)) {
+			t.Errorf("report.txt 中缺少:\n%s", want)
+		}
+	}
+	if bytes.Contains(report, []byte("plain.txt: text/plain →")) {
+		t.Error("类型相符的资源不应列为不匹配")
+	}
+
+	for _, res := range resources {
+		e := store.indexEntry(res)
+		want := map[string]string{
+			"https://cdn.example.com/vendor/bundle": ".js",
+			"https://cdn.example.com/api/config":    ".json",
+			"https://cdn.example.com/plain.txt":     ".txt",
+		}[e.URL]
+		if !strings.HasSuffix(e.Path, want) {
+			t.Errorf("%s 保存为 %s，应按嗅探到的类型补全扩展名 %s", e.URL, e.Path, want)
+		}
+	}
+}
+
+// 标记写入 resources.json 的 marker，报告按标记统计（初始加载不计入）；没有标记时不输出该节
+func TestReportMarkers(t *testing.T) {
+	resources := make(map[string]*crawler.Resource)
+	for i, marker := range []string{"", "", "mark1", "mark1", "mark2"} {
+		u := fmt.Sprintf("https://example.com/r/%d", i)
+		resources[u] = &crawler.Resource{URL: u, StatusCode: 200, MimeType: "application/json", Content: []byte("{}"), Headers: map[string]string{}, Marker: marker}
+	}
+
+	dir := t.TempDir()
+	store := NewFlat(dir)
+	if err := store.GenerateReport(resources); err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(filepath.Join(dir, "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(report), "\nResources by Marker:\n  mark1: 2\n  mark2: 1\n") {
+		t.Errorf("报告中的标记统计不正确:\n%s", report)
+	}
+	if e := store.indexEntry(resources["https://example.com/r/4"]); e.Marker != "mark2" {
+		t.Errorf("索引记录的 marker 为 %q，应为 mark2", e.Marker)
+	}
+
+	delete(resources, "https://example.com/r/2")
+	delete(resources, "https://example.com/r/3")
+	delete(resources, "https://example.com/r/4")
+	if err := store.GenerateReport(resources); err != nil {
+		t.Fatal(err)
+	}
+	if report, _ := os.ReadFile(filepath.Join(dir, "report.txt")); bytes.Contains(report, []byte("Resources by Marker")) {
+		t.Error("没有标记时不应输出 Resources by Marker")
+	}
+}
+
+// 跳过的可选产物在报告中逐项列出原因和详情；没有跳过时不输出该节
+func TestReportSkippedArtifacts(t *testing.T) {
+	resources := map[string]*crawler.Resource{
+		"https://example.com/": {URL: "https://example.com/", StatusCode: 200, MimeType: "text/html", Content: []byte("<html></html>"), Headers: map[string]string{}},
+	}
+	dir := t.TempDir()
+	store := NewFlat(dir)
+	store.SetSkippedArtifacts([]crawler.SkippedArtifact{
+		{Artifact: "rendered-html", Reason: crawler.SkipReasonDOMTooLarge, Detail: "52428800 bytes > MaxDOMBytes 20971520"},
+		{Artifact: "links", Reason: crawler.SkipReasonDOMTooLarge, Detail: "52428800 bytes > MaxDOMBytes 20971520"},
+	})
+	if err := store.GenerateReport(resources); err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(filepath.Join(dir, "report.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\nSkipped Artifacts:\n" +
+		"  rendered-html: skipped: dom-too-large (52428800 bytes > MaxDOMBytes 20971520)\n" +
+		"  links: skipped: dom-too-large (52428800 bytes > MaxDOMBytes 20971520)\n"
+	if !strings.Contains(string(report), want) {
+		t.Errorf("报告中缺少跳过项:\n%s", report)
+	}
+
+	store.SetSkippedArtifacts(nil)
+	if err := store.GenerateReport(resources); err != nil {
+		t.Fatal(err)
+	}
+	if report, _ := os.ReadFile(filepath.Join(dir, "report.txt")); bytes.Contains(report, []byte("Skipped Artifacts")) {
+		t.Error("没有跳过项时不应输出 Skipped Artifacts")
+	}
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"spider/internal/crawler"
+)
+
+// disclosureHeaders 暴露服务端软件/版本信息的响应头
+var disclosureHeaders = []string{"Server", "X-Powered-By", "X-AspNet-Version", "X-AspNetMvc-Version", "X-Generator"}
+
+// recommendedHeaders 建议设置的安全响应头及缺失时的说明
+var recommendedHeaders = []struct {
+	name  string
+	note  string
+	https bool // 仅对 HTTPS 页面有意义
+}{
+	{"Strict-Transport-Security", "未启用 HSTS，首次访问可能被降级为 HTTP", true},
+	{"Content-Security-Policy", "未设置 CSP，XSS 缺少纵深防御", false},
+	{"X-Frame-Options", "未限制被嵌入 iframe（也可用 CSP frame-ancestors），存在点击劫持风险", false},
+	{"X-Content-Type-Options", "未设置 nosniff，浏览器可能对响应做 MIME 嗅探", false},
+	{"Referrer-Policy", "未设置 Referrer-Policy，完整 URL 可能通过 Referer 泄露到第三方", false},
+	{"Permissions-Policy", "未设置 Permissions-Policy，未显式禁用摄像头、地理位置等浏览器能力", false},
+}
+
+// WriteSecurityReport 根据主文档的响应头生成 security-headers.txt：
+// 列出暴露版本信息的头、已设置的安全头，以及缺失的推荐头
+func (st *Storage) WriteSecurityReport(doc *crawler.Resource) error {
+	var report strings.Builder
+	report.WriteString("Security Headers Report\n")
+	report.WriteString("=======================\n\n")
+	report.WriteString(fmt.Sprintf("URL: %s\n", doc.URL))
+	report.WriteString(fmt.Sprintf("Status: %d\n\n", doc.StatusCode))
+
+	report.WriteString("Information Disclosure:\n")
+	disclosed := 0
+	for _, name := range disclosureHeaders {
+		if value, ok := lookupHeader(doc.Headers, name); ok {
+			report.WriteString(fmt.Sprintf("  %s: %s\n", name, value))
+			disclosed++
+		}
+	}
+	if disclosed == 0 {
+		report.WriteString("  (none)\n")
+	}
+
+	isHTTPS := strings.HasPrefix(strings.ToLower(doc.URL), "https://")
+	var missing []string
+	report.WriteString("\nSecurity Headers:\n")
+	for _, h := range recommendedHeaders {
+		if h.https && !isHTTPS {
+			continue
+		}
+		value, ok := lookupHeader(doc.Headers, h.name)
+		// CSP frame-ancestors 可以替代 X-Frame-Options
+		if !ok && h.name == "X-Frame-Options" {
+			if csp, hasCSP := lookupHeader(doc.Headers, "Content-Security-Policy"); hasCSP && strings.Contains(strings.ToLower(csp), "frame-ancestors") {
+				value, ok = "(CSP frame-ancestors)", true
+			}
+		}
+		if !ok {
+			report.WriteString(fmt.Sprintf("  %s: MISSING\n", h.name))
+			missing = append(missing, fmt.Sprintf("  - %s: %s\n", h.name, h.note))
+			continue
+		}
+		report.WriteString(fmt.Sprintf("  %s: %s\n", h.name, value))
+	}
+
+	if len(missing) > 0 {
+		report.WriteString(fmt.Sprintf("\nMissing Recommended Headers (%d):\n", len(missing)))
+		for _, line := range missing {
+			report.WriteString(line)
+		}
+	}
+
+	if err := os.MkdirAll(st.baseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create base directory: %v", err)
+	}
+	return WriteFileAtomic(filepath.Join(st.baseDir, "security-headers.txt"), []byte(report.String()), 0644)
+}
+
+// lookupHeader 不区分大小写查找响应头（HTTP/2 的头名为小写），多个值以 "; " 连接
+func lookupHeader(headers map[string]string, name string) (string, bool) {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return strings.ReplaceAll(v, "\n", "; "), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"spider/internal/crawler"
+)
+
+// SetSkipStatusCodes 设置保存时跳过的响应状态码（Config.SkipStatusCodes），如 404 错误页、500 等。
+// 跳过的资源仍写入 resources.json（path 为空）并在报告中列为失败，只是不当作正常内容写入磁盘
+func (st *Storage) SetSkipStatusCodes(codes []int) {
+	st.skipStatus = codes
+}
+
+// skipsStatus 资源的状态码是否在 SetSkipStatusCodes 的列表中
+func (st *Storage) skipsStatus(res *crawler.Resource) bool {
+	return len(st.skipStatus) > 0 && slices.Contains(st.skipStatus, res.StatusCode)
+}
+
+// writeSkippedStatusSection 列出因状态码未保存的资源，sorted 为按 URL 排序的资源
+func (st *Storage) writeSkippedStatusSection(report *strings.Builder, sorted []*crawler.Resource) {
+	var lines []string
+	for _, res := range sorted {
+		if st.skipsStatus(res) {
+			lines = append(lines, fmt.Sprintf("  %d %s", res.StatusCode, res.URL))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	report.WriteString(fmt.Sprintf("\nFailed Responses (not saved, %d):\n", len(lines)))
+	report.WriteString(strings.Join(lines, "\n") + "\n")
+}
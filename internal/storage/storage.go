@@ -1,20 +1,68 @@
 package storage
 
 import (
+	"bytes"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"spider/internal/crawler"
+	"spider/internal/logging"
 )
 
+// slowestResourceCount 报告中列出的最慢资源数
+const slowestResourceCount = 10
+
 // Storage 存储管理器
 type Storage struct {
 	baseDir   string
 	noHostDir bool // 若 true，路径不再追加 hostname 子目录（批量模式已按 host 建目录）
+
+	targetURL string // 本次爬取的目标 URL（Git 导出的提交作者邮箱）
+	gitRemote string // Git 导出后推送的远程仓库地址
+
+	groupQueryVariants bool // 报告中合并仅查询字符串不同的资源
+	keepEmpty          bool // 为响应体为空的资源写入零字节文件
+	previewBytes       int  // 报告中每个文本资源附带的响应体预览长度，0 表示不附带
+
+	forwardHeaders []string // 写入索引的响应头，空表示全部
+
+	skipStatus []int // 保存时跳过的响应状态码（报告中列为失败）
+
+	dataURIThreshold int64 // 抽取解码后超过该字节数的 data URI，0 表示关闭
+	rewriteDataURIs  bool  // 抽取后改写为相对路径而非占位注释
+
+	prettyJSON  bool // 保存时缩进格式化 JSON 响应
+	stripMapURL bool // 保存 JS / CSS 时去掉末尾的 sourceMappingURL 注释
+
+	skipUnchangedSources bool // 跳过与磁盘上同路径文件内容相同的源文件
+	sourcesWritten       int  // 写入的源文件数（仅 skipUnchangedSources 开启时统计）
+	sourcesUnchanged     int  // 内容未变而跳过的源文件数
+
+	privacy  *crawler.PrivacyReport  // 报告中输出的第三方 Cookie / 存储，nil 表示不输出
+	coverage *crawler.CoverageReport // 报告中输出的 JS / CSS 覆盖率，nil 表示不输出
+
+	hints    []ResourceHint            // 页面声明的资源提示及是否加载
+	hintsSet bool                      // 是否输出资源提示一节
+	skipped  []crawler.SkippedArtifact // 因 DOM 过大等原因跳过的可选产物
+	dialogs  []crawler.JSDialog        // 爬取期间自动关闭的 JavaScript 对话框
+
+	unloadedRefs    []UnloadedReference // DOM / CSS 中引用但未加载的资源
+	unloadedRefsSet bool                // 是否输出未加载引用一节
+
+	browserIssues    []crawler.BrowserIssue // 爬取期间浏览器报告的 CSP / CORS / 混合内容等问题
+	browserIssuesSet bool                   // 是否输出浏览器问题一节
+
+	inputURL      string   // 输入的目标 URL（报告开头的 输入 → 最终 URL）
+	finalURL      string   // 加载完成后的页面 URL
+	navigations   []string // 主框架依次提交的文档 URL
+	navigatedAway string   // 因跨源跳转停止抓取时的跳转目标
 }
 
 // New 创建存储管理器（路径格式：baseDir/hostname/path）
@@ -28,6 +76,34 @@ func NewFlat(baseDir string) *Storage {
 	return &Storage{baseDir: baseDir, noHostDir: true}
 }
 
+// SetSkippedArtifacts 设置报告中列出的、因页面异常（DOM 过大等）跳过的可选产物
+func (st *Storage) SetSkippedArtifacts(skipped []crawler.SkippedArtifact) {
+	st.skipped = skipped
+}
+
+// SetDialogs 设置报告中列出的、爬取期间自动关闭的 JavaScript 对话框（DisableJavaScriptDialogs）
+func (st *Storage) SetDialogs(dialogs []crawler.JSDialog) {
+	st.dialogs = dialogs
+}
+
+// SetGroupQueryVariants 设置报告是否把路径相同、仅查询字符串不同的资源合并为一个逻辑资源显示。
+// 只影响 report.txt，resources.json 仍逐个列出每个变体。
+func (st *Storage) SetGroupQueryVariants(group bool) {
+	st.groupQueryVariants = group
+}
+
+// SetSuppressEmptyContent 设置是否跳过响应体为空的资源（默认跳过）。
+// 关闭后，成功取回但内容为空的资源写入零字节文件；未取得响应体的资源始终跳过。
+func (st *Storage) SetSuppressEmptyContent(suppress bool) {
+	st.keepEmpty = !suppress
+}
+
+// SetForwardHeaders 设置 resources.json 中每条记录保留的响应头（不区分大小写），
+// 只保留 Cache-Control、ETag 等下游需要的头可显著缩小索引；空列表表示保留全部响应头
+func (st *Storage) SetForwardHeaders(names []string) {
+	st.forwardHeaders = names
+}
+
 // Save 保存所有资源到文件系统
 func (st *Storage) Save(resources map[string]*crawler.Resource) error {
 	// 创建基础目录
@@ -35,28 +111,56 @@ func (st *Storage) Save(resources map[string]*crawler.Resource) error {
 		return fmt.Errorf("failed to create base directory: %v", err)
 	}
 
+	written, unchanged := st.sourcesWritten, st.sourcesUnchanged
 	for _, resource := range resources {
 		if err := st.saveResource(resource); err != nil {
-			log.Printf("警告: 保存资源失败 %s: %v", resource.URL, err)
+			logging.Warnf("保存资源失败 %s: %v", resource.URL, err)
 			continue
 		}
 	}
+	if st.skipUnchangedSources {
+		log.Printf("源文件: 写入 %d 个，内容未变跳过 %d 个", st.sourcesWritten-written, st.sourcesUnchanged-unchanged)
+	}
 
 	return nil
 }
 
 // saveResource 保存单个资源
 func (st *Storage) saveResource(resource *crawler.Resource) error {
+	if st.skipsStatus(resource) {
+		log.Printf("跳过状态码 %d 的资源 %s", resource.StatusCode, resource.URL)
+		return nil
+	}
 	if len(resource.Content) == 0 {
-		return nil // 跳过空资源
+		// Content 为 nil 表示未取得响应体，非 nil 的空切片表示响应本身为空
+		if resource.Content == nil {
+			reason := "未取得响应体"
+			if resource.BodyError != "" {
+				reason += ": " + resource.BodyError
+			}
+			slog.Debug("跳过空资源", "url", resource.URL, "reason", reason)
+			return nil
+		}
+		if !st.keepEmpty {
+			slog.Debug("跳过空资源", "url", resource.URL, "reason", "响应体为空")
+			return nil
+		}
 	}
 
 	// 解析URL并生成文件路径
-	filePath, err := st.getFilePath(resource.URL)
+	filePath, err := st.getFilePath(resource.URL, resource.ContentMimeType())
 	if err != nil {
 		return err
 	}
 
+	st.extractDataURIs(resource, filePath)
+
+	countSource := st.skipUnchangedSources && isSourceMapFile(resource)
+	if countSource && sameFileContent(filePath, resource.Content) {
+		st.sourcesUnchanged++
+		return nil
+	}
+
 	// 创建目录
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -64,15 +168,28 @@ func (st *Storage) saveResource(resource *crawler.Resource) error {
 	}
 
 	// 写入文件
-	if err := os.WriteFile(filePath, resource.Content, 0644); err != nil {
+	content := st.stripSourceMappingURL(resource, st.prettyJSONContent(resource))
+	if err := WriteFileAtomic(filePath, content, 0644); err != nil {
 		return fmt.Errorf("failed to write file %s: %v", filePath, err)
 	}
+	if countSource {
+		st.sourcesWritten++
+	}
 
 	return nil
 }
 
-// getFilePath 根据URL生成文件路径
-func (st *Storage) getFilePath(urlStr string) (string, error) {
+// mimeExtensions 无扩展名时按 MIME 类型补全的扩展名，未列出的类型补 .html
+var mimeExtensions = map[string]string{
+	"application/wasm":       ".wasm",
+	"application/javascript": ".js",
+	"text/javascript":        ".js",
+	"application/json":       ".json",
+	"text/css":               ".css",
+}
+
+// getFilePath 根据URL生成文件路径，mimeType 用于为无扩展名的路径补全扩展名
+func (st *Storage) getFilePath(urlStr, mimeType string) (string, error) {
 	parsedURL, err := url.Parse(urlStr)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse URL: %v", err)
@@ -142,12 +259,45 @@ func (st *Storage) getFilePath(urlStr string) (string, error) {
 
 	// 如果文件没有扩展名，尝试根据MIME类型添加
 	if filepath.Ext(fullPath) == "" {
-		fullPath = fullPath + ".html"
+		ext, ok := mimeExtensions[baseMimeType(mimeType)]
+		if !ok {
+			ext = ".html"
+		}
+		fullPath = fullPath + ext
 	}
 
 	return fullPath, nil
 }
 
+// baseMimeType 去掉 MIME 类型的参数部分（如 ; charset=utf-8）并转为小写
+func baseMimeType(mimeType string) string {
+	base, _, _ := strings.Cut(mimeType, ";")
+	return strings.ToLower(strings.TrimSpace(base))
+}
+
+// reportMimeType 报告中使用的资源类型：以 application/octet-stream 等通用类型返回的
+// WebAssembly 模块按文件头单独归为 application/wasm
+func reportMimeType(res *crawler.Resource) string {
+	mimeType := baseMimeType(res.ContentMimeType())
+	if (mimeType == "" || mimeType == "application/octet-stream") && bytes.HasPrefix(res.Content, []byte("\x00asm")) {
+		return "application/wasm"
+	}
+	if mimeType == "" {
+		return "unknown"
+	}
+	return mimeType
+}
+
+// sortedByURL 按 URL 排序资源
+func sortedByURL(resources map[string]*crawler.Resource) []*crawler.Resource {
+	list := make([]*crawler.Resource, 0, len(resources))
+	for _, res := range resources {
+		list = append(list, res)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].URL < list[j].URL })
+	return list
+}
+
 // sanitizeFileName 清理文件名中的非法字符
 func sanitizeFileName(name string) string {
 	// 替换常见的非法字符
@@ -167,6 +317,28 @@ func sanitizeFileName(name string) string {
 	return replacer.Replace(name)
 }
 
+// SaveNetworkEvents 写入 CDP 网络事件日志 network-events.jsonl
+func (st *Storage) SaveNetworkEvents(data []byte) error {
+	if err := os.MkdirAll(st.baseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create base directory: %v", err)
+	}
+	return WriteFileAtomic(filepath.Join(st.baseDir, "network-events.jsonl"), data, 0644)
+}
+
+// SaveScreenshots 按顺序写入滚动截图 screenshot-1.png、screenshot-2.png ...
+func (st *Storage) SaveScreenshots(shots [][]byte) error {
+	if err := os.MkdirAll(st.baseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create base directory: %v", err)
+	}
+	for i, shot := range shots {
+		name := fmt.Sprintf("screenshot-%d.png", i+1)
+		if err := WriteFileAtomic(filepath.Join(st.baseDir, name), shot, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
 // GenerateReport 生成抓取报告
 func (st *Storage) GenerateReport(resources map[string]*crawler.Resource) error {
 	reportPath := filepath.Join(st.baseDir, "report.txt")
@@ -174,34 +346,313 @@ func (st *Storage) GenerateReport(resources map[string]*crawler.Resource) error
 	var report strings.Builder
 	report.WriteString("Spider Crawl Report\n")
 	report.WriteString("==================\n\n")
+	st.writeNavigationSection(&report)
 	report.WriteString(fmt.Sprintf("Total Resources: %d\n\n", len(resources)))
 
 	// 按类型分组统计
 	typeCount := make(map[string]int)
 	for _, res := range resources {
-		mimeType := res.MimeType
-		if mimeType == "" {
-			mimeType = "unknown"
-		}
-		// 简化MIME类型
-		parts := strings.Split(mimeType, ";")
-		mimeType = strings.TrimSpace(parts[0])
-		typeCount[mimeType]++
+		typeCount[reportMimeType(res)]++
 	}
 
 	report.WriteString("Resources by Type:\n")
-	for mimeType, count := range typeCount {
-		report.WriteString(fmt.Sprintf("  %s: %d\n", mimeType, count))
+	for _, mimeType := range sortedKeys(typeCount) {
+		report.WriteString(fmt.Sprintf("  %s: %d\n", mimeType, typeCount[mimeType]))
+	}
+
+	if st.sourcesWritten+st.sourcesUnchanged > 0 {
+		report.WriteString(fmt.Sprintf("\nSource Files: %d written, %d unchanged (skipped)\n", st.sourcesWritten, st.sourcesUnchanged))
+	}
+
+	// 跳过的可选产物放在前面：说明报告和输出目录里为什么缺少截图、渲染 DOM 等
+	if len(st.skipped) > 0 {
+		report.WriteString("\nSkipped Artifacts:\n")
+		for _, sk := range st.skipped {
+			report.WriteString(fmt.Sprintf("  %s: skipped: %s (%s)\n", sk.Artifact, sk.Reason, sk.Detail))
+		}
+	}
+
+	// 自动关闭的对话框：confirm 的回答会影响页面后续请求，按弹出顺序列出
+	if len(st.dialogs) > 0 {
+		report.WriteString("\nJavaScript Dialogs:\n")
+		for _, d := range st.dialogs {
+			answer := "dismissed"
+			if d.Accepted {
+				answer = "accepted"
+			}
+			report.WriteString(fmt.Sprintf("  %s (%s): %q ← %s\n", d.Type, answer, d.Message, d.FrameURL))
+		}
+	}
+
+	// 折叠的轮询响应（CollapsePolling）
+	// 按 URL 排序，保证相同输入生成相同报告
+	sorted := sortedByURL(resources)
+
+	var collapsed []*crawler.Resource
+	for _, res := range sorted {
+		if res.CollapsedCount > 0 {
+			collapsed = append(collapsed, res)
+		}
+	}
+	if len(collapsed) > 0 {
+		report.WriteString("\nCollapsed Polling Responses:\n")
+		for _, res := range collapsed {
+			path := res.URL
+			if u, err := url.Parse(res.URL); err == nil {
+				path = u.Path
+			}
+			report.WriteString(fmt.Sprintf("  collapsed %d polling responses for %s (last seen: %s)\n",
+				res.CollapsedCount, path, res.LastSeen.Format(time.RFC3339)))
+		}
+	}
+
+	// CSP 上报地址（FollowCSPReportURIs），只记录不请求
+	endpointSources := make(map[string][]string)
+	for _, res := range sorted {
+		for _, endpoint := range res.ReportEndpoints {
+			endpointSources[endpoint] = append(endpointSources[endpoint], res.URL)
+		}
+	}
+	if len(endpointSources) > 0 {
+		report.WriteString("\nCSP Report Endpoints (discovered, not fetched):\n")
+		for _, endpoint := range sortedKeys(endpointSources) {
+			report.WriteString(fmt.Sprintf("  %s (from %d resources, e.g. %s)\n",
+				endpoint, len(endpointSources[endpoint]), endpointSources[endpoint][0]))
+		}
+	}
+
+	// 声明类型与内容不符的资源（SniffMime）
+	var mislabeled []*crawler.Resource
+	for _, res := range sorted {
+		if res.DetectedMimeType != "" {
+			mislabeled = append(mislabeled, res)
+		}
+	}
+	if len(mislabeled) > 0 {
+		report.WriteString("\nMIME Type Mismatches (declared → detected):\n")
+		for _, res := range mislabeled {
+			declared := res.MimeType
+			if declared == "" {
+				declared = "(none)"
+			}
+			report.WriteString(fmt.Sprintf("  %s: %s → %s\n", res.URL, declared, res.DetectedMimeType))
+		}
+	}
+
+	// WebAssembly 模块（常以 application/octet-stream 返回）
+	writeWasmSection(&report, sorted)
+
+	// 因状态码未保存的资源（SkipStatusCodes）
+	st.writeSkippedStatusSection(&report, sorted)
+
+	// 第三方 Cookie 与跨站 iframe 存储（CapturePrivacy）
+	if st.privacy != nil {
+		st.writePrivacySection(&report)
+	}
+
+	// JSON 接口的结构摘要和示例响应
+	st.writeJSONAPISection(&report, resources)
+
+	// 声明的 preload / prefetch 等资源提示与实际加载的对照
+	if st.hintsSet {
+		st.writeHintsSection(&report)
+	}
+
+	// DOM / CSS 中引用了但没有加载的资源
+	if st.unloadedRefsSet {
+		st.writeUnloadedSection(&report)
+	}
+
+	// CSP 违规、CORS、混合内容等浏览器问题（CaptureBrowserIssues）
+	if st.browserIssuesSet {
+		st.writeBrowserIssuesSection(&report)
+	}
+
+	// 未使用的 JS / CSS（CaptureCoverage）
+	if st.coverage != nil {
+		st.writeCoverageSection(&report)
+	}
+
+	// 按标记统计（ClickMarkers / Mark），标记前的初始加载不计入
+	markerCount := make(map[string]int)
+	for _, res := range resources {
+		if res.Marker != "" {
+			markerCount[res.Marker]++
+		}
+	}
+	if len(markerCount) > 0 {
+		report.WriteString("\nResources by Marker:\n")
+		for _, marker := range sortedKeys(markerCount) {
+			report.WriteString(fmt.Sprintf("  %s: %d\n", marker, markerCount[marker]))
+		}
+	}
+
+	// 按主机限制跳过的 source map
+	var skippedMaps []*crawler.Resource
+	for _, res := range sorted {
+		if res.SourceMapSkipped != "" {
+			skippedMaps = append(skippedMaps, res)
+		}
+	}
+	if len(skippedMaps) > 0 {
+		report.WriteString("\nSkipped Source Maps (host restrictions):\n")
+		for _, res := range skippedMaps {
+			report.WriteString(fmt.Sprintf("  %s ← %s\n", res.SourceMapSkipped, res.URL))
+		}
+	}
+
+	// 超出提取上限、只提取了部分源文件的 source map
+	var truncatedMaps []*crawler.Resource
+	for _, res := range sorted {
+		if res.SourceMapTruncated != "" {
+			truncatedMaps = append(truncatedMaps, res)
+		}
+	}
+	if len(truncatedMaps) > 0 {
+		report.WriteString("\nTruncated Source Maps (extraction limits):\n")
+		for _, res := range truncatedMaps {
+			report.WriteString(fmt.Sprintf("  %s ← %s\n", res.SourceMapTruncated, res.URL))
+		}
+	}
+
+	// 按页面统计：资源数、字节数、最慢的资源（共用资源计入每个页面）
+	type pageStats struct {
+		count   int
+		bytes   int
+		slowest *crawler.Resource
+	}
+	pages := make(map[string]*pageStats)
+	for _, res := range sorted {
+		for _, page := range res.Pages {
+			ps := pages[page]
+			if ps == nil {
+				ps = &pageStats{}
+				pages[page] = ps
+			}
+			ps.count++
+			ps.bytes += len(res.Content)
+			if ps.slowest == nil || res.Latency > ps.slowest.Latency {
+				ps.slowest = res
+			}
+		}
+	}
+	if len(pages) > 0 {
+		report.WriteString("\nResources by Page:\n")
+		for _, page := range sortedKeys(pages) {
+			ps := pages[page]
+			report.WriteString(fmt.Sprintf("  %s\n", page))
+			report.WriteString(fmt.Sprintf("    Resources: %d, Size: %d bytes\n", ps.count, ps.bytes))
+			if ps.slowest.Latency > 0 {
+				report.WriteString(fmt.Sprintf("    Slowest: %s (%s)\n", ps.slowest.URL, ps.slowest.Latency.Round(time.Millisecond)))
+			}
+		}
+	}
+
+	// 耗时最长的资源（请求发出到取得响应体），定位慢 CDN 或挂起的请求
+	var timed []*crawler.Resource
+	for _, res := range sorted {
+		if res.FetchDuration > 0 {
+			timed = append(timed, res)
+		}
+	}
+	if len(timed) > 0 {
+		sort.SliceStable(timed, func(i, j int) bool { return timed[i].FetchDuration > timed[j].FetchDuration })
+		n := min(len(timed), slowestResourceCount)
+		report.WriteString(fmt.Sprintf("\nSlowest Resources (top %d):\n", n))
+		for _, res := range timed[:n] {
+			report.WriteString(fmt.Sprintf("  %s  %s (%d bytes)\n", res.FetchDuration.Round(time.Millisecond), res.URL, len(res.Content)))
+		}
 	}
 
 	report.WriteString("\n\nDetailed Resource List:\n")
 	report.WriteString("----------------------\n")
-	for _, res := range resources {
+	variants := make(map[string][]*crawler.Resource)
+	if st.groupQueryVariants {
+		for _, res := range sorted {
+			key := withoutQuery(res.URL)
+			variants[key] = append(variants[key], res)
+		}
+	}
+	previewUsed := 0
+	for _, res := range sorted {
+		if group := variants[withoutQuery(res.URL)]; len(group) > 1 {
+			// 整组在首个变体处输出一次
+			if group[0] == res {
+				writeVariantGroup(&report, withoutQuery(res.URL), group)
+			}
+			continue
+		}
 		report.WriteString(fmt.Sprintf("\nURL: %s\n", res.URL))
 		report.WriteString(fmt.Sprintf("  Status: %d\n", res.StatusCode))
-		report.WriteString(fmt.Sprintf("  Type: %s\n", res.MimeType))
+		if res.DetectedMimeType != "" {
+			report.WriteString(fmt.Sprintf("  Type: %s (detected: %s)\n", res.MimeType, res.DetectedMimeType))
+		} else {
+			report.WriteString(fmt.Sprintf("  Type: %s\n", res.MimeType))
+		}
 		report.WriteString(fmt.Sprintf("  Size: %d bytes\n", len(res.Content)))
+		if len(res.Labels) > 0 {
+			pairs := make([]string, 0, len(res.Labels))
+			for _, k := range sortedKeys(res.Labels) {
+				pairs = append(pairs, k+"="+res.Labels[k])
+			}
+			report.WriteString(fmt.Sprintf("  Labels: %s\n", strings.Join(pairs, ", ")))
+		}
+		if res.BodyError != "" {
+			if res.BodyTimedOut {
+				report.WriteString(fmt.Sprintf("  Body Error (timeout): %s\n", res.BodyError))
+			} else {
+				report.WriteString(fmt.Sprintf("  Body Error: %s\n", res.BodyError))
+			}
+		}
+		if t := res.TimingBreakdown; t != nil {
+			report.WriteString(fmt.Sprintf("  Timing: dns %.1fms, connect %.1fms, ttfb %.1fms, transfer %.1fms, total %.1fms\n",
+				t.DNS, t.Connect, t.TTFB, t.Transfer, t.Duration))
+		}
+		if len(res.Pages) > 1 {
+			report.WriteString(fmt.Sprintf("  Pages: %s\n", strings.Join(res.Pages, ", ")))
+		}
+		if res.Context != "" && res.Context != "page" {
+			report.WriteString(fmt.Sprintf("  Context: %s\n", res.Context))
+		}
+		if res.CollapsedCount > 0 {
+			report.WriteString(fmt.Sprintf("  Collapsed: %d (last seen: %s)\n",
+				res.CollapsedCount, res.LastSeen.Format(time.RFC3339)))
+		}
+		if st.previewBytes > 0 {
+			st.writePreview(&report, res, &previewUsed)
+		}
 	}
 
-	return os.WriteFile(reportPath, []byte(report.String()), 0644)
+	return WriteFileAtomic(reportPath, []byte(report.String()), 0644)
+}
+
+// writeVariantGroup 输出一组仅查询字符串不同的资源：变体数、总大小和各变体的状态与大小
+func writeVariantGroup(report *strings.Builder, asset string, group []*crawler.Resource) {
+	var total int
+	for _, res := range group {
+		total += len(res.Content)
+	}
+	report.WriteString(fmt.Sprintf("\nAsset: %s\n", asset))
+	report.WriteString(fmt.Sprintf("  Type: %s\n", group[0].MimeType))
+	report.WriteString(fmt.Sprintf("  Variants: %d\n", len(group)))
+	report.WriteString(fmt.Sprintf("  Total Size: %d bytes\n", total))
+	for _, res := range group {
+		query := ""
+		if u, err := url.Parse(res.URL); err == nil {
+			query = u.RawQuery
+		}
+		report.WriteString(fmt.Sprintf("    ?%s  [%d] %d bytes\n", query, res.StatusCode, len(res.Content)))
+	}
+}
+
+// withoutQuery 去掉 URL 的查询字符串和片段，作为逻辑资源的分组键
+func withoutQuery(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	return u.String()
 }
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"io"
+	"os"
+	"time"
+
+	"spider/internal/crawler"
+	"spider/internal/lru"
+)
+
+// fileHashKey 已有文件的身份：路径 + 大小 + 修改时间，任一变化都视为新文件需重新计算哈希
+type fileHashKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// fileHashes 记住已比较过的磁盘文件的 SHA-256，同一进程内反复抓取同一站点时不必每次重读整个输出目录；
+// 条目数与有效期有上限，长时间运行时内存不会随文件数增长
+var fileHashes = lru.New[fileHashKey, [sha256.Size]byte](4096, 30*time.Minute)
+
+// SetSkipUnchangedSources 设置保存 source map 提取的源文件时跳过与磁盘上同路径文件内容相同的，
+// 只写新出现或有变化的源文件，反复抓取同一站点时便于比较两次部署之间的差异。
+// 写入与跳过的数量列入报告的 Source Files 一节。
+func (st *Storage) SetSkipUnchangedSources(skip bool) {
+	st.skipUnchangedSources = skip
+}
+
+// isSourceMapFile 判断资源是否为 source map 中提取的源文件
+func isSourceMapFile(resource *crawler.Resource) bool {
+	return resource.Headers["X-Source"] == "SourceMap"
+}
+
+// sameFileContent 判断 path 处已有文件的内容是否与 data 相同（先比较大小，再比较 SHA-256）
+func sameFileContent(path string, data []byte) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() != int64(len(data)) {
+		return false
+	}
+	key := fileHashKey{path: path, size: info.Size(), modTime: info.ModTime()}
+	sum, ok := fileHashes.Get(key)
+	if !ok {
+		f, err := os.Open(path)
+		if err != nil {
+			return false
+		}
+		defer f.Close()
+
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return false
+		}
+		sum = [sha256.Size]byte(h.Sum(nil))
+		fileHashes.Add(key, sum)
+	}
+	return sum == sha256.Sum256(data)
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 记住的哈希以 路径 + 大小 + 修改时间 为键，文件被改写后不会误用旧哈希
+func TestSameFileContentMemoInvalidatedOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.ts")
+	if err := os.WriteFile(path, []byte("first"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !sameFileContent(path, []byte("first")) || !sameFileContent(path, []byte("first")) {
+		t.Fatal("内容相同的文件应判为相同")
+	}
+
+	// 大小相同、内容不同：修改时间变化后应重新计算哈希
+	if err := os.WriteFile(path, []byte("secnd"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if sameFileContent(path, []byte("first")) {
+		t.Error("文件改写后仍沿用了旧哈希")
+	}
+	if !sameFileContent(path, []byte("secnd")) {
+		t.Error("改写后的内容应判为相同")
+	}
+}
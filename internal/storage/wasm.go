@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"spider/internal/crawler"
+)
+
+// writeWasmSection 列出抓到的 WebAssembly 模块及大小；以通用类型返回的模块注明声明的类型。
+// sorted 为按 URL 排序的资源，没有模块时不输出
+func writeWasmSection(report *strings.Builder, sorted []*crawler.Resource) {
+	var modules []*crawler.Resource
+	total := 0
+	for _, res := range sorted {
+		if reportMimeType(res) == "application/wasm" {
+			modules = append(modules, res)
+			total += len(res.Content)
+		}
+	}
+	if len(modules) == 0 {
+		return
+	}
+	report.WriteString(fmt.Sprintf("\nWebAssembly Modules (%d, %d bytes):\n", len(modules), total))
+	for _, res := range modules {
+		line := fmt.Sprintf("  %s (%d bytes", res.URL, len(res.Content))
+		if declared := baseMimeType(res.MimeType); declared != "application/wasm" {
+			if declared == "" {
+				declared = "(none)"
+			}
+			line += ", served as " + declared
+		}
+		report.WriteString(line + ")\n")
+	}
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"spider/internal/crawler"
+)
+
+// wprArchive Web Page Replay（web_page_replay_go）的归档结构：gzip 压缩的 JSON，
+// 请求按 host → URL 分组，请求和响应均为 HTTP/1.1 报文（[]byte 在 JSON 中为 base64）
+type wprArchive struct {
+	Requests                map[string]map[string][]*wprRequest
+	Certs                   map[string][]byte
+	NegotiatedProtocol      map[string]string
+	DeterministicTimeSeedMs int64
+}
+
+type wprRequest struct {
+	SerializedRequest   []byte
+	SerializedResponse  []byte
+	LastServedSessionId uint32
+}
+
+// wprSkipHeaders 不写入归档响应的头：Content 已是解码后的完整内容，编码和长度按实际 body 重新生成
+var wprSkipHeaders = []string{"Content-Encoding", "Content-Length", "Transfer-Encoding"}
+
+// ExportWPR 将资源导出为 Web Page Replay 归档（wpr replay 使用的 archive.wprgo），
+// 可通过 wpr replay 离线重放抓到的流量做性能测量，无需重新爬取。
+// 只包含真实的 http(s) 响应：source map 提取出的源文件、内联脚本等合成资源没有对应的请求，跳过。
+// body 不截断；响应头去掉 Content-Encoding 等，按解码后的内容重写 Content-Length
+func ExportWPR(resources map[string]*crawler.Resource, dest io.Writer) error {
+	archive := &wprArchive{
+		Requests:           make(map[string]map[string][]*wprRequest),
+		Certs:              map[string][]byte{},
+		NegotiatedProtocol: map[string]string{},
+	}
+	for _, res := range sortedResources(resources) {
+		if res.Headers["X-Source"] != "" {
+			continue
+		}
+		u, err := url.Parse(res.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		entry, err := wprEntry(res, u)
+		if err != nil {
+			return fmt.Errorf("%s: %w", res.URL, err)
+		}
+		if archive.Requests[u.Host] == nil {
+			archive.Requests[u.Host] = make(map[string][]*wprRequest)
+		}
+		archive.Requests[u.Host][res.URL] = append(archive.Requests[u.Host][res.URL], entry)
+
+		// 回放时 Date.now() 从最早的响应时间开始，与抓取时一致
+		if ms := res.ResponseTime.UnixMilli(); !res.ResponseTime.IsZero() && (archive.DeterministicTimeSeedMs == 0 || ms < archive.DeterministicTimeSeedMs) {
+			archive.DeterministicTimeSeedMs = ms
+		}
+	}
+
+	gz := gzip.NewWriter(dest)
+	if err := json.NewEncoder(gz).Encode(archive); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// wprEntry 生成一条请求 / 响应记录
+func wprEntry(res *crawler.Resource, u *url.URL) (*wprRequest, error) {
+	method := res.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(res.RequestBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = wprHeader(res.RequestHeaders, "Host")
+	if _, ok := req.Header["User-Agent"]; !ok {
+		// 空值让 Request.Write 不补 Go 默认的 User-Agent
+		req.Header["User-Agent"] = []string{""}
+	}
+	var rawReq bytes.Buffer
+	if err := req.Write(&rawReq); err != nil {
+		return nil, err
+	}
+
+	statusText := res.StatusText
+	if statusText == "" {
+		// HTTP/2 没有 reason phrase
+		statusText = http.StatusText(res.StatusCode)
+	}
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", res.StatusCode, statusText),
+		StatusCode:    res.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        wprHeader(res.Headers, wprSkipHeaders...),
+		Body:          io.NopCloser(bytes.NewReader(res.Content)),
+		ContentLength: int64(len(res.Content)),
+		Request:       req,
+	}
+	var rawResp bytes.Buffer
+	if err := resp.Write(&rawResp); err != nil {
+		return nil, err
+	}
+	return &wprRequest{SerializedRequest: rawReq.Bytes(), SerializedResponse: rawResp.Bytes()}, nil
+}
+
+// wprHeader 转换为 http.Header，跳过 HTTP/2 伪首部和 skip 中的头；CDP 以换行拼接的多值头拆回多个值
+func wprHeader(headers map[string]string, skip ...string) http.Header {
+	h := make(http.Header, len(headers))
+	for _, name := range sortedKeys(headers) {
+		if strings.HasPrefix(name, ":") || containsFold(skip, name) {
+			continue
+		}
+		for value := range strings.SplitSeq(headers[name], "\n") {
+			h.Add(name, value)
+		}
+	}
+	return h
+}
+
+// containsFold list 中是否有与 s 忽略大小写相同的项
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
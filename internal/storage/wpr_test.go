@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"spider/internal/crawler"
+)
+
+// 归档可以 gunzip + JSON 解码，每条记录的请求 / 响应是可被 net/http 解析的 HTTP/1.1 报文：
+// 响应去掉 Content-Encoding，Content-Length 按解码后的 body 重写；合成资源和非 http(s) 地址跳过
+func TestExportWPR(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	resources := map[string]*crawler.Resource{}
+	for _, res := range []*crawler.Resource{
+		{
+			URL: "https://example.com/app.js", Method: "GET", StatusCode: 200, StatusText: "OK",
+			MimeType: "application/javascript", Content: []byte("console.log('hello')"),
+			Headers: map[string]string{
+				"Content-Type": "application/javascript", "Content-Encoding": "br",
+				"Content-Length": "17", "Set-Cookie": "a=1\nb=2",
+			},
+			RequestHeaders: map[string]string{"Accept": "*/*", "Host": "example.com", "User-Agent": "spider-test"},
+			ResponseTime:   at.Add(time.Second),
+		},
+		{
+			URL: "https://example.com:8443/api?q=1", Method: "POST", StatusCode: 201, Protocol: "h2",
+			MimeType: "application/json", Content: []byte(`{"ok":true}`),
+			Headers:        map[string]string{":status": "201", "content-type": "application/json"},
+			RequestHeaders: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			RequestBody:    []byte("q=1"),
+			ResponseTime:   at,
+		},
+		// 合成资源与非 http(s) 地址不写入归档
+		{URL: "https://example.com/src/app.ts", StatusCode: 200, Content: []byte("let a = 1"),
+			Headers: map[string]string{"X-Source": "SourceMap"}, ResponseTime: at.Add(2 * time.Second)},
+		{URL: "https://example.com/page.html#inline-script-0", StatusCode: 200, Content: []byte("1"),
+			Headers: map[string]string{"X-Source": "InlineScript"}, ResponseTime: at.Add(3 * time.Second)},
+		{URL: "data:text/plain,hi", StatusCode: 200, Content: []byte("hi"), ResponseTime: at.Add(4 * time.Second)},
+	} {
+		resources[res.URL] = res
+	}
+
+	var buf bytes.Buffer
+	if err := ExportWPR(resources, &buf); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("归档不是 gzip: %v", err)
+	}
+	var archive wprArchive
+	if err := json.NewDecoder(gz).Decode(&archive); err != nil {
+		t.Fatalf("归档 JSON 解码失败: %v", err)
+	}
+
+	var hosts []string
+	for host, byURL := range archive.Requests {
+		hosts = append(hosts, host)
+		for u := range byURL {
+			if _, ok := resources[u]; !ok {
+				t.Errorf("归档中有未知地址 %s", u)
+			}
+		}
+	}
+	slices.Sort(hosts)
+	if want := []string{"example.com", "example.com:8443"}; !slices.Equal(hosts, want) {
+		t.Fatalf("归档的主机为 %v，应为 %v（合成资源和 data: 地址应跳过）", hosts, want)
+	}
+	if len(archive.Requests["example.com"]) != 1 {
+		t.Errorf("example.com 下有 %d 个地址，X-Source 资源应跳过", len(archive.Requests["example.com"]))
+	}
+	if archive.DeterministicTimeSeedMs != at.UnixMilli() {
+		t.Errorf("DeterministicTimeSeedMs = %d，应为最早的响应时间 %d", archive.DeterministicTimeSeedMs, at.UnixMilli())
+	}
+
+	parse := func(t *testing.T, host, rawURL string) (*http.Request, *http.Response, []byte, []byte) {
+		t.Helper()
+		entries := archive.Requests[host][rawURL]
+		if len(entries) != 1 {
+			t.Fatalf("%s 有 %d 条记录", rawURL, len(entries))
+		}
+		req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(entries[0].SerializedRequest)))
+		if err != nil {
+			t.Fatalf("SerializedRequest 无法解析: %v\n%s", err, entries[0].SerializedRequest)
+		}
+		reqBody, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(entries[0].SerializedResponse)), req)
+		if err != nil {
+			t.Fatalf("SerializedResponse 无法解析: %v\n%s", err, entries[0].SerializedResponse)
+		}
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req, resp, reqBody, respBody
+	}
+
+	t.Run("GET", func(t *testing.T) {
+		req, resp, _, body := parse(t, "example.com", "https://example.com/app.js")
+		if req.Method != "GET" || req.Host != "example.com" || req.URL.RequestURI() != "/app.js" {
+			t.Errorf("请求为 %s %s%s", req.Method, req.Host, req.URL.RequestURI())
+		}
+		if req.Header.Get("Accept") != "*/*" || req.UserAgent() != "spider-test" {
+			t.Errorf("请求头为 %v", req.Header)
+		}
+		if resp.StatusCode != 200 || resp.Status != "200 OK" || resp.Proto != "HTTP/1.1" {
+			t.Errorf("状态行为 %s %s", resp.Proto, resp.Status)
+		}
+		if string(body) != "console.log('hello')" {
+			t.Errorf("响应体为 %q", body)
+		}
+		if got := resp.Header.Get("Content-Encoding"); got != "" {
+			t.Errorf("Content-Encoding 应去掉，实际 %q", got)
+		}
+		if resp.ContentLength != int64(len(body)) || resp.Header.Get("Content-Length") != "20" {
+			t.Errorf("Content-Length 为 %q（%d），应为解码后的 20", resp.Header.Get("Content-Length"), resp.ContentLength)
+		}
+		if got := resp.Header.Values("Set-Cookie"); !slices.Equal(got, []string{"a=1", "b=2"}) {
+			t.Errorf("多值头应拆回多行，实际 %q", got)
+		}
+		if resp.Header.Get("Content-Type") != "application/javascript" {
+			t.Errorf("Content-Type 为 %q", resp.Header.Get("Content-Type"))
+		}
+	})
+
+	t.Run("POST h2", func(t *testing.T) {
+		req, resp, reqBody, body := parse(t, "example.com:8443", "https://example.com:8443/api?q=1")
+		if req.Method != "POST" || req.Host != "example.com:8443" || req.URL.RequestURI() != "/api?q=1" {
+			t.Errorf("请求为 %s %s%s", req.Method, req.Host, req.URL.RequestURI())
+		}
+		if string(reqBody) != "q=1" || req.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+			t.Errorf("请求体为 %q（%v）", reqBody, req.Header)
+		}
+		if _, ok := req.Header["User-Agent"]; ok && req.UserAgent() != "" {
+			t.Errorf("不应补 Go 默认的 User-Agent，实际 %q", req.UserAgent())
+		}
+		// HTTP/2 没有 reason phrase，按状态码补全；伪首部不写入
+		if resp.Status != "201 Created" {
+			t.Errorf("状态为 %q", resp.Status)
+		}
+		for name := range resp.Header {
+			if name[0] == ':' {
+				t.Errorf("响应头中有伪首部 %s", name)
+			}
+		}
+		if string(body) != `{"ok":true}` || resp.ContentLength != int64(len(body)) {
+			t.Errorf("响应体为 %q，Content-Length %d", body, resp.ContentLength)
+		}
+	})
+}
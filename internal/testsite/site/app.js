@@ -0,0 +1,14 @@
+// 调用需要 Cookie 的 XHR API，结果渲染到页面
+function loadItems() {
+  var xhr = new XMLHttpRequest();
+  xhr.open("GET", "/api/items");
+  xhr.onload = function () {
+    var el = document.getElementById("items");
+    if (el) {
+      el.textContent = xhr.responseText;
+    }
+  };
+  xhr.send();
+}
+document.addEventListener("DOMContentLoaded", loadItems);
+//# sourceMappingURL=app.js.map
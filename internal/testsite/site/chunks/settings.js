@@ -0,0 +1,4 @@
+// Route chunk for the settings page, only referenced by a prefetch hint.
+export function renderSettings(root) {
+  root.textContent = "Settings";
+}
@@ -0,0 +1 @@
+window.frameLoaded = true;
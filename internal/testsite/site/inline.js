@@ -0,0 +1,2 @@
+window.inlineLoaded = true;
+//# sourceMappingURL=data:application/json;base64,eyJ2ZXJzaW9uIjogMywgImZpbGUiOiAiaW5saW5lLmpzIiwgInNvdXJjZXMiOiBbInNyYy9pbmxpbmUudHMiXSwgInNvdXJjZXNDb250ZW50IjogWyIvLyBzcmMvaW5saW5lLnRzXG5leHBvcnQgY29uc3QgaW5saW5lID0gdHJ1ZTtcbiJdLCAibmFtZXMiOiBbXSwgIm1hcHBpbmdzIjogIkFBQUEifQ==
@@ -0,0 +1 @@
+window.lazyLoaded = true;
@@ -0,0 +1,3 @@
+// source map 指向不存在的文件，提取时应记录警告而不是失败
+window.missingLoaded = true;
+//# sourceMappingURL=missing.js.map
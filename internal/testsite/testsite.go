@@ -0,0 +1,400 @@
+// Package testsite 提供内嵌的多页面测试站点，供集成测试在不访问外网的情况下驱动完整爬取。
+//
+// 站点覆盖的场景：
+//   - /index.html  滚动后才加载的懒加载图片和脚本（/img/lazy.svg、/lazy.js）、iframe（/frame.html → /frame.js）
+//   - /app.js      外部 source map（/app.js.map → src/app.ts，以及百分号编码的 src/components/Item%20List.ts）
+//   - /inline.js   data URI 内联 source map（src/inline.ts）
+//   - /missing.js  指向不存在的 source map（/missing.js.map 返回 404）
+//   - /vendor/bundle 无扩展名、以 text/plain 返回的脚本，外部 source map（/vendor/bundle.map → src/vendor.ts）
+//   - /style.css   CSS 中引用的背景图（/img/header.svg）
+//   - /redirect    302 跳转到 /page2.html
+//   - /api/items   XHR 接口，返回是否带上了首页设置的 Cookie
+//   - /docs/0 … /docs/19 互相链接的 20 个文档页（递归爬取的并行测试），DocsPages 为页数
+//   - /huge.html   生成的超大 DOM（默认 50 MB，?mb=N 调整），用于 MaxDOMBytes 相关的测试
+//   - /api/report  只有点击首页的 #open-report 按钮才会请求的 XHR（标记 / 点击相关的测试）
+//   - /hints.html  只通过 <link rel="prefetch"> 引用的路由 chunk /chunks/settings.js（CapturePrefetchAndPreload）
+//   - /dialogs.html 加载时依次弹出 alert 和 confirm，confirm 的回答决定请求 /api/items?confirmed=1 还是 =0
+//   - /base.html   <base href> 指向另一站点（127.0.0.1 ↔ localhost 互换）的 /docs/：其中的图片、链接和
+//     内联脚本的 sourceMappingURL 都应按它解析（/img/header.svg、/docs/3、/page2.html、/bootstrap.js.map）
+//   - /bounce.html 加载后立即 location.replace 到另一站点（127.0.0.1 ↔ localhost 互换）的 /page2.html
+//   - /bootstrap.html 内联 <script> 末尾引用外部 source map（/bootstrap.js.map → src/bootstrap.ts），
+//     只有解析 HTML 中的内联脚本才能发现（sourcemap.WithHTMLInlineMaps）
+//   - /privacy.html 设置第一方 Cookie，并从另一站点（127.0.0.1 ↔ localhost 互换）嵌入
+//     /tracker/frame.html 和 /tracker/pixel.gif，二者设置第三方 Cookie，iframe 写入 localStorage
+//   - /csp.html    以 Content-Security-Policy 头只允许同源资源，却引用另一站点（127.0.0.1 ↔ localhost 互换）的
+//     /img/header.svg 并带有内联脚本，两者都被浏览器拦截，产生 CSP 违规（CaptureBrowserIssues）
+//   - /late-body.html fetch /api/late-body：分块传输，响应头和前半部分立即发出，LateBodyDelay 之后才发出
+//     其余部分，responseReceived 早于 loadingFinished，过早获取响应体会得到截断的内容
+//   - /ua.html     把 navigator.userAgent 和 navigator.userAgentData（含高熵值）POST 到 /api/ua，
+//     接口连同请求的 User-Agent 和 Sec-CH-UA 系列头一起返回（RealUserAgent）
+//   - /clock.html  以 Date.now() 和 Math.random() 拼出 /api/clock 的查询参数并带有 CSS 动画，
+//     不冻结时钟时每次爬取得到的 URL 都不同（Config.Deterministic）
+//   - /wasm.html   胶水脚本 fetch 并实例化两个 WebAssembly 模块：/wasm/module.wasm（application/wasm，
+//     sourceMappingURL 自定义段指向 module.wasm.map → src/module.rs）和 /wasm/probe.wasm
+//     （以 application/octet-stream 返回、没有自定义段，只有探测 probe.wasm.map 才能发现 src/probe.rs）
+//   - /flaky.html?key=K&fail=N 同一 key 的前 N 次请求返回 503（模拟部署中的服务），之后返回 200 的页面（RetryOnStatusCodes）
+package testsite
+
+import (
+	"bufio"
+	"embed"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed site
+var siteFS embed.FS
+
+// CookieName 首页响应设置的 Cookie 名，/api/items 据此判断请求是否带上了会话
+const CookieName = "testsite_session"
+
+// 隐私页面的第三方源设置的 Cookie 名和 iframe 写入的 localStorage 键
+const (
+	TrackerCookieName = "testsite_tracker"
+	TrackerStorageKey = "tracker_id"
+)
+
+// trackerFrame 第三方 iframe：写入 localStorage（Cookie 由响应头设置）
+const trackerFrame = `<!DOCTYPE html>
+<html><body><script>localStorage.setItem("` + TrackerStorageKey + `", "42");</script></body></html>
+`
+
+// ReportButton 首页上点击后请求 ReportPath 的按钮
+const (
+	ReportButton = "#open-report"
+	ReportPath   = "/api/report"
+)
+
+// Paths 完整爬取首页后应当抓到的资源路径（不含 source map 提取出的源文件和点击才加载的 ReportPath）
+var Paths = []string{
+	"/",
+	"/style.css",
+	"/app.js",
+	"/inline.js",
+	"/missing.js",
+	"/vendor/bundle",
+	"/frame.html",
+	"/frame.js",
+	"/api/items",
+	"/img/header.svg",
+	"/img/lazy.svg",
+	"/lazy.js",
+}
+
+// SourcePaths source map 提取出的源文件路径：外部 map 两个（其中一个的 sources 经过百分号编码，保存时应已解码）、
+// 内联 map 和类型标错的 bundle 各一个，missing.js 没有
+var SourcePaths = []string{
+	"/src/app.ts",
+	"/src/components/Item List.ts",
+	"/src/inline.ts",
+	"/src/vendor.ts",
+}
+
+// Site 运行中的测试站点
+type Site struct {
+	URL    string // 站点根地址，如 http://127.0.0.1:34567
+	server *httptest.Server
+}
+
+// New 启动测试站点，调用方负责 Close
+func New() *Site {
+	server := httptest.NewServer(Handler())
+	return &Site{URL: server.URL, server: server}
+}
+
+// Close 关闭站点
+func (s *Site) Close() {
+	s.server.Close()
+}
+
+// Handler 测试站点的 HTTP 处理器，可挂到自定义的 httptest.Server 上（如 TLS）
+func Handler() http.Handler {
+	static, err := fs.Sub(siteFS, "site")
+	if err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	files := http.FileServerFS(static)
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: CookieName, Value: "1", Path: "/"})
+		http.ServeFileFS(w, r, static, "index.html")
+	})
+	mux.HandleFunc("/vendor/{file}", func(w http.ResponseWriter, r *http.Request) {
+		// 模拟把脚本和 source map 都标成 text/plain 的服务端
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		http.ServeFileFS(w, r, static, "vendor/"+r.PathValue("file"))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page2.html", http.StatusFound)
+	})
+	mux.HandleFunc("/api/items", func(w http.ResponseWriter, r *http.Request) {
+		_, err := r.Cookie(CookieName)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"items":         []string{"alpha", "beta"},
+			"authenticated": err == nil,
+		})
+	})
+	mux.HandleFunc("/docs/{n}", func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(r.PathValue("n"))
+		if err != nil || n < 0 || n >= DocsPages {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<!DOCTYPE html>\n<html><head><title>Doc %d</title><link rel=\"stylesheet\" href=\"/style.css\"></head><body>\n<h1>Doc %d</h1>\n", n, n)
+		for i := range DocsPages {
+			fmt.Fprintf(w, "<a href=\"/docs/%d\">Doc %d</a>\n", i, i)
+		}
+		fmt.Fprint(w, "</body></html>\n")
+	})
+	mux.HandleFunc("/huge.html", func(w http.ResponseWriter, r *http.Request) {
+		mb, err := strconv.Atoi(r.URL.Query().Get("mb"))
+		if err != nil || mb <= 0 {
+			mb = HugeDOMMegabytes
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		writeHugeDOM(w, mb<<20)
+	})
+	mux.HandleFunc(ReportPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"report": "quarterly", "rows": 3})
+	})
+	mux.HandleFunc("/privacy.html", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: CookieName, Value: "1", Path: "/", SameSite: http.SameSiteLaxMode})
+		other := "http://" + crossSiteHost(r.Host)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head><title>Privacy</title></head><body>
+<img src="%[1]s/tracker/pixel.gif" alt="">
+<iframe src="%[1]s/tracker/frame.html"></iframe>
+</body></html>
+`, other)
+	})
+	mux.HandleFunc("/csp.html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src 'self'")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head><title>CSP</title><link rel="stylesheet" href="/style.css"></head><body>
+<img src="http://%s/img/header.svg" alt="">
+<script>document.title = "inline script ran";</script>
+</body></html>
+`, crossSiteHost(r.Host))
+	})
+	mux.HandleFunc("/base.html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head><title>Base</title><base href="http://%s/docs/"></head><body>
+<img src="../img/header.svg" alt="">
+<a href="3">Doc 3</a>
+<a href="/page2.html">Page 2</a>
+<script>document.title = "base";
+//# sourceMappingURL=../bootstrap.js.map
+</script>
+</body></html>
+`, crossSiteHost(r.Host))
+	})
+	mux.HandleFunc("/bounce.html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head><title>Bounce</title><link rel="stylesheet" href="/style.css"></head><body>
+<script>location.replace("http://%s/page2.html");</script>
+</body></html>
+`, crossSiteHost(r.Host))
+	})
+	mux.HandleFunc("/tracker/{file}", func(w http.ResponseWriter, r *http.Request) {
+		// 跨站子资源只有 SameSite=None 的 Cookie 会被接受；localhost 视为安全上下文，可设 Secure
+		http.SetCookie(w, &http.Cookie{Name: TrackerCookieName, Value: "42", Path: "/", MaxAge: 30 * 24 * 3600,
+			Secure: true, SameSite: http.SameSiteNoneMode})
+		switch r.PathValue("file") {
+		case "frame.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, trackerFrame)
+		case "pixel.gif":
+			w.Header().Set("Content-Type", "image/gif")
+			w.Write(pixelGIF)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	mux.HandleFunc(UAPath, func(w http.ResponseWriter, r *http.Request) {
+		var page json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&page); err != nil {
+			page = json.RawMessage("null")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(UAEcho{
+			UserAgent:       r.UserAgent(),
+			SecCHUA:         r.Header.Get("Sec-CH-UA"),
+			SecCHUAMobile:   r.Header.Get("Sec-CH-UA-Mobile"),
+			SecCHUAPlatform: r.Header.Get("Sec-CH-UA-Platform"),
+			Page:            page,
+		})
+	})
+	mux.HandleFunc(ClockPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"t":     r.URL.Query().Get("t"),
+			"nonce": r.URL.Query().Get("nonce"),
+		})
+	})
+	mux.HandleFunc(LateBodyPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		half := len(LateBody) / 2
+		io.WriteString(w, LateBody[:half])
+		w.(http.Flusher).Flush()
+		select {
+		case <-time.After(LateBodyDelay):
+		case <-r.Context().Done():
+			return
+		}
+		io.WriteString(w, LateBody[half:])
+	})
+	mux.HandleFunc("/wasm/{file}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("file") {
+		case "module.wasm":
+			w.Header().Set("Content-Type", "application/wasm")
+			w.Write(wasmModule("module.wasm.map"))
+		case "probe.wasm":
+			// 模拟没有为 .wasm 配置类型的服务端
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(wasmModule(""))
+		default:
+			http.ServeFileFS(w, r, static, "wasm/"+r.PathValue("file"))
+		}
+	})
+	var flakyMu sync.Mutex
+	flakyHits := make(map[string]int)
+	mux.HandleFunc(FlakyPath, func(w http.ResponseWriter, r *http.Request) {
+		fail, _ := strconv.Atoi(r.URL.Query().Get("fail"))
+		flakyMu.Lock()
+		flakyHits[r.URL.Query().Get("key")]++
+		hit := flakyHits[r.URL.Query().Get("key")]
+		flakyMu.Unlock()
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if hit <= fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, "<!DOCTYPE html><html><body>"+FlakyUnavailable+"</body></html>\n")
+			return
+		}
+		fmt.Fprintf(w, "<!DOCTYPE html><html><head><title>Flaky</title></head><body>%s (%d)</body></html>\n", FlakyReady, hit)
+	})
+	mux.Handle("/", files)
+	return mux
+}
+
+// pixelGIF 1x1 透明 GIF
+var pixelGIF = []byte("GIF89a\x01\x00\x01\x00\x80\x00\x00\x00\x00\x00\x00\x00\x00!\xf9\x04\x01\x00\x00\x00\x00,\x00\x00\x00\x00\x01\x00\x01\x00\x00\x02\x02D\x01\x00;")
+
+// WasmSourcePaths /wasm.html 的两个模块的 source map 提取出的源文件路径，probe 的需要开启探测
+var WasmSourcePaths = []string{
+	"/wasm/src/module.rs",
+	"/wasm/src/probe.rs",
+}
+
+// wasmModule 生成一个空的 WebAssembly 模块（文件头 + 版本 1），mapURL 非空时附带 sourceMappingURL 自定义段：
+// id 0、LEB128 段长度，段内为 LEB128 长度的段名和 LEB128 长度的 URL
+func wasmModule(mapURL string) []byte {
+	module := []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}
+	if mapURL == "" {
+		return module
+	}
+	var section []byte
+	for _, s := range []string{"sourceMappingURL", mapURL} {
+		section = binary.AppendUvarint(section, uint64(len(s)))
+		section = append(section, s...)
+	}
+	module = append(module, 0x00)
+	module = binary.AppendUvarint(module, uint64(len(section)))
+	return append(module, section...)
+}
+
+// UAPath /ua.html 上报页面所见 UA 的接口，响应为 UAEcho
+const UAPath = "/api/ua"
+
+// UAEcho /api/ua 的响应：请求头中的 UA 和 Client Hints，以及页面中读到的值（原样返回）
+type UAEcho struct {
+	UserAgent       string          `json:"user_agent"`
+	SecCHUA         string          `json:"sec_ch_ua"`
+	SecCHUAMobile   string          `json:"sec_ch_ua_mobile"`
+	SecCHUAPlatform string          `json:"sec_ch_ua_platform"`
+	Page            json.RawMessage `json:"page"` // navigator.userAgent / userAgentData
+}
+
+// ClockPath /clock.html 请求的接口，查询参数 t 为页面中的 Date.now()，nonce 来自 Math.random()
+const ClockPath = "/api/clock"
+
+// /late-body.html 请求的分块响应：前半部分立即发出，LateBodyDelay 之后发出后半部分
+const (
+	LateBodyPath  = "/api/late-body"
+	LateBodyDelay = 1500 * time.Millisecond
+)
+
+// LateBody LateBodyPath 的完整响应体
+var LateBody = strings.Repeat("first half of the streamed body\n", 64) + strings.Repeat("second half, sent late\n", 64)
+
+// /flaky.html 的路径与两种响应中的文字：前 fail 次为 FlakyUnavailable（503），之后为 FlakyReady（200）
+const (
+	FlakyPath        = "/flaky.html"
+	FlakyUnavailable = "deploying"
+	FlakyReady       = "ready"
+)
+
+// DocsPages /docs/ 下互相链接的文档页数，从 /docs/0 出发 -depth 1 即可全部发现
+const DocsPages = 20
+
+// HugeDOMMegabytes /huge.html 默认生成的 DOM 大小（MB）
+const HugeDOMMegabytes = 50
+
+// writeHugeDOM 流式写出约 size 字节的评论串页面，模拟无限加载的长讨论
+func writeHugeDOM(w io.Writer, size int) {
+	const row = `<div class="comment"><a href="/user/%d">user%d</a><p>Lorem ipsum dolor sit amet, consectetur adipiscing elit.</p></div>` + "\n"
+	bw := bufio.NewWriterSize(w, 64<<10)
+	fmt.Fprint(bw, "<!DOCTYPE html>\n<html><head><title>Huge DOM</title></head><body>\n")
+	for i, written := 0, 0; written < size; i++ {
+		n, _ := fmt.Fprintf(bw, row, i, i)
+		written += n
+	}
+	fmt.Fprint(bw, "</body></html>\n")
+	bw.Flush()
+}
+
+// crossSiteHost 把 127.0.0.1 与 localhost 互换（端口不变），得到同一服务器的另一个站点
+func crossSiteHost(host string) string {
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return host
+	}
+	if hostname == "localhost" {
+		return net.JoinHostPort("127.0.0.1", port)
+	}
+	return net.JoinHostPort("localhost", port)
+}
+
+// Resolve 返回站点内 path 的完整地址
+func (s *Site) Resolve(path string) string {
+	return s.URL + path
+}
+
+// CrossSite 返回另一站点（127.0.0.1 ↔ localhost 互换，同一服务器）上 path 的完整地址
+func (s *Site) CrossSite(path string) string {
+	return "http://" + crossSiteHost(s.server.Listener.Addr().String()) + path
+}
+
+// ThirdPartyHost 隐私页面嵌入的第三方站点主机名（不含端口），即 privacy.json 中第三方 Cookie 的域
+func (s *Site) ThirdPartyHost() string {
+	hostname, _, _ := net.SplitHostPort(crossSiteHost(s.server.Listener.Addr().String()))
+	return hostname
+}
@@ -0,0 +1,149 @@
+package testsite
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// /huge.html 默认生成 HugeDOMMegabytes MB 的完整 HTML，?mb=N 可调整大小
+func TestHugeDOM(t *testing.T) {
+	site := New()
+	defer site.Close()
+
+	size := func(query string) int {
+		resp, err := http.Get(site.Resolve("/huge.html" + query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(body, []byte("<!DOCTYPE html>")) || !bytes.HasSuffix(body, []byte("</body></html>\n")) {
+			t.Errorf("%s 不是完整的 HTML 文档", query)
+		}
+		return len(body)
+	}
+
+	if n := size(""); n < HugeDOMMegabytes<<20 || n > HugeDOMMegabytes<<20+1024 {
+		t.Errorf("默认大小为 %d 字节，应约为 %d MB", n, HugeDOMMegabytes)
+	}
+	if n := size("?mb=2"); n < 2<<20 || n > 2<<20+1024 {
+		t.Errorf("?mb=2 的大小为 %d 字节", n)
+	}
+}
+
+// 隐私页面从另一站点嵌入第三方资源
+func TestCrossSiteHost(t *testing.T) {
+	for host, want := range map[string]string{
+		"127.0.0.1:8080": "localhost:8080",
+		"localhost:8080": "127.0.0.1:8080",
+		"example.com":    "example.com",
+	} {
+		if got := crossSiteHost(host); got != want {
+			t.Errorf("crossSiteHost(%q) = %q，应为 %q", host, got, want)
+		}
+	}
+	site := New()
+	defer site.Close()
+	if host := site.ThirdPartyHost(); host == "" || strings.Contains(site.URL, "//"+host+":") {
+		t.Errorf("ThirdPartyHost() = %q，应与站点主机 %s 不同", host, site.URL)
+	}
+}
+
+// /base.html 的 <base href> 指向另一站点的 /docs/
+func TestBasePage(t *testing.T) {
+	site := New()
+	defer site.Close()
+
+	if other := site.CrossSite("/"); other == site.Resolve("/") || !strings.HasPrefix(other, "http://") {
+		t.Errorf("CrossSite(\"/\") = %q，应与 %q 不同源", other, site.Resolve("/"))
+	}
+	resp, err := http.Get(site.Resolve("/base.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<base href="` + site.CrossSite("/docs/") + `">`; !strings.Contains(string(body), want) {
+		t.Errorf("/base.html 中没有 %s:\n%s", want, body)
+	}
+	// <base href> 下的资源在另一站点上可以访问
+	for _, p := range []string{"/img/header.svg", "/docs/3", "/bootstrap.js.map"} {
+		resp, err := http.Get(site.CrossSite(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s 返回 %d", site.CrossSite(p), resp.StatusCode)
+		}
+	}
+}
+
+// /api/ua 回显请求头中的 UA 和 Client Hints，以及请求体中页面上报的值
+func TestUAEcho(t *testing.T) {
+	site := New()
+	defer site.Close()
+
+	req, err := http.NewRequest("POST", site.Resolve(UAPath), strings.NewReader(`{"userAgent":"page-ua"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", "header-ua")
+	req.Header.Set("Sec-CH-UA", `"Google Chrome";v="126"`)
+	req.Header.Set("Sec-CH-UA-Platform", `"Linux"`)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var echo UAEcho
+	if err := json.NewDecoder(resp.Body).Decode(&echo); err != nil {
+		t.Fatal(err)
+	}
+	if echo.UserAgent != "header-ua" || echo.SecCHUA != `"Google Chrome";v="126"` || echo.SecCHUAPlatform != `"Linux"` || string(echo.Page) != `{"userAgent":"page-ua"}` {
+		t.Errorf("回显为 %+v（page %s）", echo, echo.Page)
+	}
+}
+
+// 同一 key 的前 fail 次请求返回 503，之后返回 200；不同 key 分别计数
+func TestFlaky(t *testing.T) {
+	site := New()
+	defer site.Close()
+
+	get := func(query string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(site.Resolve(FlakyPath + "?" + query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(body)
+	}
+	for i, want := range []int{503, 503, 200, 200} {
+		status, body := get("key=a&fail=2")
+		if status != want {
+			t.Errorf("第 %d 次请求返回 %d，应为 %d", i+1, status, want)
+		}
+		if text := map[int]string{503: FlakyUnavailable, 200: FlakyReady}[want]; !strings.Contains(body, text) {
+			t.Errorf("第 %d 次请求的页面中没有 %q: %s", i+1, text, body)
+		}
+	}
+	if status, _ := get("key=b&fail=1"); status != 503 {
+		t.Errorf("新 key 的第一次请求返回 %d，应为 503", status)
+	}
+}